@@ -48,10 +48,13 @@ func (d *Document) ExtendObjectTypeDefinitionByObjectTypeExtension(objectTypeDef
 		d.ObjectTypeDefinitions[objectTypeDefinitionRef].HasDirectives = true
 	}
 
-	if len(d.ObjectTypeExtensions[objectTypeExtensionRef].ImplementsInterfaces.Refs) > 0 {
+	// the implemented interfaces are the union of both, an interface implemented by both is kept once
+	for _, ref := range d.ObjectTypeExtensions[objectTypeExtensionRef].ImplementsInterfaces.Refs {
+		if d.ObjectTypeDefinitionImplementsInterface(objectTypeDefinitionRef, d.ResolveTypeNameBytes(ref)) {
+			continue
+		}
 		d.ObjectTypeDefinitions[objectTypeDefinitionRef].ImplementsInterfaces.Refs = append(
-			d.ObjectTypeDefinitions[objectTypeDefinitionRef].ImplementsInterfaces.Refs,
-			d.ObjectTypeExtensions[objectTypeExtensionRef].ImplementsInterfaces.Refs...,
+			d.ObjectTypeDefinitions[objectTypeDefinitionRef].ImplementsInterfaces.Refs, ref,
 		)
 	}
 
@@ -93,6 +93,21 @@ func (d *Document) OperationNameExists(operationName string) bool {
 	return false
 }
 
+// OperationDefinitionRefByName returns the operation selected by the operation name.
+// An empty operation name selects the first operation of the document.
+func (d *Document) OperationDefinitionRefByName(operationName string) (ref int, ok bool) {
+	for i := range d.RootNodes {
+		if d.RootNodes[i].Kind != NodeKindOperationDefinition {
+			continue
+		}
+		if operationName != "" && d.OperationDefinitionNameString(d.RootNodes[i].Ref) != operationName {
+			continue
+		}
+		return d.RootNodes[i].Ref, true
+	}
+	return InvalidRef, false
+}
+
 func (d *Document) NumOfOperationDefinitions() (n int) {
 	for i := range d.RootNodes {
 		if d.RootNodes[i].Kind == NodeKindOperationDefinition {
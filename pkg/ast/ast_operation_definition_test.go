@@ -6,6 +6,7 @@ import (
 	"github.com/stretchr/testify/assert"
 
 	"github.com/wundergraph/graphql-go-tools/internal/pkg/unsafeparser"
+	"github.com/wundergraph/graphql-go-tools/pkg/ast"
 )
 
 func TestDocument_OperationNameExists(t *testing.T) {
@@ -47,3 +48,46 @@ func TestDocument_OperationNameExists(t *testing.T) {
 		true,
 	))
 }
+
+func TestDocument_OperationDefinitionRefByName(t *testing.T) {
+	run := func(operation string, operationName string, expectedName string, expectedOk bool) func(t *testing.T) {
+		return func(t *testing.T) {
+			doc := unsafeparser.ParseGraphqlDocumentString(operation)
+			ref, ok := doc.OperationDefinitionRefByName(operationName)
+			assert.Equal(t, expectedOk, ok)
+			if !expectedOk {
+				assert.Equal(t, ast.InvalidRef, ref)
+				return
+			}
+			assert.Equal(t, expectedName, doc.OperationDefinitionNameString(ref))
+		}
+	}
+
+	t.Run("not found on empty document", run(
+		"",
+		"",
+		"",
+		false,
+	))
+
+	t.Run("not found on document with other operations", run(
+		"query OtherOperation {other} query AnotherOperation {another}",
+		"MyOperation",
+		"",
+		false,
+	))
+
+	t.Run("first operation without operation name", run(
+		"fragment F on T {field} query MyOperation {my} query OtherOperation {other}",
+		"",
+		"MyOperation",
+		true,
+	))
+
+	t.Run("operation selected by name", run(
+		"query OtherOperation {other} fragment F on T {field} query MyOperation {my}",
+		"MyOperation",
+		"MyOperation",
+		true,
+	))
+}
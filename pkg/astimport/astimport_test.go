@@ -6,6 +6,8 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/wundergraph/graphql-go-tools/internal/pkg/unsafeparser"
+	"github.com/wundergraph/graphql-go-tools/internal/pkg/unsafeprinter"
 	"github.com/wundergraph/graphql-go-tools/pkg/ast"
 	"github.com/wundergraph/graphql-go-tools/pkg/astparser"
 	"github.com/wundergraph/graphql-go-tools/pkg/operationreport"
@@ -318,3 +320,66 @@ func TestImporter_ImportVariableDefinitions(t *testing.T) {
 		[]int{0, 1},
 	))
 }
+
+func TestImporter_MergeVariableDefinitions(t *testing.T) {
+	run := func(fromOperation, toOperation string, expectedFrom, expectedTo string, expectedRenamed map[string]string) func(t *testing.T) {
+		return func(t *testing.T) {
+			from := unsafeparser.ParseGraphqlDocumentString(fromOperation)
+			to := unsafeparser.ParseGraphqlDocumentString(toOperation)
+
+			importer := &Importer{}
+			renamed, err := importer.MergeVariableDefinitions(0, 0, &from, &to)
+			require.NoError(t, err)
+
+			assert.Equal(t, expectedRenamed, renamed)
+			assert.Equal(t, expectedFrom, unsafeprinter.Print(&from, nil))
+			assert.Equal(t, expectedTo, unsafeprinter.Print(&to, nil))
+		}
+	}
+
+	t.Run("should reuse a variable with the same name and type", run(
+		`query A($id: ID!) { user(id: $id) { name } }`,
+		`query B($id: ID!) { product(id: $id) { upc } }`,
+		`query A($id: ID!){user(id: $id){name}}`,
+		`query B($id: ID!){product(id: $id){upc}}`,
+		map[string]string{},
+	))
+
+	t.Run("should import a variable which is not defined yet", run(
+		`query A($limit: Int) { users(limit: $limit) { name } }`,
+		`query B($id: ID!) { product(id: $id) { upc } }`,
+		`query A($limit: Int){users(limit: $limit){name}}`,
+		`query B($id: ID!, $limit: Int){product(id: $id){upc}}`,
+		map[string]string{},
+	))
+
+	t.Run("should rename a variable with a conflicting type without changing the from document", run(
+		`query A($id: Int!, $id2: String) { user(id: $id) { friends(filter: {ids: [$id]}) @include(if: $id) { name } ...UserFields } } fragment UserFields on User { posts(id: $id) { title } }`,
+		`query B($id: ID!) { product(id: $id) { upc } }`,
+		`query A($id: Int!, $id2: String){user(id: $id){friends(filter: {ids: [$id]})@include(if: $id) {name}...UserFields}} fragment UserFields on User {posts(id: $id){title}}`,
+		`query B($id: ID!, $id3: Int!, $id2: String){product(id: $id){upc}}`,
+		map[string]string{"id": "id3"},
+	))
+
+	t.Run("should rename the usages in imported selections", func(t *testing.T) {
+		from := unsafeparser.ParseGraphqlDocumentString(`query A($id: Int!) { user(id: $id) { name } }`)
+		to := unsafeparser.ParseGraphqlDocumentString(`query B($id: ID!) { product(id: $id) { upc } }`)
+
+		importer := &Importer{}
+		renamed, err := importer.MergeVariableDefinitions(0, 0, &from, &to)
+		require.NoError(t, err)
+
+		user := from.Selections[from.SelectionSets[from.OperationDefinitions[0].SelectionSet].SelectionRefs[0]].Ref
+		imported := importer.ImportField(user, &from, &to)
+		importedSelectionSet := to.AddSelectionSetToDocument(ast.SelectionSet{})
+		to.AddSelection(importedSelectionSet, ast.Selection{Kind: ast.SelectionKindField, Ref: imported})
+		importer.RenameVariableUsages(importedSelectionSet, renamed, &to)
+		to.AddSelection(to.OperationDefinitions[0].SelectionSet, ast.Selection{Kind: ast.SelectionKindField, Ref: imported})
+
+		assert.Equal(t, `query A($id: Int!){user(id: $id){name}}`, unsafeprinter.Print(&from, nil))
+		printed := unsafeprinter.Print(&to, nil)
+		assert.Contains(t, printed, `query B($id: ID!, $id2: Int!)`)
+		assert.Contains(t, printed, `product(id: $id)`)
+		assert.Contains(t, printed, `user(id: $id2)`)
+	})
+}
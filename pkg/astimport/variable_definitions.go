@@ -0,0 +1,151 @@
+package astimport
+
+import (
+	"bytes"
+	"strconv"
+
+	"github.com/wundergraph/graphql-go-tools/pkg/ast"
+)
+
+// MergeVariableDefinitions imports the variable definitions of fromOperation into toOperation.
+//
+// A variable which is already defined on toOperation with an equal type is reused.
+// A variable with the same name but a different type is imported with a name unused in both operations.
+// The from document is not modified, usages of renamed variables in nodes imported from fromOperation
+// have to be updated with RenameVariableUsages.
+// The returned map contains all renamed variables, keyed by the old variable name.
+func (i *Importer) MergeVariableDefinitions(fromOperation, toOperation int, from, to *ast.Document) (renamed map[string]string, err error) {
+	renamed = map[string]string{}
+
+	if !from.OperationDefinitions[fromOperation].HasVariableDefinitions {
+		return renamed, nil
+	}
+
+	for _, fromRef := range from.OperationDefinitions[fromOperation].VariableDefinitions.Refs {
+		name := from.VariableDefinitionNameBytes(fromRef)
+
+		var newName []byte
+		toRef, exists := to.VariableDefinitionByNameAndOperation(toOperation, name)
+		if exists {
+			equal, err := i.variableDefinitionTypesAreEqual(fromRef, toRef, from, to)
+			if err != nil {
+				return nil, err
+			}
+			if equal {
+				continue
+			}
+
+			newName = i.unusedVariableName(name, fromOperation, toOperation, from, to)
+			renamed[string(name)] = string(newName)
+		}
+
+		imported := i.ImportVariableDefinition(fromRef, from, to)
+		if newName != nil {
+			variableValue := to.VariableDefinitions[imported].VariableValue.Ref
+			to.VariableValues[variableValue].Name = to.Input.AppendInputBytes(newName)
+		}
+		to.AddImportedVariableDefinitionToOperationDefinition(toOperation, imported)
+	}
+
+	return renamed, nil
+}
+
+func (i *Importer) variableDefinitionTypesAreEqual(fromRef, toRef int, from, to *ast.Document) (bool, error) {
+	fromType, err := from.PrintTypeBytes(from.VariableDefinitions[fromRef].Type, nil)
+	if err != nil {
+		return false, err
+	}
+	toType, err := to.PrintTypeBytes(to.VariableDefinitions[toRef].Type, nil)
+	if err != nil {
+		return false, err
+	}
+	return bytes.Equal(fromType, toType), nil
+}
+
+func (i *Importer) unusedVariableName(name ast.ByteSlice, fromOperation, toOperation int, from, to *ast.Document) []byte {
+	for suffix := 2; ; suffix++ {
+		candidate := strconv.AppendInt(append([]byte{}, name...), int64(suffix), 10)
+		if _, exists := from.VariableDefinitionByNameAndOperation(fromOperation, candidate); exists {
+			continue
+		}
+		if _, exists := to.VariableDefinitionByNameAndOperation(toOperation, candidate); exists {
+			continue
+		}
+		return candidate
+	}
+}
+
+// RenameVariableUsages renames the usages of the renamed variables, keyed by the old variable name,
+// within the arguments and directives of the selection set and its nested selections,
+// e.g. of the selections imported together with variable definitions merged by MergeVariableDefinitions.
+// Fragment spreads are not followed as the fragment definitions might be used by other operations of the document.
+func (i *Importer) RenameVariableUsages(selectionSet int, renamed map[string]string, document *ast.Document) {
+	if len(renamed) == 0 {
+		return
+	}
+	renamer := &variableRenamer{
+		document: document,
+		renamed:  make(map[string]ast.ByteSliceReference, len(renamed)),
+	}
+	for oldName, newName := range renamed {
+		renamer.renamed[oldName] = document.Input.AppendInputString(newName)
+	}
+	renamer.renameSelectionSet(selectionSet)
+}
+
+type variableRenamer struct {
+	document *ast.Document
+	renamed  map[string]ast.ByteSliceReference
+}
+
+func (v *variableRenamer) renameSelectionSet(ref int) {
+	for _, selectionRef := range v.document.SelectionSets[ref].SelectionRefs {
+		selection := v.document.Selections[selectionRef]
+		switch selection.Kind {
+		case ast.SelectionKindField:
+			field := v.document.Fields[selection.Ref]
+			v.renameArguments(field.Arguments)
+			v.renameDirectives(field.Directives)
+			if field.HasSelections {
+				v.renameSelectionSet(field.SelectionSet)
+			}
+		case ast.SelectionKindInlineFragment:
+			inlineFragment := v.document.InlineFragments[selection.Ref]
+			v.renameDirectives(inlineFragment.Directives)
+			if inlineFragment.HasSelections {
+				v.renameSelectionSet(inlineFragment.SelectionSet)
+			}
+		case ast.SelectionKindFragmentSpread:
+			v.renameDirectives(v.document.FragmentSpreads[selection.Ref].Directives)
+		}
+	}
+}
+
+func (v *variableRenamer) renameDirectives(directives ast.DirectiveList) {
+	for _, ref := range directives.Refs {
+		v.renameArguments(v.document.Directives[ref].Arguments)
+	}
+}
+
+func (v *variableRenamer) renameArguments(arguments ast.ArgumentList) {
+	for _, ref := range arguments.Refs {
+		v.renameValue(v.document.Arguments[ref].Value)
+	}
+}
+
+func (v *variableRenamer) renameValue(value ast.Value) {
+	switch value.Kind {
+	case ast.ValueKindVariable:
+		if newName, ok := v.renamed[v.document.VariableValueNameString(value.Ref)]; ok {
+			v.document.VariableValues[value.Ref].Name = newName
+		}
+	case ast.ValueKindList:
+		for _, ref := range v.document.ListValues[value.Ref].Refs {
+			v.renameValue(v.document.Values[ref])
+		}
+	case ast.ValueKindObject:
+		for _, ref := range v.document.ObjectValues[value.Ref].Refs {
+			v.renameValue(v.document.ObjectFields[ref].Value)
+		}
+	}
+}
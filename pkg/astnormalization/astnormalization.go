@@ -88,10 +88,12 @@ func NormalizeNamedOperation(operation, definition *ast.Document, operationName
 
 // OperationNormalizer walks a given AST and applies all registered rules
 type OperationNormalizer struct {
-	operationWalkers     []*astvisitor.Walker
-	variablesExtraction  *variablesExtractionVisitor
-	options              options
-	definitionNormalizer *DefinitionNormalizer
+	operationWalkers                []*astvisitor.Walker
+	variablesExtraction             *variablesExtractionVisitor
+	variablesDefaultValueExtraction *variablesDefaultValueExtractionVisitor
+	options                         options
+	definitionNormalizer            *DefinitionNormalizer
+	transformations                 *transformationRecorder
 }
 
 // NewNormalizer creates a new OperationNormalizer and sets up all default rules
@@ -129,6 +131,8 @@ type options struct {
 	extractVariables          bool
 	removeUnusedVariables     bool
 	normalizeDefinition       bool
+	extractFragments          bool
+	lazyFragmentExpansion     bool
 }
 
 type Option func(options *options)
@@ -157,11 +161,34 @@ func WithNormalizeDefinition() Option {
 	}
 }
 
+// WithExtractFragments hoists identical selection sets on the same type into fragments after all other rules,
+// which shrinks operations selecting the same fields on many aliases, e.g. operations sent to subgraphs.
+// As the operation validation expects fragments to be inlined, validate the operation before extracting fragments.
+func WithExtractFragments() Option {
+	return func(options *options) {
+		options.extractFragments = true
+	}
+}
+
+// WithLazyFragmentExpansion keeps the fragment spreads and fragment definitions of the operation instead of inlining
+// all fragments into each other up front, which keeps the AST of documents with many nested fragments small.
+// The fragments get expanded on demand with a FragmentSpreadExpander, e.g. by the planner.
+// As variables might be used in fragments only, WithRemoveFragmentDefinitions and WithRemoveUnusedVariables
+// have no effect with lazy fragment expansion.
+func WithLazyFragmentExpansion() Option {
+	return func(options *options) {
+		options.lazyFragmentExpansion = true
+	}
+}
+
 func (o *OperationNormalizer) setupOperationWalkers() {
 	o.operationWalkers = make([]*astvisitor.Walker, 0, 4)
+	o.transformations = &transformationRecorder{}
 
 	fragmentInline := astvisitor.NewWalker(48)
-	fragmentSpreadInline(&fragmentInline)
+	if !o.options.lazyFragmentExpansion {
+		recordedFragmentSpreadInline(&fragmentInline, o.transformations)
+	}
 	directiveIncludeSkip(&fragmentInline)
 	o.operationWalkers = append(o.operationWalkers, &fragmentInline)
 
@@ -175,24 +202,32 @@ func (o *OperationNormalizer) setupOperationWalkers() {
 	removeSelfAliasing(&other)
 	mergeInlineFragments(&other)
 	mergeFieldSelections(&other)
-	deduplicateFields(&other)
+	recordedDeduplicateFields(&other, o.transformations)
 
-	if o.options.removeFragmentDefinitions {
-		removeFragmentDefinitions(&other)
+	if o.options.removeFragmentDefinitions && !o.options.lazyFragmentExpansion {
+		recordedRemoveFragmentDefinitions(&other, o.transformations)
 	}
-	if o.options.removeUnusedVariables {
-		deleteUnusedVariables(&other)
+	if o.options.removeUnusedVariables && !o.options.lazyFragmentExpansion {
+		deleteUnusedVariables(&other).transformations = o.transformations
 	}
 	o.operationWalkers = append(o.operationWalkers, &other)
 
 	if o.options.extractVariables {
 		variablesProcessing := astvisitor.NewWalker(48)
+		o.variablesDefaultValueExtraction = extractVariablesDefaultValue(&variablesProcessing)
+		o.variablesDefaultValueExtraction.transformations = o.transformations
+		// the coercion runs after the extraction of default values, so that defaults like `$ids: [Int] = 1` are coerced too
 		inputCoercionForList(&variablesProcessing)
-		extractVariablesDefaultValue(&variablesProcessing)
 		injectInputFieldDefaults(&variablesProcessing)
 
 		o.operationWalkers = append(o.operationWalkers, &variablesProcessing)
 	}
+
+	if o.options.extractFragments {
+		fragmentExtraction := astvisitor.NewWalker(48)
+		extractFragments(&fragmentExtraction, o.transformations)
+		o.operationWalkers = append(o.operationWalkers, &fragmentExtraction)
+	}
 }
 
 func (o *OperationNormalizer) prepareDefinition(definition *ast.Document, report *operationreport.Report) {
@@ -210,6 +245,12 @@ func (o *OperationNormalizer) NormalizeOperation(operation, definition *ast.Docu
 		}
 	}
 
+	if o.variablesExtraction != nil {
+		o.variablesExtraction.operationName = nil
+	}
+	if o.variablesDefaultValueExtraction != nil {
+		o.variablesDefaultValueExtraction.operationName = nil
+	}
 	for i := range o.operationWalkers {
 		o.operationWalkers[i].Walk(operation, definition, report)
 		if report.HasErrors() {
@@ -230,6 +271,9 @@ func (o *OperationNormalizer) NormalizeNamedOperation(operation, definition *ast
 	if o.variablesExtraction != nil {
 		o.variablesExtraction.operationName = operationName
 	}
+	if o.variablesDefaultValueExtraction != nil {
+		o.variablesDefaultValueExtraction.operationName = operationName
+	}
 	for i := range o.operationWalkers {
 		o.operationWalkers[i].Walk(operation, definition, report)
 		if report.HasErrors() {
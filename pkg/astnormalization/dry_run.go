@@ -0,0 +1,160 @@
+package astnormalization
+
+import (
+	"github.com/wundergraph/graphql-go-tools/pkg/ast"
+	"github.com/wundergraph/graphql-go-tools/pkg/astparser"
+	"github.com/wundergraph/graphql-go-tools/pkg/astprinter"
+	"github.com/wundergraph/graphql-go-tools/pkg/operationreport"
+)
+
+// TransformationKind is the kind of change a normalization rule applied to an operation
+type TransformationKind string
+
+const (
+	TransformationInlineFragmentSpread           TransformationKind = "inline fragment spread"
+	TransformationRemoveDuplicateField           TransformationKind = "remove duplicate field"
+	TransformationRemoveFragmentDefinition       TransformationKind = "remove fragment definition"
+	TransformationRemoveUnusedFragmentDefinition TransformationKind = "remove unused fragment definition"
+	TransformationApplyVariableDefaultValue      TransformationKind = "apply variable default value"
+	TransformationRemoveUnusedVariable           TransformationKind = "remove unused variable"
+	TransformationExtractFragment                TransformationKind = "extract fragment"
+)
+
+// Transformation is a single change applied to an operation during normalization
+type Transformation struct {
+	Kind TransformationKind
+	// Name is the name of the changed fragment, field or variable
+	Name string
+	// Path is the path of the selection set containing the change, e.g. "query.hero", empty for definitions
+	Path string
+}
+
+// NormalizationDiff is the result of a dry run of the normalization
+type NormalizationDiff struct {
+	// Before is the printed operation as sent by the client
+	Before string
+	// After is the printed normalized operation
+	After string
+	// Transformations are the changes applied to the operation in the order of application
+	Transformations []Transformation
+}
+
+// DryRunNormalizeOperation creates a Normalizer removing fragment definitions and extracting variables
+// and runs it on a copy of the operation, see OperationNormalizer.DryRun.
+func DryRunNormalizeOperation(operation, definition *ast.Document, operationName []byte, report *operationreport.Report) *NormalizationDiff {
+	normalizer := NewNormalizer(true, true)
+	return normalizer.DryRun(operation, definition, operationName, report)
+}
+
+// DryRun normalizes a copy of the operation and reports what the normalization changed.
+// It's meant for debugging why an upstream receives a different operation than the one sent by the client,
+// the operation itself stays unchanged. If operationName is set only the named operation gets normalized.
+func (o *OperationNormalizer) DryRun(operation, definition *ast.Document, operationName []byte, report *operationreport.Report) *NormalizationDiff {
+	before, err := astprinter.PrintStringIndent(operation, definition, "  ")
+	if err != nil {
+		report.AddInternalError(err)
+		return nil
+	}
+
+	normalized, parseReport := astparser.ParseGraphqlDocumentString(before)
+	if parseReport.HasErrors() {
+		*report = parseReport
+		return nil
+	}
+	normalized.Input.Variables = append([]byte(nil), operation.Input.Variables...)
+
+	o.transformations.start()
+	defer o.transformations.stop()
+
+	if len(operationName) != 0 {
+		o.NormalizeNamedOperation(&normalized, definition, operationName, report)
+	} else {
+		o.NormalizeOperation(&normalized, definition, report)
+	}
+	if report.HasErrors() {
+		return nil
+	}
+
+	after, err := astprinter.PrintStringIndent(&normalized, definition, "  ")
+	if err != nil {
+		report.AddInternalError(err)
+		return nil
+	}
+
+	return &NormalizationDiff{
+		Before:          before,
+		After:           after,
+		Transformations: o.transformations.applied,
+	}
+}
+
+// transformationRecorder collects the transformations applied by the rules during a dry run.
+// Outside of a dry run recording is disabled.
+type transformationRecorder struct {
+	enabled bool
+	applied []Transformation
+	// spreads are the names of the fragments spread by an operation (key "") or a fragment definition
+	spreads map[string][]string
+}
+
+func (t *transformationRecorder) start() {
+	t.enabled = true
+	t.applied = nil
+	t.spreads = map[string][]string{}
+}
+
+func (t *transformationRecorder) stop() {
+	t.enabled = false
+	t.spreads = nil
+}
+
+func (t *transformationRecorder) record(kind TransformationKind, name, path string) {
+	if t == nil || !t.enabled {
+		return
+	}
+	t.applied = append(t.applied, Transformation{
+		Kind: kind,
+		Name: name,
+		Path: path,
+	})
+}
+
+// recordFragmentSpread records the inlining of a fragment into an operation or a fragment definition (spreadBy)
+func (t *transformationRecorder) recordFragmentSpread(spreadBy, fragmentName, path string) {
+	if t == nil || !t.enabled {
+		return
+	}
+	t.spreads[spreadBy] = append(t.spreads[spreadBy], fragmentName)
+	t.record(TransformationInlineFragmentSpread, fragmentName, path)
+}
+
+// recordFragmentDefinitionRemoval records the removal of a fragment definition,
+// fragments which aren't reachable from an operation are reported as unused.
+func (t *transformationRecorder) recordFragmentDefinitionRemoval(fragmentName string) {
+	if t == nil || !t.enabled {
+		return
+	}
+	if t.isFragmentUsed(fragmentName) {
+		t.record(TransformationRemoveFragmentDefinition, fragmentName, "")
+		return
+	}
+	t.record(TransformationRemoveUnusedFragmentDefinition, fragmentName, "")
+}
+
+func (t *transformationRecorder) isFragmentUsed(fragmentName string) bool {
+	visited := map[string]bool{}
+	pending := append([]string(nil), t.spreads[""]...)
+	for len(pending) != 0 {
+		name := pending[len(pending)-1]
+		pending = pending[:len(pending)-1]
+		if name == fragmentName {
+			return true
+		}
+		if visited[name] {
+			continue
+		}
+		visited[name] = true
+		pending = append(pending, t.spreads[name]...)
+	}
+	return false
+}
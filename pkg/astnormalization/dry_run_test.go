@@ -0,0 +1,68 @@
+package astnormalization
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/wundergraph/graphql-go-tools/internal/pkg/unsafeparser"
+	"github.com/wundergraph/graphql-go-tools/internal/pkg/unsafeprinter"
+	"github.com/wundergraph/graphql-go-tools/pkg/operationreport"
+)
+
+func TestOperationNormalizer_DryRun(t *testing.T) {
+	schema := `
+scalar String
+
+type Query {
+	country(code: String): Country!
+}
+
+type Country {
+	name: String!
+	capital: String!
+}
+
+schema {
+    query: Query
+}
+`
+
+	t.Run("reports duplicate field and unused fragment", func(t *testing.T) {
+		query := `fragment Unused on Country {capital} query Q {country {name name}}`
+
+		definition := unsafeparser.ParseGraphqlDocumentString(schema)
+		operation := unsafeparser.ParseGraphqlDocumentString(query)
+
+		report := operationreport.Report{}
+		normalizer := NewWithOpts(WithRemoveFragmentDefinitions())
+		diff := normalizer.DryRun(&operation, &definition, nil, &report)
+		require.False(t, report.HasErrors(), report.Error())
+
+		assert.Equal(t, []Transformation{
+			{Kind: TransformationRemoveDuplicateField, Name: "name", Path: "query.country"},
+			{Kind: TransformationRemoveUnusedFragmentDefinition, Name: "Unused"},
+		}, diff.Transformations)
+		assert.Equal(t, unsafeprinter.Prettify(query), diff.Before)
+		assert.Equal(t, unsafeprinter.Prettify(`query Q {country {name}}`), diff.After)
+		assert.Equal(t, query, unsafeprinter.Print(&operation, nil), "operation must not be changed")
+	})
+
+	t.Run("reports inlined fragment and applied variable default", func(t *testing.T) {
+		query := `query Q($code: String = "DE") {country(code: $code) {...Fields}} fragment Fields on Country {name}`
+
+		definition := unsafeparser.ParseGraphqlDocumentString(schema)
+		operation := unsafeparser.ParseGraphqlDocumentString(query)
+
+		report := operationreport.Report{}
+		diff := DryRunNormalizeOperation(&operation, &definition, []byte("Q"), &report)
+		require.False(t, report.HasErrors(), report.Error())
+
+		assert.Equal(t, []Transformation{
+			{Kind: TransformationInlineFragmentSpread, Name: "Fields", Path: "query.country"},
+			{Kind: TransformationRemoveFragmentDefinition, Name: "Fields"},
+			{Kind: TransformationApplyVariableDefaultValue, Name: "code"},
+		}, diff.Transformations)
+	})
+}
@@ -6,8 +6,13 @@ import (
 )
 
 func deduplicateFields(walker *astvisitor.Walker) {
+	recordedDeduplicateFields(walker, nil)
+}
+
+func recordedDeduplicateFields(walker *astvisitor.Walker, transformations *transformationRecorder) {
 	visitor := deduplicateFieldsVisitor{
-		Walker: walker,
+		Walker:          walker,
+		transformations: transformations,
 	}
 	walker.RegisterEnterDocumentVisitor(&visitor)
 	walker.RegisterEnterSelectionSetVisitor(&visitor)
@@ -15,7 +20,8 @@ func deduplicateFields(walker *astvisitor.Walker) {
 
 type deduplicateFieldsVisitor struct {
 	*astvisitor.Walker
-	operation *ast.Document
+	operation       *ast.Document
+	transformations *transformationRecorder
 }
 
 func (d *deduplicateFieldsVisitor) EnterDocument(operation, definition *ast.Document) {
@@ -50,6 +56,7 @@ func (d *deduplicateFieldsVisitor) EnterSelectionSet(ref int) {
 				continue
 			}
 			if d.operation.FieldsAreEqualFlat(left, right) {
+				d.transformations.record(TransformationRemoveDuplicateField, d.operation.FieldAliasOrNameString(right), d.Path.DotDelimitedString())
 				d.operation.RemoveFromSelectionSet(ref, b)
 				d.RevisitNode()
 				return
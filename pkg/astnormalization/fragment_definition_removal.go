@@ -9,16 +9,24 @@ type FragmentDefinitionRemoval struct {
 }
 
 func removeFragmentDefinitions(walker *astvisitor.Walker) {
-	visitor := removeFragmentDefinitionsVisitor{}
+	recordedRemoveFragmentDefinitions(walker, nil)
+}
+
+func recordedRemoveFragmentDefinitions(walker *astvisitor.Walker, transformations *transformationRecorder) {
+	visitor := removeFragmentDefinitionsVisitor{
+		transformations: transformations,
+	}
 	walker.RegisterLeaveDocumentVisitor(visitor)
 }
 
 type removeFragmentDefinitionsVisitor struct {
+	transformations *transformationRecorder
 }
 
 func (r removeFragmentDefinitionsVisitor) LeaveDocument(operation, definition *ast.Document) {
 	for i := range operation.RootNodes {
 		if operation.RootNodes[i].Kind == ast.NodeKindFragmentDefinition {
+			r.transformations.recordFragmentDefinitionRemoval(operation.FragmentDefinitionNameString(operation.RootNodes[i].Ref))
 			operation.RootNodes[i].Kind = ast.NodeKindUnknown
 		}
 	}
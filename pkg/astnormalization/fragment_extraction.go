@@ -0,0 +1,261 @@
+package astnormalization
+
+import (
+	"bytes"
+	"sort"
+	"strconv"
+
+	"github.com/wundergraph/graphql-go-tools/pkg/ast"
+	"github.com/wundergraph/graphql-go-tools/pkg/astvisitor"
+	"github.com/wundergraph/graphql-go-tools/pkg/lexer/literal"
+)
+
+// extractFragments registers a visitor which hoists identical selection sets of fields returning the same type
+// into fragment definitions and replaces them with spreads of the fragment, e.g.
+//
+//	{ a: product(upc: "1") { upc name price } b: product(upc: "2") { upc name price } }
+//
+// becomes
+//
+//	{ a: product(upc: "1") { ...f0 } b: product(upc: "2") { ...f0 } } fragment f0 on Product { upc name price }
+//
+// A selection set only gets extracted when this shrinks the printed operation.
+func extractFragments(walker *astvisitor.Walker, transformations *transformationRecorder) *fragmentExtractionVisitor {
+	visitor := &fragmentExtractionVisitor{
+		Walker:          walker,
+		transformations: transformations,
+	}
+	walker.RegisterEnterDocumentVisitor(visitor)
+	walker.RegisterEnterFieldVisitor(visitor)
+	walker.RegisterLeaveDocumentVisitor(visitor)
+	return visitor
+}
+
+type fragmentExtractionVisitor struct {
+	*astvisitor.Walker
+	operation, definition *ast.Document
+	transformations       *transformationRecorder
+	groups                map[string]*selectionSetGroup
+	buf                   bytes.Buffer
+}
+
+// selectionSetGroup are the fields selecting the same selection set on the same type
+type selectionSetGroup struct {
+	typeName     string
+	selectionSet string
+	fields       []selectionSetOccurrence
+}
+
+type selectionSetOccurrence struct {
+	field int
+	// ancestorFields are the fields enclosing the field
+	ancestorFields []int
+}
+
+func (f *fragmentExtractionVisitor) EnterDocument(operation, definition *ast.Document) {
+	f.operation = operation
+	f.definition = definition
+	f.groups = map[string]*selectionSetGroup{}
+}
+
+func (f *fragmentExtractionVisitor) EnterField(ref int) {
+	if !f.operation.FieldHasSelections(ref) {
+		return
+	}
+	fieldDefinition, ok := f.FieldDefinition(ref)
+	if !ok {
+		return
+	}
+	typeName := f.definition.ResolveTypeNameString(f.definition.FieldDefinitionType(fieldDefinition))
+
+	f.buf.Reset()
+	f.writeSelectionSet(f.operation.Fields[ref].SelectionSet)
+	selectionSet := f.buf.String()
+
+	var ancestorFields []int
+	for _, ancestor := range f.Ancestors {
+		if ancestor.Kind == ast.NodeKindField {
+			ancestorFields = append(ancestorFields, ancestor.Ref)
+		}
+	}
+
+	key := typeName + " " + selectionSet
+	group, ok := f.groups[key]
+	if !ok {
+		group = &selectionSetGroup{typeName: typeName, selectionSet: selectionSet}
+		f.groups[key] = group
+	}
+	group.fields = append(group.fields, selectionSetOccurrence{field: ref, ancestorFields: ancestorFields})
+}
+
+func (f *fragmentExtractionVisitor) LeaveDocument(operation, definition *ast.Document) {
+	groups := make([]*selectionSetGroup, 0, len(f.groups))
+	for _, group := range f.groups {
+		if len(group.fields) > 1 {
+			groups = append(groups, group)
+		}
+	}
+	// extract the largest selection sets first, selection sets nested in them are only selected once afterwards
+	sort.Slice(groups, func(i, j int) bool {
+		if len(groups[i].selectionSet) != len(groups[j].selectionSet) {
+			return len(groups[i].selectionSet) > len(groups[j].selectionSet)
+		}
+		return groups[i].fields[0].field < groups[j].fields[0].field
+	})
+
+	fragmentNames := f.fragmentNames()
+	// replacedFields are the fields whose selection set got replaced by a spread,
+	// fields nested in them aren't part of the operation anymore
+	replacedFields := map[int]struct{}{}
+
+	for _, group := range groups {
+		fields := group.fields[:0:0]
+		for _, occurrence := range group.fields {
+			if !isNestedInReplacedField(occurrence, replacedFields) {
+				fields = append(fields, occurrence)
+			}
+		}
+
+		fragmentName := fragmentNames.peek()
+		if !extractionShrinksOperation(len(fields), group, fragmentName) {
+			continue
+		}
+		fragmentNames.take(fragmentName)
+
+		f.extractFragment(fragmentName, group.typeName, fields)
+		for _, occurrence := range fields[1:] {
+			replacedFields[occurrence.field] = struct{}{}
+		}
+		f.transformations.record(TransformationExtractFragment, fragmentName, "")
+	}
+}
+
+func (f *fragmentExtractionVisitor) extractFragment(fragmentName, typeName string, fields []selectionSetOccurrence) {
+	name := f.operation.Input.AppendInputString(fragmentName)
+	f.operation.FragmentDefinitions = append(f.operation.FragmentDefinitions, ast.FragmentDefinition{
+		Name: name,
+		TypeCondition: ast.TypeCondition{
+			Type: f.operation.AddNamedType([]byte(typeName)),
+		},
+		SelectionSet:  f.operation.Fields[fields[0].field].SelectionSet,
+		HasSelections: true,
+	})
+	fragmentDefinition := ast.Node{Kind: ast.NodeKindFragmentDefinition, Ref: len(f.operation.FragmentDefinitions) - 1}
+	f.operation.RootNodes = append(f.operation.RootNodes, fragmentDefinition)
+	f.operation.Index.AddNodeStr(fragmentName, fragmentDefinition)
+
+	for _, occurrence := range fields {
+		spread := f.operation.AddFragmentSpread(ast.FragmentSpread{FragmentName: name})
+		selection := f.operation.AddSelectionToDocument(ast.Selection{Kind: ast.SelectionKindFragmentSpread, Ref: spread})
+		f.operation.Fields[occurrence.field].SelectionSet = f.operation.AddSelectionSetToDocument(ast.SelectionSet{
+			SelectionRefs: []int{selection},
+		})
+	}
+}
+
+func isNestedInReplacedField(occurrence selectionSetOccurrence, replacedFields map[int]struct{}) bool {
+	for _, ancestor := range occurrence.ancestorFields {
+		if _, ok := replacedFields[ancestor]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// extractionShrinksOperation compares the size of the selection sets with the size of the fragment definition and spreads
+func extractionShrinksOperation(occurrences int, group *selectionSetGroup, fragmentName string) bool {
+	if occurrences < 2 {
+		return false
+	}
+	spreadSize := len("{...}") + len(fragmentName)
+	definitionSize := len("fragment  on ") + len(fragmentName) + len(group.typeName) + len(group.selectionSet)
+	return occurrences*len(group.selectionSet) > occurrences*spreadSize+definitionSize
+}
+
+// writeSelectionSet writes a compact representation of the selection set identifying it,
+// two selection sets with the same representation select the same fields with the same arguments and directives
+func (f *fragmentExtractionVisitor) writeSelectionSet(ref int) {
+	f.buf.Write(literal.LBRACE)
+	for i, selection := range f.operation.SelectionSets[ref].SelectionRefs {
+		if i != 0 {
+			f.buf.Write(literal.SPACE)
+		}
+		f.writeSelection(selection)
+	}
+	f.buf.Write(literal.RBRACE)
+}
+
+func (f *fragmentExtractionVisitor) writeSelection(ref int) {
+	selection := f.operation.Selections[ref]
+	switch selection.Kind {
+	case ast.SelectionKindField:
+		field := f.operation.Fields[selection.Ref]
+		if field.Alias.IsDefined {
+			f.buf.Write(f.operation.FieldAliasBytes(selection.Ref))
+			f.buf.Write(literal.COLON)
+		}
+		f.buf.Write(f.operation.FieldNameBytes(selection.Ref))
+		if field.HasArguments {
+			_ = f.operation.PrintArguments(field.Arguments.Refs, &f.buf)
+		}
+		f.writeDirectives(field.HasDirectives, field.Directives)
+		if field.HasSelections {
+			f.writeSelectionSet(field.SelectionSet)
+		}
+	case ast.SelectionKindInlineFragment:
+		inlineFragment := f.operation.InlineFragments[selection.Ref]
+		f.buf.Write(literal.SPREAD)
+		if inlineFragment.TypeCondition.Type != -1 {
+			f.buf.Write(literal.ON)
+			f.buf.Write(literal.SPACE)
+			f.buf.Write(f.operation.InlineFragmentTypeConditionName(selection.Ref))
+		}
+		f.writeDirectives(inlineFragment.HasDirectives, inlineFragment.Directives)
+		f.writeSelectionSet(inlineFragment.SelectionSet)
+	case ast.SelectionKindFragmentSpread:
+		f.buf.Write(literal.SPREAD)
+		f.buf.Write(f.operation.FragmentSpreadNameBytes(selection.Ref))
+		f.writeDirectives(f.operation.FragmentSpreads[selection.Ref].HasDirectives, f.operation.FragmentSpreads[selection.Ref].Directives)
+	}
+}
+
+func (f *fragmentExtractionVisitor) writeDirectives(hasDirectives bool, directives ast.DirectiveList) {
+	if !hasDirectives {
+		return
+	}
+	for _, directive := range directives.Refs {
+		f.buf.Write(literal.SPACE)
+		_ = f.operation.PrintDirective(directive, &f.buf)
+	}
+}
+
+// fragmentNames hands out short fragment names not used by a fragment definition of the operation
+func (f *fragmentExtractionVisitor) fragmentNames() *fragmentNameGenerator {
+	generator := &fragmentNameGenerator{
+		taken: map[string]struct{}{},
+	}
+	for i := range f.operation.FragmentDefinitions {
+		generator.taken[f.operation.FragmentDefinitionNameString(i)] = struct{}{}
+	}
+	return generator
+}
+
+type fragmentNameGenerator struct {
+	taken   map[string]struct{}
+	counter int
+}
+
+// peek returns the next free fragment name without taking it
+func (g *fragmentNameGenerator) peek() string {
+	for {
+		name := "f" + strconv.Itoa(g.counter)
+		if _, ok := g.taken[name]; !ok {
+			return name
+		}
+		g.counter++
+	}
+}
+
+func (g *fragmentNameGenerator) take(name string) {
+	g.taken[name] = struct{}{}
+}
@@ -0,0 +1,161 @@
+package astnormalization
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/wundergraph/graphql-go-tools/internal/pkg/unsafeparser"
+	"github.com/wundergraph/graphql-go-tools/internal/pkg/unsafeprinter"
+	"github.com/wundergraph/graphql-go-tools/pkg/asttransform"
+	"github.com/wundergraph/graphql-go-tools/pkg/astvalidation"
+	"github.com/wundergraph/graphql-go-tools/pkg/astvisitor"
+	"github.com/wundergraph/graphql-go-tools/pkg/operationreport"
+)
+
+const fragmentExtractionDefinition = `
+	schema { query: Query }
+	type Query {
+		product(upc: String!): Product
+		products: [Product]
+		node(id: ID!): Node
+	}
+	interface Node { id: ID! }
+	type Product implements Node {
+		id: ID!
+		upc: String!
+		name: String!
+		description: String
+		price: Int!
+		weight: Int
+		width: Int
+		height: Int
+		inStock: Boolean
+		shippingEstimate: Int
+		related: [Product]
+		reviews: [Review]
+	}
+	type Review {
+		id: ID!
+		body: String!
+		stars: Int!
+	}
+`
+
+func TestExtractFragments(t *testing.T) {
+	t.Run("identical selection sets of two aliases", func(t *testing.T) {
+		run(extractFragmentsWithoutRecorder, fragmentExtractionDefinition, `
+			{
+				a: product(upc: "1") { id upc name description price weight width height inStock shippingEstimate }
+				b: product(upc: "2") { id upc name description price weight width height inStock shippingEstimate }
+			}`, `
+			{
+				a: product(upc: "1") { ...f0 }
+				b: product(upc: "2") { ...f0 }
+			}
+			fragment f0 on Product { id upc name description price weight width height inStock shippingEstimate }`)
+	})
+	t.Run("different selection sets are not extracted", func(t *testing.T) {
+		run(extractFragmentsWithoutRecorder, fragmentExtractionDefinition, `
+			{
+				a: product(upc: "1") { id upc name description price weight width height inStock shippingEstimate }
+				b: product(upc: "2") { id upc name description price weight width height inStock }
+			}`, `
+			{
+				a: product(upc: "1") { id upc name description price weight width height inStock shippingEstimate }
+				b: product(upc: "2") { id upc name description price weight width height inStock }
+			}`)
+	})
+	t.Run("small selection sets are not extracted", func(t *testing.T) {
+		run(extractFragmentsWithoutRecorder, fragmentExtractionDefinition, `
+			{
+				a: product(upc: "1") { id }
+				b: product(upc: "2") { id }
+			}`, `
+			{
+				a: product(upc: "1") { id }
+				b: product(upc: "2") { id }
+			}`)
+	})
+	t.Run("identical selection sets on different types are not extracted", func(t *testing.T) {
+		run(extractFragmentsWithoutRecorder, fragmentExtractionDefinition, `
+			{
+				product(upc: "1") { a: id b: id c: id d: id e: id f: id g: id h: id }
+				node(id: "1") { a: id b: id c: id d: id e: id f: id g: id h: id }
+			}`, `
+			{
+				product(upc: "1") { a: id b: id c: id d: id e: id f: id g: id h: id }
+				node(id: "1") { a: id b: id c: id d: id e: id f: id g: id h: id }
+			}`)
+	})
+	t.Run("nested selection sets are extracted with the enclosing selection set", func(t *testing.T) {
+		run(extractFragmentsWithoutRecorder, fragmentExtractionDefinition, `
+			{
+				a: product(upc: "1") { name description price reviews { id body stars text: body rating: stars } }
+				b: product(upc: "2") { name description price reviews { id body stars text: body rating: stars } }
+				c: product(upc: "3") { upc reviews { id body stars text: body rating: stars } }
+				d: product(upc: "4") { price reviews { id body stars text: body rating: stars } }
+			}`, `
+			{
+				a: product(upc: "1") { ...f0 }
+				b: product(upc: "2") { ...f0 }
+				c: product(upc: "3") { upc reviews { ...f1 } }
+				d: product(upc: "4") { price reviews { ...f1 } }
+			}
+			fragment f0 on Product { name description price reviews { ...f1 } }
+			fragment f1 on Review { id body stars text: body rating: stars }`)
+	})
+	t.Run("fragment names don't collide with existing fragments", func(t *testing.T) {
+		run(extractFragmentsWithoutRecorder, fragmentExtractionDefinition, `
+			query Q {
+				a: product(upc: "1") { ...f0 }
+				b: product(upc: "2") { reviews { id body stars text: body rating: stars } }
+				c: product(upc: "3") { reviews { id body stars text: body rating: stars } }
+				d: product(upc: "4") { reviews { id body stars text: body rating: stars } }
+			}
+			fragment f0 on Product { id }`, `
+			query Q {
+				a: product(upc: "1") { ...f0 }
+				b: product(upc: "2") { ...f1 }
+				c: product(upc: "3") { ...f1 }
+				d: product(upc: "4") { ...f1 }
+			}
+			fragment f0 on Product { id }
+			fragment f1 on Product { reviews { id body stars text: body rating: stars } }`)
+	})
+}
+
+func TestOperationNormalizer_WithExtractFragments(t *testing.T) {
+	definition := unsafeparser.ParseGraphqlDocumentString(fragmentExtractionDefinition)
+	require.NoError(t, asttransform.MergeDefinitionWithBaseSchema(&definition))
+
+	operation := unsafeparser.ParseGraphqlDocumentString(`
+		query Products($first: String!, $second: String!) {
+			first: product(upc: $first) { ...ProductFields }
+			second: product(upc: $second) { id upc name description price weight width height inStock shippingEstimate }
+		}
+		fragment ProductFields on Product { id upc name description price weight width height inStock shippingEstimate }`)
+	sizeBefore := len(unsafeprinter.Print(&operation, nil))
+
+	report := operationreport.Report{}
+	normalizer := NewWithOpts(WithRemoveFragmentDefinitions(), WithExtractFragments())
+	normalizer.NormalizeOperation(&operation, &definition, &report)
+	require.False(t, report.HasErrors(), report.Error())
+
+	printed := unsafeprinter.Print(&operation, nil)
+	assert.Equal(t, `query Products($first: String!, $second: String!){first: product(upc: $first){...f0} second: product(upc: $second){...f0}} fragment f0 on Product {id upc name description price weight width height inStock shippingEstimate}`, printed)
+	assert.Less(t, len(printed), sizeBefore)
+
+	// the validator expects fragments to be inlined, so the extracted operation gets normalized again before validation
+	extracted := unsafeparser.ParseGraphqlDocumentString(printed)
+	NewWithOpts(WithRemoveFragmentDefinitions()).NormalizeOperation(&extracted, &definition, &report)
+	require.False(t, report.HasErrors(), report.Error())
+	astvalidation.DefaultOperationValidator().Validate(&extracted, &definition, &report)
+	assert.False(t, report.HasErrors(), report.Error())
+	assert.Equal(t, `query Products($first: String!, $second: String!){first: product(upc: $first){id upc name description price weight width height inStock shippingEstimate} second: product(upc: $second){id upc name description price weight width height inStock shippingEstimate}}`, unsafeprinter.Print(&extracted, nil))
+}
+
+func extractFragmentsWithoutRecorder(walker *astvisitor.Walker) {
+	extractFragments(walker, nil)
+}
@@ -11,8 +11,13 @@ import (
 )
 
 func fragmentSpreadInline(walker *astvisitor.Walker) {
+	recordedFragmentSpreadInline(walker, nil)
+}
+
+func recordedFragmentSpreadInline(walker *astvisitor.Walker, transformations *transformationRecorder) {
 	visitor := fragmentSpreadInlineVisitor{
-		Walker: walker,
+		Walker:          walker,
+		transformations: transformations,
 	}
 	walker.RegisterDocumentVisitor(&visitor)
 	walker.RegisterEnterFragmentSpreadVisitor(&visitor)
@@ -24,6 +29,7 @@ type fragmentSpreadInlineVisitor struct {
 	transformer           asttransform.Transformer
 	fragmentSpreadDepth   FragmentSpreadDepth
 	depths                Depths
+	transformations       *transformationRecorder
 }
 
 func (f *fragmentSpreadInlineVisitor) EnterDocument(operation, definition *ast.Document) {
@@ -43,8 +49,6 @@ func (f *fragmentSpreadInlineVisitor) LeaveDocument(operation, definition *ast.D
 }
 
 func (f *fragmentSpreadInlineVisitor) EnterFragmentSpread(ref int) {
-	parentTypeName := f.definition.NodeNameBytes(f.EnclosingTypeDefinition)
-
 	fragmentDefinitionRef, exists := f.operation.FragmentDefinitionRef(f.operation.FragmentSpreadNameBytes(ref))
 	if !exists {
 		fragmentName := f.operation.FragmentSpreadNameBytes(ref)
@@ -59,6 +63,45 @@ func (f *fragmentSpreadInlineVisitor) EnterFragmentSpread(ref int) {
 		return
 	}
 
+	nestedDepth, ok := f.depths.ByRef(ref)
+	if !ok {
+		f.StopWithInternalErr(fmt.Errorf("nested depth missing on depths for FragmentSpread: %s", f.operation.FragmentSpreadNameString(ref)))
+		return
+	}
+
+	precedence := asttransform.Precedence{
+		Depth: nestedDepth,
+		Order: 0,
+	}
+
+	selectionSet := f.Ancestors[len(f.Ancestors)-1].Ref
+	replaceWith := f.operation.FragmentDefinitions[fragmentDefinitionRef].SelectionSet
+	typeCondition := f.operation.FragmentDefinitions[fragmentDefinitionRef].TypeCondition
+
+	f.recordFragmentSpread(ref)
+
+	switch inlineFragmentSpread(f.definition, f.EnclosingTypeDefinition, fragmentNode, fragmentTypeName) {
+	case spreadInlinedAsSelections:
+		f.transformer.ReplaceFragmentSpread(precedence, selectionSet, ref, replaceWith)
+	case spreadInlinedAsInlineFragment:
+		f.transformer.ReplaceFragmentSpreadWithInlineFragment(precedence, selectionSet, ref, replaceWith, typeCondition)
+	}
+}
+
+type spreadInlining int
+
+const (
+	spreadNotInlined spreadInlining = iota
+	spreadInlinedAsSelections
+	spreadInlinedAsInlineFragment
+)
+
+// inlineFragmentSpread returns how a spread of a fragment on the fragment type is inlined into a selection set
+// of the enclosing type: with the selections of the fragment if the enclosing type is always of the fragment type,
+// wrapped into an inline fragment if the types intersect and not at all otherwise
+func inlineFragmentSpread(definition *ast.Document, enclosingTypeDefinition, fragmentNode ast.Node, fragmentTypeName ast.ByteSlice) spreadInlining {
+	parentTypeName := definition.NodeNameBytes(enclosingTypeDefinition)
+
 	fragmentTypeEqualsParentType := bytes.Equal(parentTypeName, fragmentTypeName)
 	var enclosingTypeImplementsFragmentType bool
 	var enclosingTypeIsMemberOfFragmentUnion bool
@@ -67,49 +110,47 @@ func (f *fragmentSpreadInlineVisitor) EnterFragmentSpread(ref int) {
 	var fragmentUnionIntersectsEnclosingInterface bool
 	var fragmentInterfaceIntersectsEnclosingUnion bool
 
-	if fragmentNode.Kind == ast.NodeKindInterfaceTypeDefinition && f.EnclosingTypeDefinition.Kind == ast.NodeKindObjectTypeDefinition {
-		enclosingTypeImplementsFragmentType = f.definition.NodeImplementsInterface(f.EnclosingTypeDefinition, fragmentNode)
+	if fragmentNode.Kind == ast.NodeKindInterfaceTypeDefinition && enclosingTypeDefinition.Kind == ast.NodeKindObjectTypeDefinition {
+		enclosingTypeImplementsFragmentType = definition.NodeImplementsInterface(enclosingTypeDefinition, fragmentNode)
 	}
 
 	if fragmentNode.Kind == ast.NodeKindUnionTypeDefinition {
-		enclosingTypeIsMemberOfFragmentUnion = f.definition.NodeIsUnionMember(f.EnclosingTypeDefinition, fragmentNode)
+		enclosingTypeIsMemberOfFragmentUnion = definition.NodeIsUnionMember(enclosingTypeDefinition, fragmentNode)
 	}
 
-	if f.EnclosingTypeDefinition.Kind == ast.NodeKindInterfaceTypeDefinition {
-		fragmentTypeImplementsEnclosingType = f.definition.NodeImplementsInterface(fragmentNode, f.EnclosingTypeDefinition)
+	if enclosingTypeDefinition.Kind == ast.NodeKindInterfaceTypeDefinition {
+		fragmentTypeImplementsEnclosingType = definition.NodeImplementsInterface(fragmentNode, enclosingTypeDefinition)
 	}
 
-	if f.EnclosingTypeDefinition.Kind == ast.NodeKindInterfaceTypeDefinition && fragmentNode.Kind == ast.NodeKindUnionTypeDefinition {
-		fragmentUnionIntersectsEnclosingInterface = f.definition.UnionNodeIntersectsInterfaceNode(fragmentNode, f.EnclosingTypeDefinition)
+	if enclosingTypeDefinition.Kind == ast.NodeKindInterfaceTypeDefinition && fragmentNode.Kind == ast.NodeKindUnionTypeDefinition {
+		fragmentUnionIntersectsEnclosingInterface = definition.UnionNodeIntersectsInterfaceNode(fragmentNode, enclosingTypeDefinition)
 	}
 
-	if f.EnclosingTypeDefinition.Kind == ast.NodeKindUnionTypeDefinition && fragmentNode.Kind == ast.NodeKindInterfaceTypeDefinition {
-		fragmentInterfaceIntersectsEnclosingUnion = f.definition.UnionNodeIntersectsInterfaceNode(f.EnclosingTypeDefinition, fragmentNode)
+	if enclosingTypeDefinition.Kind == ast.NodeKindUnionTypeDefinition && fragmentNode.Kind == ast.NodeKindInterfaceTypeDefinition {
+		fragmentInterfaceIntersectsEnclosingUnion = definition.UnionNodeIntersectsInterfaceNode(enclosingTypeDefinition, fragmentNode)
 	}
 
-	if f.EnclosingTypeDefinition.Kind == ast.NodeKindUnionTypeDefinition {
-		fragmentTypeIsMemberOfEnclosingUnionType = f.definition.NodeIsUnionMember(fragmentNode, f.EnclosingTypeDefinition)
+	if enclosingTypeDefinition.Kind == ast.NodeKindUnionTypeDefinition {
+		fragmentTypeIsMemberOfEnclosingUnionType = definition.NodeIsUnionMember(fragmentNode, enclosingTypeDefinition)
 	}
 
-	nestedDepth, ok := f.depths.ByRef(ref)
-	if !ok {
-		f.StopWithInternalErr(fmt.Errorf("nested depth missing on depths for FragmentSpread: %s", f.operation.FragmentSpreadNameString(ref)))
-		return
-	}
-
-	precedence := asttransform.Precedence{
-		Depth: nestedDepth,
-		Order: 0,
-	}
-
-	selectionSet := f.Ancestors[len(f.Ancestors)-1].Ref
-	replaceWith := f.operation.FragmentDefinitions[fragmentDefinitionRef].SelectionSet
-	typeCondition := f.operation.FragmentDefinitions[fragmentDefinitionRef].TypeCondition
-
 	switch {
 	case fragmentTypeEqualsParentType || enclosingTypeImplementsFragmentType:
-		f.transformer.ReplaceFragmentSpread(precedence, selectionSet, ref, replaceWith)
+		return spreadInlinedAsSelections
 	case fragmentTypeImplementsEnclosingType || fragmentTypeIsMemberOfEnclosingUnionType || enclosingTypeIsMemberOfFragmentUnion || fragmentUnionIntersectsEnclosingInterface || fragmentInterfaceIntersectsEnclosingUnion:
-		f.transformer.ReplaceFragmentSpreadWithInlineFragment(precedence, selectionSet, ref, replaceWith, typeCondition)
+		return spreadInlinedAsInlineFragment
+	default:
+		return spreadNotInlined
+	}
+}
+
+func (f *fragmentSpreadInlineVisitor) recordFragmentSpread(ref int) {
+	if f.transformations == nil {
+		return
+	}
+	spreadBy := ""
+	if f.Ancestors[0].Kind == ast.NodeKindFragmentDefinition {
+		spreadBy = f.operation.FragmentDefinitionNameString(f.Ancestors[0].Ref)
 	}
+	f.transformations.recordFragmentSpread(spreadBy, f.operation.FragmentSpreadNameString(ref), f.Path.DotDelimitedString())
 }
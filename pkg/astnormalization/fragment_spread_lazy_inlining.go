@@ -0,0 +1,172 @@
+package astnormalization
+
+import (
+	"bytes"
+
+	"github.com/wundergraph/graphql-go-tools/pkg/ast"
+	"github.com/wundergraph/graphql-go-tools/pkg/asttransform"
+	"github.com/wundergraph/graphql-go-tools/pkg/astvisitor"
+	"github.com/wundergraph/graphql-go-tools/pkg/operationreport"
+)
+
+// FragmentSpreadExpander expands the fragment spreads of operations normalized with WithLazyFragmentExpansion,
+// e.g. right before the operation gets planned.
+// Other than the inlining of the normalizer it only expands the fragments reachable from the expanded operation,
+// it never inlines fragments into fragment definitions, and a fragment spread multiple times into the same
+// selection set is expanded once. Afterwards the selections are merged and the fragment definitions are removed,
+// so that the operation is the same as if it was normalized without WithLazyFragmentExpansion.
+type FragmentSpreadExpander struct {
+	expansionWalker astvisitor.Walker
+	expansion       *lazyFragmentSpreadInlineVisitor
+	mergeWalker     astvisitor.Walker
+}
+
+// NewFragmentSpreadExpander creates a FragmentSpreadExpander, it can be re-used but not concurrently
+func NewFragmentSpreadExpander() *FragmentSpreadExpander {
+	expander := &FragmentSpreadExpander{
+		expansionWalker: astvisitor.NewWalker(48),
+		mergeWalker:     astvisitor.NewWalker(48),
+	}
+
+	expander.expansion = &lazyFragmentSpreadInlineVisitor{
+		Walker:  &expander.expansionWalker,
+		spreads: make(map[fragmentSpreadTarget]int),
+	}
+	expander.expansionWalker.RegisterEnterOperationVisitor(expander.expansion)
+	expander.expansionWalker.RegisterEnterFragmentDefinitionVisitor(expander.expansion)
+	expander.expansionWalker.RegisterEnterFragmentSpreadVisitor(expander.expansion)
+	// spreads skipped by @skip or @include are removed before they are expanded, like with fragmentSpreadInline
+	directiveIncludeSkip(&expander.expansionWalker)
+
+	removeSelfAliasing(&expander.mergeWalker)
+	mergeInlineFragments(&expander.mergeWalker)
+	mergeFieldSelections(&expander.mergeWalker)
+	deduplicateFields(&expander.mergeWalker)
+	removeFragmentDefinitions(&expander.mergeWalker)
+
+	return expander
+}
+
+// ExpandNamedOperation expands the fragment spreads of the operation with the name,
+// all operations of the document are expanded if the name is empty
+func (e *FragmentSpreadExpander) ExpandNamedOperation(operation, definition *ast.Document, operationName []byte, report *operationreport.Report) {
+	e.expansion.operation = operation
+	e.expansion.definition = definition
+	e.expansion.operationName = operationName
+	e.expansion.emptySelectionSet = -1
+	for target := range e.expansion.spreads {
+		delete(e.expansion.spreads, target)
+	}
+
+	// every pass expands one more level of nested fragments,
+	// so more passes than fragment definitions mean that the fragments form a cycle
+	for pass := 0; ; pass++ {
+		if pass > len(operation.FragmentDefinitions) {
+			report.AddExternalError(operationreport.ErrFragmentSpreadFormsCycle(e.expansion.expandedSpreadName))
+			return
+		}
+
+		e.expansion.transformer.Reset()
+		e.expansion.expanded = false
+
+		e.expansionWalker.Walk(operation, definition, report)
+		if report.HasErrors() {
+			return
+		}
+
+		e.expansion.transformer.ApplyTransformations(operation)
+		if !e.expansion.expanded {
+			break
+		}
+	}
+
+	e.mergeWalker.Walk(operation, definition, report)
+}
+
+// lazyFragmentSpreadInlineVisitor expands the fragment spreads of an operation one level of nesting per pass
+type lazyFragmentSpreadInlineVisitor struct {
+	*astvisitor.Walker
+	operation, definition *ast.Document
+	operationName         []byte
+	transformer           asttransform.Transformer
+	// spreads are the refs of the fragment spreads already expanded into selection sets
+	spreads map[fragmentSpreadTarget]int
+	// emptySelectionSet replaces repeated spreads of a fragment into the same selection set, -1 until it's needed
+	emptySelectionSet int
+	// expanded reports whether the current pass expanded a fragment, expandedSpreadName is the last one
+	expanded           bool
+	expandedSpreadName ast.ByteSlice
+}
+
+type fragmentSpreadTarget struct {
+	selectionSet          int
+	fragmentDefinitionRef int
+}
+
+func (f *lazyFragmentSpreadInlineVisitor) EnterOperationDefinition(ref int) {
+	if len(f.operationName) != 0 && !bytes.Equal(f.operation.OperationDefinitionNameBytes(ref), f.operationName) {
+		f.SkipNode()
+	}
+}
+
+func (f *lazyFragmentSpreadInlineVisitor) EnterFragmentDefinition(ref int) {
+	f.SkipNode()
+}
+
+func (f *lazyFragmentSpreadInlineVisitor) EnterFragmentSpread(ref int) {
+	fragmentName := f.operation.FragmentSpreadNameBytes(ref)
+	fragmentDefinitionRef, exists := f.operation.FragmentDefinitionRef(fragmentName)
+	if !exists {
+		f.StopWithExternalErr(operationreport.ErrFragmentUndefined(fragmentName))
+		return
+	}
+
+	fragmentTypeName := f.operation.FragmentDefinitionTypeName(fragmentDefinitionRef)
+	fragmentNode, exists := f.definition.NodeByName(fragmentTypeName)
+	if !exists {
+		f.StopWithExternalErr(operationreport.ErrTypeUndefined(fragmentTypeName))
+		return
+	}
+
+	inlining := inlineFragmentSpread(f.definition, f.EnclosingTypeDefinition, fragmentNode, fragmentTypeName)
+	if inlining == spreadNotInlined {
+		return
+	}
+
+	selectionSet := f.Ancestors[len(f.Ancestors)-1].Ref
+	replaceWith := f.operation.FragmentDefinitions[fragmentDefinitionRef].SelectionSet
+	typeCondition := f.operation.FragmentDefinitions[fragmentDefinitionRef].TypeCondition
+
+	if !f.operation.FragmentSpreads[ref].HasDirectives {
+		target := fragmentSpreadTarget{selectionSet: selectionSet, fragmentDefinitionRef: fragmentDefinitionRef}
+		expandedSpreadRef, ok := f.spreads[target]
+		switch {
+		case ok && expandedSpreadRef == ref:
+			// the selection set is shared by multiple fields, the spread is already expanded
+			return
+		case ok:
+			// the selections of the fragment are merged with the ones of the first spread anyway
+			replaceWith = f.emptySelectionSetRef()
+			inlining = spreadInlinedAsSelections
+		default:
+			f.spreads[target] = ref
+		}
+	}
+
+	f.expanded = true
+	f.expandedSpreadName = fragmentName
+
+	switch inlining {
+	case spreadInlinedAsSelections:
+		f.transformer.ReplaceFragmentSpread(asttransform.Precedence{}, selectionSet, ref, replaceWith)
+	case spreadInlinedAsInlineFragment:
+		f.transformer.ReplaceFragmentSpreadWithInlineFragment(asttransform.Precedence{}, selectionSet, ref, replaceWith, typeCondition)
+	}
+}
+
+func (f *lazyFragmentSpreadInlineVisitor) emptySelectionSetRef() int {
+	if f.emptySelectionSet == -1 {
+		f.emptySelectionSet = f.operation.AddSelectionSetToDocument(ast.SelectionSet{})
+	}
+	return f.emptySelectionSet
+}
@@ -0,0 +1,181 @@
+package astnormalization
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/wundergraph/graphql-go-tools/internal/pkg/unsafeparser"
+	"github.com/wundergraph/graphql-go-tools/pkg/ast"
+	"github.com/wundergraph/graphql-go-tools/pkg/astprinter"
+	"github.com/wundergraph/graphql-go-tools/pkg/asttransform"
+	"github.com/wundergraph/graphql-go-tools/pkg/operationreport"
+)
+
+const lazyFragmentExpansionDefinition = `
+schema { query: Query }
+type Query { node: Node search: [SearchResult] }
+interface Named { name: String! }
+type Node implements Named { id: ID! name: String! child(id: ID): Node children: [Node] }
+type Other implements Named { name: String! }
+union SearchResult = Node | Other
+`
+
+// nestedFragmentsOperation returns an operation spreading depth levels of fragments, each spreading the next level
+// multiple times into the same and into nested selection sets
+func nestedFragmentsOperation(depth int) string {
+	var sb strings.Builder
+	sb.WriteString("query Q { node { ...L0 } }")
+	for i := 0; i < depth; i++ {
+		if i == depth-1 {
+			_, _ = fmt.Fprintf(&sb, " fragment L%d on Node { id name }", i)
+			continue
+		}
+		_, _ = fmt.Fprintf(&sb, " fragment L%d on Node { id ...L%d ...L%d child { ...L%d name ...L%d } children { ...L%d } }", i, i+1, i+1, i+1, i+1, i+1)
+	}
+	return sb.String()
+}
+
+func TestLazyFragmentExpansion(t *testing.T) {
+	normalize := func(t *testing.T, operation string, opts ...Option) (string, operationreport.Report) {
+		t.Helper()
+
+		definitionDocument := unsafeparser.ParseGraphqlDocumentString(lazyFragmentExpansionDefinition)
+		require.NoError(t, asttransform.MergeDefinitionWithBaseSchema(&definitionDocument))
+		operationDocument := unsafeparser.ParseGraphqlDocumentString(operation)
+
+		report := operationreport.Report{}
+		normalizer := NewWithOpts(append([]Option{WithExtractVariables(), WithRemoveFragmentDefinitions(), WithRemoveUnusedVariables()}, opts...)...)
+		normalizer.NormalizeOperation(&operationDocument, &definitionDocument, &report)
+		if report.HasErrors() {
+			return "", report
+		}
+		if normalizer.options.lazyFragmentExpansion {
+			NewFragmentSpreadExpander().ExpandNamedOperation(&operationDocument, &definitionDocument, nil, &report)
+			if report.HasErrors() {
+				return "", report
+			}
+		}
+		return mustString(astprinter.PrintString(&operationDocument, &definitionDocument)), report
+	}
+
+	assertSameAsEagerExpansion := func(t *testing.T, operation string) {
+		t.Helper()
+
+		eager, report := normalize(t, operation)
+		require.False(t, report.HasErrors(), report.Error())
+		lazy, report := normalize(t, operation, WithLazyFragmentExpansion())
+		require.False(t, report.HasErrors(), report.Error())
+		assert.Equal(t, eager, lazy)
+		assert.NotContains(t, lazy, "...L")
+	}
+
+	t.Run("nested repeated fragments", func(t *testing.T) {
+		assertSameAsEagerExpansion(t, nestedFragmentsOperation(6))
+	})
+
+	t.Run("fragments on interfaces and unions", func(t *testing.T) {
+		assertSameAsEagerExpansion(t, `
+			query Q { search { ...Result } node { ...Named } }
+			fragment Result on SearchResult { ...Named ... on Node { id ...Named } }
+			fragment Named on Named { name }`)
+	})
+
+	t.Run("fragments with skip and include", func(t *testing.T) {
+		assertSameAsEagerExpansion(t, `
+			query Q { node { ...Id @skip(if: true) ...Name @include(if: true) ...Id child { ...Id @include(if: false) } } }
+			fragment Id on Node { id }
+			fragment Name on Node { name }`)
+	})
+
+	t.Run("normalization keeps the fragments", func(t *testing.T) {
+		definitionDocument := unsafeparser.ParseGraphqlDocumentString(lazyFragmentExpansionDefinition)
+		require.NoError(t, asttransform.MergeDefinitionWithBaseSchema(&definitionDocument))
+		operationDocument := unsafeparser.ParseGraphqlDocumentString(`query Q($id: ID) { node { ...Named } } fragment Named on Node { name child(id: $id) { id } }`)
+
+		report := operationreport.Report{}
+		NewWithOpts(WithLazyFragmentExpansion(), WithRemoveFragmentDefinitions(), WithRemoveUnusedVariables()).NormalizeOperation(&operationDocument, &definitionDocument, &report)
+		require.False(t, report.HasErrors(), report.Error())
+
+		printed := mustString(astprinter.PrintString(&operationDocument, &definitionDocument))
+		assert.Contains(t, printed, "...Named")
+		assert.Contains(t, printed, "fragment Named on Node")
+		// the variable is only used by the fragment
+		assert.Contains(t, printed, "$id: ID")
+	})
+
+	t.Run("fragments of other operations are not expanded", func(t *testing.T) {
+		definitionDocument := unsafeparser.ParseGraphqlDocumentString(lazyFragmentExpansionDefinition)
+		require.NoError(t, asttransform.MergeDefinitionWithBaseSchema(&definitionDocument))
+		operationDocument := unsafeparser.ParseGraphqlDocumentString(`
+			query Q { node { ...Id } }
+			query Other { node { ...Name } }
+			fragment Id on Node { id }
+			fragment Name on Node { name }`)
+
+		report := operationreport.Report{}
+		NewFragmentSpreadExpander().ExpandNamedOperation(&operationDocument, &definitionDocument, []byte("Q"), &report)
+		require.False(t, report.HasErrors(), report.Error())
+
+		other := operationDocument.OperationDefinitions[1].SelectionSet
+		node := operationDocument.Selections[operationDocument.SelectionSets[other].SelectionRefs[0]].Ref
+		selection := operationDocument.SelectionSets[operationDocument.Fields[node].SelectionSet].SelectionRefs[0]
+		assert.Equal(t, ast.SelectionKindFragmentSpread, operationDocument.Selections[selection].Kind)
+	})
+
+	t.Run("fragment cycle", func(t *testing.T) {
+		_, report := normalize(t, `
+			query Q { node { ...A } }
+			fragment A on Node { child { ...B } }
+			fragment B on Node { child { ...A } }`, WithLazyFragmentExpansion())
+		require.True(t, report.HasErrors())
+		assert.Contains(t, report.Error(), "forms fragment cycle")
+	})
+
+	t.Run("undefined fragment", func(t *testing.T) {
+		_, report := normalize(t, `query Q { node { ...Undefined } }`, WithLazyFragmentExpansion())
+		require.True(t, report.HasErrors())
+		assert.Contains(t, report.Error(), "fragment: Undefined undefined")
+	})
+}
+
+func BenchmarkLazyFragmentExpansion(b *testing.B) {
+	definition := unsafeparser.ParseGraphqlDocumentString(lazyFragmentExpansionDefinition)
+	if err := asttransform.MergeDefinitionWithBaseSchema(&definition); err != nil {
+		b.Fatal(err)
+	}
+	operation := nestedFragmentsOperation(8)
+
+	benchmark := func(b *testing.B, opts ...Option) {
+		normalizer := NewWithOpts(append([]Option{WithExtractVariables(), WithRemoveFragmentDefinitions(), WithRemoveUnusedVariables()}, opts...)...)
+		expander := NewFragmentSpreadExpander()
+		report := operationreport.Report{}
+
+		b.ReportAllocs()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			b.StopTimer()
+			operationDocument := unsafeparser.ParseGraphqlDocumentString(operation)
+			report.Reset()
+			b.StartTimer()
+
+			normalizer.NormalizeOperation(&operationDocument, &definition, &report)
+			if normalizer.options.lazyFragmentExpansion {
+				expander.ExpandNamedOperation(&operationDocument, &definition, nil, &report)
+			}
+			if report.HasErrors() {
+				b.Fatal(report.Error())
+			}
+		}
+	}
+
+	b.Run("eager", func(b *testing.B) {
+		benchmark(b)
+	})
+	b.Run("lazy", func(b *testing.B) {
+		benchmark(b, WithLazyFragmentExpansion())
+	})
+}
@@ -26,6 +26,10 @@ func (m *mergeInlineFragmentsVisitor) EnterDocument(operation, definition *ast.D
 }
 
 func (m *mergeInlineFragmentsVisitor) couldInline(set, inlineFragment int) bool {
+	if !m.operation.InlineFragments[inlineFragment].HasSelections {
+		// the selection set of an empty inline fragment is not set, it's left for validation
+		return false
+	}
 	if m.operation.InlineFragmentHasDirectives(inlineFragment) {
 		return false
 	}
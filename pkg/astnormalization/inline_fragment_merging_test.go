@@ -53,4 +53,20 @@ func TestResolveInlineFragments(t *testing.T) {
 						}
 					}`)
 	})
+	t.Run("empty inline fragment", func(t *testing.T) {
+		run(mergeInlineFragments, testDefinition, `
+					query emptyInlineFragment {
+						dog {
+							name
+							... {}
+						}
+					}`,
+			`
+					query emptyInlineFragment {
+						dog {
+							name
+							... {}
+						}
+					}`)
+	})
 }
@@ -193,6 +193,10 @@ func (i *inputCoercionForListVisitor) walkJsonObject(inputObjDefTypeRef int, dat
 		defer i.popQuery()
 
 		inputValueDefRef := i.definition.InputObjectTypeDefinitionInputValueDefinitionByName(inputObjDefTypeRef, key)
+		if inputValueDefRef == ast.InvalidRef {
+			// unknown fields are reported by the validation of the variables
+			return nil
+		}
 		typeRef := i.definition.ResolveListOrNameType(i.definition.InputValueDefinitionType(inputValueDefRef))
 
 		switch i.definition.Types[typeRef].TypeKind {
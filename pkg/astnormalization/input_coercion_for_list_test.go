@@ -649,6 +649,81 @@ func TestInputCoercionForList(t *testing.T) {
 			}`, `{"ids":[1]}`, `{"ids":[[1]]}`)
 	})
 
+	t.Run("convert list of integers to nested list of integers", func(t *testing.T) {
+		runWithVariablesAssert(t, inputCoercionForList, inputCoercionForListDefinition, `
+			query ($ids: [[Int]]) {
+			  nestedList(ids: $ids) {
+				id
+			  }
+			}`, ``,
+			`
+			query ($ids: [[Int]]) {
+			  nestedList(ids: $ids) {
+				id
+			  }
+			}`, `{"ids":[1,2]}`, `{"ids":[[1],[2]]}`)
+	})
+
+	t.Run("do not wrap null items of nested list", func(t *testing.T) {
+		runWithVariablesAssert(t, inputCoercionForList, inputCoercionForListDefinition, `
+			query ($ids: [[Int]]) {
+			  nestedList(ids: $ids) {
+				id
+			  }
+			}`, ``,
+			`
+			query ($ids: [[Int]]) {
+			  nestedList(ids: $ids) {
+				id
+			  }
+			}`, `{"ids":[1,null,[2]]}`, `{"ids":[[1],null,[2]]}`)
+	})
+
+	t.Run("do not wrap null items of non-null nested list", func(t *testing.T) {
+		runWithVariablesAssert(t, inputCoercionForList, inputCoercionForListDefinition, `
+			query ($ids: [[Int!]!]!) {
+			  nestedListNonNull(ids: $ids) {
+				id
+			  }
+			}`, ``,
+			`
+			query ($ids: [[Int!]!]!) {
+			  nestedListNonNull(ids: $ids) {
+				id
+			  }
+			}`, `{"ids":[null]}`, `{"ids":[null]}`)
+	})
+
+	t.Run("do not wrap null variable of non-null list", func(t *testing.T) {
+		runWithVariablesAssert(t, inputCoercionForList, inputCoercionForListDefinition, `
+			query ($ids: [Int!]!) {
+			  charactersByIdsNonNullInteger(ids: $ids) {
+				id
+			  }
+			}`, ``,
+			`
+			query ($ids: [Int!]!) {
+			  charactersByIdsNonNullInteger(ids: $ids) {
+				id
+			  }
+			}`, `{"ids":null}`, `{"ids":null}`)
+	})
+
+	t.Run("ignore unknown fields of input object", func(t *testing.T) {
+		runWithVariablesAssert(t, inputCoercionForList, inputCoercionForListDefinition, `
+			query ($input: InputWithList) {
+			  inputWithList(input: $input) {
+				id
+			  }
+			}`, ``,
+			`
+			query ($input: InputWithList) {
+			  inputWithList(input: $input) {
+				id
+			  }
+			}`, `{"input":{"unknown":1,"list":{"foo":"bar"}}}`, `{"input":{"unknown":1,"list":[{"foo":"bar"}]}}`)
+	})
+
 	t.Run("send inline null to charactersByIdsNonNull", func(t *testing.T) {
 		runWithVariables(t, extractVariables, inputCoercionForListDefinition, `
 			query {
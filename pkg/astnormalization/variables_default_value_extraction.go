@@ -32,6 +32,7 @@ type variablesDefaultValueExtractionVisitor struct {
 	skip                      bool
 	nonNullableVariablesNames [][]byte
 	extractedVariablesRefs    []int
+	transformations           *transformationRecorder
 }
 
 func (v *variablesDefaultValueExtractionVisitor) EnterField(ref int) {
@@ -100,16 +101,12 @@ func (v *variablesDefaultValueExtractionVisitor) EnterVariableDefinition(ref int
 		v.StopWithInternalErr(err)
 		return
 	}
+	v.transformations.record(TransformationApplyVariableDefaultValue, variableName, "")
 }
 
 func (v *variablesDefaultValueExtractionVisitor) EnterOperationDefinition(ref int) {
-	if len(v.operationName) == 0 {
-		v.skip = false
-		return
-	}
-	operationName := v.operation.OperationDefinitionNameBytes(ref)
 	v.operationRef = ref
-	v.skip = !bytes.Equal(operationName, v.operationName)
+	v.skip = len(v.operationName) != 0 && !bytes.Equal(v.operation.OperationDefinitionNameBytes(ref), v.operationName)
 
 	v.nonNullableVariablesNames = make([][]byte, 0, len(v.operation.VariableDefinitions))
 	v.extractedVariablesRefs = make([]int, 0, len(v.operation.VariableDefinitions))
@@ -266,4 +266,38 @@ func TestVariablesDefaultValueExtraction(t *testing.T) {
 			}`, `{"a":"aaa"}`, `{"d":"bar","c":"foo","b":"bazz","a":"aaa"}`)
 
 	})
+
+	t.Run("multiple operations", func(t *testing.T) {
+		t.Run("default values are added to the operation of the field", func(t *testing.T) {
+			runWithVariablesDefaultValues(t, extractVariablesDefaultValue, variablesDefaultValueExtractionDefinition, `
+				query q {
+					mixedArgs(b: "b")
+				}
+				mutation simple {
+					simple
+				}`, "", `
+				query q {
+					mixedArgs(b: "b")
+				}
+				mutation simple($a: String) {
+					simple(input: $a)
+				}`, ``, `{"a":"foo"}`)
+		})
+
+		t.Run("only the named operation is processed", func(t *testing.T) {
+			runWithVariablesDefaultValues(t, extractVariablesDefaultValue, variablesDefaultValueExtractionDefinition, `
+				mutation other {
+					simple
+				}
+				mutation simple {
+					simple
+				}`, "simple", `
+				mutation other {
+					simple
+				}
+				mutation simple($a: String) {
+					simple(input: $a)
+				}`, ``, `{"a":"foo"}`)
+		})
+	})
 }
@@ -26,6 +26,7 @@ type deleteUnusedVariablesVisitor struct {
 	definedVariables      []int
 	operationName         []byte
 	skip                  bool
+	transformations       *transformationRecorder
 }
 
 func (d *deleteUnusedVariablesVisitor) LeaveOperationDefinition(ref int) {
@@ -36,6 +37,7 @@ func (d *deleteUnusedVariablesVisitor) LeaveOperationDefinition(ref int) {
 				d.operation.OperationDefinitions[ref].VariableDefinitions.Refs = append(d.operation.OperationDefinitions[ref].VariableDefinitions.Refs[:i], d.operation.OperationDefinitions[ref].VariableDefinitions.Refs[i+1:]...)
 				d.operation.Input.Variables = jsonparser.Delete(d.operation.Input.Variables, variableName)
 				d.operation.OperationDefinitions[ref].HasVariableDefinitions = len(d.operation.OperationDefinitions[ref].VariableDefinitions.Refs) != 0
+				d.transformations.record(TransformationRemoveUnusedVariable, variableName, "")
 			}
 		}
 
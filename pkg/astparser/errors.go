@@ -51,3 +51,12 @@ func (e ErrUnexpectedIdentKey) Error() string {
 
 	return fmt.Sprintf("unexpected ident - keyword: '%s' literal: '%s' - expected: '%s' position: '%s'%s", e.keyword, e.literal, e.expected, e.position, origins)
 }
+
+// errRecoveredPanic is reported as an internal error if the parser panics on malformed input
+type errRecoveredPanic struct {
+	value interface{}
+}
+
+func (e errRecoveredPanic) Error() string {
+	return fmt.Sprintf("recovered from panic while parsing: %v", e.value)
+}
@@ -4,6 +4,7 @@ package astparser
 import (
 	"fmt"
 	"runtime"
+	"unicode/utf8"
 
 	"github.com/wundergraph/graphql-go-tools/internal/pkg/unsafebytes"
 	"github.com/wundergraph/graphql-go-tools/pkg/ast"
@@ -36,6 +37,13 @@ func ParseGraphqlDocumentBytes(input []byte) (ast.Document, operationreport.Repo
 	return doc, report
 }
 
+const (
+	// DefaultMaxDepth is the default max nesting depth of selection sets, list and object values and list types
+	DefaultMaxDepth = 256
+	// DefaultMaxTokens is the default max number of tokens of a document
+	DefaultMaxTokens = 1 << 20
+)
+
 // Parser takes a raw input and turns it into an AST
 // use NewParser() to create a parser
 // Don't create new parsers in the hot path, re-use them.
@@ -45,6 +53,9 @@ type Parser struct {
 	tokenizer            *Tokenizer
 	shouldIndex          bool
 	reportInternalErrors bool
+	// depth is the current nesting depth, maxDepth is 0 if the depth is unlimited
+	depth    int
+	maxDepth int
 }
 
 // NewParser returns a new parser with all values properly initialized
@@ -53,21 +64,46 @@ func NewParser() *Parser {
 		tokenizer:            NewTokenizer(),
 		shouldIndex:          true,
 		reportInternalErrors: false,
+		maxDepth:             DefaultMaxDepth,
 	}
 }
 
+// SetMaxDepth limits the nesting depth of selection sets, list and object values and list types,
+// documents nested deeper are rejected with an error instead of exhausting the stack. 0 disables the limit.
+func (p *Parser) SetMaxDepth(maxDepth int) {
+	p.maxDepth = maxDepth
+}
+
+// SetMaxTokens limits the number of tokens of a document, larger documents are rejected with an error
+// instead of growing the tokens without bounds. 0 disables the limit.
+func (p *Parser) SetMaxTokens(maxTokens int) {
+	p.tokenizer.tokenLimit = maxTokens
+}
+
 // PrepareImport prepares the Parser for importing new Nodes into an AST without directly parsing the content
 func (p *Parser) PrepareImport(document *ast.Document, report *operationreport.Report) {
 	p.document = document
 	p.report = report
+	p.depth = 0
 	p.tokenize()
 }
 
 // Parse parses all input in a Document.Input into the Document
+// Malformed input is reported as an error, the parser never panics on it.
 func (p *Parser) Parse(document *ast.Document, report *operationreport.Report) {
 	p.document = document
 	p.report = report
+	p.depth = 0
+	defer p.recoverPanic()
+	if !utf8.Valid(document.Input.RawBytes) {
+		p.errInvalidEncoding()
+		return
+	}
 	p.tokenize()
+	if p.tokenizer.limitExceeded {
+		p.errMaxTokensExceeded()
+		return
+	}
 	p.parse()
 }
 
@@ -133,6 +169,81 @@ func (p *Parser) identKeywordSliceRef(ref ast.ByteSliceReference) identkeyword.I
 	return identkeyword.KeywordFromLiteral(p.document.Input.ByteSlice(ref))
 }
 
+// errInvalidEncoding reports the location of the first byte which is not part of a valid UTF-8 sequence.
+// Without this check the lexer would produce a cryptic unexpected token error somewhere in the invalid sequence.
+func (p *Parser) errInvalidEncoding() {
+	line, column := 1, 1
+	input := p.document.Input.RawBytes
+	for len(input) != 0 {
+		r, size := utf8.DecodeRune(input)
+		if r == utf8.RuneError && size <= 1 {
+			break
+		}
+		if r == '\n' {
+			line++
+			column = 1
+		} else {
+			column++
+		}
+		input = input[size:]
+	}
+
+	p.report.AddExternalError(operationreport.ExternalError{
+		Message: "document is not valid UTF-8",
+		Locations: []graphqlerrors.Location{
+			{
+				Line:   uint32(line),
+				Column: uint32(column),
+			},
+		},
+	})
+}
+
+// recoverPanic reports a panic while parsing as an error, it's the last line of defense against malformed input
+func (p *Parser) recoverPanic() {
+	recovered := recover()
+	if recovered == nil {
+		return
+	}
+	p.report.AddExternalError(operationreport.ExternalError{
+		Message: "document could not be parsed",
+	})
+	p.report.AddInternalError(errRecoveredPanic{value: recovered})
+}
+
+func (p *Parser) errMaxTokensExceeded() {
+	p.report.AddExternalError(operationreport.ExternalError{
+		Message: fmt.Sprintf("document exceeds the max number of tokens of %d", p.tokenizer.tokenLimit),
+	})
+}
+
+// enterNesting increases the nesting depth, it reports an error and returns false if the depth exceeds the max depth.
+// Every call must be followed by a call of leaveNesting, regardless of the result.
+func (p *Parser) enterNesting() bool {
+	p.depth++
+	if p.maxDepth == 0 || p.depth <= p.maxDepth {
+		return true
+	}
+	if p.report.HasErrors() {
+		return false
+	}
+	next := p.tokenizer.Peek()
+	p.report.AddExternalError(operationreport.ExternalError{
+		Message: fmt.Sprintf("document exceeds the max nesting depth of %d", p.maxDepth),
+		Locations: []graphqlerrors.Location{
+			{
+				Line:   next.TextPosition.LineStart,
+				Column: next.TextPosition.CharStart,
+			},
+		},
+	})
+	return false
+}
+
+func (p *Parser) leaveNesting() {
+	p.depth--
+}
+
 func (p *Parser) errUnexpectedIdentKey(unexpected token.Token, unexpectedKey identkeyword.IdentKeyword, expectedKeywords ...identkeyword.IdentKeyword) {
 
 	if p.report.HasErrors() {
@@ -280,6 +391,7 @@ func (p *Parser) parseRootOperationTypeDefinitionList(list *ast.RootOperationTyp
 				NamedType: ast.Type{
 					TypeKind: ast.TypeKindNamed,
 					Name:     namedType.Literal,
+					Position: namedType.TextPosition,
 					OfType:   ast.InvalidRef,
 				},
 			}
@@ -463,6 +575,11 @@ func (p *Parser) ParseValue() (value ast.Value) {
 }
 
 func (p *Parser) parseObjectValue() (ref int, pos position.Position) {
+	defer p.leaveNesting()
+	if !p.enterNesting() {
+		return ast.InvalidRef, position.Position{}
+	}
+
 	var objectValue ast.ObjectValue
 	objectValue.LBRACE = p.mustRead(keyword.LBRACE).TextPosition
 
@@ -503,6 +620,11 @@ func (p *Parser) parseObjectField() int {
 }
 
 func (p *Parser) parseValueList() int {
+	defer p.leaveNesting()
+	if !p.enterNesting() {
+		return ast.InvalidRef
+	}
+
 	var list ast.ListValue
 	list.LBRACK = p.mustRead(keyword.LBRACK).TextPosition
 
@@ -851,6 +973,10 @@ func (p *Parser) ParseType() (ref int) {
 		tok := p.read()
 		ref = p.document.AddNamedTypeWithPosition(tok.Literal, tok.TextPosition)
 	} else if first == keyword.LBRACK {
+		defer p.leaveNesting()
+		if !p.enterNesting() {
+			return ast.InvalidRef
+		}
 
 		openList := p.read()
 		ofType := p.ParseType()
@@ -1271,6 +1397,10 @@ func (p *Parser) parseDirectiveLocations(locations *ast.DirectiveLocations) {
 }
 
 func (p *Parser) parseSelectionSet() (int, bool) {
+	defer p.leaveNesting()
+	if !p.enterNesting() {
+		return ast.InvalidRef, false
+	}
 
 	var set ast.SelectionSet
 
@@ -1610,7 +1740,10 @@ func (p *Parser) parseSchemaExtension(extend position.Position) {
 		schemaDefinition.Directives = p.parseDirectiveList()
 		schemaDefinition.HasDirectives = len(schemaDefinition.Directives.Refs) > 0
 	}
-	p.parseRootOperationTypeDefinitionList(&schemaDefinition.RootOperationTypeDefinitions)
+	// an extension might only add directives, e.g. extend schema @link(url: "...")
+	if !schemaDefinition.HasDirectives || p.peekEquals(keyword.LBRACE) {
+		p.parseRootOperationTypeDefinitionList(&schemaDefinition.RootOperationTypeDefinitions)
+	}
 
 	schemaExtension := ast.SchemaExtension{
 		ExtendLiteral:    extend,
@@ -0,0 +1,54 @@
+package astparser
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/wundergraph/graphql-go-tools/pkg/ast"
+	"github.com/wundergraph/graphql-go-tools/pkg/operationreport"
+)
+
+func FuzzParse(f *testing.F) {
+	seeds := []string{
+		"{",
+		"}}}",
+		"{ a { b { c }",
+		"query { a(b: [[[{c: [1, {d: $e}]}",
+		`{ a(b: "unterminated) }`,
+		`{ a(b: """unterminated block) }`,
+		"fragment on on on { ... on }",
+		"type Query { a: [[[String!]!]! }",
+		"type Query { a: [[[String!!] }",
+		"extend extend schema @a(b: -) { query: }",
+		"directive @a(b: [[Int!]]! = [[1]]) repeatable on FIELD | | QUERY",
+		"query ($a: [[Int]!] = [[1, null]]) @a(b: {}) { ...a ...on A @b { c } }",
+		"\"\"\"description\"\"\" enum A { B C } union D = | E | F input G { h: [I!] = {} }",
+		strings.Repeat("{a", 1000),
+		strings.Repeat("[", 1000),
+		strings.Repeat("a ", 1000),
+	}
+	for _, seed := range seeds {
+		f.Add(seed)
+	}
+
+	parser := NewParser()
+	parser.SetMaxTokens(1 << 14)
+	doc := ast.NewDocument()
+	report := operationreport.Report{}
+
+	f.Fuzz(func(t *testing.T, input string) {
+		doc.Reset()
+		doc.Input.ResetInputString(input)
+		report.Reset()
+
+		parser.Parse(doc, &report)
+
+		for _, err := range report.InternalErrors {
+			var recovered errRecoveredPanic
+			if errors.As(err, &recovered) {
+				t.Fatalf("parser panicked on input %q: %s", input, err)
+			}
+		}
+	})
+}
@@ -3,6 +3,7 @@ package astparser
 import (
 	"fmt"
 	"io/ioutil"
+	"strings"
 	"testing"
 
 	"github.com/wundergraph/graphql-go-tools/pkg/ast"
@@ -2264,6 +2265,80 @@ func TestErrorReport(t *testing.T) {
 			t.Fatalf("want:\n%s\ngot:\n%s\n", want, report.Error())
 		}
 	})
+	t.Run("invalid UTF-8", func(t *testing.T) {
+		_, report := ParseGraphqlDocumentString("{\n  me {\n    name\xC3\x28\n  }\n}")
+
+		if !report.HasErrors() {
+			t.Fatalf("want err, got nil")
+		}
+
+		want := "external: document is not valid UTF-8, locations: [{Line:3 Column:9}], path: []"
+		if report.Error() != want {
+			t.Fatalf("want:\n%s\ngot:\n%s\n", want, report.Error())
+		}
+	})
+	t.Run("selection sets exceed max depth", func(t *testing.T) {
+		_, report := ParseGraphqlDocumentString(strings.Repeat("{a", 300) + strings.Repeat("}", 300))
+
+		want := "external: document exceeds the max nesting depth of 256, locations: [{Line:1 Column:513}], path: []"
+		if report.Error() != want {
+			t.Fatalf("want:\n%s\ngot:\n%s\n", want, report.Error())
+		}
+	})
+	t.Run("list values exceed max depth", func(t *testing.T) {
+		_, report := ParseGraphqlDocumentString("{a(b:" + strings.Repeat("[", 100000) + ")}")
+
+		want := "external: document exceeds the max nesting depth of 256"
+		if !strings.HasPrefix(report.Error(), want) {
+			t.Fatalf("want prefix:\n%s\ngot:\n%s\n", want, report.Error())
+		}
+	})
+	t.Run("list types exceed max depth", func(t *testing.T) {
+		_, report := ParseGraphqlDocumentString("type Query { a: " + strings.Repeat("[", 100000) + " }")
+
+		want := "external: document exceeds the max nesting depth of 256"
+		if !strings.HasPrefix(report.Error(), want) {
+			t.Fatalf("want prefix:\n%s\ngot:\n%s\n", want, report.Error())
+		}
+	})
+	t.Run("unlimited depth", func(t *testing.T) {
+		parser := NewParser()
+		parser.SetMaxDepth(0)
+		doc := ast.NewDocument()
+		doc.Input.ResetInputString(strings.Repeat("{a", 300) + strings.Repeat("}", 300))
+		report := operationreport.Report{}
+		parser.Parse(doc, &report)
+
+		if report.HasErrors() {
+			t.Fatalf("want nil, got report: %s", report.Error())
+		}
+	})
+	t.Run("exceeds max tokens", func(t *testing.T) {
+		parser := NewParser()
+		parser.SetMaxTokens(3)
+		doc := ast.NewDocument()
+		doc.Input.ResetInputString("{ a b }")
+		report := operationreport.Report{}
+		parser.Parse(doc, &report)
+
+		want := "external: document exceeds the max number of tokens of 3, locations: [], path: []"
+		if report.Error() != want {
+			t.Fatalf("want:\n%s\ngot:\n%s\n", want, report.Error())
+		}
+	})
+}
+
+func TestParseByteOrderMark(t *testing.T) {
+	doc, report := ParseGraphqlDocumentString("\xEF\xBB\xBFquery Me { me { name } }")
+	if report.HasErrors() {
+		t.Fatalf("want nil, got report: %s", report.Error())
+	}
+	if len(doc.OperationDefinitions) != 1 {
+		t.Fatalf("want 1 operation, got: %d", len(doc.OperationDefinitions))
+	}
+	if name := doc.OperationDefinitionNameString(0); name != "Me" {
+		t.Fatalf("want operation name Me, got: %s", name)
+	}
 }
 
 func TestParseStarwars(t *testing.T) {
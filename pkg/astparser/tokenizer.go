@@ -14,6 +14,10 @@ type Tokenizer struct {
 	maxTokens    int
 	currentToken int
 	skipComments bool
+	// tokenLimit is the max number of tokens, 0 if it's unlimited
+	tokenLimit int
+	// limitExceeded indicates that the input got truncated after tokenLimit tokens
+	limitExceeded bool
 }
 
 // NewTokenizer returns a new tokenizer
@@ -22,22 +26,31 @@ func NewTokenizer() *Tokenizer {
 		tokens:       make([]token.Token, 256),
 		lexer:        &lexer.Lexer{},
 		skipComments: true,
+		tokenLimit:   DefaultMaxTokens,
 	}
 }
 
+// Tokenize reads all tokens of the input. If the input has more tokens than the limit,
+// only the tokens up to the limit are read and limitExceeded is set.
 func (t *Tokenizer) Tokenize(input *ast.Input) {
 	t.lexer.SetInput(input)
 	t.tokens = t.tokens[:0]
+	t.limitExceeded = false
 
 	for {
 		next := t.lexer.Read()
 		if next.Keyword == keyword.EOF {
-			t.maxTokens = len(t.tokens)
-			t.currentToken = -1
-			return
+			break
+		}
+		if t.tokenLimit != 0 && len(t.tokens) == t.tokenLimit {
+			t.limitExceeded = true
+			break
 		}
 		t.tokens = append(t.tokens, next)
 	}
+
+	t.maxTokens = len(t.tokens)
+	t.currentToken = -1
 }
 
 // hasNextToken - checks that we haven't reached eof
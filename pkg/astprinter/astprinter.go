@@ -175,6 +175,11 @@ func (p *printVisitor) LeaveDirective(ref int) {
 		ast.NodeKindFieldDefinition,
 		ast.NodeKindInputValueDefinition:
 		return
+	case ast.NodeKindSchemaExtension:
+		if len(p.document.SchemaExtensions[ancestor.Ref].RootOperationTypeDefinitions.Refs) == 0 {
+			return
+		}
+		p.write(literal.SPACE)
 	default:
 		p.write(literal.SPACE)
 	}
@@ -972,10 +977,12 @@ func (p *printVisitor) EnterSchemaExtension(ref int) {
 }
 
 func (p *printVisitor) LeaveSchemaExtension(ref int) {
-	if p.indent != nil {
-		p.write(literal.LINETERMINATOR)
+	if len(p.document.SchemaExtensions[ref].RootOperationTypeDefinitions.Refs) != 0 {
+		if p.indent != nil {
+			p.write(literal.LINETERMINATOR)
+		}
+		p.write(literal.RBRACE)
 	}
-	p.write(literal.RBRACE)
 	if !p.document.NodeIsLastRootNode(ast.Node{Kind: ast.NodeKindSchemaExtension, Ref: ref}) {
 		if p.indent != nil {
 			p.write(literal.LINETERMINATOR)
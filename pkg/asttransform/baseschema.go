@@ -29,6 +29,7 @@ func handleSchema(definition *ast.Document) error {
 	}
 
 	addSchemaDefinition(definition)
+	mergeSchemaExtensions(definition)
 	addMissingRootOperationTypeDefinitions(definition)
 	addIntrospectionQueryFields(definition, queryNodeRef)
 
@@ -46,6 +47,27 @@ func addSchemaDefinition(definition *ast.Document) {
 	definition.AddSchemaDefinitionRootNode(schemaDefinition)
 }
 
+// mergeSchemaExtensions moves root operation types and directives of schema extensions,
+// e.g. `extend schema { query: RootQuery }`, into the schema definition
+func mergeSchemaExtensions(definition *ast.Document) {
+	schemaDefinitionRef := definition.SchemaDefinitionRef()
+	rootNodes := definition.RootNodes[:0]
+	for _, node := range definition.RootNodes {
+		if node.Kind != ast.NodeKindSchemaExtension {
+			rootNodes = append(rootNodes, node)
+			continue
+		}
+
+		extension := definition.SchemaExtensions[node.Ref]
+		definition.SchemaDefinitions[schemaDefinitionRef].AddRootOperationTypeDefinitionRefs(extension.RootOperationTypeDefinitions.Refs...)
+		if extension.HasDirectives {
+			definition.SchemaDefinitions[schemaDefinitionRef].HasDirectives = true
+			definition.SchemaDefinitions[schemaDefinitionRef].Directives.Refs = append(definition.SchemaDefinitions[schemaDefinitionRef].Directives.Refs, extension.Directives.Refs...)
+		}
+	}
+	definition.RootNodes = rootNodes
+}
+
 func addMissingRootOperationTypeDefinitions(definition *ast.Document) {
 	var rootOperationTypeRefs []int
 
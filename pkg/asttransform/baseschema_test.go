@@ -109,6 +109,14 @@ func TestMergeDefinitionWithBaseSchema(t *testing.T) {
 				adminInformation: String!
 			}
 	`, "custom_query_name"))
+	t.Run("custom query type name via schema extension", runTestMerge(`
+			extend schema {
+				query: query_root
+			}
+			type query_root {
+				hello(name: String): String!
+			}
+	`, "custom_query_name_schema_extension"))
 	t.Run("complete", runTestMerge(`
 			schema {
 				query: Query
@@ -0,0 +1,131 @@
+package asttransform
+
+import (
+	"strings"
+
+	"github.com/wundergraph/graphql-go-tools/pkg/ast"
+)
+
+const linkDirectiveName = "link"
+
+// ResolveLinkImports renames the directives brought into scope by the @link directives of the schema definitions and
+// extensions of a subgraph SDL to the names of their specification, so they are recognized regardless of how the
+// subgraph imported them. E.g. for
+//
+//	extend schema @link(url: "https://specs.apollo.dev/federation/v2.0", import: [{ name: "@key", as: "@primaryKey" }])
+//
+// both @primaryKey and the namespaced @federation__key are renamed to @key.
+// The namespace of a link is its "as" argument or the name of the specification in its url.
+func ResolveLinkImports(document *ast.Document) {
+	renames := make(map[string]string)
+	namespaces := make([]string, 0, 1)
+
+	collect := func(directives ast.DirectiveList) {
+		for _, directiveRef := range directives.Refs {
+			if document.DirectiveNameString(directiveRef) != linkDirectiveName {
+				continue
+			}
+			namespace := linkNamespace(document, directiveRef)
+			if namespace != "" {
+				namespaces = append(namespaces, namespace)
+			}
+			collectLinkImports(document, directiveRef, renames)
+		}
+	}
+	for i := range document.SchemaDefinitions {
+		collect(document.SchemaDefinitions[i].Directives)
+	}
+	for i := range document.SchemaExtensions {
+		collect(document.SchemaExtensions[i].Directives)
+	}
+	if len(renames) == 0 && len(namespaces) == 0 {
+		return
+	}
+
+	resolve := func(name string) (string, bool) {
+		if original, ok := renames[name]; ok {
+			return original, true
+		}
+		for _, namespace := range namespaces {
+			if strings.HasPrefix(name, namespace+"__") {
+				return strings.TrimPrefix(name, namespace+"__"), true
+			}
+		}
+		return "", false
+	}
+
+	for i := range document.Directives {
+		if original, ok := resolve(document.DirectiveNameString(i)); ok {
+			document.Directives[i].Name = document.Input.AppendInputString(original)
+		}
+	}
+	for i := range document.DirectiveDefinitions {
+		if original, ok := resolve(document.DirectiveDefinitionNameString(i)); ok {
+			document.DirectiveDefinitions[i].Name = document.Input.AppendInputString(original)
+		}
+	}
+}
+
+// linkNamespace returns the "as" argument of a @link directive, or the name of the linked specification,
+// e.g. "federation" for the url "https://specs.apollo.dev/federation/v2.0"
+func linkNamespace(document *ast.Document, directiveRef int) string {
+	if as, ok := linkStringArgument(document, directiveRef, "as"); ok {
+		return strings.TrimPrefix(as, "@")
+	}
+	url, ok := linkStringArgument(document, directiveRef, "url")
+	if !ok {
+		return ""
+	}
+	segments := strings.Split(strings.TrimRight(url, "/"), "/")
+	name := segments[len(segments)-1]
+	if isLinkVersion(name) && len(segments) > 1 {
+		name = segments[len(segments)-2]
+	}
+	return name
+}
+
+// isLinkVersion reports whether the url segment is the version of the linked specification, e.g. "v2.0"
+func isLinkVersion(segment string) bool {
+	return len(segment) > 1 && segment[0] == 'v' && segment[1] >= '0' && segment[1] <= '9'
+}
+
+// collectLinkImports maps the aliases of the directives imported by a @link directive to their original names,
+// types of the import argument are left untouched
+func collectLinkImports(document *ast.Document, directiveRef int, renames map[string]string) {
+	imports, ok := document.DirectiveArgumentValueByName(directiveRef, []byte("import"))
+	if !ok || imports.Kind != ast.ValueKindList {
+		return
+	}
+	for _, valueRef := range document.ListValues[imports.Ref].Refs {
+		value := document.Values[valueRef]
+		if value.Kind != ast.ValueKindObject {
+			// an import without alias keeps the name of the specification
+			continue
+		}
+		var name, as string
+		for _, fieldRef := range document.ObjectValues[value.Ref].Refs {
+			fieldValue := document.ObjectFieldValue(fieldRef)
+			if fieldValue.Kind != ast.ValueKindString {
+				continue
+			}
+			switch document.ObjectFieldNameString(fieldRef) {
+			case "name":
+				name = document.StringValueContentString(fieldValue.Ref)
+			case "as":
+				as = document.StringValueContentString(fieldValue.Ref)
+			}
+		}
+		if !strings.HasPrefix(name, "@") || !strings.HasPrefix(as, "@") || name == as {
+			continue
+		}
+		renames[strings.TrimPrefix(as, "@")] = strings.TrimPrefix(name, "@")
+	}
+}
+
+func linkStringArgument(document *ast.Document, directiveRef int, name string) (string, bool) {
+	value, ok := document.DirectiveArgumentValueByName(directiveRef, []byte(name))
+	if !ok || value.Kind != ast.ValueKindString {
+		return "", false
+	}
+	return document.StringValueContentString(value.Ref), true
+}
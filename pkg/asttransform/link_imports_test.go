@@ -0,0 +1,51 @@
+package asttransform
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/wundergraph/graphql-go-tools/internal/pkg/unsafeparser"
+)
+
+func TestResolveLinkImports(t *testing.T) {
+	run := func(t *testing.T, definition string, expectedDirectiveNames ...string) {
+		t.Helper()
+		doc := unsafeparser.ParseGraphqlDocumentString(definition)
+		ResolveLinkImports(&doc)
+
+		directiveNames := make([]string, 0, len(doc.Directives))
+		for i := range doc.Directives {
+			directiveNames = append(directiveNames, doc.DirectiveNameString(i))
+		}
+		assert.Equal(t, expectedDirectiveNames, directiveNames)
+	}
+
+	t.Run("aliased import", func(t *testing.T) {
+		run(t, `
+			extend schema @link(url: "https://specs.apollo.dev/federation/v2.0", import: [{ name: "@key", as: "@primaryKey" }, "@shareable"])
+			type User @primaryKey(fields: "id") @shareable { id: ID! }`,
+			"link", "key", "shareable")
+	})
+
+	t.Run("namespaced directive of the specification name", func(t *testing.T) {
+		run(t, `
+			extend schema @link(url: "https://specs.apollo.dev/federation/v2.0", import: ["@shareable"])
+			type User @federation__key(fields: "id") { id: ID! @federation__external }`,
+			"link", "key", "external")
+	})
+
+	t.Run("namespaced directive of a custom namespace", func(t *testing.T) {
+		run(t, `
+			schema @link(url: "https://specs.apollo.dev/federation/v2.0", as: "fed") { query: Query }
+			type Query { me: User }
+			type User @fed__key(fields: "id") @federation__key(fields: "id") { id: ID! }`,
+			"link", "key", "federation__key")
+	})
+
+	t.Run("without @link directives are unchanged", func(t *testing.T) {
+		run(t, `
+			type User @primaryKey(fields: "id") @federation__key(fields: "id") { id: ID! }`,
+			"primaryKey", "federation__key")
+	})
+}
@@ -0,0 +1,71 @@
+package astvalidation
+
+import (
+	"github.com/wundergraph/graphql-go-tools/pkg/ast"
+	"github.com/wundergraph/graphql-go-tools/pkg/astvisitor"
+	"github.com/wundergraph/graphql-go-tools/pkg/operationreport"
+)
+
+// MaxRootFields validates that operations don't select more than maxRootFields top-level fields
+// Fields selected by inline fragments and fragment spreads on the root level are counted as well
+func MaxRootFields(maxRootFields int) Rule {
+	return func(walker *astvisitor.Walker) {
+		visitor := maxRootFieldsVisitor{
+			Walker:        walker,
+			maxRootFields: maxRootFields,
+		}
+		walker.RegisterEnterOperationVisitor(&visitor)
+		walker.RegisterEnterDocumentVisitor(&visitor)
+	}
+}
+
+type maxRootFieldsVisitor struct {
+	*astvisitor.Walker
+	operation        *ast.Document
+	maxRootFields    int
+	visitedFragments map[int]struct{}
+}
+
+func (m *maxRootFieldsVisitor) EnterDocument(operation, definition *ast.Document) {
+	m.operation = operation
+}
+
+func (m *maxRootFieldsVisitor) EnterOperationDefinition(ref int) {
+	if !m.operation.OperationDefinitions[ref].HasSelections {
+		return
+	}
+
+	m.visitedFragments = map[int]struct{}{}
+	rootFields := m.countFields(m.operation.OperationDefinitions[ref].SelectionSet)
+	if rootFields > m.maxRootFields {
+		operationName := m.operation.OperationDefinitionNameBytes(ref)
+		m.StopWithExternalErr(operationreport.ErrOperationExceedsMaxRootFields(operationName, rootFields, m.maxRootFields))
+	}
+}
+
+func (m *maxRootFieldsVisitor) countFields(set int) (count int) {
+	for _, selectionRef := range m.operation.SelectionSets[set].SelectionRefs {
+		selection := m.operation.Selections[selectionRef]
+		switch selection.Kind {
+		case ast.SelectionKindField:
+			count++
+		case ast.SelectionKindInlineFragment:
+			if m.operation.InlineFragments[selection.Ref].HasSelections {
+				count += m.countFields(m.operation.InlineFragments[selection.Ref].SelectionSet)
+			}
+		case ast.SelectionKindFragmentSpread:
+			fragmentRef, exists := m.operation.FragmentDefinitionRef(m.operation.FragmentSpreadNameBytes(selection.Ref))
+			if !exists {
+				continue
+			}
+			if _, visited := m.visitedFragments[fragmentRef]; visited {
+				continue
+			}
+			m.visitedFragments[fragmentRef] = struct{}{}
+			if m.operation.FragmentDefinitions[fragmentRef].HasSelections {
+				count += m.countFields(m.operation.FragmentDefinitions[fragmentRef].SelectionSet)
+			}
+		}
+	}
+	return count
+}
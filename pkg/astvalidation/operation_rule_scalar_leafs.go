@@ -0,0 +1,66 @@
+package astvalidation
+
+import (
+	"github.com/wundergraph/graphql-go-tools/pkg/ast"
+	"github.com/wundergraph/graphql-go-tools/pkg/astvisitor"
+	"github.com/wundergraph/graphql-go-tools/pkg/operationreport"
+)
+
+// ScalarLeafs validates that fields of object, interface and union type have a selection set
+// and that fields of scalar and enum type don't have one.
+// The field type is resolved through list and non-null wrappers.
+func ScalarLeafs() Rule {
+	return func(walker *astvisitor.Walker) {
+		visitor := scalarLeafsVisitor{
+			Walker: walker,
+		}
+		walker.RegisterEnterDocumentVisitor(&visitor)
+		walker.RegisterEnterFieldVisitor(&visitor)
+	}
+}
+
+type scalarLeafsVisitor struct {
+	*astvisitor.Walker
+	operation, definition *ast.Document
+}
+
+func (s *scalarLeafsVisitor) EnterDocument(operation, definition *ast.Document) {
+	s.operation = operation
+	s.definition = definition
+}
+
+func (s *scalarLeafsVisitor) EnterField(ref int) {
+	fieldName := s.operation.FieldNameBytes(ref)
+	fieldDefinition, exists := s.definition.NodeFieldDefinitionByName(s.EnclosingTypeDefinition, fieldName)
+	if !exists {
+		// unknown fields and meta fields are validated by FieldSelections
+		return
+	}
+
+	fieldType := s.definition.FieldDefinitionType(fieldDefinition)
+	typeName := s.definition.ResolveTypeNameBytes(fieldType)
+	typeDefinition, exists := s.definition.Index.FirstNonExtensionNodeByNameBytes(typeName)
+	if !exists {
+		return
+	}
+
+	hasSelections := s.operation.FieldHasSelections(ref)
+
+	switch typeDefinition.Kind {
+	case ast.NodeKindScalarTypeDefinition, ast.NodeKindEnumTypeDefinition:
+		if !hasSelections {
+			return
+		}
+		selectionSetPosition := s.operation.SelectionSets[s.operation.Fields[ref].SelectionSet].LBrace
+		s.StopWithExternalErr(operationreport.ErrNoSubselectionAllowedOnField(fieldName, typeName, selectionSetPosition))
+	case ast.NodeKindObjectTypeDefinition, ast.NodeKindInterfaceTypeDefinition, ast.NodeKindUnionTypeDefinition:
+		if hasSelections {
+			return
+		}
+		printedType, err := s.definition.PrintTypeBytes(fieldType, nil)
+		if s.HandleInternalErr(err) {
+			return
+		}
+		s.StopWithExternalErr(operationreport.ErrMissingSubselectionOnField(fieldName, printedType, s.operation.Fields[ref].Position))
+	}
+}
@@ -0,0 +1,81 @@
+package astvalidation
+
+import (
+	"bytes"
+
+	"github.com/wundergraph/graphql-go-tools/pkg/ast"
+	"github.com/wundergraph/graphql-go-tools/pkg/astvisitor"
+	"github.com/wundergraph/graphql-go-tools/pkg/lexer/literal"
+	"github.com/wundergraph/graphql-go-tools/pkg/operationreport"
+)
+
+// UnionSelections validates that the selection set of a field of union type selects __typename
+// or contains at least one type condition, as a selection without any of them selects nothing.
+// Fields of union type without any selection set are validated by ScalarLeafs.
+func UnionSelections() Rule {
+	return func(walker *astvisitor.Walker) {
+		visitor := unionSelectionsVisitor{
+			Walker: walker,
+		}
+		walker.RegisterEnterDocumentVisitor(&visitor)
+		walker.RegisterEnterFieldVisitor(&visitor)
+	}
+}
+
+type unionSelectionsVisitor struct {
+	*astvisitor.Walker
+	operation, definition *ast.Document
+}
+
+func (u *unionSelectionsVisitor) EnterDocument(operation, definition *ast.Document) {
+	u.operation = operation
+	u.definition = definition
+}
+
+func (u *unionSelectionsVisitor) EnterField(ref int) {
+	if !u.operation.FieldHasSelections(ref) {
+		return
+	}
+
+	fieldName := u.operation.FieldNameBytes(ref)
+	fieldDefinition, exists := u.definition.NodeFieldDefinitionByName(u.EnclosingTypeDefinition, fieldName)
+	if !exists {
+		return
+	}
+
+	typeName := u.definition.ResolveTypeNameBytes(u.definition.FieldDefinitionType(fieldDefinition))
+	typeDefinition, exists := u.definition.Index.FirstNonExtensionNodeByNameBytes(typeName)
+	if !exists || typeDefinition.Kind != ast.NodeKindUnionTypeDefinition {
+		return
+	}
+
+	if u.selectsTypeNameOrTypeCondition(u.operation.Fields[ref].SelectionSet) {
+		return
+	}
+
+	u.StopWithExternalErr(operationreport.ErrMissingTypeConditionOnUnionField(fieldName, typeName, u.operation.Fields[ref].Position))
+}
+
+func (u *unionSelectionsVisitor) selectsTypeNameOrTypeCondition(set int) bool {
+	for _, selectionRef := range u.operation.SelectionSets[set].SelectionRefs {
+		selection := u.operation.Selections[selectionRef]
+		switch selection.Kind {
+		case ast.SelectionKindField:
+			if bytes.Equal(u.operation.FieldNameBytes(selection.Ref), literal.TYPENAME) {
+				return true
+			}
+		case ast.SelectionKindFragmentSpread:
+			// fragment definitions always have a type condition
+			return true
+		case ast.SelectionKindInlineFragment:
+			if u.operation.InlineFragmentHasTypeCondition(selection.Ref) {
+				return true
+			}
+			inlineFragment := u.operation.InlineFragments[selection.Ref]
+			if inlineFragment.HasSelections && u.selectsTypeNameOrTypeCondition(inlineFragment.SelectionSet) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -10,7 +10,8 @@ import (
 	"github.com/wundergraph/graphql-go-tools/pkg/operationreport"
 )
 
-// FieldSelections validates if all FieldSelections are possible and valid
+// FieldSelections validates if all FieldSelections are possible and valid.
+// Whether a field has to or must not have a selection set is validated by ScalarLeafs.
 func FieldSelections() Rule {
 	return func(walker *astvisitor.Walker) {
 		fieldDefined := fieldDefined{
@@ -47,24 +48,15 @@ func (f *fieldDefined) ValidateInterfaceObjectTypeField(ref int, enclosingTypeDe
 		return
 	}
 	typeName := f.definition.NodeNameBytes(enclosingTypeDefinition)
-	hasSelections := f.operation.FieldHasSelections(ref)
 	definitions := f.definition.NodeFieldDefinitions(enclosingTypeDefinition)
 	for _, i := range definitions {
-		definitionName := f.definition.FieldDefinitionNameBytes(i)
-		if bytes.Equal(fieldName, definitionName) {
+		if bytes.Equal(fieldName, f.definition.FieldDefinitionNameBytes(i)) {
 			// field is defined
-			fieldDefinitionTypeKind := f.definition.FieldDefinitionTypeNode(i).Kind
-			switch {
-			case hasSelections && fieldDefinitionTypeKind == ast.NodeKindScalarTypeDefinition:
-				f.StopWithExternalErr(operationreport.ErrFieldSelectionOnScalar(fieldName, definitionName))
-			case !hasSelections && (fieldDefinitionTypeKind != ast.NodeKindScalarTypeDefinition && fieldDefinitionTypeKind != ast.NodeKindEnumTypeDefinition):
-				f.StopWithExternalErr(operationreport.ErrMissingFieldSelectionOnNonScalar(fieldName, typeName))
-			}
 			return
 		}
 	}
 
-	f.StopWithExternalErr(operationreport.ErrFieldUndefinedOnType(fieldName, typeName))
+	f.StopWithExternalErr(operationreport.ErrFieldUndefinedOnType(fieldName, typeName, f.operation.Fields[ref].Position))
 }
 
 func (f *fieldDefined) ValidateScalarField(ref int, enclosingTypeDefinition ast.Node) {
@@ -28,27 +28,43 @@ func (v *variablesAreInputTypesVisitor) EnterDocument(operation, definition *ast
 }
 
 func (v *variablesAreInputTypesVisitor) EnterVariableDefinition(ref int) {
+	v.HandleInternalErr(validateVariableType(v.operation, v.definition, ref, v.Report))
+}
 
-	typeName := v.operation.ResolveTypeNameBytes(v.operation.VariableDefinitions[ref].Type)
-	typeDefinitionNode, ok := v.definition.Index.FirstNodeByNameBytes(typeName)
+// ValidateVariableTypes validates that the types of all variable definitions of the operation document
+// exist in the definition and are input types. Unlike the VariablesAreInputTypes rule it doesn't walk the
+// operation and can be used to reject an operation before a full validation.
+func ValidateVariableTypes(operation, definition *ast.Document, report *operationreport.Report) {
+	for ref := range operation.VariableDefinitions {
+		if err := validateVariableType(operation, definition, ref, report); err != nil {
+			report.AddInternalError(err)
+			return
+		}
+	}
+}
+
+// validateVariableType reports an external error for an invalid variable type and returns internal errors
+func validateVariableType(operation, definition *ast.Document, ref int, report *operationreport.Report) error {
+	typeName := operation.ResolveTypeNameBytes(operation.VariableDefinitions[ref].Type)
+	typeDefinitionNode, ok := definition.Index.FirstNodeByNameBytes(typeName)
 	if !ok {
-		v.Report.AddExternalError(operationreport.ErrUnknownType(typeName, v.operation.Types[v.operation.VariableDefinitions[ref].Type].Position))
-		return
+		report.AddExternalError(operationreport.ErrUnknownType(typeName, operation.Types[operation.VariableDefinitions[ref].Type].Position))
+		return nil
 	}
 
 	switch typeDefinitionNode.Kind {
 	case ast.NodeKindInputObjectTypeDefinition, ast.NodeKindScalarTypeDefinition, ast.NodeKindEnumTypeDefinition:
-		return
+		return nil
 	default:
-		variableName := v.operation.VariableDefinitionNameBytes(ref)
-		variableTypePos := v.operation.Types[v.operation.VariableDefinitions[ref].Type].Position
+		variableName := operation.VariableDefinitionNameBytes(ref)
+		variableTypePos := operation.Types[operation.VariableDefinitions[ref].Type].Position
 
-		printedType, err := v.operation.PrintTypeBytes(v.operation.VariableDefinitions[ref].Type, nil)
-		if v.HandleInternalErr(err) {
-			return
+		printedType, err := operation.PrintTypeBytes(operation.VariableDefinitions[ref].Type, nil)
+		if err != nil {
+			return err
 		}
 
-		v.Report.AddExternalError(operationreport.ErrVariableOfTypeIsNoValidInputValue(variableName, printedType, variableTypePos))
-		return
+		report.AddExternalError(operationreport.ErrVariableOfTypeIsNoValidInputValue(variableName, printedType, variableTypePos))
+		return nil
 	}
 }
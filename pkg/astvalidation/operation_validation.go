@@ -8,7 +8,12 @@ import (
 )
 
 // DefaultOperationValidator returns a fully initialized OperationValidator with all default rules registered
-func DefaultOperationValidator() *OperationValidator {
+// and the optional rules enabled by the given options
+func DefaultOperationValidator(options ...Option) *OperationValidator {
+	var opts operationValidatorOptions
+	for _, option := range options {
+		option(&opts)
+	}
 
 	validator := OperationValidator{
 		walker: astvisitor.NewWalker(48),
@@ -18,6 +23,8 @@ func DefaultOperationValidator() *OperationValidator {
 	validator.RegisterRule(OperationNameUniqueness())
 	validator.RegisterRule(LoneAnonymousOperation())
 	validator.RegisterRule(SubscriptionSingleRootField())
+	validator.RegisterRule(ScalarLeafs())
+	validator.RegisterRule(UnionSelections())
 	validator.RegisterRule(FieldSelections())
 	validator.RegisterRule(FieldSelectionMerging())
 	validator.RegisterRule(KnownArguments())
@@ -34,9 +41,27 @@ func DefaultOperationValidator() *OperationValidator {
 	validator.RegisterRule(AllVariableUsesDefined())
 	validator.RegisterRule(AllVariablesUsed())
 
+	if opts.maxRootFields > 0 {
+		validator.RegisterRule(MaxRootFields(opts.maxRootFields))
+	}
+
 	return &validator
 }
 
+type operationValidatorOptions struct {
+	maxRootFields int
+}
+
+type Option func(options *operationValidatorOptions)
+
+// WithMaxRootFields rejects operations selecting more than maxRootFields top-level fields.
+// The limit is disabled by default.
+func WithMaxRootFields(maxRootFields int) Option {
+	return func(options *operationValidatorOptions) {
+		options.maxRootFields = maxRootFields
+	}
+}
+
 func NewOperationValidator(rules []Rule) *OperationValidator {
 	validator := OperationValidator{
 		walker: astvisitor.NewWalker(48),
@@ -300,6 +300,55 @@ func TestExecutionValidation(t *testing.T) {
 						SubscriptionSingleRootField(), Invalid)
 				})
 			})
+			t.Run("max root fields", func(t *testing.T) {
+				t.Run("within limit", func(t *testing.T) {
+					run(t, `
+							query q {
+								foo
+								bar
+							}`,
+						MaxRootFields(2), Valid)
+				})
+				t.Run("exceeding limit", func(t *testing.T) {
+					run(t, `
+							query q {
+								foo
+								bar
+								a
+							}`,
+						MaxRootFields(2), Invalid, withValidationErrors("operation: q selects 3 root fields, at most 2 root fields are allowed"))
+				})
+				t.Run("exceeding limit with aliased fields", func(t *testing.T) {
+					run(t, `
+							query q {
+								first: foo
+								second: foo
+								third: foo
+							}`,
+						MaxRootFields(2), Invalid)
+				})
+				t.Run("exceeding limit with fragments", func(t *testing.T) {
+					run(t, `
+							query q {
+								foo
+								... on Query { bar }
+								...rootFields
+							}
+							fragment rootFields on Query { a b }`,
+						MaxRootFields(3), Invalid, withDisableNormalization())
+				})
+				t.Run("nested fields are not counted", func(t *testing.T) {
+					run(t, `
+							query q {
+								dog {
+									name
+									nickname
+									barkVolume
+								}
+							}`,
+						MaxRootFields(1), Valid)
+				})
+			})
 		})
 	})
 	t.Run("5.3 FieldSelections", func(t *testing.T) {
@@ -1994,32 +2043,168 @@ func TestExecutionValidation(t *testing.T) {
 									sinceWhen
 								}
 							}`,
-					FieldSelections(), Invalid, withExpectNormalizationError())
+					ScalarLeafs(), Invalid, withExpectNormalizationError())
 			})
 			t.Run("116", func(t *testing.T) {
 				run(t, `	
 							query directQueryOnObjectWithoutSubFields {
 								human
 							}`,
-					FieldSelections(), Invalid)
+					ScalarLeafs(), Invalid)
 				run(t, `	query directQueryOnInterfaceWithoutSubFields {
 								pet
 							}`,
-					FieldSelections(), Invalid)
+					ScalarLeafs(), Invalid)
 				run(t, `	query directQueryOnUnionWithoutSubFields {
 								catOrDog
 							}`,
-					FieldSelections(), Invalid)
+					ScalarLeafs(), Invalid)
 				run(t, `
 							mutation directQueryOnUnionWithoutSubFields {
 								catOrDog
 							}`,
-					FieldSelections(), Invalid, withExpectNormalizationError())
+					ScalarLeafs(), Invalid, withExpectNormalizationError())
 				run(t, `
 							subscription directQueryOnUnionWithoutSubFields {
 								catOrDog
 							}`,
-					FieldSelections(), Invalid, withExpectNormalizationError())
+					ScalarLeafs(), Invalid, withExpectNormalizationError())
+			})
+			t.Run("scalar leafs", func(t *testing.T) {
+				t.Run("valid leaf and composite selections", func(t *testing.T) {
+					run(t, `
+							query validSelections {
+								dog {
+									barkVolume
+									doesKnowCommand(dogCommand: SIT)
+									owner {
+										name
+									}
+								}
+								catOrDog {
+									__typename
+								}
+							}`,
+						ScalarLeafs(), Valid)
+				})
+				t.Run("missing selection on object type field", func(t *testing.T) {
+					run(t, `
+							query directQueryOnObjectWithoutSubFields {
+								human
+							}`,
+						ScalarLeafs(), Invalid, withValidationErrors(`Field "human" of type "Human" must have a selection of subfields. Did you mean "human { ... }"?`))
+				})
+				t.Run("missing selection on interface type field", func(t *testing.T) {
+					run(t, `
+							query directQueryOnInterfaceWithoutSubFields {
+								pet
+							}`,
+						ScalarLeafs(), Invalid, withValidationErrors(`Field "pet" of type "Pet" must have a selection of subfields.`))
+				})
+				t.Run("missing selection on union type field", func(t *testing.T) {
+					run(t, `
+							query directQueryOnUnionWithoutSubFields {
+								catOrDog
+							}`,
+						ScalarLeafs(), Invalid, withValidationErrors(`Field "catOrDog" of type "CatOrDog" must have a selection of subfields.`))
+				})
+				t.Run("missing selection on nested object type field", func(t *testing.T) {
+					run(t, `
+							query nestedObjectWithoutSubFields {
+								dog {
+									owner
+								}
+							}`,
+						ScalarLeafs(), Invalid, withValidationErrors(`Field "owner" of type "Human" must have a selection of subfields.`))
+				})
+				t.Run("selection on scalar type field", func(t *testing.T) {
+					run(t, `
+							fragment scalarSelectionsNotAllowedOnInt on Dog {
+								barkVolume {
+									sinceWhen
+								}
+							}`,
+						ScalarLeafs(), Invalid, withDisableNormalization(), withValidationErrors(`Field "barkVolume" must not have a selection since type "Int" has no subfields.`))
+				})
+				t.Run("selection on non null scalar type field", func(t *testing.T) {
+					run(t, `
+							fragment scalarSelectionsNotAllowedOnString on Dog {
+								name {
+									length
+								}
+							}`,
+						ScalarLeafs(), Invalid, withDisableNormalization(), withValidationErrors(`Field "name" must not have a selection since type "String" has no subfields.`))
+				})
+				t.Run("selection on list of scalars type field", func(t *testing.T) {
+					run(t, `
+							query listOfScalarsWithSubFields {
+								dog {
+									extra {
+										strings {
+											length
+										}
+									}
+								}
+							}`,
+						ScalarLeafs(), Invalid, withDisableNormalization(), withValidationErrors(`Field "strings" must not have a selection since type "String" has no subfields.`))
+				})
+			})
+			t.Run("union selections", func(t *testing.T) {
+				t.Run("selection of __typename", func(t *testing.T) {
+					run(t, `
+							query unionTypeName {
+								catOrDog {
+									__typename
+								}
+							}`,
+						UnionSelections(), Valid)
+				})
+				t.Run("selection with type conditions", func(t *testing.T) {
+					run(t, `
+							query unionTypeConditions {
+								catOrDog {
+									... on Cat {
+										name
+									}
+									...dogFragment
+								}
+							}
+							fragment dogFragment on Dog {
+								barkVolume
+							}`,
+						UnionSelections(), Valid, withDisableNormalization())
+				})
+				t.Run("selection of __typename in inline fragment without type condition", func(t *testing.T) {
+					run(t, `
+							query unionTypeNameInInlineFragment($include: Boolean!) {
+								catOrDog {
+									... @include(if: $include) {
+										__typename
+									}
+								}
+							}`,
+						UnionSelections(), Valid)
+				})
+				t.Run("empty inline fragment", func(t *testing.T) {
+					run(t, `
+							query emptyUnionSelection {
+								catOrDog {
+									... {}
+								}
+							}`,
+						UnionSelections(), Invalid, withValidationErrors(`Field "catOrDog" of union type "CatOrDog" must select "__typename" or at least one type condition.`))
+				})
+				t.Run("empty nested inline fragments", func(t *testing.T) {
+					run(t, `
+							query emptyNestedUnionSelection($include: Boolean!) {
+								catOrDog {
+									... @include(if: $include) {
+										... {}
+									}
+								}
+							}`,
+						UnionSelections(), Invalid, withValidationErrors(`Field "catOrDog" of union type "CatOrDog" must select "__typename" or at least one type condition.`))
+				})
 			})
 		})
 	})
@@ -4415,6 +4600,27 @@ func BenchmarkValidation(b *testing.B) {
 	})
 }
 
+func TestDefaultOperationValidator_WithMaxRootFields(t *testing.T) {
+	run := func(operationInput string, options []Option, expectation ValidationState) func(t *testing.T) {
+		return func(t *testing.T) {
+			definition := unsafeparser.ParseGraphqlDocumentString(testDefinition)
+			operation := unsafeparser.ParseGraphqlDocumentString(operationInput)
+			report := operationreport.Report{}
+			astnormalization.NormalizeOperation(&operation, &definition, &report)
+			require.False(t, report.HasErrors(), report.Error())
+
+			result := DefaultOperationValidator(options...).Validate(&operation, &definition, &report)
+			assert.Equal(t, expectation, result, report.Error())
+		}
+	}
+
+	operation := `query q { foo bar a }`
+
+	t.Run("disabled by default", run(operation, nil, Valid))
+	t.Run("within limit", run(operation, []Option{WithMaxRootFields(3)}, Valid))
+	t.Run("exceeding limit", run(operation, []Option{WithMaxRootFields(2)}, Invalid))
+}
+
 var testDefinition = `
 schema {
 	query: Query
@@ -5,8 +5,6 @@ import (
 )
 
 func TestScalarLeafsRule(t *testing.T) {
-	t.Skip()
-
 	ExpectErrors := func(t *testing.T, queryStr string) ResultCompare {
 		return ExpectValidationErrors(t, ScalarLeafsRule, queryStr)
 	}
@@ -87,6 +87,7 @@ var rulesMap = map[string][]astvalidation.Rule{
 	VariablesAreInputTypesRule:                {astvalidation.VariablesAreInputTypes()},
 	KnownTypeNamesOperationRule:               {astvalidation.VariablesAreInputTypes(), astvalidation.Fragments()},
 	VariablesInAllowedPositionRule:            {astvalidation.ValidArguments(), astvalidation.Values()},
+	ScalarLeafsRule:                           {astvalidation.ScalarLeafs()},
 
 	// fragments rules
 	FragmentsOnCompositeTypesRule: {astvalidation.Fragments()},
@@ -101,7 +102,6 @@ var rulesMap = map[string][]astvalidation.Rule{
 	UniqueInputFieldNamesRule:  {astvalidation.Values()},
 	UniqueDirectiveNamesRule:   {},
 	LoneSchemaDefinitionRule:   {},
-	ScalarLeafsRule:            {},
 	PossibleTypeExtensionsRule: {},
 }
 
@@ -3,6 +3,7 @@ package astvalidation
 import (
 	"github.com/wundergraph/graphql-go-tools/pkg/ast"
 	"github.com/wundergraph/graphql-go-tools/pkg/astvisitor"
+	"github.com/wundergraph/graphql-go-tools/pkg/lexer/position"
 	"github.com/wundergraph/graphql-go-tools/pkg/operationreport"
 )
 
@@ -25,12 +26,15 @@ type implementingTypesAreSupersetsVisitor struct {
 	definition                           *ast.Document
 	implementingTypesWithFields          map[string][]string
 	implementingTypesWithInterfacesNames map[string][]string
+	// implementingTypesPositions are the positions of the first definitions of the types, errors are reported there
+	implementingTypesPositions map[string]position.Position
 }
 
 func (v *implementingTypesAreSupersetsVisitor) EnterDocument(operation, definition *ast.Document) {
 	v.definition = operation
 	v.implementingTypesWithFields = make(map[string][]string)
 	v.implementingTypesWithInterfacesNames = make(map[string][]string)
+	v.implementingTypesPositions = make(map[string]position.Position)
 }
 
 // LeaveDocument will iterate over all types which implement an interface by using the interface name. If a
@@ -78,7 +82,7 @@ func (v *implementingTypesAreSupersetsVisitor) LeaveDocument(operation, definiti
 			}
 
 			if !typeNameHasFields && len(interfaceFieldRefs) > 0 {
-				v.Report.AddExternalError(operationreport.ErrImplementingTypeDoesNotHaveFields([]byte(typeName)))
+				v.Report.AddExternalError(operationreport.ErrImplementingTypeDoesNotHaveFields([]byte(typeName), v.implementingTypesPositions[typeName]))
 				continue
 			}
 
@@ -89,6 +93,7 @@ func (v *implementingTypesAreSupersetsVisitor) LeaveDocument(operation, definiti
 						[]byte(typeName),
 						[]byte(interfacesNames[i]),
 						[]byte(interfaceFieldName),
+						v.implementingTypesPositions[typeName],
 					))
 				}
 			}
@@ -103,6 +108,7 @@ func (v *implementingTypesAreSupersetsVisitor) EnterInterfaceTypeDefinition(ref
 	}
 
 	typeName := v.definition.InterfaceTypeDefinitionNameString(ref)
+	v.collectPositionForTypeName(typeName, v.definition.InterfaceTypeDefinitions[ref].InterfaceLiteral)
 	fieldDefinitionRefs := v.definition.InterfaceTypeDefinitions[ref].FieldsDefinition.Refs
 	v.collectFieldsForTypeName(typeName, fieldDefinitionRefs)
 	v.collectInterfaceNamesForImplementedInterfacesByTypeName(typeName, interfacesRefs)
@@ -115,6 +121,7 @@ func (v *implementingTypesAreSupersetsVisitor) EnterInterfaceTypeExtension(ref i
 	}
 
 	typeName := v.definition.InterfaceTypeExtensionNameString(ref)
+	v.collectPositionForTypeName(typeName, v.definition.InterfaceTypeExtensions[ref].ExtendLiteral)
 	fieldDefinitionRefs := v.definition.InterfaceTypeExtensions[ref].FieldsDefinition.Refs
 
 	nodesWithTypeName, exists := v.definition.Index.NodesByNameStr(typeName)
@@ -146,6 +153,7 @@ func (v *implementingTypesAreSupersetsVisitor) EnterObjectTypeDefinition(ref int
 	}
 
 	typeName := v.definition.ObjectTypeDefinitionNameString(ref)
+	v.collectPositionForTypeName(typeName, v.definition.ObjectTypeDefinitions[ref].TypeLiteral)
 	fieldDefinitionRefs := v.definition.ObjectTypeDefinitions[ref].FieldsDefinition.Refs
 	v.collectFieldsForTypeName(typeName, fieldDefinitionRefs)
 	v.collectInterfaceNamesForImplementedInterfacesByTypeName(typeName, interfacesRefs)
@@ -158,6 +166,7 @@ func (v *implementingTypesAreSupersetsVisitor) EnterObjectTypeExtension(ref int)
 	}
 
 	typeName := v.definition.ObjectTypeExtensionNameString(ref)
+	v.collectPositionForTypeName(typeName, v.definition.ObjectTypeExtensions[ref].ExtendLiteral)
 	fieldDefinitionRefs := v.definition.ObjectTypeExtensions[ref].FieldsDefinition.Refs
 
 	nodesWithTypeName, exists := v.definition.Index.NodesByNameStr(typeName)
@@ -182,6 +191,12 @@ func (v *implementingTypesAreSupersetsVisitor) EnterObjectTypeExtension(ref int)
 	v.collectInterfaceNamesForImplementedInterfacesByTypeName(typeName, interfacesRefs)
 }
 
+func (v *implementingTypesAreSupersetsVisitor) collectPositionForTypeName(typeName string, position position.Position) {
+	if _, ok := v.implementingTypesPositions[typeName]; !ok {
+		v.implementingTypesPositions[typeName] = position
+	}
+}
+
 // collectFieldsForTypeName will add all field names of a type which implements an interface to a slice in a
 // map entry, so that it can be used as a lookup table later on.
 //
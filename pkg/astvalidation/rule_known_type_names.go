@@ -5,6 +5,7 @@ import (
 
 	"github.com/wundergraph/graphql-go-tools/pkg/ast"
 	"github.com/wundergraph/graphql-go-tools/pkg/astvisitor"
+	"github.com/wundergraph/graphql-go-tools/pkg/lexer/position"
 	"github.com/wundergraph/graphql-go-tools/pkg/operationreport"
 )
 
@@ -34,18 +35,21 @@ type knownTypeNamesVisitor struct {
 	definition           *ast.Document
 	definedTypeNameHashs map[uint64]bool
 	referencedTypeNames  map[uint64][]byte
+	// referencedTypePositions are the positions of the first references of the types
+	referencedTypePositions map[uint64]position.Position
 }
 
 func (u *knownTypeNamesVisitor) EnterDocument(operation, _ *ast.Document) {
 	u.definition = operation
 	u.definedTypeNameHashs = make(map[uint64]bool)
 	u.referencedTypeNames = make(map[uint64][]byte)
+	u.referencedTypePositions = make(map[uint64]position.Position)
 }
 
 func (u *knownTypeNamesVisitor) LeaveDocument(_, _ *ast.Document) {
 	for referencedTypeNameHash, referencedTypeName := range u.referencedTypeNames {
 		if !u.definedTypeNameHashs[referencedTypeNameHash] {
-			u.Report.AddExternalError(operationreport.ErrTypeUndefined(referencedTypeName))
+			u.Report.AddExternalError(operationreport.ErrReferencedTypeUndefined(referencedTypeName, u.referencedTypePositions[referencedTypeNameHash]))
 			continue
 		}
 	}
@@ -53,25 +57,26 @@ func (u *knownTypeNamesVisitor) LeaveDocument(_, _ *ast.Document) {
 }
 
 func (u *knownTypeNamesVisitor) EnterRootOperationTypeDefinition(ref int) {
-	referencedTypeName := u.definition.Input.ByteSlice(u.definition.RootOperationTypeDefinitions[ref].NamedType.Name)
-	u.saveReferencedTypeName(referencedTypeName)
+	namedType := u.definition.RootOperationTypeDefinitions[ref].NamedType
+	referencedTypeName := u.definition.Input.ByteSlice(namedType.Name)
+	u.saveReferencedTypeName(referencedTypeName, namedType.Position)
 }
 
 func (u *knownTypeNamesVisitor) EnterFieldDefinition(ref int) {
 	referencedTypeRef := u.definition.ResolveUnderlyingType(u.definition.FieldDefinitions[ref].Type)
 	referencedTypeName := u.definition.TypeNameBytes(referencedTypeRef)
-	u.saveReferencedTypeName(referencedTypeName)
+	u.saveReferencedTypeName(referencedTypeName, u.definition.Types[referencedTypeRef].Position)
 }
 
 func (u *knownTypeNamesVisitor) EnterUnionMemberType(ref int) {
 	referencedTypeName := u.definition.TypeNameBytes(ref)
-	u.saveReferencedTypeName(referencedTypeName)
+	u.saveReferencedTypeName(referencedTypeName, u.definition.Types[ref].Position)
 }
 
 func (u *knownTypeNamesVisitor) EnterInputValueDefinition(ref int) {
 	referencedTypeRef := u.definition.InputValueDefinitions[ref].Type
 	referencedTypeName := u.definition.TypeNameBytes(referencedTypeRef)
-	u.saveReferencedTypeName(referencedTypeName)
+	u.saveReferencedTypeName(referencedTypeName, u.definition.Types[referencedTypeRef].Position)
 }
 
 func (u *knownTypeNamesVisitor) EnterObjectTypeDefinition(ref int) {
@@ -113,9 +118,13 @@ func (u *knownTypeNamesVisitor) saveTypeName(typeName ast.ByteSlice) {
 	u.definedTypeNameHashs[xxhash.Sum64(typeName)] = true
 }
 
-func (u *knownTypeNamesVisitor) saveReferencedTypeName(referencedTypeName ast.ByteSlice) {
+func (u *knownTypeNamesVisitor) saveReferencedTypeName(referencedTypeName ast.ByteSlice, position position.Position) {
 	if len(referencedTypeName) == 0 {
 		return
 	}
-	u.referencedTypeNames[xxhash.Sum64(referencedTypeName)] = referencedTypeName
+	hash := xxhash.Sum64(referencedTypeName)
+	if _, ok := u.referencedTypeNames[hash]; !ok {
+		u.referencedTypePositions[hash] = position
+	}
+	u.referencedTypeNames[hash] = referencedTypeName
 }
@@ -1380,7 +1380,7 @@ func (w *Walker) appendAncestor(ref int, kind ast.NodeKind) {
 		}
 		if typeName == nil {
 			typeName := w.definition.NodeNameBytes(w.typeDefinitions[len(w.typeDefinitions)-1])
-			w.StopWithExternalErr(operationreport.ErrFieldUndefinedOnType(fieldName, typeName))
+			w.StopWithExternalErr(operationreport.ErrFieldUndefinedOnType(fieldName, typeName, w.document.Fields[ref].Position))
 			return
 		}
 	case ast.NodeKindObjectTypeDefinition, ast.NodeKindInterfaceTypeDefinition, ast.NodeKindUnionTypeDefinition:
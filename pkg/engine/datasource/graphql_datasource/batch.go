@@ -23,13 +23,14 @@ type Batch struct {
 // inputResponseBufferMappings defines the relationship between input containing an _entities Query
 // and the output buffers, the response needs to be mapped to
 type inputResponseBufferMappings struct {
-	// responseIndex is the array position of the response
+	// responseIndex is the array position of the response, skipped inputs have no response
 	responseIndex int
 	// originalInput is the original input of a response to allow comparing and deduplication
 	originalInput []byte
-	// assignedBufferIndices are the buffers to which the response needs to be assigned
+	// assignedBufferIndices are the buffers to which the response needs to be assigned,
+	// identical representations of multiple inputs are sent once and their response is assigned to all of their buffers
 	assignedBufferIndices []int
-
+	// skip is set for null inputs, e.g. of a null sibling, they're not sent and get a null response
 	skip bool
 }
 
@@ -88,7 +89,7 @@ func (b *BatchFactory) multiplexBatch(out *fastbuffer.FastBuffer, inputs [][]byt
 
 	var (
 		variablesIdx              int
-		skippedInputs             int
+		firstInput                = -1
 		firstRepresentationsStart int
 		firstRepresentationsEnd   int
 	)
@@ -96,13 +97,10 @@ func (b *BatchFactory) multiplexBatch(out *fastbuffer.FastBuffer, inputs [][]byt
 	for i := range inputs {
 		if bytes.Equal(inputs[i], literal.NULL) {
 			responseMappings = append(responseMappings, inputResponseBufferMappings{
-				responseIndex:         i,
 				originalInput:         inputs[i],
 				assignedBufferIndices: []int{i},
 				skip:                  true,
 			})
-			variablesIdx++
-			skippedInputs++
 			continue
 		}
 		inputVariables, _, representationsOffset, err := jsonparser.Get(inputs[i], representationPath...)
@@ -110,15 +108,19 @@ func (b *BatchFactory) multiplexBatch(out *fastbuffer.FastBuffer, inputs [][]byt
 			return nil, 0, err
 		}
 
-		if i == 0 {
+		// the header and trailer of the batch are taken from the first input which isn't null
+		if firstInput == -1 {
+			firstInput = i
 			firstRepresentationsStart = representationsOffset - len(inputVariables)
 			firstRepresentationsEnd = representationsOffset
 		}
 
 		_, err = jsonparser.ArrayEach(inputVariables, func(value []byte, dataType jsonparser.ValueType, offset int, err error) {
 			for j := range responseMappings {
-				existing := responseMappings[j].originalInput
-				if bytes.Equal(existing, value) {
+				if responseMappings[j].skip {
+					continue
+				}
+				if bytes.Equal(responseMappings[j].originalInput, value) {
 					responseMappings[j].assignedBufferIndices = append(responseMappings[j].assignedBufferIndices, i)
 					return
 				}
@@ -148,8 +150,12 @@ func (b *BatchFactory) multiplexBatch(out *fastbuffer.FastBuffer, inputs [][]byt
 	representationJsonCopy := make([]byte, len(representationJson))
 	copy(representationJsonCopy, representationJson)
 
-	header := inputs[0][0:firstRepresentationsStart]
-	trailer := inputs[0][firstRepresentationsEnd:]
+	if firstInput == -1 {
+		return responseMappings, len(inputs), nil
+	}
+
+	header := inputs[firstInput][0:firstRepresentationsStart]
+	trailer := inputs[firstInput][firstRepresentationsEnd:]
 
 	out.WriteBytes(header)
 	out.WriteBytes(representationJsonCopy)
@@ -159,35 +165,32 @@ func (b *BatchFactory) multiplexBatch(out *fastbuffer.FastBuffer, inputs [][]byt
 }
 
 func (b *Batch) demultiplexBatch(responsePair *resolve.BufPair, responseMappings []inputResponseBufferMappings, resultBufPairs []*resolve.BufPair) (err error) {
-	var outPosition int
-
+	var responses [][]byte
 	if responsePair.HasData() {
 		_, err = jsonparser.ArrayEach(responsePair.Data.Bytes(), func(value []byte, dataType jsonparser.ValueType, offset int, err error) {
-			if outPosition > len(responseMappings)+1 {
-				return
-			}
-
-			mapping := responseMappings[outPosition]
-			for mapping.skip {
-				resultBufPairs[outPosition].Data.WriteBytes(literal.NULL)
-				outPosition++
-				mapping = responseMappings[outPosition]
-			}
-
-			for _, index := range mapping.assignedBufferIndices {
-				if resultBufPairs[index].Data.Len() != 0 {
-					resultBufPairs[index].Data.WriteBytes(literal.COMMA)
-				}
-				resultBufPairs[index].Data.WriteBytes(value)
-			}
-
-			outPosition++
+			responses = append(responses, value)
 		})
 		if err != nil {
 			return err
 		}
 	}
 
+	for _, mapping := range responseMappings {
+		// inputs without a response, e.g. if the subgraph returned fewer entities than representations, get null,
+		// so the responses of the following inputs keep their position
+		value := literal.NULL
+		if !mapping.skip && mapping.responseIndex < len(responses) {
+			value = responses[mapping.responseIndex]
+		}
+
+		for _, index := range mapping.assignedBufferIndices {
+			if resultBufPairs[index].Data.Len() != 0 {
+				resultBufPairs[index].Data.WriteBytes(literal.COMMA)
+			}
+			resultBufPairs[index].Data.WriteBytes(value)
+		}
+	}
+
 	if responsePair.HasErrors() {
 		resultBufPairs[0].Errors.WriteBytes(responsePair.Errors.Bytes())
 	}
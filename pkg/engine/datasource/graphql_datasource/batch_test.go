@@ -128,23 +128,22 @@ func TestBatch(t *testing.T) {
 					assignedBufferIndices: []int{1},
 				},
 				{
-					responseIndex:         2,
 					originalInput:         []byte(`null`),
 					assignedBufferIndices: []int{2},
 					skip:                  true,
 				},
 				{
-					responseIndex:         3,
+					responseIndex:         2,
 					originalInput:         []byte(`{"upc":"top-4","__typename":"Product"}`),
 					assignedBufferIndices: []int{3},
 				},
 				{
-					responseIndex:         4,
+					responseIndex:         3,
 					originalInput:         []byte(`{"upc":"top-5","__typename":"Product"}`),
 					assignedBufferIndices: []int{4},
 				},
 				{
-					responseIndex:         5,
+					responseIndex:         4,
 					originalInput:         []byte(`{"upc":"top-6","__typename":"Product"}`),
 					assignedBufferIndices: []int{5},
 				},
@@ -152,6 +151,42 @@ func TestBatch(t *testing.T) {
 			6,
 		)
 	})
+	t.Run("deduplicate the same args around null variables", func(t *testing.T) {
+		runTestBatch(
+			t,
+			[]string{
+				"null",
+				`{"method":"POST","url":"http://product.service","body":{"query":"query($representations: [_Any!]!){_entities(representations: $representations){... on Product {name price}}}","variables":{"representations":[{"upc":"top-1","__typename":"Product"}]}}}`,
+				"null",
+				`{"method":"POST","url":"http://product.service","body":{"query":"query($representations: [_Any!]!){_entities(representations: $representations){... on Product {name price}}}","variables":{"representations":[{"upc":"top-1","__typename":"Product"}]}}}`,
+				`{"method":"POST","url":"http://product.service","body":{"query":"query($representations: [_Any!]!){_entities(representations: $representations){... on Product {name price}}}","variables":{"representations":[{"upc":"top-2","__typename":"Product"}]}}}`,
+			},
+			`{"method":"POST","url":"http://product.service","body":{"query":"query($representations: [_Any!]!){_entities(representations: $representations){... on Product {name price}}}","variables":{"representations":[{"upc":"top-1","__typename":"Product"},{"upc":"top-2","__typename":"Product"}]}}}`,
+			[]inputResponseBufferMappings{
+				{
+					originalInput:         []byte(`null`),
+					assignedBufferIndices: []int{0},
+					skip:                  true,
+				},
+				{
+					responseIndex:         0,
+					originalInput:         []byte(`{"upc":"top-1","__typename":"Product"}`),
+					assignedBufferIndices: []int{1, 3},
+				},
+				{
+					originalInput:         []byte(`null`),
+					assignedBufferIndices: []int{2},
+					skip:                  true,
+				},
+				{
+					responseIndex:         1,
+					originalInput:         []byte(`{"upc":"top-2","__typename":"Product"}`),
+					assignedBufferIndices: []int{4},
+				},
+			},
+			5,
+		)
+	})
 	t.Run("deduplicate the same args with overlaps", func(t *testing.T) {
 		runTestBatch(
 			t,
@@ -271,6 +306,42 @@ func TestBatch_Demultiplex(t *testing.T) {
 			},
 		)
 	})
+	t.Run("demultiplex deduplicated inputs around null inputs", func(t *testing.T) {
+		runTestDemultiplex(
+			t,
+			[]string{
+				`{"method":"POST","url":"http://product.service","body":{"query":"query($representations: [_Any!]!){_entities(representations: $representations){... on Product {name price}}}","variables":{"representations":[{"upc":"top-1","__typename":"Product"}]}}}`,
+				"null",
+				`{"method":"POST","url":"http://product.service","body":{"query":"query($representations: [_Any!]!){_entities(representations: $representations){... on Product {name price}}}","variables":{"representations":[{"upc":"top-1","__typename":"Product"}]}}}`,
+				`{"method":"POST","url":"http://product.service","body":{"query":"query($representations: [_Any!]!){_entities(representations: $representations){... on Product {name price}}}","variables":{"representations":[{"upc":"top-2","__typename":"Product"}]}}}`,
+				"null",
+			},
+			newBufPair(`[{"name":"Name 1","price":1,"__typename":"Product"},{"name":"Name 2","price":2,"__typename":"Product"}]`, ""),
+			[]*resolve.BufPair{
+				newBufPair(`{"name":"Name 1","price":1,"__typename":"Product"}`, ""),
+				newBufPair(`null`, ""),
+				newBufPair(`{"name":"Name 1","price":1,"__typename":"Product"}`, ""),
+				newBufPair(`{"name":"Name 2","price":2,"__typename":"Product"}`, ""),
+				newBufPair(`null`, ""),
+			},
+		)
+	})
+	t.Run("demultiplex deduplicated inputs with fewer responses than representations", func(t *testing.T) {
+		runTestDemultiplex(
+			t,
+			[]string{
+				`{"method":"POST","url":"http://product.service","body":{"query":"query($representations: [_Any!]!){_entities(representations: $representations){... on Product {name price}}}","variables":{"representations":[{"upc":"top-1","__typename":"Product"}]}}}`,
+				`{"method":"POST","url":"http://product.service","body":{"query":"query($representations: [_Any!]!){_entities(representations: $representations){... on Product {name price}}}","variables":{"representations":[{"upc":"top-2","__typename":"Product"}]}}}`,
+				`{"method":"POST","url":"http://product.service","body":{"query":"query($representations: [_Any!]!){_entities(representations: $representations){... on Product {name price}}}","variables":{"representations":[{"upc":"top-1","__typename":"Product"}]}}}`,
+			},
+			newBufPair(`[{"name":"Name 1","price":1,"__typename":"Product"}]`, ""),
+			[]*resolve.BufPair{
+				newBufPair(`{"name":"Name 1","price":1,"__typename":"Product"}`, ""),
+				newBufPair(`null`, ""),
+				newBufPair(`{"name":"Name 1","price":1,"__typename":"Product"}`, ""),
+			},
+		)
+	})
 	t.Run("demultiplex response with error", func(t *testing.T) {
 		runTestDemultiplex(
 			t,
@@ -46,6 +46,7 @@ type Planner struct {
 	federationDepth                    int
 	extractEntities                    bool
 	fetchClient                        *http.Client
+	fetchLoader                        resolve.DataSource
 	subscriptionClient                 GraphQLSubscriptionClient
 	isNested                           bool   // isNested - flags that datasource is nested e.g. field with datasource is not on a query type
 	rootTypeName                       string // rootTypeName - holds name of top level type
@@ -62,6 +63,11 @@ type Planner struct {
 	unnulVariables          bool
 
 	parentTypeNodes []ast.Node
+	// onTypeNames are the concrete types of the entities fetched for the items of an abstract parent,
+	// the fetch is skipped for items of other types. anyEntityType is set when fields of the abstract type itself
+	// are fetched, so the fetch applies to items of all types.
+	onTypeNames   [][]byte
+	anyEntityType bool
 }
 
 func (p *Planner) parentNodeIsAbstract() bool {
@@ -89,6 +95,19 @@ func (p *Planner) EnterDirective(ref int) {
 	p.addDirectiveToNode(ref, parent)
 }
 
+// forwardsDirective reports whether the directive is forwarded to the upstream, see Configuration.ForwardedDirectives
+func (p *Planner) forwardsDirective(directiveName string) bool {
+	if len(p.config.ForwardedDirectives) == 0 {
+		return true
+	}
+	for _, forwardedDirectiveName := range p.config.ForwardedDirectives {
+		if forwardedDirectiveName == directiveName {
+			return true
+		}
+	}
+	return false
+}
+
 func (p *Planner) addDirectiveToNode(directiveRef int, node ast.Node) {
 	directiveName := p.visitor.Operation.DirectiveNameString(directiveRef)
 	operationType := ast.OperationTypeQuery
@@ -98,6 +117,9 @@ func (p *Planner) addDirectiveToNode(directiveRef int, node ast.Node) {
 	if !p.visitor.Definition.DirectiveIsAllowedOnNodeKind(directiveName, node.Kind, operationType) {
 		return
 	}
+	if !p.forwardsDirective(directiveName) {
+		return
+	}
 	upstreamDirectiveName := p.dataSourceConfig.Directives.RenameTypeNameOnMatchStr(directiveName)
 	if p.upstreamDefinition != nil && !p.upstreamDefinition.DirectiveIsAllowedOnNodeKind(upstreamDirectiveName, node.Kind, operationType) {
 		return
@@ -222,6 +244,12 @@ type Configuration struct {
 	Federation             FederationConfiguration
 	UpstreamSchema         string
 	CustomScalarTypeFields []SingleTypeField
+	// ExtractFragments hoists selection sets repeated in the upstream operation into fragments to shrink the query
+	ExtractFragments bool
+	// ForwardedDirectives lists the directives of the operation which are forwarded to the upstream, e.g. a custom
+	// @trace directive defined by the upstream. When set, other directives are consumed by the gateway, e.g. @skip and
+	// @include are evaluated without forwarding them. When empty, every directive the upstream schema allows is forwarded.
+	ForwardedDirectives []string
 }
 
 type SingleTypeField struct {
@@ -237,18 +265,27 @@ func ConfigJson(config Configuration) json.RawMessage {
 type FederationConfiguration struct {
 	Enabled    bool
 	ServiceSDL string
+	// ServiceName is the name of the subgraph, e.g. to reference it in the from argument of the @override directive
+	ServiceName string
 }
 
 type SubscriptionConfiguration struct {
 	URL           string
 	UseSSE        bool
 	SSEMethodPost bool
+	// ForwardInitPayloadFields maps fields of the connection_init payload sent by the client to fields of the
+	// connection_init payload sent to the origin, e.g. {"token": "authToken"}.
+	// Only the listed fields are forwarded, see ContextWithInitPayload.
+	ForwardInitPayloadFields map[string]string
 }
 
 type FetchConfiguration struct {
 	URL    string
 	Method string
 	Header http.Header
+	// MaxResponseBytes aborts fetches with httpclient.ErrResponseTooLarge when the response body exceeds the size,
+	// 0 doesn't limit the size
+	MaxResponseBytes int64
 }
 
 func (c *Configuration) ApplyDefaults() {
@@ -296,6 +333,7 @@ func (p *Planner) ConfigureFetch() plan.FetchConfiguration {
 
 	input = httpclient.SetInputURL(input, []byte(p.config.Fetch.URL))
 	input = httpclient.SetInputMethod(input, []byte(p.config.Fetch.Method))
+	input = httpclient.SetInputMaxResponseBytes(input, p.config.Fetch.MaxResponseBytes)
 
 	var batchConfig plan.BatchConfig
 	// Allow batch query for fetching entities.
@@ -310,6 +348,7 @@ func (p *Planner) ConfigureFetch() plan.FetchConfiguration {
 		Input: string(input),
 		DataSource: &Source{
 			httpClient: p.fetchClient,
+			loader:     p.fetchLoader,
 		},
 		Variables:            p.variables,
 		DisallowSingleFlight: p.disallowSingleFlight,
@@ -319,6 +358,7 @@ func (p *Planner) ConfigureFetch() plan.FetchConfiguration {
 		},
 		BatchConfig:                           batchConfig,
 		SetTemplateOutputToNullOnVariableNull: batchConfig.AllowBatch,
+		OnTypeNames:                           p.onTypeNames,
 	}
 }
 
@@ -341,7 +381,8 @@ func (p *Planner) ConfigureSubscription() plan.SubscriptionConfiguration {
 	return plan.SubscriptionConfiguration{
 		Input: string(input),
 		DataSource: &SubscriptionSource{
-			client: p.subscriptionClient,
+			client:                   p.subscriptionClient,
+			forwardInitPayloadFields: p.config.Subscription.ForwardInitPayloadFields,
 		},
 		Variables: p.variables,
 	}
@@ -489,10 +530,25 @@ func (p *Planner) LeaveInlineFragment(_ int) {
 
 	lastIndex := len(p.nodes) - 1
 	if p.nodes[lastIndex].Kind == ast.NodeKindInlineFragment {
+		p.removeEmptyInlineFragment(p.nodes[lastIndex].Ref)
 		p.nodes = p.nodes[:lastIndex]
 	}
 }
 
+// removeEmptyInlineFragment removes an inline fragment without selections from the upstream operation.
+// This happens for fragments on the concrete types of an abstract federated field which are resolved by other subgraphs.
+func (p *Planner) removeEmptyInlineFragment(inlineFragment int) {
+	if p.upstreamOperation.InlineFragments[inlineFragment].HasSelections &&
+		len(p.upstreamOperation.SelectionSets[p.upstreamOperation.InlineFragments[inlineFragment].SelectionSet].SelectionRefs) != 0 {
+		return
+	}
+	parent := p.nodes[len(p.nodes)-2]
+	if parent.Kind != ast.NodeKindSelectionSet {
+		return
+	}
+	p.upstreamOperation.RemoveNodeFromSelectionSet(parent.Ref, ast.Node{Kind: ast.NodeKindInlineFragment, Ref: inlineFragment})
+}
+
 func (p *Planner) EnterField(ref int) {
 	if p.insideCustomScalarField {
 		return
@@ -531,7 +587,7 @@ func (p *Planner) EnterField(ref int) {
 	// Note: federated fields always have a field configuration because at
 	// least the federation key for the type the field lives on is required
 	// (and required fields are specified in the configuration).
-	p.handleFederation(fieldConfiguration)
+	p.handleFederation(ref, fieldConfiguration)
 	p.addField(ref)
 
 	upstreamFieldRef := p.nodes[len(p.nodes)-1].Ref
@@ -583,6 +639,8 @@ func (p *Planner) EnterDocument(_, _ *ast.Document) {
 	p.upstreamVariables = nil
 	p.variables = p.variables[:0]
 	p.representationsJson = p.representationsJson[:0]
+	p.onTypeNames = nil
+	p.anyEntityType = false
 	p.disallowSingleFlight = false
 	p.hasFederationRoot = false
 	p.extractEntities = false
@@ -619,7 +677,7 @@ func (p *Planner) EnterDocument(_, _ *ast.Document) {
 func (p *Planner) LeaveDocument(_, _ *ast.Document) {
 }
 
-func (p *Planner) handleFederation(fieldConfig *plan.FieldConfiguration) {
+func (p *Planner) handleFederation(fieldRef int, fieldConfig *plan.FieldConfiguration) {
 	if !p.config.Federation.Enabled { // federation must be enabled
 		return
 	}
@@ -638,19 +696,19 @@ func (p *Planner) handleFederation(fieldConfig *plan.FieldConfiguration) {
 		// LeaveDocument, but ConfigureFetch is called before this visitor's
 		// LeaveDocument is called. (Updating the visitor logic to call
 		// LeaveDocument in reverse registration order would fix this issue.)
-		p.updateRepresentationsVariable(fieldConfig)
+		p.updateRepresentationsVariable(fieldRef, fieldConfig)
 		return
 	}
 	p.hasFederationRoot = true
 	p.federationDepth = p.visitor.Walker.Depth
 	// query($representations: [_Any!]!){_entities(representations: $representations){... on Product
-	p.addRepresentationsVariableDefinition()     // $representations: [_Any!]!
-	p.addEntitiesSelectionSet()                  // {_entities(representations: $representations)
-	p.addOnTypeInlineFragment()                  // ... on Product
-	p.updateRepresentationsVariable(fieldConfig) // "variables\":{\"representations\":[{\"upc\":\"$$0$$\",\"__typename\":\"Product\"}]}}
+	p.addRepresentationsVariableDefinition()               // $representations: [_Any!]!
+	p.addEntitiesSelectionSet()                            // {_entities(representations: $representations)
+	p.addOnTypeInlineFragment()                            // ... on Product
+	p.updateRepresentationsVariable(fieldRef, fieldConfig) // "variables\":{\"representations\":[{\"upc\":\"$$0$$\",\"__typename\":\"Product\"}]}}
 }
 
-func (p *Planner) updateRepresentationsVariable(fieldConfig *plan.FieldConfiguration) {
+func (p *Planner) updateRepresentationsVariable(fieldRef int, fieldConfig *plan.FieldConfiguration) {
 	if p.visitor.Walker.Depth != p.federationDepth {
 		// given that this field has a different depth than the federation root, we skip this field
 		// this is because we only have to handle federated fields that are part of the "current" federated request
@@ -673,15 +731,21 @@ func (p *Planner) updateRepresentationsVariable(fieldConfig *plan.FieldConfigura
 
 	// RequiresFields includes `@requires` fields as well as federation keys
 	// for the type containing the field currently being visited.
-	fields := fieldConfig.RequiresFields
+	// Entities with multiple keys use the key selected for the field.
+	fields := p.visitor.RequiredFields(fieldRef, fieldConfig)
 	if len(fields) == 0 {
 		return
 	}
 
+	enclosingTypeIsAbstract := p.visitor.Walker.EnclosingTypeDefinition.Kind.IsAbstractType()
+	if p.parentNodeIsAbstract() || enclosingTypeIsAbstract {
+		p.addOnTypeName(p.lastFieldEnclosingTypeName, enclosingTypeIsAbstract)
+	}
+
 	if len(p.representationsJson) == 0 {
-		// If the parent is an abstract type, i.e., an interface or union,
-		// the representation typename must come from a parent fetch response.
-		if p.parentNodeIsAbstract() {
+		// If the parent is an abstract type, i.e., an interface or union, or the field is selected on an interface
+		// with a @key directive, the representation typename must come from a parent fetch response.
+		if p.parentNodeIsAbstract() || enclosingTypeIsAbstract {
 			objectVariable := &resolve.ObjectVariable{
 				Path: []string{"__typename"},
 			}
@@ -719,6 +783,25 @@ func (p *Planner) updateRepresentationsVariable(fieldConfig *plan.FieldConfigura
 	p.extractEntities = true
 }
 
+// addOnTypeName restricts the fetch to entities of the type, unless fields of an abstract type are fetched
+func (p *Planner) addOnTypeName(typeName string, isAbstract bool) {
+	if p.anyEntityType {
+		return
+	}
+	if isAbstract {
+		p.anyEntityType = true
+		p.onTypeNames = nil
+		return
+	}
+	onTypeName := p.visitor.Config.Types.RenameTypeNameOnMatchBytes([]byte(typeName))
+	for i := range p.onTypeNames {
+		if bytes.Equal(p.onTypeNames[i], onTypeName) {
+			return
+		}
+	}
+	p.onTypeNames = append(p.onTypeNames, onTypeName)
+}
+
 func (p *Planner) fieldDefinition(fieldName, typeName string) *ast.FieldDefinition {
 	node, ok := p.visitor.Definition.Index.FirstNodeByNameStr(typeName)
 	if !ok {
@@ -842,6 +925,7 @@ func (p *Planner) configureArgument(upstreamFieldRef, downstreamFieldRef int, fi
 func (p *Planner) configureFieldArgumentSource(upstreamFieldRef, downstreamFieldRef int, argumentConfiguration plan.ArgumentConfiguration) {
 	fieldArgument, ok := p.visitor.Operation.FieldArgument(downstreamFieldRef, []byte(argumentConfiguration.Name))
 	if !ok {
+		p.applyDefaultFieldArgument(upstreamFieldRef, downstreamFieldRef, argumentConfiguration.Name)
 		return
 	}
 	value := p.visitor.Operation.ArgumentValue(fieldArgument)
@@ -918,6 +1002,26 @@ func (p *Planner) applyInlineFieldArgument(upstreamField, downstreamField int, a
 	p.addVariableDefinitionsRecursively(value, sourcePath, nil)
 }
 
+// applyDefaultFieldArgument - adds the default value of an argument absent from the operation to the upstream field,
+// as some upstreams don't apply the defaults of their arguments themselves
+func (p *Planner) applyDefaultFieldArgument(upstreamField, downstreamField int, argumentName string) {
+	fieldName := p.visitor.Operation.FieldNameBytes(downstreamField)
+	argumentDefinition := p.visitor.Definition.NodeFieldDefinitionArgumentDefinitionByName(p.visitor.Walker.EnclosingTypeDefinition, fieldName, []byte(argumentName))
+	if argumentDefinition == -1 || !p.visitor.Definition.InputValueDefinitionHasDefaultValue(argumentDefinition) {
+		return
+	}
+	defaultValue := p.visitor.Definition.InputValueDefinitionDefaultValue(argumentDefinition)
+	if defaultValue.Kind == ast.ValueKindNull {
+		return
+	}
+	importedValue := p.visitor.Importer.ImportValue(defaultValue, p.visitor.Definition, p.upstreamOperation)
+	argRef := p.upstreamOperation.AddArgument(ast.Argument{
+		Name:  p.upstreamOperation.Input.AppendInputString(argumentName),
+		Value: importedValue,
+	})
+	p.upstreamOperation.AddArgumentToField(upstreamField, argRef)
+}
+
 // resolveNestedArgumentType - extracts type of nested field or array element of argument
 // fieldName - exists only for ast.ValueKindObject type of argument
 func (p *Planner) resolveNestedArgumentType(fieldName []byte) (fieldTypeRef int) {
@@ -1121,6 +1225,15 @@ func (p *Planner) printOperation() []byte {
 		return nil
 	}
 
+	if p.config.ExtractFragments {
+		normalizer := astnormalization.NewWithOpts(astnormalization.WithExtractFragments())
+		normalizer.NormalizeOperation(operation, definition, report)
+		if report.HasErrors() {
+			p.stopWithError(normalizationFailedErrMsg)
+			return nil
+		}
+	}
+
 	buf.Reset()
 
 	// print upstream operation
@@ -1301,6 +1414,9 @@ type Factory struct {
 	StreamingClient            *http.Client
 	OnWsConnectionInitCallback *OnWsConnectionInitCallback
 	SubscriptionClient         *SubscriptionClient
+	// Loader loads the requests to the upstream instead of the HTTPClient if set,
+	// e.g. to resolve them with an in-memory subgraph in tests
+	Loader resolve.DataSource
 }
 
 func (f *Factory) Planner(ctx context.Context) plan.DataSourcePlanner {
@@ -1317,12 +1433,27 @@ func (f *Factory) Planner(ctx context.Context) plan.DataSourcePlanner {
 	return &Planner{
 		batchFactory:       f.BatchFactory,
 		fetchClient:        f.HTTPClient,
+		fetchLoader:        f.Loader,
 		subscriptionClient: f.SubscriptionClient,
 	}
 }
 
+// FetchDataSource returns the data source of the fetches of the planners of the factory, see plan.FetchSourceFactory
+func (f *Factory) FetchDataSource() resolve.DataSource {
+	return &Source{
+		httpClient: f.HTTPClient,
+		loader:     f.Loader,
+	}
+}
+
+// FetchBatchFactory returns the batch factory of the entity fetches of the planners of the factory
+func (f *Factory) FetchBatchFactory() resolve.DataSourceBatchFactory {
+	return f.BatchFactory
+}
+
 type Source struct {
 	httpClient *http.Client
+	loader     resolve.DataSource
 }
 
 func (s *Source) compactAndUnNullVariables(input []byte) []byte {
@@ -1409,6 +1540,9 @@ func (s *Source) replaceEmptyObject(variables []byte) ([]byte, bool) {
 
 func (s *Source) Load(ctx context.Context, input []byte, writer io.Writer) (err error) {
 	input = s.compactAndUnNullVariables(input)
+	if s.loader != nil {
+		return s.loader.Load(ctx, input, writer)
+	}
 	return httpclient.Do(s.httpClient, ctx, input, writer)
 }
 
@@ -1422,6 +1556,8 @@ type GraphQLSubscriptionOptions struct {
 	Header        http.Header `json:"header"`
 	UseSSE        bool        `json:"use_sse"`
 	SSEMethodPost bool        `json:"sse_method_post"`
+	// InitPayload is the payload of the connection_init message sent to the origin
+	InitPayload json.RawMessage `json:"init_payload,omitempty"`
 }
 
 type GraphQLBody struct {
@@ -1432,7 +1568,8 @@ type GraphQLBody struct {
 }
 
 type SubscriptionSource struct {
-	client GraphQLSubscriptionClient
+	client                   GraphQLSubscriptionClient
+	forwardInitPayloadFields map[string]string
 }
 
 func (s *SubscriptionSource) Start(ctx context.Context, input []byte, next chan<- []byte) error {
@@ -1444,5 +1581,9 @@ func (s *SubscriptionSource) Start(ctx context.Context, input []byte, next chan<
 	if options.Body.Query == "" {
 		return resolve.ErrUnableToResolve
 	}
+	options.InitPayload, err = forwardInitPayload(InitPayloadFromContext(ctx), s.forwardInitPayloadFields)
+	if err != nil {
+		return err
+	}
 	return s.client.Subscribe(ctx, options, next)
 }
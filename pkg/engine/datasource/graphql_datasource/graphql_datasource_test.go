@@ -16,10 +16,14 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/wundergraph/graphql-go-tools/internal/pkg/unsafeparser"
+	"github.com/wundergraph/graphql-go-tools/pkg/astnormalization"
+	"github.com/wundergraph/graphql-go-tools/pkg/asttransform"
 	"github.com/wundergraph/graphql-go-tools/pkg/engine/datasource/httpclient"
 	. "github.com/wundergraph/graphql-go-tools/pkg/engine/datasourcetesting"
 	"github.com/wundergraph/graphql-go-tools/pkg/engine/plan"
 	"github.com/wundergraph/graphql-go-tools/pkg/engine/resolve"
+	"github.com/wundergraph/graphql-go-tools/pkg/operationreport"
 	"github.com/wundergraph/graphql-go-tools/pkg/testing/subscriptiontesting"
 )
 
@@ -3425,10 +3429,8 @@ func TestGraphQLDataSource(t *testing.T) {
 					Fields: []*resolve.Field{
 						{
 							Name: []byte("__typename"),
-							Value: &resolve.String{
-								Path:       []string{"__typename"},
-								Nullable:   false,
-								IsTypeName: true,
+							Value: &resolve.StaticString{
+								Value: "Mutation",
 							},
 						},
 						{
@@ -3664,7 +3666,7 @@ func TestGraphQLDataSource(t *testing.T) {
 			Trigger: resolve.GraphQLSubscriptionTrigger{
 				Input: []byte(`{"url":"wss://swapi.com/graphql","body":{"query":"subscription{remainingJedis}"}}`),
 				Source: &SubscriptionSource{
-					NewGraphQLSubscriptionClient(http.DefaultClient, http.DefaultClient, ctx),
+					client: NewGraphQLSubscriptionClient(http.DefaultClient, http.DefaultClient, ctx),
 				},
 			},
 			Response: &resolve.GraphQLResponse{
@@ -5877,19 +5879,19 @@ func TestGraphQLDataSource(t *testing.T) {
 											ExtractFederationEntities: true,
 										},
 										SetTemplateOutputToNullOnVariableNull: true,
+										OnTypeNames:                           [][]byte{[]byte("User")},
 									},
 									BatchFactory: batchFactory,
 								},
 								Path:     []string{"self"},
 								Nullable: true,
 								Fields: []*resolve.Field{
-									// there is a bug with interfaces that consumes this
-									//{
-									//	Name: []byte("id"),
-									//	Value: &resolve.String{
-									//		Path: []string{"id"},
-									//	},
-									//},
+									{
+										Name: []byte("id"),
+										Value: &resolve.String{
+											Path: []string{"id"},
+										},
+									},
 									{
 										Name: []byte("__typename"),
 										Value: &resolve.String{
@@ -6072,6 +6074,7 @@ func TestGraphQLDataSource(t *testing.T) {
 											ExtractFederationEntities: true,
 										},
 										SetTemplateOutputToNullOnVariableNull: true,
+										OnTypeNames:                           [][]byte{[]byte("User")},
 									},
 									BatchFactory: batchFactory,
 								},
@@ -7289,6 +7292,7 @@ func TestGraphQLDataSource(t *testing.T) {
 															ExtractFederationEntities: true,
 														},
 														SetTemplateOutputToNullOnVariableNull: true,
+														OnTypeNames:                           [][]byte{[]byte("Cat"), []byte("Dog")},
 													},
 													BatchFactory: batchFactory,
 												},
@@ -7611,6 +7615,159 @@ func TestGraphQLDataSource(t *testing.T) {
 		DisableResolveFieldPositions: true,
 	}))
 
+	t.Run("extract fragments", RunTest(userSchema, `
+		query Users {
+			a: user(id: "1") { id name tier userId: id userName: name }
+			b: user(id: "2") { id name tier userId: id userName: name }
+		}
+	`, "Users", &plan.SynchronousResponsePlan{
+		Response: &resolve.GraphQLResponse{
+			Data: &resolve.Object{
+				Fetch: &resolve.SingleFetch{
+					DataSource: &Source{},
+					BufferId:   0,
+					Input:      `{"method":"POST","url":"http://localhost:8084/query","body":{"query":"query($a: ID!, $b: ID!){a: user(id: $a){...f0} b: user(id: $b){...f0}} fragment f0 on User {id name tier userId: id userName: name}","variables":{"b":$$1$$,"a":$$0$$}}}`,
+					Variables: resolve.NewVariables(
+						&resolve.ContextVariable{
+							Path:     []string{"a"},
+							Renderer: resolve.NewJSONVariableRendererWithValidation(`{"type":["string","integer"]}`),
+						},
+						&resolve.ContextVariable{
+							Path:     []string{"b"},
+							Renderer: resolve.NewJSONVariableRendererWithValidation(`{"type":["string","integer"]}`),
+						},
+					),
+					DataSourceIdentifier:  []byte("graphql_datasource.Source"),
+					ProcessResponseConfig: resolve.ProcessResponseConfig{ExtractGraphqlResponse: true},
+				},
+				Fields: []*resolve.Field{
+					{
+						HasBuffer: true,
+						BufferID:  0,
+						Name:      []byte("a"),
+						Value: &resolve.Object{
+							Path:     []string{"a"},
+							Nullable: true,
+							Fields: []*resolve.Field{
+								{
+									Name: []byte("id"),
+									Value: &resolve.String{
+										Path: []string{"id"},
+									},
+								},
+								{
+									Name: []byte("name"),
+									Value: &resolve.String{
+										Path: []string{"name"},
+									},
+								},
+								{
+									Name: []byte("tier"),
+									Value: &resolve.String{
+										Nullable: true,
+										Path:     []string{"tier"},
+									},
+								},
+								{
+									Name: []byte("userId"),
+									Value: &resolve.String{
+										Path: []string{"userId"},
+									},
+								},
+								{
+									Name: []byte("userName"),
+									Value: &resolve.String{
+										Path: []string{"userName"},
+									},
+								},
+							},
+						},
+					},
+					{
+						HasBuffer: true,
+						BufferID:  0,
+						Name:      []byte("b"),
+						Value: &resolve.Object{
+							Path:     []string{"b"},
+							Nullable: true,
+							Fields: []*resolve.Field{
+								{
+									Name: []byte("id"),
+									Value: &resolve.String{
+										Path: []string{"id"},
+									},
+								},
+								{
+									Name: []byte("name"),
+									Value: &resolve.String{
+										Path: []string{"name"},
+									},
+								},
+								{
+									Name: []byte("tier"),
+									Value: &resolve.String{
+										Nullable: true,
+										Path:     []string{"tier"},
+									},
+								},
+								{
+									Name: []byte("userId"),
+									Value: &resolve.String{
+										Path: []string{"userId"},
+									},
+								},
+								{
+									Name: []byte("userName"),
+									Value: &resolve.String{
+										Path: []string{"userName"},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}, plan.Configuration{
+		DataSources: []plan.DataSourceConfiguration{
+			{
+				RootNodes: []plan.TypeField{
+					{
+						TypeName:   "Query",
+						FieldNames: []string{"user"},
+					},
+				},
+				ChildNodes: []plan.TypeField{
+					{
+						TypeName:   "User",
+						FieldNames: []string{"id", "name", "tier", "meta"},
+					},
+				},
+				Factory: &Factory{},
+				Custom: ConfigJson(Configuration{
+					Fetch: FetchConfiguration{
+						URL: "http://localhost:8084/query",
+					},
+					UpstreamSchema:   userSchema,
+					ExtractFragments: true,
+				}),
+			},
+		},
+		Fields: []plan.FieldConfiguration{
+			{
+				TypeName:  "Query",
+				FieldName: "user",
+				Arguments: []plan.ArgumentConfiguration{
+					{
+						Name:       "id",
+						SourceType: plan.FieldArgumentSource,
+					},
+				},
+			},
+		},
+		DisableResolveFieldPositions: true,
+	}))
+
 	t.Run("custom scalar type fields", RunTest(customUserSchema, `
 		query Custom($id: ID!) {
           custom_user(id: $id) {
@@ -8256,20 +8413,180 @@ func TestUnNullVariables(t *testing.T) {
 	})
 }
 
+type _recordingDataSource struct {
+	_fakeDataSource
+	mu     sync.Mutex
+	inputs []string
+}
+
+func (r *_recordingDataSource) Load(ctx context.Context, input []byte, w io.Writer) (err error) {
+	r.mu.Lock()
+	r.inputs = append(r.inputs, string(input))
+	r.mu.Unlock()
+	return r._fakeDataSource.Load(ctx, input, w)
+}
+
+func TestFederationBatching_DeduplicatesRepresentations(t *testing.T) {
+	userService := FakeDataSource(`{"data":{"me": {"id": "1234","username": "Me","__typename": "User"}}}`)
+	reviewsService := FakeDataSource(`{"data":{"_entities":[{"reviews": [{"body": "A highly effective form of birth control.","product": {"upc": "top-1","__typename": "Product"}},{"body": "Fedoras are one of the most fashionable hats around and can look great with a variety of outfits.","product": {"upc": "top-2","__typename": "Product"}},{"body": "Trilbies are the better fedoras.","product": {"upc": "top-1","__typename": "Product"}}]}]}}`)
+	productsService := &_recordingDataSource{
+		_fakeDataSource: _fakeDataSource{data: []byte(`{"data":{"_entities":[{"name": "Trilby"},{"name": "Fedora"}]}}`)},
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	resolver := resolve.New(ctx, resolve.NewFetcher(false), true)
+
+	buf := &bytes.Buffer{}
+	err := resolver.ResolveGraphQLResponse(resolve.NewContext(context.Background()), federationBatchingResponse(userService, reviewsService, productsService), nil, buf)
+	require.NoError(t, err)
+	assert.Equal(t, `{"data":{"me":{"id":"1234","username":"Me","reviews":[{"body":"A highly effective form of birth control.","product":{"upc":"top-1","name":"Trilby"}},{"body":"Fedoras are one of the most fashionable hats around and can look great with a variety of outfits.","product":{"upc":"top-2","name":"Fedora"}},{"body":"Trilbies are the better fedoras.","product":{"upc":"top-1","name":"Trilby"}}]}}}`, buf.String())
+
+	// both reviews of top-1 share a single representation of the batched entity fetch
+	require.Len(t, productsService.inputs, 1)
+	assert.Contains(t, productsService.inputs[0], `"representations":[{"upc":"top-1","__typename":"Product"},{"upc":"top-2","__typename":"Product"}]`)
+}
+
+func TestGraphQLDataSource_ArgumentDefaultValues(t *testing.T) {
+	definition := unsafeparser.ParseGraphqlDocumentString(`
+		type Query {
+			topProducts(first: Int = 5, after: String): [Product]
+		}
+		type Product {
+			upc: String!
+		}
+	`)
+	require.NoError(t, asttransform.MergeDefinitionWithBaseSchema(&definition))
+
+	config := plan.Configuration{
+		DataSources: []plan.DataSourceConfiguration{
+			{
+				RootNodes: []plan.TypeField{
+					{
+						TypeName:   "Query",
+						FieldNames: []string{"topProducts"},
+					},
+				},
+				ChildNodes: []plan.TypeField{
+					{
+						TypeName:   "Product",
+						FieldNames: []string{"upc"},
+					},
+				},
+				Custom: ConfigJson(Configuration{
+					Fetch: FetchConfiguration{
+						URL: "http://products.service",
+					},
+				}),
+				Factory: &Factory{},
+			},
+		},
+		Fields: []plan.FieldConfiguration{
+			{
+				TypeName:  "Query",
+				FieldName: "topProducts",
+				Arguments: []plan.ArgumentConfiguration{
+					{
+						Name:       "first",
+						SourceType: plan.FieldArgumentSource,
+					},
+					{
+						Name:       "after",
+						SourceType: plan.FieldArgumentSource,
+					},
+				},
+			},
+		},
+		DisableResolveFieldPositions: true,
+	}
+
+	run := func(operation, expectedInput string) func(t *testing.T) {
+		return func(t *testing.T) {
+			op := unsafeparser.ParseGraphqlDocumentString(operation)
+			report := operationreport.Report{}
+			// variables aren't extracted, so the arguments absent from the operation are left to the planner
+			astnormalization.NewNormalizer(true, false).NormalizeOperation(&op, &definition, &report)
+			require.False(t, report.HasErrors(), report.Error())
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			actualPlan := plan.NewPlanner(ctx, config).Plan(&op, &definition, "", &report)
+			require.False(t, report.HasErrors(), report.Error())
+
+			fetch := actualPlan.(*plan.SynchronousResponsePlan).Response.Data.(*resolve.Object).Fetch.(*resolve.SingleFetch)
+			assert.Equal(t, expectedInput, fetch.Input)
+		}
+	}
+
+	t.Run("injects the default value of an omitted argument", run(
+		`{ topProducts { upc } }`,
+		`{"method":"POST","url":"http://products.service","body":{"query":"{topProducts(first: 5){upc}}"}}`,
+	))
+	t.Run("keeps the value of a provided argument", run(
+		`{ topProducts(first: 10) { upc } }`,
+		`{"method":"POST","url":"http://products.service","body":{"query":"{topProducts(first: 10){upc}}"}}`,
+	))
+}
+
 func BenchmarkFederationBatching(b *testing.B) {
 	userService := FakeDataSource(`{"data":{"me": {"id": "1234","username": "Me","__typename": "User"}}}`)
 	reviewsService := FakeDataSource(`{"data":{"_entities":[{"reviews": [{"body": "A highly effective form of birth control.","product": {"upc": "top-1","__typename": "Product"}},{"body": "Fedoras are one of the most fashionable hats around and can look great with a variety of outfits.","product": {"upc": "top-2","__typename": "Product"}}]}]}}`)
 	productsService := FakeDataSource(`{"data":{"_entities":[{"name": "Trilby"},{"name": "Fedora"}]}}`)
 
-	reviewBatchFactory := NewBatchFactory()
-	productBatchFactory := NewBatchFactory()
-
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
 	resolver := resolve.New(ctx, resolve.NewFetcher(true), true)
 
-	preparedPlan := &resolve.GraphQLResponse{
+	preparedPlan := federationBatchingResponse(userService, reviewsService, productsService)
+
+	var err error
+	expected := []byte(`{"data":{"me":{"id":"1234","username":"Me","reviews":[{"body":"A highly effective form of birth control.","product":{"upc":"top-1","name":"Trilby"}},{"body":"Fedoras are one of the most fashionable hats around and can look great with a variety of outfits.","product":{"upc":"top-2","name":"Fedora"}}]}}}`)
+
+	pool := sync.Pool{
+		New: func() interface{} {
+			return bytes.NewBuffer(make([]byte, 0, 1024))
+		},
+	}
+
+	ctxPool := sync.Pool{
+		New: func() interface{} {
+			return resolve.NewContext(context.Background())
+		},
+	}
+
+	b.ReportAllocs()
+	b.SetBytes(int64(len(expected)))
+	b.ResetTimer()
+
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			// _ = resolver.ResolveGraphQLResponse(ctx, plan, nil, ioutil.Discard)
+			ctx := ctxPool.Get().(*resolve.Context)
+			buf := pool.Get().(*bytes.Buffer)
+			err = resolver.ResolveGraphQLResponse(ctx, preparedPlan, nil, buf)
+			if err != nil {
+				b.Fatal(err)
+			}
+			if !bytes.Equal(expected, buf.Bytes()) {
+				b.Fatalf("want:\n%s\ngot:\n%s\n", string(expected), buf.String())
+			}
+
+			buf.Reset()
+			pool.Put(buf)
+
+			ctx.Free()
+			ctxPool.Put(ctx)
+		}
+	})
+}
+
+// federationBatchingResponse is the response of the query { me { id username reviews { body product { upc name } } } }
+// with batched entity fetches for the reviews of the user and their products
+func federationBatchingResponse(userService, reviewsService, productsService resolve.DataSource) *resolve.GraphQLResponse {
+	return &resolve.GraphQLResponse{
 		Data: &resolve.Object{
 			Fetch: &resolve.SingleFetch{
 				BufferId: 0,
@@ -8319,7 +8636,7 @@ func BenchmarkFederationBatching(b *testing.B) {
 									ExtractFederationEntities: true,
 								},
 							},
-							BatchFactory: reviewBatchFactory,
+							BatchFactory: NewBatchFactory(),
 						},
 						Path:     []string{"me"},
 						Nullable: true,
@@ -8384,7 +8701,7 @@ func BenchmarkFederationBatching(b *testing.B) {
 																ExtractFederationEntities: true,
 															},
 														},
-														BatchFactory: productBatchFactory,
+														BatchFactory: NewBatchFactory(),
 													},
 													Fields: []*resolve.Field{
 														{
@@ -8415,45 +8732,6 @@ func BenchmarkFederationBatching(b *testing.B) {
 		},
 	}
 
-	var err error
-	expected := []byte(`{"data":{"me":{"id":"1234","username":"Me","reviews":[{"body":"A highly effective form of birth control.","product":{"upc":"top-1","name":"Trilby"}},{"body":"Fedoras are one of the most fashionable hats around and can look great with a variety of outfits.","product":{"upc":"top-2","name":"Fedora"}}]}}}`)
-
-	pool := sync.Pool{
-		New: func() interface{} {
-			return bytes.NewBuffer(make([]byte, 0, 1024))
-		},
-	}
-
-	ctxPool := sync.Pool{
-		New: func() interface{} {
-			return resolve.NewContext(context.Background())
-		},
-	}
-
-	b.ReportAllocs()
-	b.SetBytes(int64(len(expected)))
-	b.ResetTimer()
-
-	b.RunParallel(func(pb *testing.PB) {
-		for pb.Next() {
-			// _ = resolver.ResolveGraphQLResponse(ctx, plan, nil, ioutil.Discard)
-			ctx := ctxPool.Get().(*resolve.Context)
-			buf := pool.Get().(*bytes.Buffer)
-			err = resolver.ResolveGraphQLResponse(ctx, preparedPlan, nil, buf)
-			if err != nil {
-				b.Fatal(err)
-			}
-			if !bytes.Equal(expected, buf.Bytes()) {
-				b.Fatalf("want:\n%s\ngot:\n%s\n", string(expected), buf.String())
-			}
-
-			buf.Reset()
-			pool.Put(buf)
-
-			ctx.Free()
-			ctxPool.Put(ctx)
-		}
-	})
 }
 
 const interfaceSelectionSchema = `
@@ -2,9 +2,11 @@ package graphql_datasource
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"math"
 	"net/http"
+	"strconv"
 	"sync"
 	"time"
 
@@ -29,6 +31,8 @@ type SubscriptionClient struct {
 	handlersMu                 sync.Mutex
 	wsSubProtocol              string
 	onWsConnectionInitCallback *OnWsConnectionInitCallback
+	reconnect                  ReconnectOptions
+	multiplexer                *subscriptionMultiplexer
 
 	readTimeout time.Duration
 }
@@ -59,11 +63,32 @@ func WithOnWsConnectionInitCallback(callback *OnWsConnectionInitCallback) Option
 	}
 }
 
+// WithReconnect enables re-establishing dropped WebSocket connections to the origin.
+// Active subscriptions are resubscribed on the new connection.
+// If all attempts fail, every subscription receives an error describing the lost connection.
+func WithReconnect(reconnect ReconnectOptions) Options {
+	return func(options *opts) {
+		options.reconnect = reconnect
+	}
+}
+
+// WithMultiplexing shares one upstream subscription between identical subscriptions.
+// Subscriptions are identical if URL, headers and body (query, operation name and variables) are equal.
+// Each message of the upstream subscription is sent to all of them,
+// the upstream subscription is stopped when the last one is done.
+func WithMultiplexing() Options {
+	return func(options *opts) {
+		options.multiplexing = true
+	}
+}
+
 type opts struct {
 	readTimeout                time.Duration
 	log                        abstractlogger.Logger
 	wsSubProtocol              string
 	onWsConnectionInitCallback *OnWsConnectionInitCallback
+	reconnect                  ReconnectOptions
+	multiplexing               bool
 }
 
 // GraphQLSubscriptionClientFactory abstracts the way of creating a new GraphQLSubscriptionClient.
@@ -86,7 +111,7 @@ func NewGraphQLSubscriptionClient(httpClient, streamingClient *http.Client, engi
 	for _, option := range options {
 		option(op)
 	}
-	return &SubscriptionClient{
+	client := &SubscriptionClient{
 		httpClient:      httpClient,
 		streamingClient: streamingClient,
 		engineCtx:       engineCtx,
@@ -100,14 +125,64 @@ func NewGraphQLSubscriptionClient(httpClient, streamingClient *http.Client, engi
 		},
 		wsSubProtocol:              op.wsSubProtocol,
 		onWsConnectionInitCallback: op.onWsConnectionInitCallback,
+		reconnect:                  op.reconnect,
+	}
+	if op.multiplexing {
+		client.multiplexer = newSubscriptionMultiplexer()
 	}
+	return client
 }
 
 // Subscribe initiates a new GraphQL Subscription with the origin
 // If an existing WS connection with the same ID (Hash) exists, it is being re-used
 // If connection protocol is SSE, a new connection is always created
 // If no connection exists, the client initiates a new one
+// If multiplexing is enabled, identical subscriptions share one upstream subscription
+// The upstream subscription is reported to the observer of the context, see ContextWithUpstreamSubscriptionObserver
 func (c *SubscriptionClient) Subscribe(reqCtx context.Context, options GraphQLSubscriptionOptions, next chan<- []byte) error {
+	if c.multiplexer == nil {
+		if err := c.subscribe(reqCtx, options, next); err != nil {
+			return err
+		}
+		return c.observeUpstreamSubscription(reqCtx, options, 0)
+	}
+
+	streamID, err := c.generateStreamIDHash(options)
+	if err != nil {
+		return err
+	}
+	err = c.multiplexer.subscribe(c.engineCtx, reqCtx, streamID, next, func(ctx context.Context, next chan<- []byte) error {
+		return c.subscribe(ctx, options, next)
+	})
+	if err != nil {
+		return err
+	}
+	return c.observeUpstreamSubscription(reqCtx, options, streamID)
+}
+
+// observeUpstreamSubscription reports the upstream subscription to the observer of the context, if any
+func (c *SubscriptionClient) observeUpstreamSubscription(reqCtx context.Context, options GraphQLSubscriptionOptions, streamID uint64) error {
+	observer := upstreamSubscriptionObserverFromContext(reqCtx)
+	if observer == nil {
+		return nil
+	}
+	upstream := UpstreamSubscription{
+		URL:      options.URL,
+		SSE:      options.UseSSE,
+		StreamID: streamID,
+	}
+	if !options.UseSSE {
+		connectionID, err := c.generateHandlerIDHash(options)
+		if err != nil {
+			return err
+		}
+		upstream.ConnectionID = connectionID
+	}
+	observer(upstream)
+	return nil
+}
+
+func (c *SubscriptionClient) subscribe(reqCtx context.Context, options GraphQLSubscriptionOptions, next chan<- []byte) error {
 	if options.UseSSE {
 		return c.subscribeSSE(reqCtx, options, next)
 	}
@@ -180,7 +255,7 @@ func (c *SubscriptionClient) subscribeWS(reqCtx context.Context, options GraphQL
 	return nil
 }
 
-// generateHandlerIDHash generates a Hash based on: URL and Headers to uniquely identify Upgrade Requests
+// generateHandlerIDHash generates a Hash based on: URL, Headers and the connection_init payload to uniquely identify Upgrade Requests
 func (c *SubscriptionClient) generateHandlerIDHash(options GraphQLSubscriptionOptions) (uint64, error) {
 	var (
 		err error
@@ -197,17 +272,76 @@ func (c *SubscriptionClient) generateHandlerIDHash(options GraphQLSubscriptionOp
 	if err != nil {
 		return 0, err
 	}
+	_, err = xxh.Write(options.InitPayload)
+	if err != nil {
+		return 0, err
+	}
+
+	return xxh.Sum64(), nil
+}
+
+// generateStreamIDHash generates a Hash based on: URL, Headers, the connection_init payload and Body to uniquely identify a multiplexed subscription
+func (c *SubscriptionClient) generateStreamIDHash(options GraphQLSubscriptionOptions) (uint64, error) {
+	xxh := c.hashPool.Get().(*xxhash.Digest)
+	defer c.hashPool.Put(xxh)
+	xxh.Reset()
+
+	body, err := json.Marshal(options.Body)
+	if err != nil {
+		return 0, err
+	}
+	if _, err = xxh.WriteString(options.URL); err != nil {
+		return 0, err
+	}
+	if err = options.Header.Write(xxh); err != nil {
+		return 0, err
+	}
+	if _, err = xxh.Write(body); err != nil {
+		return 0, err
+	}
+	if _, err = xxh.Write(options.InitPayload); err != nil {
+		return 0, err
+	}
+	if _, err = xxh.WriteString(strconv.FormatBool(options.UseSSE) + strconv.FormatBool(options.SSEMethodPost)); err != nil {
+		return 0, err
+	}
 
 	return xxh.Sum64(), nil
 }
 
 func (c *SubscriptionClient) newWSConnectionHandler(reqCtx context.Context, options GraphQLSubscriptionOptions) (ConnectionHandler, error) {
+	conn, err := c.dialWS(reqCtx, options)
+	if err != nil {
+		return nil, err
+	}
+
+	if c.wsSubProtocol == "" {
+		c.wsSubProtocol = conn.Subprotocol()
+	}
+
+	// reconnecting must not depend on the request context of the first subscriber
+	reconnector := newReconnector(c.reconnect, func(ctx context.Context) (*websocket.Conn, error) {
+		return c.dialWS(ctx, options)
+	}, c.log)
+
+	switch c.wsSubProtocol {
+	case ProtocolGraphQLWS:
+		return newGQLWSConnectionHandler(c.engineCtx, conn, c.readTimeout, c.log, reconnector), nil
+	case ProtocolGraphQLTWS:
+		return newGQLTWSConnectionHandler(c.engineCtx, conn, c.readTimeout, c.log, reconnector), nil
+	default:
+		return nil, fmt.Errorf("unknown protocol %s", conn.Subprotocol())
+	}
+}
+
+// dialWS establishes a WebSocket connection to the origin and waits for the connection_ack
+func (c *SubscriptionClient) dialWS(ctx context.Context, options GraphQLSubscriptionOptions) (*websocket.Conn, error) {
 	subProtocols := []string{ProtocolGraphQLWS, ProtocolGraphQLTWS}
 	if c.wsSubProtocol != "" {
 		subProtocols = []string{c.wsSubProtocol}
 	}
 
-	conn, upgradeResponse, err := websocket.Dial(reqCtx, options.URL, &websocket.DialOptions{
+	conn, upgradeResponse, err := websocket.Dial(ctx, options.URL, &websocket.DialOptions{
 		HTTPClient:      c.httpClient,
 		HTTPHeader:      options.Header,
 		CompressionMode: websocket.CompressionDisabled,
@@ -223,45 +357,41 @@ func (c *SubscriptionClient) newWSConnectionHandler(reqCtx context.Context, opti
 		return nil, fmt.Errorf("upgrade unsuccessful")
 	}
 
-	connectionInitMessage, err := c.getConnectionInitMessage(reqCtx, options.URL, options.Header)
+	connectionInitMessage, err := c.getConnectionInitMessage(ctx, options)
 	if err != nil {
 		return nil, err
 	}
 
 	// init + ack
-	err = conn.Write(reqCtx, websocket.MessageText, connectionInitMessage)
+	err = conn.Write(ctx, websocket.MessageText, connectionInitMessage)
 	if err != nil {
 		return nil, err
 	}
 
-	if c.wsSubProtocol == "" {
-		c.wsSubProtocol = conn.Subprotocol()
-	}
-
-	if err := waitForAck(reqCtx, conn); err != nil {
+	if err := waitForAck(ctx, conn); err != nil {
 		return nil, err
 	}
 
-	switch c.wsSubProtocol {
-	case ProtocolGraphQLWS:
-		return newGQLWSConnectionHandler(c.engineCtx, conn, c.readTimeout, c.log), nil
-	case ProtocolGraphQLTWS:
-		return newGQLTWSConnectionHandler(c.engineCtx, conn, c.readTimeout, c.log), nil
-	default:
-		return nil, fmt.Errorf("unknown protocol %s", conn.Subprotocol())
-	}
+	return conn, nil
 }
 
-func (c *SubscriptionClient) getConnectionInitMessage(ctx context.Context, url string, header http.Header) ([]byte, error) {
-	if c.onWsConnectionInitCallback == nil {
-		return connectionInitMessage, nil
-	}
+// getConnectionInitMessage returns the connection_init message with the forwarded payload of the client,
+// fields returned by the OnWsConnectionInitCallback take precedence over forwarded fields
+func (c *SubscriptionClient) getConnectionInitMessage(ctx context.Context, options GraphQLSubscriptionOptions) ([]byte, error) {
+	payload := options.InitPayload
 
-	callback := *c.onWsConnectionInitCallback
+	if c.onWsConnectionInitCallback != nil {
+		callback := *c.onWsConnectionInitCallback
 
-	payload, err := callback(ctx, url, header)
-	if err != nil {
-		return nil, err
+		callbackPayload, err := callback(ctx, options.URL, options.Header)
+		if err != nil {
+			return nil, err
+		}
+
+		payload, err = mergeInitPayloads(payload, callbackPayload)
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	if len(payload) == 0 {
@@ -36,9 +36,10 @@ func TestGetConnectionInitMessageHelper(t *testing.T) {
 	}
 
 	tests := []struct {
-		name     string
-		callback *OnWsConnectionInitCallback
-		want     string
+		name        string
+		callback    *OnWsConnectionInitCallback
+		initPayload json.RawMessage
+		want        string
 	}{
 		{
 			name:     "without payload",
@@ -50,12 +51,24 @@ func TestGetConnectionInitMessageHelper(t *testing.T) {
 			callback: &callback,
 			want:     `{"type":"connection_init","payload":{"authorization":"secret"}}`,
 		},
+		{
+			name:        "with forwarded payload",
+			callback:    nil,
+			initPayload: json.RawMessage(`{"token":"client"}`),
+			want:        `{"type":"connection_init","payload":{"token":"client"}}`,
+		},
+		{
+			name:        "with forwarded payload and payload",
+			callback:    &callback,
+			initPayload: json.RawMessage(`{"authorization":"client","token":"client"}`),
+			want:        `{"type":"connection_init","payload":{"authorization":"secret","token":"client"}}`,
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			client := SubscriptionClient{onWsConnectionInitCallback: tt.callback}
-			got, err := client.getConnectionInitMessage(context.Background(), "", nil)
+			got, err := client.getConnectionInitMessage(context.Background(), GraphQLSubscriptionOptions{InitPayload: tt.initPayload})
 			require.NoError(t, err)
 			require.NotEmpty(t, got)
 
@@ -293,3 +306,243 @@ func TestWebsocketSubscriptionClientWithServerDisconnect(t *testing.T) {
 		return len(client.handlers) == 0
 	}, time.Second, time.Millisecond, "client handlers not 0")
 }
+
+func TestWebsocketSubscriptionClientReconnect(t *testing.T) {
+	serverDone := make(chan struct{})
+	connections := atomic.NewInt64(0)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := websocket.Accept(w, r, nil)
+		assert.NoError(t, err)
+		ctx := context.Background()
+		msgType, data, err := conn.Read(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, websocket.MessageText, msgType)
+		assert.Equal(t, `{"type":"connection_init"}`, string(data))
+		err = conn.Write(r.Context(), websocket.MessageText, []byte(`{"type":"connection_ack"}`))
+		assert.NoError(t, err)
+
+		if connections.Inc() == 1 {
+			msgType, data, err = conn.Read(ctx)
+			assert.NoError(t, err)
+			assert.Equal(t, websocket.MessageText, msgType)
+			assert.Equal(t, `{"type":"start","id":"1","payload":{"query":"subscription {messageAdded(roomName: \"room\"){text}}"}}`, string(data))
+			err = conn.Write(r.Context(), websocket.MessageText, []byte(`{"type":"data","id":"1","payload":{"data":{"messageAdded":{"text":"first"}}}}`))
+			assert.NoError(t, err)
+			// simulate an upstream dropping the connection
+			_ = conn.Close(websocket.StatusGoingAway, "")
+			return
+		}
+
+		msgType, data, err = conn.Read(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, websocket.MessageText, msgType)
+		assert.Equal(t, `{"type":"start","id":"1","payload":{"query":"subscription {messageAdded(roomName: \"room\"){text}}","variables":{"after":"first"}}}`, string(data))
+		err = conn.Write(r.Context(), websocket.MessageText, []byte(`{"type":"data","id":"1","payload":{"data":{"messageAdded":{"text":"second"}}}}`))
+		assert.NoError(t, err)
+
+		_, _, err = conn.Read(ctx)
+		assert.Error(t, err)
+		close(serverDone)
+	}))
+	defer server.Close()
+	ctx, clientCancel := context.WithCancel(context.Background())
+	defer clientCancel()
+	serverCtx, serverCancel := context.WithCancel(context.Background())
+	defer serverCancel()
+
+	client := NewGraphQLSubscriptionClient(http.DefaultClient, http.DefaultClient, serverCtx,
+		WithReadTimeout(time.Millisecond),
+		WithLogger(logger()),
+		WithWSSubProtocol(ProtocolGraphQLWS),
+		WithReconnect(ReconnectOptions{
+			MaxAttempts:    3,
+			InitialBackoff: time.Millisecond,
+			OnResubscribe: func(ctx context.Context, body GraphQLBody, lastPayload json.RawMessage) (GraphQLBody, error) {
+				cursor, err := jsonparser.GetString(lastPayload, "data", "messageAdded", "text")
+				if err != nil {
+					return body, err
+				}
+				body.Variables = json.RawMessage(fmt.Sprintf(`{"after":"%s"}`, cursor))
+				return body, nil
+			},
+		}),
+	)
+	next := make(chan []byte)
+	err := client.Subscribe(ctx, GraphQLSubscriptionOptions{
+		URL: server.URL,
+		Body: GraphQLBody{
+			Query: `subscription {messageAdded(roomName: "room"){text}}`,
+		},
+	}, next)
+	assert.NoError(t, err)
+	first := <-next
+	second := <-next
+	assert.Equal(t, `{"data":{"messageAdded":{"text":"first"}}}`, string(first))
+	assert.Equal(t, `{"data":{"messageAdded":{"text":"second"}}}`, string(second))
+	assert.Equal(t, int64(2), connections.Load())
+	serverCancel()
+	assert.Eventuallyf(t, func() bool {
+		<-serverDone
+		return true
+	}, time.Second, time.Millisecond*10, "server did not close")
+	assert.Eventuallyf(t, func() bool {
+		client.handlersMu.Lock()
+		defer client.handlersMu.Unlock()
+		return len(client.handlers) == 0
+	}, time.Second, time.Millisecond, "client handlers not 0")
+}
+
+func TestWebsocketSubscriptionClientReconnectFailed(t *testing.T) {
+	connections := atomic.NewInt64(0)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if connections.Inc() > 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		conn, err := websocket.Accept(w, r, nil)
+		assert.NoError(t, err)
+		ctx := context.Background()
+		_, _, err = conn.Read(ctx)
+		assert.NoError(t, err)
+		err = conn.Write(r.Context(), websocket.MessageText, []byte(`{"type":"connection_ack"}`))
+		assert.NoError(t, err)
+		_, _, err = conn.Read(ctx)
+		assert.NoError(t, err)
+		err = conn.Write(r.Context(), websocket.MessageText, []byte(`{"type":"data","id":"1","payload":{"data":{"messageAdded":{"text":"first"}}}}`))
+		assert.NoError(t, err)
+		_ = conn.Close(websocket.StatusGoingAway, "")
+	}))
+	defer server.Close()
+	ctx, clientCancel := context.WithCancel(context.Background())
+	defer clientCancel()
+
+	client := NewGraphQLSubscriptionClient(http.DefaultClient, http.DefaultClient, context.Background(),
+		WithReadTimeout(time.Millisecond),
+		WithLogger(logger()),
+		WithWSSubProtocol(ProtocolGraphQLWS),
+		WithReconnect(ReconnectOptions{
+			MaxAttempts:    2,
+			InitialBackoff: time.Millisecond,
+		}),
+	)
+	next := make(chan []byte)
+	err := client.Subscribe(ctx, GraphQLSubscriptionOptions{
+		URL: server.URL,
+		Body: GraphQLBody{
+			Query: `subscription {messageAdded(roomName: "room"){text}}`,
+		},
+	}, next)
+	assert.NoError(t, err)
+	first := <-next
+	assert.Equal(t, `{"data":{"messageAdded":{"text":"first"}}}`, string(first))
+	gap := <-next
+	assert.Contains(t, string(gap), "connection lost, reconnect failed after 2 attempts")
+	assert.Equal(t, int64(3), connections.Load())
+}
+
+func TestWebsocketSubscriptionClientMultiplexing(t *testing.T) {
+	serverDone := make(chan struct{})
+	bothSubscribed := make(chan struct{})
+	firstUnsubscribed := make(chan struct{})
+	subscribes := atomic.NewInt64(0)
+
+	products := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := websocket.Accept(w, r, nil)
+		assert.NoError(t, err)
+		ctx := context.Background()
+		_, data, err := conn.Read(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, `{"type":"connection_init"}`, string(data))
+		err = conn.Write(r.Context(), websocket.MessageText, []byte(`{"type":"connection_ack"}`))
+		assert.NoError(t, err)
+
+		_, data, err = conn.Read(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, `{"type":"start","id":"1","payload":{"query":"subscription {updateProductPrice(upc: \"top-1\"){price}}"}}`, string(data))
+		subscribes.Inc()
+
+		<-bothSubscribed
+		err = conn.Write(r.Context(), websocket.MessageText, []byte(`{"type":"data","id":"1","payload":{"data":{"updateProductPrice":{"price":1}}}}`))
+		assert.NoError(t, err)
+		err = conn.Write(r.Context(), websocket.MessageText, []byte(`{"type":"data","id":"1","payload":{"data":{"updateProductPrice":{"price":2}}}}`))
+		assert.NoError(t, err)
+
+		<-firstUnsubscribed
+		err = conn.Write(r.Context(), websocket.MessageText, []byte(`{"type":"data","id":"1","payload":{"data":{"updateProductPrice":{"price":3}}}}`))
+		assert.NoError(t, err)
+
+		// the shared subscription is only stopped once the last client is gone
+		_, data, err = conn.Read(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, `{"type":"stop","id":"1"}`, string(data))
+		close(serverDone)
+	}))
+	defer products.Close()
+	serverCtx, serverCancel := context.WithCancel(context.Background())
+	defer serverCancel()
+
+	client := NewGraphQLSubscriptionClient(http.DefaultClient, http.DefaultClient, serverCtx,
+		WithReadTimeout(time.Millisecond),
+		WithLogger(logger()),
+		WithWSSubProtocol(ProtocolGraphQLWS),
+		WithMultiplexing(),
+	)
+	options := GraphQLSubscriptionOptions{
+		URL: products.URL,
+		Body: GraphQLBody{
+			Query: `subscription {updateProductPrice(upc: "top-1"){price}}`,
+		},
+	}
+
+	firstCtx, firstCancel := context.WithCancel(context.Background())
+	defer firstCancel()
+	firstNext := make(chan []byte)
+	require.NoError(t, client.Subscribe(firstCtx, options, firstNext))
+
+	secondCtx, secondCancel := context.WithCancel(context.Background())
+	defer secondCancel()
+	secondNext := make(chan []byte)
+	require.NoError(t, client.Subscribe(secondCtx, options, secondNext))
+	close(bothSubscribed)
+
+	// messages are fanned out to the clients in no particular order, so both have to be read concurrently
+	clientsDone := &sync.WaitGroup{}
+	for _, next := range []chan []byte{firstNext, secondNext} {
+		clientsDone.Add(1)
+		go func(next chan []byte) {
+			defer clientsDone.Done()
+			assert.Equal(t, `{"data":{"updateProductPrice":{"price":1}}}`, string(<-next))
+			assert.Equal(t, `{"data":{"updateProductPrice":{"price":2}}}`, string(<-next))
+		}(next)
+	}
+	clientsDone.Wait()
+
+	// a client leaving doesn't tear down the shared subscription
+	firstCancel()
+	assert.Eventuallyf(t, func() bool {
+		client.multiplexer.streamsMu.Lock()
+		defer client.multiplexer.streamsMu.Unlock()
+		for _, stream := range client.multiplexer.streams {
+			stream.subscribersMu.Lock()
+			subscribers := len(stream.subscribers)
+			stream.subscribersMu.Unlock()
+			return subscribers == 1
+		}
+		return false
+	}, time.Second, time.Millisecond, "first client not unsubscribed")
+	close(firstUnsubscribed)
+	assert.Equal(t, `{"data":{"updateProductPrice":{"price":3}}}`, string(<-secondNext))
+
+	secondCancel()
+	assert.Eventuallyf(t, func() bool {
+		<-serverDone
+		return true
+	}, time.Second, time.Millisecond*10, "server did not receive stop")
+	assert.Equal(t, int64(1), subscribes.Load())
+
+	_, ok := <-secondNext
+	assert.False(t, ok)
+	client.multiplexer.streamsMu.Lock()
+	assert.Len(t, client.multiplexer.streams, 0)
+	client.multiplexer.streamsMu.Unlock()
+}
@@ -0,0 +1,73 @@
+package graphql_datasource
+
+import (
+	"context"
+	"encoding/json"
+)
+
+type initPayloadContextKey struct{}
+
+// ContextWithInitPayload returns a context carrying the payload of the connection_init message
+// a client sent to start the WebSocket connection the subscriptions of the context belong to.
+// Fields of the payload configured in SubscriptionConfiguration.ForwardInitPayloadFields
+// are forwarded to the connection_init message sent to the origin.
+func ContextWithInitPayload(ctx context.Context, payload json.RawMessage) context.Context {
+	return context.WithValue(ctx, initPayloadContextKey{}, payload)
+}
+
+// InitPayloadFromContext returns the connection_init payload set with ContextWithInitPayload
+func InitPayloadFromContext(ctx context.Context) json.RawMessage {
+	payload, _ := ctx.Value(initPayloadContextKey{}).(json.RawMessage)
+	return payload
+}
+
+// forwardInitPayload builds the connection_init payload for the origin from the fields of the client payload
+// which are configured to be forwarded, all other fields of the client payload are dropped
+func forwardInitPayload(clientPayload json.RawMessage, fields map[string]string) (json.RawMessage, error) {
+	if len(clientPayload) == 0 || len(fields) == 0 {
+		return nil, nil
+	}
+
+	var clientFields map[string]json.RawMessage
+	if err := json.Unmarshal(clientPayload, &clientFields); err != nil {
+		// payloads which aren't objects don't have fields to forward
+		return nil, nil
+	}
+
+	upstreamFields := make(map[string]json.RawMessage, len(fields))
+	for clientField, upstreamField := range fields {
+		if value, ok := clientFields[clientField]; ok {
+			upstreamFields[upstreamField] = value
+		}
+	}
+	if len(upstreamFields) == 0 {
+		return nil, nil
+	}
+
+	// fields are marshaled in sorted order, so equal payloads identify the same connection
+	return json.Marshal(upstreamFields)
+}
+
+// mergeInitPayloads sets the fields of the override payload on the base payload,
+// an override payload which isn't an object replaces the base payload
+func mergeInitPayloads(base, override json.RawMessage) (json.RawMessage, error) {
+	if len(base) == 0 {
+		return override, nil
+	}
+	if len(override) == 0 {
+		return base, nil
+	}
+
+	var baseFields, overrideFields map[string]json.RawMessage
+	if err := json.Unmarshal(override, &overrideFields); err != nil {
+		return override, nil
+	}
+	if err := json.Unmarshal(base, &baseFields); err != nil {
+		return nil, err
+	}
+	for field, value := range overrideFields {
+		baseFields[field] = value
+	}
+
+	return json.Marshal(baseFields)
+}
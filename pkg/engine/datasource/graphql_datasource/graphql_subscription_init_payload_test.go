@@ -0,0 +1,74 @@
+package graphql_datasource
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type optionsRecordingSubscriptionClient struct {
+	options GraphQLSubscriptionOptions
+}
+
+func (o *optionsRecordingSubscriptionClient) Subscribe(_ context.Context, options GraphQLSubscriptionOptions, _ chan<- []byte) error {
+	o.options = options
+	return nil
+}
+
+func TestForwardInitPayload(t *testing.T) {
+	fields := map[string]string{
+		"token":  "authToken",
+		"tenant": "tenant",
+	}
+
+	run := func(clientPayload string, fields map[string]string, expected string) func(t *testing.T) {
+		return func(t *testing.T) {
+			payload, err := forwardInitPayload(json.RawMessage(clientPayload), fields)
+			require.NoError(t, err)
+			assert.Equal(t, expected, string(payload))
+		}
+	}
+
+	t.Run("forwards configured fields only", run(`{"token":"secret","tenant":{"id":1},"password":"secret"}`, fields, `{"authToken":"secret","tenant":{"id":1}}`))
+	t.Run("skips missing fields", run(`{"password":"secret"}`, fields, ``))
+	t.Run("without configured fields", run(`{"token":"secret"}`, nil, ``))
+	t.Run("without client payload", run(``, fields, ``))
+	t.Run("client payload is no object", run(`"secret"`, fields, ``))
+}
+
+func TestSubscriptionSource_Start_ForwardInitPayload(t *testing.T) {
+	input := []byte(`{"url":"ws://localhost:8080","body":{"query":"subscription{remainingJedis}"}}`)
+
+	t.Run("forwards configured fields of the client payload", func(t *testing.T) {
+		client := &optionsRecordingSubscriptionClient{}
+		source := SubscriptionSource{client: client, forwardInitPayloadFields: map[string]string{"token": "token"}}
+
+		ctx := ContextWithInitPayload(context.Background(), json.RawMessage(`{"token":"secret","password":"secret"}`))
+		require.NoError(t, source.Start(ctx, input, nil))
+		assert.Equal(t, `{"token":"secret"}`, string(client.options.InitPayload))
+	})
+
+	t.Run("doesn't forward without configured fields", func(t *testing.T) {
+		client := &optionsRecordingSubscriptionClient{}
+		source := SubscriptionSource{client: client}
+
+		ctx := ContextWithInitPayload(context.Background(), json.RawMessage(`{"token":"secret"}`))
+		require.NoError(t, source.Start(ctx, input, nil))
+		assert.Nil(t, client.options.InitPayload)
+	})
+}
+
+func TestSubscriptionClient_HandlerIDHash_InitPayload(t *testing.T) {
+	client := NewGraphQLSubscriptionClient(nil, nil, context.Background())
+
+	first, err := client.generateHandlerIDHash(GraphQLSubscriptionOptions{URL: "ws://localhost:8080", InitPayload: json.RawMessage(`{"token":"first"}`)})
+	require.NoError(t, err)
+	second, err := client.generateHandlerIDHash(GraphQLSubscriptionOptions{URL: "ws://localhost:8080", InitPayload: json.RawMessage(`{"token":"second"}`)})
+	require.NoError(t, err)
+
+	// clients with different payloads must not share a connection to the origin
+	assert.NotEqual(t, first, second)
+}
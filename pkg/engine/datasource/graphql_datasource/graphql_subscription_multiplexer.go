@@ -0,0 +1,200 @@
+package graphql_datasource
+
+import (
+	"context"
+	"sync"
+)
+
+// subscriberBufferSize is the number of messages buffered for a subscriber which doesn't keep up with the upstream subscription
+const subscriberBufferSize = 32
+
+// subscriptionMultiplexer shares one upstream subscription between all identical subscriptions.
+// Subscriptions are identical if Hash(URL,Headers,Body) results in the same result.
+// Every message of the upstream subscription is fanned out to all subscribers,
+// the upstream subscription is stopped once the last subscriber is gone.
+type subscriptionMultiplexer struct {
+	streams   map[uint64]*multiplexedStream
+	streamsMu sync.Mutex
+}
+
+// multiplexedStream is an upstream subscription shared by all subscribers
+type multiplexedStream struct {
+	// started is closed once the upstream subscription is started, err is set if it couldn't be started
+	started chan struct{}
+	err     error
+	// cancel stops the upstream subscription
+	cancel        func()
+	subscribers   map[chan<- []byte]*multiplexedSubscriber
+	subscribersMu sync.Mutex
+	// closed is set once the stream is removed from the multiplexer, it doesn't accept subscribers anymore
+	closed bool
+}
+
+// multiplexedSubscriber forwards the messages of a stream to the channel of a single subscriber,
+// so that a slow subscriber doesn't delay the others
+type multiplexedSubscriber struct {
+	ctx  context.Context
+	next chan<- []byte
+	// messages buffers the messages which aren't forwarded yet, it's closed once the subscriber is removed from the stream
+	messages chan []byte
+}
+
+func newSubscriptionMultiplexer() *subscriptionMultiplexer {
+	return &subscriptionMultiplexer{
+		streams: make(map[uint64]*multiplexedStream),
+	}
+}
+
+// subscribe adds next as subscriber to the stream with the given ID.
+// If no such stream exists, start is called to initiate the upstream subscription.
+// start is called without holding a lock: identical subscriptions wait for it, other streams aren't blocked.
+// The upstream subscription doesn't depend on the context of a single subscriber,
+// so a subscriber cancelling its subscription doesn't tear down the stream for the others.
+func (m *subscriptionMultiplexer) subscribe(engineCtx, reqCtx context.Context, streamID uint64, next chan<- []byte, start func(ctx context.Context, next chan<- []byte) error) error {
+	for {
+		if err := reqCtx.Err(); err != nil {
+			return err
+		}
+
+		m.streamsMu.Lock()
+		stream, exists := m.streams[streamID]
+		if !exists {
+			stream = &multiplexedStream{
+				started:     make(chan struct{}),
+				subscribers: make(map[chan<- []byte]*multiplexedSubscriber),
+			}
+			m.streams[streamID] = stream
+		}
+		m.streamsMu.Unlock()
+
+		if exists {
+			select {
+			case <-stream.started:
+			case <-reqCtx.Done():
+				return reqCtx.Err()
+			}
+		} else {
+			// the subscriber starting the stream is added even if its context is done in the meantime,
+			// so that the upstream subscription is stopped once it's unsubscribed
+			m.start(engineCtx, streamID, stream, start)
+		}
+		if stream.err != nil {
+			return stream.err
+		}
+
+		stream.subscribersMu.Lock()
+		if stream.closed {
+			// the upstream subscription completed or its last subscriber left in the meantime, start a new one
+			stream.subscribersMu.Unlock()
+			continue
+		}
+		subscriber := &multiplexedSubscriber{
+			ctx:      reqCtx,
+			next:     next,
+			messages: make(chan []byte, subscriberBufferSize),
+		}
+		stream.subscribers[next] = subscriber
+		stream.subscribersMu.Unlock()
+
+		go subscriber.forward()
+		go m.unsubscribeOnDone(streamID, stream, subscriber)
+		return nil
+	}
+}
+
+// start initiates the upstream subscription of the stream and closes stream.started.
+// A stream which couldn't be started is removed, so that the next subscriber tries again.
+func (m *subscriptionMultiplexer) start(engineCtx context.Context, streamID uint64, stream *multiplexedStream, start func(ctx context.Context, next chan<- []byte) error) {
+	defer close(stream.started)
+
+	if engineCtx == nil {
+		engineCtx = context.Background()
+	}
+	upstreamCtx, cancel := context.WithCancel(engineCtx)
+	upstream := make(chan []byte)
+	if err := start(upstreamCtx, upstream); err != nil {
+		cancel()
+		stream.err = err
+		m.removeStream(streamID, stream)
+		return
+	}
+
+	stream.cancel = cancel
+	go m.fanOut(streamID, stream, upstream)
+}
+
+// fanOut sends every message of the upstream subscription to all subscribers.
+// A subscriber whose buffer is full misses the message instead of delaying the other subscribers.
+// Once the upstream subscription completes, the channels of the remaining subscribers are closed.
+func (m *subscriptionMultiplexer) fanOut(streamID uint64, stream *multiplexedStream, upstream <-chan []byte) {
+	for message := range upstream {
+		stream.subscribersMu.Lock()
+		for _, subscriber := range stream.subscribers {
+			select {
+			case subscriber.messages <- message:
+			default:
+			}
+		}
+		stream.subscribersMu.Unlock()
+	}
+
+	m.removeStream(streamID, stream)
+	stream.cancel()
+
+	stream.subscribersMu.Lock()
+	stream.closed = true
+	for next, subscriber := range stream.subscribers {
+		close(subscriber.messages)
+		delete(stream.subscribers, next)
+	}
+	stream.subscribersMu.Unlock()
+}
+
+// unsubscribeOnDone removes the subscriber when its context is done.
+// The upstream subscription is stopped if it was the last subscriber.
+func (m *subscriptionMultiplexer) unsubscribeOnDone(streamID uint64, stream *multiplexedStream, subscriber *multiplexedSubscriber) {
+	<-subscriber.ctx.Done()
+
+	m.streamsMu.Lock()
+	defer m.streamsMu.Unlock()
+
+	stream.subscribersMu.Lock()
+	defer stream.subscribersMu.Unlock()
+
+	if _, ok := stream.subscribers[subscriber.next]; !ok {
+		// the upstream subscription completed and removed the subscriber already
+		return
+	}
+	delete(stream.subscribers, subscriber.next)
+	close(subscriber.messages)
+
+	if len(stream.subscribers) != 0 {
+		return
+	}
+	stream.closed = true
+	if m.streams[streamID] == stream {
+		delete(m.streams, streamID)
+	}
+	stream.cancel()
+}
+
+// removeStream removes the stream from the multiplexer unless it was replaced already
+func (m *subscriptionMultiplexer) removeStream(streamID uint64, stream *multiplexedStream) {
+	m.streamsMu.Lock()
+	defer m.streamsMu.Unlock()
+	if m.streams[streamID] == stream {
+		delete(m.streams, streamID)
+	}
+}
+
+// forward sends the buffered messages to the channel of the subscriber until it's removed from the stream.
+// It's the only sender on the channel, so it closes the channel once done.
+func (s *multiplexedSubscriber) forward() {
+	defer close(s.next)
+	for message := range s.messages {
+		select {
+		case s.next <- message:
+		case <-s.ctx.Done():
+		}
+	}
+}
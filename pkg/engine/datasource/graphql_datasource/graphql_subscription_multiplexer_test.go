@@ -0,0 +1,120 @@
+package graphql_datasource
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/atomic"
+)
+
+func TestSubscriptionMultiplexer(t *testing.T) {
+	t.Run("slow subscriber doesn't delay the others", func(t *testing.T) {
+		multiplexer := newSubscriptionMultiplexer()
+		var upstream chan<- []byte
+		start := func(ctx context.Context, next chan<- []byte) error {
+			upstream = next
+			return nil
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		slow := make(chan []byte)
+		require.NoError(t, multiplexer.subscribe(nil, ctx, 1, slow, start))
+		fast := make(chan []byte)
+		require.NoError(t, multiplexer.subscribe(nil, ctx, 1, fast, start))
+
+		// the slow subscriber doesn't read until its buffer overflowed
+		messages := subscriberBufferSize * 2
+		for i := 0; i < messages; i++ {
+			upstream <- []byte(strconv.Itoa(i))
+			assert.Equal(t, strconv.Itoa(i), string(<-fast))
+		}
+		close(upstream)
+
+		_, ok := <-fast
+		assert.False(t, ok)
+
+		// the slow subscriber misses the messages which didn't fit into its buffer
+		assert.Equal(t, "0", string(<-slow))
+		received := 1
+		for range slow {
+			received++
+		}
+		assert.Less(t, received, messages)
+
+		multiplexer.streamsMu.Lock()
+		assert.Len(t, multiplexer.streams, 0)
+		multiplexer.streamsMu.Unlock()
+	})
+
+	t.Run("starting a stream doesn't block other streams", func(t *testing.T) {
+		multiplexer := newSubscriptionMultiplexer()
+		starts := atomic.NewInt64(0)
+		starting := make(chan struct{})
+		release := make(chan struct{})
+		var upstream chan<- []byte
+		blockingStart := func(ctx context.Context, next chan<- []byte) error {
+			starts.Inc()
+			close(starting)
+			<-release
+			upstream = next
+			return nil
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		first, second := make(chan []byte), make(chan []byte)
+		errs := make(chan error, 2)
+		go func() {
+			errs <- multiplexer.subscribe(nil, ctx, 1, first, blockingStart)
+		}()
+		<-starting
+		go func() {
+			errs <- multiplexer.subscribe(nil, ctx, 1, second, blockingStart)
+		}()
+
+		other := make(chan []byte)
+		require.NoError(t, multiplexer.subscribe(nil, ctx, 2, other, func(ctx context.Context, next chan<- []byte) error {
+			go func() {
+				<-ctx.Done()
+				close(next)
+			}()
+			return nil
+		}))
+
+		close(release)
+		require.NoError(t, <-errs)
+		require.NoError(t, <-errs)
+		assert.Equal(t, int64(1), starts.Load())
+
+		upstream <- []byte("1")
+		assert.Equal(t, "1", string(<-first))
+		assert.Equal(t, "1", string(<-second))
+		close(upstream)
+	})
+
+	t.Run("stream which couldn't be started is started again by the next subscriber", func(t *testing.T) {
+		multiplexer := newSubscriptionMultiplexer()
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		err := multiplexer.subscribe(nil, ctx, 1, make(chan []byte), func(ctx context.Context, next chan<- []byte) error {
+			return errors.New("connection refused")
+		})
+		assert.EqualError(t, err, "connection refused")
+
+		next := make(chan []byte)
+		var upstream chan<- []byte
+		require.NoError(t, multiplexer.subscribe(nil, ctx, 1, next, func(ctx context.Context, next chan<- []byte) error {
+			upstream = next
+			return nil
+		}))
+		upstream <- []byte("1")
+		assert.Equal(t, "1", string(<-next))
+		close(upstream)
+	})
+}
@@ -0,0 +1,34 @@
+package graphql_datasource
+
+import (
+	"context"
+)
+
+type upstreamSubscriptionObserverContextKey struct{}
+
+// UpstreamSubscription describes the subscription to the origin a subscription of a client is resolved by
+type UpstreamSubscription struct {
+	URL string `json:"url"`
+	// SSE is true for subscriptions over Server-Sent Events, all other subscriptions are sent over WebSocket connections
+	SSE bool `json:"sse"`
+	// ConnectionID identifies the WebSocket connection to the origin, subscriptions with the same id share it.
+	// It's 0 for SSE subscriptions, each of them has a connection of its own.
+	ConnectionID uint64 `json:"connectionId"`
+	// StreamID identifies the upstream subscription shared by identical subscriptions, see WithMultiplexing.
+	// It's 0 without multiplexing.
+	StreamID uint64 `json:"streamId"`
+}
+
+// UpstreamSubscriptionObserver is called for every upstream subscription started for the subscription of a context
+type UpstreamSubscriptionObserver func(upstream UpstreamSubscription)
+
+// ContextWithUpstreamSubscriptionObserver returns a context reporting the upstream subscriptions of the subscriptions
+// started with it to the observer, e.g. to find out which connections to the origins a client subscription uses.
+func ContextWithUpstreamSubscriptionObserver(ctx context.Context, observer UpstreamSubscriptionObserver) context.Context {
+	return context.WithValue(ctx, upstreamSubscriptionObserverContextKey{}, observer)
+}
+
+func upstreamSubscriptionObserverFromContext(ctx context.Context) UpstreamSubscriptionObserver {
+	observer, _ := ctx.Value(upstreamSubscriptionObserverContextKey{}).(UpstreamSubscriptionObserver)
+	return observer
+}
@@ -0,0 +1,108 @@
+package graphql_datasource
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/jensneuse/abstractlogger"
+	"nhooyr.io/websocket"
+)
+
+// ReconnectOptions configures how a dropped WebSocket connection to an origin gets re-established.
+// Events published by the origin while the connection is down are lost,
+// unless OnResubscribe resumes the subscription, e.g. from a cursor contained in the last payload.
+type ReconnectOptions struct {
+	// MaxAttempts is the maximum number of reconnection attempts, zero disables reconnecting.
+	MaxAttempts int
+	// InitialBackoff is the delay before the first attempt, it doubles with every failed attempt.
+	InitialBackoff time.Duration
+	// MaxBackoff limits the delay between two attempts, zero means no limit.
+	MaxBackoff time.Duration
+	// OnResubscribe is called for every active subscription once the connection is re-established.
+	// It receives the original body and the last payload delivered to the subscription (nil if none)
+	// and returns the body to resubscribe with.
+	OnResubscribe func(ctx context.Context, body GraphQLBody, lastPayload json.RawMessage) (GraphQLBody, error)
+}
+
+// reconnector re-establishes the connection of a WebSocket connection handler
+type reconnector struct {
+	options      ReconnectOptions
+	dial         func(ctx context.Context) (*websocket.Conn, error)
+	log          abstractlogger.Logger
+	lastPayloads map[string][]byte
+}
+
+func newReconnector(options ReconnectOptions, dial func(ctx context.Context) (*websocket.Conn, error), log abstractlogger.Logger) *reconnector {
+	return &reconnector{
+		options:      options,
+		dial:         dial,
+		log:          log,
+		lastPayloads: map[string][]byte{},
+	}
+}
+
+func (r *reconnector) enabled() bool {
+	return r != nil && r.options.MaxAttempts > 0
+}
+
+// trackPayload remembers the last payload of a subscription so that it can be passed to OnResubscribe
+func (r *reconnector) trackPayload(subscriptionID string, payload []byte) {
+	if !r.enabled() || r.options.OnResubscribe == nil {
+		return
+	}
+	r.lastPayloads[subscriptionID] = append(r.lastPayloads[subscriptionID][:0], payload...)
+}
+
+func (r *reconnector) forget(subscriptionID string) {
+	if r == nil {
+		return
+	}
+	delete(r.lastPayloads, subscriptionID)
+}
+
+// reconnect dials the origin with exponential backoff until a connection is established or all attempts are used up
+func (r *reconnector) reconnect(ctx context.Context, cause error) (*websocket.Conn, error) {
+	backoff := r.options.InitialBackoff
+	err := cause
+	for attempt := 1; attempt <= r.options.MaxAttempts; attempt++ {
+		r.log.Debug("reconnector.reconnect",
+			abstractlogger.Int("attempt", attempt),
+			abstractlogger.Error(err),
+		)
+
+		timer := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+
+		var conn *websocket.Conn
+		conn, err = r.dial(ctx)
+		if err == nil {
+			return conn, nil
+		}
+
+		backoff *= 2
+		if r.options.MaxBackoff > 0 && backoff > r.options.MaxBackoff {
+			backoff = r.options.MaxBackoff
+		}
+	}
+	return nil, fmt.Errorf("connection lost, reconnect failed after %d attempts: %w", r.options.MaxAttempts, err)
+}
+
+// resubscribeBody returns the marshalled body to resubscribe with after a reconnect
+func (r *reconnector) resubscribeBody(ctx context.Context, subscriptionID string, sub Subscription) ([]byte, error) {
+	body := sub.options.Body
+	if r.options.OnResubscribe != nil {
+		var err error
+		body, err = r.options.OnResubscribe(ctx, body, r.lastPayloads[subscriptionID])
+		if err != nil {
+			return nil, err
+		}
+	}
+	return json.Marshal(body)
+}
@@ -23,9 +23,10 @@ type gqlTWSConnectionHandler struct {
 	nextSubscriptionID int
 	subscriptions      map[string]Subscription
 	readTimeout        time.Duration
+	reconnector        *reconnector
 }
 
-func newGQLTWSConnectionHandler(ctx context.Context, conn *websocket.Conn, rt time.Duration, l log.Logger) *gqlTWSConnectionHandler {
+func newGQLTWSConnectionHandler(ctx context.Context, conn *websocket.Conn, rt time.Duration, l log.Logger, r *reconnector) *gqlTWSConnectionHandler {
 	return &gqlTWSConnectionHandler{
 		conn:               conn,
 		ctx:                ctx,
@@ -34,6 +35,7 @@ func newGQLTWSConnectionHandler(ctx context.Context, conn *websocket.Conn, rt ti
 		nextSubscriptionID: 0,
 		subscriptions:      map[string]Subscription{},
 		readTimeout:        rt,
+		reconnector:        r,
 	}
 }
 
@@ -64,6 +66,10 @@ func (h *gqlTWSConnectionHandler) StartBlocking(sub Subscription) {
 		case sub = <-h.subscribeCh:
 			h.subscribe(sub)
 		case err := <-errCh:
+			if err = h.reconnect(err); err == nil {
+				go h.readBlocking(readCtx, dataCh, errCh)
+				continue
+			}
 			h.log.Error("gqlWSConnectionHandler.StartBlocking", log.Error(err))
 			h.broadcastErrorMessage(err)
 			return
@@ -91,6 +97,32 @@ func (h *gqlTWSConnectionHandler) StartBlocking(sub Subscription) {
 	}
 }
 
+// reconnect re-establishes the connection to the origin and restarts all active subscriptions with their existing IDs
+// if reconnecting is disabled or fails, the returned error should be broadcast to all subscriptions
+func (h *gqlTWSConnectionHandler) reconnect(cause error) error {
+	if !h.reconnector.enabled() || h.ctx.Err() != nil || len(h.subscriptions) == 0 {
+		return cause
+	}
+	_ = h.conn.Close(websocket.StatusGoingAway, "")
+	conn, err := h.reconnector.reconnect(h.ctx, cause)
+	if err != nil {
+		return err
+	}
+	h.conn = conn
+	for subscriptionID, sub := range h.subscriptions {
+		graphQLBody, err := h.reconnector.resubscribeBody(h.ctx, subscriptionID, sub)
+		if err != nil {
+			return err
+		}
+		subscribeRequest := fmt.Sprintf(subscribeMessage, subscriptionID, string(graphQLBody))
+		err = h.conn.Write(h.ctx, websocket.MessageText, []byte(subscribeRequest))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func (h *gqlTWSConnectionHandler) unsubscribeAllAndCloseConn() {
 	for id := range h.subscriptions {
 		h.unsubscribe(id)
@@ -105,6 +137,7 @@ func (h *gqlTWSConnectionHandler) unsubscribe(subscriptionID string) {
 	}
 	close(sub.next)
 	delete(h.subscriptions, subscriptionID)
+	h.reconnector.forget(subscriptionID)
 
 	req := fmt.Sprintf(completeMessage, subscriptionID)
 	err := h.conn.Write(h.ctx, websocket.MessageText, []byte(req))
@@ -161,6 +194,7 @@ func (h *gqlTWSConnectionHandler) handleMessageTypeComplete(data []byte) {
 	}
 	close(sub.next)
 	delete(h.subscriptions, id)
+	h.reconnector.forget(id)
 }
 
 func (h *gqlTWSConnectionHandler) handleMessageTypeError(data []byte) {
@@ -229,6 +263,7 @@ func (h *gqlTWSConnectionHandler) handleMessageTypeNext(data []byte) {
 		sub.next <- []byte(internalError)
 		return
 	}
+	h.reconnector.trackPayload(id, value)
 
 	ctx, cancel := context.WithTimeout(h.ctx, time.Second*5)
 	defer cancel()
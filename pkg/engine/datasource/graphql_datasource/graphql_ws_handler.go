@@ -23,9 +23,10 @@ type gqlWSConnectionHandler struct {
 	nextSubscriptionID int
 	subscriptions      map[string]Subscription
 	readTimeout        time.Duration
+	reconnector        *reconnector
 }
 
-func newGQLWSConnectionHandler(ctx context.Context, conn *websocket.Conn, readTimeout time.Duration, log abstractlogger.Logger) *gqlWSConnectionHandler {
+func newGQLWSConnectionHandler(ctx context.Context, conn *websocket.Conn, readTimeout time.Duration, log abstractlogger.Logger, reconnector *reconnector) *gqlWSConnectionHandler {
 	return &gqlWSConnectionHandler{
 		conn:               conn,
 		ctx:                ctx,
@@ -34,6 +35,7 @@ func newGQLWSConnectionHandler(ctx context.Context, conn *websocket.Conn, readTi
 		nextSubscriptionID: 0,
 		subscriptions:      map[string]Subscription{},
 		readTimeout:        readTimeout,
+		reconnector:        reconnector,
 	}
 }
 
@@ -70,6 +72,10 @@ func (h *gqlWSConnectionHandler) StartBlocking(sub Subscription) {
 		case sub = <-h.subscribeCh:
 			h.subscribe(sub)
 		case err = <-errCh:
+			if err = h.reconnect(err); err == nil {
+				go h.readBlocking(readCtx, dataCh, errCh)
+				continue
+			}
 			h.log.Error("gqlWSConnectionHandler.StartBlocking", abstractlogger.Error(err))
 			h.broadcastErrorMessage(err)
 			return
@@ -121,6 +127,32 @@ func (h *gqlWSConnectionHandler) readBlocking(ctx context.Context, dataCh chan [
 	}
 }
 
+// reconnect re-establishes the connection to the origin and restarts all active subscriptions with their existing IDs
+// if reconnecting is disabled or fails, the returned error should be broadcast to all subscriptions
+func (h *gqlWSConnectionHandler) reconnect(cause error) error {
+	if !h.reconnector.enabled() || h.ctx.Err() != nil || len(h.subscriptions) == 0 {
+		return cause
+	}
+	_ = h.conn.Close(websocket.StatusGoingAway, "")
+	conn, err := h.reconnector.reconnect(h.ctx, cause)
+	if err != nil {
+		return err
+	}
+	h.conn = conn
+	for subscriptionID, sub := range h.subscriptions {
+		graphQLBody, err := h.reconnector.resubscribeBody(h.ctx, subscriptionID, sub)
+		if err != nil {
+			return err
+		}
+		startRequest := fmt.Sprintf(startMessage, subscriptionID, string(graphQLBody))
+		err = h.conn.Write(h.ctx, websocket.MessageText, []byte(startRequest))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func (h *gqlWSConnectionHandler) unsubscribeAllAndCloseConn() {
 	for id := range h.subscriptions {
 		h.unsubscribe(id)
@@ -161,6 +193,7 @@ func (h *gqlWSConnectionHandler) handleMessageTypeData(data []byte) {
 	if err != nil {
 		return
 	}
+	h.reconnector.trackPayload(id, payload)
 	ctx, cancel := context.WithTimeout(h.ctx, time.Second*5)
 	defer cancel()
 
@@ -211,6 +244,7 @@ func (h *gqlWSConnectionHandler) handleMessageTypeComplete(data []byte) {
 	}
 	close(sub.next)
 	delete(h.subscriptions, id)
+	h.reconnector.forget(id)
 }
 
 func (h *gqlWSConnectionHandler) handleMessageTypeError(data []byte) {
@@ -256,6 +290,7 @@ func (h *gqlWSConnectionHandler) unsubscribe(subscriptionID string) {
 	}
 	close(sub.next)
 	delete(h.subscriptions, subscriptionID)
+	h.reconnector.forget(subscriptionID)
 	stopRequest := fmt.Sprintf(stopMessage, subscriptionID)
 	_ = h.conn.Write(h.ctx, websocket.MessageText, []byte(stopRequest))
 }
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"strconv"
 
 	"github.com/buger/jsonparser"
 	bytetemplate "github.com/jensneuse/byte-template"
@@ -29,6 +30,7 @@ const (
 	SCHEME              = "scheme"
 	HOST                = "host"
 	UNNULLVARIABLES     = "unnull_variables"
+	MAXRESPONSEBYTES    = "max_response_bytes"
 	UNDEFINED_VARIABLES = "undefined"
 )
 
@@ -125,6 +127,23 @@ func IsInputFlagSet(input []byte, flagName string) bool {
 	return bytes.Equal(value, literal.TRUE)
 }
 
+// SetInputMaxResponseBytes limits the size of the response body, a maxBytes of 0 or less doesn't limit the size
+func SetInputMaxResponseBytes(input []byte, maxBytes int64) []byte {
+	if maxBytes <= 0 {
+		return input
+	}
+	out, _ := sjson.SetRawBytes(input, MAXRESPONSEBYTES, []byte(strconv.FormatInt(maxBytes, 10)))
+	return out
+}
+
+func inputMaxResponseBytes(input []byte) int64 {
+	maxBytes, err := jsonparser.GetInt(input, MAXRESPONSEBYTES)
+	if err != nil {
+		return 0
+	}
+	return maxBytes
+}
+
 func SetInputMethod(input, method []byte) []byte {
 	if len(method) == 0 {
 		return input
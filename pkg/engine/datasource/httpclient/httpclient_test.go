@@ -23,6 +23,12 @@ func TestHttpClient(t *testing.T) {
 	in = SetInputMethod(nil, quotes.WrapBytes(literal.HTTP_METHOD_POST))
 	assert.Equal(t, `{"method":"POST"}`, string(in))
 
+	in = SetInputMaxResponseBytes(nil, 1024)
+	assert.Equal(t, `{"max_response_bytes":1024}`, string(in))
+
+	in = SetInputMaxResponseBytes(nil, 0)
+	assert.Equal(t, ``, string(in))
+
 	in = SetInputURL(nil, []byte("foo.bar.com"))
 	assert.Equal(t, `{"url":"foo.bar.com"}`, string(in))
 
@@ -195,4 +201,60 @@ func TestHttpClientDo(t *testing.T) {
 		input = SetInputURL(input, []byte(server.URL))
 		t.Run("net", runTest(background, input, `ok`))
 	})
+
+	t.Run("max response bytes", func(t *testing.T) {
+		chunk := bytes.Repeat([]byte("a"), 1024)
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Path {
+			case "/small":
+				_, _ = w.Write([]byte("ok"))
+			case "/content-length":
+				w.Header().Set("Content-Length", "1048576")
+				for i := 0; i < 1024; i++ {
+					if _, err := w.Write(chunk); err != nil {
+						return
+					}
+				}
+			case "/stream":
+				// without a content length the body is only known to be too large while reading it
+				for i := 0; i < 1024; i++ {
+					if _, err := w.Write(chunk); err != nil {
+						return
+					}
+					w.(http.Flusher).Flush()
+				}
+			}
+		}))
+		defer server.Close()
+
+		do := func(path string, maxBytes int64) (*bytes.Buffer, error) {
+			var input []byte
+			input = SetInputMethod(input, []byte("GET"))
+			input = SetInputURL(input, []byte(server.URL+path))
+			input = SetInputMaxResponseBytes(input, maxBytes)
+			out := &bytes.Buffer{}
+			return out, Do(http.DefaultClient, background, input, out)
+		}
+
+		t.Run("within limit", func(t *testing.T) {
+			out, err := do("/small", 2)
+			assert.NoError(t, err)
+			assert.Equal(t, "ok", out.String())
+		})
+		t.Run("content length exceeds limit", func(t *testing.T) {
+			out, err := do("/content-length", 4096)
+			assert.ErrorIs(t, err, ErrResponseTooLarge)
+			assert.Equal(t, 0, out.Len())
+		})
+		t.Run("streamed body exceeds limit", func(t *testing.T) {
+			out, err := do("/stream", 4096)
+			assert.ErrorIs(t, err, ErrResponseTooLarge)
+			assert.LessOrEqual(t, out.Len(), 4097)
+		})
+		t.Run("no limit", func(t *testing.T) {
+			out, err := do("/stream", 0)
+			assert.NoError(t, err)
+			assert.Equal(t, 1024*1024, out.Len())
+		})
+	})
 }
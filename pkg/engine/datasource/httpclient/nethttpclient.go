@@ -5,6 +5,8 @@ import (
 	"compress/flate"
 	"compress/gzip"
 	"context"
+	"errors"
+	"fmt"
 	"io"
 	"net/http"
 	"time"
@@ -31,6 +33,8 @@ var (
 		{"name"},
 		{"value"},
 	}
+	// ErrResponseTooLarge is returned when a response body exceeds the size set by SetInputMaxResponseBytes
+	ErrResponseTooLarge = errors.New("response body too large")
 )
 
 func Do(client *http.Client, ctx context.Context, requestInput []byte, out io.Writer) (err error) {
@@ -104,8 +108,26 @@ func Do(client *http.Client, ctx context.Context, requestInput []byte, out io.Wr
 		return err
 	}
 
-	_, err = io.Copy(out, respReader)
-	return
+	maxResponseBytes := inputMaxResponseBytes(requestInput)
+	if maxResponseBytes <= 0 {
+		_, err = io.Copy(out, respReader)
+		return
+	}
+
+	tooLarge := fmt.Errorf("%w: %s exceeds the limit of %d bytes", ErrResponseTooLarge, url, maxResponseBytes)
+	if response.ContentLength > maxResponseBytes && respReader == response.Body {
+		return tooLarge
+	}
+	// the body is streamed into out and the copy stops after the first byte exceeding the limit,
+	// so at most maxResponseBytes+1 bytes are read regardless of the size of the body
+	written, err := io.Copy(out, io.LimitReader(respReader, maxResponseBytes+1))
+	if err != nil {
+		return err
+	}
+	if written > maxResponseBytes {
+		return tooLarge
+	}
+	return nil
 }
 
 func respBodyReader(req *http.Request, resp *http.Response) (io.ReadCloser, error) {
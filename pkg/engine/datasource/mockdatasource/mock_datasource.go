@@ -0,0 +1,84 @@
+package mockdatasource
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+
+	"github.com/wundergraph/graphql-go-tools/pkg/engine/plan"
+)
+
+// Resolver returns the canned JSON value of a mocked field, e.g. `"MOCK"` for a String field
+type Resolver func(ctx context.Context) ([]byte, error)
+
+type Configuration struct {
+	TypeName  string `json:"type_name"`
+	FieldName string `json:"field_name"`
+}
+
+func ConfigJSON(config Configuration) json.RawMessage {
+	out, _ := json.Marshal(config)
+	return out
+}
+
+// Factory creates planners resolving a single field with the Resolver instead of fetching it from its data source.
+// The field must be configured with DisableDefaultMapping, the value returned by the Resolver is the value of the field.
+type Factory struct {
+	Resolver Resolver
+}
+
+func (f *Factory) Planner(ctx context.Context) plan.DataSourcePlanner {
+	return &Planner{
+		resolver: f.Resolver,
+	}
+}
+
+type Planner struct {
+	config   Configuration
+	resolver Resolver
+}
+
+func (p *Planner) DownstreamResponseFieldAlias(downstreamFieldRef int) (alias string, exists bool) {
+	// skip, not required
+	return
+}
+
+func (p *Planner) DataSourcePlanningBehavior() plan.DataSourcePlanningBehavior {
+	return plan.DataSourcePlanningBehavior{
+		MergeAliasedRootNodes:      false,
+		OverrideFieldPathFromAlias: false,
+	}
+}
+
+func (p *Planner) Register(_ *plan.Visitor, configuration plan.DataSourceConfiguration, _ bool) error {
+	return json.Unmarshal(configuration.Custom, &p.config)
+}
+
+func (p *Planner) ConfigureFetch() plan.FetchConfiguration {
+	return plan.FetchConfiguration{
+		// the input only identifies the mocked field, the resolver doesn't get it
+		Input: string(ConfigJSON(p.config)),
+		DataSource: &Source{
+			resolver: p.resolver,
+		},
+		DisableDataLoader:    true,
+		DisallowSingleFlight: true,
+	}
+}
+
+func (p *Planner) ConfigureSubscription() plan.SubscriptionConfiguration {
+	return plan.SubscriptionConfiguration{}
+}
+
+type Source struct {
+	resolver Resolver
+}
+
+func (s *Source) Load(ctx context.Context, _ []byte, w io.Writer) (err error) {
+	data, err := s.resolver(ctx)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+	return
+}
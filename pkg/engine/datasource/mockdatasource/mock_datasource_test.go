@@ -0,0 +1,38 @@
+package mockdatasource
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSource_Load(t *testing.T) {
+	t.Run("writes the value of the resolver", func(t *testing.T) {
+		source := &Source{
+			resolver: func(ctx context.Context) ([]byte, error) {
+				return []byte(`"MOCK"`), nil
+			},
+		}
+
+		buf := &bytes.Buffer{}
+		err := source.Load(context.Background(), []byte(`{"type_name":"Product","field_name":"name"}`), buf)
+		assert.NoError(t, err)
+		assert.Equal(t, `"MOCK"`, buf.String())
+	})
+
+	t.Run("returns the error of the resolver", func(t *testing.T) {
+		source := &Source{
+			resolver: func(ctx context.Context) ([]byte, error) {
+				return nil, errors.New("mock failed")
+			},
+		}
+
+		buf := &bytes.Buffer{}
+		err := source.Load(context.Background(), nil, buf)
+		assert.EqualError(t, err, "mock failed")
+		assert.Equal(t, 0, buf.Len())
+	})
+}
@@ -0,0 +1,153 @@
+package rest_datasource
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"strconv"
+
+	"github.com/buger/jsonparser"
+	"github.com/tidwall/sjson"
+)
+
+const (
+	connectionFirstArgument = "first"
+	connectionAfterArgument = "after"
+)
+
+// ConnectionConfiguration maps a list returned by the upstream to a Relay connection,
+// e.g. for a field `friends(first: Int, after: String): FriendConnection`.
+// The items of the list become the nodes of the edges, the cursor of an edge is the base64 encoded value of CursorField.
+type ConnectionConfiguration struct {
+	// CursorField is the field of a list item which identifies its position in the list, e.g. "id"
+	CursorField string
+	// FirstQueryParam is the upstream query parameter limiting the number of returned items.
+	// It receives the "first" argument, without it all items are returned and hasNextPage is always false.
+	FirstQueryParam string
+	// AfterQueryParam is the upstream query parameter receiving the decoded cursor of the "after" argument.
+	// The upstream is expected to return the items following the item with this cursor value.
+	AfterQueryParam string
+}
+
+// queryParams returns the query parameters translating the connection arguments into the paging parameters of the upstream
+func (c *ConnectionConfiguration) queryParams() []QueryConfiguration {
+	var params []QueryConfiguration
+	if c.FirstQueryParam != "" {
+		params = append(params, QueryConfiguration{
+			Name:  c.FirstQueryParam,
+			Value: "{{ .arguments." + connectionFirstArgument + " }}",
+		})
+	}
+	if c.AfterQueryParam != "" {
+		params = append(params, QueryConfiguration{
+			Name:  c.AfterQueryParam,
+			Value: "{{ .arguments." + connectionAfterArgument + " }}",
+		})
+	}
+	return params
+}
+
+// prepareInput decodes the "after" cursor and requests one item more than "first" to determine if there is a next page.
+// It returns the prepared input and the requested number of items, -1 if the number of items isn't limited.
+func (c *ConnectionConfiguration) prepareInput(input []byte) ([]byte, int, error) {
+	first := -1
+	var (
+		index   int
+		err     error
+		updates [][2]string
+	)
+	_, _ = jsonparser.ArrayEach(input, func(value []byte, dataType jsonparser.ValueType, offset int, _ error) {
+		defer func() { index++ }()
+		if err != nil {
+			return
+		}
+
+		name, _ := jsonparser.GetString(value, "name")
+		paramValue, _ := jsonparser.GetString(value, "value")
+		if paramValue == "" || paramValue == "null" {
+			return
+		}
+
+		switch name {
+		case c.FirstQueryParam:
+			if first, err = strconv.Atoi(paramValue); err != nil || first < 0 {
+				err = fmt.Errorf("invalid value for argument %q: %s", connectionFirstArgument, paramValue)
+				return
+			}
+			updates = append(updates, [2]string{fmt.Sprintf("query_params.%d.value", index), strconv.Itoa(first + 1)})
+		case c.AfterQueryParam:
+			cursor, decodeErr := base64.StdEncoding.DecodeString(paramValue)
+			if decodeErr != nil {
+				err = fmt.Errorf("invalid value for argument %q: %s", connectionAfterArgument, paramValue)
+				return
+			}
+			updates = append(updates, [2]string{fmt.Sprintf("query_params.%d.value", index), string(cursor)})
+		}
+	}, "query_params")
+	if err != nil {
+		return nil, 0, err
+	}
+
+	for _, update := range updates {
+		if input, err = sjson.SetBytes(input, update[0], update[1]); err != nil {
+			return nil, 0, err
+		}
+	}
+
+	return input, first, nil
+}
+
+// writeConnection writes the list of the upstream response as connection containing at most first edges
+func (c *ConnectionConfiguration) writeConnection(response []byte, first int, out *bytes.Buffer) error {
+	var (
+		edges     int
+		hasNext   bool
+		endCursor string
+		err       error
+	)
+
+	out.WriteString(`{"edges":[`)
+	_, arrayErr := jsonparser.ArrayEach(response, func(item []byte, dataType jsonparser.ValueType, offset int, _ error) {
+		if err != nil || hasNext {
+			return
+		}
+		if first != -1 && edges == first {
+			hasNext = true
+			return
+		}
+
+		cursorValue, _, _, getErr := jsonparser.Get(item, c.CursorField)
+		if getErr != nil {
+			err = fmt.Errorf("cursor field %q is missing in list item: %s", c.CursorField, item)
+			return
+		}
+
+		if edges != 0 {
+			out.WriteByte(',')
+		}
+		endCursor = base64.StdEncoding.EncodeToString(cursorValue)
+		out.WriteString(`{"node":`)
+		out.Write(item)
+		out.WriteString(`,"cursor":"`)
+		out.WriteString(endCursor)
+		out.WriteString(`"}`)
+		edges++
+	})
+	if arrayErr != nil {
+		return fmt.Errorf("expected list response: %w", arrayErr)
+	}
+	if err != nil {
+		return err
+	}
+
+	out.WriteString(`],"pageInfo":{"hasNextPage":`)
+	out.WriteString(strconv.FormatBool(hasNext))
+	out.WriteString(`,"endCursor":`)
+	if edges == 0 {
+		out.WriteString(`null`)
+	} else {
+		out.WriteString(`"` + endCursor + `"`)
+	}
+	out.WriteString(`}}`)
+	return nil
+}
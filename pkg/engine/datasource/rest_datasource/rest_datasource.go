@@ -20,6 +20,7 @@ type Planner struct {
 	v                   *plan.Visitor
 	config              Configuration
 	rootField           int
+	hasRootField        bool
 	operationDefinition int
 }
 
@@ -70,6 +71,8 @@ type FetchConfiguration struct {
 	Header http.Header
 	Query  []QueryConfiguration
 	Body   string
+	// Connection optionally maps the list returned by the upstream to a Relay connection
+	Connection *ConnectionConfiguration
 }
 
 type QueryConfiguration struct {
@@ -85,7 +88,12 @@ func (p *Planner) Register(visitor *plan.Visitor, configuration plan.DataSourceC
 }
 
 func (p *Planner) EnterField(ref int) {
+	// child nodes of the data source are entered as well, the arguments are taken from the root field only
+	if p.hasRootField {
+		return
+	}
 	p.rootField = ref
+	p.hasRootField = true
 }
 
 func (p *Planner) configureInput() []byte {
@@ -99,7 +107,12 @@ func (p *Planner) configureInput() []byte {
 		input = httpclient.SetInputHeader(input, header)
 	}
 
-	preparedQuery := p.prepareQueryParams(p.rootField, p.config.Fetch.Query)
+	queryParams := p.config.Fetch.Query
+	if p.config.Fetch.Connection != nil {
+		queryParams = append(append([]QueryConfiguration{}, queryParams...), p.config.Fetch.Connection.queryParams()...)
+	}
+
+	preparedQuery := p.prepareQueryParams(p.rootField, queryParams)
 	query, err := json.Marshal(preparedQuery)
 	if err == nil && len(preparedQuery) != 0 {
 		input = httpclient.SetInputQueryParams(input, query)
@@ -112,7 +125,8 @@ func (p *Planner) ConfigureFetch() plan.FetchConfiguration {
 	return plan.FetchConfiguration{
 		Input: string(input),
 		DataSource: &Source{
-			client: p.client,
+			client:     p.client,
+			connection: p.config.Fetch.Connection,
 		},
 		DisallowSingleFlight: p.config.Fetch.Method != "GET",
 		DisableDataLoader:    true,
@@ -164,9 +178,29 @@ Next:
 }
 
 type Source struct {
-	client *http.Client
+	client     *http.Client
+	connection *ConnectionConfiguration
 }
 
 func (s *Source) Load(ctx context.Context, input []byte, w io.Writer) (err error) {
-	return httpclient.Do(s.client, ctx, input, w)
+	if s.connection == nil {
+		return httpclient.Do(s.client, ctx, input, w)
+	}
+
+	input, first, err := s.connection.prepareInput(input)
+	if err != nil {
+		return err
+	}
+
+	response := &bytes.Buffer{}
+	if err = httpclient.Do(s.client, ctx, input, response); err != nil {
+		return err
+	}
+
+	out := &bytes.Buffer{}
+	if err = s.connection.writeConnection(response.Bytes(), first, out); err != nil {
+		return err
+	}
+	_, err = w.Write(out.Bytes())
+	return err
 }
@@ -6,6 +6,7 @@ import (
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"strconv"
 	"strings"
 	"testing"
 
@@ -1159,6 +1160,177 @@ func TestFastHttpJsonDataSourcePlanning(t *testing.T) {
 			DisableResolveFieldPositions: true,
 		},
 	))
+	t.Run("get request with connection", datasourcetesting.RunTest(`
+		type Query {
+			friends(first: Int, after: String): FriendConnection
+		}
+		type FriendConnection {
+			edges: [FriendEdge!]!
+			pageInfo: PageInfo!
+		}
+		type FriendEdge {
+			node: Friend!
+			cursor: String!
+		}
+		type PageInfo {
+			hasNextPage: Boolean!
+			endCursor: String
+		}
+		type Friend {
+			name: String
+		}
+	`, `
+		query Friends($first: Int, $after: String) {
+			friends(first: $first, after: $after) {
+				edges {
+					cursor
+					node {
+						name
+					}
+				}
+				pageInfo {
+					hasNextPage
+				}
+			}
+		}
+	`, "Friends",
+		&plan.SynchronousResponsePlan{
+			Response: &resolve.GraphQLResponse{
+				Data: &resolve.Object{
+					Fetch: &resolve.SingleFetch{
+						BufferId: 0,
+						Input:    `{"query_params":[{"name":"limit","value":"$$0$$"},{"name":"after_id","value":"$$1$$"}],"method":"GET","url":"https://example.com/friends"}`,
+						DataSource: &Source{
+							connection: &ConnectionConfiguration{
+								CursorField:     "id",
+								FirstQueryParam: "limit",
+								AfterQueryParam: "after_id",
+							},
+						},
+						Variables: resolve.NewVariables(
+							&resolve.ContextVariable{
+								Path:     []string{"first"},
+								Renderer: resolve.NewPlainVariableRendererWithValidation(`{"type":["integer","null"]}`),
+							},
+							&resolve.ContextVariable{
+								Path:     []string{"after"},
+								Renderer: resolve.NewPlainVariableRendererWithValidation(`{"type":["string","null"]}`),
+							},
+						),
+						DataSourceIdentifier: []byte("rest_datasource.Source"),
+						DisableDataLoader:    true,
+					},
+					Fields: []*resolve.Field{
+						{
+							BufferID:  0,
+							HasBuffer: true,
+							Name:      []byte("friends"),
+							Value: &resolve.Object{
+								Nullable: true,
+								Fields: []*resolve.Field{
+									{
+										Name: []byte("edges"),
+										Value: &resolve.Array{
+											Path: []string{"edges"},
+											Item: &resolve.Object{
+												Fields: []*resolve.Field{
+													{
+														Name: []byte("cursor"),
+														Value: &resolve.String{
+															Path: []string{"cursor"},
+														},
+													},
+													{
+														Name: []byte("node"),
+														Value: &resolve.Object{
+															Path: []string{"node"},
+															Fields: []*resolve.Field{
+																{
+																	Name: []byte("name"),
+																	Value: &resolve.String{
+																		Path:     []string{"name"},
+																		Nullable: true,
+																	},
+																},
+															},
+														},
+													},
+												},
+											},
+										},
+									},
+									{
+										Name: []byte("pageInfo"),
+										Value: &resolve.Object{
+											Path: []string{"pageInfo"},
+											Fields: []*resolve.Field{
+												{
+													Name: []byte("hasNextPage"),
+													Value: &resolve.Boolean{
+														Path: []string{"hasNextPage"},
+													},
+												},
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		plan.Configuration{
+			DataSources: []plan.DataSourceConfiguration{
+				{
+					RootNodes: []plan.TypeField{
+						{
+							TypeName:   "Query",
+							FieldNames: []string{"friends"},
+						},
+					},
+					ChildNodes: []plan.TypeField{
+						{
+							TypeName:   "FriendConnection",
+							FieldNames: []string{"edges", "pageInfo"},
+						},
+						{
+							TypeName:   "FriendEdge",
+							FieldNames: []string{"node", "cursor"},
+						},
+						{
+							TypeName:   "PageInfo",
+							FieldNames: []string{"hasNextPage", "endCursor"},
+						},
+						{
+							TypeName:   "Friend",
+							FieldNames: []string{"name"},
+						},
+					},
+					Custom: ConfigJSON(Configuration{
+						Fetch: FetchConfiguration{
+							URL:    "https://example.com/friends",
+							Method: "GET",
+							Connection: &ConnectionConfiguration{
+								CursorField:     "id",
+								FirstQueryParam: "limit",
+								AfterQueryParam: "after_id",
+							},
+						},
+					}),
+					Factory: &Factory{},
+				},
+			},
+			Fields: []plan.FieldConfiguration{
+				{
+					TypeName:              "Query",
+					FieldName:             "friends",
+					DisableDefaultMapping: true,
+				},
+			},
+			DisableResolveFieldPositions: true,
+		},
+	))
 }
 
 func TestHttpJsonDataSource_Load(t *testing.T) {
@@ -1246,6 +1418,78 @@ func TestHttpJsonDataSource_Load(t *testing.T) {
 	})
 }
 
+func TestSource_LoadConnection(t *testing.T) {
+	friends := []string{`{"id":"1","name":"Leia"}`, `{"id":"2","name":"Luke"}`, `{"id":"3","name":"Han"}`}
+
+	// the upstream returns at most limit friends following the friend with the id after_id
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		items := friends
+		if after := r.URL.Query().Get("after_id"); after != "" {
+			for i := range friends {
+				if strings.Contains(friends[i], `"id":"`+after+`"`) {
+					items = friends[i+1:]
+				}
+			}
+		}
+		if limit := r.URL.Query().Get("limit"); limit != "" {
+			n, err := strconv.Atoi(limit)
+			require.NoError(t, err)
+			if n < len(items) {
+				items = items[:n]
+			}
+		}
+		_, _ = w.Write([]byte("[" + strings.Join(items, ",") + "]"))
+	}))
+	defer server.Close()
+
+	source := &Source{
+		client: http.DefaultClient,
+		connection: &ConnectionConfiguration{
+			CursorField:     "id",
+			FirstQueryParam: "limit",
+			AfterQueryParam: "after_id",
+		},
+	}
+
+	load := func(t *testing.T, queryParams string) string {
+		t.Helper()
+		input := []byte(fmt.Sprintf(`{"query_params":[%s],"method":"GET","url":"%s"}`, queryParams, server.URL))
+		b := &strings.Builder{}
+		require.NoError(t, source.Load(context.Background(), input, b))
+		return b.String()
+	}
+
+	t.Run("first page", func(t *testing.T) {
+		assert.Equal(t,
+			`{"edges":[{"node":{"id":"1","name":"Leia"},"cursor":"MQ=="},{"node":{"id":"2","name":"Luke"},"cursor":"Mg=="}],"pageInfo":{"hasNextPage":true,"endCursor":"Mg=="}}`,
+			load(t, `{"name":"limit","value":"2"}`),
+		)
+	})
+	t.Run("last page", func(t *testing.T) {
+		assert.Equal(t,
+			`{"edges":[{"node":{"id":"3","name":"Han"},"cursor":"Mw=="}],"pageInfo":{"hasNextPage":false,"endCursor":"Mw=="}}`,
+			load(t, `{"name":"limit","value":"2"},{"name":"after_id","value":"Mg=="}`),
+		)
+	})
+	t.Run("without first", func(t *testing.T) {
+		assert.Equal(t,
+			`{"edges":[{"node":{"id":"2","name":"Luke"},"cursor":"Mg=="},{"node":{"id":"3","name":"Han"},"cursor":"Mw=="}],"pageInfo":{"hasNextPage":false,"endCursor":"Mw=="}}`,
+			load(t, `{"name":"after_id","value":"MQ=="}`),
+		)
+	})
+	t.Run("empty list", func(t *testing.T) {
+		assert.Equal(t,
+			`{"edges":[],"pageInfo":{"hasNextPage":false,"endCursor":null}}`,
+			load(t, `{"name":"limit","value":"2"},{"name":"after_id","value":"Mw=="}`),
+		)
+	})
+	t.Run("invalid cursor", func(t *testing.T) {
+		input := []byte(fmt.Sprintf(`{"query_params":[{"name":"after_id","value":"not a cursor"}],"method":"GET","url":"%s"}`, server.URL))
+		err := source.Load(context.Background(), input, &strings.Builder{})
+		assert.EqualError(t, err, `invalid value for argument "after": not a cursor`)
+	})
+}
+
 const authSchema = `
 type Mutation {
   postPasswordlessStart(postPasswordlessStartInput: postPasswordlessStartInput): PostPasswordlessStart
@@ -0,0 +1,207 @@
+package plan
+
+import (
+	"bytes"
+	"strings"
+
+	"github.com/buger/jsonparser"
+
+	"github.com/wundergraph/graphql-go-tools/pkg/engine/resolve"
+)
+
+const (
+	ExplainedPlanKindSynchronous  = "synchronous"
+	ExplainedPlanKindStreaming    = "streaming"
+	ExplainedPlanKindSubscription = "subscription"
+
+	ExplainedFetchKindSingle       = "single"
+	ExplainedFetchKindBatch        = "batch"
+	ExplainedFetchKindSubscription = "subscription"
+)
+
+// ExplainedPlan is a stable JSON description of the fetches of a plan.
+// It is meant for debugging how an operation gets split into fetches of the data sources.
+type ExplainedPlan struct {
+	// Kind is one of "synchronous", "streaming" or "subscription"
+	Kind string `json:"kind"`
+	// Fetches contains all fetches in the order of execution,
+	// a fetch is always listed after the fetches it depends on.
+	Fetches []ExplainedFetch `json:"fetches"`
+}
+
+// ExplainedFetch describes a single fetch of a plan
+type ExplainedFetch struct {
+	// Id is the position of the fetch in ExplainedPlan.Fetches
+	Id int `json:"id"`
+	// Kind is one of "single", "batch" or "subscription"
+	Kind string `json:"kind"`
+	// DataSource identifies the data source implementation
+	DataSource string `json:"dataSource,omitempty"`
+	// Service is the name of the service the fetch targets, it is empty unless set by the caller
+	Service string `json:"service,omitempty"`
+	// URL, Method and Query are extracted from the input of GraphQL data sources
+	URL    string `json:"url,omitempty"`
+	Method string `json:"method,omitempty"`
+	Query  string `json:"query,omitempty"`
+	// Representations are the entity representations of a federated entity fetch
+	Representations string `json:"representations,omitempty"`
+	// Input is the input template of the fetch, variables are printed as $$<kind>.<path>$$,
+	// e.g. $$object.upc$$ for a field of the parent object or $$context.id$$ for an operation variable
+	Input string `json:"input"`
+	// Path is the response path of the object the fetch belongs to, "@" denotes the items of a list
+	Path []string `json:"path"`
+	// DependsOn contains the ids of the fetches which provide the data of the parent object
+	DependsOn []int `json:"dependsOn"`
+}
+
+// Explain describes the fetches of a plan without executing it
+func Explain(p Plan) *ExplainedPlan {
+	e := &planExplainer{
+		explained: &ExplainedPlan{
+			Fetches: []ExplainedFetch{},
+		},
+	}
+
+	switch t := p.(type) {
+	case *SynchronousResponsePlan:
+		e.explained.Kind = ExplainedPlanKindSynchronous
+		if t.Response != nil {
+			e.explainNode(t.Response.Data, []string{}, nil)
+		}
+	case *StreamingResponsePlan:
+		e.explained.Kind = ExplainedPlanKindStreaming
+		if t.Response != nil {
+			if t.Response.InitialResponse != nil {
+				e.explainNode(t.Response.InitialResponse.Data, []string{}, nil)
+			}
+			for _, patch := range t.Response.Patches {
+				e.explainFetch(patch.Fetch, []string{}, nil)
+				e.explainNode(patch.Value, []string{}, nil)
+			}
+		}
+	case *SubscriptionResponsePlan:
+		e.explained.Kind = ExplainedPlanKindSubscription
+		if t.Response != nil {
+			trigger := e.add(ExplainedFetch{
+				Kind:  ExplainedFetchKindSubscription,
+				Input: explainInputTemplate(t.Response.Trigger.InputTemplate, t.Response.Trigger.Input),
+				Path:  []string{},
+			})
+			if t.Response.Response != nil {
+				e.explainNode(t.Response.Response.Data, []string{}, []int{trigger})
+			}
+		}
+	}
+
+	return e.explained
+}
+
+type planExplainer struct {
+	explained *ExplainedPlan
+}
+
+func (e *planExplainer) add(fetch ExplainedFetch) int {
+	fetch.Id = len(e.explained.Fetches)
+	if fetch.DependsOn == nil {
+		fetch.DependsOn = []int{}
+	}
+	e.explained.Fetches = append(e.explained.Fetches, fetch)
+	return fetch.Id
+}
+
+// explainNode walks the response tree, dependsOn contains the fetches which provide the data of the node
+func (e *planExplainer) explainNode(node resolve.Node, path []string, dependsOn []int) {
+	switch n := node.(type) {
+	case *resolve.Object:
+		path = append(path[:len(path):len(path)], n.Path...)
+		buffers := e.explainFetch(n.Fetch, path, dependsOn)
+		for _, field := range n.Fields {
+			fieldDependsOn := dependsOn
+			if field.HasBuffer {
+				if id, ok := buffers[field.BufferID]; ok {
+					fieldDependsOn = []int{id}
+				}
+			}
+			e.explainNode(field.Value, path, fieldDependsOn)
+		}
+	case *resolve.Array:
+		path = append(path[:len(path):len(path)], n.Path...)
+		e.explainNode(n.Item, append(path, "@"), dependsOn)
+	}
+}
+
+// explainFetch adds all fetches of an object and returns the ids of the fetches by buffer id
+func (e *planExplainer) explainFetch(fetch resolve.Fetch, path []string, dependsOn []int) (buffers map[int]int) {
+	buffers = map[int]int{}
+
+	switch f := fetch.(type) {
+	case *resolve.SingleFetch:
+		buffers[f.BufferId] = e.add(explainSingleFetch(f, ExplainedFetchKindSingle, path, dependsOn))
+	case *resolve.BatchFetch:
+		buffers[f.Fetch.BufferId] = e.add(explainSingleFetch(f.Fetch, ExplainedFetchKindBatch, path, dependsOn))
+	case *resolve.ParallelFetch:
+		for i := range f.Fetches {
+			for bufferID, id := range e.explainFetch(f.Fetches[i], path, dependsOn) {
+				buffers[bufferID] = id
+			}
+		}
+	}
+
+	return buffers
+}
+
+func explainSingleFetch(fetch *resolve.SingleFetch, kind string, path []string, dependsOn []int) ExplainedFetch {
+	input := explainInputTemplate(fetch.InputTemplate, []byte(fetch.Input))
+	explained := ExplainedFetch{
+		Kind:       kind,
+		DataSource: string(fetch.DataSourceIdentifier),
+		Input:      input,
+		Path:       path,
+		DependsOn:  append([]int(nil), dependsOn...),
+	}
+
+	explained.URL, _ = jsonparser.GetString([]byte(input), "url")
+	explained.Method, _ = jsonparser.GetString([]byte(input), "method")
+	explained.Query, _ = jsonparser.GetString([]byte(input), "body", "query")
+	if representations, _, _, err := jsonparser.Get([]byte(input), "body", "variables", "representations"); err == nil {
+		explained.Representations = string(representations)
+	}
+
+	return explained
+}
+
+func explainInputTemplate(template resolve.InputTemplate, input []byte) string {
+	if len(template.Segments) == 0 {
+		return string(input)
+	}
+
+	buf := &bytes.Buffer{}
+	for _, segment := range template.Segments {
+		switch segment.SegmentType {
+		case resolve.StaticSegmentType:
+			buf.Write(segment.Data)
+		case resolve.VariableSegmentType:
+			buf.WriteString("$$")
+			buf.WriteString(explainVariableKind(segment.VariableKind))
+			if len(segment.VariableSourcePath) != 0 {
+				buf.WriteByte('.')
+				buf.WriteString(strings.Join(segment.VariableSourcePath, "."))
+			}
+			buf.WriteString("$$")
+		}
+	}
+	return buf.String()
+}
+
+func explainVariableKind(kind resolve.VariableKind) string {
+	switch kind {
+	case resolve.ContextVariableKind:
+		return "context"
+	case resolve.ObjectVariableKind:
+		return "object"
+	case resolve.HeaderVariableKind:
+		return "header"
+	default:
+		return "unknown"
+	}
+}
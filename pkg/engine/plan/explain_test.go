@@ -0,0 +1,94 @@
+package plan
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/wundergraph/graphql-go-tools/pkg/engine/resolve"
+)
+
+func TestExplain(t *testing.T) {
+	static := func(data string) resolve.TemplateSegment {
+		return resolve.TemplateSegment{SegmentType: resolve.StaticSegmentType, Data: []byte(data)}
+	}
+	objectVariable := func(path ...string) resolve.TemplateSegment {
+		return (&resolve.ObjectVariable{Path: path}).TemplateSegment()
+	}
+	fetch := func(bufferID int, segments ...resolve.TemplateSegment) *resolve.SingleFetch {
+		return &resolve.SingleFetch{
+			BufferId:             bufferID,
+			InputTemplate:        resolve.InputTemplate{Segments: segments},
+			DataSourceIdentifier: []byte("graphql_datasource.Source"),
+		}
+	}
+
+	p := &SynchronousResponsePlan{
+		Response: &resolve.GraphQLResponse{
+			Data: &resolve.Object{
+				Fetch: &resolve.ParallelFetch{
+					Fetches: []resolve.Fetch{
+						fetch(0, static(`{"method":"POST","url":"http://users","body":{"query":"{me {id}}"}}`)),
+						fetch(1, static(`{"method":"POST","url":"http://products","body":{"query":"{topProducts {upc}}"}}`)),
+					},
+				},
+				Fields: []*resolve.Field{
+					{
+						HasBuffer: true,
+						BufferID:  0,
+						Name:      []byte("me"),
+						Value: &resolve.Object{
+							Path: []string{"me"},
+							Fetch: &resolve.BatchFetch{
+								Fetch: fetch(2,
+									static(`{"method":"POST","url":"http://reviews","body":{"query":"query($representations: [_Any!]!){_entities(representations: $representations){... on User {reviews {body}}}}","variables":{"representations":[{"__typename":"User","id":`),
+									objectVariable("id"),
+									static(`}]}}}`),
+								),
+							},
+							Fields: []*resolve.Field{
+								{
+									HasBuffer: true,
+									BufferID:  2,
+									Name:      []byte("reviews"),
+									Value: &resolve.Array{
+										Path: []string{"reviews"},
+										Item: &resolve.Object{},
+									},
+								},
+							},
+						},
+					},
+					{
+						HasBuffer: true,
+						BufferID:  1,
+						Name:      []byte("topProducts"),
+						Value: &resolve.Array{
+							Path: []string{"topProducts"},
+							Item: &resolve.Object{
+								Fetch: fetch(3,
+									static(`{"method":"POST","url":"http://inventory","body":{"query":"query($upc: String){inStock(upc: $upc)}","variables":{"upc":`),
+									objectVariable("upc"),
+									static(`}}}`),
+								),
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	explained, err := json.Marshal(Explain(p))
+	require.NoError(t, err)
+
+	expected := `{"kind":"synchronous","fetches":[` +
+		`{"id":0,"kind":"single","dataSource":"graphql_datasource.Source","url":"http://users","method":"POST","query":"{me {id}}","input":"{\"method\":\"POST\",\"url\":\"http://users\",\"body\":{\"query\":\"{me {id}}\"}}","path":[],"dependsOn":[]},` +
+		`{"id":1,"kind":"single","dataSource":"graphql_datasource.Source","url":"http://products","method":"POST","query":"{topProducts {upc}}","input":"{\"method\":\"POST\",\"url\":\"http://products\",\"body\":{\"query\":\"{topProducts {upc}}\"}}","path":[],"dependsOn":[]},` +
+		`{"id":2,"kind":"batch","dataSource":"graphql_datasource.Source","url":"http://reviews","method":"POST","query":"query($representations: [_Any!]!){_entities(representations: $representations){... on User {reviews {body}}}}","representations":"[{\"__typename\":\"User\",\"id\":$$object.id$$}]","input":"{\"method\":\"POST\",\"url\":\"http://reviews\",\"body\":{\"query\":\"query($representations: [_Any!]!){_entities(representations: $representations){... on User {reviews {body}}}}\",\"variables\":{\"representations\":[{\"__typename\":\"User\",\"id\":$$object.id$$}]}}}","path":["me"],"dependsOn":[0]},` +
+		`{"id":3,"kind":"single","dataSource":"graphql_datasource.Source","url":"http://inventory","method":"POST","query":"query($upc: String){inStock(upc: $upc)}","input":"{\"method\":\"POST\",\"url\":\"http://inventory\",\"body\":{\"query\":\"query($upc: String){inStock(upc: $upc)}\",\"variables\":{\"upc\":$$object.upc$$}}}","path":["topProducts","@"],"dependsOn":[1]}` +
+		`]}`
+	assert.Equal(t, expected, string(explained))
+}
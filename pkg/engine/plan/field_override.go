@@ -0,0 +1,74 @@
+package plan
+
+import (
+	"sort"
+)
+
+// FieldOverride is a field of the root or child nodes of a data source which is migrated to another data source,
+// e.g. with the federation @override directive. Operations with the Label enabled plan the field on the data source
+// the field is migrated to, all other operations plan it on the data source the field is migrated from.
+type FieldOverride struct {
+	TypeName  string
+	FieldName string
+	Label     string
+	// Overriding is true on the data source the field is migrated to and false on the data source it is migrated from
+	Overriding bool
+}
+
+// OverrideLabels returns the sorted labels of the field overrides of all data sources
+func (c *Configuration) OverrideLabels() []string {
+	seen := map[string]struct{}{}
+	var labels []string
+	for i := range c.DataSources {
+		for _, override := range c.DataSources[i].FieldOverrides {
+			if _, ok := seen[override.Label]; ok {
+				continue
+			}
+			seen[override.Label] = struct{}{}
+			labels = append(labels, override.Label)
+		}
+	}
+	sort.Strings(labels)
+	return labels
+}
+
+// WithEnabledOverrideLabels returns a copy of the configuration where the fields of the overrides are removed from
+// the data sources which don't plan them for the enabled labels, the nodes of the configuration are not modified
+func (c Configuration) WithEnabledOverrideLabels(enabled map[string]bool) Configuration {
+	dataSources := make([]DataSourceConfiguration, len(c.DataSources))
+	copy(dataSources, c.DataSources)
+	for i := range dataSources {
+		for _, override := range dataSources[i].FieldOverrides {
+			if override.Overriding == enabled[override.Label] {
+				continue
+			}
+			dataSources[i].RemoveField(override.TypeName, override.FieldName)
+		}
+	}
+	c.DataSources = dataSources
+	return c
+}
+
+// RemoveField removes the field from the root and child nodes, so the field gets planned on another data source.
+// The nodes are copied, so configurations sharing the nodes are not affected.
+func (d *DataSourceConfiguration) RemoveField(typeName, fieldName string) {
+	d.RootNodes = removeTypeField(d.RootNodes, typeName, fieldName)
+	d.ChildNodes = removeTypeField(d.ChildNodes, typeName, fieldName)
+}
+
+func removeTypeField(nodes []TypeField, typeName, fieldName string) []TypeField {
+	out := make([]TypeField, 0, len(nodes))
+	for _, node := range nodes {
+		if node.TypeName == typeName {
+			fieldNames := make([]string, 0, len(node.FieldNames))
+			for _, name := range node.FieldNames {
+				if name != fieldName {
+					fieldNames = append(fieldNames, name)
+				}
+			}
+			node.FieldNames = fieldNames
+		}
+		out = append(out, node)
+	}
+	return out
+}
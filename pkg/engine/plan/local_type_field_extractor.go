@@ -2,6 +2,7 @@ package plan
 
 import (
 	"github.com/wundergraph/graphql-go-tools/pkg/ast"
+	"github.com/wundergraph/graphql-go-tools/pkg/astparser"
 )
 
 const FederationKeyDirectiveName = "key"
@@ -9,6 +10,7 @@ const FederationKeyDirectiveName = "key"
 const (
 	federationRequireDirectiveName  = "requires"
 	federationExternalDirectiveName = "external"
+	federationProvidesDirectiveName = "provides"
 )
 
 // LocalTypeFieldExtractor takes an ast.Document as input and generates the
@@ -16,10 +18,12 @@ const (
 // root operation types (usually Query, Mutation and Schema--though these types
 // can be configured via the schema keyword) plus "entities" as defined by the
 // Apollo federation specification. In short, entities are types with a @key
-// directive. Child nodes are field types recursively accessible via a root
+// directive or implementing an interface with a @key directive. Child nodes are field types recursively accessible via a root
 // node. Nodes are either object or interface definitions or extensions. Root
 // nodes only include "local" fields; they don't include fields that have the
-// @external directive.
+// @external directive. Fields selected by a @provides directive are
+// provided fields, they are only known below the providing field, for list
+// fields they are provided for each element.
 type LocalTypeFieldExtractor struct {
 	document               *ast.Document
 	queryTypeName          string
@@ -32,6 +36,7 @@ type LocalTypeFieldExtractor struct {
 	childrenToProcess      []string
 	rootNodes              []TypeField
 	childNodes             []TypeField
+	providedFields         []ProvidedFields
 }
 
 func NewLocalTypeFieldExtractor(document *ast.Document) *LocalTypeFieldExtractor {
@@ -135,9 +140,19 @@ func (e *LocalTypeFieldExtractor) GetAllNodes() ([]TypeField, []TypeField) {
 	// children to process.
 	e.createChildNodes()
 
+	// Record the fields selected by @provides directives below the providing
+	// fields.
+	e.collectProvidedFields()
+
 	return e.rootNodes, e.childNodes
 }
 
+// GetProvidedFields returns the fields selected by @provides directives in
+// the document, GetAllNodes must be called before.
+func (e *LocalTypeFieldExtractor) GetProvidedFields() []ProvidedFields {
+	return e.providedFields
+}
+
 func (e *LocalTypeFieldExtractor) overrideRootOperationTypeNames() {
 	indexedQueryTypeName := string(e.document.Index.QueryTypeName)
 	if indexedQueryTypeName != "" && indexedQueryTypeName != e.queryTypeName {
@@ -201,13 +216,13 @@ func (e *LocalTypeFieldExtractor) getNodeInfo(node ast.Node) *nodeInformation {
 	nodeInfo, ok := e.nodeInfoMap[typeName]
 	if ok {
 		// if this node has the key directive, we need to add it to the node information
-		nodeInfo.hasKeyDirective = nodeInfo.hasKeyDirective || e.document.NodeHasDirectiveByNameString(node, FederationKeyDirectiveName)
+		nodeInfo.hasKeyDirective = nodeInfo.hasKeyDirective || e.hasKey(node)
 		return nodeInfo
 	}
 
 	nodeInfo = &nodeInformation{
 		typeName:        typeName,
-		hasKeyDirective: e.document.NodeHasDirectiveByNameString(node, FederationKeyDirectiveName),
+		hasKeyDirective: e.hasKey(node),
 		requiredFields:  make(map[string]struct{}),
 	}
 
@@ -215,6 +230,21 @@ func (e *LocalTypeFieldExtractor) getNodeInfo(node ast.Node) *nodeInformation {
 	return nodeInfo
 }
 
+// hasKey reports whether the node has a @key directive, objects implementing an interface with a @key directive
+// are entities as well
+func (e *LocalTypeFieldExtractor) hasKey(node ast.Node) bool {
+	if e.document.NodeHasDirectiveByNameString(node, FederationKeyDirectiveName) {
+		return true
+	}
+	switch node.Kind {
+	case ast.NodeKindObjectTypeDefinition, ast.NodeKindObjectTypeExtension:
+		_, ok := interfaceKeyFields(e.document, e.document.NodeInterfaceRefs(node))
+		return ok
+	default:
+		return false
+	}
+}
+
 func (e *LocalTypeFieldExtractor) isRootNode(nodeInfo *nodeInformation) bool {
 	isFederationEntity := nodeInfo.hasKeyDirective && !nodeInfo.isInterface
 	return nodeInfo.typeName == e.queryTypeName ||
@@ -241,6 +271,121 @@ func (e *LocalTypeFieldExtractor) collectFieldDefinitions(node ast.Node, nodeInf
 	}
 }
 
+func (e *LocalTypeFieldExtractor) collectProvidedFields() {
+	e.providedFields = e.providedFields[:0]
+	for _, astNode := range e.document.RootNodes {
+		switch astNode.Kind {
+		case ast.NodeKindObjectTypeDefinition, ast.NodeKindObjectTypeExtension,
+			ast.NodeKindInterfaceTypeDefinition, ast.NodeKindInterfaceTypeExtension:
+		default:
+			continue
+		}
+
+		for _, ref := range e.document.NodeFieldDefinitions(astNode) {
+			fieldSet, selectionSetRef, ok := providedFieldSet(e.document, ref)
+			if !ok {
+				continue
+			}
+			providing := ProvidedFields{
+				ProvidingTypeName:  e.document.NodeNameString(astNode),
+				ProvidingFieldName: e.document.FieldDefinitionNameString(ref),
+			}
+			// The provided type is the named type of the field, so the
+			// fields are provided for each element of a list field.
+			providedTypeName := e.document.ResolveTypeNameString(e.document.FieldDefinitionType(ref))
+			e.addProvidedFields(providing, nil, providedTypeName, fieldSet, selectionSetRef)
+		}
+	}
+}
+
+// addProvidedFields records the fields of the selection set as fields of the
+// type provided at the path below the providing field. Nested selections
+// provide the fields of the field types, inline fragments the fields of the
+// type condition.
+func (e *LocalTypeFieldExtractor) addProvidedFields(providing ProvidedFields, path []string, typeName string, fieldSet *ast.Document, selectionSetRef int) {
+	nodeInfo, ok := e.nodeInfoMap[typeName]
+	if !ok {
+		return
+	}
+
+	for _, selectionRef := range fieldSet.SelectionSets[selectionSetRef].SelectionRefs {
+		selection := fieldSet.Selections[selectionRef]
+		switch selection.Kind {
+		case ast.SelectionKindField:
+			fieldName := fieldSet.FieldNameString(selection.Ref)
+			e.addProvidedField(providing, path, typeName, fieldName)
+			if !fieldSet.FieldHasSelections(selection.Ref) {
+				continue
+			}
+			fieldTypeName, ok := e.fieldTypeName(nodeInfo, fieldName)
+			if !ok {
+				continue
+			}
+			fieldPath := append(path[:len(path):len(path)], fieldName)
+			e.addProvidedFields(providing, fieldPath, fieldTypeName, fieldSet, fieldSet.Fields[selection.Ref].SelectionSet)
+		case ast.SelectionKindInlineFragment:
+			inlineFragment := fieldSet.InlineFragments[selection.Ref]
+			fragmentTypeName := typeName
+			if inlineFragment.TypeCondition.Type != -1 {
+				fragmentTypeName = fieldSet.ResolveTypeNameString(inlineFragment.TypeCondition.Type)
+			}
+			e.addProvidedFields(providing, path, fragmentTypeName, fieldSet, inlineFragment.SelectionSet)
+		}
+	}
+}
+
+func (e *LocalTypeFieldExtractor) addProvidedField(providing ProvidedFields, path []string, typeName, fieldName string) {
+	for i := range e.providedFields {
+		provided := &e.providedFields[i]
+		if provided.ProvidingTypeName != providing.ProvidingTypeName || provided.ProvidingFieldName != providing.ProvidingFieldName ||
+			provided.TypeName != typeName || !equalStrings(provided.Path, path) {
+			continue
+		}
+		if !containsString(provided.FieldNames, fieldName) {
+			provided.FieldNames = append(provided.FieldNames, fieldName)
+		}
+		return
+	}
+	providing.Path = path
+	providing.TypeName = typeName
+	providing.FieldNames = []string{fieldName}
+	e.providedFields = append(e.providedFields, providing)
+}
+
+func (e *LocalTypeFieldExtractor) fieldTypeName(nodeInfo *nodeInformation, fieldName string) (string, bool) {
+	for _, refs := range [][]int{nodeInfo.localFieldRefs, nodeInfo.externalFieldRefs} {
+		for _, ref := range refs {
+			if e.document.FieldDefinitionNameString(ref) == fieldName {
+				return e.document.ResolveTypeNameString(e.document.FieldDefinitionType(ref)), true
+			}
+		}
+	}
+	return "", false
+}
+
+// providedFieldSet parses the field set of the @provides directive of a field definition
+func providedFieldSet(document *ast.Document, fieldDefinitionRef int) (fieldSet *ast.Document, selectionSetRef int, ok bool) {
+	for _, directiveRef := range document.FieldDefinitions[fieldDefinitionRef].Directives.Refs {
+		if directiveName := document.DirectiveNameString(directiveRef); directiveName != federationProvidesDirectiveName {
+			continue
+		}
+
+		value, exists := document.DirectiveArgumentValueByName(directiveRef, fieldsArgumentNameBytes)
+		if !exists || value.Kind != ast.ValueKindString {
+			continue
+		}
+
+		parsed, report := astparser.ParseGraphqlDocumentString("{" + document.StringValueContentString(value.Ref) + "}")
+		if report.HasErrors() || len(parsed.OperationDefinitions) == 0 {
+			continue
+		}
+
+		return &parsed, parsed.OperationDefinitions[0].SelectionSet, true
+	}
+
+	return nil, -1, false
+}
+
 func (e *LocalTypeFieldExtractor) assignConcreteTypesToInterfaces() {
 	for interfaceName, concreteTypeNames := range e.possibleInterfaceTypes {
 		if nodeInfo, ok := e.nodeInfoMap[interfaceName]; ok {
@@ -347,3 +492,24 @@ func (e *LocalTypeFieldExtractor) createChildNodes() {
 		})
 	}
 }
+
+func containsString(values []string, value string) bool {
+	for i := range values {
+		if values[i] == value {
+			return true
+		}
+	}
+	return false
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
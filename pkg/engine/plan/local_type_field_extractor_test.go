@@ -263,6 +263,28 @@ func TestLocalTypeFieldExtractor_GetAllNodes(t *testing.T) {
 				{TypeName: "User", FieldNames: []string{"communications", "id"}},
 			})
 	})
+	t.Run("types implementing an interface with key directive", func(t *testing.T) {
+		run(t, `
+			extend interface History @key(fields: "id") {
+				id: ID! @external
+			}
+
+			extend type Purchase implements History {
+				id: ID! @external
+				product: String!
+			}
+
+			type Sale implements History {
+				id: ID!
+				rating: Int!
+			}
+		`,
+			[]TypeField{
+				{TypeName: "Purchase", FieldNames: []string{"product"}},
+				{TypeName: "Sale", FieldNames: []string{"id", "rating"}},
+			},
+			[]TypeField{})
+	})
 	t.Run("extended interface", func(t *testing.T) {
 		t.Log("Bug: The concrete types that implement an interface should also be included")
 
@@ -572,6 +594,78 @@ func TestLocalTypeFieldExtractor_GetAllNodes(t *testing.T) {
 				{TypeName: "User", FieldNames: []string{"fullname", "id", "reviews", "username"}},
 			})
 	})
+	t.Run("provided list field", func(t *testing.T) {
+		run(t, `
+			extend type Product @key(fields: "upc") {
+				upc: String! @external
+				reviewers: [User!]! @provides(fields: "username")
+			}
+
+			extend type User @key(fields: "id") {
+				id: ID! @external
+				username: String! @external
+				lastname: String! @external
+				fullname: String @requires(fields: "lastname username")
+			}
+		`,
+			[]TypeField{
+				{TypeName: "Product", FieldNames: []string{"reviewers"}},
+				{TypeName: "User", FieldNames: []string{"fullname"}},
+			},
+			[]TypeField{
+				{TypeName: "User", FieldNames: []string{"fullname", "id", "username"}},
+			})
+	})
+	t.Run("provided list field with nested objects", func(t *testing.T) {
+		run(t, `
+			extend type Product @key(fields: "upc") {
+				upc: String! @external
+				reviewers: [[User!]] @provides(fields: "username account { ... on PasswordAccount { email } }")
+			}
+
+			extend type User @key(fields: "id") {
+				id: ID! @external
+				username: String! @external
+				account: Account @external
+			}
+
+			interface Account {
+				id: ID!
+			}
+
+			extend type PasswordAccount implements Account @key(fields: "id") {
+				id: ID! @external
+			}
+		`,
+			[]TypeField{
+				{TypeName: "Product", FieldNames: []string{"reviewers"}},
+			},
+			[]TypeField{
+				{TypeName: "Account", FieldNames: []string{"id"}},
+				{TypeName: "PasswordAccount", FieldNames: []string{"id"}},
+				{TypeName: "User", FieldNames: []string{"account", "id", "username"}},
+			})
+	})
+	t.Run("provided fields which are not declared", func(t *testing.T) {
+		run(t, `
+			type Review {
+				body: String!
+				authors: [User!]! @provides(fields: "username")
+			}
+
+			extend type User @key(fields: "id") {
+				id: ID! @external
+				reviews: [Review]
+			}
+		`,
+			[]TypeField{
+				{TypeName: "User", FieldNames: []string{"reviews"}},
+			},
+			[]TypeField{
+				{TypeName: "Review", FieldNames: []string{"authors", "body"}},
+				{TypeName: "User", FieldNames: []string{"id", "reviews"}},
+			})
+	})
 	t.Run("local type extension", func(t *testing.T) {
 		run(t, `
            extend type Query {
@@ -734,6 +828,42 @@ func TestLocalTypeFieldExtractor_GetAllNodes(t *testing.T) {
 	})
 }
 
+func TestLocalTypeFieldExtractor_GetProvidedFields(t *testing.T) {
+	document := unsafeparser.ParseGraphqlDocumentString(`
+		extend type Product @key(fields: "upc") {
+			upc: String! @external
+			reviewers: [[User!]] @provides(fields: "username account { ... on PasswordAccount { email } }")
+		}
+
+		extend type User @key(fields: "id") {
+			id: ID! @external
+			username: String! @external
+			account: Account @external
+		}
+
+		interface Account {
+			id: ID!
+		}
+
+		extend type PasswordAccount implements Account @key(fields: "id") {
+			id: ID! @external
+		}
+	`)
+	extractor := NewLocalTypeFieldExtractor(&document)
+	_, childNodes := extractor.GetAllNodes()
+
+	assert.Equal(t, []ProvidedFields{
+		{ProvidingTypeName: "Product", ProvidingFieldName: "reviewers", TypeName: "User", FieldNames: []string{"username", "account"}},
+		{ProvidingTypeName: "Product", ProvidingFieldName: "reviewers", Path: []string{"account"}, TypeName: "PasswordAccount", FieldNames: []string{"email"}},
+	}, extractor.GetProvidedFields())
+	// provided fields are not child nodes outside of the providing field
+	for _, node := range childNodes {
+		if node.TypeName == "PasswordAccount" {
+			assert.NotContains(t, node.FieldNames, "email")
+		}
+	}
+}
+
 func BenchmarkGetAllNodes(b *testing.B) {
 	document := unsafeparser.ParseGraphqlDocumentString(benchmarkSDL)
 
@@ -8,9 +8,11 @@ import (
 	"reflect"
 	"regexp"
 	"strings"
+	"time"
 
 	"github.com/wundergraph/graphql-go-tools/pkg/ast"
 	"github.com/wundergraph/graphql-go-tools/pkg/astimport"
+	"github.com/wundergraph/graphql-go-tools/pkg/astnormalization"
 	"github.com/wundergraph/graphql-go-tools/pkg/astvisitor"
 	"github.com/wundergraph/graphql-go-tools/pkg/engine/resolve"
 	"github.com/wundergraph/graphql-go-tools/pkg/lexer/literal"
@@ -25,6 +27,7 @@ type Planner struct {
 	planningVisitor       *Visitor
 	requiredFieldsWalker  *astvisitor.Walker
 	requiredFieldsVisitor *requiredFieldsVisitor
+	fragmentExpander      *astnormalization.FragmentSpreadExpander
 }
 
 type Configuration struct {
@@ -36,6 +39,15 @@ type Configuration struct {
 	// This setting removes position information from all fields
 	// In production, this should be set to false so that error messages are easier to understand
 	DisableResolveFieldPositions bool
+	// CustomScalars are planned as resolve.Scalar so that the resolver serializes their values
+	CustomScalars *resolve.ScalarRegistry
+	// UnauthorizedFields are not fetched from their data sources, they are planned as resolve.FieldError instead
+	UnauthorizedFields []TypeField
+	// LazyFragmentExpansion expands the fragment spreads of the planned operation before planning it,
+	// it's required for operations normalized with astnormalization.WithLazyFragmentExpansion
+	LazyFragmentExpansion bool
+	// EnumValues rename values of enums between the schema and the data sources
+	EnumValues EnumValueConfigurations
 }
 
 type DirectiveConfigurations []DirectiveConfiguration
@@ -96,6 +108,52 @@ type TypeConfiguration struct {
 	RenameTo string
 }
 
+type EnumValueConfigurations []EnumValueConfiguration
+
+// RenameValueOnMatch returns the value of the enum in the data sources
+func (e EnumValueConfigurations) RenameValueOnMatch(typeName, valueName string) string {
+	for i := range e {
+		if e[i].TypeName == typeName && e[i].ValueName == valueName {
+			return e[i].RenameTo
+		}
+	}
+	return valueName
+}
+
+// HasType reports whether values of the enum are renamed
+func (e EnumValueConfigurations) HasType(typeName string) bool {
+	for i := range e {
+		if e[i].TypeName == typeName {
+			return true
+		}
+	}
+	return false
+}
+
+// responseRenames returns the renames of the values of the enum in the responses of the data sources
+func (e EnumValueConfigurations) responseRenames(typeName string) []resolve.RenameEnumValue {
+	var renames []resolve.RenameEnumValue
+	for i := range e {
+		if e[i].TypeName == typeName {
+			renames = append(renames, resolve.RenameEnumValue{
+				From: []byte(e[i].RenameTo),
+				To:   []byte(e[i].ValueName),
+			})
+		}
+	}
+	return renames
+}
+
+type EnumValueConfiguration struct {
+	TypeName  string
+	ValueName string
+	// RenameTo is the value of the enum in the data sources
+	// e.g. if a data source returns ACTIVE for the value ENABLED of the schema
+	// ValueName is set to ENABLED and RenameTo to ACTIVE
+	// The value is renamed in the variables sent to the data sources and in their responses
+	RenameTo string
+}
+
 type FieldConfigurations []FieldConfiguration
 
 func (f FieldConfigurations) ForTypeField(typeName, fieldName string) *FieldConfiguration {
@@ -116,11 +174,30 @@ type FieldConfiguration struct {
 	Path           []string
 	Arguments      ArgumentsConfigurations
 	RequiresFields []string
+	// AlternativeRequiresFields can be required instead of RequiresFields, e.g. for the other @key directives of an
+	// entity with multiple keys. The first of RequiresFields and the alternatives of which all fields are available
+	// from a data source of the enclosing object is required, see Visitor.RequiredFields.
+	AlternativeRequiresFields [][]string
 	// UnescapeResponseJson set to true will allow fields (String,List,Object)
 	// to be resolved from an escaped JSON string
 	// e.g. {"response":"{\"foo\":\"bar\"}"} will be returned as {"foo":"bar"} when path is "response"
 	// This way, it is possible to resolve a JSON string as part of the response without extra String encoding of the JSON
 	UnescapeResponseJson bool
+	// SubscriptionFilter filters the events of a subscription field by its arguments
+	SubscriptionFilter *SubscriptionFilterConfiguration
+}
+
+// SubscriptionFilterConfiguration configures the events of a subscription field that are sent to a client,
+// e.g. only the price updates of the product with the upc argument of updateProductPrice(upc: "1")
+type SubscriptionFilterConfiguration struct {
+	Conditions []SubscriptionFilterConditionConfiguration
+}
+
+type SubscriptionFilterConditionConfiguration struct {
+	// ArgumentName is the argument of the subscription field the value of the event must be equal to
+	ArgumentName string
+	// FieldPath is the path of the value in the event relative to the subscription field, e.g. ["upc"]
+	FieldPath []string
 }
 
 type ArgumentsConfigurations []ArgumentConfiguration
@@ -163,18 +240,31 @@ type DataSourceConfiguration struct {
 	// They are always required for the Graphql datasources cause each field could have it's own datasource
 	// For any single point datasource like HTTP/REST or GRPC we could not request less fields, as we always get a full response
 	ChildNodes []TypeField
-	Directives DirectiveConfigurations
-	Factory    PlannerFactory
-	Custom     json.RawMessage
+	// ProvidedFields - describes fields which the DataSource resolves only below the field providing them,
+	// e.g. fields selected by the @provides directive of federation
+	ProvidedFields []ProvidedFields
+	// FieldOverrides are fields of the nodes which are planned on this data source for a part of the operations only
+	FieldOverrides []FieldOverride
+	Directives     DirectiveConfigurations
+	Factory        PlannerFactory
+	Custom         json.RawMessage
 }
 
 func (d *DataSourceConfiguration) HasRootNode(typeName, fieldName string) bool {
-	for i := range d.RootNodes {
-		if typeName != d.RootNodes[i].TypeName {
+	return hasTypeField(d.RootNodes, typeName, fieldName)
+}
+
+func (d *DataSourceConfiguration) HasChildNode(typeName, fieldName string) bool {
+	return hasTypeField(d.ChildNodes, typeName, fieldName)
+}
+
+func hasTypeField(typeFields []TypeField, typeName, fieldName string) bool {
+	for i := range typeFields {
+		if typeName != typeFields[i].TypeName {
 			continue
 		}
-		for j := range d.RootNodes[i].FieldNames {
-			if fieldName == d.RootNodes[i].FieldNames[j] {
+		for j := range typeFields[i].FieldNames {
+			if fieldName == typeFields[i].FieldNames[j] {
 				return true
 			}
 		}
@@ -197,6 +287,17 @@ type TypeField struct {
 	FieldNames []string
 }
 
+// ProvidedFields are fields of a type which are resolved by the DataSource of the providing field.
+// Path are the names of the fields from the providing field to the enclosing field of the provided fields,
+// it's empty if the fields are selected directly on the providing field.
+type ProvidedFields struct {
+	ProvidingTypeName  string
+	ProvidingFieldName string
+	Path               []string
+	TypeName           string
+	FieldNames         []string
+}
+
 type FieldMapping struct {
 	TypeName              string
 	FieldName             string
@@ -223,7 +324,7 @@ func NewPlanner(ctx context.Context, config Configuration) *Planner {
 
 	requiredFieldsWalker.RegisterEnterDocumentVisitor(requiredFieldsV)
 	requiredFieldsWalker.RegisterEnterOperationVisitor(requiredFieldsV)
-	requiredFieldsWalker.RegisterEnterFieldVisitor(requiredFieldsV)
+	requiredFieldsWalker.RegisterFieldVisitor(requiredFieldsV)
 
 	// configuration
 
@@ -255,6 +356,7 @@ func NewPlanner(ctx context.Context, config Configuration) *Planner {
 		planningVisitor:       planningVisitor,
 		requiredFieldsWalker:  &requiredFieldsWalker,
 		requiredFieldsVisitor: requiredFieldsV,
+		fragmentExpander:      astnormalization.NewFragmentSpreadExpander(),
 	}
 
 	return p
@@ -277,6 +379,15 @@ func (p *Planner) Plan(operation, definition *ast.Document, operationName string
 		return
 	}
 
+	// expand fragments on demand
+
+	if config.LazyFragmentExpansion {
+		p.fragmentExpander.ExpandNamedOperation(operation, definition, []byte(p.planningVisitor.OperationName), report)
+		if report.HasErrors() {
+			return
+		}
+	}
+
 	// pre-process required fields
 
 	p.preProcessRequiredFields(&config, operation, definition, report)
@@ -292,7 +403,8 @@ func (p *Planner) Plan(operation, definition *ast.Document, operationName string
 	p.planningVisitor.Config = config
 	p.planningVisitor.fetchConfigurations = p.configurationVisitor.fetches
 	p.planningVisitor.fieldBuffers = p.configurationVisitor.fieldBuffers
-	p.planningVisitor.skipFieldPaths = p.requiredFieldsVisitor.skipFieldPaths
+	p.planningVisitor.skipFieldRefs = p.requiredFieldsVisitor.skipFieldRefs
+	p.planningVisitor.selectedRequiresFields = p.requiredFieldsVisitor.selectedRequiresFields
 
 	p.planningWalker.ResetVisitors()
 	p.planningWalker.SetVisitorFilter(p.planningVisitor)
@@ -376,7 +488,8 @@ type Visitor struct {
 	planners                     []plannerConfiguration
 	fetchConfigurations          []objectFetchConfiguration
 	fieldBuffers                 map[int]int
-	skipFieldPaths               []string
+	skipFieldRefs                []int
+	selectedRequiresFields       map[int][]string
 	fieldConfigs                 map[int]*FieldConfiguration
 	exportedVariables            map[string]struct{}
 	skipIncludeFields            map[int]skipIncludeField
@@ -399,12 +512,22 @@ type objectFetchConfiguration struct {
 	object             *resolve.Object
 	trigger            *resolve.GraphQLSubscriptionTrigger
 	planner            DataSourcePlanner
+	dataSourceIndex    int
 	bufferID           int
 	isSubscription     bool
 	fieldRef           int
 	fieldDefinitionRef int
 }
 
+// RequiredFields returns the fields the field with the ref requires from its enclosing object,
+// the RequiresFields of the field config unless one of the AlternativeRequiresFields was selected for the field
+func (v *Visitor) RequiredFields(fieldRef int, fieldConfig *FieldConfiguration) []string {
+	if requiresFields, ok := v.selectedRequiresFields[fieldRef]; ok {
+		return requiresFields
+	}
+	return fieldConfig.RequiresFields
+}
+
 func (v *Visitor) AllowVisitor(kind astvisitor.VisitorKind, ref int, visitor interface{}) bool {
 	if visitor == v {
 		return true
@@ -454,6 +577,14 @@ func (v *Visitor) EnterDirective(ref int) {
 					v.plan.SetFlushInterval(v.Operation.IntValueAsInt(value.Ref))
 				}
 			}
+		case "timeout":
+			if value, ok := v.Operation.DirectiveArgumentValueByName(ref, literal.MS); ok {
+				if value.Kind == ast.ValueKindInteger {
+					if synchronousPlan, ok := v.plan.(*SynchronousResponsePlan); ok {
+						synchronousPlan.Response.Timeout = time.Duration(v.Operation.IntValueAsInt(value.Ref)) * time.Millisecond
+					}
+				}
+			}
 		}
 	case ast.NodeKindField:
 		switch directiveName {
@@ -520,6 +651,23 @@ func (v *Visitor) EnterField(ref int) {
 
 	fieldName := v.Operation.FieldNameBytes(ref)
 	fieldAliasOrName := v.Operation.FieldAliasOrNameBytes(ref)
+	if bytes.Equal(fieldName, literal.TYPENAME) && v.isRootField() {
+		// the __typename of a root operation type is known without fetching
+		typeName := v.Walker.EnclosingTypeDefinition.NameString(v.Definition)
+		v.currentField = &resolve.Field{
+			Name: fieldAliasOrName,
+			Value: &resolve.StaticString{
+				Value: v.Config.Types.RenameTypeNameOnMatchStr(typeName),
+			},
+			Position:                v.resolveFieldPosition(ref),
+			SkipDirectiveDefined:    skip,
+			SkipVariableName:        skipVariableName,
+			IncludeDirectiveDefined: include,
+			IncludeVariableName:     includeVariableName,
+		}
+		v.appendCurrentField()
+		return
+	}
 	if bytes.Equal(fieldName, literal.TYPENAME) {
 		v.currentField = &resolve.Field{
 			Name: fieldAliasOrName,
@@ -529,13 +677,14 @@ func (v *Visitor) EnterField(ref int) {
 				IsTypeName: true,
 			},
 			OnTypeName:              v.resolveOnTypeName(),
+			OnTypeNames:             v.resolveOnTypeNames(),
 			Position:                v.resolveFieldPosition(ref),
 			SkipDirectiveDefined:    skip,
 			SkipVariableName:        skipVariableName,
 			IncludeDirectiveDefined: include,
 			IncludeVariableName:     includeVariableName,
 		}
-		*v.currentFields[len(v.currentFields)-1].fields = append(*v.currentFields[len(v.currentFields)-1].fields, v.currentField)
+		v.appendCurrentField()
 		return
 	}
 
@@ -544,6 +693,28 @@ func (v *Visitor) EnterField(ref int) {
 		return
 	}
 
+	enclosingTypeName := v.Walker.EnclosingTypeDefinition.NameString(v.Definition)
+	if v.Config.isUnauthorizedField(enclosingTypeName, string(fieldName)) {
+		// the field is resolved without fetching it, so its selections are not planned
+		v.currentField = &resolve.Field{
+			Name: fieldAliasOrName,
+			Value: &resolve.FieldError{
+				Message:  unauthorizedFieldMessage(enclosingTypeName, string(fieldName)),
+				Nullable: !v.Definition.TypeIsNonNull(v.Definition.FieldDefinitionType(fieldDefinition)),
+			},
+			OnTypeName:              v.resolveOnTypeName(),
+			OnTypeNames:             v.resolveOnTypeNames(),
+			Position:                v.resolveFieldPosition(ref),
+			SkipDirectiveDefined:    skip,
+			SkipVariableName:        skipVariableName,
+			IncludeDirectiveDefined: include,
+			IncludeVariableName:     includeVariableName,
+		}
+		v.appendCurrentField()
+		v.Walker.SkipNode()
+		return
+	}
+
 	var (
 		hasFetchConfig bool
 		i              int
@@ -575,6 +746,7 @@ func (v *Visitor) EnterField(ref int) {
 		HasBuffer:               hasBuffer,
 		BufferID:                bufferID,
 		OnTypeName:              v.resolveOnTypeName(),
+		OnTypeNames:             v.resolveOnTypeNames(),
 		Position:                v.resolveFieldPosition(ref),
 		SkipDirectiveDefined:    skip,
 		SkipVariableName:        skipVariableName,
@@ -582,7 +754,7 @@ func (v *Visitor) EnterField(ref int) {
 		IncludeVariableName:     includeVariableName,
 	}
 
-	*v.currentFields[len(v.currentFields)-1].fields = append(*v.currentFields[len(v.currentFields)-1].fields, v.currentField)
+	v.appendCurrentField()
 
 	typeName := v.Walker.EnclosingTypeDefinition.NameString(v.Definition)
 	fieldNameStr := v.Operation.FieldNameString(ref)
@@ -591,6 +763,48 @@ func (v *Visitor) EnterField(ref int) {
 		return
 	}
 	v.fieldConfigs[ref] = fieldConfig
+	if fieldConfig.SubscriptionFilter != nil && v.isRootField() {
+		v.configureSubscriptionFilter(ref, path, fieldConfig.SubscriptionFilter)
+	}
+}
+
+// configureSubscriptionFilter filters the events of a subscription by the arguments of its root field.
+// Conditions of omitted arguments are left out, so they don't filter events.
+func (v *Visitor) configureSubscriptionFilter(ref int, path []string, config *SubscriptionFilterConfiguration) {
+	subscription, ok := v.plan.(*SubscriptionResponsePlan)
+	if !ok {
+		return
+	}
+	filter := &resolve.SubscriptionFilter{}
+	for _, condition := range config.Conditions {
+		argument, exists := v.Operation.FieldArgument(ref, []byte(condition.ArgumentName))
+		if !exists {
+			continue
+		}
+		filterCondition := resolve.SubscriptionFilterCondition{
+			ValuePath: append(append([]string{}, path...), condition.FieldPath...),
+		}
+		value := v.Operation.ArgumentValue(argument)
+		if value.Kind == ast.ValueKindVariable {
+			filterCondition.VariablePath = []string{v.Operation.VariableValueNameString(value.Ref)}
+		} else {
+			staticValue, err := v.Operation.ValueToJSON(value)
+			if err != nil {
+				v.Walker.StopWithInternalErr(err)
+				return
+			}
+			filterCondition.Value = staticValue
+		}
+		filter.Conditions = append(filter.Conditions, filterCondition)
+	}
+	if len(filter.Conditions) != 0 {
+		subscription.Response.Filter = filter
+	}
+}
+
+// isRootField reports whether the current field is selected on the root operation type
+func (v *Visitor) isRootField() bool {
+	return len(v.Walker.Ancestors) == 2 && v.Walker.Ancestors[0].Kind == ast.NodeKindOperationDefinition
 }
 
 func (v *Visitor) resolveFieldPosition(ref int) resolve.Position {
@@ -603,6 +817,24 @@ func (v *Visitor) resolveFieldPosition(ref int) resolve.Position {
 	}
 }
 
+// appendCurrentField adds the current field to the fields of the enclosing object.
+// A leaf field with the response key of an earlier field, e.g. selected both outside and inside a fragment,
+// is marked so that the response contains the key only once at the position of its first occurrence.
+func (v *Visitor) appendCurrentField() {
+	fields := v.currentFields[len(v.currentFields)-1].fields
+	switch v.currentField.Value.(type) {
+	case *resolve.Object, *resolve.Array:
+	default:
+		for _, field := range *fields {
+			if bytes.Equal(field.Name, v.currentField.Name) {
+				v.currentField.DuplicateResponseKey = true
+				break
+			}
+		}
+	}
+	*fields = append(*fields, v.currentField)
+}
+
 func (v *Visitor) resolveSkipForField(ref int) (bool, string) {
 	skipInclude, ok := v.skipIncludeFields[ref]
 	if ok {
@@ -648,17 +880,57 @@ func (v *Visitor) resolveInclude(directiveRefs []int) (bool, string) {
 }
 
 func (v *Visitor) resolveOnTypeName() []byte {
-	if len(v.Walker.Ancestors) < 2 {
+	typeName, node, ok := v.inlineFragmentTypeCondition()
+	if !ok {
 		return nil
 	}
-	inlineFragment := v.Walker.Ancestors[len(v.Walker.Ancestors)-2]
-	if inlineFragment.Kind != ast.NodeKindInlineFragment {
+	if node.Kind.IsAbstractType() {
+		// objects are never of an abstract type, they're matched by its possible types, see resolveOnTypeNames
 		return nil
 	}
-	typeName := v.Operation.InlineFragmentTypeConditionName(inlineFragment.Ref)
 	return v.Config.Types.RenameTypeNameOnMatchBytes(typeName)
 }
 
+// resolveOnTypeNames returns the possible types of an abstract type condition of the enclosing inline fragment.
+// They're taken from the merged schema, so the implementations of an interface contributed by any subgraph are included.
+func (v *Visitor) resolveOnTypeNames() [][]byte {
+	_, node, ok := v.inlineFragmentTypeCondition()
+	if !ok {
+		return nil
+	}
+	var typeNames [][]byte
+	switch node.Kind {
+	case ast.NodeKindInterfaceTypeDefinition:
+		for _, implementingNode := range v.Definition.InterfaceTypeDefinitionImplementedByRootNodes(node.Ref) {
+			if implementingNode.Kind != ast.NodeKindObjectTypeDefinition {
+				continue
+			}
+			typeName := v.Definition.ObjectTypeDefinitionNameBytes(implementingNode.Ref)
+			typeNames = append(typeNames, v.Config.Types.RenameTypeNameOnMatchBytes(typeName))
+		}
+	case ast.NodeKindUnionTypeDefinition:
+		for _, memberRef := range v.Definition.UnionTypeDefinitions[node.Ref].UnionMemberTypes.Refs {
+			typeName := v.Definition.ResolveTypeNameBytes(memberRef)
+			typeNames = append(typeNames, v.Config.Types.RenameTypeNameOnMatchBytes(typeName))
+		}
+	}
+	return typeNames
+}
+
+// inlineFragmentTypeCondition returns the type condition of the inline fragment enclosing the current field
+func (v *Visitor) inlineFragmentTypeCondition() (typeName []byte, node ast.Node, ok bool) {
+	if len(v.Walker.Ancestors) < 2 {
+		return nil, ast.Node{}, false
+	}
+	inlineFragment := v.Walker.Ancestors[len(v.Walker.Ancestors)-2]
+	if inlineFragment.Kind != ast.NodeKindInlineFragment {
+		return nil, ast.Node{}, false
+	}
+	typeName = v.Operation.InlineFragmentTypeConditionName(inlineFragment.Ref)
+	node, _ = v.Definition.Index.FirstNodeByNameBytes(typeName)
+	return typeName, node, true
+}
+
 func (v *Visitor) LeaveField(ref int) {
 	if v.currentFields[len(v.currentFields)-1].popOnField == ref {
 		v.currentFields = v.currentFields[:len(v.currentFields)-1]
@@ -675,9 +947,8 @@ func (v *Visitor) LeaveField(ref int) {
 }
 
 func (v *Visitor) skipField(ref int) bool {
-	fullPath := v.Walker.Path.DotDelimitedString() + "." + v.Operation.FieldAliasOrNameString(ref)
-	for i := range v.skipFieldPaths {
-		if v.skipFieldPaths[i] == fullPath {
+	for i := range v.skipFieldRefs {
+		if v.skipFieldRefs[i] == ref {
 			return true
 		}
 	}
@@ -714,6 +985,15 @@ func (v *Visitor) resolveFieldValue(fieldRef, typeRef int, nullable bool, path [
 		switch typeDefinitionNode.Kind {
 		case ast.NodeKindScalarTypeDefinition:
 			fieldExport := v.resolveFieldExport(fieldRef)
+			if customScalar, ok := v.Config.CustomScalars.Scalar(typeName); ok {
+				return &resolve.Scalar{
+					Path:     path,
+					Nullable: nullable,
+					Export:   fieldExport,
+					TypeName: typeName,
+					Scalar:   customScalar,
+				}
+			}
 			switch typeName {
 			case "String":
 				return &resolve.String{
@@ -753,6 +1033,7 @@ func (v *Visitor) resolveFieldValue(fieldRef, typeRef int, nullable bool, path [
 				Path:                 path,
 				Nullable:             nullable,
 				UnescapeResponseJson: unescapeResponseJson,
+				EnumValues:           v.Config.EnumValues.responseRenames(typeName),
 			}
 		case ast.NodeKindObjectTypeDefinition, ast.NodeKindInterfaceTypeDefinition, ast.NodeKindUnionTypeDefinition:
 			object := &resolve.Object{
@@ -1227,6 +1508,8 @@ func (v *Visitor) configureFetch(internal objectFetchConfiguration, external Fet
 		ProcessResponseConfig:                 external.ProcessResponseConfig,
 		DisableDataLoader:                     external.DisableDataLoader,
 		SetTemplateOutputToNullOnVariableNull: external.SetTemplateOutputToNullOnVariableNull,
+		OnTypeNames:                           external.OnTypeNames,
+		DataSourceIndex:                       internal.dataSourceIndex,
 	}
 
 	// if a field depends on an exported variable, data loader needs to be disabled
@@ -1254,6 +1537,7 @@ const (
 	SynchronousResponseKind Kind = iota + 1
 	StreamingResponseKind
 	SubscriptionResponseKind
+	IncrementalResponseKind
 )
 
 type Plan interface {
@@ -1300,6 +1584,21 @@ func (_ *SubscriptionResponsePlan) PlanKind() Kind {
 	return SubscriptionResponseKind
 }
 
+// IncrementalResponsePlan is the plan of an operation using @defer and @stream,
+// it's created by splitting the SynchronousResponsePlan of the operation without the directives.
+type IncrementalResponsePlan struct {
+	Response      *resolve.GraphQLIncrementalResponse
+	FlushInterval int64
+}
+
+func (i *IncrementalResponsePlan) SetFlushInterval(interval int64) {
+	i.FlushInterval = interval
+}
+
+func (_ *IncrementalResponsePlan) PlanKind() Kind {
+	return IncrementalResponseKind
+}
+
 type DataSourcePlanningBehavior struct {
 	// MergeAliasedRootNodes will reuse a data source for multiple root fields with aliases if true.
 	// Example:
@@ -1375,6 +1674,8 @@ type FetchConfiguration struct {
 	// This is the case, e.g. when using batching and one sibling is null, resulting in a null value for one batch item
 	// Returning null in this case tells the batch implementation to skip this item
 	SetTemplateOutputToNullOnVariableNull bool
+	// OnTypeNames restricts the fetch to objects of these types, see resolve.SingleFetch
+	OnTypeNames [][]byte
 }
 
 type BatchConfig struct {
@@ -1405,12 +1706,66 @@ func (c *configurationVisitor) LeaveSelectionSet(_ int) {
 	c.parentTypeNodes = c.parentTypeNodes[:len(c.parentTypeNodes)-1]
 }
 
+// isProvidedField reports whether the current field is provided to the data source of the planner
+// by an enclosing field, see ProvidedFields
+func (c *configurationVisitor) isProvidedField(plannerConfig *plannerConfiguration, typeName, fieldName string) bool {
+	for i := range plannerConfig.dataSourceConfiguration.ProvidedFields {
+		provided := &plannerConfig.dataSourceConfiguration.ProvidedFields[i]
+		if provided.TypeName != typeName || !containsString(provided.FieldNames, fieldName) {
+			continue
+		}
+		if c.isBelowProvidingField(provided) {
+			return true
+		}
+	}
+	return false
+}
+
+// isBelowProvidingField reports whether the enclosing fields of the current field are the providing field
+// followed by the fields of the path
+func (c *configurationVisitor) isBelowProvidingField(provided *ProvidedFields) bool {
+	// the enclosing type of an ancestor field is the one of the selection set the field is selected in
+	selectionSets := 0
+	for i := range c.walker.Ancestors {
+		if c.walker.Ancestors[i].Kind == ast.NodeKindSelectionSet {
+			selectionSets++
+		}
+	}
+	pathIndex := len(provided.Path) - 1
+	for i := len(c.walker.Ancestors) - 1; i >= 0; i-- {
+		ancestor := c.walker.Ancestors[i]
+		if ancestor.Kind == ast.NodeKindSelectionSet {
+			selectionSets--
+			continue
+		}
+		if ancestor.Kind != ast.NodeKindField {
+			continue
+		}
+		ancestorFieldName := c.operation.FieldNameUnsafeString(ancestor.Ref)
+		if pathIndex >= 0 {
+			if provided.Path[pathIndex] != ancestorFieldName {
+				return false
+			}
+			pathIndex--
+			continue
+		}
+		if selectionSets < 1 || selectionSets > len(c.parentTypeNodes) {
+			return false
+		}
+		return ancestorFieldName == provided.ProvidingFieldName &&
+			c.parentTypeNodes[selectionSets-1].NameString(c.definition) == provided.ProvidingTypeName
+	}
+	return false
+}
+
 type plannerConfiguration struct {
 	parentPath              string
 	planner                 DataSourcePlanner
 	paths                   []pathConfiguration
 	dataSourceConfiguration DataSourceConfiguration
-	bufferID                int
+	// dataSourceIndex is the index of the dataSourceConfiguration in the DataSources of the Configuration
+	dataSourceIndex int
+	bufferID        int
 }
 
 // isNestedPlanner returns true in case the planner is not directly attached to the Operation root
@@ -1539,7 +1894,14 @@ func (c *configurationVisitor) EnterField(ref int) {
 	if root.Kind != ast.NodeKindOperationDefinition {
 		return
 	}
+	if c.config.isUnauthorizedField(typeName, fieldName) {
+		c.walker.SkipNode()
+		return
+	}
 	isSubscription := c.isSubscription(root.Ref, current)
+	if fieldName == "__typename" && c.addTypeNameFieldToParentPlanner(parent, current) {
+		return
+	}
 	for i, plannerConfig := range c.planners {
 		planningBehaviour := plannerConfig.planner.DataSourcePlanningBehavior()
 		if plannerConfig.hasParent(parent) && plannerConfig.hasRootNode(typeName, fieldName) && planningBehaviour.MergeAliasedRootNodes {
@@ -1548,7 +1910,7 @@ func (c *configurationVisitor) EnterField(ref int) {
 			c.fieldBuffers[ref] = plannerConfig.bufferID
 			return
 		}
-		if plannerConfig.hasPath(parent) && plannerConfig.hasChildNode(typeName, fieldName) {
+		if plannerConfig.hasPath(parent) && (plannerConfig.hasChildNode(typeName, fieldName) || c.isProvidedField(&plannerConfig, typeName, fieldName)) {
 			// has parent path + has child node = child
 			c.planners[i].paths = append(c.planners[i].paths, pathConfiguration{path: current, shouldWalkFields: true})
 			return
@@ -1558,56 +1920,141 @@ func (c *configurationVisitor) EnterField(ref int) {
 			return
 		}
 	}
-	for i, config := range c.config.DataSources {
-		if config.HasRootNode(typeName, fieldName) {
-			var (
-				bufferID int
-			)
-			if !isSubscription {
-				bufferID = c.nextBufferID()
-				c.fieldBuffers[ref] = bufferID
-			}
-			planner := c.config.DataSources[i].Factory.Planner(c.ctx)
-			isParentAbstract := c.isParentTypeNodeAbstractType()
-			paths := []pathConfiguration{
+	for _, i := range c.rootNodeDataSources(typeName, fieldName) {
+		config := c.config.DataSources[i]
+		var (
+			bufferID int
+		)
+		if !isSubscription {
+			bufferID = c.nextBufferID()
+			c.fieldBuffers[ref] = bufferID
+		}
+		planner := c.config.DataSources[i].Factory.Planner(c.ctx)
+		isParentAbstract := c.isParentTypeNodeAbstractType()
+		paths := []pathConfiguration{
+			{
+				path:             current,
+				shouldWalkFields: true,
+			},
+		}
+		if isParentAbstract {
+			// if the parent is abstract, we add the parent path as well
+			// this will ensure that we're walking into and out of the root inline fragments
+			// otherwise, we'd only walk into the fields inside the inline fragments in the root,
+			// so we'd miss the selection sets and inline fragments in the root
+			paths = append([]pathConfiguration{
 				{
-					path:             current,
-					shouldWalkFields: true,
+					path:             parent,
+					shouldWalkFields: false,
 				},
+			}, paths...)
+		}
+		c.planners = append(c.planners, plannerConfiguration{
+			bufferID:                bufferID,
+			parentPath:              parent,
+			planner:                 planner,
+			paths:                   paths,
+			dataSourceConfiguration: config,
+			dataSourceIndex:         i,
+		})
+		fieldDefinition, ok := c.walker.FieldDefinition(ref)
+		if !ok {
+			continue
+		}
+		c.fetches = append(c.fetches, objectFetchConfiguration{
+			bufferID:           bufferID,
+			planner:            planner,
+			dataSourceIndex:    i,
+			isSubscription:     isSubscription,
+			fieldRef:           ref,
+			fieldDefinitionRef: fieldDefinition,
+		})
+		return
+	}
+}
+
+// rootNodeDataSources returns the indexes of the data sources having the field as root node.
+// A field is the root node of several data sources if it's resolvable by all of them, e.g. a @shareable field of an
+// entity. The data source owning a sibling field on its own comes first then, it's part of the plan anyway,
+// so the field is resolved by the same fetch instead of another one.
+func (c *configurationVisitor) rootNodeDataSources(typeName, fieldName string) []int {
+	var indexes []int
+	for i := range c.config.DataSources {
+		if c.config.DataSources[i].HasRootNode(typeName, fieldName) {
+			indexes = append(indexes, i)
+		}
+	}
+	if len(indexes) < 2 {
+		return indexes
+	}
+	selectionSet := c.walker.Ancestors[len(c.walker.Ancestors)-1]
+	if selectionSet.Kind != ast.NodeKindSelectionSet {
+		return indexes
+	}
+	for _, siblingName := range c.selectionSetFieldNames(selectionSet.Ref, typeName) {
+		if siblingName == fieldName {
+			continue
+		}
+		owner, ok := c.singleRootNodeDataSource(typeName, siblingName)
+		if !ok {
+			continue
+		}
+		for j := range indexes {
+			if indexes[j] == owner {
+				return append([]int{owner}, append(indexes[:j:j], indexes[j+1:]...)...)
 			}
-			if isParentAbstract {
-				// if the parent is abstract, we add the parent path as well
-				// this will ensure that we're walking into and out of the root inline fragments
-				// otherwise, we'd only walk into the fields inside the inline fragments in the root,
-				// so we'd miss the selection sets and inline fragments in the root
-				paths = append([]pathConfiguration{
-					{
-						path:             parent,
-						shouldWalkFields: false,
-					},
-				}, paths...)
-			}
-			c.planners = append(c.planners, plannerConfiguration{
-				bufferID:                bufferID,
-				parentPath:              parent,
-				planner:                 planner,
-				paths:                   paths,
-				dataSourceConfiguration: config,
-			})
-			fieldDefinition, ok := c.walker.FieldDefinition(ref)
-			if !ok {
+		}
+	}
+	return indexes
+}
+
+// singleRootNodeDataSource returns the index of the data source having the field as root node
+// if no other data source has it
+func (c *configurationVisitor) singleRootNodeDataSource(typeName, fieldName string) (index int, ok bool) {
+	index = -1
+	for i := range c.config.DataSources {
+		if !c.config.DataSources[i].HasRootNode(typeName, fieldName) {
+			continue
+		}
+		if index != -1 {
+			return -1, false
+		}
+		index = i
+	}
+	return index, index != -1
+}
+
+// selectionSetFieldNames returns the names of the fields selected on the type in the selection set,
+// including the fields of inline fragments on the type
+func (c *configurationVisitor) selectionSetFieldNames(selectionSetRef int, typeName string) []string {
+	var fieldNames []string
+	for _, selectionRef := range c.operation.SelectionSets[selectionSetRef].SelectionRefs {
+		selection := c.operation.Selections[selectionRef]
+		switch selection.Kind {
+		case ast.SelectionKindField:
+			fieldNames = append(fieldNames, c.operation.FieldNameUnsafeString(selection.Ref))
+		case ast.SelectionKindInlineFragment:
+			if c.operation.InlineFragmentHasTypeCondition(selection.Ref) && c.operation.InlineFragmentTypeConditionNameString(selection.Ref) != typeName {
 				continue
 			}
-			c.fetches = append(c.fetches, objectFetchConfiguration{
-				bufferID:           bufferID,
-				planner:            planner,
-				isSubscription:     isSubscription,
-				fieldRef:           ref,
-				fieldDefinitionRef: fieldDefinition,
-			})
-			return
+			fieldNames = append(fieldNames, c.selectionSetFieldNames(c.operation.InlineFragments[selection.Ref].SelectionSet, typeName)...)
 		}
 	}
+	return fieldNames
+}
+
+// addTypeNameFieldToParentPlanner adds a __typename field to the planner fetching the enclosing object.
+// The __typename is part of the response of that fetch, e.g. of the owning subgraph of a union field,
+// so selecting only the __typename of an object never requires another fetch.
+func (c *configurationVisitor) addTypeNameFieldToParentPlanner(parent, current string) bool {
+	for i, plannerConfig := range c.planners {
+		if !plannerConfig.shouldWalkFieldsOnPath(parent) || !plannerConfig.planner.DataSourcePlanningBehavior().IncludeTypeNameFields {
+			continue
+		}
+		c.planners[i].paths = append(c.planners[i].paths, pathConfiguration{path: current, shouldWalkFields: true})
+		return true
+	}
+	return false
 }
 
 func (c *configurationVisitor) isParentTypeNodeAbstractType() bool {
@@ -1666,15 +2113,23 @@ type requiredFieldsVisitor struct {
 	walker                *astvisitor.Walker
 	config                *Configuration
 	operationName         string
-	skipFieldPaths        []string
+	skipFieldRefs         []int
+	// selectedRequiresFields are the alternative required fields selected for fields by ref,
+	// fields requiring RequiresFields aren't included
+	selectedRequiresFields map[int][]string
+	// enclosingTypeNames are the names of the enclosing types of the fields entered
+	enclosingTypeNames []string
 }
 
 func (r *requiredFieldsVisitor) EnterDocument(_, _ *ast.Document) {
-	r.skipFieldPaths = r.skipFieldPaths[:0]
+	r.skipFieldRefs = r.skipFieldRefs[:0]
+	r.selectedRequiresFields = map[int][]string{}
+	r.enclosingTypeNames = r.enclosingTypeNames[:0]
 }
 
 func (r *requiredFieldsVisitor) EnterField(ref int) {
 	typeName := r.walker.EnclosingTypeDefinition.NameString(r.definition)
+	r.enclosingTypeNames = append(r.enclosingTypeNames, typeName)
 	fieldName := r.operation.FieldNameUnsafeString(ref)
 	fieldConfig := r.config.Fields.ForTypeField(typeName, fieldName)
 	if fieldConfig == nil {
@@ -1687,9 +2142,73 @@ func (r *requiredFieldsVisitor) EnterField(ref int) {
 	if selectionSet.Kind != ast.NodeKindSelectionSet {
 		return
 	}
-	for i := range fieldConfig.RequiresFields {
-		r.handleRequiredField(selectionSet.Ref, fieldConfig.RequiresFields[i])
+	requiresFields := r.requiresFields(typeName, fieldConfig)
+	if len(fieldConfig.AlternativeRequiresFields) != 0 {
+		r.selectedRequiresFields[ref] = requiresFields
+	}
+	for i := range requiresFields {
+		r.handleRequiredField(selectionSet.Ref, requiresFields[i])
+	}
+}
+
+func (r *requiredFieldsVisitor) LeaveField(_ int) {
+	r.enclosingTypeNames = r.enclosingTypeNames[:len(r.enclosingTypeNames)-1]
+}
+
+// requiresFields returns the first of the RequiresFields and the AlternativeRequiresFields of the field config
+// of which all fields are available from a data source of the enclosing object, e.g. the key of an entity with multiple
+// keys the subgraph returning the entity knows. Without an available field set RequiresFields are required.
+func (r *requiredFieldsVisitor) requiresFields(typeName string, fieldConfig *FieldConfiguration) []string {
+	if len(fieldConfig.AlternativeRequiresFields) == 0 {
+		return fieldConfig.RequiresFields
+	}
+	parentTypeName, parentFieldName, ok := r.parentField()
+	if !ok {
+		return fieldConfig.RequiresFields
+	}
+	if r.isAvailable(parentTypeName, parentFieldName, typeName, fieldConfig.RequiresFields) {
+		return fieldConfig.RequiresFields
+	}
+	for _, requiresFields := range fieldConfig.AlternativeRequiresFields {
+		if r.isAvailable(parentTypeName, parentFieldName, typeName, requiresFields) {
+			return requiresFields
+		}
+	}
+	return fieldConfig.RequiresFields
+}
+
+// parentField returns the enclosing type name and the name of the field of the object enclosing the current field
+func (r *requiredFieldsVisitor) parentField() (typeName, fieldName string, ok bool) {
+	if len(r.enclosingTypeNames) < 2 {
+		return "", "", false
+	}
+	for i := len(r.walker.Ancestors) - 1; i >= 0; i-- {
+		if r.walker.Ancestors[i].Kind == ast.NodeKindField {
+			return r.enclosingTypeNames[len(r.enclosingTypeNames)-2], r.operation.FieldNameUnsafeString(r.walker.Ancestors[i].Ref), true
+		}
+	}
+	return "", "", false
+}
+
+// isAvailable reports whether a data source resolving the parent field resolves all the fields of the type as well
+func (r *requiredFieldsVisitor) isAvailable(parentTypeName, parentFieldName, typeName string, fieldNames []string) bool {
+	for i := range r.config.DataSources {
+		dataSource := &r.config.DataSources[i]
+		if !dataSource.HasRootNode(parentTypeName, parentFieldName) && !dataSource.HasChildNode(parentTypeName, parentFieldName) {
+			continue
+		}
+		available := true
+		for _, fieldName := range fieldNames {
+			if !dataSource.HasRootNode(typeName, fieldName) && !dataSource.HasChildNode(typeName, fieldName) {
+				available = false
+				break
+			}
+		}
+		if available {
+			return true
+		}
 	}
+	return false
 }
 
 func (r *requiredFieldsVisitor) handleRequiredField(selectionSet int, requiredFieldName string) {
@@ -1717,8 +2236,9 @@ func (r *requiredFieldsVisitor) addRequiredField(fieldName string, selectionSet
 		Ref:  addedField.Ref,
 	}
 	r.operation.AddSelection(selectionSet, selection)
-	addedFieldPath := r.walker.Path.DotDelimitedString() + "." + fieldName
-	r.skipFieldPaths = append(r.skipFieldPaths, addedFieldPath)
+	// fields are skipped by ref, as the path of a required field added to an inline fragment
+	// may equal the path of a field selected on the enclosing interface
+	r.skipFieldRefs = append(r.skipFieldRefs, addedField.Ref)
 }
 
 func (r *requiredFieldsVisitor) EnterOperationDefinition(ref int) {
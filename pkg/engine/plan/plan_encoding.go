@@ -0,0 +1,111 @@
+package plan
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/wundergraph/graphql-go-tools/pkg/engine/resolve"
+)
+
+// PlanEncodingVersion is the version of the format written by MarshalPlan,
+// plans encoded with another version are rejected by UnmarshalPlan
+const PlanEncodingVersion = 1
+
+var (
+	ErrPlanEncodingVersionMismatch = errors.New("encoded plan has an unsupported version")
+	ErrPlanSchemaMismatch          = errors.New("encoded plan was planned for another schema")
+)
+
+// FetchSourceFactory is implemented by the PlannerFactory of data sources whose fetches can be encoded.
+// It creates the DataSource and the DataSourceBatchFactory of the fetches of unmarshalled plans,
+// which are planned by the planners of the factory otherwise.
+type FetchSourceFactory interface {
+	FetchDataSource() resolve.DataSource
+	FetchBatchFactory() resolve.DataSourceBatchFactory
+}
+
+type encodedPlan struct {
+	Version       int             `json:"version"`
+	SchemaHash    string          `json:"schemaHash"`
+	FlushInterval int64           `json:"flushInterval,omitempty"`
+	Response      json.RawMessage `json:"response"`
+}
+
+// MarshalPlan encodes a SynchronousResponsePlan as versioned JSON, so it can be persisted and loaded with
+// UnmarshalPlan instead of planning the operation again. The schemaHash identifies the schema the plan
+// was planned for, plans of other kinds can't be encoded.
+func MarshalPlan(p Plan, schemaHash string) ([]byte, error) {
+	synchronous, ok := p.(*SynchronousResponsePlan)
+	if !ok {
+		return nil, fmt.Errorf("unable to encode plan of kind %d", p.PlanKind())
+	}
+	response, err := resolve.MarshalGraphQLResponse(synchronous.Response)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(encodedPlan{
+		Version:       PlanEncodingVersion,
+		SchemaHash:    schemaHash,
+		FlushInterval: synchronous.FlushInterval,
+		Response:      response,
+	})
+}
+
+// UnmarshalPlan decodes a plan encoded by MarshalPlan. It returns ErrPlanSchemaMismatch if the plan was planned
+// for another schema than the one of the schemaHash, as its fields and data sources might not exist anymore.
+// The fetches get the data sources of the config, whose factories must implement FetchSourceFactory.
+func UnmarshalPlan(data []byte, schemaHash string, config Configuration) (Plan, error) {
+	var encoded encodedPlan
+	if err := json.Unmarshal(data, &encoded); err != nil {
+		return nil, err
+	}
+	if encoded.Version != PlanEncodingVersion {
+		return nil, fmt.Errorf("%w: %d", ErrPlanEncodingVersionMismatch, encoded.Version)
+	}
+	if encoded.SchemaHash != schemaHash {
+		return nil, ErrPlanSchemaMismatch
+	}
+	response, err := resolve.UnmarshalGraphQLResponse(encoded.Response, configurationFetchSources(config.DataSources), config.CustomScalars)
+	if err != nil {
+		return nil, err
+	}
+	return &SynchronousResponsePlan{
+		Response:      response,
+		FlushInterval: encoded.FlushInterval,
+	}, nil
+}
+
+// configurationFetchSources provides the data sources of unmarshalled fetches by the index of their configuration
+type configurationFetchSources []DataSourceConfiguration
+
+func (c configurationFetchSources) factory(dataSourceIndex int) (FetchSourceFactory, error) {
+	if dataSourceIndex < 0 || dataSourceIndex >= len(c) {
+		return nil, fmt.Errorf("data source %d of encoded plan is not configured", dataSourceIndex)
+	}
+	factory, ok := c[dataSourceIndex].Factory.(FetchSourceFactory)
+	if !ok {
+		return nil, fmt.Errorf("data source %d of encoded plan doesn't support encoded plans", dataSourceIndex)
+	}
+	return factory, nil
+}
+
+func (c configurationFetchSources) DataSource(dataSourceIndex int) (resolve.DataSource, error) {
+	factory, err := c.factory(dataSourceIndex)
+	if err != nil {
+		return nil, err
+	}
+	return factory.FetchDataSource(), nil
+}
+
+func (c configurationFetchSources) BatchFactory(dataSourceIndex int) (resolve.DataSourceBatchFactory, error) {
+	factory, err := c.factory(dataSourceIndex)
+	if err != nil {
+		return nil, err
+	}
+	batchFactory := factory.FetchBatchFactory()
+	if batchFactory == nil {
+		return nil, fmt.Errorf("data source %d of encoded plan doesn't batch fetches", dataSourceIndex)
+	}
+	return batchFactory, nil
+}
@@ -436,6 +436,65 @@ func TestPlanner_Plan(t *testing.T) {
 			))
 		})
 	})
+
+	t.Run("subscription filter", func(t *testing.T) {
+		config := Configuration{
+			Fields: FieldConfigurations{
+				{
+					TypeName:  "Subscription",
+					FieldName: "newReviews",
+					SubscriptionFilter: &SubscriptionFilterConfiguration{
+						Conditions: []SubscriptionFilterConditionConfiguration{
+							{ArgumentName: "id", FieldPath: []string{"id"}},
+							{ArgumentName: "stars", FieldPath: []string{"stars"}},
+						},
+					},
+				},
+			},
+		}
+
+		filter := func(t *testing.T, operation string) *resolve.SubscriptionFilter {
+			var report operationreport.Report
+			plan := testLogic(testDefinition, operation, "", config, &report)
+			if report.HasErrors() {
+				t.Fatal(report.Error())
+			}
+			subscription, ok := plan.(*SubscriptionResponsePlan)
+			if !ok {
+				t.Fatalf("expected a subscription plan, got %T", plan)
+			}
+			return subscription.Response.Filter
+		}
+
+		t.Run("arguments with variables", func(t *testing.T) {
+			assert.Equal(t, &resolve.SubscriptionFilter{
+				Conditions: []resolve.SubscriptionFilterCondition{
+					{ValuePath: []string{"newReviews", "id"}, VariablePath: []string{"id"}},
+					{ValuePath: []string{"newReviews", "stars"}, VariablePath: []string{"stars"}},
+				},
+			}, filter(t, `subscription NewReviews($id: ID, $stars: Int) { newReviews(id: $id, stars: $stars) { id } }`))
+		})
+
+		t.Run("static argument is extracted into a variable", func(t *testing.T) {
+			assert.Equal(t, &resolve.SubscriptionFilter{
+				Conditions: []resolve.SubscriptionFilterCondition{
+					{ValuePath: []string{"newReviews", "id"}, VariablePath: []string{"a"}},
+				},
+			}, filter(t, `subscription { newReviews(id: "1") { id } }`))
+		})
+
+		t.Run("aliased field is filtered by the path of the upstream event", func(t *testing.T) {
+			assert.Equal(t, &resolve.SubscriptionFilter{
+				Conditions: []resolve.SubscriptionFilterCondition{
+					{ValuePath: []string{"newReviews", "stars"}, VariablePath: []string{"stars"}},
+				},
+			}, filter(t, `subscription NewReviews($stars: Int) { reviews: newReviews(stars: $stars) { id } }`))
+		})
+
+		t.Run("omitted arguments don't filter events", func(t *testing.T) {
+			assert.Nil(t, filter(t, `subscription { newReviews { id } }`))
+		})
+	})
 }
 
 var expectedMyHeroPlan = &SynchronousResponsePlan{
@@ -532,7 +591,7 @@ type Mutation {
 
 type Subscription {
     remainingJedis: Int!
-	newReviews: Review
+	newReviews(id: ID, stars: Int): Review
 }
 
 input ReviewInput {
@@ -36,7 +36,7 @@ func (f *RequiredFieldExtractor) addFieldsForObjectExtensionDefinitions(fieldReq
 		objectType := objectTypeExt.ObjectTypeDefinition
 		typeName := f.document.Input.ByteSliceString(objectType.Name)
 
-		primaryKeys, exists := f.primaryKeyFieldsIfObjectTypeIsEntity(objectType)
+		keyFieldSets, exists := f.primaryKeyFieldSetsIfObjectTypeIsEntity(objectType)
 		if !exists {
 			continue
 		}
@@ -47,18 +47,9 @@ func (f *RequiredFieldExtractor) addFieldsForObjectExtensionDefinitions(fieldReq
 			}
 
 			fieldName := f.document.FieldDefinitionNameString(fieldDefinitionRef)
-
-			requiredFields := make([]string, len(primaryKeys))
-			copy(requiredFields, primaryKeys)
-
 			requiredFieldsByRequiresDirective := requiredFieldsByRequiresDirective(f.document, fieldDefinitionRef)
-			requiredFields = append(requiredFields, requiredFieldsByRequiresDirective...)
 
-			*fieldRequires = append(*fieldRequires, FieldConfiguration{
-				TypeName:       typeName,
-				FieldName:      fieldName,
-				RequiresFields: requiredFields,
-			})
+			*fieldRequires = append(*fieldRequires, requiredFieldsConfiguration(typeName, fieldName, keyFieldSets, requiredFieldsByRequiresDirective))
 		}
 	}
 }
@@ -67,32 +58,54 @@ func (f *RequiredFieldExtractor) addFieldsForObjectDefinitions(fieldRequires *Fi
 	for _, objectType := range f.document.ObjectTypeDefinitions {
 		typeName := f.document.Input.ByteSliceString(objectType.Name)
 
-		primaryKeys, exists := f.primaryKeyFieldsIfObjectTypeIsEntity(objectType)
+		keyFieldSets, exists := f.primaryKeyFieldSetsIfObjectTypeIsEntity(objectType)
 		if !exists {
 			continue
 		}
 
-		primaryKeysSet := make(map[string]struct{}, len(primaryKeys))
-		for _, val := range primaryKeys {
-			primaryKeysSet[val] = struct{}{}
-		}
-
 		for _, fieldRef := range objectType.FieldsDefinition.Refs {
 			fieldName := f.document.FieldDefinitionNameString(fieldRef)
-			if _, exists := primaryKeysSet[fieldName]; exists { // Field is part of primary key, it couldn't have any required fields
+			// a field can't be fetched with a key it is part of
+			fieldKeyFieldSets := keyFieldSetsWithoutField(keyFieldSets, fieldName)
+			if len(fieldKeyFieldSets) == 0 { // Field is part of every primary key, it couldn't have any required fields
 				continue
 			}
 
-			requiredFields := make([]string, len(primaryKeys))
-			copy(requiredFields, primaryKeys)
+			*fieldRequires = append(*fieldRequires, requiredFieldsConfiguration(typeName, fieldName, fieldKeyFieldSets, nil))
+		}
+	}
+}
+
+// requiredFieldsConfiguration requires the fields of the first key with the additional fields,
+// the other keys are the alternatives, see FieldConfiguration.AlternativeRequiresFields
+func requiredFieldsConfiguration(typeName, fieldName string, keyFieldSets [][]string, additionalFields []string) FieldConfiguration {
+	requiresFields := make([][]string, 0, len(keyFieldSets))
+	for _, keyFields := range keyFieldSets {
+		requiredFields := make([]string, 0, len(keyFields)+len(additionalFields))
+		requiredFields = append(requiredFields, keyFields...)
+		requiredFields = append(requiredFields, additionalFields...)
+		requiresFields = append(requiresFields, requiredFields)
+	}
 
-			*fieldRequires = append(*fieldRequires, FieldConfiguration{
-				TypeName:       typeName,
-				FieldName:      fieldName,
-				RequiresFields: requiredFields,
-			})
+	fieldConfiguration := FieldConfiguration{
+		TypeName:       typeName,
+		FieldName:      fieldName,
+		RequiresFields: requiresFields[0],
+	}
+	if len(requiresFields) > 1 {
+		fieldConfiguration.AlternativeRequiresFields = requiresFields[1:]
+	}
+	return fieldConfiguration
+}
+
+func keyFieldSetsWithoutField(keyFieldSets [][]string, fieldName string) [][]string {
+	result := make([][]string, 0, len(keyFieldSets))
+	for _, keyFields := range keyFieldSets {
+		if !containsString(keyFields, fieldName) {
+			result = append(result, keyFields)
 		}
 	}
+	return result
 }
 
 func requiredFieldsByRequiresDirective(document *ast.Document, fieldDefinitionRef int) []string {
@@ -117,13 +130,60 @@ func requiredFieldsByRequiresDirective(document *ast.Document, fieldDefinitionRe
 	return nil
 }
 
-func (f *RequiredFieldExtractor) primaryKeyFieldsIfObjectTypeIsEntity(objectType ast.ObjectTypeDefinition) (keyFields []string, ok bool) {
-	for _, directiveRef := range objectType.Directives.Refs {
-		if directiveName := f.document.DirectiveNameString(directiveRef); directiveName != FederationKeyDirectiveName {
+// primaryKeyFieldSetsIfObjectTypeIsEntity returns the fields of the keys of the object type in the order of the @key directives,
+// objects without a @key directive implementing an interface with a @key directive are entities with the key of the interface
+func (f *RequiredFieldExtractor) primaryKeyFieldSetsIfObjectTypeIsEntity(objectType ast.ObjectTypeDefinition) (keyFieldSets [][]string, ok bool) {
+	if keyFieldSets = keyFieldSetsByKeyDirectives(f.document, objectType.Directives); len(keyFieldSets) != 0 {
+		return keyFieldSets, true
+	}
+	keyFields, ok := interfaceKeyFields(f.document, objectType.ImplementsInterfaces.Refs)
+	if !ok {
+		return nil, false
+	}
+	return [][]string{keyFields}, true
+}
+
+// interfaceKeyFields returns the key fields of the first of the implemented interfaces with a @key directive
+func interfaceKeyFields(document *ast.Document, interfaceRefs []int) (keyFields []string, ok bool) {
+	for _, typeRef := range interfaceRefs {
+		interfaceName := document.ResolveTypeNameString(typeRef)
+		for i := range document.InterfaceTypeDefinitions {
+			if document.InterfaceTypeDefinitionNameString(i) != interfaceName {
+				continue
+			}
+			if keyFields, ok = keyFieldsByKeyDirective(document, document.InterfaceTypeDefinitions[i].Directives); ok {
+				return keyFields, true
+			}
+		}
+		for i := range document.InterfaceTypeExtensions {
+			if document.InterfaceTypeExtensionNameString(i) != interfaceName {
+				continue
+			}
+			if keyFields, ok = keyFieldsByKeyDirective(document, document.InterfaceTypeExtensions[i].Directives); ok {
+				return keyFields, true
+			}
+		}
+	}
+
+	return nil, false
+}
+
+func keyFieldsByKeyDirective(document *ast.Document, directives ast.DirectiveList) (keyFields []string, ok bool) {
+	keyFieldSets := keyFieldSetsByKeyDirectives(document, directives)
+	if len(keyFieldSets) == 0 {
+		return nil, false
+	}
+	return keyFieldSets[0], true
+}
+
+// keyFieldSetsByKeyDirectives returns the fields of every @key directive, composite keys like "upc sku" have multiple fields
+func keyFieldSetsByKeyDirectives(document *ast.Document, directives ast.DirectiveList) (keyFieldSets [][]string) {
+	for _, directiveRef := range directives.Refs {
+		if directiveName := document.DirectiveNameString(directiveRef); directiveName != FederationKeyDirectiveName {
 			continue
 		}
 
-		value, exists := f.document.DirectiveArgumentValueByName(directiveRef, fieldsArgumentNameBytes)
+		value, exists := document.DirectiveArgumentValueByName(directiveRef, fieldsArgumentNameBytes)
 		if !exists {
 			continue
 		}
@@ -131,10 +191,12 @@ func (f *RequiredFieldExtractor) primaryKeyFieldsIfObjectTypeIsEntity(objectType
 			continue
 		}
 
-		fieldsStr := f.document.StringValueContentString(value.Ref)
-
-		return strings.Split(fieldsStr, " "), true
+		keyFields := strings.Fields(document.StringValueContentString(value.Ref))
+		if len(keyFields) == 0 {
+			continue
+		}
+		keyFieldSets = append(keyFieldSets, keyFields)
 	}
 
-	return nil, false
+	return keyFieldSets
 }
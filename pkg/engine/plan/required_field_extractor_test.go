@@ -61,6 +61,21 @@ func TestRequiredFieldExtractor_GetAllFieldRequires(t *testing.T) {
 			{TypeName: "Review", FieldName: "title", RequiresFields: []string{"id", "author"}},
 		})
 	})
+	t.Run("Entity with multiple primary keys", func(t *testing.T) {
+		run(t, `
+		type Product @key(fields: "id") @key(fields: "upc sku"){
+			id: ID!
+			upc: String!
+			sku: String!
+			name: String!
+		}
+		`, FieldConfigurations{
+			{TypeName: "Product", FieldName: "id", RequiresFields: []string{"upc", "sku"}},
+			{TypeName: "Product", FieldName: "upc", RequiresFields: []string{"id"}},
+			{TypeName: "Product", FieldName: "sku", RequiresFields: []string{"id"}},
+			{TypeName: "Product", FieldName: "name", RequiresFields: []string{"id"}, AlternativeRequiresFields: [][]string{{"upc", "sku"}}},
+		})
+	})
 	t.Run("Entity object extension without non-primary external fields", func(t *testing.T) {
 		run(t, `
 		extend type Review @key(fields: "id"){
@@ -83,4 +98,18 @@ func TestRequiredFieldExtractor_GetAllFieldRequires(t *testing.T) {
 			{TypeName: "Review", FieldName: "slug", RequiresFields: []string{"id", "title", "author"}},
 		})
 	})
+	t.Run("Entity implementing an interface with primary key", func(t *testing.T) {
+		run(t, `
+		extend interface History @key(fields: "id") {
+			id: ID! @external
+		}
+
+		extend type Sale implements History {
+			id: ID! @external
+			review: String!
+		}
+		`, FieldConfigurations{
+			{TypeName: "Sale", FieldName: "review", RequiresFields: []string{"id"}},
+		})
+	})
 }
@@ -0,0 +1,23 @@
+package plan
+
+import (
+	"fmt"
+)
+
+func (c *Configuration) isUnauthorizedField(typeName, fieldName string) bool {
+	for i := range c.UnauthorizedFields {
+		if c.UnauthorizedFields[i].TypeName != typeName {
+			continue
+		}
+		for _, unauthorizedFieldName := range c.UnauthorizedFields[i].FieldNames {
+			if unauthorizedFieldName == fieldName {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func unauthorizedFieldMessage(typeName, fieldName string) string {
+	return fmt.Sprintf("unauthorized to access field %s.%s", typeName, fieldName)
+}
@@ -192,6 +192,7 @@ func (d *dataLoader) Load(ctx *Context, fetch *SingleFetch, responsePair *BufPai
 	if err != nil {
 		return err
 	}
+	fetchParams = applicableFetchParams(fetch, fetchParams)
 
 	if fetchResult, err = d.resolveSingleFetch(ctx, fetch, fetchParams); err != nil {
 		return err
@@ -227,6 +228,7 @@ func (d *dataLoader) LoadBatch(ctx *Context, batchFetch *BatchFetch, responsePai
 	if err != nil {
 		return err
 	}
+	fetchParams = applicableFetchParams(batchFetch.Fetch, fetchParams)
 
 	if fetchResult, err = d.resolveBatchFetch(ctx, batchFetch, fetchParams); err != nil {
 		return err
@@ -417,6 +419,21 @@ func (d *dataLoader) selectedDataForFetch(input [][]byte, path []string, filterT
 	return d.selectedDataForFetch(temp, rest, filterTypeName)
 }
 
+// applicableFetchParams drops the objects the fetch doesn't apply to,
+// the resolver skips the fetch for these objects, so they must not be fetched nor take a result
+func applicableFetchParams(fetch *SingleFetch, fetchParams [][]byte) [][]byte {
+	if len(fetch.OnTypeNames) == 0 {
+		return fetchParams
+	}
+	applicable := make([][]byte, 0, len(fetchParams))
+	for i := range fetchParams {
+		if fetch.appliesTo(fetchParams[i]) {
+			applicable = append(applicable, fetchParams[i])
+		}
+	}
+	return applicable
+}
+
 func (d *dataLoader) getResultBufPair() (pair *BufPair) {
 	d.mu.Lock()
 	defer d.mu.Unlock()
@@ -0,0 +1,121 @@
+package resolve
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// FetchDeadlineScheduler distributes the remaining time until the deadline of a request across all pending fetches
+// and the fetches of the plan depending on their data.
+// Each fetch gets a sub deadline of the remaining time divided by the number of pending fetches plus the number of
+// fetches which have to be resolved after it, so a single slow fetch can't consume the whole time budget of the operation
+// and leaves time for the nested fetches of the plan.
+// A scheduler is request scoped and must not be shared across requests.
+type FetchDeadlineScheduler struct {
+	mu      sync.Mutex
+	pending int
+	now     func() time.Time
+	// dependents caches the number of dependent fetches per object of the plan
+	dependents map[*Object]int
+}
+
+func NewFetchDeadlineScheduler() *FetchDeadlineScheduler {
+	return &FetchDeadlineScheduler{
+		now:        time.Now,
+		dependents: map[*Object]int{},
+	}
+}
+
+// dependentFetches returns the number of fetches resolved after the fetch of the object, which is the number of fetches
+// of the longest chain of nested fetches below the object, each fetch of a parallel fetch counts as it gets its own share
+func (s *FetchDeadlineScheduler) dependentFetches(object *Object) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	dependents, ok := s.dependents[object]
+	if !ok {
+		dependents = nestedFetchChain(object.Fields)
+		s.dependents[object] = dependents
+	}
+	return dependents
+}
+
+func nestedFetchChain(fields []*Field) (longest int) {
+	for i := range fields {
+		if chain := fetchChain(fields[i].Value); chain > longest {
+			longest = chain
+		}
+	}
+	return longest
+}
+
+func fetchChain(node Node) int {
+	switch n := node.(type) {
+	case *Object:
+		return countFetches(n.Fetch) + nestedFetchChain(n.Fields)
+	case *Array:
+		return fetchChain(n.Item)
+	default:
+		return 0
+	}
+}
+
+func countFetches(fetch Fetch) int {
+	switch f := fetch.(type) {
+	case *SingleFetch, *BatchFetch:
+		return 1
+	case *ParallelFetch:
+		count := 0
+		for i := range f.Fetches {
+			count += countFetches(f.Fetches[i])
+		}
+		return count
+	default:
+		return 0
+	}
+}
+
+// add registers fetches which are about to be resolved.
+// Parallel fetches must be registered all at once before any of them starts.
+func (s *FetchDeadlineScheduler) add(fetches int) {
+	s.mu.Lock()
+	s.pending += fetches
+	s.mu.Unlock()
+}
+
+// schedule returns the context for a registered fetch, done must be called when the fetch is completed.
+// dependentFetches is the number of fetches of the plan resolved after the fetch, see dependentFetches.
+func (s *FetchDeadlineScheduler) schedule(ctx context.Context, dependentFetches int) (fetchCtx context.Context, done func()) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.pending < 1 {
+		s.pending = 1
+	}
+
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return ctx, s.done
+	}
+
+	now := s.now()
+	remaining := deadline.Sub(now)
+	if remaining <= 0 {
+		return ctx, s.done
+	}
+
+	fetchCtx, cancel := context.WithDeadline(ctx, now.Add(remaining/time.Duration(s.pending+dependentFetches)))
+	return fetchCtx, func() {
+		cancel()
+		s.done()
+	}
+}
+
+func (s *FetchDeadlineScheduler) done() {
+	s.mu.Lock()
+	if s.pending > 0 {
+		s.pending--
+	}
+	s.mu.Unlock()
+}
@@ -0,0 +1,282 @@
+package resolve
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type deadlineDataSource struct {
+	data    string
+	latency time.Duration
+
+	mu       sync.Mutex
+	timeout  time.Duration
+	loadErr  error
+	finished bool
+}
+
+func (d *deadlineDataSource) Load(ctx context.Context, input []byte, w io.Writer) (err error) {
+	d.mu.Lock()
+	if deadline, ok := ctx.Deadline(); ok {
+		d.timeout = time.Until(deadline)
+	}
+	d.mu.Unlock()
+
+	select {
+	case <-time.After(d.latency):
+		_, err = w.Write([]byte(d.data))
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	d.mu.Lock()
+	d.loadErr = err
+	d.finished = true
+	d.mu.Unlock()
+	return
+}
+
+func TestFetchDeadlineScheduler(t *testing.T) {
+	now := time.Now()
+	newScheduler := func() *FetchDeadlineScheduler {
+		scheduler := NewFetchDeadlineScheduler()
+		scheduler.now = func() time.Time {
+			return now
+		}
+		return scheduler
+	}
+
+	t.Run("distributes remaining time across pending fetches", func(t *testing.T) {
+		ctx, cancel := context.WithDeadline(context.Background(), now.Add(300*time.Millisecond))
+		defer cancel()
+
+		scheduler := newScheduler()
+		scheduler.add(3)
+
+		first, doneFirst := scheduler.schedule(ctx, 0)
+		deadline, ok := first.Deadline()
+		require.True(t, ok)
+		assert.Equal(t, now.Add(100*time.Millisecond), deadline)
+
+		second, doneSecond := scheduler.schedule(ctx, 0)
+		deadline, ok = second.Deadline()
+		require.True(t, ok)
+		assert.Equal(t, now.Add(100*time.Millisecond), deadline)
+
+		doneFirst()
+		doneSecond()
+
+		third, doneThird := scheduler.schedule(ctx, 0)
+		defer doneThird()
+		deadline, ok = third.Deadline()
+		require.True(t, ok)
+		assert.Equal(t, now.Add(300*time.Millisecond), deadline)
+	})
+
+	t.Run("leaves time for dependent fetches", func(t *testing.T) {
+		ctx, cancel := context.WithDeadline(context.Background(), now.Add(300*time.Millisecond))
+		defer cancel()
+
+		scheduler := newScheduler()
+		scheduler.add(1)
+
+		fetchCtx, done := scheduler.schedule(ctx, 2)
+		defer done()
+		deadline, ok := fetchCtx.Deadline()
+		require.True(t, ok)
+		assert.Equal(t, now.Add(100*time.Millisecond), deadline)
+	})
+
+	t.Run("counts the longest chain of nested fetches", func(t *testing.T) {
+		nested := &Object{
+			Fetch: &ParallelFetch{Fetches: []Fetch{&SingleFetch{}, &BatchFetch{Fetch: &SingleFetch{}}}},
+			Fields: []*Field{
+				{Value: &Object{Fetch: &SingleFetch{}}},
+			},
+		}
+		root := &Object{
+			Fetch: &SingleFetch{},
+			Fields: []*Field{
+				{Value: &Array{Item: nested}},
+				{Value: &Object{Fetch: &SingleFetch{}}},
+				{Value: &String{}},
+			},
+		}
+
+		scheduler := newScheduler()
+		assert.Equal(t, 3, scheduler.dependentFetches(root))
+		assert.Equal(t, 1, scheduler.dependentFetches(nested))
+	})
+
+	t.Run("keeps context without deadline", func(t *testing.T) {
+		ctx := context.Background()
+
+		scheduler := newScheduler()
+		scheduler.add(2)
+
+		fetchCtx, done := scheduler.schedule(ctx, 0)
+		defer done()
+		_, ok := fetchCtx.Deadline()
+		assert.False(t, ok)
+		assert.Equal(t, ctx, fetchCtx)
+	})
+
+	t.Run("done releases fetch", func(t *testing.T) {
+		scheduler := newScheduler()
+		scheduler.add(2)
+
+		_, done := scheduler.schedule(context.Background(), 0)
+		done()
+		assert.Equal(t, 1, scheduler.pending)
+	})
+}
+
+func TestResolver_FetchDeadlines(t *testing.T) {
+	singleFetch := func(bufferID int, dataSource DataSource) *SingleFetch {
+		return &SingleFetch{
+			BufferId:   bufferID,
+			DataSource: dataSource,
+			InputTemplate: InputTemplate{
+				Segments: []TemplateSegment{
+					{
+						SegmentType: StaticSegmentType,
+						Data:        []byte(`{}`),
+					},
+				},
+			},
+		}
+	}
+	stringField := func(bufferID int, name string) *Field {
+		return &Field{
+			HasBuffer: true,
+			BufferID:  bufferID,
+			Name:      []byte(name),
+			Value: &String{
+				Path:     []string{name},
+				Nullable: true,
+			},
+		}
+	}
+
+	fast := &deadlineDataSource{data: `{"fast":"fast"}`, latency: 10 * time.Millisecond}
+	alsoFast := &deadlineDataSource{data: `{"alsoFast":"also fast"}`, latency: 10 * time.Millisecond}
+	slow := &deadlineDataSource{data: `{"slow":"slow"}`, latency: 5 * time.Second}
+
+	response := &GraphQLResponse{
+		Data: &Object{
+			Fetch: &ParallelFetch{
+				Fetches: []Fetch{
+					singleFetch(0, fast),
+					singleFetch(1, slow),
+					singleFetch(2, alsoFast),
+				},
+			},
+			Fields: []*Field{
+				stringField(0, "fast"),
+				stringField(1, "slow"),
+				stringField(2, "alsoFast"),
+			},
+		},
+	}
+
+	rCtx, cancelResolver := context.WithCancel(context.Background())
+	defer cancelResolver()
+	resolver := newResolver(rCtx, false, false)
+
+	requestCtx, cancel := context.WithTimeout(context.Background(), 600*time.Millisecond)
+	defer cancel()
+
+	ctx := NewContext(requestCtx)
+	ctx.SetFetchDeadlineScheduler(NewFetchDeadlineScheduler())
+
+	start := time.Now()
+	buf := &bytes.Buffer{}
+	err := resolver.ResolveGraphQLResponse(ctx, response, nil, buf)
+	elapsed := time.Since(start)
+	require.NoError(t, err)
+
+	assert.Equal(t, `{"data":{"fast":"fast","slow":null,"alsoFast":"also fast"}}`, buf.String())
+	assert.Less(t, int64(elapsed), int64(600*time.Millisecond))
+
+	for _, dataSource := range []*deadlineDataSource{fast, slow, alsoFast} {
+		assert.True(t, dataSource.finished)
+		assert.LessOrEqual(t, int64(dataSource.timeout), int64(200*time.Millisecond))
+	}
+	assert.NoError(t, fast.loadErr)
+	assert.NoError(t, alsoFast.loadErr)
+	assert.ErrorIs(t, slow.loadErr, context.DeadlineExceeded)
+	assert.NoError(t, requestCtx.Err(), "overall deadline must not be exceeded")
+}
+
+func TestResolver_FetchDeadlinesOfDependentFetches(t *testing.T) {
+	root := &deadlineDataSource{data: `{"user":{"id":"1"}}`, latency: 10 * time.Millisecond}
+	nested := &deadlineDataSource{data: `{"name":"Jens"}`, latency: 10 * time.Millisecond}
+
+	response := &GraphQLResponse{
+		Data: &Object{
+			Fetch: &SingleFetch{
+				BufferId:   0,
+				DataSource: root,
+				InputTemplate: InputTemplate{
+					Segments: []TemplateSegment{{SegmentType: StaticSegmentType, Data: []byte(`{}`)}},
+				},
+			},
+			Fields: []*Field{
+				{
+					HasBuffer: true,
+					BufferID:  0,
+					Name:      []byte("user"),
+					Value: &Object{
+						Path:     []string{"user"},
+						Nullable: true,
+						Fetch: &SingleFetch{
+							BufferId:   1,
+							DataSource: nested,
+							InputTemplate: InputTemplate{
+								Segments: []TemplateSegment{{SegmentType: StaticSegmentType, Data: []byte(`{}`)}},
+							},
+						},
+						Fields: []*Field{
+							{
+								HasBuffer: true,
+								BufferID:  1,
+								Name:      []byte("name"),
+								Value:     &String{Path: []string{"name"}, Nullable: true},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	rCtx, cancelResolver := context.WithCancel(context.Background())
+	defer cancelResolver()
+	resolver := newResolver(rCtx, false, false)
+
+	requestCtx, cancel := context.WithTimeout(context.Background(), 600*time.Millisecond)
+	defer cancel()
+
+	ctx := NewContext(requestCtx)
+	ctx.SetFetchDeadlineScheduler(NewFetchDeadlineScheduler())
+
+	buf := &bytes.Buffer{}
+	err := resolver.ResolveGraphQLResponse(ctx, response, nil, buf)
+	require.NoError(t, err)
+
+	assert.Equal(t, `{"data":{"user":{"name":"Jens"}}}`, buf.String())
+	require.True(t, root.finished)
+	require.True(t, nested.finished)
+	// the root fetch leaves half of the time budget to the nested fetch, which gets all of the remaining time
+	assert.LessOrEqual(t, int64(root.timeout), int64(300*time.Millisecond))
+	assert.Greater(t, int64(nested.timeout), int64(300*time.Millisecond))
+	assert.NoError(t, root.loadErr)
+	assert.NoError(t, nested.loadErr)
+}
@@ -0,0 +1,106 @@
+package resolve
+
+import (
+	"encoding/json"
+	"sync"
+
+	"github.com/buger/jsonparser"
+)
+
+// SubgraphExtensions collects the "extensions" of the responses of subgraphs, e.g. deprecation notices or tracing,
+// to merge them into the extensions of the response namespaced by subgraph.
+// Subgraphs are identified like in SubgraphMetrics, the extensions of multiple fetches to a subgraph are merged key by key.
+// Deduplicated fetches are only collected once as they don't reach the subgraph.
+// SubgraphExtensions is request scoped and must not be shared across requests.
+type SubgraphExtensions struct {
+	mu        sync.Mutex
+	names     map[string]string
+	subgraphs map[string]map[string]json.RawMessage
+}
+
+// NewSubgraphExtensions creates a collector for the extensions of a single request.
+// subgraphNames optionally maps the url of a subgraph to its name.
+func NewSubgraphExtensions(subgraphNames map[string]string) *SubgraphExtensions {
+	return &SubgraphExtensions{
+		names:     subgraphNames,
+		subgraphs: map[string]map[string]json.RawMessage{},
+	}
+}
+
+func (e *SubgraphExtensions) record(fetch *SingleFetch, input, response []byte) {
+	if !fetch.ProcessResponseConfig.ExtractGraphqlResponse {
+		return
+	}
+	extensions, dataType, _, err := jsonparser.Get(response, "extensions")
+	if err != nil || dataType != jsonparser.Object {
+		return
+	}
+	var values map[string]json.RawMessage
+	if err = json.Unmarshal(extensions, &values); err != nil {
+		return
+	}
+	subgraph := subgraphName(fetch, input, e.names)
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	merged, ok := e.subgraphs[subgraph]
+	if !ok {
+		e.subgraphs[subgraph] = values
+		return
+	}
+	for key, value := range values {
+		merged[key] = value
+	}
+}
+
+// extensions adds the extensions of every subgraph to the extensions of the response
+func (e *SubgraphExtensions) extensions(extensions map[string]json.RawMessage) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	for subgraph, values := range e.subgraphs {
+		if len(values) == 0 {
+			continue
+		}
+		value, err := json.Marshal(values)
+		if err != nil {
+			return err
+		}
+		extensions[subgraph] = value
+	}
+	return nil
+}
+
+// HasResponseExtensions reports whether extensions are collected for the response, see ResponseExtensions
+func (c *Context) HasResponseExtensions() bool {
+	return c.subgraphMetrics != nil || c.subgraphExts != nil || c.responseValidation != nil
+}
+
+// ResponseExtensions returns the value of the "extensions" field of the response, it's nil if there are none.
+// The resolver doesn't write the extensions, they are added to the response with postprocess.Extensions.
+func (c *Context) ResponseExtensions() ([]byte, error) {
+	if !c.HasResponseExtensions() {
+		return nil, nil
+	}
+	extensions := map[string]json.RawMessage{}
+	if c.subgraphExts != nil {
+		if err := c.subgraphExts.extensions(extensions); err != nil {
+			return nil, err
+		}
+	}
+	if c.subgraphMetrics != nil {
+		if err := c.subgraphMetrics.extensions(extensions); err != nil {
+			return nil, err
+		}
+	}
+	if c.responseValidation != nil {
+		if err := c.responseValidation.extensions(extensions); err != nil {
+			return nil, err
+		}
+	}
+	if len(extensions) == 0 {
+		return nil, nil
+	}
+	return json.Marshal(extensions)
+}
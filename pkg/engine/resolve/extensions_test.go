@@ -0,0 +1,110 @@
+package resolve
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolver_SubgraphExtensions(t *testing.T) {
+	singleFetch := func(bufferID int, url, response string) *SingleFetch {
+		return &SingleFetch{
+			BufferId: bufferID,
+			DataSource: &_fakeDataSource{
+				data: []byte(response),
+			},
+			InputTemplate: InputTemplate{
+				Segments: []TemplateSegment{
+					{
+						SegmentType: StaticSegmentType,
+						Data:        []byte(`{"method":"POST","url":"` + url + `"}`),
+					},
+				},
+			},
+			ProcessResponseConfig: ProcessResponseConfig{
+				ExtractGraphqlResponse: true,
+			},
+		}
+	}
+
+	response := func() *GraphQLResponse {
+		return &GraphQLResponse{
+			Data: &Object{
+				Fetch: &ParallelFetch{
+					Fetches: []Fetch{
+						singleFetch(0, "http://accounts", `{"data":{"me":{"id":"1"}}}`),
+						singleFetch(1, "http://products", `{"data":{"topProducts":[{"upc":"top-1"}]},"extensions":{"deprecations":["Product.weight"],"tracing":{"duration":42}}}`),
+					},
+				},
+				Fields: []*Field{
+					{
+						HasBuffer: true,
+						BufferID:  0,
+						Name:      []byte("me"),
+						Value: &Object{
+							Path: []string{"me"},
+							Fields: []*Field{
+								{
+									Name:  []byte("id"),
+									Value: &String{Path: []string{"id"}},
+								},
+							},
+						},
+					},
+					{
+						HasBuffer: true,
+						BufferID:  1,
+						Name:      []byte("topProducts"),
+						Value: &Array{
+							Path: []string{"topProducts"},
+							Item: &Object{
+								Fields: []*Field{
+									{
+										Name:  []byte("upc"),
+										Value: &String{Path: []string{"upc"}},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		}
+	}
+
+	rCtx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	resolver := newResolver(rCtx, false, false)
+
+	t.Run("merges extensions of subgraphs namespaced by subgraph", func(t *testing.T) {
+		ctx := NewContext(context.Background())
+		ctx.SetSubgraphExtensions(NewSubgraphExtensions(map[string]string{
+			"http://accounts": "accounts",
+			"http://products": "products",
+		}))
+
+		buf := &bytes.Buffer{}
+		require.NoError(t, resolver.ResolveGraphQLResponse(ctx, response(), nil, buf))
+		// the extensions are added by the response pipeline of the engine
+		assert.Equal(t, `{"data":{"me":{"id":"1"},"topProducts":[{"upc":"top-1"}]}}`, buf.String())
+
+		extensions, err := ctx.ResponseExtensions()
+		require.NoError(t, err)
+		assert.Equal(t, `{"products":{"deprecations":["Product.weight"],"tracing":{"duration":42}}}`, string(extensions))
+	})
+
+	t.Run("omits extensions of subgraphs when disabled", func(t *testing.T) {
+		ctx := NewContext(context.Background())
+		buf := &bytes.Buffer{}
+		require.NoError(t, resolver.ResolveGraphQLResponse(ctx, response(), nil, buf))
+		assert.Equal(t, `{"data":{"me":{"id":"1"},"topProducts":[{"upc":"top-1"}]}}`, buf.String())
+
+		assert.False(t, ctx.HasResponseExtensions())
+		extensions, err := ctx.ResponseExtensions()
+		require.NoError(t, err)
+		assert.Nil(t, extensions)
+	})
+}
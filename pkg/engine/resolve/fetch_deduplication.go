@@ -0,0 +1,75 @@
+package resolve
+
+import (
+	"sync"
+
+	"github.com/wundergraph/graphql-go-tools/pkg/fastbuffer"
+)
+
+// fetchDeduplication shares the results of identical fetches within one operation,
+// e.g. of the entity fetches of two aliased fields selecting the same entity.
+// Unlike single flight, which only shares fetches running at the same time,
+// a result is kept until the operation is resolved.
+type fetchDeduplication struct {
+	mu      sync.Mutex
+	fetches map[uint64]*dedupedFetch
+}
+
+type dedupedFetch struct {
+	waitLoad sync.WaitGroup
+	err      error
+	bufPair  *BufPair
+}
+
+func newFetchDeduplication() *fetchDeduplication {
+	return &fetchDeduplication{
+		fetches: map[uint64]*dedupedFetch{},
+	}
+}
+
+// fetch loads the fetch once per operation and writes the result to buf.
+// Identical fetches wait for the first one and get a copy of its result.
+func (d *fetchDeduplication) fetch(ctx *Context, fetchID uint64, buf *BufPair, load func(buf *BufPair) error) error {
+	d.mu.Lock()
+	deduped, ok := d.fetches[fetchID]
+	if !ok {
+		deduped = &dedupedFetch{bufPair: NewBufPair()}
+		deduped.waitLoad.Add(1)
+		d.fetches[fetchID] = deduped
+	}
+	d.mu.Unlock()
+
+	if !ok {
+		deduped.err = load(deduped.bufPair)
+		deduped.waitLoad.Done()
+		copyBufPair(buf, deduped.bufPair)
+		return deduped.err
+	}
+
+	deduped.waitLoad.Wait()
+	if ctx.afterFetchHook != nil {
+		if deduped.bufPair.HasData() {
+			ctx.afterFetchHook.OnData(HookContext{CurrentPath: ctx.path()}, deduped.bufPair.Data.Bytes(), true)
+		}
+		if deduped.bufPair.HasErrors() {
+			ctx.afterFetchHook.OnError(HookContext{CurrentPath: ctx.path()}, deduped.bufPair.Errors.Bytes(), true)
+		}
+	}
+	copyBufPair(buf, deduped.bufPair)
+	return deduped.err
+}
+
+// fetchID identifies a fetch by its data source, the prepared input and the processing of the response
+func (f *Fetcher) fetchID(fetch *SingleFetch, preparedInput *fastbuffer.FastBuffer) uint64 {
+	hash64 := f.getHash64()
+	defer f.putHash64(hash64)
+	_, _ = hash64.Write(fetch.DataSourceIdentifier)
+	_, _ = hash64.Write(preparedInput.Bytes())
+	if fetch.ProcessResponseConfig.ExtractGraphqlResponse {
+		_, _ = hash64.Write([]byte{1})
+	}
+	if fetch.ProcessResponseConfig.ExtractFederationEntities {
+		_, _ = hash64.Write([]byte{2})
+	}
+	return hash64.Sum64()
+}
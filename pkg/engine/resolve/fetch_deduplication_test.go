@@ -0,0 +1,125 @@
+package resolve
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type countingDataSource struct {
+	data  []byte
+	loads int64
+}
+
+func (c *countingDataSource) Load(_ context.Context, _ []byte, w io.Writer) error {
+	atomic.AddInt64(&c.loads, 1)
+	_, err := w.Write(c.data)
+	return err
+}
+
+func TestResolver_FetchDeduplication(t *testing.T) {
+	// { me { id a: pet { name } b: pet { name } } }
+	response := func(petDataSource DataSource, disallowSingleFlight bool) *GraphQLResponse {
+		petFetch := func(bufferID int) *SingleFetch {
+			return &SingleFetch{
+				BufferId:             bufferID,
+				DataSource:           petDataSource,
+				DataSourceIdentifier: []byte("pets"),
+				DisallowSingleFlight: disallowSingleFlight,
+				InputTemplate: InputTemplate{
+					Segments: []TemplateSegment{
+						{
+							SegmentType: StaticSegmentType,
+							Data:        []byte(`{"owner":`),
+						},
+						{
+							SegmentType:        VariableSegmentType,
+							VariableKind:       ObjectVariableKind,
+							VariableSourcePath: []string{"id"},
+							Renderer:           NewGraphQLVariableRenderer(`{"type":"string"}`),
+						},
+						{
+							SegmentType: StaticSegmentType,
+							Data:        []byte(`}`),
+						},
+					},
+				},
+			}
+		}
+		pet := func(bufferID int, name string) *Field {
+			return &Field{
+				HasBuffer: true,
+				BufferID:  bufferID,
+				Name:      []byte(name),
+				Value: &Object{
+					Fields: []*Field{
+						{
+							Name:  []byte("name"),
+							Value: &String{Path: []string{"name"}},
+						},
+					},
+				},
+			}
+		}
+
+		a, b := pet(1, "a"), pet(2, "b")
+		return &GraphQLResponse{
+			Data: &Object{
+				Fetch: &SingleFetch{
+					BufferId:   0,
+					DataSource: FakeDataSource(`{"me":{"id":"1"}}`),
+				},
+				Fields: []*Field{
+					{
+						HasBuffer: true,
+						BufferID:  0,
+						Name:      []byte("me"),
+						Value: &Object{
+							Path:  []string{"me"},
+							Fetch: &ParallelFetch{Fetches: []Fetch{petFetch(1), petFetch(2)}},
+							Fields: []*Field{
+								{
+									Name:  []byte("id"),
+									Value: &String{Path: []string{"id"}},
+								},
+								a,
+								b,
+							},
+						},
+					},
+				},
+			},
+		}
+	}
+
+	run := func(t *testing.T, enableFetchDeduplication, disallowSingleFlight bool) int64 {
+		rCtx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		resolver := newResolver(rCtx, false, false)
+		resolver.fetcher.EnableFetchDeduplication = enableFetchDeduplication
+
+		pets := &countingDataSource{data: []byte(`{"name":"Woofie"}`)}
+		buf := &bytes.Buffer{}
+		err := resolver.ResolveGraphQLResponse(NewContext(context.Background()), response(pets, disallowSingleFlight), nil, buf)
+		require.NoError(t, err)
+		assert.Equal(t, `{"data":{"me":{"id":"1","a":{"name":"Woofie"},"b":{"name":"Woofie"}}}}`, buf.String())
+		return atomic.LoadInt64(&pets.loads)
+	}
+
+	t.Run("identical fetches are loaded once", func(t *testing.T) {
+		assert.Equal(t, int64(1), run(t, true, false))
+	})
+
+	t.Run("fetches are loaded separately when disabled", func(t *testing.T) {
+		assert.Equal(t, int64(2), run(t, false, false))
+	})
+
+	t.Run("fetches disallowing single flight are not deduplicated", func(t *testing.T) {
+		assert.Equal(t, int64(2), run(t, true, true))
+	})
+}
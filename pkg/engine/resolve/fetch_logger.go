@@ -0,0 +1,105 @@
+package resolve
+
+import (
+	"net/textproto"
+	"time"
+
+	"github.com/buger/jsonparser"
+	"github.com/jensneuse/abstractlogger"
+)
+
+const (
+	// DefaultFetchLoggerMaxBodyBytes is the default cap of the logged bodies of requests and responses
+	DefaultFetchLoggerMaxBodyBytes = 1024
+
+	redactedHeaderValue = "[REDACTED]"
+)
+
+// FetchLogger logs each request to a data source and its response at debug level, see Context.SetFetchLogger.
+// Bodies are truncated to the max body bytes and the values of redacted headers are replaced,
+// the Authorization header is always redacted.
+type FetchLogger struct {
+	logger          abstractlogger.Logger
+	maxBodyBytes    int
+	redactedHeaders map[string]struct{}
+}
+
+// NewFetchLogger creates a FetchLogger, a maxBodyBytes of 0 uses DefaultFetchLoggerMaxBodyBytes
+func NewFetchLogger(logger abstractlogger.Logger, maxBodyBytes int, redactedHeaders ...string) *FetchLogger {
+	if maxBodyBytes <= 0 {
+		maxBodyBytes = DefaultFetchLoggerMaxBodyBytes
+	}
+	redacted := map[string]struct{}{
+		"Authorization": {},
+	}
+	for _, header := range redactedHeaders {
+		redacted[textproto.CanonicalMIMEHeaderKey(header)] = struct{}{}
+	}
+	return &FetchLogger{
+		logger:          logger,
+		maxBodyBytes:    maxBodyBytes,
+		redactedHeaders: redacted,
+	}
+}
+
+func (l *FetchLogger) logRequest(fetch *SingleFetch, input []byte) {
+	l.logger.Debug("subgraph request",
+		abstractlogger.String("service", fetchService(fetch, input)),
+		abstractlogger.ByteString("header", l.redactHeader(input)),
+		abstractlogger.ByteString("body", l.truncate(fetchBody(input))),
+	)
+}
+
+func (l *FetchLogger) logResponse(fetch *SingleFetch, input, output []byte, duration time.Duration, err error) {
+	fields := []abstractlogger.Field{
+		abstractlogger.String("service", fetchService(fetch, input)),
+		abstractlogger.Int("durationMs", int(duration.Milliseconds())),
+		abstractlogger.Int("bodyBytes", len(output)),
+		abstractlogger.ByteString("body", l.truncate(output)),
+	}
+	if err != nil {
+		fields = append(fields, abstractlogger.Error(err))
+	}
+	l.logger.Debug("subgraph response", fields...)
+}
+
+func (l *FetchLogger) truncate(body []byte) []byte {
+	if len(body) <= l.maxBodyBytes {
+		return body
+	}
+	return body[:l.maxBodyBytes]
+}
+
+// redactHeader returns the "header" object of the input with the values of redacted headers replaced
+func (l *FetchLogger) redactHeader(input []byte) []byte {
+	header, dataType, _, err := jsonparser.Get(input, "header")
+	if err != nil || dataType != jsonparser.Object {
+		return nil
+	}
+	// the header is copied, as it is part of the input of the fetch
+	redacted := append([]byte(nil), header...)
+	_ = jsonparser.ObjectEach(header, func(key []byte, _ []byte, _ jsonparser.ValueType, _ int) error {
+		if _, ok := l.redactedHeaders[textproto.CanonicalMIMEHeaderKey(string(key))]; !ok {
+			return nil
+		}
+		redacted, err = jsonparser.Set(redacted, []byte(`["`+redactedHeaderValue+`"]`), string(key))
+		return err
+	})
+	return redacted
+}
+
+func fetchService(fetch *SingleFetch, input []byte) string {
+	service, err := jsonparser.GetString(input, "url")
+	if err != nil || service == "" {
+		return string(fetch.DataSourceIdentifier)
+	}
+	return service
+}
+
+func fetchBody(input []byte) []byte {
+	body, _, _, err := jsonparser.Get(input, "body")
+	if err != nil {
+		return nil
+	}
+	return body
+}
@@ -1,8 +1,10 @@
 package resolve
 
 import (
+	"bytes"
 	"hash"
 	"sync"
+	"time"
 
 	"github.com/cespare/xxhash/v2"
 
@@ -12,6 +14,9 @@ import (
 
 type Fetcher struct {
 	EnableSingleFlightLoader bool
+	// EnableFetchDeduplication loads identical fetches only once per operation, see fetchDeduplication.
+	// Like single flight it doesn't apply to fetches disallowing single flight, e.g. mutations.
+	EnableFetchDeduplication bool
 	hash64Pool               sync.Pool
 	inflightFetchPool        sync.Pool
 	bufPairPool              sync.Pool
@@ -48,6 +53,15 @@ func NewFetcher(enableSingleFlightLoader bool) *Fetcher {
 }
 
 func (f *Fetcher) Fetch(ctx *Context, fetch *SingleFetch, preparedInput *fastbuffer.FastBuffer, buf *BufPair) (err error) {
+	if ctx.fetchDeduplication == nil || fetch.DisallowSingleFlight {
+		return f.fetch(ctx, fetch, preparedInput, buf)
+	}
+	return ctx.fetchDeduplication.fetch(ctx, f.fetchID(fetch, preparedInput), buf, func(buf *BufPair) error {
+		return f.fetch(ctx, fetch, preparedInput, buf)
+	})
+}
+
+func (f *Fetcher) fetch(ctx *Context, fetch *SingleFetch, preparedInput *fastbuffer.FastBuffer, buf *BufPair) (err error) {
 	dataBuf := pool.BytesBuffer.Get()
 	defer pool.BytesBuffer.Put(dataBuf)
 
@@ -56,7 +70,7 @@ func (f *Fetcher) Fetch(ctx *Context, fetch *SingleFetch, preparedInput *fastbuf
 	}
 
 	if !f.EnableSingleFlightLoader || fetch.DisallowSingleFlight {
-		err = fetch.DataSource.Load(ctx.Context(), preparedInput.Bytes(), dataBuf)
+		err = f.load(ctx, fetch, preparedInput, dataBuf)
 		extractResponse(dataBuf.Bytes(), buf, fetch.ProcessResponseConfig)
 
 		if ctx.afterFetchHook != nil {
@@ -81,7 +95,11 @@ func (f *Fetcher) Fetch(ctx *Context, fetch *SingleFetch, preparedInput *fastbuf
 		inflight.waitFree.Add(1)
 		defer inflight.waitFree.Done()
 		f.inflightFetchMu.Unlock()
-		inflight.waitLoad.Wait()
+		select {
+		case <-inflight.loaded:
+		case <-ctx.Context().Done():
+			return ctx.Context().Err()
+		}
 		if inflight.bufPair.HasData() {
 			if ctx.afterFetchHook != nil {
 				ctx.afterFetchHook.OnData(f.hookCtx(ctx), inflight.bufPair.Data.Bytes(), true)
@@ -98,12 +116,12 @@ func (f *Fetcher) Fetch(ctx *Context, fetch *SingleFetch, preparedInput *fastbuf
 	}
 
 	inflight = f.getInflightFetch()
-	inflight.waitLoad.Add(1)
+	inflight.loaded = make(chan struct{})
 	f.inflightFetches[fetchID] = inflight
 
 	f.inflightFetchMu.Unlock()
 
-	err = fetch.DataSource.Load(ctx.Context(), preparedInput.Bytes(), dataBuf)
+	err = f.load(ctx, fetch, preparedInput, dataBuf)
 	extractResponse(dataBuf.Bytes(), &inflight.bufPair, fetch.ProcessResponseConfig)
 	inflight.err = err
 
@@ -121,7 +139,7 @@ func (f *Fetcher) Fetch(ctx *Context, fetch *SingleFetch, preparedInput *fastbuf
 		buf.Errors.WriteBytes(inflight.bufPair.Errors.Bytes())
 	}
 
-	inflight.waitLoad.Done()
+	close(inflight.loaded)
 
 	f.inflightFetchMu.Lock()
 	delete(f.inflightFetches, fetchID)
@@ -135,6 +153,31 @@ func (f *Fetcher) Fetch(ctx *Context, fetch *SingleFetch, preparedInput *fastbuf
 	return
 }
 
+// load loads the data of the fetch from the data source, records the subgraph metrics and extensions and logs the fetch if enabled
+func (f *Fetcher) load(ctx *Context, fetch *SingleFetch, preparedInput *fastbuffer.FastBuffer, dataBuf *bytes.Buffer) error {
+	if ctx.subgraphMetrics == nil && ctx.subgraphExts == nil && ctx.fetchLogger == nil {
+		return fetch.DataSource.Load(ctx.Context(), preparedInput.Bytes(), dataBuf)
+	}
+
+	if ctx.fetchLogger != nil {
+		ctx.fetchLogger.logRequest(fetch, preparedInput.Bytes())
+	}
+	responseStart := dataBuf.Len()
+	start := time.Now()
+	err := fetch.DataSource.Load(ctx.Context(), preparedInput.Bytes(), dataBuf)
+	latency := time.Since(start)
+	if ctx.subgraphMetrics != nil {
+		ctx.subgraphMetrics.record(fetch, preparedInput.Bytes(), dataBuf.Len(), latency)
+	}
+	if ctx.subgraphExts != nil {
+		ctx.subgraphExts.record(fetch, preparedInput.Bytes(), dataBuf.Bytes()[responseStart:])
+	}
+	if ctx.fetchLogger != nil {
+		ctx.fetchLogger.logResponse(fetch, preparedInput.Bytes(), dataBuf.Bytes()[responseStart:], latency, err)
+	}
+	return err
+}
+
 func (f *Fetcher) FetchBatch(ctx *Context, fetch *BatchFetch, preparedInputs []*fastbuffer.FastBuffer, bufs []*BufPair) (err error) {
 	inputs := make([][]byte, len(preparedInputs))
 	for i := range preparedInputs {
@@ -149,8 +192,11 @@ func (f *Fetcher) FetchBatch(ctx *Context, fetch *BatchFetch, preparedInputs []*
 	buf := f.getBufPair()
 	defer f.freeBufPair(buf)
 
-	if err = f.Fetch(ctx, fetch.Fetch, batch.Input(), buf); err != nil {
-		return err
+	// a batch of null inputs only, e.g. of null siblings, has nothing to fetch
+	if batch.Input().Len() != 0 {
+		if err = f.Fetch(ctx, fetch.Fetch, batch.Input(), buf); err != nil {
+			return err
+		}
 	}
 
 	if err = batch.Demultiplex(buf, bufs); err != nil {
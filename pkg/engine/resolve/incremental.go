@@ -0,0 +1,256 @@
+package resolve
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"sync"
+
+	"github.com/buger/jsonparser"
+
+	"github.com/wundergraph/graphql-go-tools/pkg/lexer/literal"
+)
+
+// GraphQLIncrementalResponse is a response using @defer and @stream, delivered in multiple payloads.
+// The fields of deferred fragments, together with the fetches only they depend on, are not part of the initial response
+// but of its patches. Object.Deferred and Array.Stream reference the patches by their index.
+type GraphQLIncrementalResponse struct {
+	InitialResponse *GraphQLResponse
+	Patches         []*IncrementalPatch
+}
+
+// IncrementalPatch is a deferred fragment or the items of a streamed list.
+// The Value of a deferred fragment is resolved with the data of the object it's deferred on,
+// the Value of a stream (Stream is true) with a single list item.
+type IncrementalPatch struct {
+	Label  string
+	Value  Node
+	Stream bool
+}
+
+// IncrementalPayloadWriter receives the payloads of an incremental response one at a time
+type IncrementalPayloadWriter interface {
+	WritePayload(payload []byte) error
+}
+
+// pendingPatch is a patch reached while resolving, together with the path and data it gets resolved with
+type pendingPatch struct {
+	index int
+	path  [][]byte
+	data  []byte
+}
+
+// incrementalPatches queues the patches reached while resolving an incremental response
+type incrementalPatches struct {
+	mu      sync.Mutex
+	pending []pendingPatch
+}
+
+func (i *incrementalPatches) add(index int, path [][]byte, data []byte) {
+	next := pendingPatch{
+		index: index,
+		path:  make([][]byte, len(path)),
+		data:  make([]byte, len(data)),
+	}
+	for j := range path {
+		next.path[j] = make([]byte, len(path[j]))
+		copy(next.path[j], path[j])
+	}
+	copy(next.data, data)
+
+	i.mu.Lock()
+	i.pending = append(i.pending, next)
+	i.mu.Unlock()
+}
+
+// next removes the next pending patch from the queue together with the following ones of the same patch,
+// e.g. a fragment deferred on all items of a list
+func (i *incrementalPatches) next() []pendingPatch {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	count := 0
+	for count < len(i.pending) && i.pending[count].index == i.pending[0].index {
+		count++
+	}
+	next := i.pending[:count:count]
+	i.pending = i.pending[count:]
+	return next
+}
+
+func (i *incrementalPatches) hasNext() bool {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	return len(i.pending) != 0
+}
+
+// deferFragments queues the fragments deferred on the object to be resolved with the data of the object
+func (r *Resolver) deferFragments(ctx *Context, object *Object, data []byte) {
+	if ctx.incremental == nil {
+		return
+	}
+	for _, index := range object.Deferred {
+		ctx.incremental.add(index, ctx.pathElements, data)
+	}
+}
+
+// ResolveGraphQLIncrementalResponse resolves the initial response and writes it with "hasNext" as the first payload.
+// Deferred fragments and streamed list items reached while resolving are queued and resolved one after another,
+// each one is written as a subsequent payload with an entry per object or list item it applies to.
+// The fetches of a deferred fragment only run once it gets resolved, so the fetches of independent deferred fragments
+// don't run concurrently but after the payload of the previous fragment is written.
+// Patches are resolved without the dataloader, fetches are still deduplicated if the fetcher is configured to.
+func (r *Resolver) ResolveGraphQLIncrementalResponse(ctx *Context, response *GraphQLIncrementalResponse, writer IncrementalPayloadWriter) (err error) {
+	ctx, cancel := ctx.withOperationTimeout(response.InitialResponse.Timeout)
+	defer cancel()
+
+	ctx.incremental = &incrementalPatches{}
+	defer func() {
+		ctx.incremental = nil
+	}()
+
+	if r.fetcher.EnableFetchDeduplication && ctx.fetchDeduplication == nil {
+		ctx.fetchDeduplication = newFetchDeduplication()
+		defer func() {
+			ctx.fetchDeduplication = nil
+		}()
+	}
+
+	initial := &bytes.Buffer{}
+	if err = r.ResolveGraphQLResponse(ctx, response.InitialResponse, nil, initial); err != nil {
+		return
+	}
+	payload, err := jsonparser.Set(initial.Bytes(), hasNextValue(ctx.incremental.hasNext()), "hasNext")
+	if err != nil {
+		return
+	}
+	if err = writer.WritePayload(payload); err != nil {
+		return
+	}
+
+	for {
+		next := ctx.incremental.next()
+		if len(next) == 0 {
+			return nil
+		}
+		var entries [][]byte
+		for i := range next {
+			entry, err := r.resolveIncrementalEntry(ctx, response.Patches[next[i].index], next[i])
+			if err != nil {
+				return err
+			}
+			if entry != nil {
+				entries = append(entries, entry)
+			}
+		}
+		hasNext := ctx.incremental.hasNext()
+		if len(entries) == 0 {
+			if hasNext {
+				continue
+			}
+			// the previous payload announced another one
+			return writer.WritePayload([]byte(`{"hasNext":false}`))
+		}
+
+		payload := make([]byte, 0, 256)
+		payload = append(payload, `{"incremental":[`...)
+		payload = append(payload, bytes.Join(entries, comma)...)
+		payload = append(payload, `],"hasNext":`...)
+		payload = append(payload, hasNextValue(hasNext)...)
+		payload = append(payload, rBrace...)
+		if err = writer.WritePayload(payload); err != nil {
+			return err
+		}
+	}
+}
+
+// resolveIncrementalEntry resolves a pending patch into an entry of the "incremental" list of a payload.
+// A deferred fragment resolving to null without errors, e.g. because it doesn't apply to the type of the object,
+// returns no entry, as well as a streamed item skipped because of its type.
+func (r *Resolver) resolveIncrementalEntry(ctx *Context, patch *IncrementalPatch, pending pendingPatch) ([]byte, error) {
+	buf := r.getBufPair()
+	defer r.freeBufPair(buf)
+
+	ctx.pathElements = append(ctx.pathElements[:0], pending.path...)
+	defer func() {
+		ctx.pathElements = ctx.pathElements[:0]
+	}()
+
+	err := r.resolveNode(ctx, patch.Value, pending.data, buf)
+	switch {
+	case err == nil:
+	case errors.Is(err, errTypeNameSkipped):
+		return nil, nil
+	case errors.Is(err, errNonNullableFieldValueIsNull):
+		buf.Data.Reset()
+	default:
+		return nil, err
+	}
+
+	hasErrors := buf.Errors.Len() != 0
+	if !patch.Stream && !hasErrors && (buf.Data.Len() == 0 || bytes.Equal(buf.Data.Bytes(), null)) {
+		return nil, nil
+	}
+
+	entry := &bytes.Buffer{}
+	entry.Write(lBrace)
+	if buf.Data.Len() != 0 {
+		if patch.Stream {
+			entry.WriteString(`"items":[`)
+			entry.Write(buf.Data.Bytes())
+			entry.Write(rBrack)
+		} else {
+			entry.WriteString(`"data":`)
+			entry.Write(buf.Data.Bytes())
+		}
+		entry.Write(comma)
+	}
+	if hasErrors {
+		entry.WriteString(`"errors":[`)
+		entry.Write(buf.Errors.Bytes())
+		entry.Write(rBrack)
+		entry.Write(comma)
+	}
+	entry.WriteString(`"path":`)
+	entry.Write(incrementalPath(pending.path))
+	if patch.Label != "" {
+		label, err := json.Marshal(patch.Label)
+		if err != nil {
+			return nil, err
+		}
+		entry.WriteString(`,"label":`)
+		entry.Write(label)
+	}
+	entry.Write(rBrace)
+	return entry.Bytes(), nil
+}
+
+// incrementalPath converts path elements into the json path of a payload, list indexes are written as numbers
+func incrementalPath(elements [][]byte) []byte {
+	path := make([]byte, 0, 64)
+	path = append(path, lBrack...)
+	first := true
+	for i := range elements {
+		if i == 0 && bytes.Equal(literal.DATA, elements[i]) {
+			continue
+		}
+		if !first {
+			path = append(path, comma...)
+		}
+		first = false
+		if len(elements[i]) != 0 && elements[i][0] >= '0' && elements[i][0] <= '9' {
+			path = append(path, elements[i]...)
+			continue
+		}
+		path = append(path, quote...)
+		path = append(path, elements[i]...)
+		path = append(path, quote...)
+	}
+	return append(path, rBrack...)
+}
+
+func hasNextValue(hasNext bool) []byte {
+	if hasNext {
+		return literal.TRUE
+	}
+	return literal.FALSE
+}
@@ -0,0 +1,271 @@
+package resolve
+
+import (
+	"context"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testPayloadWriter struct {
+	payloads []string
+}
+
+func (t *testPayloadWriter) WritePayload(payload []byte) error {
+	t.payloads = append(t.payloads, string(payload))
+	return nil
+}
+
+// payloadCountDataSource records the number of payloads written when it gets loaded
+type payloadCountDataSource struct {
+	data     string
+	writer   *testPayloadWriter
+	loadedAt int
+}
+
+func (p *payloadCountDataSource) Load(ctx context.Context, input []byte, w io.Writer) (err error) {
+	p.loadedAt = len(p.writer.payloads)
+	_, err = w.Write([]byte(p.data))
+	return
+}
+
+func TestResolver_ResolveGraphQLIncrementalResponse(t *testing.T) {
+	fetch := func(bufferID int, dataSource DataSource) *SingleFetch {
+		return &SingleFetch{
+			BufferId:   bufferID,
+			DataSource: dataSource,
+			InputTemplate: InputTemplate{
+				Segments: []TemplateSegment{
+					{
+						SegmentType: StaticSegmentType,
+						Data:        []byte(`{}`),
+					},
+				},
+			},
+		}
+	}
+	name := &Field{
+		Name:  []byte("name"),
+		Value: &String{Path: []string{"name"}},
+	}
+	resolve := func(t *testing.T, response *GraphQLIncrementalResponse, writer *testPayloadWriter) {
+		rCtx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		resolver := newResolver(rCtx, false, false)
+
+		require.NoError(t, resolver.ResolveGraphQLIncrementalResponse(NewContext(context.Background()), response, writer))
+	}
+
+	t.Run("deferred fragment and streamed items", func(t *testing.T) {
+		writer := &testPayloadWriter{}
+		deferredDataSource := &payloadCountDataSource{data: `{"primaryFunction":"Astromech"}`, writer: writer}
+
+		response := &GraphQLIncrementalResponse{
+			InitialResponse: &GraphQLResponse{
+				Data: &Object{
+					Fetch: fetch(0, FakeDataSource(`{"hero":{"name":"R2-D2","friends":[{"name":"Luke Skywalker"},{"name":"Han Solo"},{"name":"Leia Organa"}]}}`)),
+					Fields: []*Field{
+						{
+							HasBuffer: true,
+							BufferID:  0,
+							Name:      []byte("hero"),
+							Value: &Object{
+								Path: []string{"hero"},
+								Fields: []*Field{
+									name,
+									{
+										Name: []byte("friends"),
+										Value: &Array{
+											Path: []string{"friends"},
+											Item: &Object{Fields: []*Field{name}},
+											Stream: Stream{
+												Enabled:          true,
+												InitialBatchSize: 1,
+												PatchIndex:       1,
+											},
+										},
+									},
+								},
+								Deferred: []int{0},
+							},
+						},
+					},
+				},
+			},
+			Patches: []*IncrementalPatch{
+				{
+					Label: "droid",
+					Value: &Object{
+						Nullable: true,
+						Fetch:    fetch(1, deferredDataSource),
+						Fields: []*Field{
+							{
+								HasBuffer: true,
+								BufferID:  1,
+								Name:      []byte("primaryFunction"),
+								Value:     &String{Path: []string{"primaryFunction"}},
+							},
+						},
+					},
+				},
+				{
+					Value:  &Object{Fields: []*Field{name}},
+					Stream: true,
+				},
+			},
+		}
+
+		resolve(t, response, writer)
+		assert.Equal(t, []string{
+			`{"data":{"hero":{"name":"R2-D2","friends":[{"name":"Luke Skywalker"}]}},"hasNext":true}`,
+			`{"incremental":[{"items":[{"name":"Han Solo"}],"path":["hero","friends",1]},{"items":[{"name":"Leia Organa"}],"path":["hero","friends",2]}],"hasNext":true}`,
+			`{"incremental":[{"data":{"primaryFunction":"Astromech"},"path":["hero"],"label":"droid"}],"hasNext":false}`,
+		}, writer.payloads)
+		// the fetch of the deferred fragment runs after the streamed items got written
+		assert.Equal(t, 2, deferredDataSource.loadedAt)
+	})
+
+	t.Run("independent deferred fragments are resolved one after another", func(t *testing.T) {
+		writer := &testPayloadWriter{}
+		heroDataSource := &payloadCountDataSource{data: `{"primaryFunction":"Astromech"}`, writer: writer}
+		droidDataSource := &payloadCountDataSource{data: `{"primaryFunction":"Protocol"}`, writer: writer}
+		deferred := func(bufferID int, dataSource DataSource) *IncrementalPatch {
+			return &IncrementalPatch{
+				Value: &Object{
+					Nullable: true,
+					Fetch:    fetch(bufferID, dataSource),
+					Fields: []*Field{
+						{
+							HasBuffer: true,
+							BufferID:  bufferID,
+							Name:      []byte("primaryFunction"),
+							Value:     &String{Path: []string{"primaryFunction"}},
+						},
+					},
+				},
+			}
+		}
+
+		response := &GraphQLIncrementalResponse{
+			InitialResponse: &GraphQLResponse{
+				Data: &Object{
+					Fetch: fetch(0, FakeDataSource(`{"hero":{"name":"R2-D2"},"droid":{"name":"C-3PO"}}`)),
+					Fields: []*Field{
+						{
+							HasBuffer: true,
+							BufferID:  0,
+							Name:      []byte("hero"),
+							Value: &Object{
+								Path:     []string{"hero"},
+								Fields:   []*Field{name},
+								Deferred: []int{0},
+							},
+						},
+						{
+							HasBuffer: true,
+							BufferID:  0,
+							Name:      []byte("droid"),
+							Value: &Object{
+								Path:     []string{"droid"},
+								Fields:   []*Field{name},
+								Deferred: []int{1},
+							},
+						},
+					},
+				},
+			},
+			Patches: []*IncrementalPatch{
+				deferred(1, heroDataSource),
+				deferred(2, droidDataSource),
+			},
+		}
+
+		resolve(t, response, writer)
+		assert.Equal(t, []string{
+			`{"data":{"hero":{"name":"R2-D2"},"droid":{"name":"C-3PO"}},"hasNext":true}`,
+			`{"incremental":[{"data":{"primaryFunction":"Astromech"},"path":["hero"]}],"hasNext":true}`,
+			`{"incremental":[{"data":{"primaryFunction":"Protocol"},"path":["droid"]}],"hasNext":false}`,
+		}, writer.payloads)
+		// the fetch of the second fragment only runs once the payload of the first one got written
+		assert.Equal(t, 1, heroDataSource.loadedAt)
+		assert.Equal(t, 2, droidDataSource.loadedAt)
+	})
+
+	t.Run("object with deferred fields only", func(t *testing.T) {
+		response := &GraphQLIncrementalResponse{
+			InitialResponse: &GraphQLResponse{
+				Data: &Object{
+					Fetch: fetch(0, FakeDataSource(`{"droid":{"name":"R2-D2"}}`)),
+					Fields: []*Field{
+						{
+							HasBuffer: true,
+							BufferID:  0,
+							Name:      []byte("droid"),
+							Value: &Object{
+								Path:     []string{"droid"},
+								Deferred: []int{0},
+							},
+						},
+					},
+				},
+			},
+			Patches: []*IncrementalPatch{
+				{
+					Value: &Object{Nullable: true, Fields: []*Field{name}},
+				},
+			},
+		}
+
+		writer := &testPayloadWriter{}
+		resolve(t, response, writer)
+		assert.Equal(t, []string{
+			`{"data":{"droid":{}},"hasNext":true}`,
+			`{"incremental":[{"data":{"name":"R2-D2"},"path":["droid"]}],"hasNext":false}`,
+		}, writer.payloads)
+	})
+
+	t.Run("deferred fragment not applying to the type", func(t *testing.T) {
+		response := &GraphQLIncrementalResponse{
+			InitialResponse: &GraphQLResponse{
+				Data: &Object{
+					Fetch: fetch(0, FakeDataSource(`{"hero":{"__typename":"Human","name":"Luke Skywalker"}}`)),
+					Fields: []*Field{
+						{
+							HasBuffer: true,
+							BufferID:  0,
+							Name:      []byte("hero"),
+							Value: &Object{
+								Path:     []string{"hero"},
+								Fields:   []*Field{name},
+								Deferred: []int{0},
+							},
+						},
+					},
+				},
+			},
+			Patches: []*IncrementalPatch{
+				{
+					Value: &Object{
+						Nullable: true,
+						Fields: []*Field{
+							{
+								Name:       []byte("primaryFunction"),
+								Value:      &String{Path: []string{"primaryFunction"}},
+								OnTypeName: []byte("Droid"),
+							},
+						},
+					},
+				},
+			},
+		}
+
+		writer := &testPayloadWriter{}
+		resolve(t, response, writer)
+		assert.Equal(t, []string{
+			`{"data":{"hero":{"name":"Luke Skywalker"}},"hasNext":true}`,
+			`{"hasNext":false}`,
+		}, writer.payloads)
+	})
+}
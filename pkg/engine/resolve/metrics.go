@@ -0,0 +1,92 @@
+package resolve
+
+import (
+	"encoding/json"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/buger/jsonparser"
+)
+
+// SubgraphMetrics collects the number of fetches, the response bytes and the total latency per subgraph of a request.
+// Subgraphs are identified by the url of the fetch input, e.g. the url of a GraphQL or REST data source.
+// Deduplicated fetches are only accounted once as they don't reach the subgraph.
+// SubgraphMetrics is request scoped and must not be shared across requests.
+type SubgraphMetrics struct {
+	mu        sync.Mutex
+	names     map[string]string
+	subgraphs map[string]*SubgraphMetric
+}
+
+// SubgraphMetric contains the metrics of all fetches of a request to a single subgraph
+type SubgraphMetric struct {
+	Subgraph string `json:"subgraph"`
+	Fetches  int    `json:"fetches"`
+	Bytes    int    `json:"bytes"`
+	// LatencyNanoseconds is the sum of the latencies of all fetches,
+	// it might exceed the duration of the request when fetches are executed in parallel
+	LatencyNanoseconds int64 `json:"latencyNanoseconds"`
+}
+
+// NewSubgraphMetrics creates a collector for the metrics of a single request.
+// subgraphNames optionally maps the url of a subgraph to its name.
+func NewSubgraphMetrics(subgraphNames map[string]string) *SubgraphMetrics {
+	return &SubgraphMetrics{
+		names:     subgraphNames,
+		subgraphs: map[string]*SubgraphMetric{},
+	}
+}
+
+func (m *SubgraphMetrics) record(fetch *SingleFetch, input []byte, responseBytes int, latency time.Duration) {
+	subgraph := subgraphName(fetch, input, m.names)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	metric, ok := m.subgraphs[subgraph]
+	if !ok {
+		metric = &SubgraphMetric{Subgraph: subgraph}
+		m.subgraphs[subgraph] = metric
+	}
+	metric.Fetches++
+	metric.Bytes += responseBytes
+	metric.LatencyNanoseconds += int64(latency)
+}
+
+// subgraphName returns the name of the subgraph of a fetch, which is the url of its input if it's not mapped to a name
+func subgraphName(fetch *SingleFetch, input []byte, names map[string]string) string {
+	subgraph, err := jsonparser.GetString(input, "url")
+	if err != nil || subgraph == "" {
+		subgraph = string(fetch.DataSourceIdentifier)
+	}
+	if name, ok := names[subgraph]; ok {
+		subgraph = name
+	}
+	return subgraph
+}
+
+// Subgraphs returns the metrics of all subgraphs sorted by subgraph
+func (m *SubgraphMetrics) Subgraphs() []SubgraphMetric {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	subgraphs := make([]SubgraphMetric, 0, len(m.subgraphs))
+	for _, metric := range m.subgraphs {
+		subgraphs = append(subgraphs, *metric)
+	}
+	sort.Slice(subgraphs, func(i, j int) bool {
+		return subgraphs[i].Subgraph < subgraphs[j].Subgraph
+	})
+	return subgraphs
+}
+
+// extensions adds the metrics to the "subgraphs" field of the extensions of the response
+func (m *SubgraphMetrics) extensions(extensions map[string]json.RawMessage) error {
+	subgraphs, err := json.Marshal(m.Subgraphs())
+	if err != nil {
+		return err
+	}
+	extensions["subgraphs"] = subgraphs
+	return nil
+}
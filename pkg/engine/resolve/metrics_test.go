@@ -0,0 +1,149 @@
+package resolve
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/buger/jsonparser"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolver_SubgraphMetrics(t *testing.T) {
+	singleFetch := func(bufferID int, url, data string) *SingleFetch {
+		return &SingleFetch{
+			BufferId: bufferID,
+			DataSource: &_fakeDataSource{
+				data:              []byte(data),
+				artificialLatency: time.Millisecond,
+			},
+			InputTemplate: InputTemplate{
+				Segments: []TemplateSegment{
+					{
+						SegmentType: StaticSegmentType,
+						Data:        []byte(`{"method":"POST","url":"` + url + `"}`),
+					},
+				},
+			},
+		}
+	}
+
+	response := func() *GraphQLResponse {
+		return &GraphQLResponse{
+			Data: &Object{
+				Fetch: &ParallelFetch{
+					Fetches: []Fetch{
+						singleFetch(0, "http://accounts", `{"me":{"id":"1"}}`),
+						singleFetch(1, "http://products", `{"topProducts":[{"upc":"top-1"}]}`),
+					},
+				},
+				Fields: []*Field{
+					{
+						HasBuffer: true,
+						BufferID:  0,
+						Name:      []byte("me"),
+						Value: &Object{
+							Path:  []string{"me"},
+							Fetch: singleFetch(2, "http://reviews", `{"reviews":[{"body":"great"}]}`),
+							Fields: []*Field{
+								{
+									HasBuffer: true,
+									BufferID:  2,
+									Name:      []byte("reviews"),
+									Value: &Array{
+										Path: []string{"reviews"},
+										Item: &Object{
+											Fields: []*Field{
+												{
+													Name:  []byte("body"),
+													Value: &String{Path: []string{"body"}},
+												},
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+					{
+						HasBuffer: true,
+						BufferID:  1,
+						Name:      []byte("topProducts"),
+						Value: &Array{
+							Path: []string{"topProducts"},
+							Item: &Object{
+								Fetch: singleFetch(3, "http://reviews", `{"rating":5}`),
+								Fields: []*Field{
+									{
+										Name:  []byte("upc"),
+										Value: &String{Path: []string{"upc"}},
+									},
+									{
+										HasBuffer: true,
+										BufferID:  3,
+										Name:      []byte("rating"),
+										Value:     &Integer{Path: []string{"rating"}},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		}
+	}
+
+	rCtx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	resolver := newResolver(rCtx, false, false)
+
+	t.Run("writes metrics per subgraph to extensions", func(t *testing.T) {
+		ctx := NewContext(context.Background())
+		ctx.SetSubgraphMetrics(NewSubgraphMetrics(map[string]string{
+			"http://accounts": "accounts",
+			"http://reviews":  "reviews",
+		}))
+
+		buf := &bytes.Buffer{}
+		err := resolver.ResolveGraphQLResponse(ctx, response(), nil, buf)
+		require.NoError(t, err)
+
+		data, _, _, err := jsonparser.Get(buf.Bytes(), "data")
+		require.NoError(t, err)
+		assert.Equal(t, `{"me":{"reviews":[{"body":"great"}]},"topProducts":[{"upc":"top-1","rating":5}]}`, string(data))
+
+		extensions, err := ctx.ResponseExtensions()
+		require.NoError(t, err)
+		extension, _, _, err := jsonparser.Get(extensions, "subgraphs")
+		require.NoError(t, err)
+		var subgraphs []SubgraphMetric
+		require.NoError(t, json.Unmarshal(extension, &subgraphs))
+		require.Len(t, subgraphs, 3)
+
+		assert.Equal(t, "accounts", subgraphs[0].Subgraph)
+		assert.Equal(t, 1, subgraphs[0].Fetches)
+		assert.Equal(t, len(`{"me":{"id":"1"}}`), subgraphs[0].Bytes)
+
+		assert.Equal(t, "http://products", subgraphs[1].Subgraph)
+		assert.Equal(t, 1, subgraphs[1].Fetches)
+		assert.Equal(t, len(`{"topProducts":[{"upc":"top-1"}]}`), subgraphs[1].Bytes)
+
+		assert.Equal(t, "reviews", subgraphs[2].Subgraph)
+		assert.Equal(t, 2, subgraphs[2].Fetches)
+		assert.Equal(t, len(`{"reviews":[{"body":"great"}]}`)+len(`{"rating":5}`), subgraphs[2].Bytes)
+
+		for _, subgraph := range subgraphs {
+			assert.GreaterOrEqual(t, subgraph.LatencyNanoseconds, int64(subgraph.Fetches)*int64(time.Millisecond))
+		}
+	})
+
+	t.Run("omits extensions when disabled", func(t *testing.T) {
+		buf := &bytes.Buffer{}
+		err := resolver.ResolveGraphQLResponse(NewContext(context.Background()), response(), nil, buf)
+		require.NoError(t, err)
+		assert.Equal(t, `{"data":{"me":{"reviews":[{"body":"great"}]},"topProducts":[{"upc":"top-1","rating":5}]}}`, buf.String())
+	})
+}
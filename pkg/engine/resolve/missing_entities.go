@@ -0,0 +1,63 @@
+package resolve
+
+import (
+	"bytes"
+
+	"github.com/wundergraph/graphql-go-tools/pkg/lexer/literal"
+)
+
+var missingEntityMsg = []byte("entity not found")
+
+// MissingEntityPolicy controls how the resolver handles objects whose entity is missing in the response
+// of their _entities fetch, i.e. the subgraph returned null for the representation of the object
+// or fewer entities than representations
+type MissingEntityPolicy int
+
+const (
+	// MissingEntityNull sets the object to null. If the object is non-nullable, null propagates to its parent
+	// as for any non-nullable field resolving to null, see NullPropagation.
+	MissingEntityNull MissingEntityPolicy = iota
+	// MissingEntityError sets the object to null like MissingEntityNull
+	// and adds an error with the path of the object to the response.
+	MissingEntityError
+)
+
+// SetMissingEntityPolicy sets how objects with a missing entity are handled, defaults to MissingEntityNull
+func (c *Context) SetMissingEntityPolicy(policy MissingEntityPolicy) {
+	c.missingEntityPolicy = policy
+}
+
+// missingEntity reports whether an entity fetch of the object returned null for its representation.
+// Fetches skipped for the object, e.g. because of its __typename, leave their buffer empty and don't count as missing.
+func missingEntity(fetch Fetch, set *resultSet) bool {
+	switch f := fetch.(type) {
+	case *BatchFetch:
+		if !f.Fetch.ProcessResponseConfig.ExtractFederationEntities {
+			return false
+		}
+		buf, ok := set.buffers[f.Fetch.BufferId]
+		return ok && bytes.Equal(buf.Data.Bytes(), literal.NULL)
+	case *ParallelFetch:
+		for i := range f.Fetches {
+			if missingEntity(f.Fetches[i], set) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// resolveMissingEntity resolves an object with a missing entity to null, see MissingEntityPolicy
+func (r *Resolver) resolveMissingEntity(ctx *Context, object *Object, objectBuf *BufPair) error {
+	switch {
+	case ctx.missingEntityPolicy == MissingEntityError:
+		r.addError(ctx, objectBuf, missingEntityMsg)
+	case !object.Nullable:
+		r.addResolveError(ctx, objectBuf)
+	}
+	if !object.Nullable {
+		return errNonNullableFieldValueIsNull
+	}
+	r.resolveNull(objectBuf.Data)
+	return nil
+}
@@ -0,0 +1,38 @@
+package resolve
+
+import (
+	"errors"
+)
+
+// NullPropagation controls how the resolver handles non-nullable fields resolving to null
+type NullPropagation int
+
+const (
+	// NullPropagationBubble sets the nearest nullable parent of the field to null, as defined by the GraphQL specification
+	NullPropagationBubble NullPropagation = iota
+	// NullPropagationLocalized sets only the field itself to null and keeps the data of its siblings.
+	// Elements of lists are handled like fields, an element resolving to null is set to null while the other
+	// elements are kept, even if the elements of the list are non-nullable.
+	// An error with the path of the field or the element is added to the response.
+	NullPropagationLocalized
+)
+
+// SetNullPropagation sets how non-nullable fields resolving to null are handled, defaults to NullPropagationBubble
+func (c *Context) SetNullPropagation(nullPropagation NullPropagation) {
+	c.nullPropagation = nullPropagation
+}
+
+// localizeNull handles the error of a value resolved into buf in localized mode:
+// the data of the value is replaced with null and an error with the current path is added,
+// values of objects, field errors and invalid scalars added their error already. Other errors are returned unchanged.
+func (r *Resolver) localizeNull(ctx *Context, node Node, buf *BufPair, err error) error {
+	if err == nil || ctx.nullPropagation != NullPropagationLocalized || !errors.Is(err, errNonNullableFieldValueIsNull) {
+		return err
+	}
+	buf.Data.Reset()
+	if !hasOwnResolveError(node, err) {
+		r.addResolveError(ctx, buf)
+	}
+	r.resolveNull(buf.Data)
+	return nil
+}
@@ -0,0 +1,115 @@
+package resolve
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolver_NullPropagation(t *testing.T) {
+	resolve := func(t *testing.T, nullPropagation NullPropagation, response *GraphQLResponse) string {
+		rCtx, cancelResolver := context.WithCancel(context.Background())
+		defer cancelResolver()
+		resolver := newResolver(rCtx, false, false)
+
+		ctx := &Context{ctx: context.Background()}
+		ctx.SetNullPropagation(nullPropagation)
+
+		buf := &bytes.Buffer{}
+		require.NoError(t, resolver.ResolveGraphQLResponse(ctx, response, nil, buf))
+		return buf.String()
+	}
+
+	t.Run("non-nullable field of list item", func(t *testing.T) {
+		response := func() *GraphQLResponse {
+			return &GraphQLResponse{
+				Data: &Object{
+					Fetch: &SingleFetch{
+						BufferId:   0,
+						DataSource: FakeDataSource(`{"todos":[{"id":1,"name":"a"},{"id":2,"name":null},{"id":3,"name":"c"}]}`),
+					},
+					Fields: []*Field{
+						{
+							HasBuffer: true,
+							BufferID:  0,
+							Name:      []byte("todos"),
+							Value: &Array{
+								Path: []string{"todos"},
+								Item: &Object{
+									Nullable: true,
+									Fields: []*Field{
+										{
+											Name: []byte("id"),
+											Value: &Integer{
+												Path: []string{"id"},
+											},
+										},
+										{
+											Name: []byte("name"),
+											Value: &String{
+												Path: []string{"name"},
+											},
+											Position: Position{
+												Line:   3,
+												Column: 5,
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			}
+		}
+
+		t.Run("bubble", func(t *testing.T) {
+			out := resolve(t, NullPropagationBubble, response())
+			assert.Equal(t, `{"data":{"todos":[{"id":1,"name":"a"},null,{"id":3,"name":"c"}]}}`, out)
+		})
+		t.Run("localized", func(t *testing.T) {
+			out := resolve(t, NullPropagationLocalized, response())
+			assert.Equal(t, `{"errors":[{"message":"unable to resolve","locations":[{"line":3,"column":5}],"path":["todos","1","name"]}],"data":{"todos":[{"id":1,"name":"a"},{"id":2,"name":null},{"id":3,"name":"c"}]}}`, out)
+		})
+	})
+
+	t.Run("non-nullable list item", func(t *testing.T) {
+		response := func() *GraphQLResponse {
+			return &GraphQLResponse{
+				Data: &Object{
+					Fetch: &SingleFetch{
+						BufferId:   0,
+						DataSource: FakeDataSource(`{"tags":["a",null,"c"]}`),
+					},
+					Fields: []*Field{
+						{
+							HasBuffer: true,
+							BufferID:  0,
+							Name:      []byte("tags"),
+							Value: &Array{
+								Path: []string{"tags"},
+								Item: &String{},
+							},
+							Position: Position{
+								Line:   2,
+								Column: 3,
+							},
+						},
+					},
+				},
+			}
+		}
+
+		t.Run("bubble", func(t *testing.T) {
+			out := resolve(t, NullPropagationBubble, response())
+			assert.Equal(t, `{"errors":[{"message":"unable to resolve","locations":[{"line":2,"column":3}]}],"data":null}`, out)
+		})
+		t.Run("localized", func(t *testing.T) {
+			out := resolve(t, NullPropagationLocalized, response())
+			assert.Equal(t, `{"errors":[{"message":"unable to resolve","locations":[{"line":2,"column":3}],"path":["tags","1"]}],"data":{"tags":["a",null,"c"]}}`, out)
+		})
+	})
+}
@@ -95,6 +95,9 @@ const (
 	NodeKindBoolean
 	NodeKindInteger
 	NodeKindFloat
+	NodeKindScalar
+	NodeKindStaticString
+	NodeKindFieldError
 
 	FetchKindSingle FetchKind = iota + 1
 	FetchKindParallel
@@ -129,8 +132,26 @@ type Context struct {
 	dataLoader       *dataLoader
 	beforeFetchHook  BeforeFetchHook
 	afterFetchHook   AfterFetchHook
+	fetchDeadlines   *FetchDeadlineScheduler
+	subgraphMetrics  *SubgraphMetrics
+	subgraphExts     *SubgraphExtensions
+	fetchLogger      *FetchLogger
+	nullPropagation  NullPropagation
 	position         Position
 	RenameTypeNames  []RenameTypeName
+
+	maxOperationTimeout time.Duration
+	requestTimeout      time.Duration
+	operationTimeout    *operationTimeout
+
+	// missingEntityPolicy decides how objects with a missing entity are resolved, see MissingEntityPolicy
+	missingEntityPolicy MissingEntityPolicy
+	// fetchDeduplication is set while resolving a response if the fetcher deduplicates fetches
+	fetchDeduplication *fetchDeduplication
+	// responseValidation is set if the responses of data sources get validated, see ResponseValidation
+	responseValidation *ResponseValidation
+	// incremental collects deferred fragments and streamed list items while resolving an incremental response
+	incremental *incrementalPatches
 }
 
 type Request struct {
@@ -202,7 +223,22 @@ func (c *Context) clone() Context {
 		pathPrefix:      pathPrefix,
 		beforeFetchHook: c.beforeFetchHook,
 		afterFetchHook:  c.afterFetchHook,
+		fetchDeadlines:  c.fetchDeadlines,
+		subgraphMetrics: c.subgraphMetrics,
+		subgraphExts:    c.subgraphExts,
+		fetchLogger:     c.fetchLogger,
+		nullPropagation: c.nullPropagation,
 		position:        c.position,
+
+		missingEntityPolicy: c.missingEntityPolicy,
+
+		maxOperationTimeout: c.maxOperationTimeout,
+		requestTimeout:      c.requestTimeout,
+		operationTimeout:    c.operationTimeout,
+
+		fetchDeduplication: c.fetchDeduplication,
+		responseValidation: c.responseValidation,
+		incremental:        c.incremental,
 	}
 }
 
@@ -220,10 +256,22 @@ func (c *Context) Free() {
 	c.maxPatch = -1
 	c.beforeFetchHook = nil
 	c.afterFetchHook = nil
+	c.fetchDeadlines = nil
+	c.subgraphMetrics = nil
+	c.subgraphExts = nil
+	c.fetchLogger = nil
+	c.nullPropagation = NullPropagationBubble
+	c.missingEntityPolicy = MissingEntityNull
+	c.responseValidation = nil
 	c.Request.Header = nil
 	c.position = Position{}
 	c.dataLoader = nil
+	c.fetchDeduplication = nil
+	c.incremental = nil
 	c.RenameTypeNames = nil
+	c.maxOperationTimeout = 0
+	c.requestTimeout = 0
+	c.operationTimeout = nil
 }
 
 func (c *Context) SetBeforeFetchHook(hook BeforeFetchHook) {
@@ -234,6 +282,50 @@ func (c *Context) SetAfterFetchHook(hook AfterFetchHook) {
 	c.afterFetchHook = hook
 }
 
+// SetFetchDeadlineScheduler enables per fetch deadlines derived from the deadline of the request context
+func (c *Context) SetFetchDeadlineScheduler(scheduler *FetchDeadlineScheduler) {
+	c.fetchDeadlines = scheduler
+}
+
+// SetSubgraphMetrics enables the collection of subgraph metrics,
+// the metrics get written to the extensions of the response.
+func (c *Context) SetSubgraphMetrics(metrics *SubgraphMetrics) {
+	c.subgraphMetrics = metrics
+}
+
+// SetSubgraphExtensions enables the collection of the extensions of the responses of subgraphs,
+// the extensions get merged into the extensions of the response namespaced by subgraph.
+func (c *Context) SetSubgraphExtensions(extensions *SubgraphExtensions) {
+	c.subgraphExts = extensions
+}
+
+// SetFetchLogger enables the logging of the requests to data sources and their responses
+func (c *Context) SetFetchLogger(logger *FetchLogger) {
+	c.fetchLogger = logger
+}
+
+func (c *Context) addPendingFetches(fetches int) {
+	if c.fetchDeadlines != nil {
+		c.fetchDeadlines.add(fetches)
+	}
+}
+
+// dependentFetches returns the number of fetches of the plan below the object, which depend on the data of its fetch
+func (c *Context) dependentFetches(object *Object) int {
+	if c.fetchDeadlines == nil {
+		return 0
+	}
+	return c.fetchDeadlines.dependentFetches(object)
+}
+
+func (c *Context) withFetchDeadline(dependentFetches int) (*Context, func()) {
+	if c.fetchDeadlines == nil {
+		return c, func() {}
+	}
+	fetchCtx, done := c.fetchDeadlines.schedule(c.ctx, dependentFetches)
+	return c.WithContext(fetchCtx), done
+}
+
 func (c *Context) setPosition(position Position) {
 	c.position = position
 }
@@ -347,7 +439,8 @@ type Resolver struct {
 }
 
 type inflightFetch struct {
-	waitLoad sync.WaitGroup
+	// loaded is closed once the fetch is loaded, so requests waiting for it can stop waiting when they are cancelled
+	loaded   chan struct{}
 	waitFree sync.WaitGroup
 	err      error
 	bufPair  BufPair
@@ -426,12 +519,19 @@ func (r *Resolver) resolveNode(ctx *Context, node Node, data []byte, bufPair *Bu
 		return r.resolveInteger(ctx, n, data, bufPair)
 	case *Float:
 		return r.resolveFloat(ctx, n, data, bufPair)
+	case *Scalar:
+		return r.resolveScalar(ctx, n, data, bufPair)
 	case *EmptyObject:
 		r.resolveEmptyObject(bufPair.Data)
 		return
 	case *EmptyArray:
 		r.resolveEmptyArray(bufPair.Data)
 		return
+	case *StaticString:
+		r.resolveStaticString(n, bufPair.Data)
+		return
+	case *FieldError:
+		return r.resolveFieldError(ctx, n, bufPair)
 	default:
 		return
 	}
@@ -496,6 +596,9 @@ func (r *Resolver) ResolveGraphQLResponse(ctx *Context, response *GraphQLRespons
 	responseBuf := r.getBufPair()
 	defer r.freeBufPair(responseBuf)
 
+	ctx, cancel := ctx.withOperationTimeout(response.Timeout)
+	defer cancel()
+
 	extractResponse(data, responseBuf, ProcessResponseConfig{ExtractGraphqlResponse: true})
 
 	if data != nil {
@@ -510,6 +613,13 @@ func (r *Resolver) ResolveGraphQLResponse(ctx *Context, response *GraphQLRespons
 		}()
 	}
 
+	if r.fetcher.EnableFetchDeduplication && ctx.fetchDeduplication == nil {
+		ctx.fetchDeduplication = newFetchDeduplication()
+		defer func() {
+			ctx.fetchDeduplication = nil
+		}()
+	}
+
 	ignoreData := false
 	err = r.resolveNode(ctx, response.Data, responseBuf.Data.Bytes(), buf)
 	if err != nil {
@@ -521,6 +631,7 @@ func (r *Resolver) ResolveGraphQLResponse(ctx *Context, response *GraphQLRespons
 	if responseBuf.Errors.Len() > 0 {
 		r.MergeBufPairErrors(responseBuf, buf)
 	}
+	ctx.writeOperationTimeoutError(buf)
 
 	return writeGraphqlResponse(buf, writer, ignoreData)
 }
@@ -574,6 +685,9 @@ func (r *Resolver) ResolveGraphQLSubscription(ctx *Context, subscription *GraphQ
 			if !ok {
 				return nil
 			}
+			if subscription.Filter != nil && subscription.Filter.skip(ctx, data) {
+				continue
+			}
 			err = r.ResolveGraphQLResponse(ctx, subscription.Response, data, writer)
 			if err != nil {
 				return err
@@ -666,7 +780,11 @@ func (r *Resolver) ResolveGraphQLResponsePatch(ctx *Context, patch *GraphQLRespo
 	if patch.Fetch != nil {
 		set := r.getResultSet()
 		defer r.freeResultSet(set)
-		err = r.resolveFetch(ctx, patch.Fetch, data, set)
+		dependentFetches := 0
+		if object, ok := patch.Value.(*Object); ok {
+			dependentFetches = ctx.dependentFetches(object)
+		}
+		err = r.resolveFetch(ctx, patch.Fetch, dependentFetches, data, set)
 		if err != nil {
 			return err
 		}
@@ -726,12 +844,19 @@ func (r *Resolver) resolveEmptyArray(b *fastbuffer.FastBuffer) {
 	b.WriteBytes(rBrack)
 }
 
+func (r *Resolver) resolveStaticString(str *StaticString, b *fastbuffer.FastBuffer) {
+	b.WriteBytes(quote)
+	b.WriteString(str.Value)
+	b.WriteBytes(quote)
+}
+
 func (r *Resolver) resolveEmptyObject(b *fastbuffer.FastBuffer) {
 	b.WriteBytes(lBrace)
 	b.WriteBytes(rBrace)
 }
 
 func (r *Resolver) resolveArray(ctx *Context, array *Array, data []byte, arrayBuf *BufPair) (err error) {
+	ctx.validateValue(data, array.Path, "list", array.Nullable, jsonparser.Array)
 	if len(array.Path) != 0 {
 		data, _, _, _ = jsonparser.Get(data, array.Path...)
 	}
@@ -788,7 +913,11 @@ func (r *Resolver) resolveArraySynchronous(ctx *Context, array *Array, arrayItem
 		if array.Stream.Enabled {
 			if i > array.Stream.InitialBatchSize-1 {
 				ctx.addIntegerPathElement(i)
-				r.preparePatch(ctx, array.Stream.PatchIndex, nil, (*arrayItems)[i])
+				if ctx.incremental != nil {
+					ctx.incremental.add(array.Stream.PatchIndex, ctx.pathElements, (*arrayItems)[i])
+				} else {
+					r.preparePatch(ctx, array.Stream.PatchIndex, nil, (*arrayItems)[i])
+				}
 				ctx.removeLastPathElement()
 				continue
 			}
@@ -796,6 +925,7 @@ func (r *Resolver) resolveArraySynchronous(ctx *Context, array *Array, arrayItem
 
 		ctx.addIntegerPathElement(i)
 		err = r.resolveNode(ctx, array.Item, (*arrayItems)[i], itemBuf)
+		err = r.localizeNull(ctx, array.Item, itemBuf, err)
 		ctx.removeLastPathElement()
 		if err != nil {
 			if errors.Is(err, errNonNullableFieldValueIsNull) && array.Nullable {
@@ -842,7 +972,9 @@ func (r *Resolver) resolveArrayAsynchronous(ctx *Context, array *Array, arrayIte
 		cloned := ctx.clone()
 		go func(ctx Context, i int) {
 			ctx.addPathElement([]byte(strconv.Itoa(i)))
-			if e := r.resolveNode(&ctx, array.Item, itemData, itemBuf); e != nil && !errors.Is(e, errTypeNameSkipped) {
+			e := r.resolveNode(&ctx, array.Item, itemData, itemBuf)
+			e = r.localizeNull(&ctx, array.Item, itemBuf, e)
+			if e != nil && !errors.Is(e, errTypeNameSkipped) {
 				select {
 				case errCh <- e:
 				default:
@@ -896,6 +1028,7 @@ func (r *Resolver) exportField(ctx *Context, export *FieldExport, value []byte)
 }
 
 func (r *Resolver) resolveInteger(ctx *Context, integer *Integer, data []byte, integerBuf *BufPair) error {
+	ctx.validateValue(data, integer.Path, "Int", integer.Nullable, jsonparser.Number)
 	value, dataType, _, err := jsonparser.Get(data, integer.Path...)
 	if err != nil || dataType != jsonparser.Number {
 		if !integer.Nullable {
@@ -904,12 +1037,14 @@ func (r *Resolver) resolveInteger(ctx *Context, integer *Integer, data []byte, i
 		r.resolveNull(integerBuf.Data)
 		return nil
 	}
+	value = trimZeroFraction(value)
 	integerBuf.Data.WriteBytes(value)
 	r.exportField(ctx, integer.Export, value)
 	return nil
 }
 
 func (r *Resolver) resolveFloat(ctx *Context, floatValue *Float, data []byte, floatBuf *BufPair) error {
+	ctx.validateValue(data, floatValue.Path, "Float", floatValue.Nullable, jsonparser.Number)
 	value, dataType, _, err := jsonparser.Get(data, floatValue.Path...)
 	if err != nil || dataType != jsonparser.Number {
 		if !floatValue.Nullable {
@@ -918,12 +1053,36 @@ func (r *Resolver) resolveFloat(ctx *Context, floatValue *Float, data []byte, fl
 		r.resolveNull(floatBuf.Data)
 		return nil
 	}
+	if isIntegerLiteral(value) {
+		// floats are written with a decimal point, e.g. 1 as 1.0, so that clients can tell them from integers
+		value = append(value[:len(value):len(value)], '.', '0')
+	}
 	floatBuf.Data.WriteBytes(value)
 	r.exportField(ctx, floatValue.Export, value)
 	return nil
 }
 
+// isIntegerLiteral reports whether the JSON number has neither a fraction nor an exponent
+func isIntegerLiteral(number []byte) bool {
+	return bytes.IndexAny(number, ".eE") == -1
+}
+
+// trimZeroFraction removes a fraction of zeros from the JSON number, e.g. 1.0 of an Int field is written as 1
+func trimZeroFraction(number []byte) []byte {
+	dot := bytes.IndexByte(number, '.')
+	if dot == -1 {
+		return number
+	}
+	for _, c := range number[dot+1:] {
+		if c != '0' {
+			return number
+		}
+	}
+	return number[:dot]
+}
+
 func (r *Resolver) resolveBoolean(ctx *Context, boolean *Boolean, data []byte, booleanBuf *BufPair) error {
+	ctx.validateValue(data, boolean.Path, "Boolean", boolean.Nullable, jsonparser.Boolean)
 	value, valueType, _, err := jsonparser.Get(data, boolean.Path...)
 	if err != nil || valueType != jsonparser.Boolean {
 		if !boolean.Nullable {
@@ -944,6 +1103,10 @@ func (r *Resolver) resolveString(ctx *Context, str *String, data []byte, stringB
 		err       error
 	)
 
+	if !str.UnescapeResponseJson {
+		ctx.validateValue(data, str.Path, "String", str.Nullable, jsonparser.String)
+	}
+
 	value, valueType, _, err = jsonparser.Get(data, str.Path...)
 	if err != nil || valueType != jsonparser.String {
 		if err == nil && str.UnescapeResponseJson {
@@ -985,6 +1148,7 @@ func (r *Resolver) resolveString(ctx *Context, str *String, data []byte, stringB
 	}
 
 	value = r.renameTypeName(ctx, str, value)
+	value = r.renameEnumValue(str, value)
 
 	stringBuf.Data.WriteBytes(quote)
 	stringBuf.Data.WriteBytes(value)
@@ -1005,6 +1169,15 @@ func (r *Resolver) renameTypeName(ctx *Context, str *String, typeName []byte) []
 	return typeName
 }
 
+func (r *Resolver) renameEnumValue(str *String, value []byte) []byte {
+	for i := range str.EnumValues {
+		if bytes.Equal(str.EnumValues[i].From, value) {
+			return str.EnumValues[i].To
+		}
+	}
+	return value
+}
+
 func (r *Resolver) preparePatch(ctx *Context, patchIndex int, extraPath, data []byte) {
 	buf := pool.BytesBuffer.Get()
 	ctx.usedBuffers = append(ctx.usedBuffers, buf)
@@ -1018,6 +1191,11 @@ func (r *Resolver) resolveNull(b *fastbuffer.FastBuffer) {
 }
 
 func (r *Resolver) addResolveError(ctx *Context, objectBuf *BufPair) {
+	r.addError(ctx, objectBuf, unableToResolveMsg)
+}
+
+// addError adds an error with the message and the position and path of the current field
+func (r *Resolver) addError(ctx *Context, objectBuf *BufPair, message []byte) {
 	locations, path := pool.BytesBuffer.Get(), pool.BytesBuffer.Get()
 	defer pool.BytesBuffer.Put(locations)
 	defer pool.BytesBuffer.Put(path)
@@ -1050,10 +1228,47 @@ func (r *Resolver) addResolveError(ctx *Context, objectBuf *BufPair) {
 		pathBytes = path.Bytes()
 	}
 
-	objectBuf.WriteErr(unableToResolveMsg, locations.Bytes(), pathBytes, nil)
+	objectBuf.WriteErr(message, locations.Bytes(), pathBytes, nil)
+}
+
+// hasOwnResolveError reports whether the node added an error when it resolved to null with err,
+// objects add an error with their own path, field errors add their message
+// and scalars the error of serializing an invalid value
+func hasOwnResolveError(node Node, err error) bool {
+	switch node.(type) {
+	case *Object, *FieldError:
+		return true
+	case *Scalar:
+		return errors.Is(err, errInvalidScalarValue)
+	default:
+		return false
+	}
+}
+
+func (r *Resolver) resolveFieldError(ctx *Context, fieldError *FieldError, buf *BufPair) error {
+	r.addError(ctx, buf, []byte(fieldError.Message))
+	if !fieldError.Nullable {
+		return errNonNullableFieldValueIsNull
+	}
+	r.resolveNull(buf.Data)
+	return nil
+}
+
+// isFieldNameWritten returns true if one of the fields with the given name has been written
+func (r *Resolver) isFieldNameWritten(fields []*Field, written []bool, name []byte) bool {
+	for i := range fields {
+		if written[i] && bytes.Equal(fields[i].Name, name) {
+			return true
+		}
+	}
+	return false
 }
 
 func (r *Resolver) resolveObject(ctx *Context, object *Object, data []byte, objectBuf *BufPair) (err error) {
+	// the data of the root object is empty until its fetch is resolved
+	if !object.UnescapeResponseJson && (len(object.Path) != 0 || len(data) != 0) {
+		ctx.validateValue(data, object.Path, "object", object.Nullable, jsonparser.Object)
+	}
 	if len(object.Path) != 0 {
 		data, _, _, _ = jsonparser.Get(data, object.Path...)
 
@@ -1082,13 +1297,16 @@ func (r *Resolver) resolveObject(ctx *Context, object *Object, data []byte, obje
 	if object.Fetch != nil {
 		set = r.getResultSet()
 		defer r.freeResultSet(set)
-		err = r.resolveFetch(ctx, object.Fetch, data, set)
+		err = r.resolveFetch(ctx, object.Fetch, ctx.dependentFetches(object), data, set)
 		if err != nil {
 			return
 		}
 		for i := range set.buffers {
 			r.MergeBufPairErrors(set.buffers[i], objectBuf)
 		}
+		if missingEntity(object.Fetch, set) {
+			return r.resolveMissingEntity(ctx, object, objectBuf)
+		}
 	}
 
 	fieldBuf := r.getBufPair()
@@ -1097,6 +1315,14 @@ func (r *Resolver) resolveObject(ctx *Context, object *Object, data []byte, obje
 	responseElements := ctx.responseElements
 	lastFetchID := ctx.lastFetchID
 
+	var written []bool
+	for i := range object.Fields {
+		if object.Fields[i].DuplicateResponseKey {
+			written = make([]bool, len(object.Fields))
+			break
+		}
+	}
+
 	typeNameSkip := false
 	first := true
 	skipCount := 0
@@ -1118,6 +1344,10 @@ func (r *Resolver) resolveObject(ctx *Context, object *Object, data []byte, obje
 			}
 		}
 
+		if object.Fields[i].DuplicateResponseKey && r.isFieldNameWritten(object.Fields[:i], written, object.Fields[i].Name) {
+			continue
+		}
+
 		var fieldData []byte
 		if set != nil && object.Fields[i].HasBuffer {
 			buffer, ok := set.buffers[object.Fields[i].BufferID]
@@ -1130,9 +1360,9 @@ func (r *Resolver) resolveObject(ctx *Context, object *Object, data []byte, obje
 			fieldData = data
 		}
 
-		if object.Fields[i].OnTypeName != nil {
+		if object.Fields[i].OnTypeName != nil || object.Fields[i].OnTypeNames != nil {
 			typeName, _, _, _ := jsonparser.Get(fieldData, "__typename")
-			if bytes.Equal(typeName, object.Fields[i].OnTypeName) {
+			if object.Fields[i].appliesToTypeName(typeName) {
 				// Store TypeName for fetch so that dataLoader will not collect records with unmatching types together
 				if fieldObject, ok := object.Fields[i].Value.(*Object); ok {
 					switch fetch := fieldObject.Fetch.(type) {
@@ -1157,6 +1387,9 @@ func (r *Resolver) resolveObject(ctx *Context, object *Object, data []byte, obje
 		} else {
 			objectBuf.Data.WriteBytes(comma)
 		}
+		if written != nil {
+			written[i] = true
+		}
 		objectBuf.Data.WriteBytes(quote)
 		objectBuf.Data.WriteBytes(object.Fields[i].Name)
 		objectBuf.Data.WriteBytes(quote)
@@ -1164,6 +1397,7 @@ func (r *Resolver) resolveObject(ctx *Context, object *Object, data []byte, obje
 		ctx.addPathElement(object.Fields[i].Name)
 		ctx.setPosition(object.Fields[i].Position)
 		err = r.resolveNode(ctx, object.Fields[i].Value, fieldData, fieldBuf)
+		err = r.localizeNull(ctx, object.Fields[i].Value, fieldBuf, err)
 		ctx.removeLastPathElement()
 		ctx.responseElements = responseElements
 		ctx.lastFetchID = lastFetchID
@@ -1183,7 +1417,7 @@ func (r *Resolver) resolveObject(ctx *Context, object *Object, data []byte, obje
 				}
 
 				// if fied is of object type than we should not add resolve error here
-				if _, ok := object.Fields[i].Value.(*Object); !ok {
+				if !hasOwnResolveError(object.Fields[i].Value, err) {
 					r.addResolveError(ctx, objectBuf)
 				}
 			}
@@ -1197,9 +1431,16 @@ func (r *Resolver) resolveObject(ctx *Context, object *Object, data []byte, obje
 		// return empty object if all fields have been skipped
 		objectBuf.Data.WriteBytes(lBrace)
 		objectBuf.Data.WriteBytes(rBrace)
+		r.deferFragments(ctx, object, data)
 		return
 	}
 	if first {
+		if len(object.Deferred) != 0 && !typeNameSkip {
+			// all fields of the object are deferred
+			r.resolveEmptyObject(objectBuf.Data)
+			r.deferFragments(ctx, object, data)
+			return
+		}
 		if typeNameSkip && !object.Nullable {
 			return errTypeNameSkipped
 		}
@@ -1211,6 +1452,7 @@ func (r *Resolver) resolveObject(ctx *Context, object *Object, data []byte, obje
 		return
 	}
 	objectBuf.Data.WriteBytes(rBrace)
+	r.deferFragments(ctx, object, data)
 	return
 }
 
@@ -1223,7 +1465,7 @@ func (r *Resolver) recursivelySkipBatchResults(ctx *Context, object *Object, dat
 	if object.Fetch != nil && object.Fetch.FetchKind() == FetchKindBatch {
 		set := r.getResultSet()
 		defer r.freeResultSet(set)
-		_ = r.resolveFetch(ctx, object.Fetch, data, set)
+		_ = r.resolveFetch(ctx, object.Fetch, 0, data, set)
 	}
 	for i := range object.Fields {
 		value := object.Fields[i].Value
@@ -1248,7 +1490,7 @@ func (r *Resolver) freeResultSet(set *resultSet) {
 	r.resultSetPool.Put(set)
 }
 
-func (r *Resolver) resolveFetch(ctx *Context, fetch Fetch, data []byte, set *resultSet) (err error) {
+func (r *Resolver) resolveFetch(ctx *Context, fetch Fetch, dependentFetches int, data []byte, set *resultSet) (err error) {
 	// if context is cancelled, we should not resolve the fetch
 	if errors.Is(ctx.Context().Err(), context.Canceled) {
 		return nil
@@ -1256,28 +1498,38 @@ func (r *Resolver) resolveFetch(ctx *Context, fetch Fetch, data []byte, set *res
 
 	switch f := fetch.(type) {
 	case *SingleFetch:
+		if r.skipFetch(f, data, set) {
+			return nil
+		}
 		preparedInput := r.getBufPair()
 		defer r.freeBufPair(preparedInput)
 		err = r.prepareSingleFetch(ctx, f, data, set, preparedInput.Data)
 		if err != nil {
 			return err
 		}
-		err = r.resolveSingleFetch(ctx, f, preparedInput.Data, set.buffers[f.BufferId])
+		ctx.addPendingFetches(1)
+		err = r.resolveSingleFetch(ctx, f, dependentFetches, preparedInput.Data, set.buffers[f.BufferId])
 	case *BatchFetch:
+		if r.skipFetch(f.Fetch, data, set) {
+			return nil
+		}
 		preparedInput := r.getBufPair()
 		defer r.freeBufPair(preparedInput)
 		err = r.prepareSingleFetch(ctx, f.Fetch, data, set, preparedInput.Data)
 		if err != nil {
 			return err
 		}
-		err = r.resolveBatchFetch(ctx, f, preparedInput.Data, set.buffers[f.Fetch.BufferId])
+		ctx.addPendingFetches(1)
+		err = r.resolveBatchFetch(ctx, f, dependentFetches, preparedInput.Data, set.buffers[f.Fetch.BufferId])
 	case *ParallelFetch:
-		err = r.resolveParallelFetch(ctx, f, data, set)
+		err = r.resolveParallelFetch(ctx, f, dependentFetches, data, set)
 	}
 	return
 }
 
-func (r *Resolver) resolveParallelFetch(ctx *Context, fetch *ParallelFetch, data []byte, set *resultSet) (err error) {
+// resolveParallelFetch runs the fetches of an object concurrently and joins them before the fields of the object are resolved.
+// Fetches depending on the data of these fetches, e.g. entity fetches, are attached to nested objects and run afterwards.
+func (r *Resolver) resolveParallelFetch(ctx *Context, fetch *ParallelFetch, dependentFetches int, data []byte, set *resultSet) (err error) {
 	preparedInputs := r.getBufPairSlice()
 	defer r.freeBufPairSlice(preparedInputs)
 
@@ -1287,9 +1539,11 @@ func (r *Resolver) resolveParallelFetch(ctx *Context, fetch *ParallelFetch, data
 	defer r.freeWaitGroup(wg)
 
 	for i := range fetch.Fetches {
-		wg.Add(1)
 		switch f := fetch.Fetches[i].(type) {
 		case *SingleFetch:
+			if r.skipFetch(f, data, set) {
+				continue
+			}
 			preparedInput := r.getBufPair()
 			err = r.prepareSingleFetch(ctx, f, data, set, preparedInput.Data)
 			if err != nil {
@@ -1298,9 +1552,12 @@ func (r *Resolver) resolveParallelFetch(ctx *Context, fetch *ParallelFetch, data
 			*preparedInputs = append(*preparedInputs, preparedInput)
 			buf := set.buffers[f.BufferId]
 			resolvers = append(resolvers, func() error {
-				return r.resolveSingleFetch(ctx, f, preparedInput.Data, buf)
+				return r.resolveSingleFetch(ctx, f, dependentFetches, preparedInput.Data, buf)
 			})
 		case *BatchFetch:
+			if r.skipFetch(f.Fetch, data, set) {
+				continue
+			}
 			preparedInput := r.getBufPair()
 			err = r.prepareSingleFetch(ctx, f.Fetch, data, set, preparedInput.Data)
 			if err != nil {
@@ -1309,11 +1566,13 @@ func (r *Resolver) resolveParallelFetch(ctx *Context, fetch *ParallelFetch, data
 			*preparedInputs = append(*preparedInputs, preparedInput)
 			buf := set.buffers[f.Fetch.BufferId]
 			resolvers = append(resolvers, func() error {
-				return r.resolveBatchFetch(ctx, f, preparedInput.Data, buf)
+				return r.resolveBatchFetch(ctx, f, dependentFetches, preparedInput.Data, buf)
 			})
 		}
 	}
 
+	ctx.addPendingFetches(len(resolvers))
+	wg.Add(len(resolvers))
 	for _, resolver := range resolvers {
 		go func(r func() error) {
 			_ = r()
@@ -1326,6 +1585,15 @@ func (r *Resolver) resolveParallelFetch(ctx *Context, fetch *ParallelFetch, data
 	return
 }
 
+// skipFetch reports whether the fetch doesn't apply to the object, the buffer of a skipped fetch stays empty
+func (r *Resolver) skipFetch(fetch *SingleFetch, data []byte, set *resultSet) bool {
+	if fetch.appliesTo(data) {
+		return false
+	}
+	set.buffers[fetch.BufferId] = r.getBufPair()
+	return true
+}
+
 func (r *Resolver) prepareSingleFetch(ctx *Context, fetch *SingleFetch, data []byte, set *resultSet, preparedInput *fastbuffer.FastBuffer) (err error) {
 	err = fetch.InputTemplate.Render(ctx, data, preparedInput)
 	buf := r.getBufPair()
@@ -1333,23 +1601,36 @@ func (r *Resolver) prepareSingleFetch(ctx *Context, fetch *SingleFetch, data []b
 	return
 }
 
-func (r *Resolver) resolveBatchFetch(ctx *Context, fetch *BatchFetch, preparedInput *fastbuffer.FastBuffer, buf *BufPair) error {
-	if r.dataLoaderEnabled {
-		return ctx.dataLoader.LoadBatch(ctx, fetch, buf)
-	}
+func (r *Resolver) resolveBatchFetch(ctx *Context, fetch *BatchFetch, dependentFetches int, preparedInput *fastbuffer.FastBuffer, buf *BufPair) error {
+	ctx, done := ctx.withFetchDeadline(dependentFetches)
+	defer done()
 
-	if err := r.fetcher.FetchBatch(ctx, fetch, []*fastbuffer.FastBuffer{preparedInput}, []*BufPair{buf}); err != nil {
-		return err
+	var err error
+	if ctx.dataLoader != nil {
+		err = ctx.dataLoader.LoadBatch(ctx, fetch, buf)
+	} else {
+		err = r.fetcher.FetchBatch(ctx, fetch, []*fastbuffer.FastBuffer{preparedInput}, []*BufPair{buf})
 	}
-
-	return nil
+	if ctx.fetchTimedOut(err) {
+		return nil
+	}
+	return err
 }
 
-func (r *Resolver) resolveSingleFetch(ctx *Context, fetch *SingleFetch, preparedInput *fastbuffer.FastBuffer, buf *BufPair) error {
-	if r.dataLoaderEnabled && !fetch.DisableDataLoader {
-		return ctx.dataLoader.Load(ctx, fetch, buf)
+func (r *Resolver) resolveSingleFetch(ctx *Context, fetch *SingleFetch, dependentFetches int, preparedInput *fastbuffer.FastBuffer, buf *BufPair) error {
+	ctx, done := ctx.withFetchDeadline(dependentFetches)
+	defer done()
+
+	var err error
+	if ctx.dataLoader != nil && !fetch.DisableDataLoader {
+		err = ctx.dataLoader.Load(ctx, fetch, buf)
+	} else {
+		err = r.fetcher.Fetch(ctx, fetch, preparedInput, buf)
 	}
-	return r.fetcher.Fetch(ctx, fetch, preparedInput, buf)
+	if ctx.fetchTimedOut(err) {
+		return nil
+	}
+	return err
 }
 
 type Object struct {
@@ -1358,6 +1639,8 @@ type Object struct {
 	Fields               []*Field
 	Fetch                Fetch
 	UnescapeResponseJson bool `json:"unescape_response_json,omitempty"`
+	// Deferred are the indexes of the patches of a GraphQLIncrementalResponse deferred on this object
+	Deferred []int
 }
 
 func (_ *Object) NodeKind() NodeKind {
@@ -1377,18 +1660,38 @@ func (_ *EmptyArray) NodeKind() NodeKind {
 }
 
 type Field struct {
-	Name                    []byte
-	Value                   Node
-	Position                Position
-	Defer                   *DeferField
-	Stream                  *StreamField
-	HasBuffer               bool
-	BufferID                int
-	OnTypeName              []byte
+	Name       []byte
+	Value      Node
+	Position   Position
+	Defer      *DeferField
+	Stream     *StreamField
+	HasBuffer  bool
+	BufferID   int
+	OnTypeName []byte
+	// OnTypeNames are the possible types of an abstract type condition, the field is resolved for objects of any of them
+	OnTypeNames             [][]byte
 	SkipDirectiveDefined    bool
 	SkipVariableName        string
 	IncludeDirectiveDefined bool
 	IncludeVariableName     string
+	// DuplicateResponseKey is set if an earlier field of the object has the same name,
+	// e.g. a field selected both outside and inside a fragment.
+	// The field is only written if no earlier field with the name was written,
+	// so each key appears once at the position of its first occurrence.
+	DuplicateResponseKey bool
+}
+
+// appliesToTypeName reports whether the field is resolved for objects of the type, see OnTypeName and OnTypeNames
+func (f *Field) appliesToTypeName(typeName []byte) bool {
+	if f.OnTypeName != nil && bytes.Equal(typeName, f.OnTypeName) {
+		return true
+	}
+	for i := range f.OnTypeNames {
+		if bytes.Equal(typeName, f.OnTypeNames[i]) {
+			return true
+		}
+	}
+	return false
 }
 
 type Position struct {
@@ -1438,6 +1741,27 @@ type SingleFetch struct {
 	// This is the case, e.g. when using batching and one sibling is null, resulting in a null value for one batch item
 	// Returning null in this case tells the batch implementation to skip this item
 	SetTemplateOutputToNullOnVariableNull bool
+	// OnTypeNames restricts the fetch to objects of these types, objects with another __typename are skipped.
+	// This is the case for entity fetches of abstract types, e.g. of a list of interface items,
+	// where each item is fetched from the subgraph owning its concrete type.
+	OnTypeNames [][]byte
+	// DataSourceIndex is the index of the data source configuration the fetch was planned for,
+	// it identifies the DataSource of fetches of encoded plans, see MarshalGraphQLResponse
+	DataSourceIndex int `json:"-"`
+}
+
+// appliesTo reports whether the fetch applies to the object, see OnTypeNames
+func (s *SingleFetch) appliesTo(object []byte) bool {
+	if len(s.OnTypeNames) == 0 {
+		return true
+	}
+	typeName, _, _, _ := jsonparser.Get(object, "__typename")
+	for i := range s.OnTypeNames {
+		if bytes.Equal(typeName, s.OnTypeNames[i]) {
+			return true
+		}
+	}
+	return false
 }
 
 type ProcessResponseConfig struct {
@@ -1449,6 +1773,7 @@ func (_ *SingleFetch) FetchKind() FetchKind {
 	return FetchKindSingle
 }
 
+// ParallelFetch groups the independent fetches of an object, e.g. of root fields of different subgraphs
 type ParallelFetch struct {
 	Fetches []Fetch
 }
@@ -1479,12 +1804,35 @@ type String struct {
 	Export               *FieldExport `json:"export,omitempty"`
 	UnescapeResponseJson bool         `json:"unescape_response_json,omitempty"`
 	IsTypeName           bool         `json:"is_type_name,omitempty"`
+	// EnumValues rename the values of an enum returned by the data source to the values of the schema
+	EnumValues []RenameEnumValue `json:"enum_values,omitempty"`
 }
 
 func (_ *String) NodeKind() NodeKind {
 	return NodeKindString
 }
 
+// StaticString is a string known at planning time which doesn't depend on the data of a fetch,
+// e.g. the __typename of a root operation type
+type StaticString struct {
+	Value string
+}
+
+func (_ *StaticString) NodeKind() NodeKind {
+	return NodeKindStaticString
+}
+
+// FieldError resolves a field known at planning time to fail to null and adds an error with the Message,
+// e.g. for fields the request isn't authorized to access. The Message must be a valid JSON string content.
+type FieldError struct {
+	Message  string
+	Nullable bool
+}
+
+func (_ *FieldError) NodeKind() NodeKind {
+	return NodeKindFieldError
+}
+
 type Boolean struct {
 	Path     []string
 	Nullable bool
@@ -1536,6 +1884,8 @@ func (_ *Array) NodeKind() NodeKind {
 type GraphQLSubscription struct {
 	Trigger  GraphQLSubscriptionTrigger
 	Response *GraphQLResponse
+	// Filter skips the events which don't match the arguments of the subscription field, if set
+	Filter *SubscriptionFilter
 }
 
 type GraphQLSubscriptionTrigger struct {
@@ -1553,12 +1903,18 @@ type FlushWriter interface {
 type GraphQLResponse struct {
 	Data            Node
 	RenameTypeNames []RenameTypeName
+	// Timeout is the deadline for resolving the response defined by the operation, 0 means no timeout
+	Timeout time.Duration
 }
 
 type RenameTypeName struct {
 	From, To []byte
 }
 
+type RenameEnumValue struct {
+	From, To []byte
+}
+
 type GraphQLStreamingResponse struct {
 	InitialResponse *GraphQLResponse
 	Patches         []*GraphQLResponsePatch
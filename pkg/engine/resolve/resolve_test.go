@@ -4218,6 +4218,123 @@ func TestResolver_ResolveGraphQLSubscription(t *testing.T) {
 		assert.Equal(t, `{"data":{"counter":1}}`, out.flushed[1])
 		assert.Equal(t, `{"data":{"counter":2}}`, out.flushed[2])
 	})
+
+	t.Run("should only send events matching the subscription filter", func(t *testing.T) {
+		c, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		upcs := []string{"1", "2", "1"}
+		fakeStream := FakeStream(cancel, func(count int) (message string, ok bool) {
+			return fmt.Sprintf(`{"data":{"updateProductPrice":{"upc":"%s","price":%d}}}`, upcs[count], count), true
+		})
+
+		plan := &GraphQLSubscription{
+			Trigger: GraphQLSubscriptionTrigger{
+				Source: fakeStream,
+			},
+			Response: &GraphQLResponse{
+				Data: &Object{
+					Fields: []*Field{
+						{
+							Name: []byte("updateProductPrice"),
+							Value: &Object{
+								Path: []string{"updateProductPrice"},
+								Fields: []*Field{
+									{
+										Name:  []byte("upc"),
+										Value: &String{Path: []string{"upc"}},
+									},
+									{
+										Name:  []byte("price"),
+										Value: &Integer{Path: []string{"price"}},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			Filter: &SubscriptionFilter{
+				Conditions: []SubscriptionFilterCondition{
+					{
+						ValuePath:    []string{"updateProductPrice", "upc"},
+						VariablePath: []string{"upc"},
+					},
+				},
+			},
+		}
+		out := &TestFlushWriter{
+			buf: bytes.Buffer{},
+		}
+		resolver := newResolver(c, false, false)
+
+		ctx := Context{
+			ctx:       c,
+			Variables: []byte(`{"upc":"1"}`),
+		}
+
+		err := resolver.ResolveGraphQLSubscription(&ctx, plan, out)
+		assert.NoError(t, err)
+		assert.Equal(t, 2, len(out.flushed))
+		assert.Equal(t, `{"data":{"updateProductPrice":{"upc":"1","price":0}}}`, out.flushed[0])
+		assert.Equal(t, `{"data":{"updateProductPrice":{"upc":"1","price":2}}}`, out.flushed[1])
+	})
+
+	t.Run("should compare an Int variable with a string value of the subscription filter", func(t *testing.T) {
+		c, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		upcs := []string{"1", "2", "1"}
+		fakeStream := FakeStream(cancel, func(count int) (message string, ok bool) {
+			return fmt.Sprintf(`{"data":{"updateProductPrice":{"upc":"%s"}}}`, upcs[count]), true
+		})
+
+		plan := &GraphQLSubscription{
+			Trigger: GraphQLSubscriptionTrigger{
+				Source: fakeStream,
+			},
+			Response: &GraphQLResponse{
+				Data: &Object{
+					Fields: []*Field{
+						{
+							Name: []byte("updateProductPrice"),
+							Value: &Object{
+								Path: []string{"updateProductPrice"},
+								Fields: []*Field{
+									{
+										Name:  []byte("upc"),
+										Value: &String{Path: []string{"upc"}},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			Filter: &SubscriptionFilter{
+				Conditions: []SubscriptionFilterCondition{
+					{
+						ValuePath:    []string{"updateProductPrice", "upc"},
+						VariablePath: []string{"upc"},
+					},
+				},
+			},
+		}
+		out := &TestFlushWriter{
+			buf: bytes.Buffer{},
+		}
+		resolver := newResolver(c, false, false)
+
+		ctx := Context{
+			ctx:       c,
+			Variables: []byte(`{"upc":2}`),
+		}
+
+		err := resolver.ResolveGraphQLSubscription(&ctx, plan, out)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, len(out.flushed))
+		assert.Equal(t, `{"data":{"updateProductPrice":{"upc":"2"}}}`, out.flushed[0])
+	})
 }
 
 func BenchmarkResolver_ResolveNode(b *testing.B) {
@@ -0,0 +1,651 @@
+package resolve
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/wundergraph/graphql-go-tools/pkg/graphqljsonschema"
+)
+
+const (
+	encodedNodeKindObject       = "object"
+	encodedNodeKindEmptyObject  = "emptyObject"
+	encodedNodeKindArray        = "array"
+	encodedNodeKindEmptyArray   = "emptyArray"
+	encodedNodeKindNull         = "null"
+	encodedNodeKindString       = "string"
+	encodedNodeKindBoolean      = "boolean"
+	encodedNodeKindInteger      = "integer"
+	encodedNodeKindFloat        = "float"
+	encodedNodeKindStaticString = "staticString"
+	encodedNodeKindFieldError   = "fieldError"
+	encodedNodeKindScalar       = "scalar"
+
+	encodedFetchKindSingle   = "single"
+	encodedFetchKindBatch    = "batch"
+	encodedFetchKindParallel = "parallel"
+
+	encodedVariableKindContext = "context"
+	encodedVariableKindObject  = "object"
+	encodedVariableKindHeader  = "header"
+)
+
+// FetchSources provides the data sources of unmarshalled fetches. Data sources can't be encoded,
+// so fetches are encoded with the DataSourceIndex of the data source configuration they were planned for instead.
+type FetchSources interface {
+	DataSource(dataSourceIndex int) (DataSource, error)
+	BatchFactory(dataSourceIndex int) (DataSourceBatchFactory, error)
+}
+
+type encodedResponse struct {
+	Data            *encodedNode     `json:"data"`
+	RenameTypeNames []encodedRenamed `json:"renameTypeNames,omitempty"`
+	Timeout         time.Duration    `json:"timeout,omitempty"`
+}
+
+type encodedRenamed struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+type encodedNode struct {
+	Kind                 string           `json:"kind"`
+	Path                 []string         `json:"path,omitempty"`
+	Nullable             bool             `json:"nullable,omitempty"`
+	Fields               []encodedField   `json:"fields,omitempty"`
+	Fetch                *encodedFetch    `json:"fetch,omitempty"`
+	UnescapeResponseJson bool             `json:"unescapeResponseJson,omitempty"`
+	Deferred             []int            `json:"deferred,omitempty"`
+	Export               *FieldExport     `json:"export,omitempty"`
+	IsTypeName           bool             `json:"isTypeName,omitempty"`
+	EnumValues           []encodedRenamed `json:"enumValues,omitempty"`
+	Value                string           `json:"value,omitempty"`
+	Message              string           `json:"message,omitempty"`
+	ResolveAsynchronous  bool             `json:"resolveAsynchronous,omitempty"`
+	Item                 *encodedNode     `json:"item,omitempty"`
+	Stream               *Stream          `json:"stream,omitempty"`
+	Defer                *Defer           `json:"defer,omitempty"`
+	TypeName             string           `json:"typeName,omitempty"`
+}
+
+type encodedField struct {
+	Name                    string       `json:"name"`
+	Value                   *encodedNode `json:"value"`
+	Position                Position     `json:"position"`
+	Defer                   *DeferField  `json:"defer,omitempty"`
+	Stream                  *StreamField `json:"stream,omitempty"`
+	HasBuffer               bool         `json:"hasBuffer,omitempty"`
+	BufferID                int          `json:"bufferId,omitempty"`
+	OnTypeName              *string      `json:"onTypeName,omitempty"`
+	OnTypeNames             []string     `json:"onTypeNames,omitempty"`
+	SkipDirectiveDefined    bool         `json:"skipDirectiveDefined,omitempty"`
+	SkipVariableName        string       `json:"skipVariableName,omitempty"`
+	IncludeDirectiveDefined bool         `json:"includeDirectiveDefined,omitempty"`
+	IncludeVariableName     string       `json:"includeVariableName,omitempty"`
+	DuplicateResponseKey    bool         `json:"duplicateResponseKey,omitempty"`
+}
+
+type encodedFetch struct {
+	Kind                                  string                `json:"kind"`
+	Fetches                               []encodedFetch        `json:"fetches,omitempty"`
+	DataSourceIndex                       int                   `json:"dataSourceIndex"`
+	BufferId                              int                   `json:"bufferId"`
+	Input                                 string                `json:"input,omitempty"`
+	Variables                             []encodedVariable     `json:"variables,omitempty"`
+	DisallowSingleFlight                  bool                  `json:"disallowSingleFlight,omitempty"`
+	DisableDataLoader                     bool                  `json:"disableDataLoader,omitempty"`
+	InputTemplate                         encodedInputTemplate  `json:"inputTemplate"`
+	TypeName                              string                `json:"typeName,omitempty"`
+	DataSourceIdentifier                  string                `json:"dataSourceIdentifier,omitempty"`
+	ProcessResponseConfig                 ProcessResponseConfig `json:"processResponseConfig"`
+	SetTemplateOutputToNullOnVariableNull bool                  `json:"setTemplateOutputToNullOnVariableNull,omitempty"`
+	OnTypeNames                           []string              `json:"onTypeNames,omitempty"`
+}
+
+type encodedInputTemplate struct {
+	Segments                              []encodedSegment `json:"segments,omitempty"`
+	SetTemplateOutputToNullOnVariableNull bool             `json:"setTemplateOutputToNullOnVariableNull,omitempty"`
+}
+
+type encodedSegment struct {
+	SegmentType        SegmentType      `json:"segmentType"`
+	Data               string           `json:"data,omitempty"`
+	VariableKind       VariableKind     `json:"variableKind,omitempty"`
+	VariableSourcePath []string         `json:"variableSourcePath,omitempty"`
+	Renderer           *encodedRenderer `json:"renderer,omitempty"`
+}
+
+type encodedVariable struct {
+	Kind     string           `json:"kind"`
+	Path     []string         `json:"path"`
+	Renderer *encodedRenderer `json:"renderer,omitempty"`
+}
+
+// encodedRenderer is one of the VariableRenderer implementations of the package, identified by its Kind
+type encodedRenderer struct {
+	Kind          string       `json:"kind"`
+	JSONSchema    string       `json:"jsonSchema,omitempty"`
+	RootValueType JsonRootType `json:"rootValueType"`
+}
+
+// MarshalGraphQLResponse encodes the response plan as JSON, see UnmarshalGraphQLResponse.
+// It returns an error for nodes, variables and renderers implemented outside the package.
+func MarshalGraphQLResponse(response *GraphQLResponse) ([]byte, error) {
+	data, err := encodeNode(response.Data)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(encodedResponse{
+		Data:            data,
+		RenameTypeNames: encodeRenamedTypeNames(response.RenameTypeNames),
+		Timeout:         response.Timeout,
+	})
+}
+
+// UnmarshalGraphQLResponse decodes a response plan encoded by MarshalGraphQLResponse.
+// The data sources of its fetches are provided by the sources, the custom scalars are looked up in the scalars.
+func UnmarshalGraphQLResponse(data []byte, sources FetchSources, scalars *ScalarRegistry) (*GraphQLResponse, error) {
+	var encoded encodedResponse
+	if err := json.Unmarshal(data, &encoded); err != nil {
+		return nil, err
+	}
+	d := responseDecoder{sources: sources, scalars: scalars}
+	node, err := d.decodeNode(encoded.Data)
+	if err != nil {
+		return nil, err
+	}
+	response := &GraphQLResponse{
+		Data:    node,
+		Timeout: encoded.Timeout,
+	}
+	for _, renamed := range encoded.RenameTypeNames {
+		response.RenameTypeNames = append(response.RenameTypeNames, RenameTypeName{From: []byte(renamed.From), To: []byte(renamed.To)})
+	}
+	return response, nil
+}
+
+func encodeRenamedTypeNames(renamed []RenameTypeName) []encodedRenamed {
+	var out []encodedRenamed
+	for i := range renamed {
+		out = append(out, encodedRenamed{From: string(renamed[i].From), To: string(renamed[i].To)})
+	}
+	return out
+}
+
+func encodeNode(node Node) (*encodedNode, error) {
+	switch n := node.(type) {
+	case nil:
+		return nil, nil
+	case *Object:
+		fields := make([]encodedField, 0, len(n.Fields))
+		for _, field := range n.Fields {
+			encoded, err := encodeField(field)
+			if err != nil {
+				return nil, err
+			}
+			fields = append(fields, encoded)
+		}
+		fetch, err := encodeFetch(n.Fetch)
+		if err != nil {
+			return nil, err
+		}
+		return &encodedNode{
+			Kind:                 encodedNodeKindObject,
+			Path:                 n.Path,
+			Nullable:             n.Nullable,
+			Fields:               fields,
+			Fetch:                fetch,
+			UnescapeResponseJson: n.UnescapeResponseJson,
+			Deferred:             n.Deferred,
+		}, nil
+	case *EmptyObject:
+		return &encodedNode{Kind: encodedNodeKindEmptyObject}, nil
+	case *Array:
+		item, err := encodeNode(n.Item)
+		if err != nil {
+			return nil, err
+		}
+		stream := n.Stream
+		return &encodedNode{
+			Kind:                encodedNodeKindArray,
+			Path:                n.Path,
+			Nullable:            n.Nullable,
+			ResolveAsynchronous: n.ResolveAsynchronous,
+			Item:                item,
+			Stream:              &stream,
+		}, nil
+	case *EmptyArray:
+		return &encodedNode{Kind: encodedNodeKindEmptyArray}, nil
+	case *Null:
+		nullDefer := n.Defer
+		return &encodedNode{Kind: encodedNodeKindNull, Defer: &nullDefer}, nil
+	case *String:
+		var enumValues []encodedRenamed
+		for i := range n.EnumValues {
+			enumValues = append(enumValues, encodedRenamed{From: string(n.EnumValues[i].From), To: string(n.EnumValues[i].To)})
+		}
+		return &encodedNode{
+			Kind:                 encodedNodeKindString,
+			Path:                 n.Path,
+			Nullable:             n.Nullable,
+			Export:               n.Export,
+			UnescapeResponseJson: n.UnescapeResponseJson,
+			IsTypeName:           n.IsTypeName,
+			EnumValues:           enumValues,
+		}, nil
+	case *Boolean:
+		return &encodedNode{Kind: encodedNodeKindBoolean, Path: n.Path, Nullable: n.Nullable, Export: n.Export}, nil
+	case *Integer:
+		return &encodedNode{Kind: encodedNodeKindInteger, Path: n.Path, Nullable: n.Nullable, Export: n.Export}, nil
+	case *Float:
+		return &encodedNode{Kind: encodedNodeKindFloat, Path: n.Path, Nullable: n.Nullable, Export: n.Export}, nil
+	case *StaticString:
+		return &encodedNode{Kind: encodedNodeKindStaticString, Value: n.Value}, nil
+	case *FieldError:
+		return &encodedNode{Kind: encodedNodeKindFieldError, Message: n.Message, Nullable: n.Nullable}, nil
+	case *Scalar:
+		return &encodedNode{Kind: encodedNodeKindScalar, Path: n.Path, Nullable: n.Nullable, Export: n.Export, TypeName: n.TypeName}, nil
+	default:
+		return nil, fmt.Errorf("unable to encode node of type %T", node)
+	}
+}
+
+func encodeField(field *Field) (encodedField, error) {
+	value, err := encodeNode(field.Value)
+	if err != nil {
+		return encodedField{}, err
+	}
+	encoded := encodedField{
+		Name:                    string(field.Name),
+		Value:                   value,
+		Position:                field.Position,
+		Defer:                   field.Defer,
+		Stream:                  field.Stream,
+		HasBuffer:               field.HasBuffer,
+		BufferID:                field.BufferID,
+		OnTypeNames:             encodeTypeNames(field.OnTypeNames),
+		SkipDirectiveDefined:    field.SkipDirectiveDefined,
+		SkipVariableName:        field.SkipVariableName,
+		IncludeDirectiveDefined: field.IncludeDirectiveDefined,
+		IncludeVariableName:     field.IncludeVariableName,
+		DuplicateResponseKey:    field.DuplicateResponseKey,
+	}
+	if field.OnTypeName != nil {
+		onTypeName := string(field.OnTypeName)
+		encoded.OnTypeName = &onTypeName
+	}
+	return encoded, nil
+}
+
+func encodeTypeNames(typeNames [][]byte) []string {
+	var out []string
+	for i := range typeNames {
+		out = append(out, string(typeNames[i]))
+	}
+	return out
+}
+
+func encodeFetch(fetch Fetch) (*encodedFetch, error) {
+	switch f := fetch.(type) {
+	case nil:
+		return nil, nil
+	case *SingleFetch:
+		return encodeSingleFetch(f)
+	case *BatchFetch:
+		encoded, err := encodeSingleFetch(f.Fetch)
+		if err != nil {
+			return nil, err
+		}
+		encoded.Kind = encodedFetchKindBatch
+		return encoded, nil
+	case *ParallelFetch:
+		encoded := &encodedFetch{Kind: encodedFetchKindParallel}
+		for i := range f.Fetches {
+			parallel, err := encodeFetch(f.Fetches[i])
+			if err != nil {
+				return nil, err
+			}
+			encoded.Fetches = append(encoded.Fetches, *parallel)
+		}
+		return encoded, nil
+	default:
+		return nil, fmt.Errorf("unable to encode fetch of type %T", fetch)
+	}
+}
+
+func encodeSingleFetch(fetch *SingleFetch) (*encodedFetch, error) {
+	encoded := &encodedFetch{
+		Kind:                                  encodedFetchKindSingle,
+		DataSourceIndex:                       fetch.DataSourceIndex,
+		BufferId:                              fetch.BufferId,
+		Input:                                 fetch.Input,
+		DisallowSingleFlight:                  fetch.DisallowSingleFlight,
+		DisableDataLoader:                     fetch.DisableDataLoader,
+		TypeName:                              string(fetch.TypeName),
+		DataSourceIdentifier:                  string(fetch.DataSourceIdentifier),
+		ProcessResponseConfig:                 fetch.ProcessResponseConfig,
+		SetTemplateOutputToNullOnVariableNull: fetch.SetTemplateOutputToNullOnVariableNull,
+		OnTypeNames:                           encodeTypeNames(fetch.OnTypeNames),
+		InputTemplate: encodedInputTemplate{
+			SetTemplateOutputToNullOnVariableNull: fetch.InputTemplate.SetTemplateOutputToNullOnVariableNull,
+		},
+	}
+	for _, variable := range fetch.Variables {
+		encodedVar, err := encodeVariable(variable)
+		if err != nil {
+			return nil, err
+		}
+		encoded.Variables = append(encoded.Variables, encodedVar)
+	}
+	for _, segment := range fetch.InputTemplate.Segments {
+		renderer, err := encodeRenderer(segment.Renderer)
+		if err != nil {
+			return nil, err
+		}
+		encoded.InputTemplate.Segments = append(encoded.InputTemplate.Segments, encodedSegment{
+			SegmentType:        segment.SegmentType,
+			Data:               string(segment.Data),
+			VariableKind:       segment.VariableKind,
+			VariableSourcePath: segment.VariableSourcePath,
+			Renderer:           renderer,
+		})
+	}
+	return encoded, nil
+}
+
+func encodeVariable(variable Variable) (encodedVariable, error) {
+	var (
+		encoded  encodedVariable
+		renderer VariableRenderer
+	)
+	switch v := variable.(type) {
+	case *ContextVariable:
+		encoded = encodedVariable{Kind: encodedVariableKindContext, Path: v.Path}
+		renderer = v.Renderer
+	case *ObjectVariable:
+		encoded = encodedVariable{Kind: encodedVariableKindObject, Path: v.Path}
+		renderer = v.Renderer
+	case *HeaderVariable:
+		return encodedVariable{Kind: encodedVariableKindHeader, Path: v.Path}, nil
+	default:
+		return encodedVariable{}, fmt.Errorf("unable to encode variable of type %T", variable)
+	}
+	var err error
+	encoded.Renderer, err = encodeRenderer(renderer)
+	return encoded, err
+}
+
+func encodeRenderer(renderer VariableRenderer) (*encodedRenderer, error) {
+	switch r := renderer.(type) {
+	case nil:
+		return nil, nil
+	case *JSONVariableRenderer:
+		return &encodedRenderer{Kind: r.Kind, JSONSchema: r.JSONSchema, RootValueType: r.rootValueType}, nil
+	case *PlainVariableRenderer:
+		return &encodedRenderer{Kind: r.Kind, JSONSchema: r.JSONSchema, RootValueType: r.rootValueType}, nil
+	case *GraphQLVariableRenderer:
+		return &encodedRenderer{Kind: r.Kind, JSONSchema: r.JSONSchema, RootValueType: r.rootValueType}, nil
+	case *CSVVariableRenderer:
+		return &encodedRenderer{Kind: r.Kind, RootValueType: r.arrayValueType}, nil
+	default:
+		return nil, fmt.Errorf("unable to encode variable renderer of type %T", renderer)
+	}
+}
+
+type responseDecoder struct {
+	sources FetchSources
+	scalars *ScalarRegistry
+}
+
+func (d *responseDecoder) decodeNode(encoded *encodedNode) (Node, error) {
+	if encoded == nil {
+		return nil, nil
+	}
+	switch encoded.Kind {
+	case encodedNodeKindObject:
+		object := &Object{
+			Nullable:             encoded.Nullable,
+			Path:                 encoded.Path,
+			Fields:               make([]*Field, 0, len(encoded.Fields)),
+			UnescapeResponseJson: encoded.UnescapeResponseJson,
+			Deferred:             encoded.Deferred,
+		}
+		for i := range encoded.Fields {
+			field, err := d.decodeField(&encoded.Fields[i])
+			if err != nil {
+				return nil, err
+			}
+			object.Fields = append(object.Fields, field)
+		}
+		fetch, err := d.decodeFetch(encoded.Fetch)
+		if err != nil {
+			return nil, err
+		}
+		object.Fetch = fetch
+		return object, nil
+	case encodedNodeKindEmptyObject:
+		return &EmptyObject{}, nil
+	case encodedNodeKindArray:
+		item, err := d.decodeNode(encoded.Item)
+		if err != nil {
+			return nil, err
+		}
+		array := &Array{
+			Path:                encoded.Path,
+			Nullable:            encoded.Nullable,
+			ResolveAsynchronous: encoded.ResolveAsynchronous,
+			Item:                item,
+		}
+		if encoded.Stream != nil {
+			array.Stream = *encoded.Stream
+		}
+		return array, nil
+	case encodedNodeKindEmptyArray:
+		return &EmptyArray{}, nil
+	case encodedNodeKindNull:
+		null := &Null{}
+		if encoded.Defer != nil {
+			null.Defer = *encoded.Defer
+		}
+		return null, nil
+	case encodedNodeKindString:
+		str := &String{
+			Path:                 encoded.Path,
+			Nullable:             encoded.Nullable,
+			Export:               encoded.Export,
+			UnescapeResponseJson: encoded.UnescapeResponseJson,
+			IsTypeName:           encoded.IsTypeName,
+		}
+		for _, enumValue := range encoded.EnumValues {
+			str.EnumValues = append(str.EnumValues, RenameEnumValue{From: []byte(enumValue.From), To: []byte(enumValue.To)})
+		}
+		return str, nil
+	case encodedNodeKindBoolean:
+		return &Boolean{Path: encoded.Path, Nullable: encoded.Nullable, Export: encoded.Export}, nil
+	case encodedNodeKindInteger:
+		return &Integer{Path: encoded.Path, Nullable: encoded.Nullable, Export: encoded.Export}, nil
+	case encodedNodeKindFloat:
+		return &Float{Path: encoded.Path, Nullable: encoded.Nullable, Export: encoded.Export}, nil
+	case encodedNodeKindStaticString:
+		return &StaticString{Value: encoded.Value}, nil
+	case encodedNodeKindFieldError:
+		return &FieldError{Message: encoded.Message, Nullable: encoded.Nullable}, nil
+	case encodedNodeKindScalar:
+		scalar, ok := d.scalars.Scalar(encoded.TypeName)
+		if !ok {
+			return nil, fmt.Errorf("custom scalar %s is not registered", encoded.TypeName)
+		}
+		return &Scalar{
+			Path:     encoded.Path,
+			Nullable: encoded.Nullable,
+			Export:   encoded.Export,
+			TypeName: encoded.TypeName,
+			Scalar:   scalar,
+		}, nil
+	default:
+		return nil, fmt.Errorf("unknown node kind %q", encoded.Kind)
+	}
+}
+
+func (d *responseDecoder) decodeField(encoded *encodedField) (*Field, error) {
+	value, err := d.decodeNode(encoded.Value)
+	if err != nil {
+		return nil, err
+	}
+	field := &Field{
+		Name:                    []byte(encoded.Name),
+		Value:                   value,
+		Position:                encoded.Position,
+		Defer:                   encoded.Defer,
+		Stream:                  encoded.Stream,
+		HasBuffer:               encoded.HasBuffer,
+		BufferID:                encoded.BufferID,
+		OnTypeNames:             decodeTypeNames(encoded.OnTypeNames),
+		SkipDirectiveDefined:    encoded.SkipDirectiveDefined,
+		SkipVariableName:        encoded.SkipVariableName,
+		IncludeDirectiveDefined: encoded.IncludeDirectiveDefined,
+		IncludeVariableName:     encoded.IncludeVariableName,
+		DuplicateResponseKey:    encoded.DuplicateResponseKey,
+	}
+	if encoded.OnTypeName != nil {
+		field.OnTypeName = []byte(*encoded.OnTypeName)
+	}
+	return field, nil
+}
+
+func decodeTypeNames(typeNames []string) [][]byte {
+	var out [][]byte
+	for i := range typeNames {
+		out = append(out, []byte(typeNames[i]))
+	}
+	return out
+}
+
+func (d *responseDecoder) decodeFetch(encoded *encodedFetch) (Fetch, error) {
+	if encoded == nil {
+		return nil, nil
+	}
+	switch encoded.Kind {
+	case encodedFetchKindSingle:
+		return d.decodeSingleFetch(encoded)
+	case encodedFetchKindBatch:
+		fetch, err := d.decodeSingleFetch(encoded)
+		if err != nil {
+			return nil, err
+		}
+		batchFactory, err := d.sources.BatchFactory(encoded.DataSourceIndex)
+		if err != nil {
+			return nil, err
+		}
+		return &BatchFetch{Fetch: fetch, BatchFactory: batchFactory}, nil
+	case encodedFetchKindParallel:
+		parallel := &ParallelFetch{}
+		for i := range encoded.Fetches {
+			fetch, err := d.decodeFetch(&encoded.Fetches[i])
+			if err != nil {
+				return nil, err
+			}
+			parallel.Fetches = append(parallel.Fetches, fetch)
+		}
+		return parallel, nil
+	default:
+		return nil, fmt.Errorf("unknown fetch kind %q", encoded.Kind)
+	}
+}
+
+func (d *responseDecoder) decodeSingleFetch(encoded *encodedFetch) (*SingleFetch, error) {
+	dataSource, err := d.sources.DataSource(encoded.DataSourceIndex)
+	if err != nil {
+		return nil, err
+	}
+	fetch := &SingleFetch{
+		BufferId:                              encoded.BufferId,
+		Input:                                 encoded.Input,
+		DataSource:                            dataSource,
+		DataSourceIndex:                       encoded.DataSourceIndex,
+		DisallowSingleFlight:                  encoded.DisallowSingleFlight,
+		DisableDataLoader:                     encoded.DisableDataLoader,
+		ProcessResponseConfig:                 encoded.ProcessResponseConfig,
+		SetTemplateOutputToNullOnVariableNull: encoded.SetTemplateOutputToNullOnVariableNull,
+		OnTypeNames:                           decodeTypeNames(encoded.OnTypeNames),
+		InputTemplate: InputTemplate{
+			SetTemplateOutputToNullOnVariableNull: encoded.InputTemplate.SetTemplateOutputToNullOnVariableNull,
+		},
+	}
+	if encoded.TypeName != "" {
+		fetch.TypeName = []byte(encoded.TypeName)
+	}
+	if encoded.DataSourceIdentifier != "" {
+		fetch.DataSourceIdentifier = []byte(encoded.DataSourceIdentifier)
+	}
+	for i := range encoded.Variables {
+		variable, err := decodeVariable(&encoded.Variables[i])
+		if err != nil {
+			return nil, err
+		}
+		fetch.Variables = append(fetch.Variables, variable)
+	}
+	for _, segment := range encoded.InputTemplate.Segments {
+		renderer, err := decodeRenderer(segment.Renderer)
+		if err != nil {
+			return nil, err
+		}
+		decoded := TemplateSegment{
+			SegmentType:        segment.SegmentType,
+			VariableKind:       segment.VariableKind,
+			VariableSourcePath: segment.VariableSourcePath,
+			Renderer:           renderer,
+		}
+		if segment.Data != "" {
+			decoded.Data = []byte(segment.Data)
+		}
+		fetch.InputTemplate.Segments = append(fetch.InputTemplate.Segments, decoded)
+	}
+	return fetch, nil
+}
+
+func decodeVariable(encoded *encodedVariable) (Variable, error) {
+	renderer, err := decodeRenderer(encoded.Renderer)
+	if err != nil {
+		return nil, err
+	}
+	switch encoded.Kind {
+	case encodedVariableKindContext:
+		return &ContextVariable{Path: encoded.Path, Renderer: renderer}, nil
+	case encodedVariableKindObject:
+		return &ObjectVariable{Path: encoded.Path, Renderer: renderer}, nil
+	case encodedVariableKindHeader:
+		return &HeaderVariable{Path: encoded.Path}, nil
+	default:
+		return nil, fmt.Errorf("unknown variable kind %q", encoded.Kind)
+	}
+}
+
+func decodeRenderer(encoded *encodedRenderer) (VariableRenderer, error) {
+	if encoded == nil {
+		return nil, nil
+	}
+	var (
+		validator *graphqljsonschema.Validator
+		err       error
+	)
+	if encoded.JSONSchema != "" {
+		validator, err = graphqljsonschema.NewValidatorFromString(encoded.JSONSchema)
+		if err != nil {
+			return nil, err
+		}
+	}
+	switch encoded.Kind {
+	case VariableRendererKindJson, VariableRendererKindJsonWithValidation:
+		return &JSONVariableRenderer{Kind: encoded.Kind, JSONSchema: encoded.JSONSchema, validator: validator, rootValueType: encoded.RootValueType}, nil
+	case VariableRendererKindPlain, VariableRendererKindPlanWithValidation:
+		return &PlainVariableRenderer{Kind: encoded.Kind, JSONSchema: encoded.JSONSchema, validator: validator, rootValueType: encoded.RootValueType}, nil
+	case VariableRendererKindGraphqlWithValidation:
+		return &GraphQLVariableRenderer{Kind: encoded.Kind, JSONSchema: encoded.JSONSchema, validator: validator, rootValueType: encoded.RootValueType}, nil
+	case VariableRendererKindCsv:
+		return &CSVVariableRenderer{Kind: encoded.Kind, arrayValueType: encoded.RootValueType}, nil
+	default:
+		return nil, fmt.Errorf("unknown variable renderer kind %q", encoded.Kind)
+	}
+}
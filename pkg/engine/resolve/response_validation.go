@@ -0,0 +1,114 @@
+package resolve
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/buger/jsonparser"
+)
+
+// maxViolationValueLength limits the length of the values quoted in the messages of violations
+const maxViolationValueLength = 64
+
+// ResponseValidation validates the values of the responses of data sources against the types of the fields
+// of the operation while they get resolved, e.g. to catch subgraphs returning data which violates their own schema
+// during development: values of the wrong type and null or missing values of non-nullable fields.
+// The violations get added to the "responseValidation" field of the extensions of the response,
+// the response itself is resolved as without validation.
+// It's expensive as every value gets looked up twice, so it should not be enabled in production.
+// ResponseValidation is request scoped and must not be shared across requests.
+type ResponseValidation struct {
+	mu         sync.Mutex
+	violations []ResponseViolation
+}
+
+// ResponseViolation is a value of the response of a data source which doesn't match the type of its field
+type ResponseViolation struct {
+	// Path is the path of the field in the response, e.g. /data/me/age
+	Path    string `json:"path"`
+	Message string `json:"message"`
+}
+
+// NewResponseValidation creates a validation for the responses of a single request
+func NewResponseValidation() *ResponseValidation {
+	return &ResponseValidation{}
+}
+
+// Violations returns the violations in the order they were found
+func (v *ResponseValidation) Violations() []ResponseViolation {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	violations := make([]ResponseViolation, len(v.violations))
+	copy(violations, v.violations)
+	return violations
+}
+
+func (v *ResponseValidation) add(path, message string) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	v.violations = append(v.violations, ResponseViolation{Path: path, Message: message})
+}
+
+// extensions adds the violations to the "responseValidation" field of the extensions of the response
+func (v *ResponseValidation) extensions(extensions map[string]json.RawMessage) error {
+	violations := v.Violations()
+	if len(violations) == 0 {
+		return nil
+	}
+	value, err := json.Marshal(violations)
+	if err != nil {
+		return err
+	}
+	extensions["responseValidation"] = value
+	return nil
+}
+
+// SetResponseValidation enables the validation of the responses of data sources, see ResponseValidation
+func (c *Context) SetResponseValidation(validation *ResponseValidation) {
+	c.responseValidation = validation
+}
+
+// validateValue adds a violation if the value at the path in data doesn't match the type of the current field.
+// typeName describes the type of the field, e.g. Int, and valueType is the JSON type of its values.
+func (c *Context) validateValue(data []byte, path []string, typeName string, nullable bool, valueType jsonparser.ValueType) {
+	if c.responseValidation == nil {
+		return
+	}
+
+	expected := typeName
+	if !nullable {
+		expected = "non-null " + typeName
+	}
+
+	value, actualType, _, err := jsonparser.Get(data, path...)
+	switch {
+	case err != nil:
+		if !nullable {
+			c.responseValidation.add(string(c.path()), fmt.Sprintf("expected %s, got no value", expected))
+		}
+	case actualType == jsonparser.Null:
+		if !nullable {
+			c.responseValidation.add(string(c.path()), fmt.Sprintf("expected %s, got null", expected))
+		}
+	case actualType != valueType:
+		c.responseValidation.add(string(c.path()), fmt.Sprintf("expected %s, got %s", expected, describeValue(value, actualType)))
+	}
+}
+
+// describeValue describes a JSON value for the message of a violation, e.g. string "abc"
+func describeValue(value []byte, valueType jsonparser.ValueType) string {
+	switch valueType {
+	case jsonparser.Object, jsonparser.Array:
+		return valueType.String()
+	}
+	if len(value) > maxViolationValueLength {
+		value = append(value[:maxViolationValueLength:maxViolationValueLength], "..."...)
+	}
+	if valueType == jsonparser.String {
+		return fmt.Sprintf("string %q", value)
+	}
+	return fmt.Sprintf("%s %s", valueType, value)
+}
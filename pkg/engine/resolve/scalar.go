@@ -0,0 +1,101 @@
+package resolve
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/buger/jsonparser"
+)
+
+// errInvalidScalarValue is returned for non-nullable scalars with a value the custom scalar fails to serialize,
+// the scalar added an error for the value already
+var errInvalidScalarValue = fmt.Errorf("%w: invalid scalar value", errNonNullableFieldValueIsNull)
+
+// CustomScalar implements input coercion and result serialization of a custom scalar type.
+// Both functions receive and return JSON values, a nil function passes values through unchanged.
+type CustomScalar struct {
+	// ParseValue coerces an input value, e.g. a variable, it returns an error for invalid values
+	ParseValue func(value []byte) ([]byte, error)
+	// Serialize coerces a result value received from a data source, it returns an error for invalid values
+	Serialize func(value []byte) ([]byte, error)
+}
+
+// ScalarRegistry holds the custom scalars of a schema, keyed by the name of the scalar type
+type ScalarRegistry struct {
+	scalars map[string]CustomScalar
+}
+
+func NewScalarRegistry() *ScalarRegistry {
+	return &ScalarRegistry{
+		scalars: map[string]CustomScalar{},
+	}
+}
+
+func (s *ScalarRegistry) Register(typeName string, scalar CustomScalar) *ScalarRegistry {
+	s.scalars[typeName] = scalar
+	return s
+}
+
+func (s *ScalarRegistry) Scalar(typeName string) (scalar CustomScalar, ok bool) {
+	if s == nil {
+		return CustomScalar{}, false
+	}
+	scalar, ok = s.scalars[typeName]
+	return scalar, ok
+}
+
+// Scalar is the value of a registered custom scalar, it gets serialized by the custom scalar
+type Scalar struct {
+	Path     []string
+	Nullable bool
+	Export   *FieldExport `json:"export,omitempty"`
+	TypeName string
+	Scalar   CustomScalar `json:"-"`
+}
+
+func (_ *Scalar) NodeKind() NodeKind {
+	return NodeKindScalar
+}
+
+func (r *Resolver) resolveScalar(ctx *Context, scalar *Scalar, data []byte, scalarBuf *BufPair) error {
+	value, valueType, _, err := jsonparser.Get(data, scalar.Path...)
+	if err != nil || valueType == jsonparser.Null {
+		if !scalar.Nullable {
+			return errNonNullableFieldValueIsNull
+		}
+		r.resolveNull(scalarBuf.Data)
+		return nil
+	}
+
+	if valueType == jsonparser.String {
+		// jsonparser strips the quotes of string values
+		value = append(append([]byte{'"'}, value...), '"')
+	}
+
+	if scalar.Scalar.Serialize != nil {
+		value, err = scalar.Scalar.Serialize(value)
+		if err != nil {
+			// like for other field errors the field resolves to null instead of failing the response
+			r.addError(ctx, scalarBuf, invalidScalarValueMessage(scalar.TypeName, err))
+			if !scalar.Nullable {
+				return errInvalidScalarValue
+			}
+			r.resolveNull(scalarBuf.Data)
+			return nil
+		}
+	}
+
+	scalarBuf.Data.WriteBytes(value)
+	if scalar.Export != nil && scalar.Export.AsString && len(value) > 1 && value[0] == '"' {
+		// exportField quotes the value itself
+		value = value[1 : len(value)-1]
+	}
+	r.exportField(ctx, scalar.Export, value)
+	return nil
+}
+
+// invalidScalarValueMessage returns the error message for a value failing to serialize as JSON string content
+func invalidScalarValueMessage(typeName string, err error) []byte {
+	message, _ := json.Marshal(fmt.Sprintf("Invalid value for scalar %s: %s", typeName, err))
+	return message[1 : len(message)-1]
+}
@@ -0,0 +1,75 @@
+package resolve
+
+import (
+	"bytes"
+
+	"github.com/buger/jsonparser"
+)
+
+// SubscriptionFilter skips the events of a subscription which don't match all of its conditions,
+// e.g. the price updates of other products than the one a client subscribed to
+type SubscriptionFilter struct {
+	Conditions []SubscriptionFilterCondition
+}
+
+// SubscriptionFilterCondition matches the events with a value at ValuePath equal to the value of the variable
+// at VariablePath, or equal to Value if the value is static.
+// An event matches if the variable is undefined or null, so an omitted argument doesn't filter events.
+// Strings and numbers are compared by their text, because an ID is serialized as a string but accepts Int input,
+// e.g. the argument upc: 1 matches the event value "1".
+type SubscriptionFilterCondition struct {
+	// ValuePath is the path of the value in the data of the event
+	ValuePath    []string
+	VariablePath []string
+	// Value is the JSON value to compare with if the condition has no VariablePath
+	Value []byte
+}
+
+// skip reports whether the event doesn't match the filter and must not be sent to the client
+func (f *SubscriptionFilter) skip(ctx *Context, event []byte) bool {
+	for i := range f.Conditions {
+		if !f.Conditions[i].matches(ctx, event) {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *SubscriptionFilterCondition) matches(ctx *Context, event []byte) bool {
+	expected, expectedType := c.Value, jsonparser.NotExist
+	if len(c.VariablePath) != 0 {
+		expected, expectedType, _, _ = jsonparser.Get(ctx.Variables, c.VariablePath...)
+	} else if len(c.Value) != 0 {
+		expected, expectedType, _, _ = jsonparser.Get(c.Value)
+	}
+	if expectedType == jsonparser.NotExist || expectedType == jsonparser.Null {
+		return true
+	}
+	actual, actualType, _, err := jsonparser.Get(event, append([]string{"data"}, c.ValuePath...)...)
+	if err != nil {
+		return false
+	}
+	if actualType == expectedType {
+		return bytes.Equal(actual, expected)
+	}
+	if !isScalarText(actualType) || !isScalarText(expectedType) {
+		return false
+	}
+	return scalarText(actual, actualType) == scalarText(expected, expectedType)
+}
+
+func isScalarText(valueType jsonparser.ValueType) bool {
+	return valueType == jsonparser.String || valueType == jsonparser.Number
+}
+
+// scalarText returns the unescaped text of a string or the literal of a number
+func scalarText(value []byte, valueType jsonparser.ValueType) string {
+	if valueType != jsonparser.String {
+		return string(value)
+	}
+	text, err := jsonparser.ParseString(value)
+	if err != nil {
+		return string(value)
+	}
+	return text
+}
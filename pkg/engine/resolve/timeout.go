@@ -0,0 +1,72 @@
+package resolve
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+// operationTimeout is the deadline of an operation which defines a timeout, e.g. with the @timeout directive.
+// It's shared by all copies of the Context resolving the operation.
+type operationTimeout struct {
+	ctx      context.Context
+	timeout  time.Duration
+	exceeded int32
+}
+
+// SetMaxOperationTimeout sets the upper bound for timeouts defined by operations.
+// Longer timeouts are clamped to max, a max of 0 disables clamping.
+func (c *Context) SetMaxOperationTimeout(max time.Duration) {
+	c.maxOperationTimeout = max
+}
+
+// SetRequestTimeout sets the deadline for resolving the response of the operation, regardless of the timeout defined by
+// the operation. Fetches cut off by it resolve to null like for operation timeouts, a timeout of 0 disables it.
+func (c *Context) SetRequestTimeout(timeout time.Duration) {
+	c.requestTimeout = timeout
+}
+
+// withOperationTimeout applies the timeout of the operation, or the shorter request timeout,
+// as deadline for resolving the response
+func (c *Context) withOperationTimeout(timeout time.Duration) (*Context, func()) {
+	if c.operationTimeout != nil {
+		// the response is resolved as part of an operation which has a deadline already
+		return c, func() {}
+	}
+	if c.maxOperationTimeout > 0 && timeout > c.maxOperationTimeout {
+		timeout = c.maxOperationTimeout
+	}
+	if c.requestTimeout > 0 && (timeout <= 0 || timeout > c.requestTimeout) {
+		timeout = c.requestTimeout
+	}
+	if timeout <= 0 {
+		return c, func() {}
+	}
+
+	operationCtx, cancel := context.WithTimeout(c.ctx, timeout)
+	cpy := c.WithContext(operationCtx)
+	cpy.operationTimeout = &operationTimeout{
+		ctx:     operationCtx,
+		timeout: timeout,
+	}
+	return cpy, cancel
+}
+
+// fetchTimedOut reports whether err is caused by exceeding the operation timeout.
+// Fetches cut off by the operation timeout don't fail the response, their fields resolve to null instead.
+func (c *Context) fetchTimedOut(err error) bool {
+	if err == nil || c.operationTimeout == nil || c.operationTimeout.ctx.Err() == nil {
+		return false
+	}
+	atomic.StoreInt32(&c.operationTimeout.exceeded, 1)
+	return true
+}
+
+// writeOperationTimeoutError adds an error to the response if fetches were cut off by the operation timeout
+func (c *Context) writeOperationTimeoutError(buf *BufPair) {
+	if c.operationTimeout == nil || atomic.LoadInt32(&c.operationTimeout.exceeded) == 0 {
+		return
+	}
+	buf.WriteErr([]byte(fmt.Sprintf("operation timed out after %s", c.operationTimeout.timeout)), nil, nil, nil)
+}
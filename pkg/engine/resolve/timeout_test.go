@@ -0,0 +1,131 @@
+package resolve
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolver_OperationTimeout(t *testing.T) {
+	singleFetch := func(bufferID int, dataSource DataSource) *SingleFetch {
+		return &SingleFetch{
+			BufferId:   bufferID,
+			DataSource: dataSource,
+			InputTemplate: InputTemplate{
+				Segments: []TemplateSegment{
+					{
+						SegmentType: StaticSegmentType,
+						Data:        []byte(`{}`),
+					},
+				},
+			},
+		}
+	}
+	stringField := func(bufferID int, name string, nullable bool) *Field {
+		return &Field{
+			HasBuffer: true,
+			BufferID:  bufferID,
+			Name:      []byte(name),
+			Value: &String{
+				Path:     []string{name},
+				Nullable: nullable,
+			},
+		}
+	}
+	resolve := func(t *testing.T, ctx *Context, response *GraphQLResponse) string {
+		rCtx, cancelResolver := context.WithCancel(context.Background())
+		defer cancelResolver()
+		resolver := newResolver(rCtx, false, false)
+
+		buf := &bytes.Buffer{}
+		require.NoError(t, resolver.ResolveGraphQLResponse(ctx, response, nil, buf))
+		return buf.String()
+	}
+
+	t.Run("cuts off slow fetch and returns partial data", func(t *testing.T) {
+		fast := &deadlineDataSource{data: `{"fast":"fast"}`, latency: 10 * time.Millisecond}
+		slow := &deadlineDataSource{data: `{"slow":"slow"}`, latency: 100 * time.Millisecond}
+
+		response := &GraphQLResponse{
+			Timeout: 50 * time.Millisecond,
+			Data: &Object{
+				Fetch: &ParallelFetch{
+					Fetches: []Fetch{
+						singleFetch(0, fast),
+						singleFetch(1, slow),
+					},
+				},
+				Fields: []*Field{
+					stringField(0, "fast", true),
+					stringField(1, "slow", true),
+				},
+			},
+		}
+
+		out := resolve(t, NewContext(context.Background()), response)
+		assert.Equal(t, `{"errors":[{"message":"operation timed out after 50ms"}],"data":{"fast":"fast","slow":null}}`, out)
+		assert.NoError(t, fast.loadErr)
+		assert.ErrorIs(t, slow.loadErr, context.DeadlineExceeded)
+	})
+
+	t.Run("cut off single fetch doesn't fail the response", func(t *testing.T) {
+		slow := &deadlineDataSource{data: `{"slow":"slow"}`, latency: 100 * time.Millisecond}
+
+		response := &GraphQLResponse{
+			Timeout: 50 * time.Millisecond,
+			Data: &Object{
+				Fetch: singleFetch(0, slow),
+				Fields: []*Field{
+					stringField(0, "slow", false),
+				},
+			},
+		}
+
+		out := resolve(t, NewContext(context.Background()), response)
+		assert.Equal(t, `{"errors":[{"message":"unable to resolve","locations":[{"line":0,"column":0}]},{"message":"operation timed out after 50ms"}],"data":null}`, out)
+	})
+
+	t.Run("timeout is clamped to max", func(t *testing.T) {
+		slow := &deadlineDataSource{data: `{"slow":"slow"}`, latency: 5 * time.Second}
+
+		response := &GraphQLResponse{
+			Timeout: 10 * time.Second,
+			Data: &Object{
+				Fetch: singleFetch(0, slow),
+				Fields: []*Field{
+					stringField(0, "slow", true),
+				},
+			},
+		}
+
+		ctx := NewContext(context.Background())
+		ctx.SetMaxOperationTimeout(50 * time.Millisecond)
+
+		start := time.Now()
+		out := resolve(t, ctx, response)
+		assert.Less(t, int64(time.Since(start)), int64(time.Second))
+		assert.Equal(t, `{"errors":[{"message":"operation timed out after 50ms"}],"data":{"slow":null}}`, out)
+	})
+
+	t.Run("no error when fetches complete in time", func(t *testing.T) {
+		fast := &deadlineDataSource{data: `{"fast":"fast"}`, latency: 10 * time.Millisecond}
+
+		response := &GraphQLResponse{
+			Timeout: time.Second,
+			Data: &Object{
+				Fetch: singleFetch(0, fast),
+				Fields: []*Field{
+					stringField(0, "fast", true),
+				},
+			},
+		}
+
+		out := resolve(t, NewContext(context.Background()), response)
+		assert.Equal(t, `{"data":{"fast":"fast"}}`, out)
+		assert.True(t, fast.timeout > 0 && fast.timeout <= time.Second)
+	})
+}
@@ -0,0 +1,396 @@
+package federation
+
+import (
+	"fmt"
+
+	"github.com/wundergraph/graphql-go-tools/pkg/ast"
+	"github.com/wundergraph/graphql-go-tools/pkg/astparser"
+	"github.com/wundergraph/graphql-go-tools/pkg/astprinter"
+)
+
+const (
+	inaccessibleDirectiveName = "inaccessible"
+	tagDirectiveName          = "tag"
+	tagDirectiveNameArgument  = "name"
+	sourceFieldDirectiveName  = "sourceField"
+	deprecatedDirectiveName   = "deprecated"
+)
+
+// BuildPublicSchemaDocument takes a merged base schema and turns it into the schema exposed to clients.
+// All types, fields, arguments, input fields and enum values marked with @inaccessible get removed.
+// If includeTags are given, only fields carrying one of the tags or belonging to a type carrying one of the tags are kept.
+// Object and interface types without remaining fields get removed as well.
+// It returns an error if a remaining field, argument or input field refers to a removed type.
+// The base schema itself is not modified and must still be used for planning.
+func BuildPublicSchemaDocument(baseSchema string, includeTags ...string) (string, error) {
+	return BuildPublicSchemaDocumentWithOptions(baseSchema, PublicSchemaOptions{IncludeTags: includeTags})
+}
+
+// PublicSchemaOptions configures the public schema built by BuildPublicSchemaDocumentWithOptions
+type PublicSchemaOptions struct {
+	// IncludeTags restricts the public schema to tagged fields and types, see BuildPublicSchemaDocument
+	IncludeTags []string
+	// ExposedDirectives are the directives whose usages are kept in the public schema besides @deprecated,
+	// so introspection and the SDL of the public schema don't reveal internal directives like @key or @external.
+	// If empty, the usages of all directives but @inaccessible, @tag and @sourceField are kept.
+	ExposedDirectives []string
+}
+
+// BuildPublicSchemaDocumentWithOptions works like BuildPublicSchemaDocument,
+// it additionally removes the usages of directives which aren't exposed by the options.
+func BuildPublicSchemaDocumentWithOptions(baseSchema string, options PublicSchemaOptions) (string, error) {
+	builder := newPublicSchemaBuilder(options.IncludeTags, options.ExposedDirectives)
+	return builder.buildPublicSchema(baseSchema)
+}
+
+type publicSchemaBuilder struct {
+	doc          *ast.Document
+	includeTags  map[string]struct{}
+	removedTypes map[string]struct{}
+	// exposedDirectives is nil if the usages of all but the federation directives are kept
+	exposedDirectives map[string]struct{}
+}
+
+func newPublicSchemaBuilder(includeTags, exposedDirectives []string) *publicSchemaBuilder {
+	tags := make(map[string]struct{}, len(includeTags))
+	for _, tag := range includeTags {
+		tags[tag] = struct{}{}
+	}
+
+	builder := &publicSchemaBuilder{
+		includeTags:  tags,
+		removedTypes: map[string]struct{}{},
+	}
+	if len(exposedDirectives) > 0 {
+		builder.exposedDirectives = map[string]struct{}{deprecatedDirectiveName: {}}
+		for _, directive := range exposedDirectives {
+			builder.exposedDirectives[directive] = struct{}{}
+		}
+	}
+	return builder
+}
+
+func (p *publicSchemaBuilder) buildPublicSchema(baseSchema string) (string, error) {
+	doc, report := astparser.ParseGraphqlDocumentString(baseSchema)
+	if report.HasErrors() {
+		return "", fmt.Errorf("parse graphql document string: %w", report)
+	}
+	p.doc = &doc
+
+	p.removeInaccessibleTypes()
+	p.removeFields()
+	p.removeInaccessibleInputValuesAndEnumValues()
+	p.removeEmptyTypes()
+	p.removeReferencesToRemovedTypes()
+
+	if err := p.validateTypeReferences(); err != nil {
+		return "", err
+	}
+
+	p.removeDirectives()
+
+	return astprinter.PrintStringIndent(p.doc, nil, "  ")
+}
+
+func (p *publicSchemaBuilder) removeInaccessibleTypes() {
+	for _, node := range p.typeDefinitionNodes() {
+		if p.doc.NodeHasDirectiveByNameString(node, inaccessibleDirectiveName) {
+			p.removeType(node)
+		}
+	}
+}
+
+func (p *publicSchemaBuilder) removeType(node ast.Node) {
+	p.removedTypes[p.doc.NodeNameString(node)] = struct{}{}
+	p.doc.RemoveRootNode(node)
+}
+
+// removeFields removes inaccessible fields and, if tags are configured, fields which are not tagged
+func (p *publicSchemaBuilder) removeFields() {
+	for _, node := range p.typeDefinitionNodes() {
+		switch node.Kind {
+		case ast.NodeKindObjectTypeDefinition:
+			definition := &p.doc.ObjectTypeDefinitions[node.Ref]
+			definition.FieldsDefinition.Refs = p.publicFields(node, definition.FieldsDefinition.Refs)
+			definition.HasFieldDefinitions = len(definition.FieldsDefinition.Refs) > 0
+		case ast.NodeKindInterfaceTypeDefinition:
+			definition := &p.doc.InterfaceTypeDefinitions[node.Ref]
+			definition.FieldsDefinition.Refs = p.publicFields(node, definition.FieldsDefinition.Refs)
+			definition.HasFieldDefinitions = len(definition.FieldsDefinition.Refs) > 0
+		}
+	}
+}
+
+func (p *publicSchemaBuilder) publicFields(parent ast.Node, fieldRefs []int) []int {
+	parentIsTagged := p.hasIncludedTag(p.doc.NodeDirectives(parent))
+
+	public := make([]int, 0, len(fieldRefs))
+	for _, fieldRef := range fieldRefs {
+		directiveRefs := p.doc.FieldDefinitions[fieldRef].Directives.Refs
+		if p.hasDirective(directiveRefs, inaccessibleDirectiveName) {
+			continue
+		}
+		if len(p.includeTags) > 0 && !parentIsTagged && !p.hasIncludedTag(directiveRefs) {
+			continue
+		}
+		public = append(public, fieldRef)
+	}
+	return public
+}
+
+func (p *publicSchemaBuilder) removeInaccessibleInputValuesAndEnumValues() {
+	for _, node := range p.typeDefinitionNodes() {
+		switch node.Kind {
+		case ast.NodeKindObjectTypeDefinition:
+			p.removeInaccessibleArguments(p.doc.ObjectTypeDefinitions[node.Ref].FieldsDefinition.Refs)
+		case ast.NodeKindInterfaceTypeDefinition:
+			p.removeInaccessibleArguments(p.doc.InterfaceTypeDefinitions[node.Ref].FieldsDefinition.Refs)
+		case ast.NodeKindInputObjectTypeDefinition:
+			definition := &p.doc.InputObjectTypeDefinitions[node.Ref]
+			definition.InputFieldsDefinition.Refs = p.accessibleInputValues(definition.InputFieldsDefinition.Refs)
+			definition.HasInputFieldsDefinition = len(definition.InputFieldsDefinition.Refs) > 0
+		case ast.NodeKindEnumTypeDefinition:
+			definition := &p.doc.EnumTypeDefinitions[node.Ref]
+			accessible := make([]int, 0, len(definition.EnumValuesDefinition.Refs))
+			for _, enumValueRef := range definition.EnumValuesDefinition.Refs {
+				if !p.hasDirective(p.doc.EnumValueDefinitions[enumValueRef].Directives.Refs, inaccessibleDirectiveName) {
+					accessible = append(accessible, enumValueRef)
+				}
+			}
+			definition.EnumValuesDefinition.Refs = accessible
+			definition.HasEnumValuesDefinition = len(accessible) > 0
+		}
+	}
+}
+
+func (p *publicSchemaBuilder) removeInaccessibleArguments(fieldRefs []int) {
+	for _, fieldRef := range fieldRefs {
+		definition := &p.doc.FieldDefinitions[fieldRef]
+		definition.ArgumentsDefinition.Refs = p.accessibleInputValues(definition.ArgumentsDefinition.Refs)
+		definition.HasArgumentsDefinitions = len(definition.ArgumentsDefinition.Refs) > 0
+	}
+}
+
+func (p *publicSchemaBuilder) accessibleInputValues(inputValueRefs []int) []int {
+	accessible := make([]int, 0, len(inputValueRefs))
+	for _, inputValueRef := range inputValueRefs {
+		if !p.hasDirective(p.doc.InputValueDefinitions[inputValueRef].Directives.Refs, inaccessibleDirectiveName) {
+			accessible = append(accessible, inputValueRef)
+		}
+	}
+	return accessible
+}
+
+// removeEmptyTypes removes object and interface types which have no fields left,
+// the root operation types are removed as well if all of their fields are removed.
+func (p *publicSchemaBuilder) removeEmptyTypes() {
+	for _, node := range p.typeDefinitionNodes() {
+		switch node.Kind {
+		case ast.NodeKindObjectTypeDefinition:
+			if !p.doc.ObjectTypeDefinitions[node.Ref].HasFieldDefinitions {
+				p.removeType(node)
+			}
+		case ast.NodeKindInterfaceTypeDefinition:
+			if !p.doc.InterfaceTypeDefinitions[node.Ref].HasFieldDefinitions {
+				p.removeType(node)
+			}
+		}
+	}
+}
+
+// removeReferencesToRemovedTypes removes removed types from union members and implemented interfaces,
+// unions without remaining members get removed.
+func (p *publicSchemaBuilder) removeReferencesToRemovedTypes() {
+	for _, node := range p.typeDefinitionNodes() {
+		switch node.Kind {
+		case ast.NodeKindObjectTypeDefinition:
+			definition := &p.doc.ObjectTypeDefinitions[node.Ref]
+			definition.ImplementsInterfaces.Refs = p.typeRefsWithoutRemovedTypes(definition.ImplementsInterfaces.Refs)
+		case ast.NodeKindUnionTypeDefinition:
+			definition := &p.doc.UnionTypeDefinitions[node.Ref]
+			definition.UnionMemberTypes.Refs = p.typeRefsWithoutRemovedTypes(definition.UnionMemberTypes.Refs)
+			definition.HasUnionMemberTypes = len(definition.UnionMemberTypes.Refs) > 0
+			if !definition.HasUnionMemberTypes {
+				p.removeType(node)
+			}
+		}
+	}
+}
+
+func (p *publicSchemaBuilder) typeRefsWithoutRemovedTypes(typeRefs []int) []int {
+	remaining := make([]int, 0, len(typeRefs))
+	for _, typeRef := range typeRefs {
+		if !p.isRemovedType(typeRef) {
+			remaining = append(remaining, typeRef)
+		}
+	}
+	return remaining
+}
+
+func (p *publicSchemaBuilder) validateTypeReferences() error {
+	for _, node := range p.typeDefinitionNodes() {
+		typeName := p.doc.NodeNameString(node)
+		switch node.Kind {
+		case ast.NodeKindObjectTypeDefinition:
+			if err := p.validateFields(typeName, p.doc.ObjectTypeDefinitions[node.Ref].FieldsDefinition.Refs); err != nil {
+				return err
+			}
+		case ast.NodeKindInterfaceTypeDefinition:
+			if err := p.validateFields(typeName, p.doc.InterfaceTypeDefinitions[node.Ref].FieldsDefinition.Refs); err != nil {
+				return err
+			}
+		case ast.NodeKindInputObjectTypeDefinition:
+			for _, inputValueRef := range p.doc.InputObjectTypeDefinitions[node.Ref].InputFieldsDefinition.Refs {
+				if p.isRemovedType(p.doc.InputValueDefinitions[inputValueRef].Type) {
+					return fmt.Errorf("input field \"%s.%s\" is of inaccessible type \"%s\"",
+						typeName, p.doc.InputValueDefinitionNameString(inputValueRef), p.doc.ResolveTypeNameString(p.doc.InputValueDefinitions[inputValueRef].Type))
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func (p *publicSchemaBuilder) validateFields(typeName string, fieldRefs []int) error {
+	for _, fieldRef := range fieldRefs {
+		fieldName := p.doc.FieldDefinitionNameString(fieldRef)
+		fieldType := p.doc.FieldDefinitions[fieldRef].Type
+		if p.isRemovedType(fieldType) {
+			return fmt.Errorf("field \"%s.%s\" returns inaccessible type \"%s\"", typeName, fieldName, p.doc.ResolveTypeNameString(fieldType))
+		}
+		for _, argumentRef := range p.doc.FieldDefinitions[fieldRef].ArgumentsDefinition.Refs {
+			argumentType := p.doc.InputValueDefinitions[argumentRef].Type
+			if p.isRemovedType(argumentType) {
+				return fmt.Errorf("argument \"%s\" of field \"%s.%s\" is of inaccessible type \"%s\"",
+					p.doc.InputValueDefinitionNameString(argumentRef), typeName, fieldName, p.doc.ResolveTypeNameString(argumentType))
+			}
+		}
+	}
+	return nil
+}
+
+// removeDirectives removes the @inaccessible, @tag and @sourceField directives and their definitions from the public schema
+func (p *publicSchemaBuilder) removeDirectives() {
+	for i := len(p.doc.RootNodes) - 1; i >= 0; i-- {
+		node := p.doc.RootNodes[i]
+		if node.Kind != ast.NodeKindDirectiveDefinition {
+			continue
+		}
+		switch p.doc.DirectiveDefinitionNameString(node.Ref) {
+		case inaccessibleDirectiveName, tagDirectiveName, sourceFieldDirectiveName:
+			p.doc.RemoveRootNode(node)
+		}
+	}
+
+	for _, node := range p.typeDefinitionNodes() {
+		switch node.Kind {
+		case ast.NodeKindObjectTypeDefinition:
+			definition := &p.doc.ObjectTypeDefinitions[node.Ref]
+			definition.HasDirectives = p.removeFederationDirectives(&definition.Directives)
+			p.removeFieldDirectives(definition.FieldsDefinition.Refs)
+		case ast.NodeKindInterfaceTypeDefinition:
+			definition := &p.doc.InterfaceTypeDefinitions[node.Ref]
+			definition.HasDirectives = p.removeFederationDirectives(&definition.Directives)
+			p.removeFieldDirectives(definition.FieldsDefinition.Refs)
+		case ast.NodeKindUnionTypeDefinition:
+			definition := &p.doc.UnionTypeDefinitions[node.Ref]
+			definition.HasDirectives = p.removeFederationDirectives(&definition.Directives)
+		case ast.NodeKindScalarTypeDefinition:
+			definition := &p.doc.ScalarTypeDefinitions[node.Ref]
+			definition.HasDirectives = p.removeFederationDirectives(&definition.Directives)
+		case ast.NodeKindEnumTypeDefinition:
+			definition := &p.doc.EnumTypeDefinitions[node.Ref]
+			definition.HasDirectives = p.removeFederationDirectives(&definition.Directives)
+			for _, enumValueRef := range definition.EnumValuesDefinition.Refs {
+				enumValue := &p.doc.EnumValueDefinitions[enumValueRef]
+				enumValue.HasDirectives = p.removeFederationDirectives(&enumValue.Directives)
+			}
+		case ast.NodeKindInputObjectTypeDefinition:
+			definition := &p.doc.InputObjectTypeDefinitions[node.Ref]
+			definition.HasDirectives = p.removeFederationDirectives(&definition.Directives)
+			p.removeInputValueDirectives(definition.InputFieldsDefinition.Refs)
+		}
+	}
+}
+
+func (p *publicSchemaBuilder) removeFieldDirectives(fieldRefs []int) {
+	for _, fieldRef := range fieldRefs {
+		definition := &p.doc.FieldDefinitions[fieldRef]
+		definition.HasDirectives = p.removeFederationDirectives(&definition.Directives)
+		p.removeInputValueDirectives(definition.ArgumentsDefinition.Refs)
+	}
+}
+
+func (p *publicSchemaBuilder) removeInputValueDirectives(inputValueRefs []int) {
+	for _, inputValueRef := range inputValueRefs {
+		definition := &p.doc.InputValueDefinitions[inputValueRef]
+		definition.HasDirectives = p.removeFederationDirectives(&definition.Directives)
+	}
+}
+
+// removeFederationDirectives removes @inaccessible, @tag and @sourceField from the list, as well as directives which
+// aren't exposed if exposed directives are configured, and returns whether directives are left
+func (p *publicSchemaBuilder) removeFederationDirectives(directives *ast.DirectiveList) bool {
+	remaining := directives.Refs[:0]
+	for _, directiveRef := range directives.Refs {
+		directiveName := p.doc.DirectiveNameString(directiveRef)
+		switch directiveName {
+		case inaccessibleDirectiveName, tagDirectiveName, sourceFieldDirectiveName:
+			continue
+		}
+		if p.exposedDirectives != nil {
+			if _, exposed := p.exposedDirectives[directiveName]; !exposed {
+				continue
+			}
+		}
+		remaining = append(remaining, directiveRef)
+	}
+	directives.Refs = remaining
+	return len(remaining) > 0
+}
+
+func (p *publicSchemaBuilder) typeDefinitionNodes() []ast.Node {
+	nodes := make([]ast.Node, 0, len(p.doc.RootNodes))
+	for _, node := range p.doc.RootNodes {
+		switch node.Kind {
+		case ast.NodeKindObjectTypeDefinition,
+			ast.NodeKindInterfaceTypeDefinition,
+			ast.NodeKindUnionTypeDefinition,
+			ast.NodeKindScalarTypeDefinition,
+			ast.NodeKindEnumTypeDefinition,
+			ast.NodeKindInputObjectTypeDefinition:
+			nodes = append(nodes, node)
+		}
+	}
+	return nodes
+}
+
+func (p *publicSchemaBuilder) isRemovedType(typeRef int) bool {
+	_, removed := p.removedTypes[p.doc.ResolveTypeNameString(typeRef)]
+	return removed
+}
+
+func (p *publicSchemaBuilder) hasDirective(directiveRefs []int, directiveName string) bool {
+	for _, directiveRef := range directiveRefs {
+		if p.doc.DirectiveNameString(directiveRef) == directiveName {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *publicSchemaBuilder) hasIncludedTag(directiveRefs []int) bool {
+	for _, directiveRef := range directiveRefs {
+		if p.doc.DirectiveNameString(directiveRef) != tagDirectiveName {
+			continue
+		}
+		value, ok := p.doc.DirectiveArgumentValueByName(directiveRef, []byte(tagDirectiveNameArgument))
+		if !ok || value.Kind != ast.ValueKindString {
+			continue
+		}
+		if _, included := p.includeTags[p.doc.StringValueContentString(value.Ref)]; included {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,181 @@
+package federation
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/wundergraph/graphql-go-tools/pkg/astparser"
+	"github.com/wundergraph/graphql-go-tools/pkg/astprinter"
+)
+
+func TestBuildPublicSchemaDocument(t *testing.T) {
+	runWithOptions := func(t *testing.T, baseSchema, expectedSchema string, options PublicSchemaOptions) {
+		t.Helper()
+
+		actual, err := BuildPublicSchemaDocumentWithOptions(baseSchema, options)
+		require.NoError(t, err)
+
+		expectedDoc, report := astparser.ParseGraphqlDocumentString(expectedSchema)
+		require.False(t, report.HasErrors(), report.Error())
+		expected, err := astprinter.PrintStringIndent(&expectedDoc, nil, "  ")
+		require.NoError(t, err)
+
+		assert.Equal(t, expected, actual)
+	}
+	run := func(t *testing.T, baseSchema, expectedSchema string, includeTags ...string) {
+		t.Helper()
+		runWithOptions(t, baseSchema, expectedSchema, PublicSchemaOptions{IncludeTags: includeTags})
+	}
+
+	t.Run("removes inaccessible elements", func(t *testing.T) {
+		run(t, `
+			type Query {
+				me: User
+				search(filter: Filter, debug: Boolean @inaccessible): [Node]
+				secret: Secret @inaccessible
+			}
+			type User implements Node @key(fields: "id") {
+				id: ID! @inaccessible
+				username: String!
+				role: Role!
+			}
+			type Secret @inaccessible {
+				value: String!
+			}
+			interface Node {
+				username: String!
+			}
+			union Result = User | Secret
+			enum Role {
+				ADMIN @inaccessible
+				USER
+			}
+			input Filter {
+				username: String
+				internalId: ID @inaccessible
+			}
+			directive @inaccessible on FIELD_DEFINITION | OBJECT | INTERFACE | UNION | ARGUMENT_DEFINITION | SCALAR | ENUM | ENUM_VALUE | INPUT_OBJECT | INPUT_FIELD_DEFINITION
+		`, `
+			type Query {
+				me: User
+				search(filter: Filter): [Node]
+			}
+			type User implements Node @key(fields: "id") {
+				username: String!
+				role: Role!
+			}
+			interface Node {
+				username: String!
+			}
+			union Result = User
+			enum Role {
+				USER
+			}
+			input Filter {
+				username: String
+			}
+		`)
+	})
+
+	t.Run("includes only tagged elements", func(t *testing.T) {
+		run(t, `
+			type Query {
+				me: User @tag(name: "public")
+				internalUsers: [User] @tag(name: "internal")
+				health: String
+			}
+			type User @tag(name: "public") {
+				id: ID!
+				username: String!
+			}
+			type Mutation {
+				deleteUser(id: ID!): Boolean
+			}
+		`, `
+			type Query {
+				me: User
+			}
+			type User {
+				id: ID!
+				username: String!
+			}
+		`, "public")
+	})
+
+	t.Run("returns error for field returning an inaccessible type", func(t *testing.T) {
+		_, err := BuildPublicSchemaDocument(`
+			type Query {
+				me: User
+			}
+			type User @inaccessible {
+				id: ID!
+			}
+		`)
+		assert.EqualError(t, err, `field "Query.me" returns inaccessible type "User"`)
+	})
+
+	t.Run("returns error for argument of an inaccessible type", func(t *testing.T) {
+		_, err := BuildPublicSchemaDocument(`
+			type Query {
+				users(filter: Filter): [String]
+			}
+			input Filter @inaccessible {
+				id: ID
+			}
+		`)
+		assert.EqualError(t, err, `argument "filter" of field "Query.users" is of inaccessible type "Filter"`)
+	})
+
+	t.Run("keeps inaccessible elements in the merged base schema", func(t *testing.T) {
+		baseSchema, err := BuildBaseSchemaDocument(`
+			extend type Query {
+				me: User
+			}
+			type User @key(fields: "id") {
+				id: ID! @inaccessible
+				username: String!
+			}
+		`, `
+			extend type User @key(fields: "id") {
+				id: ID! @external
+				reviews: [String]
+			}
+		`)
+		require.NoError(t, err)
+		assert.Contains(t, baseSchema, "id: ID! @inaccessible")
+
+		run(t, baseSchema, `
+			type Query {
+				me: User
+			}
+			type User {
+				username: String!
+				reviews: [String]
+			}
+		`)
+	})
+
+	t.Run("keeps only the usages of exposed directives", func(t *testing.T) {
+		runWithOptions(t, `
+			type Query {
+				products: [Product] @requiresScopes(scopes: [["read"]])
+			}
+			type Product @key(fields: "upc") @owner(team: "catalog") {
+				upc: String! @external
+				name: String @deprecated(reason: "use title") @tag(name: "public")
+				title: String
+			}
+		`, `
+			type Query {
+				products: [Product] @requiresScopes(scopes: [["read"]])
+			}
+			type Product {
+				upc: String!
+				name: String @deprecated(reason: "use title")
+				title: String
+			}
+		`, PublicSchemaOptions{ExposedDirectives: []string{"requiresScopes"}})
+	})
+}
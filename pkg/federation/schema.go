@@ -7,6 +7,7 @@ import (
 	"github.com/wundergraph/graphql-go-tools/pkg/ast"
 	"github.com/wundergraph/graphql-go-tools/pkg/astparser"
 	"github.com/wundergraph/graphql-go-tools/pkg/astprinter"
+	"github.com/wundergraph/graphql-go-tools/pkg/asttransform"
 	"github.com/wundergraph/graphql-go-tools/pkg/astvisitor"
 	"github.com/wundergraph/graphql-go-tools/pkg/federation/sdlmerge"
 	"github.com/wundergraph/graphql-go-tools/pkg/operationreport"
@@ -116,6 +117,7 @@ func (s *schemaBuilder) entityUnionTypes(serviceSDL string) []string {
 	if report.HasErrors() {
 		return nil
 	}
+	asttransform.ResolveLinkImports(doc)
 
 	walker := astvisitor.NewWalker(4)
 	visitor := &schemaBuilderVisitor{}
@@ -148,19 +150,45 @@ func (s *schemaBuilderVisitor) EnterDocument(operation, definition *ast.Document
 }
 
 func (s *schemaBuilderVisitor) EnterObjectTypeExtension(ref int) {
-	for _, i := range s.definition.ObjectTypeExtensions[ref].Directives.Refs {
-		if s.definition.DirectiveNameString(i) == "key" {
-			s.addEntity(s.definition.ObjectTypeExtensionNameString(ref))
-		}
+	objectType := s.definition.ObjectTypeExtensions[ref].ObjectTypeDefinition
+	if s.hasKey(objectType.Directives) || s.implementsEntityInterface(objectType.ImplementsInterfaces) {
+		s.addEntity(s.definition.ObjectTypeExtensionNameString(ref))
 	}
 }
 
 func (s *schemaBuilderVisitor) EnterObjectTypeDefinition(ref int) {
-	for _, i := range s.definition.ObjectTypeDefinitions[ref].Directives.Refs {
+	objectType := s.definition.ObjectTypeDefinitions[ref]
+	if s.hasKey(objectType.Directives) || s.implementsEntityInterface(objectType.ImplementsInterfaces) {
+		s.addEntity(s.definition.ObjectTypeDefinitionNameString(ref))
+	}
+}
+
+func (s *schemaBuilderVisitor) hasKey(directives ast.DirectiveList) bool {
+	for _, i := range directives.Refs {
 		if s.definition.DirectiveNameString(i) == "key" {
-			s.addEntity(s.definition.ObjectTypeDefinitionNameString(ref))
+			return true
+		}
+	}
+	return false
+}
+
+// implementsEntityInterface reports whether one of the interfaces has a @key directive,
+// objects implementing such an interface are entities
+func (s *schemaBuilderVisitor) implementsEntityInterface(interfaces ast.TypeList) bool {
+	for _, typeRef := range interfaces.Refs {
+		interfaceName := s.definition.ResolveTypeNameString(typeRef)
+		for i := range s.definition.InterfaceTypeDefinitions {
+			if s.definition.InterfaceTypeDefinitionNameString(i) == interfaceName && s.hasKey(s.definition.InterfaceTypeDefinitions[i].Directives) {
+				return true
+			}
+		}
+		for i := range s.definition.InterfaceTypeExtensions {
+			if s.definition.InterfaceTypeExtensionNameString(i) == interfaceName && s.hasKey(s.definition.InterfaceTypeExtensions[i].Directives) {
+				return true
+			}
 		}
 	}
+	return false
 }
 
 const federationTemplate = `
@@ -0,0 +1,326 @@
+package federation
+
+import (
+	"fmt"
+
+	"github.com/wundergraph/graphql-go-tools/pkg/ast"
+	"github.com/wundergraph/graphql-go-tools/pkg/astparser"
+)
+
+// SchemaChange is a difference between two versions of a schema
+type SchemaChange struct {
+	// Path is the schema coordinate of the changed element, e.g. "User.email" or "Query.users(first:)".
+	Path    string
+	Message string
+	// Breaking is true if operations which are valid against the old schema might fail against the new schema.
+	Breaking bool
+}
+
+// SchemaCompatibilityReport lists the changes between two versions of a schema
+type SchemaCompatibilityReport struct {
+	Changes []SchemaChange
+}
+
+// HasBreakingChanges returns true if any of the changes is breaking
+func (r SchemaCompatibilityReport) HasBreakingChanges() bool {
+	for i := range r.Changes {
+		if r.Changes[i].Breaking {
+			return true
+		}
+	}
+	return false
+}
+
+// BreakingChanges returns the breaking changes only
+func (r SchemaCompatibilityReport) BreakingChanges() []SchemaChange {
+	var breaking []SchemaChange
+	for i := range r.Changes {
+		if r.Changes[i].Breaking {
+			breaking = append(breaking, r.Changes[i])
+		}
+	}
+	return breaking
+}
+
+// CheckSchemaCompatibility compares two versions of a merged schema, e.g. before and after a subgraph update.
+// Removed types, fields, arguments, enum values, union members and interfaces, changed field types
+// which accept fewer values, and added required arguments or input fields are breaking.
+// Additions are safe, as well as making an output type non-null or an input type nullable.
+func CheckSchemaCompatibility(oldSchema, newSchema string) (SchemaCompatibilityReport, error) {
+	oldDoc, report := astparser.ParseGraphqlDocumentString(oldSchema)
+	if report.HasErrors() {
+		return SchemaCompatibilityReport{}, fmt.Errorf("parse old schema: %w", report)
+	}
+	newDoc, report := astparser.ParseGraphqlDocumentString(newSchema)
+	if report.HasErrors() {
+		return SchemaCompatibilityReport{}, fmt.Errorf("parse new schema: %w", report)
+	}
+
+	checker := schemaCompatibilityChecker{
+		oldDoc: &oldDoc,
+		newDoc: &newDoc,
+	}
+	checker.checkTypes()
+	return SchemaCompatibilityReport{Changes: checker.changes}, nil
+}
+
+type schemaCompatibilityChecker struct {
+	oldDoc, newDoc *ast.Document
+	changes        []SchemaChange
+}
+
+func (c *schemaCompatibilityChecker) breaking(path, format string, args ...interface{}) {
+	c.changes = append(c.changes, SchemaChange{Path: path, Message: fmt.Sprintf(format, args...), Breaking: true})
+}
+
+func (c *schemaCompatibilityChecker) safe(path, format string, args ...interface{}) {
+	c.changes = append(c.changes, SchemaChange{Path: path, Message: fmt.Sprintf(format, args...)})
+}
+
+func (c *schemaCompatibilityChecker) checkTypes() {
+	oldTypes := typeDefinitionsByName(c.oldDoc)
+	newTypes := typeDefinitionsByName(c.newDoc)
+
+	for _, oldNode := range typeDefinitions(c.oldDoc) {
+		typeName := c.oldDoc.NodeNameString(oldNode)
+		newNode, exists := newTypes[typeName]
+		if !exists {
+			c.breaking(typeName, "type %q was removed", typeName)
+			continue
+		}
+		if oldNode.Kind != newNode.Kind {
+			c.breaking(typeName, "type %q changed from %s to %s", typeName, typeKindName(oldNode.Kind), typeKindName(newNode.Kind))
+			continue
+		}
+
+		switch oldNode.Kind {
+		case ast.NodeKindObjectTypeDefinition:
+			c.checkTypeNames(typeName, "interface", c.oldDoc.ObjectTypeDefinitions[oldNode.Ref].ImplementsInterfaces.Refs, c.newDoc.ObjectTypeDefinitions[newNode.Ref].ImplementsInterfaces.Refs)
+			c.checkFields(typeName, c.oldDoc.ObjectTypeDefinitions[oldNode.Ref].FieldsDefinition.Refs, c.newDoc.ObjectTypeDefinitions[newNode.Ref].FieldsDefinition.Refs)
+		case ast.NodeKindInterfaceTypeDefinition:
+			c.checkFields(typeName, c.oldDoc.InterfaceTypeDefinitions[oldNode.Ref].FieldsDefinition.Refs, c.newDoc.InterfaceTypeDefinitions[newNode.Ref].FieldsDefinition.Refs)
+		case ast.NodeKindUnionTypeDefinition:
+			c.checkTypeNames(typeName, "member", c.oldDoc.UnionTypeDefinitions[oldNode.Ref].UnionMemberTypes.Refs, c.newDoc.UnionTypeDefinitions[newNode.Ref].UnionMemberTypes.Refs)
+		case ast.NodeKindEnumTypeDefinition:
+			c.checkEnumValues(typeName, c.oldDoc.EnumTypeDefinitions[oldNode.Ref].EnumValuesDefinition.Refs, c.newDoc.EnumTypeDefinitions[newNode.Ref].EnumValuesDefinition.Refs)
+		case ast.NodeKindInputObjectTypeDefinition:
+			c.checkInputValues(typeName, "input field", typeName+".%s", c.oldDoc.InputObjectTypeDefinitions[oldNode.Ref].InputFieldsDefinition.Refs, c.newDoc.InputObjectTypeDefinitions[newNode.Ref].InputFieldsDefinition.Refs)
+		}
+	}
+
+	for _, newNode := range typeDefinitions(c.newDoc) {
+		typeName := c.newDoc.NodeNameString(newNode)
+		if _, exists := oldTypes[typeName]; !exists {
+			c.safe(typeName, "type %q was added", typeName)
+		}
+	}
+}
+
+func (c *schemaCompatibilityChecker) checkFields(typeName string, oldFieldRefs, newFieldRefs []int) {
+	for _, oldRef := range oldFieldRefs {
+		fieldName := c.oldDoc.FieldDefinitionNameString(oldRef)
+		path := typeName + "." + fieldName
+		newRef := fieldDefinitionByName(c.newDoc, newFieldRefs, fieldName)
+		if newRef == ast.InvalidRef {
+			c.breaking(path, "field %q was removed", path)
+			continue
+		}
+
+		oldType, newType := c.oldDoc.FieldDefinitions[oldRef].Type, c.newDoc.FieldDefinitions[newRef].Type
+		if !c.isCompatibleOutputType(oldType, newType) {
+			c.breaking(path, "field %q changed type from %q to %q", path, printType(c.oldDoc, oldType), printType(c.newDoc, newType))
+		} else if printType(c.oldDoc, oldType) != printType(c.newDoc, newType) {
+			c.safe(path, "field %q changed type from %q to %q", path, printType(c.oldDoc, oldType), printType(c.newDoc, newType))
+		}
+
+		c.checkInputValues(path, "argument", path+"(%s:)", c.oldDoc.FieldDefinitions[oldRef].ArgumentsDefinition.Refs, c.newDoc.FieldDefinitions[newRef].ArgumentsDefinition.Refs)
+	}
+
+	for _, newRef := range newFieldRefs {
+		fieldName := c.newDoc.FieldDefinitionNameString(newRef)
+		if fieldDefinitionByName(c.oldDoc, oldFieldRefs, fieldName) == ast.InvalidRef {
+			path := typeName + "." + fieldName
+			c.safe(path, "field %q was added", path)
+		}
+	}
+}
+
+// checkInputValues compares arguments or input fields, pathFormat formats the path of an input value by its name
+func (c *schemaCompatibilityChecker) checkInputValues(parentPath, kind, pathFormat string, oldRefs, newRefs []int) {
+	for _, oldRef := range oldRefs {
+		name := c.oldDoc.InputValueDefinitionNameString(oldRef)
+		path := fmt.Sprintf(pathFormat, name)
+		newRef := inputValueDefinitionByName(c.newDoc, newRefs, name)
+		if newRef == ast.InvalidRef {
+			c.breaking(path, "%s %q of %q was removed", kind, name, parentPath)
+			continue
+		}
+
+		oldType, newType := c.oldDoc.InputValueDefinitions[oldRef].Type, c.newDoc.InputValueDefinitions[newRef].Type
+		if !c.isCompatibleInputType(oldType, newType) {
+			c.breaking(path, "%s %q of %q changed type from %q to %q", kind, name, parentPath, printType(c.oldDoc, oldType), printType(c.newDoc, newType))
+		} else if printType(c.oldDoc, oldType) != printType(c.newDoc, newType) {
+			c.safe(path, "%s %q of %q changed type from %q to %q", kind, name, parentPath, printType(c.oldDoc, oldType), printType(c.newDoc, newType))
+		}
+	}
+
+	for _, newRef := range newRefs {
+		name := c.newDoc.InputValueDefinitionNameString(newRef)
+		if inputValueDefinitionByName(c.oldDoc, oldRefs, name) != ast.InvalidRef {
+			continue
+		}
+		path := fmt.Sprintf(pathFormat, name)
+		if c.newDoc.TypeIsNonNull(c.newDoc.InputValueDefinitions[newRef].Type) && !c.newDoc.InputValueDefinitionHasDefaultValue(newRef) {
+			c.breaking(path, "required %s %q was added to %q", kind, name, parentPath)
+			continue
+		}
+		c.safe(path, "optional %s %q was added to %q", kind, name, parentPath)
+	}
+}
+
+func (c *schemaCompatibilityChecker) checkEnumValues(typeName string, oldRefs, newRefs []int) {
+	oldValues := make(map[string]struct{}, len(oldRefs))
+	for _, ref := range oldRefs {
+		oldValues[c.oldDoc.EnumValueDefinitionNameString(ref)] = struct{}{}
+	}
+	newValues := make(map[string]struct{}, len(newRefs))
+	for _, ref := range newRefs {
+		newValues[c.newDoc.EnumValueDefinitionNameString(ref)] = struct{}{}
+	}
+
+	for _, ref := range oldRefs {
+		value := c.oldDoc.EnumValueDefinitionNameString(ref)
+		if _, exists := newValues[value]; !exists {
+			c.breaking(typeName+"."+value, "enum value %q was removed from %q", value, typeName)
+		}
+	}
+	for _, ref := range newRefs {
+		value := c.newDoc.EnumValueDefinitionNameString(ref)
+		if _, exists := oldValues[value]; !exists {
+			c.safe(typeName+"."+value, "enum value %q was added to %q", value, typeName)
+		}
+	}
+}
+
+// checkTypeNames compares the implemented interfaces of objects or the members of unions
+func (c *schemaCompatibilityChecker) checkTypeNames(typeName, kind string, oldTypeRefs, newTypeRefs []int) {
+	oldNames := make(map[string]struct{}, len(oldTypeRefs))
+	for _, ref := range oldTypeRefs {
+		oldNames[c.oldDoc.ResolveTypeNameString(ref)] = struct{}{}
+	}
+	newNames := make(map[string]struct{}, len(newTypeRefs))
+	for _, ref := range newTypeRefs {
+		newNames[c.newDoc.ResolveTypeNameString(ref)] = struct{}{}
+	}
+
+	for _, ref := range oldTypeRefs {
+		name := c.oldDoc.ResolveTypeNameString(ref)
+		if _, exists := newNames[name]; !exists {
+			c.breaking(typeName, "%s %q was removed from %q", kind, name, typeName)
+		}
+	}
+	for _, ref := range newTypeRefs {
+		name := c.newDoc.ResolveTypeNameString(ref)
+		if _, exists := oldNames[name]; !exists {
+			c.safe(typeName, "%s %q was added to %q", kind, name, typeName)
+		}
+	}
+}
+
+// isCompatibleOutputType returns true if every value of the new type is a value of the old type,
+// e.g. a nullable field may become non-null.
+func (c *schemaCompatibilityChecker) isCompatibleOutputType(oldRef, newRef int) bool {
+	oldType, newType := c.oldDoc.Types[oldRef], c.newDoc.Types[newRef]
+	switch {
+	case newType.TypeKind == ast.TypeKindNonNull && oldType.TypeKind != ast.TypeKindNonNull:
+		return c.isCompatibleOutputType(oldRef, newType.OfType)
+	case oldType.TypeKind != newType.TypeKind:
+		return false
+	case oldType.TypeKind == ast.TypeKindNamed:
+		return c.oldDoc.TypeNameString(oldRef) == c.newDoc.TypeNameString(newRef)
+	default:
+		return c.isCompatibleOutputType(oldType.OfType, newType.OfType)
+	}
+}
+
+// isCompatibleInputType returns true if every value of the old type is a value of the new type,
+// e.g. a non-null argument may become nullable.
+func (c *schemaCompatibilityChecker) isCompatibleInputType(oldRef, newRef int) bool {
+	oldType, newType := c.oldDoc.Types[oldRef], c.newDoc.Types[newRef]
+	switch {
+	case oldType.TypeKind == ast.TypeKindNonNull && newType.TypeKind != ast.TypeKindNonNull:
+		return c.isCompatibleInputType(oldType.OfType, newRef)
+	case oldType.TypeKind != newType.TypeKind:
+		return false
+	case oldType.TypeKind == ast.TypeKindNamed:
+		return c.oldDoc.TypeNameString(oldRef) == c.newDoc.TypeNameString(newRef)
+	default:
+		return c.isCompatibleInputType(oldType.OfType, newType.OfType)
+	}
+}
+
+func typeDefinitions(doc *ast.Document) []ast.Node {
+	nodes := make([]ast.Node, 0, len(doc.RootNodes))
+	for _, node := range doc.RootNodes {
+		switch node.Kind {
+		case ast.NodeKindObjectTypeDefinition,
+			ast.NodeKindInterfaceTypeDefinition,
+			ast.NodeKindUnionTypeDefinition,
+			ast.NodeKindScalarTypeDefinition,
+			ast.NodeKindEnumTypeDefinition,
+			ast.NodeKindInputObjectTypeDefinition:
+			nodes = append(nodes, node)
+		}
+	}
+	return nodes
+}
+
+func typeDefinitionsByName(doc *ast.Document) map[string]ast.Node {
+	nodes := typeDefinitions(doc)
+	byName := make(map[string]ast.Node, len(nodes))
+	for _, node := range nodes {
+		byName[doc.NodeNameString(node)] = node
+	}
+	return byName
+}
+
+func typeKindName(kind ast.NodeKind) string {
+	switch kind {
+	case ast.NodeKindObjectTypeDefinition:
+		return "object"
+	case ast.NodeKindInterfaceTypeDefinition:
+		return "interface"
+	case ast.NodeKindUnionTypeDefinition:
+		return "union"
+	case ast.NodeKindScalarTypeDefinition:
+		return "scalar"
+	case ast.NodeKindEnumTypeDefinition:
+		return "enum"
+	case ast.NodeKindInputObjectTypeDefinition:
+		return "input object"
+	}
+	return kind.String()
+}
+
+func fieldDefinitionByName(doc *ast.Document, fieldRefs []int, name string) int {
+	for _, ref := range fieldRefs {
+		if doc.FieldDefinitionNameString(ref) == name {
+			return ref
+		}
+	}
+	return ast.InvalidRef
+}
+
+func inputValueDefinitionByName(doc *ast.Document, inputValueRefs []int, name string) int {
+	for _, ref := range inputValueRefs {
+		if doc.InputValueDefinitionNameString(ref) == name {
+			return ref
+		}
+	}
+	return ast.InvalidRef
+}
+
+func printType(doc *ast.Document, typeRef int) string {
+	printed, _ := doc.PrintTypeBytes(typeRef, nil)
+	return string(printed)
+}
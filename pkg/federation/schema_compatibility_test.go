@@ -0,0 +1,178 @@
+package federation
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckSchemaCompatibility(t *testing.T) {
+	const oldSchema = `
+		type Query {
+			me: User
+			users(first: Int): [User!]!
+		}
+		type User {
+			id: ID!
+			username: String
+			role: Role!
+		}
+		enum Role {
+			ADMIN
+			USER
+		}
+		input Filter {
+			username: String
+		}
+	`
+
+	run := func(t *testing.T, newSchema string, expectedChanges ...SchemaChange) {
+		t.Helper()
+
+		report, err := CheckSchemaCompatibility(oldSchema, newSchema)
+		require.NoError(t, err)
+		assert.Equal(t, expectedChanges, report.Changes)
+	}
+
+	t.Run("no changes", func(t *testing.T) {
+		report, err := CheckSchemaCompatibility(oldSchema, oldSchema)
+		require.NoError(t, err)
+		assert.Empty(t, report.Changes)
+		assert.False(t, report.HasBreakingChanges())
+	})
+
+	t.Run("removed field is breaking", func(t *testing.T) {
+		report, err := CheckSchemaCompatibility(oldSchema, `
+			type Query {
+				me: User
+				users(first: Int): [User!]!
+			}
+			type User {
+				id: ID!
+				role: Role!
+			}
+			enum Role {
+				ADMIN
+				USER
+			}
+			input Filter {
+				username: String
+			}
+		`)
+		require.NoError(t, err)
+		assert.True(t, report.HasBreakingChanges())
+		assert.Equal(t, []SchemaChange{
+			{Path: "User.username", Message: `field "User.username" was removed`, Breaking: true},
+		}, report.BreakingChanges())
+	})
+
+	t.Run("added optional field is safe", func(t *testing.T) {
+		run(t, `
+			type Query {
+				me: User
+				users(first: Int): [User!]!
+			}
+			type User {
+				id: ID!
+				username: String
+				email: String
+				role: Role!
+			}
+			enum Role {
+				ADMIN
+				USER
+			}
+			input Filter {
+				username: String
+				email: String
+			}
+		`,
+			SchemaChange{Path: "User.email", Message: `field "User.email" was added`},
+			SchemaChange{Path: "Filter.email", Message: `optional input field "email" was added to "Filter"`},
+		)
+	})
+
+	t.Run("changed field type is breaking", func(t *testing.T) {
+		run(t, `
+			type Query {
+				me: User
+				users(first: Int): [User]
+			}
+			type User {
+				id: Int!
+				username: String!
+				role: Role!
+			}
+			enum Role {
+				ADMIN
+				USER
+			}
+			input Filter {
+				username: String
+			}
+		`,
+			SchemaChange{Path: "Query.users", Message: `field "Query.users" changed type from "[User!]!" to "[User]"`, Breaking: true},
+			SchemaChange{Path: "User.id", Message: `field "User.id" changed type from "ID!" to "Int!"`, Breaking: true},
+			SchemaChange{Path: "User.username", Message: `field "User.username" changed type from "String" to "String!"`},
+		)
+	})
+
+	t.Run("arguments and input fields", func(t *testing.T) {
+		run(t, `
+			type Query {
+				me(id: ID!): User
+				users(first: Int!, after: String): [User!]!
+			}
+			type User {
+				id: ID!
+				username: String
+				role: Role!
+			}
+			enum Role {
+				ADMIN
+				USER
+			}
+			input Filter {
+				username: String!
+				role: Role! = USER
+			}
+		`,
+			SchemaChange{Path: "Query.me(id:)", Message: `required argument "id" was added to "Query.me"`, Breaking: true},
+			SchemaChange{Path: "Query.users(first:)", Message: `argument "first" of "Query.users" changed type from "Int" to "Int!"`, Breaking: true},
+			SchemaChange{Path: "Query.users(after:)", Message: `optional argument "after" was added to "Query.users"`},
+			SchemaChange{Path: "Filter.username", Message: `input field "username" of "Filter" changed type from "String" to "String!"`, Breaking: true},
+			SchemaChange{Path: "Filter.role", Message: `optional input field "role" was added to "Filter"`},
+		)
+	})
+
+	t.Run("types and enum values", func(t *testing.T) {
+		run(t, `
+			type Query {
+				me: User
+				users(first: Int): [User!]!
+			}
+			interface User {
+				id: ID!
+				username: String
+				role: Role!
+			}
+			enum Role {
+				USER
+				GUEST
+			}
+			scalar DateTime
+		`,
+			SchemaChange{Path: "User", Message: `type "User" changed from object to interface`, Breaking: true},
+			SchemaChange{Path: "Role.ADMIN", Message: `enum value "ADMIN" was removed from "Role"`, Breaking: true},
+			SchemaChange{Path: "Role.GUEST", Message: `enum value "GUEST" was added to "Role"`},
+			SchemaChange{Path: "Filter", Message: `type "Filter" was removed`, Breaking: true},
+			SchemaChange{Path: "DateTime", Message: `type "DateTime" was added`},
+		)
+	})
+
+	t.Run("invalid schema", func(t *testing.T) {
+		_, err := CheckSchemaCompatibility(oldSchema, `type Query {`)
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,252 @@
+package sdlmerge
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/wundergraph/graphql-go-tools/pkg/ast"
+	"github.com/wundergraph/graphql-go-tools/pkg/astparser"
+	"github.com/wundergraph/graphql-go-tools/pkg/astprinter"
+	"github.com/wundergraph/graphql-go-tools/pkg/operationreport"
+)
+
+const shareableDirectiveName = "shareable"
+
+// FieldConflictPolicy decides how fields of a shared type defined with different types in several subgraphs are merged
+type FieldConflictPolicy int
+
+const (
+	// FieldConflictPolicyError fails the merge for any field defined with different types
+	FieldConflictPolicyError FieldConflictPolicy = iota
+	// FieldConflictPolicyShareable merges fields marked as @shareable in every subgraph defining them, on the field
+	// or on its type, if their types only differ in nullability. The merged field is nullable wherever any of the
+	// definitions is nullable. Any other conflict fails the merge.
+	FieldConflictPolicyShareable
+)
+
+// Subgraph is the SDL of a subgraph, the name identifies the subgraph in merge errors
+type Subgraph struct {
+	Name string
+	SDL  string
+}
+
+// fieldType is a field type independent of the document it's defined in
+type fieldType struct {
+	name    string
+	ofType  *fieldType
+	nonNull bool
+}
+
+func newFieldType(document *ast.Document, ref int) *fieldType {
+	out := &fieldType{}
+	if document.Types[ref].TypeKind == ast.TypeKindNonNull {
+		out.nonNull = true
+		ref = document.Types[ref].OfType
+	}
+	if document.Types[ref].TypeKind == ast.TypeKindList {
+		out.ofType = newFieldType(document, document.Types[ref].OfType)
+		return out
+	}
+	out.name = document.TypeNameString(ref)
+	return out
+}
+
+func (t *fieldType) String() string {
+	out := t.name
+	if t.ofType != nil {
+		out = "[" + t.ofType.String() + "]"
+	}
+	if t.nonNull {
+		out += "!"
+	}
+	return out
+}
+
+// relaxed returns the type accepting the values of both types, it's nullable wherever one of them is nullable.
+// It returns false for types differing in more than their nullability.
+func (t *fieldType) relaxed(other *fieldType) (*fieldType, bool) {
+	out := &fieldType{nonNull: t.nonNull && other.nonNull}
+	switch {
+	case t.ofType != nil && other.ofType != nil:
+		ofType, ok := t.ofType.relaxed(other.ofType)
+		if !ok {
+			return nil, false
+		}
+		out.ofType = ofType
+	case t.ofType == nil && other.ofType == nil && t.name == other.name:
+		out.name = t.name
+	default:
+		return nil, false
+	}
+	return out, true
+}
+
+func (t *fieldType) addTo(document *ast.Document) (ref int) {
+	if t.ofType != nil {
+		ref = document.AddListType(t.ofType.addTo(document))
+	} else {
+		ref = document.AddNamedType([]byte(t.name))
+	}
+	if t.nonNull {
+		ref = document.AddNonNullType(ref)
+	}
+	return ref
+}
+
+type fieldDefinitionOccurrence struct {
+	subgraph  int
+	ref       int
+	fieldType *fieldType
+	shareable bool
+}
+
+// fieldConflictResolver compares the fields of the object and interface types of all subgraphs,
+// fields marked as @external are skipped because they're defined by another subgraph
+type fieldConflictResolver struct {
+	policy    FieldConflictPolicy
+	subgraphs []Subgraph
+	documents []*ast.Document
+	// occurrences are the definitions of a field in the subgraphs keyed by "Type.field"
+	occurrences map[string][]fieldDefinitionOccurrence
+	// keys are the keys of occurrences in the order of their first definition
+	keys []string
+}
+
+// resolveFieldConflicts fails with an error naming the field, the subgraphs and the types of the first field defined
+// with different types which can't be merged by the policy. Fields merged by the policy are rewritten in the SDLs.
+func resolveFieldConflicts(subgraphs []Subgraph, policy FieldConflictPolicy) error {
+	resolver := &fieldConflictResolver{
+		policy:      policy,
+		subgraphs:   subgraphs,
+		documents:   make([]*ast.Document, len(subgraphs)),
+		occurrences: make(map[string][]fieldDefinitionOccurrence),
+	}
+
+	for i := range subgraphs {
+		document, report := astparser.ParseGraphqlDocumentString(subgraphs[i].SDL)
+		if report.HasErrors() {
+			return fmt.Errorf(parseDocumentError, report)
+		}
+		resolver.documents[i] = &document
+		resolver.collect(i)
+	}
+
+	rewritten := make([]bool, len(subgraphs))
+	for _, key := range resolver.keys {
+		if err := resolver.resolve(key, rewritten); err != nil {
+			return err
+		}
+	}
+
+	for i := range subgraphs {
+		if !rewritten[i] {
+			continue
+		}
+		out, err := astprinter.PrintString(resolver.documents[i], nil)
+		if err != nil {
+			return fmt.Errorf("stringify schema: %w", err)
+		}
+		subgraphs[i].SDL = out
+	}
+	return nil
+}
+
+func (r *fieldConflictResolver) collect(subgraph int) {
+	document := r.documents[subgraph]
+	for i := range document.ObjectTypeDefinitions {
+		definition := document.ObjectTypeDefinitions[i]
+		r.collectFields(subgraph, document.ObjectTypeDefinitionNameString(i), definition.Directives, definition.FieldsDefinition.Refs)
+	}
+	for i := range document.ObjectTypeExtensions {
+		extension := document.ObjectTypeExtensions[i]
+		r.collectFields(subgraph, document.ObjectTypeExtensionNameString(i), extension.Directives, extension.FieldsDefinition.Refs)
+	}
+	for i := range document.InterfaceTypeDefinitions {
+		definition := document.InterfaceTypeDefinitions[i]
+		r.collectFields(subgraph, document.InterfaceTypeDefinitionNameString(i), definition.Directives, definition.FieldsDefinition.Refs)
+	}
+	for i := range document.InterfaceTypeExtensions {
+		extension := document.InterfaceTypeExtensions[i]
+		r.collectFields(subgraph, document.InterfaceTypeExtensionNameString(i), extension.Directives, extension.FieldsDefinition.Refs)
+	}
+}
+
+func (r *fieldConflictResolver) collectFields(subgraph int, typeName string, typeDirectives ast.DirectiveList, fieldRefs []int) {
+	document := r.documents[subgraph]
+	typeIsShareable := typeDirectives.HasDirectiveByName(document, shareableDirectiveName)
+	for _, ref := range fieldRefs {
+		if document.FieldDefinitionHasNamedDirective(ref, "external") {
+			continue
+		}
+		key := typeName + "." + document.FieldDefinitionNameString(ref)
+		if _, ok := r.occurrences[key]; !ok {
+			r.keys = append(r.keys, key)
+		}
+		r.occurrences[key] = append(r.occurrences[key], fieldDefinitionOccurrence{
+			subgraph:  subgraph,
+			ref:       ref,
+			fieldType: newFieldType(document, document.FieldDefinitions[ref].Type),
+			shareable: typeIsShareable || document.FieldDefinitionHasNamedDirective(ref, shareableDirectiveName),
+		})
+	}
+}
+
+func (r *fieldConflictResolver) resolve(key string, rewritten []bool) error {
+	occurrences := r.occurrences[key]
+	first := occurrences[0]
+	var conflict *fieldDefinitionOccurrence
+	for i := 1; i < len(occurrences); i++ {
+		if occurrences[i].fieldType.String() != first.fieldType.String() {
+			conflict = &occurrences[i]
+			break
+		}
+	}
+	if conflict == nil {
+		return nil
+	}
+
+	if merged, ok := r.mergeShareable(occurrences); ok {
+		for _, occurrence := range occurrences {
+			if occurrence.fieldType.String() == merged.String() {
+				continue
+			}
+			document := r.documents[occurrence.subgraph]
+			document.FieldDefinitions[occurrence.ref].Type = merged.addTo(document)
+			rewritten[occurrence.subgraph] = true
+		}
+		return nil
+	}
+
+	typeName, fieldName, _ := strings.Cut(key, ".")
+	report := operationreport.Report{}
+	report.AddExternalError(operationreport.ErrFieldsMustHaveIdenticalTypesToFederate(
+		typeName, fieldName,
+		r.subgraphName(first.subgraph), first.fieldType.String(),
+		r.subgraphName(conflict.subgraph), conflict.fieldType.String(),
+	))
+	return fmt.Errorf("merge field definitions: %w", report)
+}
+
+// mergeShareable returns the relaxed type of the definitions if the policy allows to merge them
+func (r *fieldConflictResolver) mergeShareable(occurrences []fieldDefinitionOccurrence) (merged *fieldType, ok bool) {
+	if r.policy != FieldConflictPolicyShareable {
+		return nil, false
+	}
+	merged = occurrences[0].fieldType
+	for _, occurrence := range occurrences {
+		if !occurrence.shareable {
+			return nil, false
+		}
+		if merged, ok = merged.relaxed(occurrence.fieldType); !ok {
+			return nil, false
+		}
+	}
+	return merged, true
+}
+
+func (r *fieldConflictResolver) subgraphName(subgraph int) string {
+	if name := r.subgraphs[subgraph].Name; name != "" {
+		return name
+	}
+	return fmt.Sprintf("#%d", subgraph)
+}
@@ -180,6 +180,27 @@ func TestExtendObjectType(t *testing.T) {
 		`, noKeyDirectiveErrorMessage("Mammal"))
 	})
 
+	t.Run("extend object type by interfaces unions the implemented interfaces", func(t *testing.T) {
+		run(t, newExtendObjectTypeDefinition(newTestNormalizer(false)), `
+			type Sale implements History {
+				id: ID!
+			}
+
+			extend type Sale implements History & Refundable {
+				refunded: Boolean!
+			}
+		`, `
+			type Sale implements History & Refundable {
+				id: ID!
+				refunded: Boolean!
+			}
+
+			extend type Sale implements History & Refundable {
+				refunded: Boolean!
+			}
+		`)
+	})
+
 	t.Run("Extending multiple entities returns an error", func(t *testing.T) {
 		runAndExpectError(t, newExtendObjectTypeDefinition(newTestNormalizer(true)), `
 			 type Mammal @key(fields: "name") {
@@ -0,0 +1,37 @@
+package sdlmerge
+
+import (
+	"github.com/wundergraph/graphql-go-tools/pkg/ast"
+	"github.com/wundergraph/graphql-go-tools/pkg/astvisitor"
+)
+
+// newRemoveDuplicateDirectiveDefinitions keeps the first definition of custom directives defined by multiple subgraphs,
+// e.g. a @trace directive defined by every subgraph it is forwarded to
+func newRemoveDuplicateDirectiveDefinitions() *removeDuplicateDirectiveDefinitions {
+	return &removeDuplicateDirectiveDefinitions{}
+}
+
+type removeDuplicateDirectiveDefinitions struct{}
+
+func (r *removeDuplicateDirectiveDefinitions) Register(walker *astvisitor.Walker) {
+	walker.RegisterLeaveDocumentVisitor(r)
+}
+
+func (r *removeDuplicateDirectiveDefinitions) LeaveDocument(operation, _ *ast.Document) {
+	defined := make(map[string]struct{}, len(operation.DirectiveDefinitions))
+	var duplicates []ast.Node
+	for _, node := range operation.RootNodes {
+		if node.Kind != ast.NodeKindDirectiveDefinition {
+			continue
+		}
+		name := operation.DirectiveDefinitionNameString(node.Ref)
+		if _, exists := defined[name]; exists {
+			duplicates = append(duplicates, node)
+			continue
+		}
+		defined[name] = struct{}{}
+	}
+	if duplicates != nil {
+		operation.DeleteRootNodes(duplicates)
+	}
+}
@@ -0,0 +1,30 @@
+package sdlmerge
+
+import "testing"
+
+func TestRemoveDuplicateDirectiveDefinitions(t *testing.T) {
+	t.Run("Input and output are identical when no duplications", func(t *testing.T) {
+		run(t, newRemoveDuplicateDirectiveDefinitions(), `
+			directive @trace(label: String) on FIELD
+			directive @computed on FIELD_DEFINITION
+		`, `
+			directive @trace(label: String) on FIELD
+			directive @computed on FIELD_DEFINITION
+		`)
+	})
+
+	t.Run("Same name directive definitions are merged", func(t *testing.T) {
+		run(t, newRemoveDuplicateDirectiveDefinitions(), `
+			directive @trace(label: String) on FIELD
+			type Query {
+				me: String
+			}
+			directive @trace(label: String) on FIELD
+		`, `
+			directive @trace(label: String) on FIELD
+			type Query {
+				me: String
+			}
+		`)
+	})
+}
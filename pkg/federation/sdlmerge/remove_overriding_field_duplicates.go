@@ -0,0 +1,46 @@
+package sdlmerge
+
+import (
+	"github.com/wundergraph/graphql-go-tools/pkg/ast"
+	"github.com/wundergraph/graphql-go-tools/pkg/astvisitor"
+)
+
+const overrideDirectiveName = "override"
+
+// newRemoveOverridingFieldDuplicates removes fields with the @override directive if the type has another field with
+// the same name, which is the field of the subgraph the field is migrated from.
+func newRemoveOverridingFieldDuplicates() *removeOverridingFieldDuplicates {
+	return &removeOverridingFieldDuplicates{}
+}
+
+type removeOverridingFieldDuplicates struct {
+	operation *ast.Document
+}
+
+func (r *removeOverridingFieldDuplicates) Register(walker *astvisitor.Walker) {
+	walker.RegisterEnterDocumentVisitor(r)
+	walker.RegisterEnterObjectTypeDefinitionVisitor(r)
+}
+
+func (r *removeOverridingFieldDuplicates) EnterDocument(operation, _ *ast.Document) {
+	r.operation = operation
+}
+
+func (r *removeOverridingFieldDuplicates) EnterObjectTypeDefinition(ref int) {
+	fieldRefs := r.operation.ObjectTypeDefinitions[ref].FieldsDefinition.Refs
+	var refsForDeletion []int
+	for _, fieldRef := range fieldRefs {
+		if !r.operation.FieldDefinitionHasNamedDirective(fieldRef, overrideDirectiveName) {
+			continue
+		}
+		fieldName := r.operation.FieldDefinitionNameString(fieldRef)
+		for _, otherRef := range fieldRefs {
+			if otherRef != fieldRef && r.operation.FieldDefinitionNameString(otherRef) == fieldName &&
+				!r.operation.FieldDefinitionHasNamedDirective(otherRef, overrideDirectiveName) {
+				refsForDeletion = append(refsForDeletion, fieldRef)
+				break
+			}
+		}
+	}
+	r.operation.RemoveFieldDefinitionsFromObjectTypeDefinition(refsForDeletion, ref)
+}
@@ -0,0 +1,44 @@
+package sdlmerge
+
+import (
+	"testing"
+)
+
+func TestRemoveOverridingFieldDuplicates(t *testing.T) {
+	t.Run("remove overriding field with duplicate", func(t *testing.T) {
+		run(
+			t, newRemoveOverridingFieldDuplicates(),
+			`
+				type Product {
+					upc: String!
+					price: Int
+					name: String
+					price: Int @override(from: "products")
+				}
+			`,
+			`
+				type Product {
+					upc: String!
+					price: Int
+					name: String
+				}
+			`)
+	})
+
+	t.Run("keep overriding field without duplicate", func(t *testing.T) {
+		run(
+			t, newRemoveOverridingFieldDuplicates(),
+			`
+				type Product {
+					upc: String!
+					price: Int @override(from: "products")
+				}
+			`,
+			`
+				type Product {
+					upc: String!
+					price: Int @override(from: "products")
+				}
+			`)
+	})
+}
@@ -0,0 +1,44 @@
+package sdlmerge
+
+import (
+	"github.com/wundergraph/graphql-go-tools/pkg/ast"
+	"github.com/wundergraph/graphql-go-tools/pkg/astvisitor"
+)
+
+// newRemoveShareableFieldDuplicates removes the duplicates of fields with the @shareable directive, which are defined
+// by several subgraphs and therefore merged into the type once per subgraph. The first definition is kept.
+// Field definitions of several subgraphs have the same type once merged, see resolveFieldConflicts.
+func newRemoveShareableFieldDuplicates() *removeShareableFieldDuplicates {
+	return &removeShareableFieldDuplicates{}
+}
+
+type removeShareableFieldDuplicates struct {
+	operation *ast.Document
+}
+
+func (r *removeShareableFieldDuplicates) Register(walker *astvisitor.Walker) {
+	walker.RegisterEnterDocumentVisitor(r)
+	walker.RegisterEnterObjectTypeDefinitionVisitor(r)
+}
+
+func (r *removeShareableFieldDuplicates) EnterDocument(operation, _ *ast.Document) {
+	r.operation = operation
+}
+
+func (r *removeShareableFieldDuplicates) EnterObjectTypeDefinition(ref int) {
+	typeIsShareable := r.operation.ObjectTypeDefinitions[ref].Directives.HasDirectiveByName(r.operation, shareableDirectiveName)
+	fieldRefs := r.operation.ObjectTypeDefinitions[ref].FieldsDefinition.Refs
+	seen := make(map[string]struct{}, len(fieldRefs))
+	var refsForDeletion []int
+	for _, fieldRef := range fieldRefs {
+		fieldName := r.operation.FieldDefinitionNameString(fieldRef)
+		if _, ok := seen[fieldName]; !ok {
+			seen[fieldName] = struct{}{}
+			continue
+		}
+		if typeIsShareable || r.operation.FieldDefinitionHasNamedDirective(fieldRef, shareableDirectiveName) {
+			refsForDeletion = append(refsForDeletion, fieldRef)
+		}
+	}
+	r.operation.RemoveFieldDefinitionsFromObjectTypeDefinition(refsForDeletion, ref)
+}
@@ -0,0 +1,64 @@
+package sdlmerge
+
+import (
+	"testing"
+)
+
+func TestRemoveShareableFieldDuplicates(t *testing.T) {
+	t.Run("remove duplicate of shareable field", func(t *testing.T) {
+		run(
+			t, newRemoveShareableFieldDuplicates(),
+			`
+				type Product {
+					upc: String!
+					name: String! @shareable
+					price: Int
+					name: String! @shareable
+				}
+			`,
+			`
+				type Product {
+					upc: String!
+					name: String! @shareable
+					price: Int
+				}
+			`)
+	})
+
+	t.Run("remove duplicate of field of shareable type", func(t *testing.T) {
+		run(
+			t, newRemoveShareableFieldDuplicates(),
+			`
+				type Product @shareable {
+					upc: String!
+					name: String!
+					name: String!
+				}
+			`,
+			`
+				type Product @shareable {
+					upc: String!
+					name: String!
+				}
+			`)
+	})
+
+	t.Run("keep duplicate of field which is not shareable", func(t *testing.T) {
+		run(
+			t, newRemoveShareableFieldDuplicates(),
+			`
+				type Product {
+					upc: String!
+					name: String!
+					name: String!
+				}
+			`,
+			`
+				type Product {
+					upc: String!
+					name: String!
+					name: String!
+				}
+			`)
+	})
+}
@@ -0,0 +1,73 @@
+package sdlmerge
+
+import (
+	"github.com/wundergraph/graphql-go-tools/pkg/ast"
+)
+
+// RenameRootOperationTypes renames the root operation types of a subgraph configured via a schema definition
+// or extension, e.g. `schema { query: RootQuery }`, to Query, Mutation and Subscription and removes the schema
+// definitions and extensions. The merged schema always uses the default root operation type names, so the root
+// fields of all subgraphs end up on the same types.
+func RenameRootOperationTypes(document *ast.Document) {
+	renames := make(map[string][]byte)
+	for _, rootOperationTypeDefinition := range document.RootOperationTypeDefinitions {
+		typeName := document.Input.ByteSliceString(rootOperationTypeDefinition.NamedType.Name)
+		switch rootOperationTypeDefinition.OperationType {
+		case ast.OperationTypeQuery:
+			renames[typeName] = ast.DefaultQueryTypeName
+		case ast.OperationTypeMutation:
+			renames[typeName] = ast.DefaultMutationTypeName
+		case ast.OperationTypeSubscription:
+			renames[typeName] = ast.DefaultSubscriptionTypeName
+		}
+	}
+
+	for i := len(document.RootNodes) - 1; i >= 0; i-- {
+		switch document.RootNodes[i].Kind {
+		case ast.NodeKindSchemaDefinition, ast.NodeKindSchemaExtension:
+			document.RemoveRootNode(document.RootNodes[i])
+		}
+	}
+
+	for typeName, newTypeName := range renames {
+		if typeName == string(newTypeName) {
+			continue
+		}
+		renameType(document, typeName, newTypeName)
+	}
+
+	if len(renames) > 0 {
+		document.Index.QueryTypeName = ast.DefaultQueryTypeName
+		document.Index.MutationTypeName = ast.DefaultMutationTypeName
+		document.Index.SubscriptionTypeName = ast.DefaultSubscriptionTypeName
+	}
+}
+
+func renameType(document *ast.Document, typeName string, newTypeName []byte) {
+	newName := document.Input.AppendInputBytes(newTypeName)
+
+	for i := range document.ObjectTypeDefinitions {
+		if document.ObjectTypeDefinitionNameString(i) == typeName {
+			document.ObjectTypeDefinitions[i].Name = newName
+		}
+	}
+	for i := range document.ObjectTypeExtensions {
+		if document.ObjectTypeExtensionNameString(i) == typeName {
+			document.ObjectTypeExtensions[i].Name = newName
+		}
+	}
+	for i := range document.Types {
+		if document.Types[i].TypeKind == ast.TypeKindNamed && document.TypeNameString(i) == typeName {
+			document.Types[i].Name = newName
+		}
+	}
+
+	nodes, ok := document.Index.NodesByNameStr(typeName)
+	if !ok {
+		return
+	}
+	document.Index.RemoveNodeByName([]byte(typeName))
+	for _, node := range nodes {
+		document.Index.AddNodeBytes(newTypeName, node)
+	}
+}
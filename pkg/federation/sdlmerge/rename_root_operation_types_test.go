@@ -0,0 +1,91 @@
+package sdlmerge
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/wundergraph/graphql-go-tools/internal/pkg/unsafeparser"
+	"github.com/wundergraph/graphql-go-tools/pkg/astprinter"
+)
+
+func TestRenameRootOperationTypes(t *testing.T) {
+	runRename := func(t *testing.T, sdl, expectedOutput string) {
+		t.Helper()
+
+		document := unsafeparser.ParseGraphqlDocumentString(sdl)
+		expectedOutputDocument := unsafeparser.ParseGraphqlDocumentString(expectedOutput)
+
+		RenameRootOperationTypes(&document)
+
+		got := mustString(astprinter.PrintStringIndent(&document, nil, " "))
+		want := mustString(astprinter.PrintStringIndent(&expectedOutputDocument, nil, " "))
+		assert.Equal(t, want, got)
+		assert.Equal(t, "Query", document.Index.QueryTypeName.String())
+	}
+
+	t.Run("rename types of schema definition", func(t *testing.T) {
+		runRename(t, `
+			schema {
+				query: RootQuery
+				mutation: RootMutation
+				subscription: RootSubscription
+			}
+			type RootQuery {
+				viewer: RootQuery
+			}
+			type RootMutation {
+				like: Boolean
+			}
+			type RootSubscription {
+				likes: Int
+			}
+			extend type RootQuery {
+				me: String
+			}
+		`, `
+			type Query {
+				viewer: Query
+			}
+			type Mutation {
+				like: Boolean
+			}
+			type Subscription {
+				likes: Int
+			}
+			extend type Query {
+				me: String
+			}
+		`)
+	})
+
+	t.Run("rename types of schema extension", func(t *testing.T) {
+		runRename(t, `
+			extend schema {
+				query: RootQuery
+			}
+			type RootQuery {
+				me: String
+			}
+		`, `
+			type Query {
+				me: String
+			}
+		`)
+	})
+
+	t.Run("remove schema definition with default type names", func(t *testing.T) {
+		runRename(t, `
+			schema {
+				query: Query
+			}
+			type Query {
+				me: String
+			}
+		`, `
+			type Query {
+				me: String
+			}
+		`)
+	})
+}
@@ -36,10 +36,24 @@ func MergeAST(ast *ast.Document) error {
 	return normalizer.normalize(ast)
 }
 
+// MergeSDLs merges the SDLs of subgraphs into the SDL of the supergraph,
+// fields defined with different types in several subgraphs fail the merge
 func MergeSDLs(SDLs ...string) (string, error) {
-	rawDocs := make([]string, 0, len(SDLs)+1)
+	subgraphs := make([]Subgraph, 0, len(SDLs))
+	for _, sdl := range SDLs {
+		subgraphs = append(subgraphs, Subgraph{SDL: sdl})
+	}
+	return MergeSubgraphs(subgraphs, FieldConflictPolicyError)
+}
+
+// MergeSubgraphs merges the subgraphs into the SDL of the supergraph,
+// the policy decides how fields defined with different types in several subgraphs are merged
+func MergeSubgraphs(subgraphs []Subgraph, policy FieldConflictPolicy) (string, error) {
+	rawDocs := make([]string, 0, len(subgraphs)+1)
 	rawDocs = append(rawDocs, rootOperationTypeDefinitions)
-	rawDocs = append(rawDocs, SDLs...)
+	for _, subgraph := range subgraphs {
+		rawDocs = append(rawDocs, subgraph.SDL)
+	}
 	if validationError := validateSubgraphs(rawDocs[1:]); validationError != nil {
 		return "", validationError
 	}
@@ -47,6 +61,17 @@ func MergeSDLs(SDLs ...string) (string, error) {
 		return "", normalizationError
 	}
 
+	normalizedSubgraphs := make([]Subgraph, len(subgraphs))
+	for i := range subgraphs {
+		normalizedSubgraphs[i] = Subgraph{Name: subgraphs[i].Name, SDL: rawDocs[i+1]}
+	}
+	if err := resolveFieldConflicts(normalizedSubgraphs, policy); err != nil {
+		return "", err
+	}
+	for i := range normalizedSubgraphs {
+		rawDocs[i+1] = normalizedSubgraphs[i].SDL
+	}
+
 	doc, report := astparser.ParseGraphqlDocumentString(strings.Join(rawDocs, "\n"))
 	if report.HasErrors() {
 		return "", fmt.Errorf("parse graphql document string: %w", report)
@@ -96,6 +121,8 @@ func normalizeSubgraphs(subgraphs []string) error {
 		if report.HasErrors() {
 			return fmt.Errorf(parseDocumentError, report)
 		}
+		asttransform.ResolveLinkImports(&doc)
+		RenameRootOperationTypes(&doc)
 		subgraphNormalizer.NormalizeDefinition(&doc, &report)
 		if report.HasErrors() {
 			return fmt.Errorf("normalize schema: %w", report)
@@ -134,11 +161,14 @@ func (m *normalizer) setupWalkers() {
 		// visitors for cleaning up federated duplicated fields and directives
 		{
 			newRemoveFieldDefinitions("external"),
+			newRemoveOverridingFieldDuplicates(),
+			newRemoveShareableFieldDuplicates(),
 			newRemoveDuplicateFieldedSharedTypesVisitor(),
 			newRemoveDuplicateFieldlessSharedTypesVisitor(),
+			newRemoveDuplicateDirectiveDefinitions(),
 			newRemoveInterfaceDefinitionDirective("key"),
-			newRemoveObjectTypeDefinitionDirective("key"),
-			newRemoveFieldDefinitionDirective("provides", "requires"),
+			newRemoveObjectTypeDefinitionDirective("key", shareableDirectiveName),
+			newRemoveFieldDefinitionDirective("provides", "requires", overrideDirectiveName, shareableDirectiveName),
 		},
 	}
 
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -114,6 +115,57 @@ func TestMergeSDLs(t *testing.T) {
 		accountSchema, productSchema, reviewSchema, likeSchema, disLikeSchema, paymentSchema, onlinePaymentSchema, classicPaymentSchema,
 	))
 
+	t.Run("should merge sdls with custom root operation type names", runMergeTest(
+		`
+			type Query {
+				me: User
+				latestReview: Review
+			}
+			type Mutation {
+				addReview(body: String!): Review
+			}
+			type User {
+				id: ID!
+				username: String!
+				reviews: [Review]
+			}
+			type Review {
+				body: String!
+			}
+		`,
+		`
+			schema {
+				query: RootQuery
+			}
+			type RootQuery {
+				me: User
+			}
+			type User @key(fields: "id") {
+				id: ID!
+				username: String!
+			}
+		`,
+		`
+			extend schema {
+				query: ReviewsQuery
+				mutation: ReviewsMutation
+			}
+			type ReviewsQuery {
+				latestReview: Review
+			}
+			type ReviewsMutation {
+				addReview(body: String!): Review
+			}
+			type Review {
+				body: String!
+			}
+			extend type User @key(fields: "id") {
+				id: ID! @external
+				reviews: [Review]
+			}
+		`,
+	))
+
 	t.Run("When merging product and review, the unresolved orphan extension for User will return an error", runMergeTestAndExpectError(
 		unresolvedExtensionOrphansErrorMessage("User"),
 		productSchema, reviewSchema,
@@ -145,6 +197,95 @@ func TestMergeSDLs(t *testing.T) {
 	))
 }
 
+func TestMergeSubgraphs(t *testing.T) {
+	inventory := Subgraph{Name: "inventory", SDL: `
+		extend type Query {
+			inStock: [Product]
+		}
+
+		type Product @shareable {
+			name: String!
+			price: Float
+		}
+	`}
+	products := Subgraph{Name: "products", SDL: `
+		extend type Query {
+			topProducts: [Product]
+		}
+
+		type Product @shareable {
+			name: String!
+			price: Int!
+		}
+	`}
+
+	t.Run("fields with different types should return an error naming the field, the subgraphs and the types", func(t *testing.T) {
+		for _, policy := range []FieldConflictPolicy{FieldConflictPolicyError, FieldConflictPolicyShareable} {
+			_, err := MergeSubgraphs([]Subgraph{products, inventory}, policy)
+			actual, _ := operationreport.ExternalErrorMessage(err, testFormatExternalErrorMessage)
+			assert.Equal(t, "the field 'Product.price' must have the same type in any subgraphs to federate, it's 'Int!' in the subgraph 'products' and 'Float' in the subgraph 'inventory'", actual)
+		}
+	})
+
+	t.Run("unnamed subgraphs should be named by their index", func(t *testing.T) {
+		_, err := MergeSDLs(products.SDL, inventory.SDL)
+		actual, _ := operationreport.ExternalErrorMessage(err, testFormatExternalErrorMessage)
+		assert.Equal(t, "the field 'Product.price' must have the same type in any subgraphs to federate, it's 'Int!' in the subgraph '#0' and 'Float' in the subgraph '#1'", actual)
+	})
+
+	reviews := Subgraph{Name: "reviews", SDL: `
+		extend type Query {
+			topReviews: [Review]
+		}
+
+		type Review {
+			body: String!
+			tags: [String!] @shareable
+		}
+	`}
+	ratings := Subgraph{Name: "ratings", SDL: `
+		extend type Query {
+			topRated: [Review]
+		}
+
+		type Review {
+			body: String!
+			tags: [String]! @shareable
+		}
+	`}
+
+	t.Run("shareable fields differing in nullability should be relaxed by the shareable policy", func(t *testing.T) {
+		got, err := MergeSubgraphs([]Subgraph{reviews, ratings}, FieldConflictPolicyShareable)
+		require.NoError(t, err)
+
+		expectedOutputDocument := unsafeparser.ParseGraphqlDocumentString(`
+			type Query {
+				topReviews: [Review]
+				topRated: [Review]
+			}
+
+			type Review {
+				body: String!
+				tags: [String]
+			}
+		`)
+		assert.Equal(t, mustString(astprinter.PrintString(&expectedOutputDocument, nil)), got)
+	})
+
+	t.Run("shareable fields differing in nullability should return an error by default", func(t *testing.T) {
+		_, err := MergeSubgraphs([]Subgraph{reviews, ratings}, FieldConflictPolicyError)
+		actual, _ := operationreport.ExternalErrorMessage(err, testFormatExternalErrorMessage)
+		assert.Equal(t, "the field 'Review.tags' must have the same type in any subgraphs to federate, it's '[String!]' in the subgraph 'reviews' and '[String]!' in the subgraph 'ratings'", actual)
+	})
+
+	t.Run("fields differing in nullability should return an error if not every definition is shareable", func(t *testing.T) {
+		ratings := Subgraph{Name: "ratings", SDL: strings.Replace(ratings.SDL, "tags: [String]! @shareable", "tags: [String]!", 1)}
+		_, err := MergeSubgraphs([]Subgraph{reviews, ratings}, FieldConflictPolicyShareable)
+		actual, _ := operationreport.ExternalErrorMessage(err, testFormatExternalErrorMessage)
+		assert.Equal(t, "the field 'Review.tags' must have the same type in any subgraphs to federate, it's '[String!]' in the subgraph 'reviews' and '[String]!' in the subgraph 'ratings'", actual)
+	})
+}
+
 const (
 	accountSchema = `
 		extend type Query {
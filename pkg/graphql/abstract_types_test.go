@@ -0,0 +1,148 @@
+package graphql
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jensneuse/abstractlogger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/wundergraph/graphql-go-tools/pkg/engine/datasource/graphql_datasource"
+)
+
+func TestExecutionEngineV2_AbstractTypes(t *testing.T) {
+	accountsResponses, accountsRequests := make(chan string, 1), make(chan string, 1)
+	accounts := newUpstream(t, accountsResponses, accountsRequests)
+	salesResponses, salesRequests := make(chan string, 1), make(chan string, 1)
+	sales := newUpstream(t, salesResponses, salesRequests)
+
+	// The History interface is implemented by Purchase in the accounts subgraph and by Sale, an entity of the
+	// sales subgraph which doesn't know the interface.
+	factory := NewFederationEngineConfigFactory([]graphql_datasource.Configuration{
+		{
+			Fetch: graphql_datasource.FetchConfiguration{
+				URL: accounts.URL,
+			},
+			Federation: graphql_datasource.FederationConfiguration{
+				Enabled:    true,
+				ServiceSDL: `extend type Query { me: User } type User @key(fields: "id") { id: ID! history: [History!]! activity: [Activity!]! } interface History { id: ID! } type Purchase implements History { id: ID! price: Int! } extend type Sale implements History @key(fields: "id") { id: ID! @external } union Activity = Purchase | Sale`,
+			},
+		},
+		{
+			Fetch: graphql_datasource.FetchConfiguration{
+				URL: sales.URL,
+			},
+			Federation: graphql_datasource.FederationConfiguration{
+				Enabled:    true,
+				ServiceSDL: `extend type Query { topSales: [Sale] } type Sale @key(fields: "id") { id: ID! amount: Int! }`,
+			},
+		},
+	}, graphql_datasource.NewBatchFactory())
+	engineConf, err := factory.EngineV2Configuration()
+	require.NoError(t, err)
+
+	engineCtx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	engine, err := NewExecutionEngineV2(engineCtx, abstractlogger.NoopLogger, engineConf)
+	require.NoError(t, err)
+
+	execute := func(t *testing.T, query string) string {
+		operation := Request{Query: query}
+		resultWriter := NewEngineResultWriter()
+		require.NoError(t, engine.Execute(context.Background(), &operation, &resultWriter))
+		return resultWriter.String()
+	}
+
+	t.Run("interface fields are resolved for the implementations of all subgraphs", func(t *testing.T) {
+		accountsResponses <- `{"data":{"me":{"history":[{"__typename":"Purchase","id":"p1","price":10},{"__typename":"Sale","id":"s1"}]}}}`
+		salesResponses <- `{"data":{"_entities":[{"__typename":"Sale","amount":20}]}}`
+
+		assert.JSONEq(t, `{"data":{"me":{"history":[{"id":"p1","price":10},{"id":"s1","amount":20}]}}}`,
+			execute(t, `{ me { history { id ... on Purchase { price } ... on Sale { amount } } } }`))
+		<-accountsRequests
+		assert.Contains(t, <-salesRequests, `... on Sale {amount}`)
+	})
+
+	t.Run("fields of an interface fragment are resolved for all its possible types", func(t *testing.T) {
+		accountsResponses <- `{"data":{"me":{"activity":[{"__typename":"Purchase","id":"p1"},{"__typename":"Sale","id":"s1"}]}}}`
+
+		assert.JSONEq(t, `{"data":{"me":{"activity":[{"id":"p1"},{"id":"s1"}]}}}`,
+			execute(t, `{ me { activity { ... on History { id } } } }`))
+		assert.Contains(t, <-accountsRequests, `... on History {`)
+		assert.Empty(t, salesRequests)
+	})
+}
+
+func TestExecutionEngineV2_AbstractTypeFragmentsAcrossSubgraphs(t *testing.T) {
+	accountsResponses, accountsRequests := make(chan string, 1), make(chan string, 1)
+	accounts := newUpstream(t, accountsResponses, accountsRequests)
+	walletsResponses, walletsRequests := make(chan string, 1), make(chan string, 1)
+	wallets := newUpstream(t, walletsResponses, walletsRequests)
+	ratingsResponses, ratingsRequests := make(chan string, 1), make(chan string, 1)
+	ratings := newUpstream(t, ratingsResponses, ratingsRequests)
+
+	// The implementations of the History interface are owned by the accounts subgraph,
+	// their extra fields are split across the wallets and the ratings subgraph.
+	factory := NewFederationEngineConfigFactory([]graphql_datasource.Configuration{
+		{
+			Fetch: graphql_datasource.FetchConfiguration{
+				URL: accounts.URL,
+			},
+			Federation: graphql_datasource.FederationConfiguration{
+				Enabled:    true,
+				ServiceSDL: `extend type Query { me: User } type User @key(fields: "id") { id: ID! username: String! history: [History!]! } interface History { id: ID! } type Purchase implements History @key(fields: "id") { id: ID! } type Sale implements History @key(fields: "id") { id: ID! }`,
+			},
+		},
+		{
+			Fetch: graphql_datasource.FetchConfiguration{
+				URL: wallets.URL,
+			},
+			Federation: graphql_datasource.FederationConfiguration{
+				Enabled:    true,
+				ServiceSDL: `extend type Purchase @key(fields: "id") { id: ID! @external wallet: Wallet } interface Wallet { currency: String! amount: Float! } type WalletType1 implements Wallet { currency: String! amount: Float! specialField1: String! } type WalletType2 implements Wallet { currency: String! amount: Float! specialField2: String! }`,
+			},
+		},
+		{
+			Fetch: graphql_datasource.FetchConfiguration{
+				URL: ratings.URL,
+			},
+			Federation: graphql_datasource.FederationConfiguration{
+				Enabled:    true,
+				ServiceSDL: `extend type Sale @key(fields: "id") { id: ID! @external rating: Int! }`,
+			},
+		},
+	}, graphql_datasource.NewBatchFactory())
+	engineConf, err := factory.EngineV2Configuration()
+	require.NoError(t, err)
+
+	engineCtx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	engine, err := NewExecutionEngineV2(engineCtx, abstractlogger.NoopLogger, engineConf)
+	require.NoError(t, err)
+
+	accountsResponses <- `{"data":{"me":{"username":"Me","history":[{"__typename":"Purchase","id":"p1"},{"__typename":"Sale","id":"s1"}]}}}`
+	walletsResponses <- `{"data":{"_entities":[{"__typename":"Purchase","wallet":{"__typename":"WalletType1","amount":123,"specialField1":"some special value 1"}}]}}`
+	ratingsResponses <- `{"data":{"_entities":[{"__typename":"Sale","rating":5}]}}`
+
+	operation := Request{Query: `{ me { username history { ... on Purchase { wallet { amount ... on WalletType1 { specialField1 } ... on WalletType2 { specialField2 } } } ... on Sale { rating } } } }`}
+	resultWriter := NewEngineResultWriter()
+	require.NoError(t, engine.Execute(context.Background(), &operation, &resultWriter))
+	assert.JSONEq(t, `{"data":{"me":{"username":"Me","history":[{"wallet":{"amount":123,"specialField1":"some special value 1"}},{"rating":5}]}}}`,
+		resultWriter.String())
+
+	accountsRequest := <-accountsRequests
+	assert.NotContains(t, accountsRequest, "wallet")
+	assert.NotContains(t, accountsRequest, "rating")
+
+	walletsRequest := <-walletsRequests
+	assert.Contains(t, walletsRequest, `... on Purchase {wallet {`)
+	assert.Contains(t, walletsRequest, `... on WalletType1 {specialField1}`)
+	assert.NotContains(t, walletsRequest, "Sale")
+
+	ratingsRequest := <-ratingsRequests
+	assert.Contains(t, ratingsRequest, `... on Sale {rating}`)
+	assert.NotContains(t, ratingsRequest, "Purchase")
+}
@@ -0,0 +1,92 @@
+package graphql
+
+import (
+	"fmt"
+
+	"github.com/wundergraph/graphql-go-tools/pkg/ast"
+	"github.com/wundergraph/graphql-go-tools/pkg/astvisitor"
+	"github.com/wundergraph/graphql-go-tools/pkg/operationreport"
+)
+
+// aliasLimitVisitor counts the aliased selections of each field coordinate, e.g. "Query.user", within a selection set.
+// It rejects operations selecting a field under more aliases than the limit, so an expensive field can't be
+// amplified by requesting it under thousands of aliases.
+type aliasLimitVisitor struct {
+	*astvisitor.Walker
+	operation, definition *ast.Document
+	operationName         string
+	maxAliases            int
+	// aliases are the numbers of aliased selections keyed by the selection set and the field coordinate
+	aliases map[aliasedField]int
+	errs    RequestErrors
+}
+
+type aliasedField struct {
+	selectionSet int
+	typeName     string
+	fieldName    string
+}
+
+func (v *aliasLimitVisitor) EnterOperationDefinition(ref int) {
+	if selected, _ := v.operation.OperationDefinitionRefByName(v.operationName); ref != selected {
+		v.SkipNode()
+	}
+}
+
+func (v *aliasLimitVisitor) EnterField(ref int) {
+	if !v.operation.FieldAliasIsDefined(ref) || len(v.Ancestors) == 0 {
+		return
+	}
+	parent := v.Ancestors[len(v.Ancestors)-1]
+	if parent.Kind != ast.NodeKindSelectionSet {
+		return
+	}
+
+	key := aliasedField{
+		selectionSet: parent.Ref,
+		typeName:     v.EnclosingTypeDefinition.NameString(v.definition),
+		fieldName:    v.operation.FieldNameString(ref),
+	}
+	v.aliases[key]++
+	if v.aliases[key] != v.maxAliases+1 {
+		return
+	}
+	v.errs = append(v.errs, RequestError{
+		Message: fmt.Sprintf(`Field "%s.%s" is selected under more than %d aliases.`,
+			key.typeName, key.fieldName, v.maxAliases),
+		Locations: operationreport.LocationsFromPosition(v.operation.Fields[ref].Position),
+	})
+}
+
+// validateAliasLimit returns an error for each field selected under more than maxAliases aliases within a
+// selection set, a maxAliases of 0 disables the limit
+func (r *Request) validateAliasLimit(schema *Schema, maxAliases int) error {
+	if maxAliases <= 0 {
+		return nil
+	}
+
+	report := r.parseQueryOnce()
+	if report.HasErrors() {
+		return report
+	}
+
+	walker := astvisitor.NewWalker(48)
+	visitor := aliasLimitVisitor{
+		Walker:        &walker,
+		operation:     &r.document,
+		definition:    &schema.document,
+		operationName: r.OperationName,
+		maxAliases:    maxAliases,
+		aliases:       make(map[aliasedField]int),
+	}
+	walker.RegisterEnterOperationVisitor(&visitor)
+	walker.RegisterEnterFieldVisitor(&visitor)
+	walker.Walk(&r.document, &schema.document, &report)
+	if report.HasErrors() {
+		return report
+	}
+	if len(visitor.errs) > 0 {
+		return visitor.errs
+	}
+	return nil
+}
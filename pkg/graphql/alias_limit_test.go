@@ -0,0 +1,84 @@
+package graphql
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/jensneuse/abstractlogger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/wundergraph/graphql-go-tools/pkg/engine/datasource/graphql_datasource"
+	"github.com/wundergraph/graphql-go-tools/pkg/engine/plan"
+	"github.com/wundergraph/graphql-go-tools/pkg/graphqlerrors"
+)
+
+func TestExecutionEngineV2_MaxAliasesPerField(t *testing.T) {
+	schema, err := NewSchemaFromString(`
+		schema { query: Query }
+
+		type Query {
+			user: User
+		}
+
+		type User {
+			name: String!
+		}`)
+	require.NoError(t, err)
+
+	upstream := &recordingRoundTripper{
+		responseBody: `{"data":{"a":{"name":"Jens","first":"Jens","second":"Jens"},"b":{"name":"Jens"}}}`,
+	}
+
+	engineConf := NewEngineV2Configuration(schema)
+	engineConf.SetMaxAliasesPerField(2)
+	engineConf.SetDataSources([]plan.DataSourceConfiguration{
+		{
+			RootNodes: []plan.TypeField{
+				{TypeName: "Query", FieldNames: []string{"user"}},
+			},
+			ChildNodes: []plan.TypeField{
+				{TypeName: "User", FieldNames: []string{"name"}},
+			},
+			Factory: &graphql_datasource.Factory{
+				HTTPClient: &http.Client{Transport: upstream},
+			},
+			Custom: graphql_datasource.ConfigJson(graphql_datasource.Configuration{
+				Fetch: graphql_datasource.FetchConfiguration{
+					URL:    "https://example.com/",
+					Method: "POST",
+				},
+			}),
+		},
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	engine, err := NewExecutionEngineV2(ctx, abstractlogger.Noop{}, engineConf)
+	require.NoError(t, err)
+
+	t.Run("operation within the limit is executed", func(t *testing.T) {
+		operation := Request{Query: `{ a: user { name first: name second: name } b: user { name } }`}
+		resultWriter := NewEngineResultWriter()
+		require.NoError(t, engine.Execute(context.Background(), &operation, &resultWriter))
+		assert.Equal(t, `{"data":{"a":{"name":"Jens","first":"Jens","second":"Jens"},"b":{"name":"Jens"}}}`, resultWriter.String())
+	})
+
+	t.Run("operation exceeding the limit is rejected before execution", func(t *testing.T) {
+		upstream.requests = nil
+
+		operation := Request{Query: `{ a: user { name } b: user { name } c: user { name } }`}
+		resultWriter := NewEngineResultWriter()
+		err := engine.Execute(context.Background(), &operation, &resultWriter)
+		require.Error(t, err)
+
+		var requestErrors RequestErrors
+		require.ErrorAs(t, err, &requestErrors)
+		require.Len(t, requestErrors, 1)
+		assert.Equal(t, `Field "Query.user" is selected under more than 2 aliases.`, requestErrors[0].Message)
+		assert.Equal(t, []graphqlerrors.Location{{Line: 1, Column: 37}}, requestErrors[0].Locations)
+		assert.Empty(t, upstream.requests)
+	})
+}
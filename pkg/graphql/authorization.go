@@ -0,0 +1,214 @@
+package graphql
+
+import (
+	"context"
+	"sort"
+
+	"github.com/wundergraph/graphql-go-tools/pkg/ast"
+	"github.com/wundergraph/graphql-go-tools/pkg/astvisitor"
+	"github.com/wundergraph/graphql-go-tools/pkg/engine/plan"
+	"github.com/wundergraph/graphql-go-tools/pkg/operationreport"
+)
+
+const (
+	authenticatedDirectiveName  = "authenticated"
+	requiresScopesDirectiveName = "requiresScopes"
+	requiresScopesScopesArg     = "scopes"
+)
+
+type authorizationScopesContextKey struct{}
+
+// WithAuthorizationScopes returns a context of an authenticated request granted the scopes,
+// e.g. populated from a verified token in a header of the request.
+// Executing an operation with the context enforces the federation directives of the fields of the schema:
+//
+//	directive @authenticated on FIELD_DEFINITION | OBJECT | INTERFACE | SCALAR | ENUM
+//	directive @requiresScopes(scopes: [[String!]!]!) on FIELD_DEFINITION | OBJECT | INTERFACE | SCALAR | ENUM
+//
+// Fields with @authenticated require an authenticated request. Fields with @requiresScopes require all scopes
+// of any of the inner lists. The directives of a type restrict its fields and the fields returning it.
+// Fields selected on an interface are restricted by the directives of all object types implementing the interface.
+// Operations executed with a context without scopes are unauthenticated.
+func WithAuthorizationScopes(ctx context.Context, scopes ...string) context.Context {
+	if scopes == nil {
+		scopes = []string{}
+	}
+	return context.WithValue(ctx, authorizationScopesContextKey{}, scopes)
+}
+
+// AuthorizationScopesFromContext returns the scopes granted to the request and whether the request is authenticated
+func AuthorizationScopesFromContext(ctx context.Context) (scopes []string, authenticated bool) {
+	scopes, authenticated = ctx.Value(authorizationScopesContextKey{}).([]string)
+	return scopes, authenticated
+}
+
+// unauthorizedFields returns the fields selected by the operation which the request isn't authorized to access,
+// sorted by type and field name. The fields are resolved to null with an error instead of being fetched.
+func (r *Request) unauthorizedFields(ctx context.Context, schema *Schema) ([]plan.TypeField, error) {
+	scopes, authenticated := AuthorizationScopesFromContext(ctx)
+	granted := make(map[string]struct{}, len(scopes))
+	for _, scope := range scopes {
+		granted[scope] = struct{}{}
+	}
+
+	walker := astvisitor.NewWalker(48)
+	visitor := &authorizationVisitor{
+		Walker:        &walker,
+		operation:     &r.document,
+		definition:    &schema.document,
+		operationName: r.OperationName,
+		authenticated: authenticated,
+		granted:       granted,
+		unauthorized:  map[string]map[string]struct{}{},
+	}
+	walker.RegisterEnterOperationVisitor(visitor)
+	walker.RegisterEnterFieldVisitor(visitor)
+
+	report := operationreport.Report{}
+	walker.Walk(&r.document, &schema.document, &report)
+	if report.HasErrors() {
+		return nil, report
+	}
+
+	if len(visitor.unauthorized) == 0 {
+		return nil, nil
+	}
+	typeFields := make([]plan.TypeField, 0, len(visitor.unauthorized))
+	for typeName, fields := range visitor.unauthorized {
+		typeField := plan.TypeField{TypeName: typeName, FieldNames: make([]string, 0, len(fields))}
+		for fieldName := range fields {
+			typeField.FieldNames = append(typeField.FieldNames, fieldName)
+		}
+		sort.Strings(typeField.FieldNames)
+		typeFields = append(typeFields, typeField)
+	}
+	sort.Slice(typeFields, func(i, j int) bool {
+		return typeFields[i].TypeName < typeFields[j].TypeName
+	})
+	return typeFields, nil
+}
+
+type authorizationVisitor struct {
+	*astvisitor.Walker
+	operation, definition *ast.Document
+	operationName         string
+	authenticated         bool
+	granted               map[string]struct{}
+	unauthorized          map[string]map[string]struct{}
+}
+
+func (a *authorizationVisitor) EnterOperationDefinition(ref int) {
+	if selected, _ := a.operation.OperationDefinitionRefByName(a.operationName); ref != selected {
+		a.SkipNode()
+	}
+}
+
+func (a *authorizationVisitor) EnterField(ref int) {
+	fieldName := a.operation.FieldNameBytes(ref)
+	if a.isAuthorized(authorizationDirectives(a.definition, a.EnclosingTypeDefinition, fieldName)) {
+		return
+	}
+
+	typeName := a.definition.NodeNameString(a.EnclosingTypeDefinition)
+	fields, ok := a.unauthorized[typeName]
+	if !ok {
+		fields = map[string]struct{}{}
+		a.unauthorized[typeName] = fields
+	}
+	fields[string(fieldName)] = struct{}{}
+	// the selections of an unauthorized field are not resolved
+	a.SkipNode()
+}
+
+// isAuthorized reports whether the request satisfies all authorization directives
+func (a *authorizationVisitor) isAuthorized(directives []int) bool {
+	for _, directive := range directives {
+		if !a.authenticated {
+			return false
+		}
+		if a.definition.DirectiveNameString(directive) == requiresScopesDirectiveName && !a.hasAnyScopes(directive) {
+			return false
+		}
+	}
+	return true
+}
+
+// hasAnyScopes reports whether all scopes of any of the inner lists of the @requiresScopes directive are granted
+func (a *authorizationVisitor) hasAnyScopes(directive int) bool {
+	scopes, ok := a.definition.DirectiveArgumentValueByName(directive, []byte(requiresScopesScopesArg))
+	if !ok || scopes.Kind != ast.ValueKindList {
+		return false
+	}
+	for _, ref := range a.definition.ListValues[scopes.Ref].Refs {
+		if a.hasAllScopes(a.definition.Value(ref)) {
+			return true
+		}
+	}
+	return false
+}
+
+// hasAllScopes reports whether all scopes of the list value are granted
+func (a *authorizationVisitor) hasAllScopes(scopes ast.Value) bool {
+	if scopes.Kind != ast.ValueKindList {
+		return false
+	}
+	for _, ref := range a.definition.ListValues[scopes.Ref].Refs {
+		scope := a.definition.Value(ref)
+		if scope.Kind != ast.ValueKindString {
+			return false
+		}
+		if _, ok := a.granted[a.definition.StringValueContentString(scope.Ref)]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// authorizationDirectives returns the @authenticated and @requiresScopes directives restricting the field of the type,
+// i.e. the directives of the field definition, of the type and of the type returned by the field.
+// Fields of interfaces are restricted by the directives of the fields of all object types implementing the interface,
+// like denied fields are removed from interfaces, see Request.RemoveFields.
+func authorizationDirectives(definition *ast.Document, typeNode ast.Node, fieldName ast.ByteSlice) (directives []int) {
+	fieldDefinition, ok := definition.NodeFieldDefinitionByName(typeNode, fieldName)
+	if !ok {
+		return nil
+	}
+	directives = appendAuthorizationDirectives(directives, definition, definition.FieldDefinitions[fieldDefinition].Directives.Refs)
+	directives = appendAuthorizationDirectives(directives, definition, definition.NodeDirectives(typeNode))
+	directives = appendAuthorizationDirectives(directives, definition, definition.NodeDirectives(definition.FieldDefinitionTypeNode(fieldDefinition)))
+
+	if typeNode.Kind != ast.NodeKindInterfaceTypeDefinition {
+		return directives
+	}
+	for _, implementingNode := range definition.InterfaceTypeDefinitionImplementedByRootNodes(typeNode.Ref) {
+		if implementingNode.Kind != ast.NodeKindObjectTypeDefinition {
+			continue
+		}
+		directives = append(directives, authorizationDirectives(definition, implementingNode, fieldName)...)
+	}
+	return directives
+}
+
+func appendAuthorizationDirectives(directives []int, definition *ast.Document, refs []int) []int {
+	for _, ref := range refs {
+		switch definition.DirectiveNameString(ref) {
+		case authenticatedDirectiveName, requiresScopesDirectiveName:
+			directives = append(directives, ref)
+		}
+	}
+	return directives
+}
+
+// hasAuthorizationDirectives reports whether a field or a type of the schema has @authenticated or @requiresScopes
+func hasAuthorizationDirectives(schema *Schema) bool {
+	if schema == nil {
+		return false
+	}
+	for i := range schema.document.Directives {
+		switch schema.document.DirectiveNameString(i) {
+		case authenticatedDirectiveName, requiresScopesDirectiveName:
+			return true
+		}
+	}
+	return false
+}
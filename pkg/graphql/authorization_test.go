@@ -0,0 +1,162 @@
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/jensneuse/abstractlogger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/wundergraph/graphql-go-tools/pkg/engine/datasource/graphql_datasource"
+	"github.com/wundergraph/graphql-go-tools/pkg/engine/plan"
+)
+
+func TestExecutionEngineV2_Authorization(t *testing.T) {
+	accountsUpstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"data":{"me":{"id":"1234","username":"Me","lastLogin":"yesterday"}}}`))
+	}))
+	defer accountsUpstream.Close()
+
+	var emailFetches int64
+	emailsUpstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt64(&emailFetches, 1)
+		_, _ = w.Write([]byte(`{"data":{"_entities":[{"__typename":"User","email":"me@example.com"}]}}`))
+	}))
+	defer emailsUpstream.Close()
+
+	factory := NewFederationEngineConfigFactory([]graphql_datasource.Configuration{
+		{
+			Fetch: graphql_datasource.FetchConfiguration{
+				URL: accountsUpstream.URL,
+			},
+			Federation: graphql_datasource.FederationConfiguration{
+				Enabled:    true,
+				ServiceSDL: `extend type Query { me: User } type User @key(fields: "id") { id: ID! username: String! lastLogin: String @authenticated }`,
+			},
+		},
+		{
+			Fetch: graphql_datasource.FetchConfiguration{
+				URL: emailsUpstream.URL,
+			},
+			Federation: graphql_datasource.FederationConfiguration{
+				Enabled:    true,
+				ServiceSDL: `extend type User @key(fields: "id") { id: ID! @external email: String @requiresScopes(scopes: [["read:email"]]) }`,
+			},
+		},
+	}, graphql_datasource.NewBatchFactory())
+	engineConf, err := factory.EngineV2Configuration()
+	require.NoError(t, err)
+
+	engineCtx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	engine, err := NewExecutionEngineV2(engineCtx, abstractlogger.NoopLogger, engineConf)
+	require.NoError(t, err)
+
+	type response struct {
+		Errors []struct {
+			Message string        `json:"message"`
+			Path    []interface{} `json:"path"`
+		} `json:"errors"`
+		Data json.RawMessage `json:"data"`
+	}
+
+	execute := func(t *testing.T, ctx context.Context, query string) response {
+		operation := Request{Query: query}
+		resultWriter := NewEngineResultWriter()
+		require.NoError(t, engine.Execute(ctx, &operation, &resultWriter))
+
+		var resp response
+		require.NoError(t, json.Unmarshal(resultWriter.Bytes(), &resp))
+		return resp
+	}
+
+	t.Run("requires scopes", func(t *testing.T) {
+		for _, ctx := range []context.Context{
+			context.Background(),
+			WithAuthorizationScopes(context.Background()),
+			WithAuthorizationScopes(context.Background(), "read:username"),
+		} {
+			atomic.StoreInt64(&emailFetches, 0)
+			resp := execute(t, ctx, `{ me { username email } }`)
+			assert.Equal(t, `{"me":{"username":"Me","email":null}}`, string(resp.Data))
+			require.Len(t, resp.Errors, 1)
+			assert.Equal(t, "unauthorized to access field User.email", resp.Errors[0].Message)
+			assert.Equal(t, []interface{}{"me", "email"}, resp.Errors[0].Path)
+			assert.Equal(t, int64(0), atomic.LoadInt64(&emailFetches))
+		}
+
+		atomic.StoreInt64(&emailFetches, 0)
+		resp := execute(t, WithAuthorizationScopes(context.Background(), "read:username", "read:email"), `{ me { username email } }`)
+		assert.Equal(t, `{"me":{"username":"Me","email":"me@example.com"}}`, string(resp.Data))
+		assert.Len(t, resp.Errors, 0)
+		assert.Equal(t, int64(1), atomic.LoadInt64(&emailFetches))
+	})
+
+	t.Run("authenticated", func(t *testing.T) {
+		resp := execute(t, context.Background(), `{ me { username lastLogin } }`)
+		assert.Equal(t, `{"me":{"username":"Me","lastLogin":null}}`, string(resp.Data))
+		require.Len(t, resp.Errors, 1)
+		assert.Equal(t, "unauthorized to access field User.lastLogin", resp.Errors[0].Message)
+
+		resp = execute(t, WithAuthorizationScopes(context.Background()), `{ me { username lastLogin } }`)
+		assert.Equal(t, `{"me":{"username":"Me","lastLogin":"yesterday"}}`, string(resp.Data))
+		assert.Len(t, resp.Errors, 0)
+	})
+}
+
+func TestRequest_unauthorizedFields(t *testing.T) {
+	schema, err := NewSchemaFromString(`
+		directive @authenticated on FIELD_DEFINITION | OBJECT | INTERFACE | SCALAR | ENUM
+		directive @requiresScopes(scopes: [[String!]!]!) on FIELD_DEFINITION | OBJECT | INTERFACE | SCALAR | ENUM
+
+		schema { query: Query }
+		type Query { node(id: ID!): Node me: User account: Account }
+		interface Node { id: ID! secret: String }
+		type User implements Node { id: ID! name: String secret: String @requiresScopes(scopes: [["read:secret"]]) }
+		type Product implements Node { id: ID! secret: String }
+		type Account @authenticated { id: ID! balance: Int }
+	`)
+	require.NoError(t, err)
+
+	unauthorizedFields := func(t *testing.T, ctx context.Context, query string) []plan.TypeField {
+		request := Request{Query: query}
+		result, err := request.Normalize(schema)
+		require.NoError(t, err)
+		require.True(t, result.Successful)
+		fields, err := request.unauthorizedFields(ctx, schema)
+		require.NoError(t, err)
+		return fields
+	}
+
+	t.Run("field of an interface is restricted by the fields of its implementations", func(t *testing.T) {
+		fields := unauthorizedFields(t, WithAuthorizationScopes(context.Background()), `{ node(id: "1") { id secret } }`)
+		assert.Equal(t, []plan.TypeField{{TypeName: "Node", FieldNames: []string{"secret"}}}, fields)
+
+		fields = unauthorizedFields(t, WithAuthorizationScopes(context.Background(), "read:secret"), `{ node(id: "1") { id secret } }`)
+		assert.Nil(t, fields)
+	})
+
+	t.Run("field of an implementation selected by a fragment", func(t *testing.T) {
+		fields := unauthorizedFields(t, WithAuthorizationScopes(context.Background()), `{ node(id: "1") { ... on User { name secret } ... on Product { secret } } }`)
+		assert.Equal(t, []plan.TypeField{{TypeName: "User", FieldNames: []string{"secret"}}}, fields)
+	})
+
+	t.Run("type requiring authentication restricts the fields returning it", func(t *testing.T) {
+		fields := unauthorizedFields(t, context.Background(), `{ account { id balance } }`)
+		assert.Equal(t, []plan.TypeField{{TypeName: "Query", FieldNames: []string{"account"}}}, fields)
+
+		fields = unauthorizedFields(t, WithAuthorizationScopes(context.Background()), `{ account { id balance } }`)
+		assert.Nil(t, fields)
+	})
+
+	t.Run("unrestricted fields are authorized", func(t *testing.T) {
+		fields := unauthorizedFields(t, context.Background(), `{ me { id name } }`)
+		assert.Nil(t, fields)
+	})
+}
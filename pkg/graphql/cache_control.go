@@ -0,0 +1,184 @@
+package graphql
+
+import (
+	"strconv"
+
+	"github.com/wundergraph/graphql-go-tools/pkg/ast"
+	"github.com/wundergraph/graphql-go-tools/pkg/astvisitor"
+)
+
+const (
+	cacheControlDirectiveName = "cacheControl"
+	cacheControlMaxAgeArg     = "maxAge"
+	cacheControlScopeArg      = "scope"
+)
+
+type CacheControlScope string
+
+const (
+	CacheControlScopePublic  CacheControlScope = "PUBLIC"
+	CacheControlScopePrivate CacheControlScope = "PRIVATE"
+)
+
+// CacheControl is the cache policy of a response derived from the @cacheControl directives of the selected fields:
+//
+//	enum CacheControlScope { PUBLIC PRIVATE }
+//	directive @cacheControl(maxAge: Int, scope: CacheControlScope) on FIELD_DEFINITION
+//
+// MaxAge is the minimum maxAge in seconds of all selected fields. Fields without maxAge inherit the maxAge of their parent,
+// root fields without maxAge make the response uncacheable. The scope is private if any selected field is private.
+// Fields restricted by @authenticated or @requiresScopes are private too, as their data depends on the authorization
+// of the caller, see WithAuthorizationScopes.
+type CacheControl struct {
+	MaxAge int
+	Scope  CacheControlScope
+}
+
+// Cacheable reports whether the response might be cached
+func (c CacheControl) Cacheable() bool {
+	return c.MaxAge > 0
+}
+
+// HeaderValue returns the value of the Cache-Control HTTP header for the response
+func (c CacheControl) HeaderValue() string {
+	if !c.Cacheable() {
+		return "no-store"
+	}
+	maxAge := "max-age=" + strconv.Itoa(c.MaxAge)
+	if c.Scope == CacheControlScopePrivate {
+		return "private, " + maxAge
+	}
+	return maxAge
+}
+
+// CacheControl computes the cache policy of the response of the request.
+// Only queries are cacheable, mutations and subscriptions always result in an uncacheable policy.
+// The privateFields make the scope private like @cacheControl(scope: PRIVATE) if they're selected,
+// e.g. to declare fields depending on the caller without changing the schema of a subgraph.
+func (r *Request) CacheControl(schema *Schema, privateFields ...TypeFields) (CacheControl, error) {
+	if schema == nil {
+		return CacheControl{}, ErrNilSchema
+	}
+
+	report := r.parseQueryOnce()
+	if report.HasErrors() {
+		return CacheControl{}, report
+	}
+
+	if !r.IsNormalized() {
+		result, err := r.Normalize(schema)
+		if err != nil {
+			return CacheControl{}, err
+		}
+		if !result.Successful {
+			return CacheControl{}, result.Errors
+		}
+	}
+
+	walker := astvisitor.NewWalker(48)
+	visitor := cacheControlVisitor{
+		Walker:        &walker,
+		operation:     &r.document,
+		definition:    &schema.document,
+		operationName: r.OperationName,
+		privateFields: privateFields,
+		scope:         CacheControlScopePublic,
+	}
+	walker.RegisterEnterOperationVisitor(&visitor)
+	walker.RegisterEnterFieldVisitor(&visitor)
+	walker.Walk(&r.document, &schema.document, &report)
+	if report.HasErrors() {
+		return CacheControl{}, report
+	}
+
+	if visitor.uncacheable || !visitor.hasMaxAge {
+		return CacheControl{Scope: visitor.scope}, nil
+	}
+	return CacheControl{MaxAge: visitor.maxAge, Scope: visitor.scope}, nil
+}
+
+type cacheControlVisitor struct {
+	*astvisitor.Walker
+	operation, definition *ast.Document
+	operationName         string
+	privateFields         []TypeFields
+
+	maxAge      int
+	hasMaxAge   bool
+	uncacheable bool
+	scope       CacheControlScope
+}
+
+func (c *cacheControlVisitor) EnterOperationDefinition(ref int) {
+	if selected, _ := c.operation.OperationDefinitionRefByName(c.operationName); ref != selected {
+		c.SkipNode()
+		return
+	}
+	if c.operation.OperationDefinitions[ref].OperationType != ast.OperationTypeQuery {
+		c.uncacheable = true
+		c.Stop()
+	}
+}
+
+func (c *cacheControlVisitor) EnterField(ref int) {
+	if c.operation.FieldNameString(ref) == "__typename" {
+		return
+	}
+
+	isRootField := len(c.Ancestors) == 2 && c.Ancestors[0].Kind == ast.NodeKindOperationDefinition
+
+	fieldDefinition, ok := c.FieldDefinition(ref)
+	if !ok {
+		return
+	}
+	if c.isPrivateField(fieldDefinition) || len(authorizationDirectives(c.definition, c.EnclosingTypeDefinition, c.operation.FieldNameBytes(ref))) > 0 {
+		c.scope = CacheControlScopePrivate
+	}
+	directive, ok := c.definition.FieldDefinitionDirectiveByName(fieldDefinition, []byte(cacheControlDirectiveName))
+	if !ok {
+		if isRootField {
+			c.uncacheable = true
+		}
+		return
+	}
+
+	if scope, ok := c.definition.DirectiveArgumentValueByName(directive, []byte(cacheControlScopeArg)); ok && scope.Kind == ast.ValueKindEnum {
+		if c.definition.EnumValueNameString(scope.Ref) == string(CacheControlScopePrivate) {
+			c.scope = CacheControlScopePrivate
+		}
+	}
+
+	maxAge, ok := c.definition.DirectiveArgumentValueByName(directive, []byte(cacheControlMaxAgeArg))
+	if !ok || maxAge.Kind != ast.ValueKindInteger {
+		if isRootField {
+			c.uncacheable = true
+		}
+		return
+	}
+
+	fieldMaxAge := int(c.definition.IntValueAsInt(maxAge.Ref))
+	if !c.hasMaxAge || fieldMaxAge < c.maxAge {
+		c.maxAge = fieldMaxAge
+		c.hasMaxAge = true
+	}
+}
+
+// isPrivateField reports whether the field definition is one of the configured private fields of its enclosing type
+func (c *cacheControlVisitor) isPrivateField(fieldDefinition int) bool {
+	if len(c.privateFields) == 0 {
+		return false
+	}
+	typeName := c.definition.NodeNameString(c.EnclosingTypeDefinition)
+	fieldName := c.definition.FieldDefinitionNameString(fieldDefinition)
+	for _, typeFields := range c.privateFields {
+		if typeFields.TypeName != typeName {
+			continue
+		}
+		for _, privateFieldName := range typeFields.FieldNames {
+			if privateFieldName == fieldName {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,101 @@
+package graphql
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequest_CacheControl(t *testing.T) {
+	schema, err := NewSchemaFromString(`
+		enum CacheControlScope { PUBLIC PRIVATE }
+		directive @cacheControl(maxAge: Int, scope: CacheControlScope) on FIELD_DEFINITION
+		directive @authenticated on FIELD_DEFINITION
+		directive @requiresScopes(scopes: [[String!]!]!) on FIELD_DEFINITION
+
+		schema { query: Query mutation: Mutation }
+		type Query {
+			products: [Product] @cacheControl(maxAge: 30)
+			reviews: [Review] @cacheControl(maxAge: 10)
+			me: User @cacheControl(maxAge: 60, scope: PRIVATE)
+			now: String
+		}
+		type Mutation { addReview(body: String): Review @cacheControl(maxAge: 30) }
+		type Product { upc: String name: String price: Int @cacheControl(maxAge: 5) cost: Int @requiresScopes(scopes: [["read:cost"]]) }
+		type Review { body: String author: String @authenticated }
+		type User { username: String }
+	`)
+	require.NoError(t, err)
+
+	cacheControl := func(t *testing.T, query string) CacheControl {
+		request := Request{Query: query}
+		result, err := request.CacheControl(schema)
+		require.NoError(t, err)
+		return result
+	}
+
+	t.Run("should return error when schema is nil", func(t *testing.T) {
+		request := Request{Query: `{ products { upc } }`}
+		_, err := request.CacheControl(nil)
+		assert.Equal(t, ErrNilSchema, err)
+	})
+
+	t.Run("max age is the minimum max age of the selected fields", func(t *testing.T) {
+		result := cacheControl(t, `{ products { upc } reviews { body } }`)
+		assert.Equal(t, CacheControl{MaxAge: 10, Scope: CacheControlScopePublic}, result)
+		assert.Equal(t, "max-age=10", result.HeaderValue())
+	})
+
+	t.Run("nested fields restrict the max age", func(t *testing.T) {
+		result := cacheControl(t, `{ products { upc ... on Product { price } } }`)
+		assert.Equal(t, CacheControl{MaxAge: 5, Scope: CacheControlScopePublic}, result)
+	})
+
+	t.Run("private scope", func(t *testing.T) {
+		result := cacheControl(t, `{ products { upc } me { username } }`)
+		assert.Equal(t, CacheControl{MaxAge: 30, Scope: CacheControlScopePrivate}, result)
+		assert.Equal(t, "private, max-age=30", result.HeaderValue())
+	})
+
+	t.Run("private scope of configured fields", func(t *testing.T) {
+		request := Request{Query: `{ products { upc name } }`}
+		result, err := request.CacheControl(schema, TypeFields{TypeName: "Product", FieldNames: []string{"name"}})
+		require.NoError(t, err)
+		assert.Equal(t, CacheControl{MaxAge: 30, Scope: CacheControlScopePrivate}, result)
+
+		request = Request{Query: `{ products { upc } }`}
+		result, err = request.CacheControl(schema, TypeFields{TypeName: "Product", FieldNames: []string{"name"}})
+		require.NoError(t, err)
+		assert.Equal(t, CacheControl{MaxAge: 30, Scope: CacheControlScopePublic}, result)
+	})
+
+	t.Run("private scope of fields requiring authorization", func(t *testing.T) {
+		result := cacheControl(t, `{ products { upc cost } }`)
+		assert.Equal(t, CacheControl{MaxAge: 30, Scope: CacheControlScopePrivate}, result)
+
+		result = cacheControl(t, `{ reviews { body author } }`)
+		assert.Equal(t, CacheControl{MaxAge: 10, Scope: CacheControlScopePrivate}, result)
+	})
+
+	t.Run("root field without max age is uncacheable", func(t *testing.T) {
+		result := cacheControl(t, `{ products { upc } now }`)
+		assert.False(t, result.Cacheable())
+		assert.Equal(t, "no-store", result.HeaderValue())
+	})
+
+	t.Run("mutation is uncacheable", func(t *testing.T) {
+		result := cacheControl(t, `mutation { addReview(body: "great") { body } }`)
+		assert.False(t, result.Cacheable())
+	})
+
+	t.Run("only the selected operation is considered", func(t *testing.T) {
+		request := Request{
+			OperationName: "Products",
+			Query:         `query Products { products { upc } } query Now { now }`,
+		}
+		result, err := request.CacheControl(schema)
+		require.NoError(t, err)
+		assert.Equal(t, CacheControl{MaxAge: 30, Scope: CacheControlScopePublic}, result)
+	})
+}
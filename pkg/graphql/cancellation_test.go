@@ -0,0 +1,113 @@
+package graphql
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/jensneuse/abstractlogger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/wundergraph/graphql-go-tools/pkg/engine/datasource/graphql_datasource"
+)
+
+func TestExecutionEngineV2_Cancellation(t *testing.T) {
+	newEngine := func(t *testing.T, upstreamURL string, enableSingleFlight bool) *ExecutionEngineV2 {
+		factory := NewFederationEngineConfigFactory([]graphql_datasource.Configuration{
+			{
+				Fetch: graphql_datasource.FetchConfiguration{
+					URL: upstreamURL,
+				},
+				Federation: graphql_datasource.FederationConfiguration{
+					Enabled:    true,
+					ServiceSDL: `extend type Query { me: User } type User @key(fields: "id") { id: ID! username: String! }`,
+				},
+			},
+		}, graphql_datasource.NewBatchFactory())
+		engineConf, err := factory.EngineV2Configuration()
+		require.NoError(t, err)
+		engineConf.EnableSingleFlight(enableSingleFlight)
+
+		engineCtx, cancel := context.WithCancel(context.Background())
+		t.Cleanup(cancel)
+
+		engine, err := NewExecutionEngineV2(engineCtx, abstractlogger.NoopLogger, engineConf)
+		require.NoError(t, err)
+		return engine
+	}
+
+	execute := func(ctx context.Context, engine *ExecutionEngineV2) <-chan string {
+		done := make(chan string, 1)
+		go func() {
+			operation := Request{Query: `{ me { id username } }`}
+			resultWriter := NewEngineResultWriter()
+			_ = engine.Execute(ctx, &operation, &resultWriter)
+			done <- resultWriter.String()
+		}()
+		return done
+	}
+
+	t.Run("cancelled request aborts the subgraph fetch", func(t *testing.T) {
+		reached := make(chan struct{})
+		upstreamErr := make(chan error, 1)
+		upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			close(reached)
+			<-r.Context().Done()
+			upstreamErr <- r.Context().Err()
+		}))
+		t.Cleanup(upstream.Close)
+
+		engine := newEngine(t, upstream.URL, false)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		done := execute(ctx, engine)
+
+		<-reached
+		cancel()
+
+		select {
+		case err := <-upstreamErr:
+			assert.ErrorIs(t, err, context.Canceled)
+		case <-time.After(5 * time.Second):
+			t.Fatal("upstream request wasn't cancelled")
+		}
+
+		select {
+		case <-done:
+		case <-time.After(5 * time.Second):
+			t.Fatal("execute didn't return after the request was cancelled")
+		}
+	})
+
+	t.Run("cancelled request stops waiting for a single flight fetch", func(t *testing.T) {
+		reached := make(chan struct{}, 1)
+		release := make(chan struct{})
+		upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			reached <- struct{}{}
+			<-release
+			_, _ = w.Write([]byte(`{"data":{"me":{"id":"1","username":"Me"}}}`))
+		}))
+		t.Cleanup(upstream.Close)
+
+		engine := newEngine(t, upstream.URL, true)
+
+		loading := execute(context.Background(), engine)
+		<-reached
+
+		ctx, cancel := context.WithCancel(context.Background())
+		waiting := execute(ctx, engine)
+		cancel()
+
+		select {
+		case <-waiting:
+		case <-time.After(5 * time.Second):
+			t.Fatal("execute didn't return after the request was cancelled")
+		}
+
+		close(release)
+		assert.Equal(t, `{"data":{"me":{"id":"1","username":"Me"}}}`, <-loading)
+	})
+}
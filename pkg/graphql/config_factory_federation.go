@@ -5,18 +5,31 @@ import (
 	"net/http"
 	"time"
 
+	"github.com/wundergraph/graphql-go-tools/pkg/ast"
 	"github.com/wundergraph/graphql-go-tools/pkg/astparser"
+	"github.com/wundergraph/graphql-go-tools/pkg/asttransform"
 	graphqlDataSource "github.com/wundergraph/graphql-go-tools/pkg/engine/datasource/graphql_datasource"
 	"github.com/wundergraph/graphql-go-tools/pkg/engine/plan"
 	"github.com/wundergraph/graphql-go-tools/pkg/engine/resolve"
 	"github.com/wundergraph/graphql-go-tools/pkg/federation"
+	"github.com/wundergraph/graphql-go-tools/pkg/federation/sdlmerge"
+)
+
+const (
+	federationOverrideDirectiveName    = "override"
+	federationSourceFieldDirectiveName = "sourceField"
 )
 
 type federationEngineConfigFactoryOptions struct {
 	httpClient                *http.Client
+	dataSourceHttpClients     map[string]*http.Client
+	dataSourceLoaders         map[string]resolve.DataSource
 	streamingClient           *http.Client
 	subscriptionClientFactory graphqlDataSource.GraphQLSubscriptionClientFactory
 	subscriptionType          SubscriptionType
+	subscriptionMultiplexing  bool
+	publicSchemaIncludeTags   []string
+	publicSchemaDirectives    []string
 }
 
 type FederationEngineConfigFactoryOption func(options *federationEngineConfigFactoryOptions)
@@ -27,6 +40,22 @@ func WithFederationHttpClient(client *http.Client) FederationEngineConfigFactory
 	}
 }
 
+// WithFederationDataSourceHttpClients sets dedicated http clients for data sources, keyed by the fetch url of the data source.
+// Data sources without a dedicated client use the client set by WithFederationHttpClient.
+func WithFederationDataSourceHttpClients(clients map[string]*http.Client) FederationEngineConfigFactoryOption {
+	return func(options *federationEngineConfigFactoryOptions) {
+		options.dataSourceHttpClients = clients
+	}
+}
+
+// WithFederationDataSourceLoaders sets loaders resolving the requests to data sources instead of their http clients,
+// keyed by the fetch url of the data source, e.g. in-memory subgraphs in tests.
+func WithFederationDataSourceLoaders(loaders map[string]resolve.DataSource) FederationEngineConfigFactoryOption {
+	return func(options *federationEngineConfigFactoryOptions) {
+		options.dataSourceLoaders = loaders
+	}
+}
+
 func WithFederationStreamingClient(client *http.Client) FederationEngineConfigFactoryOption {
 	return func(options *federationEngineConfigFactoryOptions) {
 		options.streamingClient = client
@@ -45,6 +74,31 @@ func WithFederationSubscriptionType(subscriptionType SubscriptionType) Federatio
 	}
 }
 
+// WithFederationSubscriptionMultiplexing shares one upstream subscription between identical subscriptions of clients,
+// see graphql_datasource.WithMultiplexing.
+func WithFederationSubscriptionMultiplexing() FederationEngineConfigFactoryOption {
+	return func(options *federationEngineConfigFactoryOptions) {
+		options.subscriptionMultiplexing = true
+	}
+}
+
+// WithFederationPublicSchemaIncludeTags restricts the public schema to fields carrying one of the tags
+// or belonging to a type carrying one of the tags, see federation.BuildPublicSchemaDocument.
+func WithFederationPublicSchemaIncludeTags(tags ...string) FederationEngineConfigFactoryOption {
+	return func(options *federationEngineConfigFactoryOptions) {
+		options.publicSchemaIncludeTags = tags
+	}
+}
+
+// WithFederationPublicSchemaDirectives restricts the directive usages of the public schema to @deprecated and the
+// directives, e.g. to show @requiresScopes in the SDL of the public schema while hiding federation directives like @key.
+// See federation.PublicSchemaOptions.
+func WithFederationPublicSchemaDirectives(directives ...string) FederationEngineConfigFactoryOption {
+	return func(options *federationEngineConfigFactoryOptions) {
+		options.publicSchemaDirectives = directives
+	}
+}
+
 func NewFederationEngineConfigFactory(dataSourceConfigs []graphqlDataSource.Configuration, batchFactory resolve.DataSourceBatchFactory, opts ...FederationEngineConfigFactoryOption) *FederationEngineConfigFactory {
 	options := federationEngineConfigFactoryOptions{
 		httpClient: &http.Client{
@@ -67,23 +121,34 @@ func NewFederationEngineConfigFactory(dataSourceConfigs []graphqlDataSource.Conf
 
 	return &FederationEngineConfigFactory{
 		httpClient:                options.httpClient,
+		dataSourceHttpClients:     options.dataSourceHttpClients,
+		dataSourceLoaders:         options.dataSourceLoaders,
 		streamingClient:           options.streamingClient,
 		dataSourceConfigs:         dataSourceConfigs,
 		batchFactory:              batchFactory,
 		subscriptionClientFactory: options.subscriptionClientFactory,
 		subscriptionType:          options.subscriptionType,
+		subscriptionMultiplexing:  options.subscriptionMultiplexing,
+		publicSchemaIncludeTags:   options.publicSchemaIncludeTags,
+		publicSchemaDirectives:    options.publicSchemaDirectives,
 	}
 }
 
 // FederationEngineConfigFactory is used to create a v2 engine config for a supergraph with multiple data sources for subgraphs.
 type FederationEngineConfigFactory struct {
 	httpClient                *http.Client
+	dataSourceHttpClients     map[string]*http.Client
+	dataSourceLoaders         map[string]resolve.DataSource
 	streamingClient           *http.Client
 	dataSourceConfigs         []graphqlDataSource.Configuration
 	schema                    *Schema
 	batchFactory              resolve.DataSourceBatchFactory
 	subscriptionClientFactory graphqlDataSource.GraphQLSubscriptionClientFactory
 	subscriptionType          SubscriptionType
+	subscriptionMultiplexing  bool
+	publicSchemaIncludeTags   []string
+	publicSchemaDirectives    []string
+	publicSchema              *Schema
 }
 
 func (f *FederationEngineConfigFactory) SetMergedSchemaFromString(mergedSchema string) (err error) {
@@ -91,6 +156,7 @@ func (f *FederationEngineConfigFactory) SetMergedSchemaFromString(mergedSchema s
 	if err != nil {
 		return fmt.Errorf("set merged schema in FederationEngineConfigFactory: %s", err.Error())
 	}
+	f.publicSchema = nil
 	return nil
 }
 
@@ -116,13 +182,47 @@ func (f *FederationEngineConfigFactory) MergedSchema() (*Schema, error) {
 	return f.schema, nil
 }
 
+// PublicSchema returns the merged schema without @inaccessible elements, restricted to the configured include tags.
+// Only the usages of the exposed directives are kept if they are configured, see WithFederationPublicSchemaDirectives.
+// It is used for validation and introspection, while planning uses the merged schema.
+func (f *FederationEngineConfigFactory) PublicSchema() (*Schema, error) {
+	if f.publicSchema != nil {
+		return f.publicSchema, nil
+	}
+
+	schema, err := f.MergedSchema()
+	if err != nil {
+		return nil, err
+	}
+
+	rawPublicSchema, err := federation.BuildPublicSchemaDocumentWithOptions(string(schema.Input()), federation.PublicSchemaOptions{
+		IncludeTags:       f.publicSchemaIncludeTags,
+		ExposedDirectives: f.publicSchemaDirectives,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("build public schema: %w", err)
+	}
+
+	if f.publicSchema, err = NewSchemaFromString(rawPublicSchema); err != nil {
+		return nil, fmt.Errorf("parse public schema from string: %v", err)
+	}
+
+	return f.publicSchema, nil
+}
+
 func (f *FederationEngineConfigFactory) EngineV2Configuration() (conf EngineV2Configuration, err error) {
 	schema, err := f.MergedSchema()
 	if err != nil {
 		return conf, fmt.Errorf("get schema: %v", err)
 	}
 
+	publicSchema, err := f.PublicSchema()
+	if err != nil {
+		return conf, fmt.Errorf("get public schema: %v", err)
+	}
+
 	conf = NewEngineV2Configuration(schema)
+	conf.SetPublicSchema(publicSchema)
 
 	fieldConfigs, err := f.engineConfigFieldConfigs(schema)
 	if err != nil {
@@ -144,12 +244,13 @@ func (f *FederationEngineConfigFactory) engineConfigFieldConfigs(schema *Schema)
 	var planFieldConfigs plan.FieldConfigurations
 
 	for _, dataSourceConfig := range f.dataSourceConfigs {
-		doc, report := astparser.ParseGraphqlDocumentString(dataSourceConfig.Federation.ServiceSDL)
-		if report.HasErrors() {
-			return nil, fmt.Errorf("parse graphql document string: %s", report.Error())
+		doc, err := parseServiceSDL(dataSourceConfig.Federation.ServiceSDL)
+		if err != nil {
+			return nil, err
 		}
-		extractor := plan.NewRequiredFieldExtractor(&doc)
+		extractor := plan.NewRequiredFieldExtractor(doc)
 		planFieldConfigs = append(planFieldConfigs, extractor.GetAllRequiredFields()...)
+		planFieldConfigs = sourceFieldConfigs(planFieldConfigs, doc)
 	}
 
 	planFieldConfigs = newGraphQLFieldConfigsV2Generator(schema).Generate(planFieldConfigs...)
@@ -157,18 +258,22 @@ func (f *FederationEngineConfigFactory) engineConfigFieldConfigs(schema *Schema)
 }
 
 func (f *FederationEngineConfigFactory) engineConfigDataSources() (planDataSources []plan.DataSourceConfiguration, err error) {
+	docs := make([]*ast.Document, 0, len(f.dataSourceConfigs))
 	for _, dataSourceConfig := range f.dataSourceConfigs {
-		doc, report := astparser.ParseGraphqlDocumentString(dataSourceConfig.Federation.ServiceSDL)
-		if report.HasErrors() {
-			return nil, fmt.Errorf("parse graphql document string: %s", report.Error())
+		doc, err := parseServiceSDL(dataSourceConfig.Federation.ServiceSDL)
+		if err != nil {
+			return nil, err
 		}
+		docs = append(docs, doc)
 
-		planDataSource, err := newGraphQLDataSourceV2Generator(&doc).Generate(
+		planDataSource, err := newGraphQLDataSourceV2Generator(doc).Generate(
 			dataSourceConfig,
 			f.batchFactory,
-			f.httpClient,
+			f.dataSourceHttpClient(dataSourceConfig),
 			WithDataSourceV2GeneratorSubscriptionConfiguration(f.streamingClient, f.subscriptionType),
 			WithDataSourceV2GeneratorSubscriptionClientFactory(f.subscriptionClientFactory),
+			WithDataSourceV2GeneratorSubscriptionMultiplexing(f.subscriptionMultiplexing),
+			WithDataSourceV2GeneratorLoader(f.dataSourceLoaders[dataSourceConfig.Fetch.URL]),
 		)
 		if err != nil {
 			return nil, err
@@ -177,5 +282,129 @@ func (f *FederationEngineConfigFactory) engineConfigDataSources() (planDataSourc
 		planDataSources = append(planDataSources, planDataSource)
 	}
 
+	f.overrideFields(planDataSources, docs)
+
 	return
 }
+
+// overrideFields moves fields with the @override directive from the data source of the subgraph named by the from
+// argument to the data source of the overriding subgraph. Fields with a label are moved for the operations
+// having the label enabled only, see plan.FieldOverride.
+func (f *FederationEngineConfigFactory) overrideFields(planDataSources []plan.DataSourceConfiguration, docs []*ast.Document) {
+	for i, doc := range docs {
+		for _, node := range doc.RootNodes {
+			if node.Kind != ast.NodeKindObjectTypeDefinition && node.Kind != ast.NodeKindObjectTypeExtension {
+				continue
+			}
+			typeName := doc.NodeNameString(node)
+			for _, fieldRef := range doc.NodeFieldDefinitions(node) {
+				directiveRef, ok := doc.FieldDefinitionDirectiveByName(fieldRef, []byte(federationOverrideDirectiveName))
+				if !ok {
+					continue
+				}
+				from, ok := doc.DirectiveArgumentValueByName(directiveRef, []byte("from"))
+				if !ok || from.Kind != ast.ValueKindString {
+					continue
+				}
+				overridden := f.dataSourceIndexByServiceName(doc.ValueContentString(from))
+				if overridden == -1 || overridden == i {
+					continue
+				}
+
+				fieldName := doc.FieldDefinitionNameString(fieldRef)
+				label := ""
+				if value, ok := doc.DirectiveArgumentValueByName(directiveRef, []byte("label")); ok && value.Kind == ast.ValueKindString {
+					label = doc.ValueContentString(value)
+				}
+				if label == "" {
+					planDataSources[overridden].RemoveField(typeName, fieldName)
+					continue
+				}
+
+				planDataSources[overridden].FieldOverrides = append(planDataSources[overridden].FieldOverrides, plan.FieldOverride{
+					TypeName:  typeName,
+					FieldName: fieldName,
+					Label:     label,
+				})
+				planDataSources[i].FieldOverrides = append(planDataSources[i].FieldOverrides, plan.FieldOverride{
+					TypeName:   typeName,
+					FieldName:  fieldName,
+					Label:      label,
+					Overriding: true,
+				})
+			}
+		}
+	}
+}
+
+// sourceFieldConfigs maps fields renamed with the @sourceField directive to the name of the field in the subgraph,
+// e.g. `handle: String @sourceField(name: "username")` fetches username from the subgraph and presents it as handle.
+// The mapping applies to all data sources of the field, so a renamed field should be resolved by a single subgraph.
+//
+//	directive @sourceField(name: String!) on FIELD_DEFINITION
+func sourceFieldConfigs(fieldConfigs plan.FieldConfigurations, doc *ast.Document) plan.FieldConfigurations {
+	for _, node := range doc.RootNodes {
+		switch node.Kind {
+		case ast.NodeKindObjectTypeDefinition, ast.NodeKindObjectTypeExtension,
+			ast.NodeKindInterfaceTypeDefinition, ast.NodeKindInterfaceTypeExtension:
+		default:
+			continue
+		}
+		typeName := doc.NodeNameString(node)
+		for _, fieldRef := range doc.NodeFieldDefinitions(node) {
+			directiveRef, ok := doc.FieldDefinitionDirectiveByName(fieldRef, []byte(federationSourceFieldDirectiveName))
+			if !ok {
+				continue
+			}
+			name, ok := doc.DirectiveArgumentValueByName(directiveRef, []byte("name"))
+			if !ok || name.Kind != ast.ValueKindString {
+				continue
+			}
+
+			fieldName := doc.FieldDefinitionNameString(fieldRef)
+			path := []string{doc.ValueContentString(name)}
+			if fieldConfig := fieldConfigs.ForTypeField(typeName, fieldName); fieldConfig != nil {
+				fieldConfig.Path = path
+				continue
+			}
+			fieldConfigs = append(fieldConfigs, plan.FieldConfiguration{
+				TypeName:  typeName,
+				FieldName: fieldName,
+				Path:      path,
+			})
+		}
+	}
+	return fieldConfigs
+}
+
+func (f *FederationEngineConfigFactory) dataSourceIndexByServiceName(serviceName string) int {
+	if serviceName == "" {
+		return -1
+	}
+	for i := range f.dataSourceConfigs {
+		if f.dataSourceConfigs[i].Federation.ServiceName == serviceName {
+			return i
+		}
+	}
+	return -1
+}
+
+// parseServiceSDL parses the SDL of a subgraph and renames custom root operation types to the names used by the
+// merged schema, so root nodes of the data source match the types operations are planned against.
+// Directives imported by @link are renamed to their federation names, e.g. an aliased @key.
+func parseServiceSDL(serviceSDL string) (*ast.Document, error) {
+	doc, report := astparser.ParseGraphqlDocumentString(serviceSDL)
+	if report.HasErrors() {
+		return nil, fmt.Errorf("parse graphql document string: %s", report.Error())
+	}
+	asttransform.ResolveLinkImports(&doc)
+	sdlmerge.RenameRootOperationTypes(&doc)
+	return &doc, nil
+}
+
+func (f *FederationEngineConfigFactory) dataSourceHttpClient(dataSourceConfig graphqlDataSource.Configuration) *http.Client {
+	if client, ok := f.dataSourceHttpClients[dataSourceConfig.Fetch.URL]; ok && client != nil {
+		return client
+	}
+	return f.httpClient
+}
@@ -1,9 +1,15 @@
 package graphql
 
 import (
+	"bytes"
+	"context"
+	"io/ioutil"
 	"net/http"
+	"net/http/httptest"
 	"testing"
 
+	"github.com/jensneuse/abstractlogger"
+
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
@@ -12,6 +18,7 @@ import (
 	graphqlDataSource "github.com/wundergraph/graphql-go-tools/pkg/engine/datasource/graphql_datasource"
 	"github.com/wundergraph/graphql-go-tools/pkg/engine/plan"
 	"github.com/wundergraph/graphql-go-tools/pkg/engine/resolve"
+	"github.com/wundergraph/graphql-go-tools/pkg/federation"
 )
 
 func TestEngineConfigV2Factory_EngineV2Configuration(t *testing.T) {
@@ -82,8 +89,13 @@ func TestEngineConfigV2Factory_EngineV2Configuration(t *testing.T) {
 		}, baseFederationSchema, func(t *testing.T, baseSchema string) EngineV2Configuration {
 			schema, err := NewSchemaFromString(baseSchema)
 			require.NoError(t, err)
+			rawPublicSchema, err := federation.BuildPublicSchemaDocument(baseSchema)
+			require.NoError(t, err)
+			publicSchema, err := NewSchemaFromString(rawPublicSchema)
+			require.NoError(t, err)
 
 			conf := NewEngineV2Configuration(schema)
+			conf.SetPublicSchema(publicSchema)
 			conf.SetFieldConfigurations(plan.FieldConfigurations{
 				{
 					TypeName:       "User",
@@ -240,6 +252,325 @@ func TestEngineConfigV2Factory_EngineV2Configuration(t *testing.T) {
 	})
 }
 
+func TestFederationEngineConfigFactory_PublicSchema(t *testing.T) {
+	accountsSDL := `
+		extend type Query {
+			me: User
+		}
+		type User @key(fields: "id") {
+			id: ID! @inaccessible
+			username: String!
+		}`
+
+	reviewsSDL := `
+		type Review {
+			body: String!
+		}
+		extend type User @key(fields: "id") {
+			id: ID! @external
+			reviews: [Review]
+		}`
+
+	accountsUpstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"data":{"me":{"__typename":"User","username":"Me","id":"1234"}}}`))
+	}))
+	defer accountsUpstream.Close()
+
+	var reviewsRequestBody []byte
+	reviewsUpstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		reviewsRequestBody, _ = ioutil.ReadAll(r.Body)
+		_, _ = w.Write([]byte(`{"data":{"_entities":[{"__typename":"User","reviews":[{"body":"A highly effective form of birth control."}]}]}}`))
+	}))
+	defer reviewsUpstream.Close()
+
+	factory := NewFederationEngineConfigFactory([]graphqlDataSource.Configuration{
+		{
+			Fetch: graphqlDataSource.FetchConfiguration{
+				URL:    accountsUpstream.URL,
+				Method: http.MethodPost,
+			},
+			Federation: graphqlDataSource.FederationConfiguration{
+				Enabled:    true,
+				ServiceSDL: accountsSDL,
+			},
+		},
+		{
+			Fetch: graphqlDataSource.FetchConfiguration{
+				URL:    reviewsUpstream.URL,
+				Method: http.MethodPost,
+			},
+			Federation: graphqlDataSource.FederationConfiguration{
+				Enabled:    true,
+				ServiceSDL: reviewsSDL,
+			},
+		},
+	}, graphqlDataSource.NewBatchFactory())
+
+	engineConfig, err := factory.EngineV2Configuration()
+	require.NoError(t, err)
+	engine, err := NewExecutionEngineV2(context.Background(), abstractlogger.NoopLogger, engineConfig)
+	require.NoError(t, err)
+
+	execute := func(t *testing.T, query string) (string, error) {
+		t.Helper()
+		resultWriter := NewEngineResultWriter()
+		err := engine.Execute(context.Background(), &Request{Query: query}, &resultWriter)
+		return resultWriter.String(), err
+	}
+
+	t.Run("inaccessible field is absent from introspection", func(t *testing.T) {
+		result, err := execute(t, `{ __type(name: "User") { fields { name } } }`)
+		require.NoError(t, err)
+		assert.Equal(t, `{"data":{"__type":{"fields":[{"name":"username"},{"name":"reviews"}]}}}`, result)
+	})
+
+	t.Run("inaccessible field can't be selected", func(t *testing.T) {
+		_, err := execute(t, `{ me { id } }`)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "field: id not defined on type: User")
+	})
+
+	t.Run("inaccessible field is used for @key resolution", func(t *testing.T) {
+		result, err := execute(t, `{ me { username reviews { body } } }`)
+		require.NoError(t, err)
+		assert.Equal(t, `{"data":{"me":{"username":"Me","reviews":[{"body":"A highly effective form of birth control."}]}}}`, result)
+		assert.Contains(t, string(reviewsRequestBody), `"representations":[{"id":"1234","__typename":"User"}]`)
+	})
+}
+
+func TestFederationEngineConfigFactory_PublicSchemaDirectives(t *testing.T) {
+	productsSDL := `
+		extend type Query {
+			topProducts: [Product]
+		}
+		type Product @key(fields: "upc") {
+			upc: String!
+			name: String @deprecated(reason: "use title")
+			title: String @owner(team: "catalog")
+		}`
+
+	reviewsSDL := `
+		extend type Product @key(fields: "upc") {
+			upc: String! @external
+			reviews: [String]
+		}`
+
+	factory := NewFederationEngineConfigFactory([]graphqlDataSource.Configuration{
+		{
+			Fetch: graphqlDataSource.FetchConfiguration{
+				URL: "http://products.service",
+			},
+			Federation: graphqlDataSource.FederationConfiguration{
+				Enabled:    true,
+				ServiceSDL: productsSDL,
+			},
+		},
+		{
+			Fetch: graphqlDataSource.FetchConfiguration{
+				URL: "http://reviews.service",
+			},
+			Federation: graphqlDataSource.FederationConfiguration{
+				Enabled:    true,
+				ServiceSDL: reviewsSDL,
+			},
+		},
+	}, graphqlDataSource.NewBatchFactory(), WithFederationPublicSchemaDirectives("owner"))
+
+	publicSchema, err := factory.PublicSchema()
+	require.NoError(t, err)
+	sdl := string(publicSchema.Input())
+	assert.Contains(t, sdl, `@deprecated(reason: "use title")`)
+	assert.Contains(t, sdl, `@owner(team: "catalog")`)
+	assert.NotContains(t, sdl, "@key")
+	assert.NotContains(t, sdl, "@external")
+
+	engineConfig, err := factory.EngineV2Configuration()
+	require.NoError(t, err)
+	engine, err := NewExecutionEngineV2(context.Background(), abstractlogger.NoopLogger, engineConfig)
+	require.NoError(t, err)
+
+	resultWriter := NewEngineResultWriter()
+	err = engine.Execute(context.Background(), &Request{
+		Query: `{ __type(name: "Product") { fields(includeDeprecated: true) { name isDeprecated deprecationReason } } }`,
+	}, &resultWriter)
+	require.NoError(t, err)
+	assert.Equal(t, `{"data":{"__type":{"fields":[{"name":"upc","isDeprecated":false,"deprecationReason":null},{"name":"name","isDeprecated":true,"deprecationReason":"use title"},{"name":"title","isDeprecated":false,"deprecationReason":null},{"name":"reviews","isDeprecated":false,"deprecationReason":null}]}}}`, resultWriter.String())
+}
+
+func TestFederationEngineConfigFactory_CustomRootOperationTypes(t *testing.T) {
+	accountsSDL := `
+		schema {
+			query: RootQuery
+		}
+		type RootQuery {
+			me: User
+		}
+		type User @key(fields: "id") {
+			id: ID!
+			username: String!
+		}`
+
+	reviewsSDL := `
+		extend schema {
+			query: ReviewsQuery
+		}
+		type ReviewsQuery {
+			latestReview: Review
+		}
+		type Review {
+			body: String!
+		}
+		extend type User @key(fields: "id") {
+			id: ID! @external
+			reviews: [Review]
+		}`
+
+	var accountsRequestBody []byte
+	accountsUpstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		accountsRequestBody, _ = ioutil.ReadAll(r.Body)
+		_, _ = w.Write([]byte(`{"data":{"me":{"__typename":"User","username":"Me","id":"1234"}}}`))
+	}))
+	defer accountsUpstream.Close()
+
+	reviewsUpstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		if bytes.Contains(body, []byte("_entities")) {
+			_, _ = w.Write([]byte(`{"data":{"_entities":[{"__typename":"User","reviews":[{"body":"A highly effective form of birth control."}]}]}}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"data":{"latestReview":{"body":"Fedoras are one of the most fashionable hats around."}}}`))
+	}))
+	defer reviewsUpstream.Close()
+
+	factory := NewFederationEngineConfigFactory([]graphqlDataSource.Configuration{
+		{
+			Fetch: graphqlDataSource.FetchConfiguration{
+				URL:    accountsUpstream.URL,
+				Method: http.MethodPost,
+			},
+			Federation: graphqlDataSource.FederationConfiguration{
+				Enabled:    true,
+				ServiceSDL: accountsSDL,
+			},
+		},
+		{
+			Fetch: graphqlDataSource.FetchConfiguration{
+				URL:    reviewsUpstream.URL,
+				Method: http.MethodPost,
+			},
+			Federation: graphqlDataSource.FederationConfiguration{
+				Enabled:    true,
+				ServiceSDL: reviewsSDL,
+			},
+		},
+	}, graphqlDataSource.NewBatchFactory())
+
+	engineConfig, err := factory.EngineV2Configuration()
+	require.NoError(t, err)
+	engine, err := NewExecutionEngineV2(context.Background(), abstractlogger.NoopLogger, engineConfig)
+	require.NoError(t, err)
+
+	execute := func(t *testing.T, query string) (string, error) {
+		t.Helper()
+		resultWriter := NewEngineResultWriter()
+		err := engine.Execute(context.Background(), &Request{Query: query}, &resultWriter)
+		return resultWriter.String(), err
+	}
+
+	t.Run("root fields of all subgraphs are merged into Query", func(t *testing.T) {
+		result, err := execute(t, `{ __schema { queryType { name fields { name } } } }`)
+		require.NoError(t, err)
+		assert.Equal(t, `{"data":{"__schema":{"queryType":{"name":"Query","fields":[{"name":"me"},{"name":"latestReview"}]}}}}`, result)
+	})
+
+	t.Run("root field of a subgraph with custom query type name", func(t *testing.T) {
+		result, err := execute(t, `{ me { username reviews { body } } }`)
+		require.NoError(t, err)
+		assert.Equal(t, `{"data":{"me":{"username":"Me","reviews":[{"body":"A highly effective form of birth control."}]}}}`, result)
+		assert.Equal(t, `{"query":"{me {username id}}"}`, string(accountsRequestBody))
+	})
+
+	t.Run("root field of a subgraph with query type name set via schema extension", func(t *testing.T) {
+		result, err := execute(t, `{ latestReview { body } }`)
+		require.NoError(t, err)
+		assert.Equal(t, `{"data":{"latestReview":{"body":"Fedoras are one of the most fashionable hats around."}}}`, result)
+	})
+
+	t.Run("unknown root field is rejected", func(t *testing.T) {
+		_, err := execute(t, `{ topProducts { upc } }`)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "field: topProducts not defined on type: Query")
+	})
+}
+
+func TestFederationEngineConfigFactory_LinkImports(t *testing.T) {
+	accountsSDL := `
+		extend schema @link(url: "https://specs.apollo.dev/federation/v2.0", import: [{ name: "@key", as: "@primaryKey" }])
+		extend type Query {
+			me: User
+		}
+		type User @primaryKey(fields: "id") {
+			id: ID!
+			username: String!
+		}`
+
+	reviewsSDL := `
+		extend schema @link(url: "https://specs.apollo.dev/federation/v2.0", as: "fed")
+		type Review {
+			body: String!
+		}
+		extend type User @fed__key(fields: "id") {
+			id: ID! @fed__external
+			reviews: [Review]
+		}`
+
+	accountsUpstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"data":{"me":{"__typename":"User","username":"Me","id":"1234"}}}`))
+	}))
+	defer accountsUpstream.Close()
+
+	var reviewsRequestBody []byte
+	reviewsUpstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		reviewsRequestBody, _ = ioutil.ReadAll(r.Body)
+		_, _ = w.Write([]byte(`{"data":{"_entities":[{"__typename":"User","reviews":[{"body":"A highly effective form of birth control."}]}]}}`))
+	}))
+	defer reviewsUpstream.Close()
+
+	factory := NewFederationEngineConfigFactory([]graphqlDataSource.Configuration{
+		{
+			Fetch: graphqlDataSource.FetchConfiguration{
+				URL:    accountsUpstream.URL,
+				Method: http.MethodPost,
+			},
+			Federation: graphqlDataSource.FederationConfiguration{
+				Enabled:    true,
+				ServiceSDL: accountsSDL,
+			},
+		},
+		{
+			Fetch: graphqlDataSource.FetchConfiguration{
+				URL:    reviewsUpstream.URL,
+				Method: http.MethodPost,
+			},
+			Federation: graphqlDataSource.FederationConfiguration{
+				Enabled:    true,
+				ServiceSDL: reviewsSDL,
+			},
+		},
+	}, graphqlDataSource.NewBatchFactory())
+
+	engineConfig, err := factory.EngineV2Configuration()
+	require.NoError(t, err)
+	engine, err := NewExecutionEngineV2(context.Background(), abstractlogger.NoopLogger, engineConfig)
+	require.NoError(t, err)
+
+	resultWriter := NewEngineResultWriter()
+	err = engine.Execute(context.Background(), &Request{Query: `{ me { username reviews { body } } }`}, &resultWriter)
+	require.NoError(t, err)
+	assert.Equal(t, `{"data":{"me":{"username":"Me","reviews":[{"body":"A highly effective form of birth control."}]}}}`, resultWriter.String())
+	assert.Contains(t, string(reviewsRequestBody), `"representations":[{"id":"1234","__typename":"User"}]`)
+}
+
 const (
 	accountSchema = `
 		extend type Query {
@@ -0,0 +1,233 @@
+package graphql
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/buger/jsonparser"
+
+	"github.com/wundergraph/graphql-go-tools/pkg/ast"
+	"github.com/wundergraph/graphql-go-tools/pkg/engine/resolve"
+	"github.com/wundergraph/graphql-go-tools/pkg/lexer/literal"
+	"github.com/wundergraph/graphql-go-tools/pkg/operationreport"
+)
+
+// coerceCustomScalarVariables parses all values of custom scalar types in the variables and replaces them with the
+// coerced values, including the values nested in input objects and lists.
+// Invalid variables result in request errors, so that no data source gets called.
+func (r *Request) coerceCustomScalarVariables(schema *Schema, scalars *resolve.ScalarRegistry) error {
+	if scalars == nil {
+		return nil
+	}
+
+	if report := r.parseQueryOnce(); report.HasErrors() {
+		return report
+	}
+
+	operationRef, ok := r.document.OperationDefinitionRefByName(r.OperationName)
+	if !ok {
+		return nil
+	}
+
+	coercion := customScalarCoercion{
+		definition: &schema.document,
+		scalars:    scalars,
+		visited:    map[string]bool{},
+	}
+
+	var errs RequestErrors
+	for _, ref := range r.document.OperationDefinitions[operationRef].VariableDefinitions.Refs {
+		typeRef := r.document.VariableDefinitions[ref].Type
+		if !coercion.containsCustomScalar(r.document.ResolveTypeNameString(typeRef)) {
+			continue
+		}
+
+		name := r.document.VariableDefinitionNameString(ref)
+		value, valueType, _, err := jsonparser.Get(r.Variables, name)
+		if err != nil || valueType == jsonparser.Null {
+			// missing values are reported by the variables validation
+			continue
+		}
+
+		coerced, err := coercion.coerce(&r.document, typeRef, value, valueType, name)
+		if err != nil {
+			invalid, ok := err.(invalidCustomScalarValueError)
+			if !ok {
+				return err
+			}
+			errs = append(errs, RequestError{
+				Message: invalid.message(name),
+				Locations: operationreport.LocationsFromPosition(
+					r.document.VariableValues[r.document.VariableDefinitions[ref].VariableValue.Ref].Dollar,
+				),
+			})
+			continue
+		}
+
+		r.Variables, err = jsonparser.Set(r.Variables, coerced, name)
+		if err != nil {
+			return err
+		}
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// invalidCustomScalarValueError is returned for a value the custom scalar fails to parse,
+// path is the path of the value in the variable, e.g. filter.dates[1]
+type invalidCustomScalarValueError struct {
+	path     string
+	typeName string
+	err      error
+}
+
+func (i invalidCustomScalarValueError) Error() string {
+	return i.err.Error()
+}
+
+func (i invalidCustomScalarValueError) message(variableName string) string {
+	if i.path == variableName {
+		return fmt.Sprintf(`Variable "$%s" got invalid value; Expected type "%s". %s`, variableName, i.typeName, i.err)
+	}
+	return fmt.Sprintf(`Variable "$%s" got invalid value at "%s"; Expected type "%s". %s`, variableName, i.path, i.typeName, i.err)
+}
+
+// customScalarCoercion coerces the custom scalar values of variables,
+// the types of input object fields are resolved with the definition
+type customScalarCoercion struct {
+	definition *ast.Document
+	scalars    *resolve.ScalarRegistry
+	// visited caches whether a type contains custom scalars
+	visited map[string]bool
+}
+
+// containsCustomScalar reports whether the type is a custom scalar or an input object with custom scalar fields
+func (c *customScalarCoercion) containsCustomScalar(typeName string) bool {
+	if contains, ok := c.visited[typeName]; ok {
+		return contains
+	}
+	contains := c.reachesCustomScalar(typeName, map[string]struct{}{})
+	c.visited[typeName] = contains
+	return contains
+}
+
+// reachesCustomScalar is containsCustomScalar without caching the results of types checked while seen,
+// as they are incomplete for input objects referencing each other
+func (c *customScalarCoercion) reachesCustomScalar(typeName string, seen map[string]struct{}) bool {
+	if contains, ok := c.visited[typeName]; ok {
+		return contains
+	}
+	if _, ok := seen[typeName]; ok {
+		return false
+	}
+	seen[typeName] = struct{}{}
+
+	if scalar, ok := c.scalars.Scalar(typeName); ok && scalar.ParseValue != nil {
+		return true
+	}
+	node, ok := c.definition.Index.FirstNodeByNameStr(typeName)
+	if !ok || node.Kind != ast.NodeKindInputObjectTypeDefinition {
+		return false
+	}
+	for _, ref := range c.definition.InputObjectTypeDefinitions[node.Ref].InputFieldsDefinition.Refs {
+		if c.reachesCustomScalar(c.definition.ResolveTypeNameString(c.definition.InputValueDefinitionType(ref)), seen) {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *customScalarCoercion) coerce(document *ast.Document, typeRef int, value []byte, valueType jsonparser.ValueType, path string) ([]byte, error) {
+	if valueType == jsonparser.Null {
+		return literal.NULL, nil
+	}
+
+	switch document.Types[typeRef].TypeKind {
+	case ast.TypeKindNonNull:
+		return c.coerce(document, document.Types[typeRef].OfType, value, valueType, path)
+	case ast.TypeKindList:
+		if valueType != jsonparser.Array {
+			// a single value is coerced to a list of one item
+			return c.coerce(document, document.Types[typeRef].OfType, value, valueType, path)
+		}
+
+		items := &bytes.Buffer{}
+		items.WriteByte('[')
+		var itemErr error
+		index := 0
+		_, err := jsonparser.ArrayEach(value, func(item []byte, itemType jsonparser.ValueType, offset int, err error) {
+			defer func() { index++ }()
+			if itemErr != nil {
+				return
+			}
+			coerced, err := c.coerce(document, document.Types[typeRef].OfType, item, itemType, fmt.Sprintf("%s[%d]", path, index))
+			if err != nil {
+				itemErr = err
+				return
+			}
+			if items.Len() > 1 {
+				items.WriteByte(',')
+			}
+			items.Write(coerced)
+		})
+		if err != nil {
+			return nil, err
+		}
+		if itemErr != nil {
+			return nil, itemErr
+		}
+		items.WriteByte(']')
+		return items.Bytes(), nil
+	}
+
+	if valueType == jsonparser.String {
+		// jsonparser strips the quotes of string values
+		value = append(append([]byte{'"'}, value...), '"')
+	}
+
+	typeName := document.ResolveTypeNameString(typeRef)
+	if scalar, ok := c.scalars.Scalar(typeName); ok && scalar.ParseValue != nil {
+		coerced, err := scalar.ParseValue(value)
+		if err != nil {
+			return nil, invalidCustomScalarValueError{path: path, typeName: typeName, err: err}
+		}
+		return coerced, nil
+	}
+
+	if valueType != jsonparser.Object || !c.containsCustomScalar(typeName) {
+		return value, nil
+	}
+	node, _ := c.definition.Index.FirstNodeByNameStr(typeName)
+	return c.coerceInputObject(node.Ref, value, path)
+}
+
+func (c *customScalarCoercion) coerceInputObject(inputObjectRef int, value []byte, path string) ([]byte, error) {
+	// the value is part of the variables, jsonparser.Set must not modify them in place
+	value = append([]byte(nil), value...)
+
+	for _, ref := range c.definition.InputObjectTypeDefinitions[inputObjectRef].InputFieldsDefinition.Refs {
+		typeRef := c.definition.InputValueDefinitionType(ref)
+		if !c.containsCustomScalar(c.definition.ResolveTypeNameString(typeRef)) {
+			continue
+		}
+
+		name := c.definition.InputValueDefinitionNameString(ref)
+		fieldValue, fieldValueType, _, err := jsonparser.Get(value, name)
+		if err != nil {
+			continue
+		}
+
+		coerced, err := c.coerce(c.definition, typeRef, fieldValue, fieldValueType, path+"."+name)
+		if err != nil {
+			return nil, err
+		}
+		value, err = jsonparser.Set(value, coerced, name)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return value, nil
+}
@@ -0,0 +1,218 @@
+package graphql
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jensneuse/abstractlogger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/wundergraph/graphql-go-tools/pkg/engine/datasource/graphql_datasource"
+	"github.com/wundergraph/graphql-go-tools/pkg/engine/plan"
+	"github.com/wundergraph/graphql-go-tools/pkg/engine/resolve"
+	"github.com/wundergraph/graphql-go-tools/pkg/graphqlerrors"
+)
+
+type recordingRoundTripper struct {
+	requests     []string
+	responseBody string
+}
+
+func (r *recordingRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	body, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		return nil, err
+	}
+	r.requests = append(r.requests, string(body))
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Body:       ioutil.NopCloser(strings.NewReader(r.responseBody)),
+	}, nil
+}
+
+// dateTimeScalar parses RFC3339 dates and serializes them in UTC
+func dateTimeScalar() resolve.CustomScalar {
+	coerce := func(value []byte) ([]byte, error) {
+		str, err := strconv.Unquote(string(value))
+		if err != nil {
+			return nil, fmt.Errorf("DateTime must be a string")
+		}
+		date, err := time.Parse(time.RFC3339, str)
+		if err != nil {
+			return nil, fmt.Errorf("DateTime must be a RFC3339 date")
+		}
+		return []byte(strconv.Quote(date.UTC().Format(time.RFC3339))), nil
+	}
+	return resolve.CustomScalar{
+		ParseValue: coerce,
+		Serialize:  coerce,
+	}
+}
+
+func TestExecutionEngineV2_CustomScalars(t *testing.T) {
+	schema, err := NewSchemaFromString(`
+		scalar DateTime
+
+		schema { query: Query }
+
+		type Query {
+			events(after: DateTime!): [Event!]!
+		}
+
+		type Event {
+			name: String!
+			startsAt: DateTime!
+		}`)
+	require.NoError(t, err)
+
+	const eventsResponse = `{"data":{"events":[{"name":"GraphQL Conf","startsAt":"2022-06-07T10:00:00+02:00"}]}}`
+
+	execute := func(t *testing.T, variables, responseBody string) (*recordingRoundTripper, string, error) {
+		upstream := &recordingRoundTripper{
+			responseBody: responseBody,
+		}
+
+		engineConf := NewEngineV2Configuration(schema)
+		engineConf.SetCustomScalars(resolve.NewScalarRegistry().Register("DateTime", dateTimeScalar()))
+		engineConf.SetDataSources([]plan.DataSourceConfiguration{
+			{
+				RootNodes: []plan.TypeField{
+					{TypeName: "Query", FieldNames: []string{"events"}},
+				},
+				ChildNodes: []plan.TypeField{
+					{TypeName: "Event", FieldNames: []string{"name", "startsAt"}},
+				},
+				Factory: &graphql_datasource.Factory{
+					HTTPClient: &http.Client{Transport: upstream},
+				},
+				Custom: graphql_datasource.ConfigJson(graphql_datasource.Configuration{
+					Fetch: graphql_datasource.FetchConfiguration{
+						URL:    "https://example.com/",
+						Method: "POST",
+					},
+				}),
+			},
+		})
+		engineConf.SetFieldConfigurations(plan.FieldConfigurations{
+			{
+				TypeName:  "Query",
+				FieldName: "events",
+				Arguments: []plan.ArgumentConfiguration{
+					{Name: "after", SourceType: plan.FieldArgumentSource},
+				},
+			},
+		})
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		engine, err := NewExecutionEngineV2(ctx, abstractlogger.Noop{}, engineConf)
+		require.NoError(t, err)
+
+		operation := Request{
+			OperationName: "Events",
+			Query:         `query Events($after: DateTime!) { events(after: $after) { name startsAt } }`,
+			Variables:     []byte(variables),
+		}
+		resultWriter := NewEngineResultWriter()
+		err = engine.Execute(context.Background(), &operation, &resultWriter)
+		return upstream, resultWriter.String(), err
+	}
+
+	t.Run("rejects invalid variable before contacting subgraphs", func(t *testing.T) {
+		upstream, _, err := execute(t, `{"after":"07.06.2022"}`, eventsResponse)
+		require.Error(t, err)
+
+		requestErrors, ok := err.(RequestErrors)
+		require.True(t, ok)
+		assert.Equal(t, RequestErrors{
+			{
+				Message:   `Variable "$after" got invalid value; Expected type "DateTime". DateTime must be a RFC3339 date`,
+				Locations: []graphqlerrors.Location{{Line: 1, Column: 14}},
+			},
+		}, requestErrors)
+		assert.Empty(t, upstream.requests)
+	})
+
+	t.Run("accepts valid variable", func(t *testing.T) {
+		upstream, response, err := execute(t, `{"after":"2022-06-01T12:00:00+02:00"}`, eventsResponse)
+		require.NoError(t, err)
+
+		require.Len(t, upstream.requests, 1)
+		assert.Contains(t, upstream.requests[0], `"variables":{"after":"2022-06-01T10:00:00Z"}`)
+		assert.Equal(t, `{"data":{"events":[{"name":"GraphQL Conf","startsAt":"2022-06-07T08:00:00Z"}]}}`, response)
+	})
+
+	t.Run("invalid result value is a field error", func(t *testing.T) {
+		_, response, err := execute(t, `{"after":"2022-06-01T12:00:00+02:00"}`, `{"data":{"events":[{"name":"GraphQL Conf","startsAt":"07.06.2022"}]}}`)
+		require.NoError(t, err)
+
+		assert.Contains(t, response, `"message":"Invalid value for scalar DateTime: DateTime must be a RFC3339 date"`)
+		assert.Contains(t, response, `"path":["events",0,"startsAt"]`)
+		// the non-nullable field is null, so its parents are null up to the nullable data
+		assert.Contains(t, response, `"data":null`)
+		assert.Equal(t, 1, strings.Count(response, `"message"`))
+	})
+}
+
+func TestRequest_coerceCustomScalarVariables(t *testing.T) {
+	schema, err := NewSchemaFromString(`
+		scalar DateTime
+
+		schema { query: Query }
+
+		type Query {
+			events(filter: EventFilter): [String]
+		}
+
+		input EventFilter {
+			name: String
+			after: DateTime
+			between: [DateTime!]
+			and: EventFilter
+		}`)
+	require.NoError(t, err)
+	scalars := resolve.NewScalarRegistry().Register("DateTime", dateTimeScalar())
+
+	coerce := func(t *testing.T, variables string) (*Request, error) {
+		request := &Request{
+			Query:     `query Events($filter: EventFilter) { events(filter: $filter) }`,
+			Variables: []byte(variables),
+		}
+		return request, request.coerceCustomScalarVariables(schema, scalars)
+	}
+
+	t.Run("coerces values in input objects and lists", func(t *testing.T) {
+		request, err := coerce(t, `{"filter":{"name":"conf","after":"2022-06-01T12:00:00+02:00","between":["2022-06-01T12:00:00+02:00"],"and":{"after":"2022-06-02T12:00:00+02:00"}}}`)
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"filter":{"name":"conf","after":"2022-06-01T10:00:00Z","between":["2022-06-01T10:00:00Z"],"and":{"after":"2022-06-02T10:00:00Z"}}}`, string(request.Variables))
+	})
+
+	t.Run("rejects nested invalid value", func(t *testing.T) {
+		_, err := coerce(t, `{"filter":{"and":{"between":["2022-06-01T12:00:00+02:00","07.06.2022"]}}}`)
+		require.Error(t, err)
+
+		requestErrors, ok := err.(RequestErrors)
+		require.True(t, ok)
+		require.Len(t, requestErrors, 1)
+		assert.Equal(t, `Variable "$filter" got invalid value at "filter.and.between[1]"; Expected type "DateTime". DateTime must be a RFC3339 date`, requestErrors[0].Message)
+	})
+
+	t.Run("ignores variables of other operations", func(t *testing.T) {
+		request := &Request{
+			OperationName: "Names",
+			Query: `query Events($filter: EventFilter) { events(filter: $filter) }
+				query Names($filter: String) { events(filter: {name: $filter}) }`,
+			Variables: []byte(`{"filter":"07.06.2022"}`),
+		}
+		require.NoError(t, request.coerceCustomScalarVariables(schema, scalars))
+		assert.Equal(t, `{"filter":"07.06.2022"}`, string(request.Variables))
+	})
+}
@@ -0,0 +1,56 @@
+package graphql
+
+import (
+	"bytes"
+	"encoding/json"
+
+	"github.com/buger/jsonparser"
+
+	"github.com/wundergraph/graphql-go-tools/pkg/lexer/literal"
+)
+
+// SetDefaultVariables fills in the variables of the operation the client didn't provide with the defaults,
+// e.g. a locale defaulted by the server. Per spec the default values declared by the operation take precedence,
+// so defaults are only set for variables without a value in both the request and the operation.
+// Defaults of variables the operation doesn't declare are ignored.
+// It must be called before the variables are validated.
+func (r *Request) SetDefaultVariables(defaults map[string]json.RawMessage) error {
+	if len(defaults) == 0 {
+		return nil
+	}
+
+	if report := r.parseQueryOnce(); report.HasErrors() {
+		return report
+	}
+
+	operationRef, ok := r.document.OperationDefinitionRefByName(r.OperationName)
+	if !ok {
+		return nil
+	}
+
+	for _, ref := range r.document.OperationDefinitions[operationRef].VariableDefinitions.Refs {
+		if r.document.VariableDefinitionHasDefaultValue(ref) {
+			continue
+		}
+		name := r.document.VariableDefinitionNameString(ref)
+		value, ok := defaults[name]
+		if !ok {
+			continue
+		}
+		if _, _, _, err := jsonparser.Get(r.Variables, name); err == nil {
+			continue
+		}
+
+		variables := bytes.TrimSpace(r.Variables)
+		if len(variables) == 0 || bytes.Equal(variables, literal.NULL) {
+			r.Variables = []byte("{}")
+		}
+		var err error
+		r.Variables, err = jsonparser.Set(r.Variables, value, name)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
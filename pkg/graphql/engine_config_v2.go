@@ -3,22 +3,46 @@ package graphql
 import (
 	"errors"
 	"net/http"
+	"time"
 
 	"github.com/wundergraph/graphql-go-tools/pkg/ast"
+	"github.com/wundergraph/graphql-go-tools/pkg/astnormalization"
 	graphqlDataSource "github.com/wundergraph/graphql-go-tools/pkg/engine/datasource/graphql_datasource"
+	"github.com/wundergraph/graphql-go-tools/pkg/engine/datasource/mockdatasource"
 	"github.com/wundergraph/graphql-go-tools/pkg/engine/plan"
 	"github.com/wundergraph/graphql-go-tools/pkg/engine/resolve"
+	"github.com/wundergraph/graphql-go-tools/pkg/postprocess"
 )
 
 const (
 	DefaultFlushIntervalInMilliseconds = 1000
+	DefaultPlanCacheSize               = 1024
 )
 
 type EngineV2Configuration struct {
 	schema                   *Schema
+	publicSchema             *Schema
 	plannerConfig            plan.Configuration
 	websocketBeforeStartHook WebsocketBeforeStartHook
 	dataLoaderConfig         dataLoaderConfig
+	maxOperationTimeout      time.Duration
+	maxAliasesPerField       int
+	fieldMocks               []fieldMock
+	enableFieldMocks         bool
+	executionLogging         *executionLoggingConfig
+	planCacheSize            int
+	responseValidation       bool
+	lazyFragmentExpansion    bool
+	// operationHasher is nil if the keys of the plan cache are hashed with the default XXHashOperationHasher
+	operationHasher OperationHasher
+	// responsePipeline is nil if responses are written as resolved
+	responsePipeline *postprocess.ResponsePipeline
+}
+
+type fieldMock struct {
+	typeName  string
+	fieldName string
+	resolver  mockdatasource.Resolver
 }
 
 func NewEngineV2Configuration(schema *Schema) EngineV2Configuration {
@@ -39,6 +63,7 @@ func NewEngineV2Configuration(schema *Schema) EngineV2Configuration {
 type dataLoaderConfig struct {
 	EnableSingleFlightLoader bool
 	EnableDataLoader         bool
+	EnableFetchDeduplication bool
 }
 
 func (e *EngineV2Configuration) AddDataSource(dataSource plan.DataSourceConfiguration) {
@@ -57,6 +82,22 @@ func (e *EngineV2Configuration) SetFieldConfigurations(fieldConfigs plan.FieldCo
 	e.plannerConfig.Fields = fieldConfigs
 }
 
+// SetSubscriptionFilter sends only the events of the subscription field typeName.fieldName to a client
+// which match the arguments of the client, e.g. if the subgraph publishes the events of all products
+func (e *EngineV2Configuration) SetSubscriptionFilter(typeName, fieldName string, filter *plan.SubscriptionFilterConfiguration) {
+	for i := range e.plannerConfig.Fields {
+		if e.plannerConfig.Fields[i].TypeName == typeName && e.plannerConfig.Fields[i].FieldName == fieldName {
+			e.plannerConfig.Fields[i].SubscriptionFilter = filter
+			return
+		}
+	}
+	e.plannerConfig.Fields = append(e.plannerConfig.Fields, plan.FieldConfiguration{
+		TypeName:           typeName,
+		FieldName:          fieldName,
+		SubscriptionFilter: filter,
+	})
+}
+
 func (e *EngineV2Configuration) DataSources() []plan.DataSourceConfiguration {
 	return e.plannerConfig.DataSources
 }
@@ -73,15 +114,209 @@ func (e *EngineV2Configuration) EnableSingleFlight(enable bool) {
 	e.dataLoaderConfig.EnableSingleFlightLoader = enable
 }
 
+// EnableFetchDeduplication loads identical fetches of an operation only once and shares the result,
+// e.g. the entity fetches of two aliased fields selecting the same entity.
+// Fetches of mutations are never deduplicated.
+func (e *EngineV2Configuration) EnableFetchDeduplication(enable bool) {
+	e.dataLoaderConfig.EnableFetchDeduplication = enable
+}
+
+// AddFieldMock registers a mock for the field typeName.fieldName, replacing an existing mock of the field.
+// When field mocks are enabled, the field is resolved by the resolver instead of its data source,
+// mocked and real fields can be selected in the same operation. Fields of subscriptions can't be mocked.
+func (e *EngineV2Configuration) AddFieldMock(typeName, fieldName string, resolver mockdatasource.Resolver) {
+	for i := range e.fieldMocks {
+		if e.fieldMocks[i].typeName == typeName && e.fieldMocks[i].fieldName == fieldName {
+			e.fieldMocks[i].resolver = resolver
+			return
+		}
+	}
+	e.fieldMocks = append(e.fieldMocks, fieldMock{typeName: typeName, fieldName: fieldName, resolver: resolver})
+}
+
+// EnableFieldMocks resolves the fields registered with AddFieldMock by their mocks
+func (e *EngineV2Configuration) EnableFieldMocks(enable bool) {
+	e.enableFieldMocks = enable
+}
+
+// applyFieldMocks removes the mocked fields from all data sources and adds a data source for each mock,
+// so the planner resolves mocked fields with a nested fetch of the mock.
+func (e *EngineV2Configuration) applyFieldMocks() {
+	subscriptionTypeName := e.schema.SubscriptionTypeName()
+	mockedFields := make(map[TypeFieldLookupKey]struct{}, len(e.fieldMocks))
+	dataSources := make([]plan.DataSourceConfiguration, 0, len(e.fieldMocks)+len(e.plannerConfig.DataSources))
+	fieldConfigs := make(plan.FieldConfigurations, len(e.plannerConfig.Fields))
+	copy(fieldConfigs, e.plannerConfig.Fields)
+
+	for _, mock := range e.fieldMocks {
+		if mock.typeName == subscriptionTypeName {
+			continue
+		}
+		mockedFields[CreateTypeFieldLookupKey(mock.typeName, mock.fieldName)] = struct{}{}
+
+		dataSources = append(dataSources, plan.DataSourceConfiguration{
+			RootNodes: []plan.TypeField{
+				{
+					TypeName:   mock.typeName,
+					FieldNames: []string{mock.fieldName},
+				},
+			},
+			Factory: &mockdatasource.Factory{
+				Resolver: mock.resolver,
+			},
+			Custom: mockdatasource.ConfigJSON(mockdatasource.Configuration{
+				TypeName:  mock.typeName,
+				FieldName: mock.fieldName,
+			}),
+		})
+		fieldConfigs = mockFieldConfiguration(fieldConfigs, mock.typeName, mock.fieldName)
+	}
+
+	if len(mockedFields) == 0 {
+		return
+	}
+
+	for _, dataSource := range e.plannerConfig.DataSources {
+		dataSource.RootNodes = withoutMockedFields(dataSource.RootNodes, mockedFields)
+		dataSource.ChildNodes = withoutMockedFields(dataSource.ChildNodes, mockedFields)
+		dataSources = append(dataSources, dataSource)
+	}
+
+	e.plannerConfig.DataSources = dataSources
+	e.plannerConfig.Fields = fieldConfigs
+}
+
+// mockFieldConfiguration makes the value of a mocked field the whole response of its mock
+// and drops the fields its data source required
+func mockFieldConfiguration(fieldConfigs plan.FieldConfigurations, typeName, fieldName string) plan.FieldConfigurations {
+	for i := range fieldConfigs {
+		if fieldConfigs[i].TypeName == typeName && fieldConfigs[i].FieldName == fieldName {
+			fieldConfigs[i].DisableDefaultMapping = true
+			fieldConfigs[i].Path = nil
+			fieldConfigs[i].RequiresFields = nil
+			fieldConfigs[i].AlternativeRequiresFields = nil
+			return fieldConfigs
+		}
+	}
+	return append(fieldConfigs, plan.FieldConfiguration{
+		TypeName:              typeName,
+		FieldName:             fieldName,
+		DisableDefaultMapping: true,
+	})
+}
+
+func withoutMockedFields(typeFields []plan.TypeField, mockedFields map[TypeFieldLookupKey]struct{}) []plan.TypeField {
+	out := make([]plan.TypeField, 0, len(typeFields))
+	for _, typeField := range typeFields {
+		fieldNames := make([]string, 0, len(typeField.FieldNames))
+		for _, fieldName := range typeField.FieldNames {
+			if _, ok := mockedFields[CreateTypeFieldLookupKey(typeField.TypeName, fieldName)]; ok {
+				continue
+			}
+			fieldNames = append(fieldNames, fieldName)
+		}
+		if len(fieldNames) == 0 {
+			continue
+		}
+		typeField.FieldNames = fieldNames
+		out = append(out, typeField)
+	}
+	return out
+}
+
 // SetWebsocketBeforeStartHook - sets before start hook which will be called before processing any operation sent over websockets
 func (e *EngineV2Configuration) SetWebsocketBeforeStartHook(hook WebsocketBeforeStartHook) {
 	e.websocketBeforeStartHook = hook
 }
 
+// SetCustomScalars sets the registry of custom scalars.
+// Variables of custom scalar types get parsed before execution and results get serialized by the registered scalar.
+func (e *EngineV2Configuration) SetCustomScalars(scalars *resolve.ScalarRegistry) {
+	e.plannerConfig.CustomScalars = scalars
+}
+
+// SetEnumValueConfigurations sets the values of enums which are named differently by the data sources.
+// The values are renamed in the variables sent to the data sources and in their responses.
+func (e *EngineV2Configuration) SetEnumValueConfigurations(enumValues plan.EnumValueConfigurations) {
+	e.plannerConfig.EnumValues = enumValues
+}
+
+// SetMaxOperationTimeout sets the upper bound for timeouts set by operations with the @timeout(ms: Int!) directive.
+// The directive must be defined by the schema, longer timeouts are clamped to max, a max of 0 disables clamping.
+func (e *EngineV2Configuration) SetMaxOperationTimeout(max time.Duration) {
+	e.maxOperationTimeout = max
+}
+
+// SetMaxAliasesPerField limits the number of aliases a field can be selected under within a selection set,
+// operations exceeding it are rejected before planning. A max of 0 disables the limit, which is the default.
+func (e *EngineV2Configuration) SetMaxAliasesPerField(max int) {
+	e.maxAliasesPerField = max
+}
+
+// SetPlanCacheSize sets the number of operation plans cached by the engine, defaults to DefaultPlanCacheSize.
+// The least recently used plan gets evicted when the cache is full.
+func (e *EngineV2Configuration) SetPlanCacheSize(size int) {
+	e.planCacheSize = size
+}
+
+// SetResponsePipeline post processes every response with the pipeline, e.g. to mask fields or omit null values,
+// see postprocess.ResponsePipeline. The extensions collected while resolving are added by the pipeline as well,
+// with the order postprocess.ResponseOrderExtensions.
+func (e *EngineV2Configuration) SetResponsePipeline(pipeline *postprocess.ResponsePipeline) {
+	e.responsePipeline = pipeline
+}
+
+// SetPublicSchema sets the schema exposed to clients, e.g. a federated schema without @inaccessible elements.
+// Operations get normalized and validated against the public schema and introspection returns the public schema,
+// while planning still uses the full schema.
+func (e *EngineV2Configuration) SetPublicSchema(schema *Schema) {
+	e.publicSchema = schema
+}
+
+// EnableResponseValidation validates the responses of data sources against the types of the fields of the operation,
+// e.g. during development to catch subgraphs returning data which violates their own schema.
+// Violations get logged as warnings and added to the extensions of the response, see resolve.ResponseValidation.
+// It's expensive and disabled by default.
+func (e *EngineV2Configuration) EnableResponseValidation(enable bool) {
+	e.responseValidation = enable
+}
+
+// EnableLazyFragmentExpansion keeps the fragments of operations during normalization and expands them on demand
+// while planning instead of inlining all fragments into each other up front,
+// see astnormalization.WithLazyFragmentExpansion and plan.Configuration.LazyFragmentExpansion.
+// It reduces the memory needed to plan huge documents with many nested fragments and is disabled by default.
+func (e *EngineV2Configuration) EnableLazyFragmentExpansion(enable bool) {
+	e.lazyFragmentExpansion = enable
+	e.plannerConfig.LazyFragmentExpansion = enable
+}
+
+// normalizationOptions returns the options operations get normalized with in addition to the defaults of Request.Normalize
+func (e *EngineV2Configuration) normalizationOptions() []astnormalization.Option {
+	if e.lazyFragmentExpansion {
+		return []astnormalization.Option{astnormalization.WithLazyFragmentExpansion()}
+	}
+	return nil
+}
+
+// SetOperationHasher sets the hasher of the keys of the plan cache, defaults to XXHashOperationHasher
+func (e *EngineV2Configuration) SetOperationHasher(hasher OperationHasher) {
+	e.operationHasher = hasher
+}
+
+// exposedSchema returns the public schema if set, otherwise the full schema
+func (e *EngineV2Configuration) exposedSchema() *Schema {
+	if e.publicSchema != nil {
+		return e.publicSchema
+	}
+	return e.schema
+}
+
 type dataSourceV2GeneratorOptions struct {
 	streamingClient           *http.Client
 	subscriptionType          SubscriptionType
 	subscriptionClientFactory graphqlDataSource.GraphQLSubscriptionClientFactory
+	subscriptionMultiplexing  bool
+	loader                    resolve.DataSource
 }
 
 type DataSourceV2GeneratorOption func(options *dataSourceV2GeneratorOptions)
@@ -99,6 +334,19 @@ func WithDataSourceV2GeneratorSubscriptionClientFactory(factory graphqlDataSourc
 	}
 }
 
+func WithDataSourceV2GeneratorSubscriptionMultiplexing(enabled bool) DataSourceV2GeneratorOption {
+	return func(options *dataSourceV2GeneratorOptions) {
+		options.subscriptionMultiplexing = enabled
+	}
+}
+
+// WithDataSourceV2GeneratorLoader loads the requests to the upstream with the loader instead of the http client
+func WithDataSourceV2GeneratorLoader(loader resolve.DataSource) DataSourceV2GeneratorOption {
+	return func(options *dataSourceV2GeneratorOptions) {
+		options.loader = loader
+	}
+}
+
 type graphqlDataSourceV2Generator struct {
 	document *ast.Document
 }
@@ -113,6 +361,7 @@ func (d *graphqlDataSourceV2Generator) Generate(config graphqlDataSource.Configu
 	var planDataSource plan.DataSourceConfiguration
 	extractor := plan.NewLocalTypeFieldExtractor(d.document)
 	planDataSource.RootNodes, planDataSource.ChildNodes = extractor.GetAllNodes()
+	planDataSource.ProvidedFields = extractor.GetProvidedFields()
 
 	definedOptions := &dataSourceV2GeneratorOptions{
 		streamingClient:           &http.Client{Timeout: 0},
@@ -128,6 +377,7 @@ func (d *graphqlDataSourceV2Generator) Generate(config graphqlDataSource.Configu
 		HTTPClient:      httpClient,
 		StreamingClient: definedOptions.streamingClient,
 		BatchFactory:    batchFactory,
+		Loader:          definedOptions.loader,
 	}
 
 	subscriptionClient, err := d.generateSubscriptionClient(httpClient, definedOptions)
@@ -143,6 +393,11 @@ func (d *graphqlDataSourceV2Generator) Generate(config graphqlDataSource.Configu
 }
 
 func (d *graphqlDataSourceV2Generator) generateSubscriptionClient(httpClient *http.Client, definedOptions *dataSourceV2GeneratorOptions) (*graphqlDataSource.SubscriptionClient, error) {
+	var clientOptions []graphqlDataSource.Options
+	if definedOptions.subscriptionMultiplexing {
+		clientOptions = append(clientOptions, graphqlDataSource.WithMultiplexing())
+	}
+
 	var graphqlSubscriptionClient graphqlDataSource.GraphQLSubscriptionClient
 	switch definedOptions.subscriptionType {
 	case SubscriptionTypeGraphQLTransportWS:
@@ -150,7 +405,7 @@ func (d *graphqlDataSourceV2Generator) generateSubscriptionClient(httpClient *ht
 			httpClient,
 			definedOptions.streamingClient,
 			nil,
-			graphqlDataSource.WithWSSubProtocol(graphqlDataSource.ProtocolGraphQLTWS),
+			append(clientOptions, graphqlDataSource.WithWSSubProtocol(graphqlDataSource.ProtocolGraphQLTWS))...,
 		)
 	default:
 		// for compatibility reasons we fall back to graphql-ws protocol
@@ -158,7 +413,7 @@ func (d *graphqlDataSourceV2Generator) generateSubscriptionClient(httpClient *ht
 			httpClient,
 			definedOptions.streamingClient,
 			nil,
-			graphqlDataSource.WithWSSubProtocol(graphqlDataSource.ProtocolGraphQLWS),
+			append(clientOptions, graphqlDataSource.WithWSSubProtocol(graphqlDataSource.ProtocolGraphQLWS))...,
 		)
 	}
 
@@ -291,6 +291,51 @@ func TestEngineV2Configuration_EnableSingleFlight(t *testing.T) {
 	assert.False(t, conf.dataLoaderConfig.EnableSingleFlightLoader)
 }
 
+func TestEngineV2Configuration_applyFieldMocks(t *testing.T) {
+	schema, err := NewSchemaFromString(graphqlGeneratorSchema)
+	require.NoError(t, err)
+
+	conf := NewEngineV2Configuration(schema)
+	conf.AddDataSource(plan.DataSourceConfiguration{
+		RootNodes: []plan.TypeField{
+			{TypeName: "Query", FieldNames: []string{"me"}},
+			{TypeName: "Subscription", FieldNames: []string{"userCount"}},
+		},
+		ChildNodes: []plan.TypeField{
+			{TypeName: "User", FieldNames: []string{"id", "name", "age"}},
+			{TypeName: "Language", FieldNames: []string{"name"}},
+		},
+	})
+	conf.AddFieldConfiguration(plan.FieldConfiguration{
+		TypeName:       "User",
+		FieldName:      "name",
+		RequiresFields: []string{"id"},
+	})
+
+	mock := func(ctx context.Context) ([]byte, error) {
+		return []byte(`"MOCK"`), nil
+	}
+	conf.AddFieldMock("User", "name", mock)
+	conf.AddFieldMock("Language", "name", mock)
+	conf.AddFieldMock("Subscription", "userCount", mock)
+	conf.applyFieldMocks()
+
+	dataSources := conf.DataSources()
+	require.Len(t, dataSources, 3)
+	assert.Equal(t, []plan.TypeField{{TypeName: "User", FieldNames: []string{"name"}}}, dataSources[0].RootNodes)
+	assert.Equal(t, []plan.TypeField{{TypeName: "Language", FieldNames: []string{"name"}}}, dataSources[1].RootNodes)
+	assert.Equal(t, []plan.TypeField{
+		{TypeName: "Query", FieldNames: []string{"me"}},
+		{TypeName: "Subscription", FieldNames: []string{"userCount"}},
+	}, dataSources[2].RootNodes)
+	assert.Equal(t, []plan.TypeField{{TypeName: "User", FieldNames: []string{"id", "age"}}}, dataSources[2].ChildNodes)
+
+	assert.Equal(t, plan.FieldConfigurations{
+		{TypeName: "User", FieldName: "name", DisableDefaultMapping: true},
+		{TypeName: "Language", FieldName: "name", DisableDefaultMapping: true},
+	}, conf.FieldConfigurations())
+}
+
 var mockSubscriptionClient = &graphqlDataSource.SubscriptionClient{}
 
 type MockSubscriptionClientFactory struct{}
@@ -0,0 +1,68 @@
+package graphql
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jensneuse/abstractlogger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/wundergraph/graphql-go-tools/pkg/engine/datasource/graphql_datasource"
+)
+
+func TestExecutionEngineV2_EntityKeys(t *testing.T) {
+	execute := func(t *testing.T, productsSDL string) (productsRequest string) {
+		productsRequests, reviewsRequests := make(chan string, 1), make(chan string, 1)
+		products := newStaticUpstream(t, `{"data":{"_entities":[{"__typename":"Product","name":"Table"}]}}`, productsRequests)
+		reviews := newStaticUpstream(t, `{"data":{"topReviews":[{"body":"Great","product":{"__typename":"Product","upc":"1","sku":"A"}}]}}`, reviewsRequests)
+
+		factory := NewFederationEngineConfigFactory([]graphql_datasource.Configuration{
+			{
+				Fetch: graphql_datasource.FetchConfiguration{
+					URL: products.URL,
+				},
+				Federation: graphql_datasource.FederationConfiguration{
+					Enabled:    true,
+					ServiceSDL: productsSDL,
+				},
+			},
+			{
+				Fetch: graphql_datasource.FetchConfiguration{
+					URL: reviews.URL,
+				},
+				Federation: graphql_datasource.FederationConfiguration{
+					Enabled:    true,
+					ServiceSDL: `extend type Query { topReviews: [Review] } type Review { body: String! product: Product! } extend type Product @key(fields: "upc sku") { upc: String! @external sku: String! @external }`,
+				},
+			},
+		}, graphql_datasource.NewBatchFactory())
+		engineConf, err := factory.EngineV2Configuration()
+		require.NoError(t, err)
+
+		engineCtx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		engine, err := NewExecutionEngineV2(engineCtx, abstractlogger.NoopLogger, engineConf)
+		require.NoError(t, err)
+
+		operation := Request{Query: `{ topReviews { body product { name } } }`}
+		resultWriter := NewEngineResultWriter()
+		require.NoError(t, engine.Execute(context.Background(), &operation, &resultWriter))
+		assert.Equal(t, `{"data":{"topReviews":[{"body":"Great","product":{"name":"Table"}}]}}`, resultWriter.String())
+
+		<-reviewsRequests
+		return <-productsRequests
+	}
+
+	t.Run("composite key", func(t *testing.T) {
+		productsRequest := execute(t, `extend type Query { topProducts: [Product] } type Product @key(fields: "upc sku") { upc: String! sku: String! name: String! }`)
+		assert.Contains(t, productsRequest, `"representations":[{"__typename":"Product","upc":"1","sku":"A"}]`)
+	})
+
+	t.Run("key available from the subgraph returning the entity is selected", func(t *testing.T) {
+		productsRequest := execute(t, `extend type Query { topProducts: [Product] } type Product @key(fields: "id") @key(fields: "upc sku") { id: ID! upc: String! sku: String! name: String! }`)
+		assert.Contains(t, productsRequest, `"representations":[{"__typename":"Product","upc":"1","sku":"A"}]`)
+		assert.NotContains(t, productsRequest, `"id"`)
+	})
+}
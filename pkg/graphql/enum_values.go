@@ -0,0 +1,183 @@
+package graphql
+
+import (
+	"bytes"
+
+	"github.com/buger/jsonparser"
+
+	"github.com/wundergraph/graphql-go-tools/pkg/ast"
+	"github.com/wundergraph/graphql-go-tools/pkg/engine/plan"
+	"github.com/wundergraph/graphql-go-tools/pkg/lexer/literal"
+)
+
+// renameEnumVariables replaces the values of enums in the variables with the values of the data sources,
+// including the values nested in input objects and lists, see plan.EnumValueConfiguration.
+// The values in the responses of the data sources are renamed by the resolver.
+func (r *Request) renameEnumVariables(schema *Schema, enumValues plan.EnumValueConfigurations) error {
+	if len(enumValues) == 0 {
+		return nil
+	}
+
+	if report := r.parseQueryOnce(); report.HasErrors() {
+		return report
+	}
+
+	renaming := enumValueRenaming{
+		definition: &schema.document,
+		enumValues: enumValues,
+		visited:    map[string]bool{},
+	}
+
+	for ref := range r.document.VariableDefinitions {
+		typeRef := r.document.VariableDefinitions[ref].Type
+		if !renaming.containsRenamedEnum(r.document.ResolveTypeNameString(typeRef)) {
+			continue
+		}
+
+		name := r.document.VariableDefinitionNameString(ref)
+		value, valueType, _, err := jsonparser.Get(r.Variables, name)
+		if err != nil || valueType == jsonparser.Null {
+			continue
+		}
+
+		renamed, err := renaming.rename(&r.document, typeRef, value, valueType)
+		if err != nil {
+			return err
+		}
+
+		r.Variables, err = jsonparser.Set(r.Variables, renamed, name)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// enumValueRenaming renames the enum values of variables,
+// the types of input object fields are resolved with the definition
+type enumValueRenaming struct {
+	definition *ast.Document
+	enumValues plan.EnumValueConfigurations
+	// visited caches whether a type contains renamed enums
+	visited map[string]bool
+}
+
+// containsRenamedEnum reports whether the type is an enum with renamed values or an input object with fields of such enums
+func (e *enumValueRenaming) containsRenamedEnum(typeName string) bool {
+	if contains, ok := e.visited[typeName]; ok {
+		return contains
+	}
+	contains := e.reachesRenamedEnum(typeName, map[string]struct{}{})
+	e.visited[typeName] = contains
+	return contains
+}
+
+// reachesRenamedEnum is containsRenamedEnum without caching the results of types checked while seen,
+// as they are incomplete for input objects referencing each other
+func (e *enumValueRenaming) reachesRenamedEnum(typeName string, seen map[string]struct{}) bool {
+	if contains, ok := e.visited[typeName]; ok {
+		return contains
+	}
+	if _, ok := seen[typeName]; ok {
+		return false
+	}
+	seen[typeName] = struct{}{}
+
+	if e.enumValues.HasType(typeName) {
+		return true
+	}
+	node, ok := e.definition.Index.FirstNodeByNameStr(typeName)
+	if !ok || node.Kind != ast.NodeKindInputObjectTypeDefinition {
+		return false
+	}
+	for _, ref := range e.definition.InputObjectTypeDefinitions[node.Ref].InputFieldsDefinition.Refs {
+		if e.reachesRenamedEnum(e.definition.ResolveTypeNameString(e.definition.InputValueDefinitionType(ref)), seen) {
+			return true
+		}
+	}
+	return false
+}
+
+func (e *enumValueRenaming) rename(document *ast.Document, typeRef int, value []byte, valueType jsonparser.ValueType) ([]byte, error) {
+	if valueType == jsonparser.Null {
+		return literal.NULL, nil
+	}
+
+	switch document.Types[typeRef].TypeKind {
+	case ast.TypeKindNonNull:
+		return e.rename(document, document.Types[typeRef].OfType, value, valueType)
+	case ast.TypeKindList:
+		if valueType != jsonparser.Array {
+			return e.rename(document, document.Types[typeRef].OfType, value, valueType)
+		}
+
+		items := &bytes.Buffer{}
+		items.WriteByte('[')
+		var itemErr error
+		_, err := jsonparser.ArrayEach(value, func(item []byte, itemType jsonparser.ValueType, offset int, err error) {
+			if itemErr != nil {
+				return
+			}
+			renamed, err := e.rename(document, document.Types[typeRef].OfType, item, itemType)
+			if err != nil {
+				itemErr = err
+				return
+			}
+			if items.Len() > 1 {
+				items.WriteByte(',')
+			}
+			items.Write(renamed)
+		})
+		if err != nil {
+			return nil, err
+		}
+		if itemErr != nil {
+			return nil, itemErr
+		}
+		items.WriteByte(']')
+		return items.Bytes(), nil
+	}
+
+	typeName := document.ResolveTypeNameString(typeRef)
+	if valueType == jsonparser.String {
+		if e.enumValues.HasType(typeName) {
+			value = []byte(e.enumValues.RenameValueOnMatch(typeName, string(value)))
+		}
+		// jsonparser strips the quotes of string values
+		return append(append([]byte{'"'}, value...), '"'), nil
+	}
+
+	if valueType != jsonparser.Object || !e.containsRenamedEnum(typeName) {
+		return value, nil
+	}
+	node, _ := e.definition.Index.FirstNodeByNameStr(typeName)
+	return e.renameInputObject(node.Ref, value)
+}
+
+func (e *enumValueRenaming) renameInputObject(inputObjectRef int, value []byte) ([]byte, error) {
+	// the value is part of the variables, jsonparser.Set must not modify them in place
+	value = append([]byte(nil), value...)
+
+	for _, ref := range e.definition.InputObjectTypeDefinitions[inputObjectRef].InputFieldsDefinition.Refs {
+		typeRef := e.definition.InputValueDefinitionType(ref)
+		if !e.containsRenamedEnum(e.definition.ResolveTypeNameString(typeRef)) {
+			continue
+		}
+
+		name := e.definition.InputValueDefinitionNameString(ref)
+		fieldValue, fieldValueType, _, err := jsonparser.Get(value, name)
+		if err != nil {
+			continue
+		}
+
+		renamed, err := e.rename(e.definition, typeRef, fieldValue, fieldValueType)
+		if err != nil {
+			return nil, err
+		}
+		value, err = jsonparser.Set(value, renamed, name)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return value, nil
+}
@@ -0,0 +1,100 @@
+package graphql
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/jensneuse/abstractlogger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/wundergraph/graphql-go-tools/pkg/engine/datasource/graphql_datasource"
+	"github.com/wundergraph/graphql-go-tools/pkg/engine/plan"
+)
+
+func TestExecutionEngineV2_EnumValues(t *testing.T) {
+	schema, err := NewSchemaFromString(`
+		schema { query: Query }
+
+		enum Status { ENABLED DISABLED }
+
+		type Query {
+			users(status: Status, filter: UserFilter): [User!]!
+		}
+
+		input UserFilter {
+			statuses: [Status!]
+		}
+
+		type User {
+			name: String!
+			status: Status!
+			history: [Status!]!
+			settings: Settings
+		}
+
+		type Settings {
+			notifications: Status
+		}`)
+	require.NoError(t, err)
+
+	upstream := &recordingRoundTripper{
+		responseBody: `{"data":{"users":[{"name":"Jens","status":"ACTIVE","history":["DISABLED","ACTIVE"],"settings":{"notifications":"ACTIVE"}}]}}`,
+	}
+
+	engineConf := NewEngineV2Configuration(schema)
+	engineConf.SetEnumValueConfigurations(plan.EnumValueConfigurations{
+		{TypeName: "Status", ValueName: "ENABLED", RenameTo: "ACTIVE"},
+	})
+	engineConf.SetDataSources([]plan.DataSourceConfiguration{
+		{
+			RootNodes: []plan.TypeField{
+				{TypeName: "Query", FieldNames: []string{"users"}},
+			},
+			ChildNodes: []plan.TypeField{
+				{TypeName: "User", FieldNames: []string{"name", "status", "history", "settings"}},
+				{TypeName: "Settings", FieldNames: []string{"notifications"}},
+			},
+			Factory: &graphql_datasource.Factory{
+				HTTPClient: &http.Client{Transport: upstream},
+			},
+			Custom: graphql_datasource.ConfigJson(graphql_datasource.Configuration{
+				Fetch: graphql_datasource.FetchConfiguration{
+					URL:    "https://example.com/",
+					Method: "POST",
+				},
+			}),
+		},
+	})
+	engineConf.SetFieldConfigurations(plan.FieldConfigurations{
+		{
+			TypeName:  "Query",
+			FieldName: "users",
+			Arguments: []plan.ArgumentConfiguration{
+				{Name: "status", SourceType: plan.FieldArgumentSource},
+				{Name: "filter", SourceType: plan.FieldArgumentSource},
+			},
+		},
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	engine, err := NewExecutionEngineV2(ctx, abstractlogger.Noop{}, engineConf)
+	require.NoError(t, err)
+
+	operation := Request{
+		OperationName: "Users",
+		Query:         `query Users($status: Status, $filter: UserFilter) { users(status: $status, filter: $filter) { name status history settings { notifications } } }`,
+		Variables:     []byte(`{"status":"ENABLED","filter":{"statuses":["DISABLED","ENABLED"]}}`),
+	}
+	resultWriter := NewEngineResultWriter()
+	require.NoError(t, engine.Execute(context.Background(), &operation, &resultWriter))
+
+	require.Len(t, upstream.requests, 1)
+	// the values of the variables, including the values in lists of input objects, are renamed for the subgraph
+	assert.Contains(t, upstream.requests[0], `"status":"ACTIVE"`)
+	assert.Contains(t, upstream.requests[0], `"filter":{"statuses":["DISABLED","ACTIVE"]}`)
+	assert.Equal(t, `{"data":{"users":[{"name":"Jens","status":"ENABLED","history":["DISABLED","ENABLED"],"settings":{"notifications":"ENABLED"}}]}}`, resultWriter.String())
+}
@@ -154,7 +154,8 @@ func schemaValidationErrorsFromOperationReport(report operationreport.Report) (e
 
 	for _, externalError := range report.ExternalErrors {
 		validationError := SchemaValidationError{
-			Message: externalError.Message,
+			Message:   externalError.Message,
+			Locations: externalError.Locations,
 		}
 
 		errors = append(errors, validationError)
@@ -184,6 +185,8 @@ func (s SchemaValidationErrors) ErrorByIndex(i int) error {
 
 type SchemaValidationError struct {
 	Message string `json:"message"`
+	// Locations are the positions of the violation in the schema, if known
+	Locations []graphqlerrors.Location `json:"locations,omitempty"`
 }
 
 func (s SchemaValidationError) Error() string {
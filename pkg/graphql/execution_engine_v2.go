@@ -6,10 +6,13 @@ import (
 	"compress/gzip"
 	"context"
 	"errors"
+	"hash"
 	"io/ioutil"
 	"net/http"
 	"strconv"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	lru "github.com/hashicorp/golang-lru"
 	"github.com/jensneuse/abstractlogger"
@@ -105,8 +108,14 @@ func (e *EngineResultWriter) AsHTTPResponse(status int, headers http.Header) *ht
 }
 
 type internalExecutionContext struct {
-	resolveContext *resolve.Context
-	postProcessor  *postprocess.Processor
+	resolveContext   *resolve.Context
+	postProcessor    *postprocess.Processor
+	responsePipeline *postprocess.ResponsePipeline
+	deniedFields     []Type
+	// unauthorizedFields are the fields of the operation the request isn't authorized to access
+	unauthorizedFields []plan.TypeField
+	// incremental is set while executing an operation using @defer and @stream, see ExecuteIncremental
+	incremental *incrementalOperation
 }
 
 func newInternalExecutionContext() *internalExecutionContext {
@@ -136,16 +145,31 @@ func (e *internalExecutionContext) setVariables(variables []byte) {
 
 func (e *internalExecutionContext) reset() {
 	e.resolveContext.Free()
+	e.responsePipeline = nil
+	e.deniedFields = nil
+	e.unauthorizedFields = nil
+	e.incremental = nil
 }
 
 type ExecutionEngineV2 struct {
+	// planCount is the number of operations planned, i.e. not served from the cache.
+	// It is accessed atomically and kept first for 64-bit alignment.
+	planCount uint64
+
 	logger                       abstractlogger.Logger
 	config                       EngineV2Configuration
 	planner                      *plan.Planner
 	plannerMu                    sync.Mutex
 	resolver                     *resolve.Resolver
 	internalExecutionContextPool sync.Pool
-	executionPlanCache           *lru.Cache
+	// executionPlanCache holds the plans of operations keyed by the hash of the normalized operation.
+	// Plans are only valid for the schema and data sources of the engine,
+	// reloading the schema requires a new engine which starts with an empty cache.
+	executionPlanCache *lru.Cache
+	overrideLabels     []string
+	executionLogger    *executionLogger
+	// authorization is true if the schema has fields with @authenticated or @requiresScopes
+	authorization bool
 }
 
 type WebsocketBeforeStartHook interface {
@@ -193,14 +217,83 @@ func WithAdditionalHttpHeaders(headers http.Header, excludeByKeys ...string) Exe
 	}
 }
 
+// WithSubgraphMetrics adds the number of fetches, the response bytes and the total latency per subgraph
+// to the extensions of the response, see resolve.SubgraphMetrics.
+// subgraphNames optionally maps the url of a subgraph to its name.
+func WithSubgraphMetrics(subgraphNames map[string]string) ExecutionOptionsV2 {
+	return func(ctx *internalExecutionContext) {
+		ctx.resolveContext.SetSubgraphMetrics(resolve.NewSubgraphMetrics(subgraphNames))
+	}
+}
+
+// WithSubgraphExtensions merges the "extensions" of the responses of subgraphs into the extensions of the response,
+// namespaced by subgraph, see resolve.SubgraphExtensions. It's opt-in as subgraphs might return internal extensions.
+// subgraphNames optionally maps the url of a subgraph to its name.
+func WithSubgraphExtensions(subgraphNames map[string]string) ExecutionOptionsV2 {
+	return func(ctx *internalExecutionContext) {
+		ctx.resolveContext.SetSubgraphExtensions(resolve.NewSubgraphExtensions(subgraphNames))
+	}
+}
+
+// WithResponsePipeline runs the post processors of the pipeline on each response before it gets written,
+// instead of the pipeline of the configuration, see EngineV2Configuration.SetResponsePipeline.
+// The response gets buffered until it is complete, for subscriptions each message gets processed on its own.
+func WithResponsePipeline(pipeline *postprocess.ResponsePipeline) ExecutionOptionsV2 {
+	return func(ctx *internalExecutionContext) {
+		ctx.responsePipeline = pipeline
+	}
+}
+
+// WithDeniedFields removes the selections of the denied fields from the operation before it gets planned,
+// e.g. to hide fields from some clients. See Request.RemoveFields.
+func WithDeniedFields(deniedFields []Type) ExecutionOptionsV2 {
+	return func(ctx *internalExecutionContext) {
+		ctx.deniedFields = deniedFields
+	}
+}
+
+// WithLocalizedNullPropagation sets non-nullable fields resolving to null to null themselves instead of their nearest
+// nullable parent, so the data of their siblings is kept. See resolve.NullPropagationLocalized.
+func WithLocalizedNullPropagation() ExecutionOptionsV2 {
+	return func(ctx *internalExecutionContext) {
+		ctx.resolveContext.SetNullPropagation(resolve.NullPropagationLocalized)
+	}
+}
+
+// WithMissingEntityErrors adds an error with the path of the object to the response for each object whose entity
+// is missing in the response of its _entities fetch. Such objects are set to null in any case, see resolve.MissingEntityPolicy.
+func WithMissingEntityErrors() ExecutionOptionsV2 {
+	return func(ctx *internalExecutionContext) {
+		ctx.resolveContext.SetMissingEntityPolicy(resolve.MissingEntityError)
+	}
+}
+
+// WithRequestTimeout bounds the total time of resolving the operation, including the fetches of all subgraphs.
+// Fetches still running at the deadline are cancelled, their fields resolve to null and the response gets a timeout error.
+// Timeouts defined by the operation only apply if they are shorter.
+func WithRequestTimeout(timeout time.Duration) ExecutionOptionsV2 {
+	return func(ctx *internalExecutionContext) {
+		ctx.resolveContext.SetRequestTimeout(timeout)
+	}
+}
+
 func NewExecutionEngineV2(ctx context.Context, logger abstractlogger.Logger, engineConfig EngineV2Configuration) (*ExecutionEngineV2, error) {
-	executionPlanCache, err := lru.New(1024)
+	planCacheSize := engineConfig.planCacheSize
+	if planCacheSize <= 0 {
+		planCacheSize = DefaultPlanCacheSize
+	}
+	executionPlanCache, err := lru.New(planCacheSize)
 	if err != nil {
 		return nil, err
 	}
 	fetcher := resolve.NewFetcher(engineConfig.dataLoaderConfig.EnableSingleFlightLoader)
+	fetcher.EnableFetchDeduplication = engineConfig.dataLoaderConfig.EnableFetchDeduplication
+
+	if engineConfig.enableFieldMocks {
+		engineConfig.applyFieldMocks()
+	}
 
-	introspectionCfg, err := introspection_datasource.NewIntrospectionConfigFactory(&engineConfig.schema.document)
+	introspectionCfg, err := introspection_datasource.NewIntrospectionConfigFactory(&engineConfig.exposedSchema().document)
 	if err != nil {
 		return nil, err
 	}
@@ -221,44 +314,64 @@ func NewExecutionEngineV2(ctx context.Context, logger abstractlogger.Logger, eng
 			},
 		},
 		executionPlanCache: executionPlanCache,
+		overrideLabels:     engineConfig.plannerConfig.OverrideLabels(),
+		authorization:      hasAuthorizationDirectives(engineConfig.schema),
+		executionLogger:    newExecutionLogger(logger, engineConfig.executionLogging),
 	}, nil
 }
 
 func (e *ExecutionEngineV2) Execute(ctx context.Context, operation *Request, writer resolve.FlushWriter, options ...ExecutionOptionsV2) error {
-	if !operation.IsNormalized() {
-		result, err := operation.Normalize(e.config.schema)
-		if err != nil {
-			return err
-		}
-
-		if !result.Successful {
-			return result.Errors
-		}
+	if e.executionLogger != nil {
+		e.executionLogger.parseOperation(operation)
 	}
 
-	result, err := operation.ValidateForSchema(e.config.schema)
+	start := time.Now()
+	err := e.prepareOperation(operation)
+	if e.executionLogger != nil {
+		e.executionLogger.logNormalizedOperation(operation, time.Since(start), err)
+	}
 	if err != nil {
 		return err
 	}
-	if !result.Valid {
-		return result.Errors
-	}
 
 	execContext := e.getExecutionCtx()
 	defer e.putExecutionCtx(execContext)
 
 	execContext.prepare(ctx, operation.Variables, operation.request)
+	execContext.resolveContext.SetMaxOperationTimeout(e.config.maxOperationTimeout)
 
 	for i := range options {
 		options[i](execContext)
 	}
 
-	var report operationreport.Report
-	cachedPlan := e.getCachedPlan(execContext, &operation.document, &e.config.schema.document, operation.OperationName, &report)
-	if report.HasErrors() {
-		return report
+	var responseValidation *resolve.ResponseValidation
+	if e.config.responseValidation {
+		responseValidation = resolve.NewResponseValidation()
+		execContext.resolveContext.SetResponseValidation(responseValidation)
+	}
+
+	start = time.Now()
+	cachedPlan, err := e.planOperation(ctx, execContext, operation)
+	if err != nil {
+		return err
 	}
 
+	var responseWriter *countingFlushWriter
+	if e.executionLogger != nil {
+		e.executionLogger.logPlan(operation, cachedPlan, time.Since(start))
+		execContext.resolveContext.SetFetchLogger(e.executionLogger.fetchLogger)
+		responseWriter = &countingFlushWriter{FlushWriter: writer}
+		writer = responseWriter
+	}
+
+	var pipelineWriter *responsePipelineWriter
+	if pipeline := e.responsePipeline(execContext); !pipeline.Empty() {
+		pipelineCtx := postprocess.WithResponseOperation(ctx, &operation.document, &e.config.schema.document, operation.OperationName)
+		pipelineWriter = newResponsePipelineWriter(pipelineCtx, pipeline, writer)
+		writer = pipelineWriter
+	}
+
+	start = time.Now()
 	switch p := cachedPlan.(type) {
 	case *plan.SynchronousResponsePlan:
 		err = e.resolver.ResolveGraphQLResponse(execContext.resolveContext, p.Response, nil, writer)
@@ -268,22 +381,128 @@ func (e *ExecutionEngineV2) Execute(ctx context.Context, operation *Request, wri
 		return errors.New("execution of operation is not possible")
 	}
 
+	if pipelineWriter != nil && err == nil {
+		// synchronous responses are not flushed by the resolver
+		err = pipelineWriter.writeProcessed()
+		if err == nil {
+			err = pipelineWriter.err
+		}
+	}
+
+	if e.executionLogger != nil {
+		e.executionLogger.logResolvedResponse(operation, time.Since(start), responseWriter.written, err)
+	}
+
+	if responseValidation != nil {
+		e.logResponseViolations(operation, responseValidation.Violations())
+	}
+
 	return err
 }
 
-func (e *ExecutionEngineV2) getCachedPlan(ctx *internalExecutionContext, operation, definition *ast.Document, operationName string, report *operationreport.Report) plan.Plan {
+// logResponseViolations logs the values of the responses of data sources which don't match the types of their fields
+func (e *ExecutionEngineV2) logResponseViolations(operation *Request, violations []resolve.ResponseViolation) {
+	for i := range violations {
+		e.logger.Warn("response of data source violates the schema",
+			abstractlogger.String("operationName", operation.OperationName),
+			abstractlogger.String("path", violations[i].Path),
+			abstractlogger.String("message", violations[i].Message),
+		)
+	}
+}
 
-	hash := pool.Hash64.Get()
-	hash.Reset()
-	defer pool.Hash64.Put(hash)
-	err := astprinter.Print(operation, definition, hash)
+// prepareOperation normalizes and validates the operation against the exposed schema, coerces its variables
+// and renames the enum values of its variables to the values of the data sources
+func (e *ExecutionEngineV2) prepareOperation(operation *Request) error {
+	if err := e.validateOperation(operation); err != nil {
+		return err
+	}
+
+	if err := operation.validateAliasLimit(e.config.exposedSchema(), e.config.maxAliasesPerField); err != nil {
+		return err
+	}
+
+	result, err := operation.ValidateVariables(e.config.exposedSchema())
+	if err != nil {
+		return err
+	}
+	if !result.Valid {
+		return result.Errors
+	}
+
+	if err := operation.coerceCustomScalarVariables(e.config.exposedSchema(), e.config.plannerConfig.CustomScalars); err != nil {
+		return err
+	}
+
+	return operation.renameEnumVariables(e.config.exposedSchema(), e.config.plannerConfig.EnumValues)
+}
+
+// validateOperation normalizes and validates the operation against the exposed schema
+func (e *ExecutionEngineV2) validateOperation(operation *Request) error {
+	if err := operation.validateOperationName(); err != nil {
+		return err
+	}
+
+	schema := e.config.exposedSchema()
+	if !operation.IsNormalized() {
+		result, err := operation.normalize(schema, e.config.normalizationOptions()...)
+		if err != nil {
+			return err
+		}
+
+		if !result.Successful {
+			return result.Errors
+		}
+	}
+
+	result, err := operation.ValidateForSchema(schema)
+	if err != nil {
+		return err
+	}
+	if !result.Valid {
+		return result.Errors
+	}
+	return nil
+}
+
+// planOperation removes the denied fields of the operation and returns its plan, planning it if it's not cached
+func (e *ExecutionEngineV2) planOperation(ctx context.Context, execContext *internalExecutionContext, operation *Request) (plan.Plan, error) {
+	if err := e.restrictOperationFields(ctx, execContext, operation); err != nil {
+		return nil, err
+	}
+
+	var report operationreport.Report
+	cachedPlan := e.getCachedPlan(execContext, &operation.document, &e.config.schema.document, operation.OperationName, &report)
+	if report.HasErrors() {
+		return nil, report
+	}
+	return cachedPlan, nil
+}
+
+// restrictOperationFields removes the denied fields of the operation and collects its unauthorized fields,
+// which are planned without fetching them
+func (e *ExecutionEngineV2) restrictOperationFields(ctx context.Context, execContext *internalExecutionContext, operation *Request) (err error) {
+	if len(execContext.deniedFields) > 0 {
+		if err = operation.RemoveFields(e.config.exposedSchema(), execContext.deniedFields); err != nil {
+			return err
+		}
+	}
+
+	if e.authorization {
+		if execContext.unauthorizedFields, err = operation.unauthorizedFields(ctx, e.config.schema); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (e *ExecutionEngineV2) getCachedPlan(ctx *internalExecutionContext, operation, definition *ast.Document, operationName string, report *operationreport.Report) plan.Plan {
+	cacheKey, overrideLabels, err := e.operationPlanCacheKey(ctx, operation, definition, operationName)
 	if err != nil {
 		report.AddInternalError(err)
 		return nil
 	}
 
-	cacheKey := hash.Sum64()
-
 	if cached, ok := e.executionPlanCache.Get(cacheKey); ok {
 		if p, ok := cached.(plan.Plan); ok {
 			return p
@@ -292,16 +511,101 @@ func (e *ExecutionEngineV2) getCachedPlan(ctx *internalExecutionContext, operati
 
 	e.plannerMu.Lock()
 	defer e.plannerMu.Unlock()
+	// concurrent requests of the same operation wait for the first one to plan it
+	if cached, ok := e.executionPlanCache.Get(cacheKey); ok {
+		if p, ok := cached.(plan.Plan); ok {
+			return p
+		}
+	}
+	plannerConfig := e.config.plannerConfig
+	if overrideLabels != nil {
+		plannerConfig = plannerConfig.WithEnabledOverrideLabels(overrideLabels)
+	}
+	plannerConfig.UnauthorizedFields = ctx.unauthorizedFields
+	e.planner.SetConfig(plannerConfig)
 	planResult := e.planner.Plan(operation, definition, operationName, report)
+	atomic.AddUint64(&e.planCount, 1)
 	if report.HasErrors() {
 		return nil
 	}
 
 	p := ctx.postProcessor.Process(planResult)
+	if ctx.incremental != nil {
+		p = ctx.incremental.split(p)
+	}
 	e.executionPlanCache.Add(cacheKey, p)
 	return p
 }
 
+// operationPlanCacheKey returns the key of the plan of the operation in the plan cache
+// and the enabled override labels the operation is planned with
+func (e *ExecutionEngineV2) operationPlanCacheKey(ctx *internalExecutionContext, operation, definition *ast.Document, operationName string) (interface{}, map[string]bool, error) {
+	hash := e.newPlanCacheHash()
+	defer e.freePlanCacheHash(hash)
+	err := astprinter.Print(operation, definition, hash)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// documents with multiple operations keep all of them, the name selects the planned one
+	_, _ = hash.Write([]byte(operationName))
+	_, _ = hash.Write([]byte{0})
+
+	// the enabled labels of field overrides select the data sources of the overridden fields,
+	// so they are part of the cache key
+	var overrideLabels map[string]bool
+	if len(e.overrideLabels) != 0 {
+		overrideLabels = enabledOverrideLabels(e.overrideLabels)
+		for _, label := range e.overrideLabels {
+			if overrideLabels[label] {
+				_, _ = hash.Write([]byte(label))
+				_, _ = hash.Write([]byte{0})
+			}
+		}
+	}
+
+	// unauthorized fields are planned without fetching them
+	for _, typeField := range ctx.unauthorizedFields {
+		_, _ = hash.Write([]byte(typeField.TypeName))
+		for _, fieldName := range typeField.FieldNames {
+			_, _ = hash.Write([]byte{'.'})
+			_, _ = hash.Write([]byte(fieldName))
+		}
+		_, _ = hash.Write([]byte{0})
+	}
+
+	// incremental operations are planned like the operation without @defer and @stream and split afterwards
+	if ctx.incremental != nil {
+		ctx.incremental.writeCacheKey(hash)
+	}
+
+	return planCacheKey(hash), overrideLabels, nil
+}
+
+// newPlanCacheHash returns a hash of the operation hasher of the engine, the default xxhash hashes are pooled
+func (e *ExecutionEngineV2) newPlanCacheHash() hash.Hash {
+	if e.config.operationHasher == nil {
+		h := pool.Hash64.Get()
+		h.Reset()
+		return h
+	}
+	return e.config.operationHasher.New()
+}
+
+func (e *ExecutionEngineV2) freePlanCacheHash(h hash.Hash) {
+	if e.config.operationHasher == nil {
+		pool.Hash64.Put(h.(hash.Hash64))
+	}
+}
+
+// planCacheKey returns the key of the plan cache, 64 bit hashes are used as is to not allocate a key
+func planCacheKey(h hash.Hash) interface{} {
+	if h64, ok := h.(hash.Hash64); ok {
+		return h64.Sum64()
+	}
+	return string(h.Sum(nil))
+}
+
 func (e *ExecutionEngineV2) GetWebsocketBeforeStartHook() WebsocketBeforeStartHook {
 	return e.config.websocketBeforeStartHook
 }
@@ -310,7 +614,63 @@ func (e *ExecutionEngineV2) getExecutionCtx() *internalExecutionContext {
 	return e.internalExecutionContextPool.Get().(*internalExecutionContext)
 }
 
+// responsePipeline returns the pipeline post processing the response of the execution,
+// it includes the extensions collected while resolving
+func (e *ExecutionEngineV2) responsePipeline(execContext *internalExecutionContext) *postprocess.ResponsePipeline {
+	pipeline := e.config.responsePipeline
+	if execContext.responsePipeline != nil {
+		pipeline = execContext.responsePipeline
+	}
+	if execContext.resolveContext.HasResponseExtensions() {
+		pipeline = pipeline.With(postprocess.ResponseOrderExtensions, postprocess.Extensions(execContext.resolveContext.ResponseExtensions))
+	}
+	return pipeline
+}
+
 func (e *ExecutionEngineV2) putExecutionCtx(ctx *internalExecutionContext) {
 	ctx.reset()
 	e.internalExecutionContextPool.Put(ctx)
 }
+
+// responsePipelineWriter buffers the response until it is complete and writes it after running the response pipeline
+type responsePipelineWriter struct {
+	ctx      context.Context
+	pipeline *postprocess.ResponsePipeline
+	writer   resolve.FlushWriter
+	buf      bytes.Buffer
+	err      error
+}
+
+func newResponsePipelineWriter(ctx context.Context, pipeline *postprocess.ResponsePipeline, writer resolve.FlushWriter) *responsePipelineWriter {
+	return &responsePipelineWriter{
+		ctx:      ctx,
+		pipeline: pipeline,
+		writer:   writer,
+	}
+}
+
+func (w *responsePipelineWriter) Write(p []byte) (n int, err error) {
+	return w.buf.Write(p)
+}
+
+func (w *responsePipelineWriter) Flush() {
+	if err := w.writeProcessed(); err != nil {
+		w.err = err
+		return
+	}
+	w.writer.Flush()
+}
+
+func (w *responsePipelineWriter) writeProcessed() error {
+	if w.buf.Len() == 0 {
+		return nil
+	}
+	defer w.buf.Reset()
+
+	response, err := w.pipeline.Process(w.ctx, w.buf.Bytes())
+	if err != nil {
+		return err
+	}
+	_, err = w.writer.Write(response)
+	return err
+}
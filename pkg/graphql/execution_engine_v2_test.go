@@ -4,16 +4,22 @@ import (
 	"compress/flate"
 	"compress/gzip"
 	"context"
+	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/jensneuse/abstractlogger"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
 
 	"github.com/wundergraph/graphql-go-tools/pkg/engine/datasource/graphql_datasource"
 	"github.com/wundergraph/graphql-go-tools/pkg/engine/datasource/httpclient"
@@ -22,6 +28,7 @@ import (
 	"github.com/wundergraph/graphql-go-tools/pkg/engine/plan"
 	"github.com/wundergraph/graphql-go-tools/pkg/engine/resolve"
 	"github.com/wundergraph/graphql-go-tools/pkg/operationreport"
+	"github.com/wundergraph/graphql-go-tools/pkg/postprocess"
 	"github.com/wundergraph/graphql-go-tools/pkg/starwars"
 	"github.com/wundergraph/graphql-go-tools/pkg/testing/federationtesting"
 	accounts "github.com/wundergraph/graphql-go-tools/pkg/testing/federationtesting/accounts/graph"
@@ -502,6 +509,102 @@ func TestExecutionEngineV2_Execute(t *testing.T) {
 		},
 	))
 
+	t.Run("execute root __typename", func(t *testing.T) {
+		heroDataSource := func(t *testing.T) []plan.DataSourceConfiguration {
+			return []plan.DataSourceConfiguration{
+				{
+					RootNodes: []plan.TypeField{
+						{TypeName: "Query", FieldNames: []string{"hero"}},
+					},
+					Factory: &rest_datasource.Factory{
+						Client: testNetHttpClient(t, roundTripperTestCase{
+							expectedHost:     "example.com",
+							expectedPath:     "/",
+							expectedBody:     "",
+							sendResponseBody: `{"hero": {"name": "Luke Skywalker"}}`,
+							sendStatusCode:   200,
+						}),
+					},
+					Custom: rest_datasource.ConfigJSON(rest_datasource.Configuration{
+						Fetch: rest_datasource.FetchConfiguration{
+							URL:    "https://example.com/",
+							Method: "GET",
+						},
+					}),
+				},
+			}
+		}
+
+		t.Run("of query without fetch", runWithoutError(
+			ExecutionEngineV2TestCase{
+				schema: starwarsSchema(t),
+				operation: func(t *testing.T) Request {
+					return Request{Query: `{ __typename }`}
+				},
+				dataSources:      heroDataSource(t),
+				fields:           []plan.FieldConfiguration{},
+				expectedResponse: `{"data":{"__typename":"Query"}}`,
+			},
+		))
+
+		t.Run("of query next to fetched field", runWithoutError(
+			ExecutionEngineV2TestCase{
+				schema: starwarsSchema(t),
+				operation: func(t *testing.T) Request {
+					return Request{Query: `{ typename: __typename hero { name } }`}
+				},
+				dataSources:      heroDataSource(t),
+				fields:           []plan.FieldConfiguration{},
+				expectedResponse: `{"data":{"typename":"Query","hero":{"name":"Luke Skywalker"}}}`,
+			},
+		))
+
+		t.Run("of mutation", runWithoutError(
+			ExecutionEngineV2TestCase{
+				schema: starwarsSchema(t),
+				operation: func(t *testing.T) Request {
+					return Request{Query: `mutation { __typename }`}
+				},
+				dataSources:      heroDataSource(t),
+				fields:           []plan.FieldConfiguration{},
+				expectedResponse: `{"data":{"__typename":"Mutation"}}`,
+			},
+		))
+
+		t.Run("of custom root operation type", runWithoutError(
+			ExecutionEngineV2TestCase{
+				schema: func() *Schema {
+					schema, err := NewSchemaFromString(`
+						schema { query: RootQuery }
+						type RootQuery { hero: Hero }
+						type Hero { name: String }
+					`)
+					require.NoError(t, err)
+					return schema
+				}(),
+				operation: func(t *testing.T) Request {
+					return Request{Query: `{ __typename }`}
+				},
+				dataSources: []plan.DataSourceConfiguration{
+					{
+						RootNodes: []plan.TypeField{
+							{TypeName: "RootQuery", FieldNames: []string{"hero"}},
+						},
+						Factory: &rest_datasource.Factory{},
+						Custom: rest_datasource.ConfigJSON(rest_datasource.Configuration{
+							Fetch: rest_datasource.FetchConfiguration{
+								URL:    "https://example.com/",
+								Method: "GET",
+							},
+						}),
+					},
+				},
+				fields:           []plan.FieldConfiguration{},
+				expectedResponse: `{"data":{"__typename":"RootQuery"}}`,
+			},
+		))
+	})
+
 	t.Run("execute with header injection", runWithoutError(
 		ExecutionEngineV2TestCase{
 			schema: starwarsSchema(t),
@@ -1673,6 +1776,41 @@ func TestExecutionEngineV2_Execute(t *testing.T) {
 		},
 		"fragment spread: fragment reviewFields must be spread on type Review and not type Droid",
 	))
+
+	t.Run("invalid variable value returns error without calling the data source", runWithAndCompareError(
+		ExecutionEngineV2TestCase{
+			schema: starwarsSchema(t),
+			operation: func(t *testing.T) Request {
+				return Request{
+					OperationName: "CreateReview",
+					Query:         `mutation CreateReview($episode: Episode!, $review: ReviewInput!) { createReview(episode: $episode, review: $review) { stars } }`,
+					Variables:     []byte(`{"episode":"JEDI","review":{"stars":"five"}}`),
+				}
+			},
+			dataSources: []plan.DataSourceConfiguration{
+				{
+					RootNodes: []plan.TypeField{
+						{TypeName: "Mutation", FieldNames: []string{"createReview"}},
+					},
+					ChildNodes: []plan.TypeField{
+						{TypeName: "Review", FieldNames: []string{"stars"}},
+					},
+					Factory: &graphql_datasource.Factory{
+						HTTPClient: testNetHttpClient(t, roundTripperTestCase{}),
+					},
+					Custom: graphql_datasource.ConfigJson(graphql_datasource.Configuration{
+						Fetch: graphql_datasource.FetchConfiguration{
+							URL:    "https://example.com/",
+							Method: "POST",
+						},
+					}),
+				},
+			},
+			fields:           []plan.FieldConfiguration{},
+			expectedResponse: ``,
+		},
+		`Variable "$review" got invalid value {"stars":"five"} at "review.stars"; Expected type "Int".`,
+	))
 }
 
 func testNetHttpClient(t *testing.T, testCase roundTripperTestCase) *http.Client {
@@ -1772,6 +1910,548 @@ func TestExecutionWithOptions(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+func TestExecutionWithResponsePipeline(t *testing.T) {
+	engineConf := NewEngineV2Configuration(starwarsSchema(t))
+	engineConf.SetDataSources([]plan.DataSourceConfiguration{
+		{
+			RootNodes: []plan.TypeField{
+				{
+					TypeName:   "Query",
+					FieldNames: []string{"hero"},
+				},
+			},
+			ChildNodes: []plan.TypeField{
+				{
+					TypeName:   "Character",
+					FieldNames: []string{"name"},
+				},
+			},
+			Factory: &graphql_datasource.Factory{
+				HTTPClient: testNetHttpClient(t, roundTripperTestCase{
+					expectedHost:     "example.com",
+					expectedPath:     "/",
+					expectedBody:     "",
+					sendResponseBody: `{"data":{"hero":{"name":"Luke Skywalker"}}}`,
+					sendStatusCode:   200,
+				}),
+			},
+			Custom: graphql_datasource.ConfigJson(graphql_datasource.Configuration{
+				Fetch: graphql_datasource.FetchConfiguration{
+					URL:    "https://example.com/",
+					Method: "GET",
+				},
+			}),
+		},
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	engine, err := NewExecutionEngineV2(ctx, abstractlogger.Noop{}, engineConf)
+	require.NoError(t, err)
+
+	pipeline := postprocess.NewResponsePipeline().
+		Register(0, postprocess.ResponsePostProcessorFunc(func(ctx context.Context, response *postprocess.Response) error {
+			response.Extensions = []byte(`{"cost":1}`)
+			return nil
+		}))
+
+	operation := loadStarWarsQuery(starwars.FileSimpleHeroQuery, nil)(t)
+	resultWriter := NewEngineResultWriter()
+	err = engine.Execute(context.Background(), &operation, &resultWriter, WithResponsePipeline(pipeline))
+	require.NoError(t, err)
+	assert.Equal(t, `{"data":{"hero":{"name":"Luke Skywalker"}},"extensions":{"cost":1}}`, resultWriter.String())
+}
+
+func TestExecutionWithDeniedFields(t *testing.T) {
+	schema, err := NewSchemaFromString(`
+		schema { query: Query }
+		type Query { me: User }
+		type User { id: ID! username: String! }`)
+	require.NoError(t, err)
+
+	engineConf := NewEngineV2Configuration(schema)
+	engineConf.SetDataSources([]plan.DataSourceConfiguration{
+		{
+			RootNodes: []plan.TypeField{
+				{
+					TypeName:   "Query",
+					FieldNames: []string{"me"},
+				},
+			},
+			ChildNodes: []plan.TypeField{
+				{
+					TypeName:   "User",
+					FieldNames: []string{"id", "username"},
+				},
+			},
+			Factory: &graphql_datasource.Factory{
+				HTTPClient: testNetHttpClient(t, roundTripperTestCase{
+					expectedHost:     "example.com",
+					expectedPath:     "/",
+					expectedBody:     "",
+					sendResponseBody: `{"data":{"me":{"username":"Me"}}}`,
+					sendStatusCode:   200,
+				}),
+			},
+			Custom: graphql_datasource.ConfigJson(graphql_datasource.Configuration{
+				Fetch: graphql_datasource.FetchConfiguration{
+					URL:    "https://example.com/",
+					Method: "POST",
+				},
+			}),
+		},
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	engine, err := NewExecutionEngineV2(ctx, abstractlogger.Noop{}, engineConf)
+	require.NoError(t, err)
+
+	deniedFields := []Type{{Name: "User", Fields: []string{"id"}}}
+
+	t.Run("denied fields are not fetched", func(t *testing.T) {
+		before := &beforeFetchHook{}
+		operation := Request{Query: `{ me { id username } }`}
+		resultWriter := NewEngineResultWriter()
+		err := engine.Execute(context.Background(), &operation, &resultWriter, WithDeniedFields(deniedFields), WithBeforeFetchHook(before))
+		require.NoError(t, err)
+		assert.Equal(t, `{"method":"POST","url":"https://example.com/","body":{"query":"{me {username}}"}}`, before.input)
+		assert.Equal(t, `{"data":{"me":{"username":"Me"}}}`, resultWriter.String())
+	})
+
+	t.Run("operation without selections after removal", func(t *testing.T) {
+		operation := Request{Query: `{ me { id } }`}
+		resultWriter := NewEngineResultWriter()
+		err := engine.Execute(context.Background(), &operation, &resultWriter, WithDeniedFields(deniedFields))
+		assert.Equal(t, ErrOperationWithoutSelections, err)
+	})
+}
+
+func TestExecutionWithOperationTimeout(t *testing.T) {
+	schema, err := NewSchemaFromString(`
+		directive @timeout(ms: Int!) on QUERY | MUTATION
+
+		schema { query: Query }
+		type Query { fast: String slow: String }
+	`)
+	require.NoError(t, err)
+
+	fastUpstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`"fast"`))
+	}))
+	defer fastUpstream.Close()
+
+	slowUpstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-time.After(100 * time.Millisecond):
+			_, _ = w.Write([]byte(`"slow"`))
+		case <-r.Context().Done():
+		}
+	}))
+	defer slowUpstream.Close()
+
+	dataSource := func(fieldName, url string) plan.DataSourceConfiguration {
+		return plan.DataSourceConfiguration{
+			RootNodes: []plan.TypeField{
+				{TypeName: "Query", FieldNames: []string{fieldName}},
+			},
+			Factory: &rest_datasource.Factory{
+				Client: httpclient.DefaultNetHttpClient,
+			},
+			Custom: rest_datasource.ConfigJSON(rest_datasource.Configuration{
+				Fetch: rest_datasource.FetchConfiguration{
+					URL:    url,
+					Method: "GET",
+				},
+			}),
+		}
+	}
+
+	execute := func(t *testing.T, maxOperationTimeout time.Duration, query string) string {
+		engineConf := NewEngineV2Configuration(schema)
+		engineConf.SetDataSources([]plan.DataSourceConfiguration{
+			dataSource("fast", fastUpstream.URL),
+			dataSource("slow", slowUpstream.URL),
+		})
+		engineConf.SetFieldConfigurations([]plan.FieldConfiguration{
+			{TypeName: "Query", FieldName: "fast", DisableDefaultMapping: true},
+			{TypeName: "Query", FieldName: "slow", DisableDefaultMapping: true},
+		})
+		engineConf.SetMaxOperationTimeout(maxOperationTimeout)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		engine, err := NewExecutionEngineV2(ctx, abstractlogger.Noop{}, engineConf)
+		require.NoError(t, err)
+
+		operation := Request{Query: query}
+		resultWriter := NewEngineResultWriter()
+		err = engine.Execute(context.Background(), &operation, &resultWriter)
+		require.NoError(t, err)
+		return resultWriter.String()
+	}
+
+	t.Run("cuts off slow fetch and returns partial data", func(t *testing.T) {
+		response := execute(t, 0, `query @timeout(ms: 50) { fast slow }`)
+		assert.Equal(t, `{"errors":[{"message":"operation timed out after 50ms"}],"data":{"fast":"fast","slow":null}}`, response)
+	})
+
+	t.Run("timeout is clamped to server max", func(t *testing.T) {
+		response := execute(t, 50*time.Millisecond, `query @timeout(ms: 60000) { fast slow }`)
+		assert.Equal(t, `{"errors":[{"message":"operation timed out after 50ms"}],"data":{"fast":"fast","slow":null}}`, response)
+	})
+
+	t.Run("fetches completing in time", func(t *testing.T) {
+		response := execute(t, 0, `query @timeout(ms: 1000) { fast slow }`)
+		assert.Equal(t, `{"data":{"fast":"fast","slow":"slow"}}`, response)
+	})
+}
+
+func TestExecutionEngineV2_FederationInterfaceEntityKeys(t *testing.T) {
+	accountsUpstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"data":{"me":{"id":"1","history":[{"__typename":"Purchase","id":"p1","amount":1},{"__typename":"Sale","id":"s1","rating":5},{"__typename":"Purchase","id":"p2","amount":2}]}}}`))
+	}))
+	defer accountsUpstream.Close()
+
+	// entitiesUpstream resolves the entities of a single type and fails for representations of any other type
+	entitiesUpstream := func(typeName, fieldName string) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, err := ioutil.ReadAll(r.Body)
+			require.NoError(t, err)
+			var request struct {
+				Variables struct {
+					Representations []struct {
+						TypeName string `json:"__typename"`
+						ID       string `json:"id"`
+					} `json:"representations"`
+				} `json:"variables"`
+			}
+			require.NoError(t, json.Unmarshal(body, &request))
+
+			entities := make([]string, 0, len(request.Variables.Representations))
+			for _, representation := range request.Variables.Representations {
+				if representation.TypeName != typeName {
+					_, _ = fmt.Fprintf(w, `{"errors":[{"message":"unknown type: %s"}]}`, representation.TypeName)
+					return
+				}
+				entities = append(entities, fmt.Sprintf(`{"__typename":"%s","%s":"%s-%s"}`, typeName, fieldName, fieldName, representation.ID))
+			}
+			_, _ = fmt.Fprintf(w, `{"data":{"_entities":[%s]}}`, strings.Join(entities, ","))
+		}))
+	}
+	productsUpstream := entitiesUpstream("Purchase", "product")
+	defer productsUpstream.Close()
+	reviewsUpstream := entitiesUpstream("Sale", "review")
+	defer reviewsUpstream.Close()
+
+	factory := NewFederationEngineConfigFactory([]graphql_datasource.Configuration{
+		{
+			Fetch: graphql_datasource.FetchConfiguration{URL: accountsUpstream.URL},
+			Federation: graphql_datasource.FederationConfiguration{
+				Enabled: true,
+				ServiceSDL: `
+					extend type Query { me: User }
+					type User @key(fields: "id") { id: ID! history: [History!]! }
+					interface History @key(fields: "id") { id: ID! }
+					type Purchase implements History { id: ID! amount: Int! }
+					type Sale implements History { id: ID! rating: Int! }
+				`,
+			},
+		},
+		{
+			Fetch: graphql_datasource.FetchConfiguration{URL: productsUpstream.URL},
+			Federation: graphql_datasource.FederationConfiguration{
+				Enabled: true,
+				ServiceSDL: `
+					extend interface History @key(fields: "id") { id: ID! @external }
+					extend type Purchase implements History { id: ID! @external product: String! }
+				`,
+			},
+		},
+		{
+			Fetch: graphql_datasource.FetchConfiguration{URL: reviewsUpstream.URL},
+			Federation: graphql_datasource.FederationConfiguration{
+				Enabled: true,
+				ServiceSDL: `
+					extend interface History @key(fields: "id") { id: ID! @external }
+					extend type Sale implements History { id: ID! @external review: String! }
+				`,
+			},
+		},
+	}, graphql_datasource.NewBatchFactory())
+	engineConf, err := factory.EngineV2Configuration()
+	require.NoError(t, err)
+
+	execute := func(t *testing.T, enableDataLoader bool, query string) string {
+		engineConf.EnableDataLoader(enableDataLoader)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		engine, err := NewExecutionEngineV2(ctx, abstractlogger.Noop{}, engineConf)
+		require.NoError(t, err)
+
+		operation := Request{Query: query}
+		resultWriter := NewEngineResultWriter()
+		err = engine.Execute(context.Background(), &operation, &resultWriter)
+		require.NoError(t, err)
+		return resultWriter.String()
+	}
+
+	for _, enableDataLoader := range []bool{false, true} {
+		t.Run(fmt.Sprintf("data loader enabled: %v", enableDataLoader), func(t *testing.T) {
+			t.Run("interface fields of a single subgraph", func(t *testing.T) {
+				response := execute(t, enableDataLoader, `{ me { history { id ... on Purchase { amount } } } }`)
+				assert.Equal(t, `{"data":{"me":{"history":[{"id":"p1","amount":1},{"id":"s1"},{"id":"p2","amount":2}]}}}`, response)
+			})
+
+			t.Run("entities of a list resolved by the subgraph of their type", func(t *testing.T) {
+				response := execute(t, enableDataLoader, `{ me { history { id ... on Purchase { amount product } ... on Sale { rating review } } } }`)
+				assert.Equal(t, `{"data":{"me":{"history":[{"id":"p1","amount":1,"product":"product-p1"},{"id":"s1","rating":5,"review":"review-s1"},{"id":"p2","amount":2,"product":"product-p2"}]}}}`, response)
+			})
+		})
+	}
+}
+
+func TestExecutionEngineV2_ExecutionLogging(t *testing.T) {
+	accountsUpstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"data":{"me":{"id":"1234","username":"Me"}}}`))
+	}))
+	defer accountsUpstream.Close()
+	reviewsUpstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"data":{"_entities":[{"__typename":"User","reviews":[{"body":"A highly effective form of birth control."}]}]}}`))
+	}))
+	defer reviewsUpstream.Close()
+
+	factory := NewFederationEngineConfigFactory([]graphql_datasource.Configuration{
+		{
+			Fetch: graphql_datasource.FetchConfiguration{
+				URL:    accountsUpstream.URL,
+				Header: http.Header{"Authorization": []string{"Bearer secret"}},
+			},
+			Federation: graphql_datasource.FederationConfiguration{
+				Enabled:    true,
+				ServiceSDL: `extend type Query { me: User } type User @key(fields: "id") { id: ID! username: String! }`,
+			},
+		},
+		{
+			Fetch: graphql_datasource.FetchConfiguration{
+				URL:    reviewsUpstream.URL,
+				Header: http.Header{"Authorization": []string{"Bearer secret"}},
+			},
+			Federation: graphql_datasource.FederationConfiguration{
+				Enabled:    true,
+				ServiceSDL: `type Review { body: String! } extend type User @key(fields: "id") { id: ID! @external reviews: [Review] }`,
+			},
+		},
+	}, graphql_datasource.NewBatchFactory())
+	engineConf, err := factory.EngineV2Configuration()
+	require.NoError(t, err)
+	engineConf.EnableExecutionLogging(16)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	core, logs := observer.New(zap.DebugLevel)
+	engine, err := NewExecutionEngineV2(ctx, abstractlogger.NewZapLogger(zap.New(core), abstractlogger.DebugLevel), engineConf)
+	require.NoError(t, err)
+
+	operation := Request{OperationName: "MyReviews", Query: `query MyReviews { me { username reviews { body } } }`}
+	resultWriter := NewEngineResultWriter()
+	err = engine.Execute(context.Background(), &operation, &resultWriter)
+	require.NoError(t, err)
+	assert.Equal(t, `{"data":{"me":{"username":"Me","reviews":[{"body":"A highly effective form of birth control."}]}}}`, resultWriter.String())
+
+	messages := make([]string, 0, logs.Len())
+	for _, entry := range logs.All() {
+		messages = append(messages, entry.Message)
+	}
+	assert.Equal(t, []string{
+		"parsed operation",
+		"normalized operation",
+		"planned operation",
+		"subgraph request",
+		"subgraph response",
+		"subgraph request",
+		"subgraph response",
+		"resolved response",
+	}, messages)
+
+	for _, entry := range logs.All() {
+		fields := entry.ContextMap()
+		assert.NotContains(t, fields, "error", entry.Message)
+		switch entry.Message {
+		case "subgraph request":
+			assert.Contains(t, []interface{}{accountsUpstream.URL, reviewsUpstream.URL}, fields["service"])
+			assert.Contains(t, fields["header"], `"Authorization":["[REDACTED]"]`)
+			assert.NotContains(t, fields["header"], "secret")
+			assert.LessOrEqual(t, len(fields["body"].(string)), 16)
+		case "subgraph response":
+			assert.Contains(t, fields, "durationMs")
+			assert.LessOrEqual(t, len(fields["body"].(string)), 16)
+		case "planned operation":
+			assert.Equal(t, "MyReviews", fields["operationName"])
+			assert.Equal(t, int64(2), fields["fetches"])
+		case "resolved response":
+			assert.Equal(t, "MyReviews", fields["operationName"])
+			assert.Equal(t, int64(len(resultWriter.String())), fields["bodyBytes"])
+		}
+	}
+}
+
+func TestExecutionEngineV2_PlanCache(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"data":{"me":{"id":"1234","username":"Me"}}}`))
+	}))
+	defer upstream.Close()
+
+	factory := NewFederationEngineConfigFactory([]graphql_datasource.Configuration{
+		{
+			Fetch: graphql_datasource.FetchConfiguration{
+				URL: upstream.URL,
+			},
+			Federation: graphql_datasource.FederationConfiguration{
+				Enabled:    true,
+				ServiceSDL: `extend type Query { me: User } type User @key(fields: "id") { id: ID! username: String! }`,
+			},
+		},
+	}, graphql_datasource.NewBatchFactory())
+	engineConf, err := factory.EngineV2Configuration()
+	require.NoError(t, err)
+	engineConf.SetPlanCacheSize(2)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	execute := func(t *testing.T, engine *ExecutionEngineV2, query string) string {
+		operation := Request{Query: query}
+		resultWriter := NewEngineResultWriter()
+		require.NoError(t, engine.Execute(context.Background(), &operation, &resultWriter))
+		return resultWriter.String()
+	}
+
+	t.Run("identical operations are planned once", func(t *testing.T) {
+		engine, err := NewExecutionEngineV2(ctx, abstractlogger.NoopLogger, engineConf)
+		require.NoError(t, err)
+
+		assert.Equal(t, `{"data":{"me":{"username":"Me"}}}`, execute(t, engine, `{ me { username } }`))
+		assert.Equal(t, uint64(1), atomic.LoadUint64(&engine.planCount))
+
+		assert.Equal(t, `{"data":{"me":{"username":"Me"}}}`, execute(t, engine, `{ me { username } }`))
+		assert.Equal(t, uint64(1), atomic.LoadUint64(&engine.planCount))
+
+		assert.Equal(t, `{"data":{"me":{"id":"1234","username":"Me"}}}`, execute(t, engine, `{ me { id username } }`))
+		assert.Equal(t, uint64(2), atomic.LoadUint64(&engine.planCount))
+	})
+
+	t.Run("concurrent identical operations are planned once", func(t *testing.T) {
+		engine, err := NewExecutionEngineV2(ctx, abstractlogger.NoopLogger, engineConf)
+		require.NoError(t, err)
+
+		wg := sync.WaitGroup{}
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				operation := Request{Query: `{ me { username } }`}
+				resultWriter := NewEngineResultWriter()
+				assert.NoError(t, engine.Execute(context.Background(), &operation, &resultWriter))
+				assert.Equal(t, `{"data":{"me":{"username":"Me"}}}`, resultWriter.String())
+			}()
+		}
+		wg.Wait()
+		assert.Equal(t, uint64(1), atomic.LoadUint64(&engine.planCount))
+	})
+
+	t.Run("least recently used plan is evicted", func(t *testing.T) {
+		engine, err := NewExecutionEngineV2(ctx, abstractlogger.NoopLogger, engineConf)
+		require.NoError(t, err)
+
+		execute(t, engine, `{ me { username } }`)
+		execute(t, engine, `{ me { id } }`)
+		execute(t, engine, `{ me { id username } }`)
+		assert.Equal(t, 2, engine.executionPlanCache.Len())
+		assert.Equal(t, uint64(3), atomic.LoadUint64(&engine.planCount))
+
+		execute(t, engine, `{ me { id username } }`)
+		assert.Equal(t, uint64(3), atomic.LoadUint64(&engine.planCount))
+		execute(t, engine, `{ me { username } }`)
+		assert.Equal(t, uint64(4), atomic.LoadUint64(&engine.planCount))
+	})
+
+	t.Run("warm up", func(t *testing.T) {
+		engine, err := NewExecutionEngineV2(ctx, abstractlogger.NoopLogger, engineConf)
+		require.NoError(t, err)
+
+		errs := engine.WarmUp(context.Background(), [][]byte{
+			[]byte(`{"query":"query Me { me { username } }","operationName":"Me"}`),
+			[]byte(`{"query":"query Email { me { email } }","operationName":"Email"}`),
+		})
+		require.Len(t, errs, 1)
+		assert.Equal(t, 1, errs[0].Index)
+		assert.Equal(t, "Email", errs[0].OperationName)
+		assert.Contains(t, errs[0].Error(), "email")
+		assert.Equal(t, 1, engine.executionPlanCache.Len())
+		assert.Equal(t, uint64(1), atomic.LoadUint64(&engine.planCount))
+
+		operation := Request{Query: `query Me { me { username } }`, OperationName: "Me"}
+		resultWriter := NewEngineResultWriter()
+		require.NoError(t, engine.Execute(context.Background(), &operation, &resultWriter))
+		assert.Equal(t, `{"data":{"me":{"username":"Me"}}}`, resultWriter.String())
+		assert.Equal(t, uint64(1), atomic.LoadUint64(&engine.planCount))
+	})
+
+	t.Run("reloaded engine plans again", func(t *testing.T) {
+		engine, err := NewExecutionEngineV2(ctx, abstractlogger.NoopLogger, engineConf)
+		require.NoError(t, err)
+		execute(t, engine, `{ me { username } }`)
+
+		reloadedEngineConf, err := factory.EngineV2Configuration()
+		require.NoError(t, err)
+		reloadedEngine, err := NewExecutionEngineV2(ctx, abstractlogger.NoopLogger, reloadedEngineConf)
+		require.NoError(t, err)
+		assert.Equal(t, `{"data":{"me":{"username":"Me"}}}`, execute(t, reloadedEngine, `{ me { username } }`))
+		assert.Equal(t, uint64(1), atomic.LoadUint64(&reloadedEngine.planCount))
+	})
+}
+
+func TestExecutionEngineV2_MaxResponseBytes(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"data":{"me":{"id":"1234","username":"` + strings.Repeat("a", 1024*1024) + `"}}}`))
+	}))
+	defer upstream.Close()
+
+	factory := NewFederationEngineConfigFactory([]graphql_datasource.Configuration{
+		{
+			Fetch: graphql_datasource.FetchConfiguration{
+				URL:              upstream.URL,
+				MaxResponseBytes: 1024,
+			},
+			Federation: graphql_datasource.FederationConfiguration{
+				Enabled:    true,
+				ServiceSDL: `extend type Query { me: User } type User @key(fields: "id") { id: ID! username: String! }`,
+			},
+		},
+	}, graphql_datasource.NewBatchFactory())
+	engineConf, err := factory.EngineV2Configuration()
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	engine, err := NewExecutionEngineV2(ctx, abstractlogger.NoopLogger, engineConf)
+	require.NoError(t, err)
+
+	operation := Request{Query: `{ me { username } }`}
+	resultWriter := NewEngineResultWriter()
+	err = engine.Execute(context.Background(), &operation, &resultWriter)
+	assert.ErrorIs(t, err, httpclient.ErrResponseTooLarge)
+	assert.Equal(t, "", resultWriter.String())
+}
+
 func TestExecutionEngineV2_GetCachedPlan(t *testing.T) {
 	schema, err := NewSchemaFromString(testSubscriptionDefinition)
 	require.NoError(t, err)
@@ -1886,6 +2566,25 @@ func TestExecutionEngineV2_GetCachedPlan(t *testing.T) {
 		assert.Equal(t, 2, engine.executionPlanCache.Len())
 		assert.NotEqual(t, cachedPlan, oldestCachedPlan.(*plan.SubscriptionResponsePlan))
 	})
+
+	t.Run("should plan the operations of the same document separately", func(t *testing.T) {
+		t.Cleanup(engine.executionPlanCache.Purge)
+		require.Equal(t, 0, engine.executionPlanCache.Len())
+
+		multipleOperationsRequest := Request{
+			Query: testSubscriptionLastRegisteredUserOperation + testSubscriptionLiveUserCountOperation,
+		}
+		normalizationResult, err := multipleOperationsRequest.Normalize(schema)
+		require.NoError(t, err)
+		require.True(t, normalizationResult.Successful)
+
+		report := operationreport.Report{}
+		lastRegisteredUserPlan := engine.getCachedPlan(newInternalExecutionContext(), &multipleOperationsRequest.document, &schema.document, "LastRegisteredUser", &report)
+		liveUserCountPlan := engine.getCachedPlan(newInternalExecutionContext(), &multipleOperationsRequest.document, &schema.document, "LiveUserCount", &report)
+		assert.False(t, report.HasErrors())
+		assert.Equal(t, 2, engine.executionPlanCache.Len())
+		assert.NotEqual(t, lastRegisteredUserPlan, liveUserCountPlan)
+	})
 }
 
 func BenchmarkExecutionEngineV2(b *testing.B) {
@@ -0,0 +1,115 @@
+package graphql
+
+import (
+	"time"
+
+	"github.com/jensneuse/abstractlogger"
+
+	"github.com/wundergraph/graphql-go-tools/pkg/astprinter"
+	"github.com/wundergraph/graphql-go-tools/pkg/engine/plan"
+	"github.com/wundergraph/graphql-go-tools/pkg/engine/resolve"
+)
+
+type executionLoggingConfig struct {
+	maxBodyBytes    int
+	redactedHeaders []string
+}
+
+// EnableExecutionLogging logs the stages of each execution at debug level with the logger of the engine:
+// the parsed and the normalized operation, a summary of the plan, each subgraph request and response
+// and the resolved response. Bodies of subgraph requests and responses are truncated to maxBodyBytes,
+// see resolve.NewFetchLogger, the values of the redacted headers and of the Authorization header are never logged.
+func (e *EngineV2Configuration) EnableExecutionLogging(maxBodyBytes int, redactedHeaders ...string) {
+	e.executionLogging = &executionLoggingConfig{
+		maxBodyBytes:    maxBodyBytes,
+		redactedHeaders: redactedHeaders,
+	}
+}
+
+// executionLogger logs the stages of executions, all entries carry the name of the operation
+type executionLogger struct {
+	logger      abstractlogger.Logger
+	fetchLogger *resolve.FetchLogger
+}
+
+func newExecutionLogger(logger abstractlogger.Logger, config *executionLoggingConfig) *executionLogger {
+	if config == nil {
+		return nil
+	}
+	return &executionLogger{
+		logger:      logger,
+		fetchLogger: resolve.NewFetchLogger(logger, config.maxBodyBytes, config.redactedHeaders...),
+	}
+}
+
+// parseOperation parses the operation ahead of its preparation to log the parse result on its own
+func (l *executionLogger) parseOperation(operation *Request) {
+	start := time.Now()
+	report := operation.parseQueryOnce()
+	duration := time.Since(start)
+	fields := []abstractlogger.Field{
+		abstractlogger.String("operationName", operation.OperationName),
+		abstractlogger.Int("durationMs", int(duration.Milliseconds())),
+		abstractlogger.Bool("valid", !report.HasErrors()),
+	}
+	if report.HasErrors() {
+		fields = append(fields, abstractlogger.Error(report))
+	}
+	l.logger.Debug("parsed operation", fields...)
+}
+
+func (l *executionLogger) logNormalizedOperation(operation *Request, duration time.Duration, err error) {
+	fields := []abstractlogger.Field{
+		abstractlogger.String("operationName", operation.OperationName),
+		abstractlogger.Int("durationMs", int(duration.Milliseconds())),
+	}
+	if err != nil {
+		fields = append(fields, abstractlogger.Error(err))
+	} else if normalized, printErr := astprinter.PrintString(&operation.document, nil); printErr == nil {
+		fields = append(fields, abstractlogger.String("query", normalized))
+	}
+	l.logger.Debug("normalized operation", fields...)
+}
+
+func (l *executionLogger) logPlan(operation *Request, p plan.Plan, duration time.Duration) {
+	explained := plan.Explain(p)
+	services := make([]string, 0, len(explained.Fetches))
+	for _, fetch := range explained.Fetches {
+		service := fetch.URL
+		if service == "" {
+			service = fetch.DataSource
+		}
+		services = append(services, service)
+	}
+	l.logger.Debug("planned operation",
+		abstractlogger.String("operationName", operation.OperationName),
+		abstractlogger.Int("durationMs", int(duration.Milliseconds())),
+		abstractlogger.String("kind", explained.Kind),
+		abstractlogger.Int("fetches", len(explained.Fetches)),
+		abstractlogger.Strings("services", services),
+	)
+}
+
+func (l *executionLogger) logResolvedResponse(operation *Request, duration time.Duration, responseBytes int, err error) {
+	fields := []abstractlogger.Field{
+		abstractlogger.String("operationName", operation.OperationName),
+		abstractlogger.Int("durationMs", int(duration.Milliseconds())),
+		abstractlogger.Int("bodyBytes", responseBytes),
+	}
+	if err != nil {
+		fields = append(fields, abstractlogger.Error(err))
+	}
+	l.logger.Debug("resolved response", fields...)
+}
+
+// countingFlushWriter counts the bytes of the response written by the resolver
+type countingFlushWriter struct {
+	resolve.FlushWriter
+	written int
+}
+
+func (w *countingFlushWriter) Write(p []byte) (n int, err error) {
+	n, err = w.FlushWriter.Write(p)
+	w.written += n
+	return n, err
+}
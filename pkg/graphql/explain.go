@@ -0,0 +1,32 @@
+package graphql
+
+import (
+	"context"
+
+	"github.com/wundergraph/graphql-go-tools/pkg/engine/plan"
+	"github.com/wundergraph/graphql-go-tools/pkg/operationreport"
+)
+
+// Explain plans the operation like Execute but returns a description of the planned fetches instead of executing them.
+func (e *ExecutionEngineV2) Explain(ctx context.Context, operation *Request, options ...ExecutionOptionsV2) (*plan.ExplainedPlan, error) {
+	if err := e.prepareOperation(operation); err != nil {
+		return nil, err
+	}
+
+	execContext := e.getExecutionCtx()
+	defer e.putExecutionCtx(execContext)
+
+	execContext.prepare(ctx, operation.Variables, operation.request)
+
+	for i := range options {
+		options[i](execContext)
+	}
+
+	var report operationreport.Report
+	cachedPlan := e.getCachedPlan(execContext, &operation.document, &e.config.schema.document, operation.OperationName, &report)
+	if report.HasErrors() {
+		return nil, report
+	}
+
+	return plan.Explain(cachedPlan), nil
+}
@@ -0,0 +1,86 @@
+package graphql
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/jensneuse/abstractlogger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/wundergraph/graphql-go-tools/pkg/engine/datasource/graphql_datasource"
+)
+
+func TestExecutionEngineV2_FieldOrder(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// the fields are intentionally returned in an order different from the query
+		_, _ = w.Write([]byte(`{"data":{"node":{"__typename":"User","name":"Name","username":"Username","id":"1"}}}`))
+	}))
+	defer upstream.Close()
+
+	factory := NewFederationEngineConfigFactory([]graphql_datasource.Configuration{
+		{
+			Fetch: graphql_datasource.FetchConfiguration{
+				URL: upstream.URL,
+			},
+			Federation: graphql_datasource.FederationConfiguration{
+				Enabled:    true,
+				ServiceSDL: `extend type Query { node: Node } interface Node { id: ID! } type User implements Node @key(fields: "id") { id: ID! username: String! name: String }`,
+			},
+		},
+	}, graphql_datasource.NewBatchFactory())
+	engineConf, err := factory.EngineV2Configuration()
+	require.NoError(t, err)
+
+	engineCtx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	engine, err := NewExecutionEngineV2(engineCtx, abstractlogger.NoopLogger, engineConf)
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name             string
+		query            string
+		expectedResponse string
+	}{
+		{
+			name:             "fields",
+			query:            `{ node { id ... on User { username name } } }`,
+			expectedResponse: `{"data":{"node":{"id":"1","username":"Username","name":"Name"}}}`,
+		},
+		{
+			name:             "reordered fields",
+			query:            `{ node { ... on User { name username } id } }`,
+			expectedResponse: `{"data":{"node":{"name":"Name","username":"Username","id":"1"}}}`,
+		},
+		{
+			name:             "fragment spread",
+			query:            `{ node { ...UserFields id } } fragment UserFields on User { username name }`,
+			expectedResponse: `{"data":{"node":{"username":"Username","name":"Name","id":"1"}}}`,
+		},
+		{
+			name:             "field selected outside and inside of fragment",
+			query:            `{ node { ... on User { username id } id } }`,
+			expectedResponse: `{"data":{"node":{"username":"Username","id":"1"}}}`,
+		},
+		{
+			name:             "field selected inside and outside of fragment",
+			query:            `{ node { id ... on User { username id } } }`,
+			expectedResponse: `{"data":{"node":{"id":"1","username":"Username"}}}`,
+		},
+	}
+
+	for _, testCase := range testCases {
+		testCase := testCase
+		t.Run(testCase.name, func(t *testing.T) {
+			for i := 0; i < 10; i++ {
+				operation := Request{Query: testCase.query}
+				resultWriter := NewEngineResultWriter()
+				require.NoError(t, engine.Execute(context.Background(), &operation, &resultWriter))
+				assert.Equal(t, testCase.expectedResponse, resultWriter.String())
+			}
+		})
+	}
+}
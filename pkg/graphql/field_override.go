@@ -0,0 +1,29 @@
+package graphql
+
+import (
+	"math/rand"
+	"strconv"
+	"strings"
+)
+
+// enabledOverrideLabels selects the labels of the field overrides enabled for an operation, see plan.FieldOverride.
+// Labels of the form "percent(x)" are enabled for x percent of the operations, all other labels are disabled.
+func enabledOverrideLabels(labels []string) map[string]bool {
+	enabled := make(map[string]bool, len(labels))
+	for _, label := range labels {
+		percent, ok := overrideLabelPercent(label)
+		enabled[label] = ok && rand.Float64()*100 < percent
+	}
+	return enabled
+}
+
+func overrideLabelPercent(label string) (float64, bool) {
+	if !strings.HasPrefix(label, "percent(") || !strings.HasSuffix(label, ")") {
+		return 0, false
+	}
+	percent, err := strconv.ParseFloat(label[len("percent("):len(label)-1], 64)
+	if err != nil || percent < 0 || percent > 100 {
+		return 0, false
+	}
+	return percent, true
+}
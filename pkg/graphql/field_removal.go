@@ -0,0 +1,144 @@
+package graphql
+
+import (
+	"errors"
+
+	"github.com/wundergraph/graphql-go-tools/pkg/ast"
+	"github.com/wundergraph/graphql-go-tools/pkg/astvisitor"
+	"github.com/wundergraph/graphql-go-tools/pkg/operationreport"
+)
+
+var ErrOperationWithoutSelections = errors.New("operation has no selections left after removing the denied fields")
+
+// RemoveFields removes the selections of the denied fields from the operation, e.g. to hide fields of the schema
+// from some clients without rejecting their requests. Fields selected on an interface are removed if the field is
+// denied on any of the types implementing the interface.
+// Fields and inline fragments left with an empty selection set are removed as well.
+// Only the operation to execute is changed, ErrOperationWithoutSelections is returned if it has no selections left.
+func (r *Request) RemoveFields(schema *Schema, deniedFields []Type) error {
+	if schema == nil {
+		return ErrNilSchema
+	}
+
+	if !r.IsNormalized() {
+		result, err := r.Normalize(schema)
+		if err != nil {
+			return err
+		}
+
+		if !result.Successful {
+			return result.Errors
+		}
+	}
+
+	denied := make(map[string]map[string]struct{}, len(deniedFields))
+	for _, deniedType := range deniedFields {
+		fields, ok := denied[deniedType.Name]
+		if !ok {
+			fields = make(map[string]struct{}, len(deniedType.Fields))
+			denied[deniedType.Name] = fields
+		}
+		for _, field := range deniedType.Fields {
+			fields[field] = struct{}{}
+		}
+	}
+
+	walker := astvisitor.NewWalker(48)
+	visitor := &fieldRemovalVisitor{
+		Walker:        &walker,
+		operation:     &r.document,
+		definition:    &schema.document,
+		denied:        denied,
+		removedFields: make(map[int]struct{}),
+	}
+	walker.RegisterEnterFieldVisitor(visitor)
+
+	report := operationreport.Report{}
+	walker.Walk(&r.document, &schema.document, &report)
+	if report.HasErrors() {
+		return report
+	}
+	if len(visitor.removedFields) == 0 {
+		return nil
+	}
+
+	operationRef, ok := r.document.OperationDefinitionRefByName(r.OperationName)
+	if !ok {
+		return nil
+	}
+	if !visitor.removeSelections(r.document.OperationDefinitions[operationRef].SelectionSet) {
+		return ErrOperationWithoutSelections
+	}
+
+	return nil
+}
+
+type fieldRemovalVisitor struct {
+	*astvisitor.Walker
+	operation, definition *ast.Document
+	denied                map[string]map[string]struct{}
+	removedFields         map[int]struct{}
+}
+
+func (f *fieldRemovalVisitor) EnterField(ref int) {
+	fieldName := f.operation.FieldNameString(ref)
+	if f.isDenied(f.EnclosingTypeDefinition, fieldName) {
+		f.removedFields[ref] = struct{}{}
+		f.SkipNode()
+	}
+}
+
+func (f *fieldRemovalVisitor) isDenied(typeNode ast.Node, fieldName string) bool {
+	if fields, ok := f.denied[f.definition.NodeNameString(typeNode)]; ok {
+		if _, ok := fields[fieldName]; ok {
+			return true
+		}
+	}
+
+	if typeNode.Kind != ast.NodeKindInterfaceTypeDefinition {
+		return false
+	}
+	for _, implementingNode := range f.definition.InterfaceTypeDefinitionImplementedByRootNodes(typeNode.Ref) {
+		if implementingNode.Kind != ast.NodeKindObjectTypeDefinition {
+			continue
+		}
+		if f.isDenied(implementingNode, fieldName) {
+			return true
+		}
+	}
+	return false
+}
+
+// removeSelections removes the denied fields and the selections left empty from the selection set,
+// it returns false if the selection set has no selections left
+func (f *fieldRemovalVisitor) removeSelections(selectionSet int) bool {
+	selectionRefs := f.operation.SelectionSets[selectionSet].SelectionRefs
+	remaining := selectionRefs[:0]
+	for _, selectionRef := range selectionRefs {
+		selection := f.operation.Selections[selectionRef]
+		switch selection.Kind {
+		case ast.SelectionKindField:
+			if _, ok := f.removedFields[selection.Ref]; ok {
+				continue
+			}
+			field := f.operation.Fields[selection.Ref]
+			if field.HasSelections && !f.removeSelections(field.SelectionSet) {
+				continue
+			}
+		case ast.SelectionKindInlineFragment:
+			inlineFragment := f.operation.InlineFragments[selection.Ref]
+			if inlineFragment.HasSelections && !f.removeSelections(inlineFragment.SelectionSet) {
+				continue
+			}
+		case ast.SelectionKindFragmentSpread:
+			// fragments are only left in operations normalized with lazy fragment expansion
+			fragmentDefinitionRef, ok := f.operation.FragmentDefinitionRef(f.operation.FragmentSpreadNameBytes(selection.Ref))
+			if ok && !f.removeSelections(f.operation.FragmentDefinitions[fragmentDefinitionRef].SelectionSet) {
+				continue
+			}
+		}
+		remaining = append(remaining, selectionRef)
+	}
+	f.operation.SelectionSets[selectionSet].SelectionRefs = remaining
+	return len(remaining) > 0
+}
@@ -0,0 +1,91 @@
+package graphql
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/wundergraph/graphql-go-tools/pkg/astprinter"
+)
+
+func TestRequest_RemoveFields(t *testing.T) {
+	schema, err := NewSchemaFromString(`
+		schema { query: Query }
+		type Query {
+			me: User
+			node(id: ID!): Node
+		}
+		interface Node { id: ID! }
+		type User implements Node {
+			id: ID!
+			username: String!
+			email: String
+			friends: [User]
+		}
+		type Product implements Node {
+			id: ID!
+			name: String!
+		}`)
+	require.NoError(t, err)
+
+	run := func(query, operationName string, deniedFields []Type, expectedOperation string) func(t *testing.T) {
+		return func(t *testing.T) {
+			request := Request{Query: query, OperationName: operationName}
+			require.NoError(t, request.RemoveFields(schema, deniedFields))
+
+			printed, err := astprinter.PrintString(&request.document, nil)
+			require.NoError(t, err)
+			assert.Equal(t, expectedOperation, printed)
+		}
+	}
+
+	runWithError := func(query, operationName string, deniedFields []Type) func(t *testing.T) {
+		return func(t *testing.T) {
+			request := Request{Query: query, OperationName: operationName}
+			assert.Equal(t, ErrOperationWithoutSelections, request.RemoveFields(schema, deniedFields))
+		}
+	}
+
+	deniedUserID := []Type{{Name: "User", Fields: []string{"id"}}}
+
+	t.Run("removes denied fields", run(
+		`{ me { id username } }`, "", deniedUserID,
+		`{me {username}}`,
+	))
+	t.Run("removes denied fields of fragments", run(
+		`{ me { ...UserFields } } fragment UserFields on User { id username }`, "", deniedUserID,
+		`{me {username}}`,
+	))
+	t.Run("removes fields left without selections", run(
+		`{ me { username friends { id } } }`, "", deniedUserID,
+		`{me {username}}`,
+	))
+	t.Run("removes inline fragments left without selections", run(
+		`{ node(id: "1") { ... on User { email } ... on Product { name } } }`, "", []Type{{Name: "User", Fields: []string{"email"}}},
+		`query($a: ID!){node(id: $a){... on Product {name}}}`,
+	))
+	t.Run("removes fields of interfaces denied on an implementing type", run(
+		`{ node(id: "1") { id ... on Product { name } } }`, "", deniedUserID,
+		`query($a: ID!){node(id: $a){... on Product {name}}}`,
+	))
+	t.Run("keeps fields which are not denied", run(
+		`{ me { id username } }`, "", []Type{{Name: "Product", Fields: []string{"id"}}},
+		`{me {id username}}`,
+	))
+	t.Run("errors if the operation has no selections left", runWithError(
+		`{ me { id } }`, "", deniedUserID,
+	))
+	t.Run("errors if the executed operation has no selections left", runWithError(
+		`query A { me { username } } query B { me { id } }`, "B", deniedUserID,
+	))
+	t.Run("ignores operations which are not executed", run(
+		`query A { me { username } } query B { me { id } }`, "A", deniedUserID,
+		`query A {me {username}} query B {me {id}}`,
+	))
+
+	t.Run("should return error when schema is nil", func(t *testing.T) {
+		request := Request{Query: `{ me { id } }`}
+		assert.Equal(t, ErrNilSchema, request.RemoveFields(nil, deniedUserID))
+	})
+}
@@ -0,0 +1,135 @@
+package graphql
+
+import (
+	"encoding/hex"
+	"io"
+
+	"github.com/wundergraph/graphql-go-tools/pkg/ast"
+	"github.com/wundergraph/graphql-go-tools/pkg/astparser"
+	"github.com/wundergraph/graphql-go-tools/pkg/astprinter"
+	"github.com/wundergraph/graphql-go-tools/pkg/pool"
+)
+
+type fingerprintOptions struct {
+	includeDirectives bool
+}
+
+type FingerprintOption func(options *fingerprintOptions)
+
+// WithFingerprintDirectives includes all directives used within the operation and their arguments into the fingerprint,
+// not only the ones changing the selected fields like @skip and @include. Constant arguments are hashed by value, arguments bound to variables are hashed by the variable reference,
+// so the fingerprint doesn't change with the variables of a request.
+func WithFingerprintDirectives() FingerprintOption {
+	return func(options *fingerprintOptions) {
+		options.includeDirectives = true
+	}
+}
+
+// Fingerprint returns a deterministic hash of the operation which is independent of formatting.
+// By default only @skip, @include, @defer and @stream are part of the fingerprint as they change the response,
+// other directives are ignored, e.g. operations differing only in a tracing directive share a fingerprint.
+func (r *Request) Fingerprint(options ...FingerprintOption) (uint64, error) {
+	hash := pool.Hash64.Get()
+	hash.Reset()
+	defer pool.Hash64.Put(hash)
+	if err := r.printFingerprint(hash, options...); err != nil {
+		return 0, err
+	}
+	return hash.Sum64(), nil
+}
+
+// FingerprintKey returns the fingerprint of the operation as the hex encoded hash of the hasher, see Fingerprint
+func (r *Request) FingerprintKey(hasher OperationHasher, options ...FingerprintOption) (string, error) {
+	h := hasher.New()
+	if err := r.printFingerprint(h, options...); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// printFingerprint prints the operation the fingerprint is calculated from
+func (r *Request) printFingerprint(out io.Writer, options ...FingerprintOption) error {
+	opts := &fingerprintOptions{}
+	for _, option := range options {
+		option(opts)
+	}
+
+	// the request document might get normalized, so the fingerprint is always calculated from the raw query
+	document, report := astparser.ParseGraphqlDocumentString(r.Query)
+	if report.HasErrors() {
+		return report
+	}
+
+	if !opts.includeDirectives {
+		removeNonSemanticDirectives(&document)
+	}
+
+	return astprinter.Print(&document, nil, out)
+}
+
+// OperationHash returns a hash of the normalized operation, its name and variables.
+// Other than the fingerprint it depends on the variables, identical operations have the same hash regardless of
+// formatting, fragments and whether arguments are inlined or passed as variables, e.g. to coalesce identical operations.
+// The request must be normalized.
+func (r *Request) OperationHash() (uint64, error) {
+	if !r.isNormalized {
+		return 0, ErrRequestNotNormalized
+	}
+
+	hash := pool.Hash64.Get()
+	hash.Reset()
+	defer pool.Hash64.Put(hash)
+	if err := astprinter.Print(&r.document, nil, hash); err != nil {
+		return 0, err
+	}
+	_, _ = hash.Write([]byte(r.OperationName))
+	_, _ = hash.Write(r.Variables)
+	return hash.Sum64(), nil
+}
+
+// semanticDirectives change the selected fields or how the response is delivered,
+// so they are part of the fingerprint even if directives are not included
+var semanticDirectives = map[string]struct{}{
+	"skip":    {},
+	"include": {},
+	"defer":   {},
+	"stream":  {},
+}
+
+// removeNonSemanticDirectives removes all directives but the semanticDirectives from the operations of the document
+func removeNonSemanticDirectives(document *ast.Document) {
+	semanticOnly := func(refs []int) []int {
+		kept := refs[:0]
+		for _, ref := range refs {
+			if _, ok := semanticDirectives[document.DirectiveNameString(ref)]; ok {
+				kept = append(kept, ref)
+			}
+		}
+		return kept
+	}
+
+	for i := range document.OperationDefinitions {
+		document.OperationDefinitions[i].Directives.Refs = semanticOnly(document.OperationDefinitions[i].Directives.Refs)
+		document.OperationDefinitions[i].HasDirectives = len(document.OperationDefinitions[i].Directives.Refs) != 0
+	}
+	for i := range document.VariableDefinitions {
+		document.VariableDefinitions[i].Directives.Refs = semanticOnly(document.VariableDefinitions[i].Directives.Refs)
+		document.VariableDefinitions[i].HasDirectives = len(document.VariableDefinitions[i].Directives.Refs) != 0
+	}
+	for i := range document.Fields {
+		document.Fields[i].Directives.Refs = semanticOnly(document.Fields[i].Directives.Refs)
+		document.Fields[i].HasDirectives = len(document.Fields[i].Directives.Refs) != 0
+	}
+	for i := range document.InlineFragments {
+		document.InlineFragments[i].Directives.Refs = semanticOnly(document.InlineFragments[i].Directives.Refs)
+		document.InlineFragments[i].HasDirectives = len(document.InlineFragments[i].Directives.Refs) != 0
+	}
+	for i := range document.FragmentSpreads {
+		document.FragmentSpreads[i].Directives.Refs = semanticOnly(document.FragmentSpreads[i].Directives.Refs)
+		document.FragmentSpreads[i].HasDirectives = len(document.FragmentSpreads[i].Directives.Refs) != 0
+	}
+	for i := range document.FragmentDefinitions {
+		document.FragmentDefinitions[i].Directives.Refs = semanticOnly(document.FragmentDefinitions[i].Directives.Refs)
+		document.FragmentDefinitions[i].HasDirectives = len(document.FragmentDefinitions[i].Directives.Refs) != 0
+	}
+}
@@ -0,0 +1,118 @@
+package graphql
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequest_Fingerprint(t *testing.T) {
+	fingerprint := func(t *testing.T, query string, options ...FingerprintOption) uint64 {
+		request := Request{Query: query}
+		result, err := request.Fingerprint(options...)
+		require.NoError(t, err)
+		return result
+	}
+
+	t.Run("is independent of formatting", func(t *testing.T) {
+		assert.Equal(t,
+			fingerprint(t, `query Hero { hero { name } }`),
+			fingerprint(t, "query Hero {\n\thero {\n\t\tname\n\t}\n}"),
+		)
+	})
+
+	t.Run("ignores non-semantic directives by default", func(t *testing.T) {
+		assert.Equal(t,
+			fingerprint(t, `{ hero { name friends @trace(level: 1) { name } } }`),
+			fingerprint(t, `{ hero { name friends @trace(level: 2) { name } } }`),
+		)
+		assert.Equal(t,
+			fingerprint(t, `{ hero @trace { name } }`),
+			fingerprint(t, `{ hero { name } }`),
+		)
+	})
+
+	t.Run("keeps skip and include by default", func(t *testing.T) {
+		assert.NotEqual(t,
+			fingerprint(t, `{ hero { name friends @skip(if: true) { name } } }`),
+			fingerprint(t, `{ hero { name friends @skip(if: false) { name } } }`),
+		)
+		assert.NotEqual(t,
+			fingerprint(t, `{ hero { name ... on Droid @include(if: false) { primaryFunction } } }`),
+			fingerprint(t, `{ hero { name ... on Droid { primaryFunction } } }`),
+		)
+		assert.Equal(t,
+			fingerprint(t, `{ hero { name friends @skip(if: true) @trace { name } } }`),
+			fingerprint(t, `{ hero { name friends @skip(if: true) { name } } }`),
+		)
+	})
+
+	t.Run("with directives", func(t *testing.T) {
+		t.Run("constant directive arguments change the fingerprint", func(t *testing.T) {
+			assert.NotEqual(t,
+				fingerprint(t, `{ hero { name friends @skip(if: true) { name } } }`, WithFingerprintDirectives()),
+				fingerprint(t, `{ hero { name friends @skip(if: false) { name } } }`, WithFingerprintDirectives()),
+			)
+		})
+
+		t.Run("directives change the fingerprint", func(t *testing.T) {
+			assert.NotEqual(t,
+				fingerprint(t, `{ hero { name friends { name } } }`, WithFingerprintDirectives()),
+				fingerprint(t, `{ hero { name friends @include(if: true) { name } } }`, WithFingerprintDirectives()),
+			)
+		})
+
+		t.Run("variable arguments are hashed by reference", func(t *testing.T) {
+			query := `query Hero($skip: Boolean!) { hero { name friends @skip(if: $skip) { name } } }`
+			withSkip := Request{Query: query, Variables: []byte(`{"skip":true}`)}
+			withoutSkip := Request{Query: query, Variables: []byte(`{"skip":false}`)}
+
+			first, err := withSkip.Fingerprint(WithFingerprintDirectives())
+			require.NoError(t, err)
+			second, err := withoutSkip.Fingerprint(WithFingerprintDirectives())
+			require.NoError(t, err)
+			assert.Equal(t, first, second)
+		})
+	})
+
+	t.Run("invalid query", func(t *testing.T) {
+		request := Request{Query: `{ hero { name `}
+		_, err := request.Fingerprint()
+		assert.Error(t, err)
+	})
+}
+
+func TestRequest_OperationHash(t *testing.T) {
+	schema := heroWithArgumentSchema(t)
+
+	operationHash := func(t *testing.T, request Request) uint64 {
+		result, err := request.Normalize(schema)
+		require.NoError(t, err)
+		require.True(t, result.Successful)
+		hash, err := request.OperationHash()
+		require.NoError(t, err)
+		return hash
+	}
+
+	t.Run("is independent of formatting and inline arguments", func(t *testing.T) {
+		assert.Equal(t,
+			operationHash(t, Request{Query: `query Hero { hero(name: "Luke") }`}),
+			operationHash(t, Request{Query: "query Hero($a: String) {\n\thero(name: $a)\n}", Variables: []byte(`{"a":"Luke"}`)}),
+		)
+	})
+
+	t.Run("variables change the hash", func(t *testing.T) {
+		query := `query Hero($name: String) { hero(name: $name) }`
+		assert.NotEqual(t,
+			operationHash(t, Request{Query: query, Variables: []byte(`{"name":"Luke"}`)}),
+			operationHash(t, Request{Query: query, Variables: []byte(`{"name":"Leia"}`)}),
+		)
+	})
+
+	t.Run("request is not normalized", func(t *testing.T) {
+		request := Request{Query: `{ hero(name: "Luke") }`}
+		_, err := request.OperationHash()
+		assert.Equal(t, ErrRequestNotNormalized, err)
+	})
+}
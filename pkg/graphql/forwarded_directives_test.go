@@ -0,0 +1,61 @@
+package graphql
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/jensneuse/abstractlogger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/wundergraph/graphql-go-tools/pkg/engine/datasource/graphql_datasource"
+)
+
+func TestExecutionEngineV2_ForwardedDirectives(t *testing.T) {
+	requests := make(chan string, 1)
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := ioutil.ReadAll(r.Body)
+		require.NoError(t, err)
+		requests <- string(body)
+		_, _ = w.Write([]byte(`{"data":{"me":{"id":"1","name":"Jens"}}}`))
+	}))
+	defer upstream.Close()
+
+	factory := NewFederationEngineConfigFactory([]graphql_datasource.Configuration{
+		{
+			Fetch: graphql_datasource.FetchConfiguration{
+				URL: upstream.URL,
+			},
+			Federation: graphql_datasource.FederationConfiguration{
+				Enabled:    true,
+				ServiceSDL: `directive @trace(label: String) on FIELD extend type Query { me: User } type User @key(fields: "id") { id: ID! name: String! }`,
+			},
+			ForwardedDirectives: []string{"trace"},
+		},
+	}, graphql_datasource.NewBatchFactory())
+	engineConf, err := factory.EngineV2Configuration()
+	require.NoError(t, err)
+
+	engineCtx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	engine, err := NewExecutionEngineV2(engineCtx, abstractlogger.NoopLogger, engineConf)
+	require.NoError(t, err)
+
+	operation := Request{
+		Query:     `query($withName: Boolean!) { me { id @trace(label: "me.id") name @include(if: $withName) } }`,
+		Variables: []byte(`{"withName":true}`),
+	}
+	resultWriter := NewEngineResultWriter()
+	require.NoError(t, engine.Execute(context.Background(), &operation, &resultWriter))
+	assert.Equal(t, `{"data":{"me":{"id":"1","name":"Jens"}}}`, resultWriter.String())
+
+	upstreamRequest := <-requests
+	assert.Contains(t, upstreamRequest, `id @trace(label: \"me.id\")`)
+	// @include is evaluated by the gateway
+	assert.NotContains(t, upstreamRequest, `@include`)
+	assert.NotContains(t, upstreamRequest, `withName`)
+}
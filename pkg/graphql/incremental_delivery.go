@@ -0,0 +1,660 @@
+package graphql
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/buger/jsonparser"
+
+	"github.com/wundergraph/graphql-go-tools/pkg/ast"
+	"github.com/wundergraph/graphql-go-tools/pkg/astparser"
+	"github.com/wundergraph/graphql-go-tools/pkg/astprinter"
+	"github.com/wundergraph/graphql-go-tools/pkg/engine/plan"
+	"github.com/wundergraph/graphql-go-tools/pkg/engine/resolve"
+	"github.com/wundergraph/graphql-go-tools/pkg/lexer/literal"
+	"github.com/wundergraph/graphql-go-tools/pkg/postprocess"
+)
+
+const (
+	deferDirectiveName  = "defer"
+	streamDirectiveName = "stream"
+)
+
+var ErrOperationNotFound = errors.New("operation not found")
+
+// ErrIncrementalDeliveryOnMutation rejects mutations using @defer or @stream,
+// the fields of mutations must be resolved serially and completely before the response is written
+var ErrIncrementalDeliveryOnMutation = errors.New("@defer and @stream are not supported on mutations")
+
+// IncrementalResponseWriter receives the payloads of an incrementally delivered response,
+// e.g. to write them as parts of a multipart/mixed http response.
+type IncrementalResponseWriter interface {
+	WritePayload(payload []byte) error
+}
+
+// HasIncrementalDelivery returns true if the operation selected by the operation name uses the @defer or @stream directive,
+// including the fragments it spreads.
+func (r *Request) HasIncrementalDelivery() (bool, error) {
+	report := r.parseQueryOnce()
+	if report.HasErrors() {
+		return false, report
+	}
+
+	operationRef, ok := r.document.OperationDefinitionRefByName(r.OperationName)
+	if !ok || !r.document.OperationDefinitions[operationRef].HasSelections {
+		return false, nil
+	}
+
+	return hasIncrementalDeliveryDirective(&r.document, r.document.OperationDefinitions[operationRef].SelectionSet, map[int]struct{}{}), nil
+}
+
+// hasIncrementalDeliveryDirective reports whether a selection of the selection set or of its nested selection sets
+// uses @defer or @stream, following fragment spreads. fragments are the fragment definitions already followed.
+func hasIncrementalDeliveryDirective(document *ast.Document, set int, fragments map[int]struct{}) bool {
+	for _, selectionRef := range document.SelectionSets[set].SelectionRefs {
+		selection := document.Selections[selectionRef]
+
+		var node ast.Node
+		nestedSet := ast.InvalidRef
+		switch selection.Kind {
+		case ast.SelectionKindField:
+			node = ast.Node{Kind: ast.NodeKindField, Ref: selection.Ref}
+			if document.Fields[selection.Ref].HasSelections {
+				nestedSet = document.Fields[selection.Ref].SelectionSet
+			}
+		case ast.SelectionKindInlineFragment:
+			node = ast.Node{Kind: ast.NodeKindInlineFragment, Ref: selection.Ref}
+			if document.InlineFragments[selection.Ref].HasSelections {
+				nestedSet = document.InlineFragments[selection.Ref].SelectionSet
+			}
+		case ast.SelectionKindFragmentSpread:
+			node = ast.Node{Kind: ast.NodeKindFragmentSpread, Ref: selection.Ref}
+			fragment, ok := document.FragmentDefinitionRef(document.FragmentSpreadNameBytes(selection.Ref))
+			if _, followed := fragments[fragment]; ok && !followed && document.FragmentDefinitions[fragment].HasSelections {
+				fragments[fragment] = struct{}{}
+				nestedSet = document.FragmentDefinitions[fragment].SelectionSet
+			}
+		}
+
+		for _, directive := range document.NodeDirectives(node) {
+			switch document.DirectiveNameString(directive) {
+			case deferDirectiveName, streamDirectiveName:
+				return true
+			}
+		}
+		if nestedSet != ast.InvalidRef && hasIncrementalDeliveryDirective(document, nestedSet, fragments) {
+			return true
+		}
+	}
+	return false
+}
+
+// ExecuteIncremental executes an operation using @defer and @stream.
+//
+// The operation is planned once without the directives. The fields of deferred fragments and the fetches only they
+// depend on are split off the plan, as well as the items of streamed lists exceeding their initialCount.
+// The initial payload is written once the remaining fields are resolved, deferred fragments and streamed items
+// are resolved afterwards and written as subsequent payloads one at a time.
+// Independent deferred fragments are not resolved concurrently, the fetches of a fragment only start
+// once the payload of the previous one is written, see resolve.Resolver.ResolveGraphQLIncrementalResponse.
+// Fields selected by a deferred fragment and outside of it, or sharing their fetch with such fields,
+// are resolved with the initial payload.
+// @defer and @stream inside of named fragment definitions or deferred fragments are resolved with the enclosing payload.
+//
+// The response pipeline only processes the initial payload. Mutations are rejected with ErrIncrementalDeliveryOnMutation.
+// Outstanding fetches are cancelled if ctx is done or writing a payload fails, e.g. because the client disconnected.
+func (e *ExecutionEngineV2) ExecuteIncremental(ctx context.Context, operation *Request, writer IncrementalResponseWriter, options ...ExecutionOptionsV2) error {
+	incremental, err := newIncrementalOperation(operation)
+	if err != nil {
+		return err
+	}
+
+	request := incremental.request
+	if err = e.prepareOperation(request); err != nil {
+		return err
+	}
+
+	execContext := e.getExecutionCtx()
+	defer e.putExecutionCtx(execContext)
+
+	execContext.prepare(ctx, request.Variables, request.request)
+	execContext.resolveContext.SetMaxOperationTimeout(e.config.maxOperationTimeout)
+	execContext.incremental = incremental
+
+	for i := range options {
+		options[i](execContext)
+	}
+
+	var responseValidation *resolve.ResponseValidation
+	if e.config.responseValidation {
+		responseValidation = resolve.NewResponseValidation()
+		execContext.resolveContext.SetResponseValidation(responseValidation)
+	}
+
+	cachedPlan, err := e.planOperation(ctx, execContext, request)
+	if err != nil {
+		return err
+	}
+	incrementalPlan, ok := cachedPlan.(*plan.IncrementalResponsePlan)
+	if !ok {
+		return errors.New("execution of operation is not possible")
+	}
+
+	var payloadWriter resolve.IncrementalPayloadWriter = writer
+	if pipeline := e.responsePipeline(execContext); !pipeline.Empty() {
+		payloadWriter = &initialPayloadPipelineWriter{
+			ctx:      postprocess.WithResponseOperation(ctx, &request.document, &e.config.schema.document, request.OperationName),
+			pipeline: pipeline,
+			writer:   writer,
+		}
+	}
+
+	err = e.resolver.ResolveGraphQLIncrementalResponse(execContext.resolveContext, incrementalPlan.Response, payloadWriter)
+
+	if responseValidation != nil {
+		e.logResponseViolations(request, responseValidation.Violations())
+	}
+
+	return err
+}
+
+// initialPayloadPipelineWriter runs the response pipeline on the initial payload of an incremental response,
+// "hasNext" is set again afterwards as the pipeline only keeps the fields of a regular response.
+type initialPayloadPipelineWriter struct {
+	ctx      context.Context
+	pipeline *postprocess.ResponsePipeline
+	writer   IncrementalResponseWriter
+	written  bool
+}
+
+func (w *initialPayloadPipelineWriter) WritePayload(payload []byte) error {
+	if w.written {
+		return w.writer.WritePayload(payload)
+	}
+	w.written = true
+
+	hasNext, err := jsonparser.GetBoolean(payload, "hasNext")
+	if err != nil {
+		return err
+	}
+	payload, err = w.pipeline.Process(w.ctx, payload)
+	if err != nil {
+		return err
+	}
+	payload, err = jsonparser.Set(payload, []byte(strconv.FormatBool(hasNext)), "hasNext")
+	if err != nil {
+		return err
+	}
+	return w.writer.WritePayload(payload)
+}
+
+// incrementalOperation is an operation using @defer and @stream
+type incrementalOperation struct {
+	// request is the operation without @defer and @stream, its plan is split into the incremental plan
+	request  *Request
+	deferred []deferredFragment
+	streams  []streamedField
+}
+
+type deferredFragment struct {
+	label string
+	path  []string
+	// fields are the response keys of the fields to defer, i.e. selected by the fragment but not outside of deferred fragments
+	fields map[string]struct{}
+}
+
+type streamedField struct {
+	label        string
+	path         []string
+	initialCount int
+}
+
+type deferredSelection struct {
+	label     string
+	path      []string
+	selection int
+	fields    map[string]struct{}
+}
+
+func newIncrementalOperation(request *Request) (*incrementalOperation, error) {
+	document, report := astparser.ParseGraphqlDocumentString(request.Query)
+	if report.HasErrors() {
+		return nil, report
+	}
+
+	operationRef, ok := document.OperationDefinitionRefByName(request.OperationName)
+	if !ok {
+		return nil, ErrOperationNotFound
+	}
+	if document.OperationDefinitions[operationRef].OperationType == ast.OperationTypeMutation {
+		return nil, ErrIncrementalDeliveryOnMutation
+	}
+
+	collector := incrementalSelectionCollector{
+		document:  &document,
+		variables: request.Variables,
+	}
+	operationSet := document.OperationDefinitions[operationRef].SelectionSet
+	if document.OperationDefinitions[operationRef].HasSelections {
+		collector.collect(operationSet, nil)
+	}
+
+	incremental := &incrementalOperation{
+		streams: collector.streams,
+	}
+
+	deferredSelections := make(map[int]struct{}, len(collector.deferred))
+	for _, deferred := range collector.deferred {
+		deferredSelections[deferred.selection] = struct{}{}
+	}
+	for _, deferred := range collector.deferred {
+		selected := map[string]struct{}{}
+		collector.responseKeys(operationSet, deferred.path, deferredSelections, selected, map[int]struct{}{})
+		fields := make(map[string]struct{}, len(deferred.fields))
+		for key := range deferred.fields {
+			if _, ok := selected[key]; !ok {
+				fields[key] = struct{}{}
+			}
+		}
+		incremental.deferred = append(incremental.deferred, deferredFragment{
+			label:  deferred.label,
+			path:   deferred.path,
+			fields: fields,
+		})
+	}
+
+	var err error
+	incremental.request, err = withoutIncrementalDeliveryDirectives(request)
+	if err != nil {
+		return nil, err
+	}
+	return incremental, nil
+}
+
+// withoutIncrementalDeliveryDirectives creates a new request from the operation without any @defer and @stream directives
+func withoutIncrementalDeliveryDirectives(request *Request) (*Request, error) {
+	document, report := astparser.ParseGraphqlDocumentString(request.Query)
+	if report.HasErrors() {
+		return nil, report
+	}
+
+	removeIncrementalDeliveryDirectives(&document)
+
+	query, err := astprinter.PrintString(&document, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Request{
+		OperationName: request.OperationName,
+		Variables:     request.Variables,
+		Query:         query,
+		request:       request.request,
+	}, nil
+}
+
+// writeCacheKey adds the split of the plan to the plan cache key,
+// it depends on the variables of the @defer and @stream directives
+func (i *incrementalOperation) writeCacheKey(w io.Writer) {
+	// the plan differs from the one of the same operation executed without incremental delivery
+	_, _ = io.WriteString(w, "incremental")
+	_, _ = w.Write([]byte{0})
+	for _, deferred := range i.deferred {
+		_, _ = io.WriteString(w, "defer:"+deferred.label+":"+strings.Join(deferred.path, "."))
+		keys := make([]string, 0, len(deferred.fields))
+		for key := range deferred.fields {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		_, _ = io.WriteString(w, ":"+strings.Join(keys, ","))
+		_, _ = w.Write([]byte{0})
+	}
+	for _, stream := range i.streams {
+		_, _ = io.WriteString(w, "stream:"+stream.label+":"+strings.Join(stream.path, ".")+":"+strconv.Itoa(stream.initialCount))
+		_, _ = w.Write([]byte{0})
+	}
+}
+
+// split moves the deferred fields and the fetches only they depend on out of the plan into the patches of an
+// incremental plan and streams the lists of streamed fields.
+// The plan must not be shared, it's modified in place.
+func (i *incrementalOperation) split(p plan.Plan) plan.Plan {
+	synchronous, ok := p.(*plan.SynchronousResponsePlan)
+	if !ok {
+		return p
+	}
+
+	response := &resolve.GraphQLIncrementalResponse{
+		InitialResponse: synchronous.Response,
+	}
+
+	for _, deferred := range i.deferred {
+		for _, object := range objectsAtPath(synchronous.Response.Data, deferred.path) {
+			fragment := deferFields(object, deferred.fields)
+			if fragment == nil {
+				continue
+			}
+			object.Deferred = append(object.Deferred, len(response.Patches))
+			response.Patches = append(response.Patches, &resolve.IncrementalPatch{
+				Label: deferred.label,
+				Value: fragment,
+			})
+		}
+	}
+
+	for _, stream := range i.streams {
+		parents, key := stream.path[:len(stream.path)-1], stream.path[len(stream.path)-1]
+		for _, object := range objectsAtPath(synchronous.Response.Data, parents) {
+			for _, field := range object.Fields {
+				array, ok := field.Value.(*resolve.Array)
+				if !ok || string(field.Name) != key {
+					continue
+				}
+				array.Stream = resolve.Stream{
+					Enabled:          true,
+					InitialBatchSize: stream.initialCount,
+					PatchIndex:       len(response.Patches),
+				}
+				response.Patches = append(response.Patches, &resolve.IncrementalPatch{
+					Label:  stream.label,
+					Value:  array.Item,
+					Stream: true,
+				})
+			}
+		}
+	}
+
+	return &plan.IncrementalResponsePlan{
+		Response:      response,
+		FlushInterval: synchronous.FlushInterval,
+	}
+}
+
+// objectsAtPath returns the objects at the path of response keys, lists on the way are passed through
+func objectsAtPath(node resolve.Node, path []string) (objects []*resolve.Object) {
+	switch node := node.(type) {
+	case *resolve.Array:
+		return objectsAtPath(node.Item, path)
+	case *resolve.Object:
+		if len(path) == 0 {
+			return []*resolve.Object{node}
+		}
+		for _, field := range node.Fields {
+			if string(field.Name) == path[0] {
+				objects = append(objects, objectsAtPath(field.Value, path[1:])...)
+			}
+		}
+	}
+	return objects
+}
+
+// deferFields moves the fields with the response keys out of the object into a new object, together with their fetches.
+// A field stays in the object if its fetch isn't a fetch of the object or if fields staying in the object use it as well.
+// It returns nil if no field is moved.
+func deferFields(object *resolve.Object, keys map[string]struct{}) *resolve.Object {
+	deferred := make([]bool, len(object.Fields))
+	for j, field := range object.Fields {
+		_, deferred[j] = keys[string(field.Name)]
+	}
+
+	for changed := true; changed; {
+		changed = false
+		for j, field := range object.Fields {
+			if !deferred[j] || !field.HasBuffer {
+				continue
+			}
+			if !hasFetch(object.Fetch, field.BufferID) || bufferUsedByOtherFields(object.Fields, deferred, field.BufferID) {
+				deferred[j] = false
+				changed = true
+			}
+		}
+	}
+
+	fragment := &resolve.Object{Nullable: true}
+	fields := object.Fields[:0:0]
+	var fetches []resolve.Fetch
+	for j, field := range object.Fields {
+		if !deferred[j] {
+			fields = append(fields, field)
+			continue
+		}
+		fragment.Fields = append(fragment.Fields, field)
+		if !field.HasBuffer {
+			continue
+		}
+		var fetch resolve.Fetch
+		if object.Fetch, fetch = takeFetch(object.Fetch, field.BufferID); fetch != nil {
+			fetches = append(fetches, fetch)
+		}
+	}
+	if len(fragment.Fields) == 0 {
+		return nil
+	}
+
+	object.Fields = fields
+	switch len(fetches) {
+	case 0:
+	case 1:
+		fragment.Fetch = fetches[0]
+	default:
+		fragment.Fetch = &resolve.ParallelFetch{Fetches: fetches}
+	}
+	return fragment
+}
+
+func bufferUsedByOtherFields(fields []*resolve.Field, deferred []bool, bufferID int) bool {
+	for j, field := range fields {
+		if !deferred[j] && field.HasBuffer && field.BufferID == bufferID {
+			return true
+		}
+	}
+	return false
+}
+
+func hasFetch(fetch resolve.Fetch, bufferID int) bool {
+	_, taken := takeFetch(fetch, bufferID)
+	return taken != nil
+}
+
+// takeFetch removes the fetch writing into the buffer from fetch and returns the remaining fetch and the removed one
+func takeFetch(fetch resolve.Fetch, bufferID int) (remaining, taken resolve.Fetch) {
+	switch fetch := fetch.(type) {
+	case *resolve.SingleFetch:
+		if fetch.BufferId == bufferID {
+			return nil, fetch
+		}
+	case *resolve.BatchFetch:
+		if fetch.Fetch.BufferId == bufferID {
+			return nil, fetch
+		}
+	case *resolve.ParallelFetch:
+		for j := range fetch.Fetches {
+			nested, taken := takeFetch(fetch.Fetches[j], bufferID)
+			if taken == nil {
+				continue
+			}
+			rest := make([]resolve.Fetch, 0, len(fetch.Fetches))
+			rest = append(rest, fetch.Fetches[:j]...)
+			if nested != nil {
+				rest = append(rest, nested)
+			}
+			rest = append(rest, fetch.Fetches[j+1:]...)
+			switch len(rest) {
+			case 0:
+				return nil, taken
+			case 1:
+				return rest[0], taken
+			default:
+				return &resolve.ParallelFetch{Fetches: rest}, taken
+			}
+		}
+	}
+	return fetch, nil
+}
+
+type incrementalSelectionCollector struct {
+	document  *ast.Document
+	variables []byte
+	deferred  []deferredSelection
+	streams   []streamedField
+}
+
+// collect walks the selections of the operation and collects the enabled @defer and @stream directives
+func (c *incrementalSelectionCollector) collect(set int, path []string) {
+	for _, selectionRef := range c.document.SelectionSets[set].SelectionRefs {
+		selection := c.document.Selections[selectionRef]
+
+		var node ast.Node
+		switch selection.Kind {
+		case ast.SelectionKindField:
+			node = ast.Node{Kind: ast.NodeKindField, Ref: selection.Ref}
+		case ast.SelectionKindInlineFragment:
+			node = ast.Node{Kind: ast.NodeKindInlineFragment, Ref: selection.Ref}
+		case ast.SelectionKindFragmentSpread:
+			node = ast.Node{Kind: ast.NodeKindFragmentSpread, Ref: selection.Ref}
+		}
+
+		if directive, ok := c.enabledDirective(node, deferDirectiveName); ok && selection.Kind != ast.SelectionKindField {
+			fields := map[string]struct{}{}
+			c.selectionResponseKeys(selectionRef, nil, nil, fields, map[int]struct{}{})
+			c.deferred = append(c.deferred, deferredSelection{
+				label:     c.label(directive),
+				path:      path,
+				selection: selectionRef,
+				fields:    fields,
+			})
+			continue
+		}
+
+		switch selection.Kind {
+		case ast.SelectionKindField:
+			field := c.document.Fields[selection.Ref]
+			fieldPath := append(path[:len(path):len(path)], c.document.FieldAliasOrNameString(selection.Ref))
+			if directive, ok := c.enabledDirective(node, streamDirectiveName); ok {
+				c.streams = append(c.streams, streamedField{
+					label:        c.label(directive),
+					path:         fieldPath,
+					initialCount: c.initialCount(directive),
+				})
+				continue
+			}
+			if field.HasSelections {
+				c.collect(field.SelectionSet, fieldPath)
+			}
+		case ast.SelectionKindInlineFragment:
+			inlineFragment := c.document.InlineFragments[selection.Ref]
+			if inlineFragment.HasSelections {
+				c.collect(inlineFragment.SelectionSet, path)
+			}
+		}
+	}
+}
+
+// responseKeys adds the response keys of the fields selected at the path below the selection set to keys,
+// following inline fragments and fragment spreads. The selections in skip are ignored.
+func (c *incrementalSelectionCollector) responseKeys(set int, path []string, skip map[int]struct{}, keys map[string]struct{}, fragments map[int]struct{}) {
+	for _, selectionRef := range c.document.SelectionSets[set].SelectionRefs {
+		if _, ok := skip[selectionRef]; ok {
+			continue
+		}
+		c.selectionResponseKeys(selectionRef, path, skip, keys, fragments)
+	}
+}
+
+// selectionResponseKeys is responseKeys for a single selection,
+// fragments are the fragment definitions currently followed to stop at fragment cycles
+func (c *incrementalSelectionCollector) selectionResponseKeys(selectionRef int, path []string, skip map[int]struct{}, keys map[string]struct{}, fragments map[int]struct{}) {
+	selection := c.document.Selections[selectionRef]
+	switch selection.Kind {
+	case ast.SelectionKindField:
+		key := c.document.FieldAliasOrNameString(selection.Ref)
+		if len(path) == 0 {
+			keys[key] = struct{}{}
+			return
+		}
+		if key == path[0] && c.document.Fields[selection.Ref].HasSelections {
+			c.responseKeys(c.document.Fields[selection.Ref].SelectionSet, path[1:], skip, keys, fragments)
+		}
+	case ast.SelectionKindInlineFragment:
+		if c.document.InlineFragments[selection.Ref].HasSelections {
+			c.responseKeys(c.document.InlineFragments[selection.Ref].SelectionSet, path, skip, keys, fragments)
+		}
+	case ast.SelectionKindFragmentSpread:
+		fragment, ok := c.document.FragmentDefinitionRef(c.document.FragmentSpreadNameBytes(selection.Ref))
+		if !ok || !c.document.FragmentDefinitions[fragment].HasSelections {
+			return
+		}
+		if _, ok := fragments[fragment]; ok {
+			return
+		}
+		fragments[fragment] = struct{}{}
+		c.responseKeys(c.document.FragmentDefinitions[fragment].SelectionSet, path, skip, keys, fragments)
+		delete(fragments, fragment)
+	}
+}
+
+func (c *incrementalSelectionCollector) enabledDirective(node ast.Node, name string) (ref int, ok bool) {
+	for _, ref = range c.document.NodeDirectives(node) {
+		if c.document.DirectiveNameString(ref) != name {
+			continue
+		}
+		value, exists := c.document.DirectiveArgumentValueByName(ref, literal.IF)
+		if !exists {
+			return ref, true
+		}
+		switch value.Kind {
+		case ast.ValueKindBoolean:
+			return ref, bool(c.document.BooleanValue(value.Ref))
+		case ast.ValueKindVariable:
+			enabled, err := jsonparser.GetBoolean(c.variables, c.document.VariableValueNameString(value.Ref))
+			return ref, err != nil || enabled
+		default:
+			return ref, true
+		}
+	}
+	return ast.InvalidRef, false
+}
+
+func (c *incrementalSelectionCollector) label(directive int) string {
+	value, exists := c.document.DirectiveArgumentValueByName(directive, literal.LABEL)
+	if !exists || value.Kind != ast.ValueKindString {
+		return ""
+	}
+	return c.document.StringValueContentString(value.Ref)
+}
+
+func (c *incrementalSelectionCollector) initialCount(directive int) int {
+	value, exists := c.document.DirectiveArgumentValueByName(directive, literal.INITIAL_COUNT)
+	if !exists || value.Kind != ast.ValueKindInteger {
+		return 0
+	}
+	return int(c.document.IntValueAsInt(value.Ref))
+}
+
+func removeIncrementalDeliveryDirectives(document *ast.Document) {
+	for i := range document.Fields {
+		removeIncrementalDeliveryDirectivesFromNode(document, ast.Node{Kind: ast.NodeKindField, Ref: i})
+	}
+	for i := range document.InlineFragments {
+		removeIncrementalDeliveryDirectivesFromNode(document, ast.Node{Kind: ast.NodeKindInlineFragment, Ref: i})
+	}
+	for i := range document.FragmentSpreads {
+		removeIncrementalDeliveryDirectivesFromNode(document, ast.Node{Kind: ast.NodeKindFragmentSpread, Ref: i})
+	}
+}
+
+func removeIncrementalDeliveryDirectivesFromNode(document *ast.Document, node ast.Node) {
+	directives := document.NodeDirectives(node)
+	remove := make([]int, 0, len(directives))
+	for _, ref := range directives {
+		switch document.DirectiveNameString(ref) {
+		case deferDirectiveName, streamDirectiveName:
+			remove = append(remove, ref)
+		}
+	}
+	document.RemoveDirectivesFromNode(node, remove)
+}
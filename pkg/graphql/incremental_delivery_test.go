@@ -0,0 +1,161 @@
+package graphql
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/wundergraph/graphql-go-tools/pkg/engine/plan"
+	"github.com/wundergraph/graphql-go-tools/pkg/engine/resolve"
+)
+
+func TestRequest_HasIncrementalDelivery(t *testing.T) {
+	run := func(query string, expected bool) func(t *testing.T) {
+		return func(t *testing.T) {
+			request := Request{Query: query}
+			incremental, err := request.HasIncrementalDelivery()
+			require.NoError(t, err)
+			assert.Equal(t, expected, incremental)
+		}
+	}
+
+	t.Run("without directives", run(`{ hero { name } }`, false))
+	t.Run("with defer", run(`{ hero { name ... @defer { friends { name } } } }`, true))
+	t.Run("with stream", run(`{ hero { friends @stream { name } } }`, true))
+	t.Run("with defer in fragment", run(`{ hero { ...HeroFriends } } fragment HeroFriends on Character { ... @defer { friends { name } } }`, true))
+
+	t.Run("with defer in other operation", func(t *testing.T) {
+		request := Request{
+			OperationName: "Hero",
+			Query:         `query Hero { hero { name } } query Friends { hero { ... @defer { friends { name } } } }`,
+		}
+		incremental, err := request.HasIncrementalDelivery()
+		require.NoError(t, err)
+		assert.False(t, incremental)
+
+		request.OperationName = "Friends"
+		incremental, err = request.HasIncrementalDelivery()
+		require.NoError(t, err)
+		assert.True(t, incremental)
+	})
+}
+
+func TestIncrementalOperation(t *testing.T) {
+	request := &Request{
+		Query: `query Hero($deferFriend: Boolean!) {
+			hero {
+				name
+				... on Droid @defer(label: "droid") { name primaryFunction }
+				friends @stream(initialCount: 1) { name }
+			}
+			droid { ... @defer { name } }
+			friend: droid { ...DroidName @defer(if: $deferFriend) }
+		}
+		fragment DroidName on Droid { name }`,
+		Variables: []byte(`{"deferFriend":false}`),
+	}
+
+	incremental, err := newIncrementalOperation(request)
+	require.NoError(t, err)
+
+	t.Run("operation without directives", func(t *testing.T) {
+		assert.Equal(t, `query Hero($deferFriend: Boolean!){hero {name ... on Droid {name primaryFunction} friends {name}} droid {...{name}} friend: droid {...DroidName}} fragment DroidName on Droid {name}`, incremental.request.Query)
+		assert.Equal(t, []streamedField{{path: []string{"hero", "friends"}, initialCount: 1}}, incremental.streams)
+	})
+
+	t.Run("deferred fragments", func(t *testing.T) {
+		assert.Equal(t, []deferredFragment{
+			// name is selected outside of the fragment as well
+			{label: "droid", path: []string{"hero"}, fields: map[string]struct{}{"primaryFunction": {}}},
+			{path: []string{"droid"}, fields: map[string]struct{}{"name": {}}},
+		}, incremental.deferred)
+	})
+
+	t.Run("split plan", func(t *testing.T) {
+		fetch := func(bufferID int) *resolve.SingleFetch {
+			return &resolve.SingleFetch{BufferId: bufferID}
+		}
+		heroFetch := fetch(2)
+		p := incremental.split(&plan.SynchronousResponsePlan{
+			Response: &resolve.GraphQLResponse{
+				Data: &resolve.Object{
+					Fetch: &resolve.ParallelFetch{Fetches: []resolve.Fetch{fetch(0), fetch(1)}},
+					Fields: []*resolve.Field{
+						{
+							Name:      []byte("hero"),
+							HasBuffer: true,
+							BufferID:  0,
+							Value: &resolve.Object{
+								Path:  []string{"hero"},
+								Fetch: heroFetch,
+								Fields: []*resolve.Field{
+									{Name: []byte("name"), Value: &resolve.String{Path: []string{"name"}}},
+									{Name: []byte("primaryFunction"), HasBuffer: true, BufferID: 2, Value: &resolve.String{Path: []string{"primaryFunction"}}},
+									{Name: []byte("friends"), Value: &resolve.Array{Path: []string{"friends"}, Item: &resolve.Object{}}},
+								},
+							},
+						},
+						{
+							Name:      []byte("droid"),
+							HasBuffer: true,
+							BufferID:  1,
+							Value: &resolve.Object{
+								Path: []string{"droid"},
+								Fields: []*resolve.Field{
+									{Name: []byte("name"), Value: &resolve.String{Path: []string{"name"}}},
+								},
+							},
+						},
+					},
+				},
+			},
+		})
+
+		incrementalPlan, ok := p.(*plan.IncrementalResponsePlan)
+		require.True(t, ok)
+		response := incrementalPlan.Response
+		require.Len(t, response.Patches, 3)
+
+		hero := response.InitialResponse.Data.(*resolve.Object).Fields[0].Value.(*resolve.Object)
+		assert.Nil(t, hero.Fetch)
+		assert.Equal(t, []int{0}, hero.Deferred)
+		require.Len(t, hero.Fields, 2)
+		assert.Equal(t, "name", string(hero.Fields[0].Name))
+		assert.Equal(t, "droid", response.Patches[0].Label)
+		heroFragment := response.Patches[0].Value.(*resolve.Object)
+		assert.Same(t, heroFetch, heroFragment.Fetch)
+		require.Len(t, heroFragment.Fields, 1)
+		assert.Equal(t, "primaryFunction", string(heroFragment.Fields[0].Name))
+
+		droid := response.InitialResponse.Data.(*resolve.Object).Fields[1].Value.(*resolve.Object)
+		assert.Empty(t, droid.Fields)
+		assert.Equal(t, []int{1}, droid.Deferred)
+		assert.Nil(t, response.Patches[1].Value.(*resolve.Object).Fetch)
+
+		friends := hero.Fields[1].Value.(*resolve.Array)
+		assert.Equal(t, resolve.Stream{Enabled: true, InitialBatchSize: 1, PatchIndex: 2}, friends.Stream)
+		assert.True(t, response.Patches[2].Stream)
+		assert.Same(t, friends.Item, response.Patches[2].Value)
+	})
+
+	t.Run("fields sharing a fetch with other fields are not deferred", func(t *testing.T) {
+		object := &resolve.Object{
+			Fetch: &resolve.SingleFetch{BufferId: 0},
+			Fields: []*resolve.Field{
+				{Name: []byte("name"), HasBuffer: true, BufferID: 0},
+				{Name: []byte("primaryFunction"), HasBuffer: true, BufferID: 0},
+			},
+		}
+		assert.Nil(t, deferFields(object, map[string]struct{}{"primaryFunction": {}}))
+		assert.Len(t, object.Fields, 2)
+		assert.NotNil(t, object.Fetch)
+	})
+}
+
+func TestIncrementalOperation_Mutation(t *testing.T) {
+	_, err := newIncrementalOperation(&Request{
+		Query: `mutation AddReview { addReview(body: "Great") { body ... @defer { author { name } } } }`,
+	})
+	assert.Equal(t, ErrIncrementalDeliveryOnMutation, err)
+}
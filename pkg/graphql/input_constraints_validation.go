@@ -0,0 +1,238 @@
+package graphql
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/buger/jsonparser"
+
+	"github.com/wundergraph/graphql-go-tools/pkg/ast"
+	"github.com/wundergraph/graphql-go-tools/pkg/astvisitor"
+	"github.com/wundergraph/graphql-go-tools/pkg/operationreport"
+)
+
+const (
+	lengthDirectiveName  = "length"
+	lengthMinArg         = "min"
+	lengthMaxArg         = "max"
+	patternDirectiveName = "pattern"
+	patternRegexArg      = "regex"
+)
+
+// constraintPatterns caches the compiled regular expressions of @pattern directives
+var constraintPatterns sync.Map
+
+// inputConstraintsVisitor validates the values of field arguments against the constraint directives
+// of their argument and input field definitions:
+//
+//	directive @length(min: Int, max: Int) on ARGUMENT_DEFINITION | INPUT_FIELD_DEFINITION
+//	directive @pattern(regex: String!) on ARGUMENT_DEFINITION | INPUT_FIELD_DEFINITION
+//
+// @length limits the number of characters of a string or the number of items of a list,
+// @pattern requires a string to match the regular expression.
+// It expects a normalized operation, so every argument value is a variable.
+type inputConstraintsVisitor struct {
+	*astvisitor.Walker
+	operation, definition *ast.Document
+	operationName         string
+	variables             []byte
+	path                  []string
+	errs                  RequestErrors
+}
+
+func (v *inputConstraintsVisitor) EnterOperationDefinition(ref int) {
+	if selected, _ := v.operation.OperationDefinitionRefByName(v.operationName); ref != selected {
+		v.SkipNode()
+	}
+}
+
+func (v *inputConstraintsVisitor) EnterArgument(ref int) {
+	if v.Ancestors[len(v.Ancestors)-1].Kind != ast.NodeKindField {
+		return
+	}
+	inputValueDefinition, ok := v.ArgumentInputValueDefinition(ref)
+	if !ok {
+		return
+	}
+
+	value, valueType, ok := v.argumentValue(v.operation.ArgumentValue(ref))
+	if !ok {
+		return
+	}
+
+	field := v.Ancestors[len(v.Ancestors)-1].Ref
+	v.path = append(v.path[:0], v.operation.FieldNameString(field), v.operation.ArgumentNameString(ref))
+	typeRef := v.definition.InputValueDefinitions[inputValueDefinition].Type
+	if reason := v.validateValue(value, valueType, typeRef, inputValueDefinition); reason != "" {
+		v.errs = append(v.errs, RequestError{
+			Message:   fmt.Sprintf(`Value at "%s" %s`, strings.Join(v.path, "."), reason),
+			Locations: operationreport.LocationsFromPosition(v.operation.Arguments[ref].Position),
+		})
+	}
+}
+
+// argumentValue returns the JSON value of an argument, false if the value is unknown or not provided
+func (v *inputConstraintsVisitor) argumentValue(value ast.Value) ([]byte, jsonparser.ValueType, bool) {
+	if value.Kind == ast.ValueKindVariable {
+		data, dataType, _, err := jsonparser.Get(v.variables, v.operation.VariableValueNameString(value.Ref))
+		return data, dataType, err == nil
+	}
+	data, err := v.operation.ValueToJSON(value)
+	if err != nil {
+		return nil, jsonparser.NotExist, false
+	}
+	data, dataType, _, err := jsonparser.Get(data)
+	return data, dataType, err == nil
+}
+
+// validateValue returns the reason why value violates a constraint, or an empty string if it's valid.
+// inputValueDefinition is the argument or input field definition carrying the constraints of the value, -1 for list items.
+// On failure the path points to the invalid value.
+func (v *inputConstraintsVisitor) validateValue(value []byte, valueType jsonparser.ValueType, typeRef, inputValueDefinition int) string {
+	if valueType == jsonparser.Null {
+		return ""
+	}
+
+	if inputValueDefinition != ast.InvalidRef {
+		if reason := v.validateConstraints(value, valueType, inputValueDefinition); reason != "" {
+			return reason
+		}
+	}
+
+	if v.definition.Types[typeRef].TypeKind == ast.TypeKindNonNull {
+		typeRef = v.definition.Types[typeRef].OfType
+	}
+
+	if v.definition.Types[typeRef].TypeKind == ast.TypeKindList {
+		if valueType != jsonparser.Array {
+			// a single value is coerced to a list of one item
+			return v.validateValue(value, valueType, v.definition.Types[typeRef].OfType, ast.InvalidRef)
+		}
+		var (
+			index  int
+			reason string
+		)
+		_, _ = jsonparser.ArrayEach(value, func(item []byte, itemType jsonparser.ValueType, _ int, _ error) {
+			defer func() { index++ }()
+			if reason != "" {
+				return
+			}
+			v.path = append(v.path, fmt.Sprintf("%d", index))
+			if reason = v.validateValue(item, itemType, v.definition.Types[typeRef].OfType, ast.InvalidRef); reason == "" {
+				v.path = v.path[:len(v.path)-1]
+			}
+		})
+		return reason
+	}
+
+	if valueType != jsonparser.Object {
+		return ""
+	}
+	node, ok := v.definition.Index.FirstNodeByNameBytes(v.definition.TypeNameBytes(typeRef))
+	if !ok || node.Kind != ast.NodeKindInputObjectTypeDefinition {
+		return ""
+	}
+	for _, fieldRef := range v.definition.InputObjectTypeDefinitions[node.Ref].InputFieldsDefinition.Refs {
+		fieldName := v.definition.InputValueDefinitionNameString(fieldRef)
+		fieldValue, fieldValueType, _, err := jsonparser.Get(value, fieldName)
+		if err != nil {
+			continue
+		}
+		v.path = append(v.path, fieldName)
+		if reason := v.validateValue(fieldValue, fieldValueType, v.definition.InputValueDefinitions[fieldRef].Type, fieldRef); reason != "" {
+			return reason
+		}
+		v.path = v.path[:len(v.path)-1]
+	}
+	return ""
+}
+
+func (v *inputConstraintsVisitor) validateConstraints(value []byte, valueType jsonparser.ValueType, inputValueDefinition int) string {
+	if !v.definition.InputValueDefinitions[inputValueDefinition].HasDirectives {
+		return ""
+	}
+	for _, directive := range v.definition.InputValueDefinitions[inputValueDefinition].Directives.Refs {
+		var reason string
+		switch v.definition.DirectiveNameString(directive) {
+		case lengthDirectiveName:
+			reason = v.validateLength(value, valueType, directive)
+		case patternDirectiveName:
+			reason = v.validatePattern(value, valueType, directive)
+		}
+		if reason != "" {
+			return reason
+		}
+	}
+	return ""
+}
+
+func (v *inputConstraintsVisitor) validateLength(value []byte, valueType jsonparser.ValueType, directive int) string {
+	var length int
+	unit := "characters"
+	switch valueType {
+	case jsonparser.String:
+		unescaped, err := jsonparser.ParseString(value)
+		if err != nil {
+			return ""
+		}
+		length = utf8.RuneCountInString(unescaped)
+	case jsonparser.Array:
+		unit = "items"
+		_, _ = jsonparser.ArrayEach(value, func(_ []byte, _ jsonparser.ValueType, _ int, _ error) {
+			length++
+		})
+	default:
+		return ""
+	}
+
+	if min, ok := v.definition.DirectiveArgumentValueByName(directive, []byte(lengthMinArg)); ok && min.Kind == ast.ValueKindInteger {
+		if minLength := int(v.definition.IntValueAsInt(min.Ref)); length < minLength {
+			return fmt.Sprintf("must be at least %d %s long, got %d.", minLength, unit, length)
+		}
+	}
+	if max, ok := v.definition.DirectiveArgumentValueByName(directive, []byte(lengthMaxArg)); ok && max.Kind == ast.ValueKindInteger {
+		if maxLength := int(v.definition.IntValueAsInt(max.Ref)); length > maxLength {
+			return fmt.Sprintf("must be at most %d %s long, got %d.", maxLength, unit, length)
+		}
+	}
+	return ""
+}
+
+func (v *inputConstraintsVisitor) validatePattern(value []byte, valueType jsonparser.ValueType, directive int) string {
+	if valueType != jsonparser.String {
+		return ""
+	}
+	regex, ok := v.definition.DirectiveArgumentValueByName(directive, []byte(patternRegexArg))
+	if !ok || regex.Kind != ast.ValueKindString {
+		return ""
+	}
+	expr := v.definition.StringValueContentString(regex.Ref)
+
+	pattern, err := constraintPattern(expr)
+	if err != nil {
+		return fmt.Sprintf(`can't be validated, invalid pattern "%s": %s.`, expr, err)
+	}
+	unescaped, err := jsonparser.ParseString(value)
+	if err != nil {
+		return ""
+	}
+	if !pattern.MatchString(unescaped) {
+		return fmt.Sprintf(`must match pattern "%s".`, expr)
+	}
+	return ""
+}
+
+func constraintPattern(expr string) (*regexp.Regexp, error) {
+	if pattern, ok := constraintPatterns.Load(expr); ok {
+		return pattern.(*regexp.Regexp), nil
+	}
+	pattern, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, err
+	}
+	constraintPatterns.Store(expr, pattern)
+	return pattern, nil
+}
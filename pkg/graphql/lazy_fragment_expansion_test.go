@@ -0,0 +1,67 @@
+package graphql
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/jensneuse/abstractlogger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/wundergraph/graphql-go-tools/pkg/engine/datasource/graphql_datasource"
+)
+
+func TestExecutionEngineV2_LazyFragmentExpansion(t *testing.T) {
+	upstreamRequests := make(chan string, 1)
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := ioutil.ReadAll(r.Body)
+		require.NoError(t, err)
+		upstreamRequests <- string(body)
+		_, _ = w.Write([]byte(`{"data":{"me":{"id":"1","name":"Jens","friends":[{"id":"2","name":"Stefan"}]}}}`))
+	}))
+	defer upstream.Close()
+
+	execute := func(t *testing.T, enableLazyFragmentExpansion bool) (response, upstreamRequest string) {
+		factory := NewFederationEngineConfigFactory([]graphql_datasource.Configuration{
+			{
+				Fetch: graphql_datasource.FetchConfiguration{
+					URL: upstream.URL,
+				},
+				Federation: graphql_datasource.FederationConfiguration{
+					Enabled:    true,
+					ServiceSDL: `extend type Query { me: User } type User @key(fields: "id") { id: ID! name: String! friends: [User!] }`,
+				},
+			},
+		}, graphql_datasource.NewBatchFactory())
+		engineConf, err := factory.EngineV2Configuration()
+		require.NoError(t, err)
+		engineConf.EnableLazyFragmentExpansion(enableLazyFragmentExpansion)
+
+		engineCtx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		engine, err := NewExecutionEngineV2(engineCtx, abstractlogger.NoopLogger, engineConf)
+		require.NoError(t, err)
+
+		operation := Request{
+			OperationName: "Me",
+			Query: `query Me { me { ...UserFields friends { ...UserFields ...Name } } }
+				query Other { me { ...Name } }
+				fragment UserFields on User { id ...Name ...Name }
+				fragment Name on User { name }`,
+		}
+		resultWriter := NewEngineResultWriter()
+		require.NoError(t, engine.Execute(context.Background(), &operation, &resultWriter))
+		return resultWriter.String(), <-upstreamRequests
+	}
+
+	eagerResponse, eagerUpstreamRequest := execute(t, false)
+	lazyResponse, lazyUpstreamRequest := execute(t, true)
+
+	assert.Equal(t, `{"data":{"me":{"id":"1","name":"Jens","friends":[{"id":"2","name":"Stefan"}]}}}`, lazyResponse)
+	assert.Equal(t, eagerResponse, lazyResponse)
+	assert.Equal(t, eagerUpstreamRequest, lazyUpstreamRequest)
+}
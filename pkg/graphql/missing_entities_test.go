@@ -0,0 +1,72 @@
+package graphql
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jensneuse/abstractlogger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/wundergraph/graphql-go-tools/pkg/engine/datasource/graphql_datasource"
+)
+
+func TestExecutionEngineV2_MissingEntities(t *testing.T) {
+	reviewsResponses, reviewsRequests := make(chan string, 1), make(chan string, 1)
+	reviews := newUpstream(t, reviewsResponses, reviewsRequests)
+	accountsResponses, accountsRequests := make(chan string, 1), make(chan string, 1)
+	accounts := newUpstream(t, accountsResponses, accountsRequests)
+
+	factory := NewFederationEngineConfigFactory([]graphql_datasource.Configuration{
+		{
+			Fetch: graphql_datasource.FetchConfiguration{
+				URL: reviews.URL,
+			},
+			Federation: graphql_datasource.FederationConfiguration{
+				Enabled:    true,
+				ServiceSDL: `extend type Query { topReviews: [Review] } type Review { body: String! author: User } extend type User @key(fields: "id") { id: ID! @external }`,
+			},
+		},
+		{
+			Fetch: graphql_datasource.FetchConfiguration{
+				URL: accounts.URL,
+			},
+			Federation: graphql_datasource.FederationConfiguration{
+				Enabled:    true,
+				ServiceSDL: `extend type Query { me: User } type User @key(fields: "id") { id: ID! username: String! }`,
+			},
+		},
+	}, graphql_datasource.NewBatchFactory())
+	engineConf, err := factory.EngineV2Configuration()
+	require.NoError(t, err)
+	engineConf.EnableDataLoader(true)
+
+	engineCtx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	engine, err := NewExecutionEngineV2(engineCtx, abstractlogger.NoopLogger, engineConf)
+	require.NoError(t, err)
+
+	execute := func(t *testing.T, options ...ExecutionOptionsV2) string {
+		// the authors of the first and the last review are the same user, its representation is sent once
+		reviewsResponses <- `{"data":{"topReviews":[{"body":"A","author":{"__typename":"User","id":"1"}},{"body":"B","author":{"__typename":"User","id":"2"}},{"body":"C","author":{"__typename":"User","id":"1"}}]}}`
+		accountsResponses <- `{"data":{"_entities":[{"__typename":"User","username":"Me"},null]}}`
+
+		operation := Request{Query: `{ topReviews { body author { username } } }`}
+		resultWriter := NewEngineResultWriter()
+		require.NoError(t, engine.Execute(context.Background(), &operation, &resultWriter, options...))
+		<-reviewsRequests
+		<-accountsRequests
+		return resultWriter.String()
+	}
+
+	t.Run("object of a missing entity is null", func(t *testing.T) {
+		assert.JSONEq(t, `{"data":{"topReviews":[{"body":"A","author":{"username":"Me"}},{"body":"B","author":null},{"body":"C","author":{"username":"Me"}}]}}`,
+			execute(t))
+	})
+
+	t.Run("object of a missing entity is null with an error", func(t *testing.T) {
+		assert.JSONEq(t, `{"errors":[{"message":"entity not found","locations":[{"line":1,"column":21}],"path":["topReviews","1","author"]}],"data":{"topReviews":[{"body":"A","author":{"username":"Me"}},{"body":"B","author":null},{"body":"C","author":{"username":"Me"}}]}}`,
+			execute(t, WithMissingEntityErrors()))
+	})
+}
@@ -11,6 +11,11 @@ type NormalizationResult struct {
 }
 
 func (r *Request) Normalize(schema *Schema) (result NormalizationResult, err error) {
+	return r.normalize(schema)
+}
+
+// normalize normalizes the request with additional options, e.g. astnormalization.WithLazyFragmentExpansion
+func (r *Request) normalize(schema *Schema, opts ...astnormalization.Option) (result NormalizationResult, err error) {
 	if schema == nil {
 		return NormalizationResult{Successful: false, Errors: nil}, ErrNilSchema
 	}
@@ -22,11 +27,11 @@ func (r *Request) Normalize(schema *Schema) (result NormalizationResult, err err
 
 	r.document.Input.Variables = r.Variables
 
-	normalizer := astnormalization.NewWithOpts(
+	normalizer := astnormalization.NewWithOpts(append([]astnormalization.Option{
 		astnormalization.WithExtractVariables(),
 		astnormalization.WithRemoveFragmentDefinitions(),
 		astnormalization.WithRemoveUnusedVariables(),
-	)
+	}, opts...)...)
 
 	if r.OperationName != "" {
 		normalizer.NormalizeNamedOperation(&r.document, &schema.document, []byte(r.OperationName), &report)
@@ -227,6 +227,18 @@ query Search {
     charactersByIds(ids: $ids){
         name
     }
+}`)
+	})
+
+	t.Run("input coercion for lists with variable default value", func(t *testing.T) {
+		schema := inputCoercionForListSchema(t)
+		request := Request{
+			Query: `query($ids: [Int] = 1) {charactersByIds(ids: $ids) { name }}`,
+		}
+		runNormalizationWithSchema(t, schema, &request, `{"ids":[1]}`, `query($ids: [Int]){
+    charactersByIds(ids: $ids){
+        name
+    }
 }`)
 	})
 }
@@ -0,0 +1,47 @@
+package graphql
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/jensneuse/abstractlogger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/wundergraph/graphql-go-tools/pkg/engine/datasource/graphql_datasource"
+)
+
+func TestExecutionEngineV2_NumberTypes(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"data":{"product":{"price":1,"weight":2.50,"rating":-4,"stock":3.0,"sold":12}}}`))
+	}))
+	defer upstream.Close()
+
+	factory := NewFederationEngineConfigFactory([]graphql_datasource.Configuration{
+		{
+			Fetch: graphql_datasource.FetchConfiguration{
+				URL: upstream.URL,
+			},
+			Federation: graphql_datasource.FederationConfiguration{
+				Enabled:    true,
+				ServiceSDL: `extend type Query { product: Product } type Product { price: Float! weight: Float! rating: Float stock: Int! sold: Int }`,
+			},
+		},
+	}, graphql_datasource.NewBatchFactory())
+	engineConf, err := factory.EngineV2Configuration()
+	require.NoError(t, err)
+
+	engineCtx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	engine, err := NewExecutionEngineV2(engineCtx, abstractlogger.NoopLogger, engineConf)
+	require.NoError(t, err)
+
+	operation := Request{Query: `{ product { price weight rating stock sold } }`}
+	resultWriter := NewEngineResultWriter()
+	require.NoError(t, engine.Execute(context.Background(), &operation, &resultWriter))
+	// floats are written with a decimal point, integers without
+	assert.Equal(t, `{"data":{"product":{"price":1.0,"weight":2.50,"rating":-4.0,"stock":3,"sold":12}}}`, resultWriter.String())
+}
@@ -0,0 +1,65 @@
+package graphql
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"hash"
+	"strings"
+
+	"github.com/cespare/xxhash/v2"
+
+	"github.com/wundergraph/graphql-go-tools/pkg/astprinter"
+)
+
+// OperationHasher creates the hashes operations are written into to compute their keys,
+// e.g. the keys of the plan cache or of an allowlist of operations.
+type OperationHasher interface {
+	New() hash.Hash
+}
+
+// OperationHasherFunc is an OperationHasher creating hashes with the function, e.g. sha256.New
+type OperationHasherFunc func() hash.Hash
+
+func (f OperationHasherFunc) New() hash.Hash {
+	return f()
+}
+
+var (
+	// XXHashOperationHasher hashes operations with xxhash. It's fast but not collision resistant,
+	// it's the default for keys which never leave the process, e.g. the keys of the plan cache.
+	XXHashOperationHasher OperationHasher = OperationHasherFunc(func() hash.Hash { return xxhash.New() })
+	// SHA256OperationHasher hashes operations with sha256, the hash clients use for persisted queries.
+	SHA256OperationHasher OperationHasher = OperationHasherFunc(sha256.New)
+)
+
+// OperationKey returns the hex encoded hash of the normalized operation and its name.
+// Other than OperationHash it doesn't depend on the variables, identical operations have the same key regardless of
+// formatting, fragments and whether arguments are inlined or passed as variables.
+// The request must be normalized.
+func (r *Request) OperationKey(hasher OperationHasher) (string, error) {
+	if !r.isNormalized {
+		return "", ErrRequestNotNormalized
+	}
+
+	h := hasher.New()
+	if err := astprinter.Print(&r.document, nil, h); err != nil {
+		return "", err
+	}
+	_, _ = h.Write([]byte(r.OperationName))
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// PersistedQueryHash returns the hash of an automatic persisted query, the hex encoded sha256 hash of the query
+// as sent by the client. It's always sha256 to match the hash computed by clients, regardless of the OperationHasher
+// used for other keys.
+func PersistedQueryHash(query string) string {
+	h := SHA256OperationHasher.New()
+	_, _ = h.Write([]byte(query))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// VerifyPersistedQuery reports whether the sha256 hash sent by a client with an automatic persisted query
+// matches the query, see PersistedQueryHash.
+func VerifyPersistedQuery(query, sha256Hash string) bool {
+	return strings.EqualFold(PersistedQueryHash(query), sha256Hash)
+}
@@ -0,0 +1,70 @@
+package graphql
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequest_OperationKey(t *testing.T) {
+	schema := heroWithArgumentSchema(t)
+
+	operationKey := func(t *testing.T, hasher OperationHasher, request Request) string {
+		result, err := request.Normalize(schema)
+		require.NoError(t, err)
+		require.True(t, result.Successful)
+		key, err := request.OperationKey(hasher)
+		require.NoError(t, err)
+		return key
+	}
+
+	hashers := []struct {
+		name      string
+		hasher    OperationHasher
+		keyLength int
+	}{
+		{name: "xxhash", hasher: XXHashOperationHasher, keyLength: 16},
+		{name: "sha256", hasher: SHA256OperationHasher, keyLength: 64},
+	}
+
+	for _, h := range hashers {
+		h := h
+		t.Run(h.name, func(t *testing.T) {
+			t.Run("is stable after normalization", func(t *testing.T) {
+				key := operationKey(t, h.hasher, Request{Query: `query Hero { hero(name: "Luke") }`})
+				assert.Len(t, key, h.keyLength)
+				assert.Equal(t, key, operationKey(t, h.hasher, Request{Query: "query Hero {\n\thero(name: \"Luke\")\n}"}))
+				assert.Equal(t, key, operationKey(t, h.hasher, Request{Query: `query Hero { ...HeroFields } fragment HeroFields on Query { hero(name: "Luke") }`}))
+				assert.Equal(t, key, operationKey(t, h.hasher, Request{Query: `query Hero($name: String) { hero(name: $name) }`, Variables: []byte(`{"name":"Luke"}`)}))
+			})
+
+			t.Run("operation name changes the key", func(t *testing.T) {
+				assert.NotEqual(t,
+					operationKey(t, h.hasher, Request{Query: `query Hero { hero(name: "Luke") }`}),
+					operationKey(t, h.hasher, Request{Query: `query Villain { hero(name: "Luke") }`}),
+				)
+			})
+		})
+	}
+
+	t.Run("request is not normalized", func(t *testing.T) {
+		request := Request{Query: `{ hero(name: "Luke") }`}
+		_, err := request.OperationKey(XXHashOperationHasher)
+		assert.Equal(t, ErrRequestNotNormalized, err)
+	})
+}
+
+func TestPersistedQueryHash(t *testing.T) {
+	const sha256Hash = "001c3174e099bd72b729d0c0a529ba9f5a740c446e2a6e1d71b283cb84ec3065"
+
+	assert.Equal(t, sha256Hash, PersistedQueryHash(`{ hello }`))
+	assert.True(t, VerifyPersistedQuery(`{ hello }`, sha256Hash))
+	// the hash is calculated from the query as sent by the client
+	assert.False(t, VerifyPersistedQuery(`{hello}`, sha256Hash))
+
+	xxHash := XXHashOperationHasher.New()
+	_, _ = xxHash.Write([]byte(`{ hello }`))
+	assert.False(t, VerifyPersistedQuery(`{ hello }`, hex.EncodeToString(xxHash.Sum(nil))))
+}
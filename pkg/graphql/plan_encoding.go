@@ -0,0 +1,68 @@
+package graphql
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/wundergraph/graphql-go-tools/pkg/engine/plan"
+)
+
+// MarshalPlan plans the operation like Execute and encodes its plan, so it can be persisted and loaded with LoadPlan,
+// e.g. by the other instances of a deployment. Only plans of queries and mutations can be encoded.
+// The data source factories must implement plan.FetchSourceFactory to load the plan again.
+func (e *ExecutionEngineV2) MarshalPlan(ctx context.Context, operation *Request, options ...ExecutionOptionsV2) ([]byte, error) {
+	if err := e.validateOperation(operation); err != nil {
+		return nil, err
+	}
+
+	execContext := e.getExecutionCtx()
+	defer e.putExecutionCtx(execContext)
+
+	execContext.prepare(ctx, operation.Variables, operation.request)
+	for i := range options {
+		options[i](execContext)
+	}
+
+	cachedPlan, err := e.planOperation(ctx, execContext, operation)
+	if err != nil {
+		return nil, err
+	}
+	return plan.MarshalPlan(cachedPlan, e.schemaHash())
+}
+
+// LoadPlan adds the plan of the operation encoded by MarshalPlan to the plan cache, so the operation is executed
+// without planning it. The options must be the ones the plan was encoded with, as they are part of the cache key.
+// Plans encoded for another schema are rejected with plan.ErrPlanSchemaMismatch.
+func (e *ExecutionEngineV2) LoadPlan(ctx context.Context, operation *Request, encodedPlan []byte, options ...ExecutionOptionsV2) error {
+	if err := e.validateOperation(operation); err != nil {
+		return err
+	}
+
+	execContext := e.getExecutionCtx()
+	defer e.putExecutionCtx(execContext)
+
+	execContext.prepare(ctx, operation.Variables, operation.request)
+	for i := range options {
+		options[i](execContext)
+	}
+
+	if err := e.restrictOperationFields(ctx, execContext, operation); err != nil {
+		return err
+	}
+
+	loadedPlan, err := plan.UnmarshalPlan(encodedPlan, e.schemaHash(), e.config.plannerConfig)
+	if err != nil {
+		return err
+	}
+
+	cacheKey, _, err := e.operationPlanCacheKey(execContext, &operation.document, &e.config.schema.document, operation.OperationName)
+	if err != nil {
+		return err
+	}
+	e.executionPlanCache.Add(cacheKey, loadedPlan)
+	return nil
+}
+
+func (e *ExecutionEngineV2) schemaHash() string {
+	return strconv.FormatUint(e.config.schema.Hash(), 10)
+}
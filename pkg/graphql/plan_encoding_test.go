@@ -0,0 +1,87 @@
+package graphql
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+
+	"github.com/jensneuse/abstractlogger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/wundergraph/graphql-go-tools/pkg/engine/datasource/graphql_datasource"
+	"github.com/wundergraph/graphql-go-tools/pkg/engine/plan"
+)
+
+func TestExecutionEngineV2_EncodedPlans(t *testing.T) {
+	reviews := newStaticUpstream(t, `{"data":{"topReviews":[{"body":"A","author":{"__typename":"User","id":"1"}},{"body":"B","author":{"__typename":"User","id":"2"}}]}}`, nil)
+	accounts := newStaticUpstream(t, `{"data":{"_entities":[{"__typename":"User","username":"Me"},{"__typename":"User","username":"You"}]}}`, nil)
+
+	engineCtx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	newEngine := func(t *testing.T, accountsSDL string) *ExecutionEngineV2 {
+		factory := NewFederationEngineConfigFactory([]graphql_datasource.Configuration{
+			{
+				Fetch: graphql_datasource.FetchConfiguration{
+					URL: reviews.URL,
+				},
+				Federation: graphql_datasource.FederationConfiguration{
+					Enabled:    true,
+					ServiceSDL: `extend type Query { topReviews(first: Int): [Review] } type Review { body: String! author: User } extend type User @key(fields: "id") { id: ID! @external }`,
+				},
+			},
+			{
+				Fetch: graphql_datasource.FetchConfiguration{
+					URL: accounts.URL,
+				},
+				Federation: graphql_datasource.FederationConfiguration{
+					Enabled:    true,
+					ServiceSDL: accountsSDL,
+				},
+			},
+		}, graphql_datasource.NewBatchFactory())
+		engineConf, err := factory.EngineV2Configuration()
+		require.NoError(t, err)
+
+		engine, err := NewExecutionEngineV2(engineCtx, abstractlogger.NoopLogger, engineConf)
+		require.NoError(t, err)
+		return engine
+	}
+
+	const accountsSDL = `extend type Query { me: User } type User @key(fields: "id") { id: ID! username: String! }`
+	operation := func() *Request {
+		return &Request{
+			OperationName: "Reviews",
+			Query:         `query Reviews($first: Int) { topReviews(first: $first) { body author { username } } }`,
+			Variables:     []byte(`{"first":2}`),
+		}
+	}
+	execute := func(t *testing.T, engine *ExecutionEngineV2) string {
+		resultWriter := NewEngineResultWriter()
+		require.NoError(t, engine.Execute(context.Background(), operation(), &resultWriter))
+		return resultWriter.String()
+	}
+
+	planningEngine := newEngine(t, accountsSDL)
+	encodedPlan, err := planningEngine.MarshalPlan(context.Background(), operation())
+	require.NoError(t, err)
+
+	t.Run("loaded plan is executed like a fresh plan", func(t *testing.T) {
+		engine := newEngine(t, accountsSDL)
+		require.NoError(t, engine.LoadPlan(context.Background(), operation(), encodedPlan))
+
+		assert.Equal(t, execute(t, planningEngine), execute(t, engine))
+		assert.Equal(t, uint64(0), atomic.LoadUint64(&engine.planCount))
+	})
+
+	t.Run("plan of another schema is rejected", func(t *testing.T) {
+		engine := newEngine(t, `extend type Query { me: User } type User @key(fields: "id") { id: ID! username: String! email: String }`)
+		err := engine.LoadPlan(context.Background(), operation(), encodedPlan)
+		assert.ErrorIs(t, err, plan.ErrPlanSchemaMismatch)
+
+		assert.JSONEq(t, `{"data":{"topReviews":[{"body":"A","author":{"username":"Me"}},{"body":"B","author":{"username":"You"}}]}}`,
+			execute(t, engine))
+		assert.Equal(t, uint64(1), atomic.LoadUint64(&engine.planCount))
+	})
+}
@@ -0,0 +1,74 @@
+package graphql
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jensneuse/abstractlogger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/wundergraph/graphql-go-tools/pkg/engine/datasource/graphql_datasource"
+)
+
+func TestExecutionEngineV2_Provides(t *testing.T) {
+	productsResponses, productsRequests := make(chan string, 1), make(chan string, 1)
+	products := newUpstream(t, productsResponses, productsRequests)
+	reviewsResponses, reviewsRequests := make(chan string, 1), make(chan string, 1)
+	reviews := newUpstream(t, reviewsResponses, reviewsRequests)
+
+	factory := NewFederationEngineConfigFactory([]graphql_datasource.Configuration{
+		{
+			Fetch: graphql_datasource.FetchConfiguration{
+				URL: products.URL,
+			},
+			Federation: graphql_datasource.FederationConfiguration{
+				Enabled:    true,
+				ServiceSDL: `extend type Query { topProducts: [Product] } type Product @key(fields: "upc") { upc: String! name: String! price: Int! }`,
+			},
+		},
+		{
+			Fetch: graphql_datasource.FetchConfiguration{
+				URL: reviews.URL,
+			},
+			Federation: graphql_datasource.FederationConfiguration{
+				Enabled:    true,
+				ServiceSDL: `extend type Query { topReviews: [Review] } type Review { body: String! product: Product! @provides(fields: "name") } extend type Product @key(fields: "upc") { upc: String! @external name: String! @external }`,
+			},
+		},
+	}, graphql_datasource.NewBatchFactory())
+	engineConf, err := factory.EngineV2Configuration()
+	require.NoError(t, err)
+
+	engineCtx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	engine, err := NewExecutionEngineV2(engineCtx, abstractlogger.NoopLogger, engineConf)
+	require.NoError(t, err)
+
+	execute := func(t *testing.T, query string) string {
+		operation := Request{Query: query}
+		resultWriter := NewEngineResultWriter()
+		require.NoError(t, engine.Execute(context.Background(), &operation, &resultWriter))
+		return resultWriter.String()
+	}
+
+	t.Run("provided field is not fetched from its owner", func(t *testing.T) {
+		reviewsResponses <- `{"data":{"topReviews":[{"body":"Great","product":{"name":"Table"}}]}}`
+
+		assert.Equal(t, `{"data":{"topReviews":[{"body":"Great","product":{"name":"Table"}}]}}`, execute(t, `{ topReviews { body product { name } } }`))
+		assert.Contains(t, <-reviewsRequests, `product {name}`)
+		assert.Empty(t, productsRequests)
+	})
+
+	t.Run("fields which are not provided are fetched from their owner", func(t *testing.T) {
+		reviewsResponses <- `{"data":{"topReviews":[{"body":"Great","product":{"name":"Table","__typename":"Product","upc":"1"}}]}}`
+		productsResponses <- `{"data":{"_entities":[{"__typename":"Product","price":10}]}}`
+
+		assert.Equal(t, `{"data":{"topReviews":[{"body":"Great","product":{"name":"Table","price":10}}]}}`, execute(t, `{ topReviews { body product { name price } } }`))
+		<-reviewsRequests
+		productsRequest := <-productsRequests
+		assert.Contains(t, productsRequest, `... on Product {price}`)
+		assert.NotContains(t, productsRequest, `name`)
+	})
+}
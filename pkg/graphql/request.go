@@ -29,8 +29,10 @@ const (
 )
 
 var (
-	ErrEmptyRequest = errors.New("the provided request is empty")
-	ErrNilSchema    = errors.New("the provided schema is nil")
+	ErrEmptyRequest           = errors.New("the provided request is empty")
+	ErrNilSchema              = errors.New("the provided schema is nil")
+	ErrInvalidRequestEncoding = errors.New("the provided request is not encoded as UTF-8")
+	ErrRequestNotNormalized   = errors.New("the provided request is not normalized")
 )
 
 type Request struct {
@@ -46,7 +48,10 @@ type Request struct {
 	validForSchema map[uint64]ValidationResult
 }
 
-func UnmarshalRequest(reader io.Reader, request *Request) error {
+// UnmarshalRequest reads a JSON encoded request.
+// A leading UTF-8 byte order mark is ignored, bodies which are not valid UTF-8 are rejected with ErrInvalidRequestEncoding
+// unless they can be transcoded according to the provided options.
+func UnmarshalRequest(reader io.Reader, request *Request, options ...UnmarshalRequestOption) error {
 	requestBytes, err := ioutil.ReadAll(reader)
 	if err != nil {
 		return err
@@ -56,12 +61,22 @@ func UnmarshalRequest(reader io.Reader, request *Request) error {
 		return ErrEmptyRequest
 	}
 
+	var opts unmarshalRequestOptions
+	for _, option := range options {
+		option(&opts)
+	}
+
+	requestBytes, err = decodeRequestBody(requestBytes, opts)
+	if err != nil {
+		return err
+	}
+
 	return json.Unmarshal(requestBytes, &request)
 }
 
-func UnmarshalHttpRequest(r *http.Request, request *Request) error {
+func UnmarshalHttpRequest(r *http.Request, request *Request, options ...UnmarshalRequestOption) error {
 	request.request.Header = r.Header
-	return UnmarshalRequest(r.Body, request)
+	return UnmarshalRequest(r.Body, request, options...)
 }
 
 func (r *Request) SetHeader(header http.Header) {
@@ -179,24 +194,50 @@ func (r *Request) IsIntrospectionQuery() (result bool, err error) {
 	return true, nil
 }
 
-func (r *Request) OperationType() (OperationType, error) {
+// validateOperationName checks that the operation name selects exactly one operation of the document.
+// Documents with a single operation don't need an operation name.
+func (r *Request) validateOperationName() error {
 	report := r.parseQueryOnce()
 	if report.HasErrors() {
-		return OperationTypeUnknown, report
+		return report
 	}
 
+	var operationCount int
 	for _, rootNode := range r.document.RootNodes {
 		if rootNode.Kind != ast.NodeKindOperationDefinition {
 			continue
 		}
-
-		if r.OperationName != "" && r.document.OperationDefinitionNameString(rootNode.Ref) != r.OperationName {
-			continue
+		operationCount++
+		if r.OperationName != "" && r.document.OperationDefinitionNameString(rootNode.Ref) == r.OperationName {
+			return nil
 		}
+	}
+
+	switch {
+	case operationCount == 0:
+		// documents without operations are rejected by the validation
+		return nil
+	case r.OperationName != "":
+		report.AddExternalError(operationreport.ErrOperationWithProvidedOperationNameNotFound(r.OperationName))
+	case operationCount > 1:
+		report.AddExternalError(operationreport.ErrRequiredOperationNameIsMissing())
+	default:
+		return nil
+	}
+	return RequestErrorsFromOperationReport(report)
+}
+
+func (r *Request) OperationType() (OperationType, error) {
+	report := r.parseQueryOnce()
+	if report.HasErrors() {
+		return OperationTypeUnknown, report
+	}
 
-		opType := r.document.OperationDefinitions[rootNode.Ref].OperationType
-		return OperationType(opType), nil
+	operationRef, ok := r.document.OperationDefinitionRefByName(r.OperationName)
+	if !ok {
+		return OperationTypeUnknown, nil
 	}
 
-	return OperationTypeUnknown, nil
+	opType := r.document.OperationDefinitions[operationRef].OperationType
+	return OperationType(opType), nil
 }
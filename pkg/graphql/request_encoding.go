@@ -0,0 +1,75 @@
+package graphql
+
+import (
+	"bytes"
+	"encoding/binary"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+var (
+	utf8ByteOrderMark              = []byte{0xEF, 0xBB, 0xBF}
+	utf16BigEndianByteOrderMark    = []byte{0xFE, 0xFF}
+	utf16LittleEndianByteOrderMark = []byte{0xFF, 0xFE}
+)
+
+type unmarshalRequestOptions struct {
+	transcodeUTF16 bool
+}
+
+// UnmarshalRequestOption configures how UnmarshalRequest decodes the request body
+type UnmarshalRequestOption func(options *unmarshalRequestOptions)
+
+// WithUTF16Transcoding transcodes request bodies starting with a UTF-16 byte order mark to UTF-8.
+// Without this option such bodies are rejected with ErrInvalidRequestEncoding.
+func WithUTF16Transcoding() UnmarshalRequestOption {
+	return func(options *unmarshalRequestOptions) {
+		options.transcodeUTF16 = true
+	}
+}
+
+// decodeRequestBody returns the UTF-8 encoded body without a leading byte order mark
+func decodeRequestBody(body []byte, options unmarshalRequestOptions) ([]byte, error) {
+	switch {
+	case bytes.HasPrefix(body, utf8ByteOrderMark):
+		body = body[len(utf8ByteOrderMark):]
+	case bytes.HasPrefix(body, utf16BigEndianByteOrderMark) && options.transcodeUTF16:
+		return transcodeUTF16(body[len(utf16BigEndianByteOrderMark):], binary.BigEndian)
+	case bytes.HasPrefix(body, utf16LittleEndianByteOrderMark) && options.transcodeUTF16:
+		return transcodeUTF16(body[len(utf16LittleEndianByteOrderMark):], binary.LittleEndian)
+	}
+
+	if !utf8.Valid(body) {
+		return nil, ErrInvalidRequestEncoding
+	}
+
+	return body, nil
+}
+
+func transcodeUTF16(body []byte, order binary.ByteOrder) ([]byte, error) {
+	if len(body)%2 != 0 {
+		return nil, ErrInvalidRequestEncoding
+	}
+
+	units := make([]uint16, len(body)/2)
+	for i := range units {
+		units[i] = order.Uint16(body[i*2:])
+	}
+
+	out := make([]byte, 0, len(units))
+	for i := 0; i < len(units); i++ {
+		r := rune(units[i])
+		if utf16.IsSurrogate(r) {
+			if i+1 == len(units) {
+				return nil, ErrInvalidRequestEncoding
+			}
+			i++
+			if r = utf16.DecodeRune(r, rune(units[i])); r == utf8.RuneError {
+				return nil, ErrInvalidRequestEncoding
+			}
+		}
+		out = utf8.AppendRune(out, r)
+	}
+
+	return out, nil
+}
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"strings"
 	"testing"
+	"unicode/utf16"
 
 	"github.com/stretchr/testify/assert"
 
@@ -33,6 +34,52 @@ func TestUnmarshalRequest(t *testing.T) {
 		assert.Equal(t, "Hello", request.OperationName)
 		assert.Equal(t, "query Hello { hello }", request.Query)
 	})
+
+	t.Run("should ignore leading byte order mark", func(t *testing.T) {
+		requestBytes := []byte("\xEF\xBB\xBF" + `{"operationName": "Hello", "query": "query Hello { hello }"}`)
+
+		var request Request
+		err := UnmarshalRequest(bytes.NewBuffer(requestBytes), &request)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "Hello", request.OperationName)
+		assert.Equal(t, "query Hello { hello }", request.Query)
+	})
+
+	t.Run("should return error when request is not valid UTF-8", func(t *testing.T) {
+		requestBytes := []byte(`{"query": "query { hello(name: \"` + "\xC3\x28" + `\") }"}`)
+
+		var request Request
+		err := UnmarshalRequest(bytes.NewBuffer(requestBytes), &request)
+
+		assert.Equal(t, ErrInvalidRequestEncoding, err)
+	})
+
+	t.Run("UTF-16 encoded request", func(t *testing.T) {
+		utf16LittleEndian := func(s string) []byte {
+			out := []byte{0xFF, 0xFE}
+			for _, r := range utf16.Encode([]rune(s)) {
+				out = append(out, byte(r), byte(r>>8))
+			}
+			return out
+		}
+		requestBytes := utf16LittleEndian(`{"query": "query { hello(name: \"😀\") }"}`)
+
+		t.Run("should return error without transcoding", func(t *testing.T) {
+			var request Request
+			err := UnmarshalRequest(bytes.NewBuffer(requestBytes), &request)
+
+			assert.Equal(t, ErrInvalidRequestEncoding, err)
+		})
+
+		t.Run("should transcode to UTF-8", func(t *testing.T) {
+			var request Request
+			err := UnmarshalRequest(bytes.NewBuffer(requestBytes), &request, WithUTF16Transcoding())
+
+			assert.NoError(t, err)
+			assert.Equal(t, `query { hello(name: "😀") }`, request.Query)
+		})
+	})
 }
 
 func TestRequest_Print(t *testing.T) {
@@ -262,6 +309,30 @@ func TestRequest_OperationType(t *testing.T) {
 	})
 }
 
+func TestRequest_validateOperationName(t *testing.T) {
+	run := func(query, operationName string, expectedErr string) func(t *testing.T) {
+		return func(t *testing.T) {
+			request := Request{OperationName: operationName, Query: query}
+			err := request.validateOperationName()
+			if expectedErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.EqualError(t, err, expectedErr)
+		}
+	}
+
+	t.Run("single anonymous operation", run("{ hello }", "", ""))
+	t.Run("single named operation without name", run("query Hello { hello }", "", ""))
+	t.Run("selects one of multiple operations", run("query Hello { hello } query World { world }", "World", ""))
+	t.Run("multiple operations without name", run("query Hello { hello } query World { world }", "",
+		"operation name is required when providing multiple operations, locations: [], path: []"))
+	t.Run("unknown operation name", run("query Hello { hello } query World { world }", "Foo",
+		"cannot find an operation with name: Foo, locations: [], path: []"))
+	t.Run("unknown operation name of single operation", run("{ hello }", "Foo",
+		"cannot find an operation with name: Foo, locations: [], path: []"))
+}
+
 const namedIntrospectionQuery = `{"operationName":"IntrospectionQuery","variables":{},"query":"query IntrospectionQuery {\n  __schema {\n    queryType {\n      name\n    }\n    mutationType {\n      name\n    }\n    subscriptionType {\n      name\n    }\n    types {\n      ...FullType\n    }\n    directives {\n      name\n      description\n      locations\n      args {\n        ...InputValue\n      }\n    }\n  }\n}\n\nfragment FullType on __Type {\n  kind\n  name\n  description\n  fields(includeDeprecated: true) {\n    name\n    description\n    args {\n      ...InputValue\n    }\n    type {\n      ...TypeRef\n    }\n    isDeprecated\n    deprecationReason\n  }\n  inputFields {\n    ...InputValue\n  }\n  interfaces {\n    ...TypeRef\n  }\n  enumValues(includeDeprecated: true) {\n    name\n    description\n    isDeprecated\n    deprecationReason\n  }\n  possibleTypes {\n    ...TypeRef\n  }\n}\n\nfragment InputValue on __InputValue {\n  name\n  description\n  type {\n    ...TypeRef\n  }\n  defaultValue\n}\n\nfragment TypeRef on __Type {\n  kind\n  name\n  ofType {\n    kind\n    name\n    ofType {\n      kind\n      name\n      ofType {\n        kind\n        name\n        ofType {\n          kind\n          name\n          ofType {\n            kind\n            name\n            ofType {\n              kind\n              name\n              ofType {\n                kind\n                name\n              }\n            }\n          }\n        }\n      }\n    }\n  }\n}\n"}`
 const singleNamedIntrospectionQueryWithoutOperationName = `{"operationName":"","variables":{},"query":"query IntrospectionQuery {\n  __schema {\n    queryType {\n      name\n    }\n    mutationType {\n      name\n    }\n    subscriptionType {\n      name\n    }\n    types {\n      ...FullType\n    }\n    directives {\n      name\n      description\n      locations\n      args {\n        ...InputValue\n      }\n    }\n  }\n}\n\nfragment FullType on __Type {\n  kind\n  name\n  description\n  fields(includeDeprecated: true) {\n    name\n    description\n    args {\n      ...InputValue\n    }\n    type {\n      ...TypeRef\n    }\n    isDeprecated\n    deprecationReason\n  }\n  inputFields {\n    ...InputValue\n  }\n  interfaces {\n    ...TypeRef\n  }\n  enumValues(includeDeprecated: true) {\n    name\n    description\n    isDeprecated\n    deprecationReason\n  }\n  possibleTypes {\n    ...TypeRef\n  }\n}\n\nfragment InputValue on __InputValue {\n  name\n  description\n  type {\n    ...TypeRef\n  }\n  defaultValue\n}\n\nfragment TypeRef on __Type {\n  kind\n  name\n  ofType {\n    kind\n    name\n    ofType {\n      kind\n      name\n      ofType {\n        kind\n        name\n        ofType {\n          kind\n          name\n          ofType {\n            kind\n            name\n            ofType {\n              kind\n              name\n              ofType {\n                kind\n                name\n              }\n            }\n          }\n        }\n      }\n    }\n  }\n}\n"}`
 const silentIntrospectionQuery = `{"operationName":null,"variables":{},"query":"{\n  __schema {\n    queryType {\n      name\n    }\n    mutationType {\n      name\n    }\n    subscriptionType {\n      name\n    }\n    types {\n      ...FullType\n    }\n    directives {\n      name\n      description\n      locations\n      args {\n        ...InputValue\n      }\n    }\n  }\n}\n\nfragment FullType on __Type {\n  kind\n  name\n  description\n  fields(includeDeprecated: true) {\n    name\n    description\n    args {\n      ...InputValue\n    }\n    type {\n      ...TypeRef\n    }\n    isDeprecated\n    deprecationReason\n  }\n  inputFields {\n    ...InputValue\n  }\n  interfaces {\n    ...TypeRef\n  }\n  enumValues(includeDeprecated: true) {\n    name\n    description\n    isDeprecated\n    deprecationReason\n  }\n  possibleTypes {\n    ...TypeRef\n  }\n}\n\nfragment InputValue on __InputValue {\n  name\n  description\n  type {\n    ...TypeRef\n  }\n  defaultValue\n}\n\nfragment TypeRef on __Type {\n  kind\n  name\n  ofType {\n    kind\n    name\n    ofType {\n      kind\n      name\n      ofType {\n        kind\n        name\n        ofType {\n          kind\n          name\n          ofType {\n            kind\n            name\n            ofType {\n              kind\n              name\n              ofType {\n                kind\n                name\n              }\n            }\n          }\n        }\n      }\n    }\n  }\n}\n"}`
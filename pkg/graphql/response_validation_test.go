@@ -0,0 +1,61 @@
+package graphql
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/jensneuse/abstractlogger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/wundergraph/graphql-go-tools/pkg/engine/datasource/graphql_datasource"
+)
+
+func TestExecutionEngineV2_ResponseValidation(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"data":{"me":{"id":"1","age":"abc","friends":[{"id":"2"},{"id":null}]}}}`))
+	}))
+	defer upstream.Close()
+
+	execute := func(t *testing.T, enableResponseValidation bool) string {
+		factory := NewFederationEngineConfigFactory([]graphql_datasource.Configuration{
+			{
+				Fetch: graphql_datasource.FetchConfiguration{
+					URL: upstream.URL,
+				},
+				Federation: graphql_datasource.FederationConfiguration{
+					Enabled:    true,
+					ServiceSDL: `extend type Query { me: User } type User @key(fields: "id") { id: ID! age: Int! friends: [User!] }`,
+				},
+			},
+		}, graphql_datasource.NewBatchFactory())
+		engineConf, err := factory.EngineV2Configuration()
+		require.NoError(t, err)
+		engineConf.EnableResponseValidation(enableResponseValidation)
+
+		engineCtx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		engine, err := NewExecutionEngineV2(engineCtx, abstractlogger.NoopLogger, engineConf)
+		require.NoError(t, err)
+
+		operation := Request{Query: `{ me { id friends { id } age } }`}
+		resultWriter := NewEngineResultWriter()
+		require.NoError(t, engine.Execute(context.Background(), &operation, &resultWriter))
+		return resultWriter.String()
+	}
+
+	t.Run("violations are added to the extensions", func(t *testing.T) {
+		response := execute(t, true)
+		assert.Contains(t, response, `"extensions":{"responseValidation":[`+
+			`{"path":"/data/me/friends/1/id","message":"expected non-null String, got null"},`+
+			`{"path":"/data/me/age","message":"expected non-null Int, got string \"abc\""}]}`)
+	})
+
+	t.Run("disabled by default", func(t *testing.T) {
+		response := execute(t, false)
+		assert.NotContains(t, response, "responseValidation")
+	})
+}
@@ -0,0 +1,67 @@
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/jensneuse/abstractlogger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/wundergraph/graphql-go-tools/pkg/engine/datasource/graphql_datasource"
+)
+
+func TestExecutionEngineV2_RootTypename(t *testing.T) {
+	accountsRequests := make(chan string, 1)
+	accounts := newStaticUpstream(t, `{"data":{"me":{"username":"Me"}}}`, accountsRequests)
+
+	factory := NewFederationEngineConfigFactory([]graphql_datasource.Configuration{
+		{
+			Fetch: graphql_datasource.FetchConfiguration{
+				URL: accounts.URL,
+			},
+			Federation: graphql_datasource.FederationConfiguration{
+				Enabled:    true,
+				ServiceSDL: `extend type Query { me: User } type User @key(fields: "id") { id: ID! username: String! }`,
+			},
+		},
+	}, graphql_datasource.NewBatchFactory())
+	engineConf, err := factory.EngineV2Configuration()
+	require.NoError(t, err)
+
+	engineCtx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	engine, err := NewExecutionEngineV2(engineCtx, abstractlogger.NoopLogger, engineConf)
+	require.NoError(t, err)
+
+	execute := func(t *testing.T, query, variables string) string {
+		operation := Request{Query: query, Variables: json.RawMessage(variables)}
+		resultWriter := NewEngineResultWriter()
+		require.NoError(t, engine.Execute(context.Background(), &operation, &resultWriter))
+		return resultWriter.String()
+	}
+
+	t.Run("typename of the query type is resolved without fetching", func(t *testing.T) {
+		assert.Equal(t, `{"data":{"__typename":"Query"}}`, execute(t, `{ __typename }`, ""))
+		assert.Empty(t, accountsRequests)
+	})
+
+	t.Run("aliased typenames", func(t *testing.T) {
+		assert.Equal(t, `{"data":{"a":"Query","b":"Query"}}`, execute(t, `{ a: __typename b: __typename }`, ""))
+		assert.Empty(t, accountsRequests)
+	})
+
+	t.Run("typenames with skip and include", func(t *testing.T) {
+		query := `query Typename($skip: Boolean!) { skipped: __typename @skip(if: $skip) included: __typename @include(if: $skip) }`
+		assert.Equal(t, `{"data":{"included":"Query"}}`, execute(t, query, `{"skip":true}`))
+		assert.Equal(t, `{"data":{"skipped":"Query"}}`, execute(t, query, `{"skip":false}`))
+		assert.Empty(t, accountsRequests)
+	})
+
+	t.Run("typename next to a field of a subgraph", func(t *testing.T) {
+		assert.Equal(t, `{"data":{"__typename":"Query","me":{"username":"Me"}}}`, execute(t, `{ __typename me { username } }`, ""))
+		assert.Contains(t, <-accountsRequests, "username")
+	})
+}
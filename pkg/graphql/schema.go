@@ -76,7 +76,20 @@ func NewSchemaFromString(schema string) (*Schema, error) {
 }
 
 func ValidateSchemaString(schema string) (result ValidationResult, err error) {
-	parsedSchema, err := NewSchemaFromString(schema)
+	return ValidateSDL([]byte(schema))
+}
+
+// ValidateSDL validates the SDL of a schema, e.g. of a subgraph before it's loaded, independent of any operation.
+// Syntax errors and violations of the schema rules, e.g. undefined types or objects missing fields of their interfaces,
+// are returned as errors of the result with their locations in the SDL.
+func ValidateSDL(sdl []byte) (result ValidationResult, err error) {
+	parsedSchema, err := createSchema(sdl, true)
+	if report, ok := err.(operationreport.Report); ok && len(report.ExternalErrors) > 0 {
+		return ValidationResult{
+			Valid:  false,
+			Errors: schemaValidationErrorsFromOperationReport(report),
+		}, nil
+	}
 	if err != nil {
 		return ValidationResult{
 			Valid: false,
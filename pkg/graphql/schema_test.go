@@ -12,6 +12,7 @@ import (
 	"github.com/wundergraph/graphql-go-tools/pkg/astprinter"
 	"github.com/wundergraph/graphql-go-tools/pkg/asttransform"
 	"github.com/wundergraph/graphql-go-tools/pkg/engine/plan"
+	"github.com/wundergraph/graphql-go-tools/pkg/graphqlerrors"
 	"github.com/wundergraph/graphql-go-tools/pkg/testing/goldie"
 )
 
@@ -347,6 +348,51 @@ func TestValidateSchemaString(t *testing.T) {
 	))
 }
 
+func TestValidateSDL(t *testing.T) {
+	t.Run("valid schema", func(t *testing.T) {
+		result, err := ValidateSDL([]byte(`
+			interface Node { id: ID! }
+			type User implements Node { id: ID! name: String }
+			type Query { me: User }
+		`))
+		require.NoError(t, err)
+		assert.True(t, result.Valid)
+		assert.Nil(t, result.Errors)
+	})
+
+	t.Run("object missing a field of its interface", func(t *testing.T) {
+		result, err := ValidateSDL([]byte("interface Node {\n  id: ID!\n}\n\ntype User implements Node {\n  name: String\n}\n\ntype Query {\n  me: User\n}\n"))
+		require.NoError(t, err)
+		assert.False(t, result.Valid)
+		assert.Equal(t, SchemaValidationErrors{
+			{
+				Message:   "type 'User' does not implement field 'id' from interface 'Node'",
+				Locations: []graphqlerrors.Location{{Line: 5, Column: 1}},
+			},
+		}, result.Errors)
+	})
+
+	t.Run("undefined type", func(t *testing.T) {
+		result, err := ValidateSDL([]byte("type Query {\n  me: Account\n}\n"))
+		require.NoError(t, err)
+		assert.False(t, result.Valid)
+		assert.Equal(t, SchemaValidationErrors{
+			{
+				Message:   `Unknown type "Account".`,
+				Locations: []graphqlerrors.Location{{Line: 2, Column: 7}},
+			},
+		}, result.Errors)
+	})
+
+	t.Run("syntax error", func(t *testing.T) {
+		result, err := ValidateSDL([]byte("type Query {\n  me: \n"))
+		require.NoError(t, err)
+		assert.False(t, result.Valid)
+		require.Len(t, result.Errors, 1)
+		assert.NotEmpty(t, result.Errors[0].Locations)
+	})
+}
+
 func TestSchema_Validate(t *testing.T) {
 	run := func(schema string, expectedValid bool, expectedValidationErrorCount int) func(t *testing.T) {
 		return func(t *testing.T) {
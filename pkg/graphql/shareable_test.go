@@ -0,0 +1,101 @@
+package graphql
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jensneuse/abstractlogger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/wundergraph/graphql-go-tools/pkg/engine/datasource/graphql_datasource"
+)
+
+func TestExecutionEngineV2_Shareable(t *testing.T) {
+	productsResponses, productsRequests := make(chan string, 1), make(chan string, 1)
+	products := newUpstream(t, productsResponses, productsRequests)
+	reviewsResponses, reviewsRequests := make(chan string, 1), make(chan string, 1)
+	reviews := newUpstream(t, reviewsResponses, reviewsRequests)
+	accountsResponses, accountsRequests := make(chan string, 1), make(chan string, 1)
+	accounts := newUpstream(t, accountsResponses, accountsRequests)
+
+	factory := NewFederationEngineConfigFactory([]graphql_datasource.Configuration{
+		{
+			Fetch: graphql_datasource.FetchConfiguration{
+				URL: products.URL,
+			},
+			Federation: graphql_datasource.FederationConfiguration{
+				Enabled:    true,
+				ServiceSDL: `extend type Query { topProducts: [Product] } type Product @key(fields: "upc") { upc: String! name: String! @shareable price: Int! }`,
+			},
+		},
+		{
+			Fetch: graphql_datasource.FetchConfiguration{
+				URL: reviews.URL,
+			},
+			Federation: graphql_datasource.FederationConfiguration{
+				Enabled:    true,
+				ServiceSDL: `extend type Query { topReviews: [Review] } type Review { body: String! product: Product! } extend type Product @key(fields: "upc") { upc: String! @external name: String! @shareable reviews: [Review] }`,
+			},
+		},
+		{
+			Fetch: graphql_datasource.FetchConfiguration{
+				URL: accounts.URL,
+			},
+			Federation: graphql_datasource.FederationConfiguration{
+				Enabled:    true,
+				ServiceSDL: `extend type Query { me: User } type User @key(fields: "id") { id: ID! favoriteProduct: Product } extend type Product @key(fields: "upc") { upc: String! @external }`,
+			},
+		},
+	}, graphql_datasource.NewBatchFactory())
+	engineConf, err := factory.EngineV2Configuration()
+	require.NoError(t, err)
+
+	engineCtx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	engine, err := NewExecutionEngineV2(engineCtx, abstractlogger.NoopLogger, engineConf)
+	require.NoError(t, err)
+
+	execute := func(t *testing.T, query string) string {
+		operation := Request{Query: query}
+		resultWriter := NewEngineResultWriter()
+		require.NoError(t, engine.Execute(context.Background(), &operation, &resultWriter))
+		return resultWriter.String()
+	}
+
+	t.Run("shareable field is resolved by the subgraph of the enclosing fetch", func(t *testing.T) {
+		reviewsResponses <- `{"data":{"topReviews":[{"body":"Great","product":{"name":"Table"}}]}}`
+
+		assert.Equal(t, `{"data":{"topReviews":[{"body":"Great","product":{"name":"Table"}}]}}`, execute(t, `{ topReviews { body product { name } } }`))
+		assert.Contains(t, <-reviewsRequests, `product {name}`)
+		assert.Empty(t, productsRequests)
+	})
+
+	t.Run("shareable field is resolved by the entity fetch of a sibling field", func(t *testing.T) {
+		for _, query := range []string{
+			`{ me { favoriteProduct { name reviews { body } } } }`,
+			`{ me { favoriteProduct { reviews { body } name } } }`,
+		} {
+			accountsResponses <- `{"data":{"me":{"favoriteProduct":{"__typename":"Product","upc":"1"}}}}`
+			reviewsResponses <- `{"data":{"_entities":[{"__typename":"Product","name":"Table","reviews":[{"body":"Great"}]}]}}`
+
+			assert.JSONEq(t, `{"data":{"me":{"favoriteProduct":{"name":"Table","reviews":[{"body":"Great"}]}}}}`, execute(t, query))
+			<-accountsRequests
+			reviewsRequest := <-reviewsRequests
+			assert.Contains(t, reviewsRequest, `... on Product {`)
+			assert.Contains(t, reviewsRequest, `name`)
+			assert.Empty(t, productsRequests)
+		}
+	})
+
+	t.Run("shareable field without sibling fields is resolved by the first subgraph defining it", func(t *testing.T) {
+		accountsResponses <- `{"data":{"me":{"favoriteProduct":{"__typename":"Product","upc":"1"}}}}`
+		productsResponses <- `{"data":{"_entities":[{"__typename":"Product","name":"Table"}]}}`
+
+		assert.Equal(t, `{"data":{"me":{"favoriteProduct":{"name":"Table"}}}}`, execute(t, `{ me { favoriteProduct { name } } }`))
+		<-accountsRequests
+		assert.Contains(t, <-productsRequests, `... on Product {name}`)
+		assert.Empty(t, reviewsRequests)
+	})
+}
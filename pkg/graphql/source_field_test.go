@@ -0,0 +1,74 @@
+package graphql
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jensneuse/abstractlogger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/wundergraph/graphql-go-tools/pkg/engine/datasource/graphql_datasource"
+)
+
+func TestExecutionEngineV2_SourceField(t *testing.T) {
+	accountsRequests := make(chan string, 1)
+	accounts := newStaticUpstream(t, `{"data":{"me":{"__typename":"User","id":"1","handle":"Me"}}}`, accountsRequests)
+	reviewsRequests := make(chan string, 1)
+	reviews := newStaticUpstream(t, `{"data":{"_entities":[{"__typename":"User","nick":"Nick"}]}}`, reviewsRequests)
+
+	factory := NewFederationEngineConfigFactory([]graphql_datasource.Configuration{
+		{
+			Fetch: graphql_datasource.FetchConfiguration{
+				URL: accounts.URL,
+			},
+			Federation: graphql_datasource.FederationConfiguration{
+				Enabled:    true,
+				ServiceSDL: `extend type Query { me: User } type User @key(fields: "id") { id: ID! handle: String! @sourceField(name: "username") }`,
+			},
+		},
+		{
+			Fetch: graphql_datasource.FetchConfiguration{
+				URL: reviews.URL,
+			},
+			Federation: graphql_datasource.FederationConfiguration{
+				Enabled:    true,
+				ServiceSDL: `extend type User @key(fields: "id") { id: ID! @external nick: String @sourceField(name: "nickname") }`,
+			},
+		},
+	}, graphql_datasource.NewBatchFactory())
+	engineConf, err := factory.EngineV2Configuration()
+	require.NoError(t, err)
+
+	engineCtx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	engine, err := NewExecutionEngineV2(engineCtx, abstractlogger.NoopLogger, engineConf)
+	require.NoError(t, err)
+
+	execute := func(t *testing.T, query string) string {
+		operation := Request{Query: query}
+		resultWriter := NewEngineResultWriter()
+		require.NoError(t, engine.Execute(context.Background(), &operation, &resultWriter))
+		return resultWriter.String()
+	}
+
+	t.Run("root fetch", func(t *testing.T) {
+		assert.Equal(t, `{"data":{"me":{"handle":"Me"}}}`, execute(t, `{ me { handle } }`))
+		assert.Contains(t, <-accountsRequests, `handle: username`)
+	})
+
+	t.Run("entity fetch", func(t *testing.T) {
+		assert.Equal(t, `{"data":{"me":{"handle":"Me","nick":"Nick"}}}`, execute(t, `{ me { handle nick } }`))
+		assert.Contains(t, <-accountsRequests, `handle: username`)
+		assert.Contains(t, <-reviewsRequests, `nick: nickname`)
+	})
+
+	t.Run("public schema", func(t *testing.T) {
+		schema, err := factory.PublicSchema()
+		require.NoError(t, err)
+		assert.Contains(t, string(schema.Input()), "handle: String!")
+		assert.NotContains(t, string(schema.Input()), "username")
+		assert.NotContains(t, string(schema.Input()), "sourceField")
+	})
+}
@@ -0,0 +1,68 @@
+package graphql
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// upstreamTimeout bounds the time an upstream waits for the test to take its request or to give its response,
+// so that an unexpected request fails the test instead of blocking it forever
+const upstreamTimeout = 5 * time.Second
+
+// newUpstream starts a subgraph which sends the body of every request to requests
+// and answers it with the next of the responses. The server is closed once the test is done.
+func newUpstream(t *testing.T, responses <-chan string, requests chan<- string) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, ok := recordUpstreamRequest(t, r, requests)
+		if !ok {
+			http.Error(w, "unexpected request", http.StatusInternalServerError)
+			return
+		}
+		select {
+		case response := <-responses:
+			_, _ = w.Write([]byte(response))
+		case <-time.After(upstreamTimeout):
+			t.Errorf("no response for the upstream request %s", body)
+			http.Error(w, "no response", http.StatusInternalServerError)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// newStaticUpstream starts a subgraph which answers every request with the response.
+// The body of every request is sent to requests unless it's nil. The server is closed once the test is done.
+func newStaticUpstream(t *testing.T, response string, requests chan<- string) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := recordUpstreamRequest(t, r, requests); !ok {
+			http.Error(w, "unexpected request", http.StatusInternalServerError)
+			return
+		}
+		_, _ = w.Write([]byte(response))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// recordUpstreamRequest reads the body of the request and sends it to requests unless it's nil.
+// It runs on the goroutine of the server, so failures are reported with t.Errorf instead of stopping the test.
+func recordUpstreamRequest(t *testing.T, r *http.Request, requests chan<- string) (body string, ok bool) {
+	data, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		t.Errorf("read upstream request: %s", err)
+		return "", false
+	}
+	if requests == nil {
+		return string(data), true
+	}
+	select {
+	case requests <- string(data):
+		return string(data), true
+	case <-time.After(upstreamTimeout):
+		t.Errorf("unexpected upstream request %s", data)
+		return string(data), false
+	}
+}
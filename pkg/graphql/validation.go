@@ -2,6 +2,7 @@ package graphql
 
 import (
 	"github.com/wundergraph/graphql-go-tools/pkg/astvalidation"
+	"github.com/wundergraph/graphql-go-tools/pkg/astvisitor"
 	"github.com/wundergraph/graphql-go-tools/pkg/operationreport"
 )
 
@@ -40,6 +41,66 @@ func (r *Request) ValidateForSchema(schema *Schema) (result ValidationResult, er
 	return result, err
 }
 
+// ValidateVariableTypes only validates that the types of the variables declared by the operations exist in the schema
+// and are input types. It's a fast path to reject a request early and doesn't replace ValidateForSchema.
+func (r *Request) ValidateVariableTypes(schema *Schema) (result ValidationResult, err error) {
+	if schema == nil {
+		return ValidationResult{Valid: false, Errors: nil}, ErrNilSchema
+	}
+
+	report := r.parseQueryOnce()
+	if report.HasErrors() {
+		return operationValidationResultFromReport(report)
+	}
+
+	astvalidation.ValidateVariableTypes(&r.document, &schema.document, &report)
+	return operationValidationResultFromReport(report)
+}
+
+// ValidateVariables validates the values of the variables against the types declared by the variable definitions of the operation.
+// It reports missing required variables, null values for non-null types, values of the wrong type
+// including list items and input object fields, and unknown enum values.
+// Afterwards the values of arguments and their input object fields are validated against
+// the @length and @pattern constraint directives of their definitions.
+func (r *Request) ValidateVariables(schema *Schema) (result ValidationResult, err error) {
+	if schema == nil {
+		return ValidationResult{Valid: false, Errors: nil}, ErrNilSchema
+	}
+
+	report := r.parseQueryOnce()
+	if report.HasErrors() {
+		return operationValidationResultFromReport(report)
+	}
+
+	validator := variablesValidator{
+		operation:  &r.document,
+		definition: &schema.document,
+		variables:  r.Variables,
+	}
+	if errs := validator.validate(r.OperationName); len(errs) > 0 {
+		return ValidationResult{Valid: false, Errors: errs}, nil
+	}
+
+	walker := astvisitor.NewWalker(48)
+	constraints := inputConstraintsVisitor{
+		Walker:        &walker,
+		operation:     &r.document,
+		definition:    &schema.document,
+		operationName: r.OperationName,
+		variables:     r.Variables,
+	}
+	walker.RegisterEnterOperationVisitor(&constraints)
+	walker.RegisterEnterArgumentVisitor(&constraints)
+	walker.Walk(&r.document, &schema.document, &report)
+	if report.HasErrors() {
+		return operationValidationResultFromReport(report)
+	}
+	if len(constraints.errs) > 0 {
+		return ValidationResult{Valid: false, Errors: constraints.errs}, nil
+	}
+	return ValidationResult{Valid: true, Errors: nil}, nil
+}
+
 // ValidateRestrictedFields validates a request by checking if `restrictedFields` contains blocked fields.
 //
 // Deprecated: This function can only handle blocked fields. Use `ValidateFieldRestrictions` if you
@@ -8,6 +8,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/wundergraph/graphql-go-tools/pkg/graphqlerrors"
 	"github.com/wundergraph/graphql-go-tools/pkg/operationreport"
 	"github.com/wundergraph/graphql-go-tools/pkg/starwars"
 )
@@ -85,6 +86,36 @@ func TestRequest_ValidateForSchema(t *testing.T) {
 		assert.Nil(t, result.Errors)
 	})
 
+	t.Run("should return gql errors for empty union selection after normalization", func(t *testing.T) {
+		schema := starwarsSchema(t)
+		request := Request{
+			OperationName: "Search",
+			Query:         `query Search { search(name: "r2") { ... {} } }`,
+		}
+
+		normalizationResult, err := request.Normalize(schema)
+		require.NoError(t, err)
+		require.True(t, normalizationResult.Successful)
+
+		result, err := request.ValidateForSchema(schema)
+		assert.NoError(t, err)
+		assert.False(t, result.Valid)
+		assert.Equal(t, `Field "search" of union type "SearchResult" must select "__typename" or at least one type condition.`, result.Errors.(RequestErrors)[0].Message)
+	})
+
+	t.Run("should return valid result for union selection with type condition", func(t *testing.T) {
+		schema := starwarsSchema(t)
+		request := Request{
+			OperationName: "Search",
+			Query:         `query Search { search(name: "r2") { ... on Droid { name } } }`,
+		}
+
+		result, err := request.ValidateForSchema(schema)
+		assert.NoError(t, err)
+		assert.True(t, result.Valid)
+		assert.Nil(t, result.Errors)
+	})
+
 	t.Run("should return valid result when validation is successful", func(t *testing.T) {
 		schema := starwarsSchema(t)
 		request := requestForQuery(t, starwars.FileSimpleHeroQuery)
@@ -96,6 +127,250 @@ func TestRequest_ValidateForSchema(t *testing.T) {
 	})
 }
 
+func TestRequest_ValidateVariableTypes(t *testing.T) {
+	schema, err := NewSchemaFromString(`
+		schema { query: Query }
+		type Query { hero(episode: Episode, filter: HeroFilter): Hero }
+		type Hero { name: String }
+		enum Episode { NEWHOPE EMPIRE JEDI }
+		input HeroFilter { name: String }
+	`)
+	require.NoError(t, err)
+
+	t.Run("should return error when schema is nil", func(t *testing.T) {
+		request := Request{
+			Query: `query Hero($episode: Episode) { hero(episode: $episode) { name } }`,
+		}
+
+		result, err := request.ValidateVariableTypes(nil)
+		assert.Equal(t, ErrNilSchema, err)
+		assert.Equal(t, ValidationResult{Valid: false, Errors: nil}, result)
+	})
+
+	t.Run("should return valid result for input types", func(t *testing.T) {
+		request := Request{
+			Query: `query Hero($episode: Episode!, $filter: HeroFilter, $names: [String!]) { hero(episode: $episode, filter: $filter) { name } }`,
+		}
+
+		result, err := request.ValidateVariableTypes(schema)
+		assert.NoError(t, err)
+		assert.True(t, result.Valid)
+		assert.Nil(t, result.Errors)
+	})
+
+	t.Run("should return gql errors for unknown variable type", func(t *testing.T) {
+		request := Request{
+			Query: `query Hero($episode: Episod) { hero(episode: $episode) { name } }`,
+		}
+
+		result, err := request.ValidateVariableTypes(schema)
+		assert.NoError(t, err)
+		assert.False(t, result.Valid)
+		require.Equal(t, 1, result.Errors.Count())
+		assert.Equal(t, `Unknown type "Episod".`, result.Errors.(RequestErrors)[0].Message)
+		assert.Equal(t, []graphqlerrors.Location{{Line: 1, Column: 22}}, result.Errors.(RequestErrors)[0].Locations)
+	})
+
+	t.Run("should return gql errors for output variable type", func(t *testing.T) {
+		request := Request{
+			Query: `query Hero($hero: [Hero!]) { hero { name } }`,
+		}
+
+		result, err := request.ValidateVariableTypes(schema)
+		assert.NoError(t, err)
+		assert.False(t, result.Valid)
+		require.Equal(t, 1, result.Errors.Count())
+		assert.Equal(t, `Variable "$hero" cannot be non-input type "[Hero!]".`, result.Errors.(RequestErrors)[0].Message)
+	})
+
+	t.Run("should not validate the selections", func(t *testing.T) {
+		request := Request{
+			Query: `query Villain($episode: Episode) { villain(episode: $episode) { name } }`,
+		}
+
+		result, err := request.ValidateVariableTypes(schema)
+		assert.NoError(t, err)
+		assert.True(t, result.Valid)
+	})
+}
+
+func TestRequest_ValidateVariables(t *testing.T) {
+	schema, err := NewSchemaFromString(`
+		schema { query: Query }
+		type Query { heroes(limit: Int!, episode: Episode, filter: HeroFilter): [Hero] }
+		type Hero { name: String }
+		enum Episode { NEWHOPE EMPIRE JEDI }
+		input HeroFilter { name: String! episodes: [Episode!] }
+	`)
+	require.NoError(t, err)
+
+	validate := func(t *testing.T, variables string) ValidationResult {
+		request := Request{
+			Query:     `query Heroes($limit: Int!, $episode: Episode, $filter: HeroFilter) { heroes(limit: $limit, episode: $episode, filter: $filter) { name } }`,
+			Variables: []byte(variables),
+		}
+		result, err := request.ValidateVariables(schema)
+		require.NoError(t, err)
+		return result
+	}
+
+	t.Run("should return error when schema is nil", func(t *testing.T) {
+		request := Request{
+			Query: `query Heroes($limit: Int!) { heroes(limit: $limit) { name } }`,
+		}
+
+		result, err := request.ValidateVariables(nil)
+		assert.Equal(t, ErrNilSchema, err)
+		assert.Equal(t, ValidationResult{Valid: false, Errors: nil}, result)
+	})
+
+	t.Run("should return valid result for valid Int", func(t *testing.T) {
+		result := validate(t, `{"limit":3}`)
+		assert.True(t, result.Valid)
+		assert.Nil(t, result.Errors)
+	})
+
+	t.Run("should return valid result for valid enum and input object", func(t *testing.T) {
+		result := validate(t, `{"limit":3,"episode":"JEDI","filter":{"name":"Luke","episodes":["NEWHOPE","JEDI"]}}`)
+		assert.True(t, result.Valid)
+		assert.Nil(t, result.Errors)
+	})
+
+	t.Run("should return gql error for string instead of Int", func(t *testing.T) {
+		result := validate(t, `{"limit":"3"}`)
+		assert.False(t, result.Valid)
+		require.Equal(t, 1, result.Errors.Count())
+		assert.Equal(t, `Variable "$limit" got invalid value "3"; Expected type "Int".`, result.Errors.(RequestErrors)[0].Message)
+		assert.Equal(t, []graphqlerrors.Location{{Line: 1, Column: 14}}, result.Errors.(RequestErrors)[0].Locations)
+	})
+
+	t.Run("should return gql error for missing required variable", func(t *testing.T) {
+		result := validate(t, `{"episode":"JEDI"}`)
+		assert.False(t, result.Valid)
+		require.Equal(t, 1, result.Errors.Count())
+		assert.Equal(t, `Variable "$limit" of required type "Int!" was not provided.`, result.Errors.(RequestErrors)[0].Message)
+	})
+
+	t.Run("should return gql error for null value of non-null variable", func(t *testing.T) {
+		result := validate(t, `{"limit":null}`)
+		assert.False(t, result.Valid)
+		require.Equal(t, 1, result.Errors.Count())
+		assert.Equal(t, `Variable "$limit" of non-null type "Int!" must not be null.`, result.Errors.(RequestErrors)[0].Message)
+	})
+
+	t.Run("should return gql error for unknown enum value", func(t *testing.T) {
+		result := validate(t, `{"limit":3,"episode":"CLONES"}`)
+		assert.False(t, result.Valid)
+		require.Equal(t, 1, result.Errors.Count())
+		assert.Equal(t, `Variable "$episode" got invalid value "CLONES"; Value "CLONES" does not exist in "Episode" enum.`, result.Errors.(RequestErrors)[0].Message)
+	})
+
+	t.Run("should return gql error with path of invalid nested value", func(t *testing.T) {
+		result := validate(t, `{"limit":3,"filter":{"name":"Luke","episodes":["JEDI",null]}}`)
+		assert.False(t, result.Valid)
+		require.Equal(t, 1, result.Errors.Count())
+		assert.Equal(t, `Variable "$filter" got invalid value {"name":"Luke","episodes":["JEDI",null]} at "filter.episodes[1]"; Expected non-nullable type "Episode!" not to be null.`, result.Errors.(RequestErrors)[0].Message)
+	})
+
+	t.Run("should return gql errors for missing and unknown input object fields", func(t *testing.T) {
+		result := validate(t, `{"limit":1.5,"filter":{"title":"Luke"}}`)
+		assert.False(t, result.Valid)
+		require.Equal(t, 2, result.Errors.Count())
+		assert.Equal(t, `Variable "$limit" got invalid value 1.5; Expected type "Int".`, result.Errors.(RequestErrors)[0].Message)
+		assert.Equal(t, `Variable "$filter" got invalid value {"title":"Luke"}; Field "name" of required type "String!" was not provided.`, result.Errors.(RequestErrors)[1].Message)
+	})
+
+	t.Run("should coerce single value to list", func(t *testing.T) {
+		result := validate(t, `{"limit":3,"filter":{"name":"Luke","episodes":"JEDI"}}`)
+		assert.True(t, result.Valid)
+	})
+}
+
+func TestRequest_ValidateVariables_InputConstraints(t *testing.T) {
+	schema, err := NewSchemaFromString(`
+		schema { query: Query mutation: Mutation }
+		directive @length(min: Int, max: Int) on ARGUMENT_DEFINITION | INPUT_FIELD_DEFINITION
+		directive @pattern(regex: String!) on ARGUMENT_DEFINITION | INPUT_FIELD_DEFINITION
+		type Query { review(upc: String!): Review }
+		type Mutation {
+			addReview(authorID: String! @pattern(regex: "^[0-9]+$"), upc: String!, review: String! @length(min: 1, max: 10)): Review!
+			addReviews(reviews: [ReviewInput!]! @length(max: 2)): [Review!]!
+		}
+		type Review { body: String! }
+		input ReviewInput { upc: String! body: String! @length(max: 10) }
+	`)
+	require.NoError(t, err)
+
+	validate := func(t *testing.T, query, variables string) ValidationResult {
+		request := Request{
+			Query:     query,
+			Variables: []byte(variables),
+		}
+		result, err := request.ValidateVariables(schema)
+		require.NoError(t, err)
+		return result
+	}
+
+	addReview := `mutation AddReview($authorID: String!, $upc: String!, $review: String!) { addReview(authorID: $authorID, upc: $upc, review: $review) { body } }`
+	addReviews := `mutation AddReviews($reviews: [ReviewInput!]!) { addReviews(reviews: $reviews) { body } }`
+
+	t.Run("should return valid result for review within max length", func(t *testing.T) {
+		result := validate(t, addReview, `{"authorID":"1234","upc":"top-1","review":"A nice hat"}`)
+		assert.True(t, result.Valid)
+		assert.Nil(t, result.Errors)
+	})
+
+	t.Run("should return gql error for review over max length", func(t *testing.T) {
+		result := validate(t, addReview, `{"authorID":"1234","upc":"top-1","review":"A really nice hat"}`)
+		assert.False(t, result.Valid)
+		require.Equal(t, 1, result.Errors.Count())
+		assert.Equal(t, `Value at "addReview.review" must be at most 10 characters long, got 17.`, result.Errors.(RequestErrors)[0].Message)
+		assert.Equal(t, []graphqlerrors.Location{{Line: 1, Column: 117}}, result.Errors.(RequestErrors)[0].Locations)
+	})
+
+	t.Run("should count characters instead of bytes", func(t *testing.T) {
+		result := validate(t, addReview, `{"authorID":"1234","upc":"top-1","review":"Schöner Hut"}`)
+		assert.False(t, result.Valid)
+		require.Equal(t, 1, result.Errors.Count())
+		assert.Equal(t, `Value at "addReview.review" must be at most 10 characters long, got 11.`, result.Errors.(RequestErrors)[0].Message)
+	})
+
+	t.Run("should return gql error for review below min length", func(t *testing.T) {
+		result := validate(t, addReview, `{"authorID":"1234","upc":"top-1","review":""}`)
+		assert.False(t, result.Valid)
+		require.Equal(t, 1, result.Errors.Count())
+		assert.Equal(t, `Value at "addReview.review" must be at least 1 characters long, got 0.`, result.Errors.(RequestErrors)[0].Message)
+	})
+
+	t.Run("should return gql error for value not matching pattern", func(t *testing.T) {
+		result := validate(t, addReview, `{"authorID":"abc","upc":"top-1","review":"A nice hat"}`)
+		assert.False(t, result.Valid)
+		require.Equal(t, 1, result.Errors.Count())
+		assert.Equal(t, `Value at "addReview.authorID" must match pattern "^[0-9]+$".`, result.Errors.(RequestErrors)[0].Message)
+	})
+
+	t.Run("should validate literal argument values", func(t *testing.T) {
+		result := validate(t, `mutation { addReview(authorID: "1234", upc: "top-1", review: "A really nice hat") { body } }`, ``)
+		assert.False(t, result.Valid)
+		require.Equal(t, 1, result.Errors.Count())
+		assert.Equal(t, `Value at "addReview.review" must be at most 10 characters long, got 17.`, result.Errors.(RequestErrors)[0].Message)
+	})
+
+	t.Run("should validate input object fields in lists", func(t *testing.T) {
+		result := validate(t, addReviews, `{"reviews":[{"upc":"top-1","body":"A nice hat"},{"upc":"top-2","body":"A really nice hat"}]}`)
+		assert.False(t, result.Valid)
+		require.Equal(t, 1, result.Errors.Count())
+		assert.Equal(t, `Value at "addReviews.reviews.1.body" must be at most 10 characters long, got 17.`, result.Errors.(RequestErrors)[0].Message)
+	})
+
+	t.Run("should return gql error for list over max length", func(t *testing.T) {
+		result := validate(t, addReviews, `{"reviews":[{"upc":"top-1","body":"1"},{"upc":"top-2","body":"2"},{"upc":"top-3","body":"3"}]}`)
+		assert.False(t, result.Valid)
+		require.Equal(t, 1, result.Errors.Count())
+		assert.Equal(t, `Value at "addReviews.reviews" must be at most 2 items long, got 3.`, result.Errors.(RequestErrors)[0].Message)
+	})
+}
+
 func TestRequest_ValidateRestrictedFields(t *testing.T) {
 	t.Run("should return error when schema is nil", func(t *testing.T) {
 		request := Request{}
@@ -0,0 +1,219 @@
+package graphql
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/buger/jsonparser"
+
+	"github.com/wundergraph/graphql-go-tools/pkg/ast"
+	"github.com/wundergraph/graphql-go-tools/pkg/operationreport"
+)
+
+// variablesValidator validates the JSON values of variables against the types of their variable definitions.
+// Named types are looked up in the schema, values of custom scalars are accepted as is.
+type variablesValidator struct {
+	operation  *ast.Document
+	definition *ast.Document
+	variables  []byte
+	path       []string
+}
+
+func (v *variablesValidator) validate(operationName string) RequestErrors {
+	operationRef, ok := v.operation.OperationDefinitionRefByName(operationName)
+	if !ok {
+		return nil
+	}
+
+	var errs RequestErrors
+	for _, ref := range v.operation.OperationDefinitions[operationRef].VariableDefinitions.Refs {
+		if err := v.validateVariable(ref); err != nil {
+			errs = append(errs, *err)
+		}
+	}
+	return errs
+}
+
+func (v *variablesValidator) validateVariable(ref int) *RequestError {
+	name := v.operation.VariableDefinitionNameString(ref)
+	typeRef := v.operation.VariableDefinitions[ref].Type
+	typeName, _ := v.operation.PrintTypeBytes(typeRef, nil)
+	locations := operationreport.LocationsFromPosition(v.operation.VariableValues[v.operation.VariableDefinitions[ref].VariableValue.Ref].Dollar)
+
+	value, valueType, _, err := jsonparser.Get(v.variables, name)
+	if err == jsonparser.KeyPathNotFoundError {
+		if v.operation.Types[typeRef].TypeKind != ast.TypeKindNonNull || v.operation.VariableDefinitions[ref].DefaultValue.IsDefined {
+			return nil
+		}
+		return &RequestError{
+			Message:   fmt.Sprintf(`Variable "$%s" of required type "%s" was not provided.`, name, typeName),
+			Locations: locations,
+		}
+	}
+	if err != nil {
+		return &RequestError{
+			Message:   fmt.Sprintf(`Variable "$%s" got invalid value; %s`, name, err),
+			Locations: locations,
+		}
+	}
+	if valueType == jsonparser.Null && v.operation.Types[typeRef].TypeKind == ast.TypeKindNonNull {
+		return &RequestError{
+			Message:   fmt.Sprintf(`Variable "$%s" of non-null type "%s" must not be null.`, name, typeName),
+			Locations: locations,
+		}
+	}
+
+	v.path = append(v.path[:0], name)
+	reason := v.validateValue(v.operation, typeRef, value, valueType)
+	if reason == "" {
+		return nil
+	}
+
+	at := ""
+	if len(v.path) > 1 {
+		at = fmt.Sprintf(` at "%s"`, strings.Join(v.path, ""))
+	}
+	return &RequestError{
+		Message:   fmt.Sprintf(`Variable "$%s" got invalid value %s%s; %s`, name, printJSONValue(value, valueType), at, reason),
+		Locations: locations,
+	}
+}
+
+// validateValue returns the reason why value isn't valid for the type, or an empty string if it's valid.
+// On failure the path points to the invalid value.
+func (v *variablesValidator) validateValue(document *ast.Document, typeRef int, value []byte, valueType jsonparser.ValueType) string {
+	if valueType == jsonparser.Null {
+		if document.Types[typeRef].TypeKind == ast.TypeKindNonNull {
+			typeName, _ := document.PrintTypeBytes(typeRef, nil)
+			return fmt.Sprintf(`Expected non-nullable type "%s" not to be null.`, typeName)
+		}
+		return ""
+	}
+
+	switch document.Types[typeRef].TypeKind {
+	case ast.TypeKindNonNull:
+		return v.validateValue(document, document.Types[typeRef].OfType, value, valueType)
+	case ast.TypeKindList:
+		if valueType != jsonparser.Array {
+			// a single value is coerced to a list of one item
+			return v.validateValue(document, document.Types[typeRef].OfType, value, valueType)
+		}
+		var (
+			index  int
+			reason string
+		)
+		_, _ = jsonparser.ArrayEach(value, func(item []byte, itemType jsonparser.ValueType, offset int, err error) {
+			defer func() { index++ }()
+			if reason != "" {
+				return
+			}
+			v.path = append(v.path, "["+strconv.Itoa(index)+"]")
+			if reason = v.validateValue(document, document.Types[typeRef].OfType, item, itemType); reason == "" {
+				v.path = v.path[:len(v.path)-1]
+			}
+		})
+		return reason
+	}
+
+	typeName := document.TypeNameBytes(typeRef)
+	node, ok := v.definition.Index.FirstNodeByNameBytes(typeName)
+	if !ok {
+		return fmt.Sprintf(`Unknown type "%s".`, typeName)
+	}
+
+	switch node.Kind {
+	case ast.NodeKindScalarTypeDefinition:
+		if !isValidScalarValue(typeName.String(), value, valueType) {
+			return fmt.Sprintf(`Expected type "%s".`, typeName)
+		}
+	case ast.NodeKindEnumTypeDefinition:
+		if valueType != jsonparser.String {
+			return fmt.Sprintf(`Expected type "%s".`, typeName)
+		}
+		if !v.definition.EnumTypeDefinitionContainsEnumValue(node.Ref, value) {
+			return fmt.Sprintf(`Value "%s" does not exist in "%s" enum.`, value, typeName)
+		}
+	case ast.NodeKindInputObjectTypeDefinition:
+		if valueType != jsonparser.Object {
+			return fmt.Sprintf(`Expected type "%s" to be an object.`, typeName)
+		}
+		return v.validateInputObject(node.Ref, value)
+	default:
+		return fmt.Sprintf(`Expected input type, got "%s".`, typeName)
+	}
+	return ""
+}
+
+func (v *variablesValidator) validateInputObject(ref int, value []byte) string {
+	typeName := v.definition.InputObjectTypeDefinitionNameString(ref)
+	fields := v.definition.InputObjectTypeDefinitions[ref].InputFieldsDefinition.Refs
+
+	for _, fieldRef := range fields {
+		fieldName := v.definition.InputValueDefinitionNameString(fieldRef)
+		fieldTypeRef := v.definition.InputValueDefinitions[fieldRef].Type
+
+		fieldValue, fieldValueType, _, err := jsonparser.Get(value, fieldName)
+		if err == jsonparser.KeyPathNotFoundError {
+			if v.definition.Types[fieldTypeRef].TypeKind == ast.TypeKindNonNull && !v.definition.InputValueDefinitionHasDefaultValue(fieldRef) {
+				fieldTypeName, _ := v.definition.PrintTypeBytes(fieldTypeRef, nil)
+				return fmt.Sprintf(`Field "%s" of required type "%s" was not provided.`, fieldName, fieldTypeName)
+			}
+			continue
+		}
+		if err != nil {
+			return err.Error()
+		}
+
+		v.path = append(v.path, "."+fieldName)
+		if reason := v.validateValue(v.definition, fieldTypeRef, fieldValue, fieldValueType); reason != "" {
+			return reason
+		}
+		v.path = v.path[:len(v.path)-1]
+	}
+
+	var reason string
+	_ = jsonparser.ObjectEach(value, func(key []byte, _ []byte, _ jsonparser.ValueType, _ int) error {
+		if reason == "" && v.definition.InputObjectTypeDefinitionInputValueDefinitionByName(ref, key) == ast.InvalidRef {
+			reason = fmt.Sprintf(`Field "%s" is not defined by type "%s".`, key, typeName)
+		}
+		return nil
+	})
+	return reason
+}
+
+// isValidScalarValue validates values of the built-in scalars, values of custom scalars are always valid
+func isValidScalarValue(typeName string, value []byte, valueType jsonparser.ValueType) bool {
+	switch typeName {
+	case "Int":
+		return valueType == jsonparser.Number && isInt32(value)
+	case "Float":
+		return valueType == jsonparser.Number
+	case "String":
+		return valueType == jsonparser.String
+	case "Boolean":
+		return valueType == jsonparser.Boolean
+	case "ID":
+		return valueType == jsonparser.String || valueType == jsonparser.Number && !bytes.ContainsAny(value, ".eE")
+	default:
+		return true
+	}
+}
+
+func isInt32(value []byte) bool {
+	number, err := strconv.ParseFloat(string(value), 64)
+	if err != nil {
+		return false
+	}
+	return number == math.Trunc(number) && number >= math.MinInt32 && number <= math.MaxInt32
+}
+
+func printJSONValue(value []byte, valueType jsonparser.ValueType) string {
+	if valueType == jsonparser.String {
+		// jsonparser strips the quotes of string values
+		return `"` + string(value) + `"`
+	}
+	return string(value)
+}
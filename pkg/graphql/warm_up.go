@@ -0,0 +1,64 @@
+package graphql
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+)
+
+// WarmUpError is the error of an operation which couldn't be warmed up
+type WarmUpError struct {
+	// Index is the position of the operation in the operations passed to WarmUp.
+	Index         int
+	OperationName string
+	Err           error
+}
+
+func (e WarmUpError) Error() string {
+	if e.OperationName == "" {
+		return fmt.Sprintf("operation %d: %s", e.Index, e.Err)
+	}
+	return fmt.Sprintf("operation %d (%s): %s", e.Index, e.OperationName, e.Err)
+}
+
+func (e WarmUpError) Unwrap() error {
+	return e.Err
+}
+
+// WarmUp parses, normalizes, validates and plans the operations without executing them, so their plans are cached
+// before the first request, e.g. the persisted queries of clients at startup.
+// Every operation is a GraphQL request body, see UnmarshalRequest. The variables of the operations aren't validated
+// as the values of later requests aren't known yet. The options apply like for Execute, e.g. WithDeniedFields.
+// It returns the errors of the operations which failed, e.g. because they select a field which was removed,
+// so a deployment can be rejected if one of its critical operations can't be planned anymore.
+func (e *ExecutionEngineV2) WarmUp(ctx context.Context, operations [][]byte, options ...ExecutionOptionsV2) []WarmUpError {
+	var errs []WarmUpError
+	for i := range operations {
+		var operation Request
+		if err := UnmarshalRequest(bytes.NewReader(operations[i]), &operation); err != nil {
+			errs = append(errs, WarmUpError{Index: i, Err: err})
+			continue
+		}
+		if err := e.warmUpOperation(ctx, &operation, options...); err != nil {
+			errs = append(errs, WarmUpError{Index: i, OperationName: operation.OperationName, Err: err})
+		}
+	}
+	return errs
+}
+
+func (e *ExecutionEngineV2) warmUpOperation(ctx context.Context, operation *Request, options ...ExecutionOptionsV2) error {
+	if err := e.validateOperation(operation); err != nil {
+		return err
+	}
+
+	execContext := e.getExecutionCtx()
+	defer e.putExecutionCtx(execContext)
+
+	execContext.prepare(ctx, operation.Variables, operation.request)
+	for i := range options {
+		options[i](execContext)
+	}
+
+	_, err := e.planOperation(ctx, execContext, operation)
+	return err
+}
@@ -1,6 +1,7 @@
 package graphqljsonschema
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
@@ -213,8 +214,11 @@ func MustNewValidatorFromString(schema string) *Validator {
 }
 
 func (v *Validator) Validate(ctx context.Context, inputJSON []byte) error {
+	// numbers are decoded as json.Number, large integers and precise decimals would lose precision as float64
+	decoder := json.NewDecoder(bytes.NewReader(inputJSON))
+	decoder.UseNumber()
 	var value interface{}
-	if err := json.Unmarshal(inputJSON, &value); err != nil {
+	if err := decoder.Decode(&value); err != nil {
 		return err
 	}
 	if err := v.schema.Validate(value); err != nil {
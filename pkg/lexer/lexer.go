@@ -25,9 +25,13 @@ func (l *Lexer) Read() (tok token.Token) {
 	for {
 		tok.SetStart(l.input.InputPosition, l.input.TextPosition)
 		next = l.readRune()
-		if !l.byteIsWhitespace(next) {
-			break
+		if l.byteIsWhitespace(next) {
+			continue
+		}
+		if next == runes.BOM[0] && l.skipByteOrderMark() {
+			continue
 		}
+		break
 	}
 
 	if l.matchSingleRuneToken(next, &tok) {
@@ -323,6 +327,22 @@ func runeIsDigit(r byte) bool {
 	}
 }
 
+// skipByteOrderMark skips the remaining bytes of a UTF-8 encoded UnicodeBOM after its first byte has been read.
+// The GraphQL spec treats the BOM as an ignored token, e.g. a document saved by an editor might start with it.
+func (l *Lexer) skipByteOrderMark() bool {
+	end := l.input.InputPosition + len(runes.BOM) - 1
+	if end > l.input.Length {
+		return false
+	}
+	for i := 1; i < len(runes.BOM); i++ {
+		if l.input.RawBytes[l.input.InputPosition+i-1] != runes.BOM[i] {
+			return false
+		}
+	}
+	l.input.InputPosition = end
+	return true
+}
+
 func (l *Lexer) byteIsWhitespace(r byte) bool {
 	switch r {
 	case runes.SPACE, runes.TAB, runes.CARRIAGERETURN, runes.LINETERMINATOR, runes.COMMA:
@@ -92,6 +92,9 @@ func TestLexer_Peek_Read(t *testing.T) {
 	t.Run("peek whitespace length with comma", func(t *testing.T) {
 		run("   ,foo", mustPeekWhitespaceLength(4))
 	})
+	t.Run("read ignoring leading byte order mark", func(t *testing.T) {
+		run("\xEF\xBB\xBFquery {}", mustRead(keyword.IDENT, "query"), mustRead(keyword.LBRACE, "{"), mustRead(keyword.RBRACE, "}"), mustRead(keyword.EOF, ""))
+	})
 	t.Run("read correct when resetting input", func(t *testing.T) {
 		run("x",
 			mustRead(keyword.IDENT, "x"),
@@ -100,7 +100,10 @@ var (
 	OP                            = []byte("op")
 	REPLACE                       = []byte("replace")
 	INITIAL_BATCH_SIZE            = []byte("initialBatchSize")
+	INITIAL_COUNT                 = []byte("initialCount")
+	LABEL                         = []byte("label")
 	MILLISECONDS                  = []byte("milliSeconds")
+	MS                            = []byte("ms")
 	PATH                          = []byte("path")
 	VALUE                         = []byte("value")
 	HTTP_METHOD_GET               = []byte("GET")
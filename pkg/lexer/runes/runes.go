@@ -33,3 +33,6 @@ const (
 	LBRACE = '{'
 	RBRACE = '}'
 )
+
+// BOM is the UTF-8 encoding of the UnicodeBOM (U+FEFF) which is ignored in GraphQL documents
+var BOM = []byte{0xEF, 0xBB, 0xBF}
@@ -27,6 +27,9 @@ const (
 	UnknownFieldOfInputObjectErrMsg         = `Field "%s" is not defined by type "%s".`
 	DuplicatedFieldInputObjectErrMsg        = `There can be only one input field named "%s".`
 	ValueIsNotAnInputObjectTypeErrMsg       = `Expected value of type "%s", found %s.`
+	MissingSubselectionErrMsg               = `Field "%s" of type "%s" must have a selection of subfields. Did you mean "%s { ... }"?`
+	NoSubselectionAllowedErrMsg             = `Field "%s" must not have a selection since type "%s" has no subfields.`
+	MissingUnionTypeConditionErrMsg         = `Field "%s" of union type "%s" must select "__typename" or at least one type condition.`
 )
 
 type ExternalError struct {
@@ -49,8 +52,9 @@ func ErrDocumentDoesntContainExecutableOperation() (err ExternalError) {
 	return
 }
 
-func ErrFieldUndefinedOnType(fieldName, typeName ast.ByteSlice) (err ExternalError) {
+func ErrFieldUndefinedOnType(fieldName, typeName ast.ByteSlice, position position.Position) (err ExternalError) {
 	err.Message = fmt.Sprintf("field: %s not defined on type: %s", fieldName, typeName)
+	err.Locations = LocationsFromPosition(position)
 	return err
 }
 
@@ -64,6 +68,13 @@ func ErrTypeUndefined(typeName ast.ByteSlice) (err ExternalError) {
 	return err
 }
 
+// ErrReferencedTypeUndefined is ErrTypeUndefined at the position of the reference of the type
+func ErrReferencedTypeUndefined(typeName ast.ByteSlice, position position.Position) (err ExternalError) {
+	err.Message = fmt.Sprintf(UnknownTypeErrMsg, typeName)
+	err.Locations = LocationsFromPosition(position)
+	return err
+}
+
 func ErrScalarTypeUndefined(scalarName ast.ByteSlice) (err ExternalError) {
 	err.Message = fmt.Sprintf("scalar not defined: %s", scalarName)
 	return err
@@ -119,6 +130,11 @@ func ErrSubscriptionMustOnlyHaveOneRootSelection(subscriptionName ast.ByteSlice)
 	return err
 }
 
+func ErrOperationExceedsMaxRootFields(operationName ast.ByteSlice, rootFields, maxRootFields int) (err ExternalError) {
+	err.Message = fmt.Sprintf("operation: %s selects %d root fields, at most %d root fields are allowed", operationName, rootFields, maxRootFields)
+	return err
+}
+
 func ErrFieldSelectionOnUnion(fieldName, unionName ast.ByteSlice) (err ExternalError) {
 
 	err.Message = fmt.Sprintf("cannot select field: %s on union: %s", fieldName, unionName)
@@ -155,6 +171,24 @@ func ErrMissingFieldSelectionOnNonScalar(fieldName, enclosingTypeName ast.ByteSl
 	return err
 }
 
+func ErrMissingSubselectionOnField(fieldName, printedFieldType ast.ByteSlice, position position.Position) (err ExternalError) {
+	err.Message = fmt.Sprintf(MissingSubselectionErrMsg, fieldName, printedFieldType, fieldName)
+	err.Locations = LocationsFromPosition(position)
+	return err
+}
+
+func ErrNoSubselectionAllowedOnField(fieldName, leafTypeName ast.ByteSlice, position position.Position) (err ExternalError) {
+	err.Message = fmt.Sprintf(NoSubselectionAllowedErrMsg, fieldName, leafTypeName)
+	err.Locations = LocationsFromPosition(position)
+	return err
+}
+
+func ErrMissingTypeConditionOnUnionField(fieldName, unionTypeName ast.ByteSlice, position position.Position) (err ExternalError) {
+	err.Message = fmt.Sprintf(MissingUnionTypeConditionErrMsg, fieldName, unionTypeName)
+	err.Locations = LocationsFromPosition(position)
+	return err
+}
+
 func ErrArgumentNotDefinedOnDirective(argName, directiveName ast.ByteSlice, position position.Position) (err ExternalError) {
 	err.Message = fmt.Sprintf(UnknownArgumentOnDirectiveErrMsg, argName, directiveName)
 	err.Locations = LocationsFromPosition(position)
@@ -431,13 +465,15 @@ func ErrTransitiveInterfaceExtensionImplementingWithoutBody(interfaceExtensionNa
 	return err
 }
 
-func ErrTypeDoesNotImplementFieldFromInterface(typeName, interfaceName, fieldName ast.ByteSlice) (err ExternalError) {
+func ErrTypeDoesNotImplementFieldFromInterface(typeName, interfaceName, fieldName ast.ByteSlice, position position.Position) (err ExternalError) {
 	err.Message = fmt.Sprintf("type '%s' does not implement field '%s' from interface '%s'", typeName, fieldName, interfaceName)
+	err.Locations = LocationsFromPosition(position)
 	return err
 }
 
-func ErrImplementingTypeDoesNotHaveFields(typeName ast.ByteSlice) (err ExternalError) {
+func ErrImplementingTypeDoesNotHaveFields(typeName ast.ByteSlice, position position.Position) (err ExternalError) {
 	err.Message = fmt.Sprintf("type '%s' implements an interface but does not have any fields defined", typeName)
+	err.Locations = LocationsFromPosition(position)
 	return err
 }
 
@@ -446,6 +482,11 @@ func ErrSharedTypesMustBeIdenticalToFederate(typeName string) (err ExternalError
 	return err
 }
 
+func ErrFieldsMustHaveIdenticalTypesToFederate(typeName, fieldName, subgraph, fieldType, otherSubgraph, otherFieldType string) (err ExternalError) {
+	err.Message = fmt.Sprintf("the field '%s.%s' must have the same type in any subgraphs to federate, it's '%s' in the subgraph '%s' and '%s' in the subgraph '%s'", typeName, fieldName, fieldType, subgraph, otherFieldType, otherSubgraph)
+	return err
+}
+
 func ErrEntitiesMustNotBeDuplicated(typeName string) (err ExternalError) {
 	err.Message = fmt.Sprintf("the entity named '%s' is defined in the subgraph(s) more than once", typeName)
 	return err
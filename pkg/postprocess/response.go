@@ -0,0 +1,145 @@
+package postprocess
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/buger/jsonparser"
+)
+
+// Response is the structured GraphQL response passed through a ResponsePipeline.
+// Fields which are not part of the response are nil, post processors may set or remove them.
+type Response struct {
+	Errors     json.RawMessage
+	Data       json.RawMessage
+	Extensions json.RawMessage
+}
+
+// ResponsePostProcessor transforms a resolved response, e.g. to mask fields, add extensions or omit null values.
+type ResponsePostProcessor interface {
+	ProcessResponse(ctx context.Context, response *Response) error
+}
+
+// ResponsePostProcessorFunc is an adapter to use a plain function as ResponsePostProcessor
+type ResponsePostProcessorFunc func(ctx context.Context, response *Response) error
+
+func (f ResponsePostProcessorFunc) ProcessResponse(ctx context.Context, response *Response) error {
+	return f(ctx, response)
+}
+
+// Orders of the built-in response post processors, custom post processors are registered in between,
+// e.g. with ResponseOrderExtensions+1 to run on the response including the extensions but before null omission.
+const (
+	ResponseOrderFieldMasking = 100
+	ResponseOrderDirectives   = 200
+	ResponseOrderExtensions   = 300
+	ResponseOrderNullOmission = 400
+)
+
+type orderedResponsePostProcessor struct {
+	order     int
+	processor ResponsePostProcessor
+}
+
+// ResponsePipeline runs response post processors in ascending order.
+// Post processors registered with the same order run in the order of registration.
+type ResponsePipeline struct {
+	processors []orderedResponsePostProcessor
+}
+
+func NewResponsePipeline() *ResponsePipeline {
+	return &ResponsePipeline{}
+}
+
+// Register adds a post processor to the pipeline, it runs after all post processors with a lower or equal order
+func (p *ResponsePipeline) Register(order int, processor ResponsePostProcessor) *ResponsePipeline {
+	p.processors = append(p.processors, orderedResponsePostProcessor{
+		order:     order,
+		processor: processor,
+	})
+	sort.SliceStable(p.processors, func(i, j int) bool {
+		return p.processors[i].order < p.processors[j].order
+	})
+	return p
+}
+
+// With returns a copy of the pipeline with the post processor added, the pipeline itself is unchanged.
+// It can be called on a nil pipeline.
+func (p *ResponsePipeline) With(order int, processor ResponsePostProcessor) *ResponsePipeline {
+	pipeline := &ResponsePipeline{}
+	if p != nil {
+		pipeline.processors = append(make([]orderedResponsePostProcessor, 0, len(p.processors)+1), p.processors...)
+	}
+	return pipeline.Register(order, processor)
+}
+
+// Empty reports whether the pipeline has no post processors, it's true for a nil pipeline
+func (p *ResponsePipeline) Empty() bool {
+	return p == nil || len(p.processors) == 0
+}
+
+// Process runs all post processors on the response and returns the transformed response
+func (p *ResponsePipeline) Process(ctx context.Context, response []byte) ([]byte, error) {
+	if p.Empty() {
+		return response, nil
+	}
+
+	structured, err := parseResponse(response)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range p.processors {
+		if err := p.processors[i].processor.ProcessResponse(ctx, structured); err != nil {
+			return nil, err
+		}
+	}
+
+	return structured.marshal(), nil
+}
+
+func parseResponse(response []byte) (*Response, error) {
+	structured := &Response{}
+	err := jsonparser.ObjectEach(response, func(key []byte, value []byte, dataType jsonparser.ValueType, offset int) error {
+		if dataType == jsonparser.String {
+			// jsonparser strips the quotes of string values
+			value = response[offset-len(value)-2 : offset]
+		}
+		switch string(key) {
+		case "errors":
+			structured.Errors = value
+		case "data":
+			structured.Data = value
+		case "extensions":
+			structured.Extensions = value
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("parse response: %w", err)
+	}
+	return structured, nil
+}
+
+func (r *Response) marshal() []byte {
+	buf := &bytes.Buffer{}
+	buf.WriteByte('{')
+	writeField := func(name string, value json.RawMessage) {
+		if value == nil {
+			return
+		}
+		if buf.Len() > 1 {
+			buf.WriteByte(',')
+		}
+		buf.WriteString(`"` + name + `":`)
+		buf.Write(value)
+	}
+	writeField("errors", r.Errors)
+	writeField("data", r.Data)
+	writeField("extensions", r.Extensions)
+	buf.WriteByte('}')
+	return buf.Bytes()
+}
@@ -0,0 +1,366 @@
+package postprocess
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+
+	"github.com/buger/jsonparser"
+
+	"github.com/wundergraph/graphql-go-tools/pkg/ast"
+	"github.com/wundergraph/graphql-go-tools/pkg/lexer/literal"
+)
+
+type responseOperationKey struct{}
+
+type responseOperation struct {
+	operation, definition *ast.Document
+	operationName         string
+}
+
+// WithResponseOperation adds the normalized document of the operation the response is resolved for, the name selecting
+// the operation of the document and the schema to the context passed to the ResponsePipeline.
+// Post processors selecting fields by type or directive skip responses without it.
+func WithResponseOperation(ctx context.Context, operation, definition *ast.Document, operationName string) context.Context {
+	return context.WithValue(ctx, responseOperationKey{}, responseOperation{operation: operation, definition: definition, operationName: operationName})
+}
+
+func responseOperationFromContext(ctx context.Context) (responseOperation, bool) {
+	operation, ok := ctx.Value(responseOperationKey{}).(responseOperation)
+	return operation, ok && operation.operation != nil && operation.definition != nil
+}
+
+// FieldCoordinate is the name of a field and the name of its enclosing type, e.g. User.email
+type FieldCoordinate struct {
+	TypeName  string
+	FieldName string
+}
+
+// MaskFields replaces the non-null values of the fields with mask, e.g. with "***" to hide sensitive values.
+// A field selected on an interface is masked if the field of the interface or of the __typename of the object matches.
+// It should be registered with ResponseOrderFieldMasking.
+func MaskFields(mask json.RawMessage, fields ...FieldCoordinate) ResponsePostProcessor {
+	masked := make(map[FieldCoordinate]struct{}, len(fields))
+	for _, field := range fields {
+		masked[field] = struct{}{}
+	}
+	return ResponsePostProcessorFunc(func(ctx context.Context, response *Response) error {
+		return rewriteResponseFields(ctx, response, func(operation *ast.Document, field responseField, value []byte, valueType jsonparser.ValueType) ([]byte, error) {
+			if valueType == jsonparser.Null {
+				return value, nil
+			}
+			fieldName := operation.FieldNameString(field.ref)
+			for _, typeName := range field.typeNames {
+				if _, ok := masked[FieldCoordinate{TypeName: typeName, FieldName: fieldName}]; ok {
+					return mask, nil
+				}
+			}
+			return value, nil
+		})
+	})
+}
+
+// ResponseDirectiveHandler transforms the value of a field selected with the directive the handler is registered for
+type ResponseDirectiveHandler func(ctx context.Context, value json.RawMessage) (json.RawMessage, error)
+
+// HandleDirectives runs the handler of a directive on the value of every field selected with the directive,
+// e.g. to format values on request of the client. The directives must be defined in the schema.
+// Handlers of multiple directives of a field run in the order of the directives.
+// It should be registered with ResponseOrderDirectives.
+func HandleDirectives(handlers map[string]ResponseDirectiveHandler) ResponsePostProcessor {
+	return ResponsePostProcessorFunc(func(ctx context.Context, response *Response) error {
+		return rewriteResponseFields(ctx, response, func(operation *ast.Document, field responseField, value []byte, valueType jsonparser.ValueType) ([]byte, error) {
+			if !operation.FieldHasDirectives(field.ref) {
+				return value, nil
+			}
+			for _, directiveRef := range operation.Fields[field.ref].Directives.Refs {
+				handler, ok := handlers[operation.DirectiveNameString(directiveRef)]
+				if !ok {
+					continue
+				}
+				handled, err := handler(ctx, value)
+				if err != nil {
+					return nil, err
+				}
+				value = handled
+			}
+			return value, nil
+		})
+	})
+}
+
+// Extensions adds the extensions returned by extensions to the extensions of the response,
+// e.g. the extensions collected by the resolver, see resolve.Context.ResponseExtensions.
+// Extensions of the response with the same key are replaced. It should be registered with ResponseOrderExtensions.
+func Extensions(extensions func() ([]byte, error)) ResponsePostProcessor {
+	return ResponsePostProcessorFunc(func(ctx context.Context, response *Response) error {
+		added, err := extensions()
+		if err != nil || len(added) == 0 {
+			return err
+		}
+		if len(response.Extensions) == 0 || bytes.Equal(response.Extensions, literal.NULL) {
+			response.Extensions = added
+			return nil
+		}
+
+		merged := append([]byte(nil), response.Extensions...)
+		err = jsonparser.ObjectEach(added, func(key []byte, value []byte, valueType jsonparser.ValueType, offset int) error {
+			var setErr error
+			merged, setErr = jsonparser.Set(merged, rawValue(value, valueType), string(key))
+			return setErr
+		})
+		if err != nil {
+			return err
+		}
+		response.Extensions = merged
+		return nil
+	})
+}
+
+// OmitNullFields removes the fields with null values from the objects of the data, e.g. to reduce the size of
+// responses of clients treating missing and null fields the same. It should be registered with ResponseOrderNullOmission.
+func OmitNullFields() ResponsePostProcessor {
+	return ResponsePostProcessorFunc(func(ctx context.Context, response *Response) error {
+		if len(response.Data) == 0 || response.Data[0] != '{' {
+			return nil
+		}
+		data, err := omitNullFields(response.Data, jsonparser.Object)
+		if err != nil {
+			return err
+		}
+		response.Data = data
+		return nil
+	})
+}
+
+func omitNullFields(value []byte, valueType jsonparser.ValueType) ([]byte, error) {
+	switch valueType {
+	case jsonparser.Object:
+		return rewriteObject(value, func(key, value []byte, valueType jsonparser.ValueType) ([]byte, bool, error) {
+			if valueType == jsonparser.Null {
+				return nil, false, nil
+			}
+			value, err := omitNullFields(value, valueType)
+			return value, true, err
+		})
+	case jsonparser.Array:
+		return rewriteArray(value, omitNullFields)
+	default:
+		return value, nil
+	}
+}
+
+// responseField is a field of the operation selected on an object of the data,
+// typeNames are the enclosing type of the field and the __typename of the object if it differs
+type responseField struct {
+	ref       int
+	typeNames []string
+}
+
+type responseFieldRewrite func(operation *ast.Document, field responseField, value []byte, valueType jsonparser.ValueType) ([]byte, error)
+
+// rewriteResponseFields walks the data along the selections of the operation from the context
+// and replaces the value of every field with the value returned by rewrite, children are rewritten first
+func rewriteResponseFields(ctx context.Context, response *Response, rewrite responseFieldRewrite) error {
+	operation, ok := responseOperationFromContext(ctx)
+	if !ok || len(response.Data) == 0 || response.Data[0] != '{' {
+		return nil
+	}
+	operationRef, ok := operation.operation.OperationDefinitionRefByName(operation.operationName)
+	if !ok {
+		return nil
+	}
+
+	rewriter := &responseFieldRewriter{
+		operation:  operation.operation,
+		definition: operation.definition,
+		rewrite:    rewrite,
+	}
+	rootTypeName := rewriter.rootTypeName(operation.operation.OperationDefinitions[operationRef].OperationType)
+	data, err := rewriter.rewriteObject(operation.operation.OperationDefinitions[operationRef].SelectionSet, rootTypeName, response.Data)
+	if err != nil {
+		return err
+	}
+	response.Data = data
+	return nil
+}
+
+type responseFieldRewriter struct {
+	operation, definition *ast.Document
+	rewrite               responseFieldRewrite
+}
+
+func (r *responseFieldRewriter) rootTypeName(operationType ast.OperationType) string {
+	switch operationType {
+	case ast.OperationTypeMutation:
+		return string(r.definition.Index.MutationTypeName)
+	case ast.OperationTypeSubscription:
+		return string(r.definition.Index.SubscriptionTypeName)
+	default:
+		return string(r.definition.Index.QueryTypeName)
+	}
+}
+
+func (r *responseFieldRewriter) rewriteObject(selectionSet int, typeName string, data []byte) ([]byte, error) {
+	objectTypeName, _ := jsonparser.GetString(data, "__typename")
+	fields := map[string]responseField{}
+	r.collectFields(selectionSet, typeName, objectTypeName, fields)
+
+	return rewriteObject(data, func(key, value []byte, valueType jsonparser.ValueType) ([]byte, bool, error) {
+		field, ok := fields[string(key)]
+		if !ok {
+			return value, true, nil
+		}
+		value, err := r.rewriteField(field, value, valueType)
+		return value, true, err
+	})
+}
+
+func (r *responseFieldRewriter) rewriteField(field responseField, value []byte, valueType jsonparser.ValueType) ([]byte, error) {
+	if r.operation.FieldHasSelections(field.ref) && (valueType == jsonparser.Object || valueType == jsonparser.Array) {
+		fieldTypeName, ok := r.fieldTypeName(field.typeNames[0], r.operation.FieldNameBytes(field.ref))
+		if ok {
+			var err error
+			value, err = r.rewriteValue(r.operation.Fields[field.ref].SelectionSet, fieldTypeName, value, valueType)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	return r.rewrite(r.operation, field, value, valueType)
+}
+
+func (r *responseFieldRewriter) rewriteValue(selectionSet int, typeName string, value []byte, valueType jsonparser.ValueType) ([]byte, error) {
+	switch valueType {
+	case jsonparser.Object:
+		return r.rewriteObject(selectionSet, typeName, value)
+	case jsonparser.Array:
+		return rewriteArray(value, func(item []byte, itemType jsonparser.ValueType) ([]byte, error) {
+			return r.rewriteValue(selectionSet, typeName, item, itemType)
+		})
+	default:
+		return value, nil
+	}
+}
+
+// collectFields collects the fields of the selection set by response key,
+// fragments on types other than the __typename of the object are skipped if the type is an object type
+func (r *responseFieldRewriter) collectFields(selectionSet int, typeName, objectTypeName string, fields map[string]responseField) {
+	for _, selectionRef := range r.operation.SelectionSets[selectionSet].SelectionRefs {
+		selection := r.operation.Selections[selectionRef]
+		switch selection.Kind {
+		case ast.SelectionKindField:
+			typeNames := []string{typeName}
+			if objectTypeName != "" && objectTypeName != typeName {
+				typeNames = append(typeNames, objectTypeName)
+			}
+			fields[r.operation.FieldAliasOrNameString(selection.Ref)] = responseField{ref: selection.Ref, typeNames: typeNames}
+		case ast.SelectionKindInlineFragment:
+			fragmentTypeName := typeName
+			if r.operation.InlineFragmentHasTypeCondition(selection.Ref) {
+				fragmentTypeName = r.operation.ResolveTypeNameString(r.operation.InlineFragments[selection.Ref].TypeCondition.Type)
+			}
+			if r.excludesObject(fragmentTypeName, objectTypeName) {
+				continue
+			}
+			r.collectFields(r.operation.InlineFragments[selection.Ref].SelectionSet, fragmentTypeName, objectTypeName, fields)
+		case ast.SelectionKindFragmentSpread:
+			fragmentRef, ok := r.operation.FragmentDefinitionRef(r.operation.FragmentSpreadNameBytes(selection.Ref))
+			if !ok {
+				continue
+			}
+			fragmentTypeName := r.operation.FragmentDefinitionTypeName(fragmentRef).String()
+			if r.excludesObject(fragmentTypeName, objectTypeName) {
+				continue
+			}
+			r.collectFields(r.operation.FragmentDefinitions[fragmentRef].SelectionSet, fragmentTypeName, objectTypeName, fields)
+		}
+	}
+}
+
+// excludesObject reports whether a fragment on the type doesn't apply to an object with the __typename
+func (r *responseFieldRewriter) excludesObject(fragmentTypeName, objectTypeName string) bool {
+	if objectTypeName == "" || fragmentTypeName == objectTypeName {
+		return false
+	}
+	node, ok := r.definition.NodeByNameStr(fragmentTypeName)
+	return ok && node.Kind == ast.NodeKindObjectTypeDefinition
+}
+
+func (r *responseFieldRewriter) fieldTypeName(typeName string, fieldName []byte) (string, bool) {
+	node, ok := r.definition.NodeByNameStr(typeName)
+	if !ok {
+		return "", false
+	}
+	fieldDefinition, ok := r.definition.NodeFieldDefinitionByName(node, fieldName)
+	if !ok {
+		return "", false
+	}
+	return r.definition.ResolveTypeNameString(r.definition.FieldDefinitionType(fieldDefinition)), true
+}
+
+// rewriteObject replaces the value of every field of the JSON object with the value returned by rewrite,
+// fields are removed if keep is false. Values passed to rewrite are valid JSON, strings keep their quotes.
+func rewriteObject(data []byte, rewrite func(key, value []byte, valueType jsonparser.ValueType) (rewritten []byte, keep bool, err error)) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	buf.WriteByte('{')
+	err := jsonparser.ObjectEach(data, func(key []byte, value []byte, valueType jsonparser.ValueType, offset int) error {
+		rewritten, keep, err := rewrite(key, rawValue(value, valueType), valueType)
+		if err != nil || !keep {
+			return err
+		}
+		if buf.Len() > 1 {
+			buf.WriteByte(',')
+		}
+		buf.WriteByte('"')
+		buf.Write(key)
+		buf.WriteString(`":`)
+		buf.Write(rewritten)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// rewriteArray replaces every item of the JSON array with the value returned by rewrite
+func rewriteArray(data []byte, rewrite func(item []byte, itemType jsonparser.ValueType) ([]byte, error)) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	buf.WriteByte('[')
+	var rewriteErr error
+	_, err := jsonparser.ArrayEach(data, func(item []byte, itemType jsonparser.ValueType, offset int, err error) {
+		if rewriteErr != nil {
+			return
+		}
+		rewritten, err := rewrite(rawValue(item, itemType), itemType)
+		if err != nil {
+			rewriteErr = err
+			return
+		}
+		if buf.Len() > 1 {
+			buf.WriteByte(',')
+		}
+		buf.Write(rewritten)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if rewriteErr != nil {
+		return nil, rewriteErr
+	}
+	buf.WriteByte(']')
+	return buf.Bytes(), nil
+}
+
+// rawValue returns the JSON value, jsonparser strips the quotes of string values
+func rawValue(value []byte, valueType jsonparser.ValueType) []byte {
+	if valueType != jsonparser.String {
+		return value
+	}
+	quoted := make([]byte, 0, len(value)+2)
+	quoted = append(quoted, '"')
+	quoted = append(quoted, value...)
+	return append(quoted, '"')
+}
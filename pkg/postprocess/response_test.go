@@ -0,0 +1,172 @@
+package postprocess
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/buger/jsonparser"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/wundergraph/graphql-go-tools/internal/pkg/unsafeparser"
+	"github.com/wundergraph/graphql-go-tools/pkg/asttransform"
+)
+
+func TestResponsePipeline_Process(t *testing.T) {
+	maskEmail := ResponsePostProcessorFunc(func(ctx context.Context, response *Response) error {
+		data, err := jsonparser.Set(response.Data, []byte(`"***"`), "user", "email")
+		if err != nil {
+			return err
+		}
+		response.Data = data
+		return nil
+	})
+	addMaskedExtension := ResponsePostProcessorFunc(func(ctx context.Context, response *Response) error {
+		email, err := jsonparser.GetString(response.Data, "user", "email")
+		if err != nil {
+			return err
+		}
+		response.Extensions = []byte(`{"email":"` + email + `"}`)
+		return nil
+	})
+
+	t.Run("runs post processors ordered", func(t *testing.T) {
+		pipeline := NewResponsePipeline().
+			Register(20, addMaskedExtension).
+			Register(10, maskEmail)
+
+		out, err := pipeline.Process(context.Background(), []byte(`{"data":{"user":{"name":"Jens","email":"jens@example.com"}}}`))
+		require.NoError(t, err)
+		assert.Equal(t, `{"data":{"user":{"name":"Jens","email":"***"}},"extensions":{"email":"***"}}`, string(out))
+	})
+
+	t.Run("same order runs in registration order", func(t *testing.T) {
+		pipeline := NewResponsePipeline().
+			Register(0, addMaskedExtension).
+			Register(0, maskEmail)
+
+		out, err := pipeline.Process(context.Background(), []byte(`{"data":{"user":{"name":"Jens","email":"jens@example.com"}}}`))
+		require.NoError(t, err)
+		assert.Equal(t, `{"data":{"user":{"name":"Jens","email":"***"}},"extensions":{"email":"jens@example.com"}}`, string(out))
+	})
+
+	t.Run("keeps errors and extensions", func(t *testing.T) {
+		pipeline := NewResponsePipeline().Register(0, ResponsePostProcessorFunc(func(ctx context.Context, response *Response) error {
+			response.Data = []byte(`null`)
+			return nil
+		}))
+
+		out, err := pipeline.Process(context.Background(), []byte(`{"errors":[{"message":"unauthorized"}],"data":{"user":null},"extensions":{"trace":"abc"}}`))
+		require.NoError(t, err)
+		assert.Equal(t, `{"errors":[{"message":"unauthorized"}],"data":null,"extensions":{"trace":"abc"}}`, string(out))
+	})
+
+	t.Run("without post processors", func(t *testing.T) {
+		out, err := NewResponsePipeline().Process(context.Background(), []byte(`{"data":{"user":null}}`))
+		require.NoError(t, err)
+		assert.Equal(t, `{"data":{"user":null}}`, string(out))
+	})
+
+	t.Run("post processor error", func(t *testing.T) {
+		pipeline := NewResponsePipeline().Register(0, ResponsePostProcessorFunc(func(ctx context.Context, response *Response) error {
+			return errors.New("failed")
+		}))
+
+		_, err := pipeline.Process(context.Background(), []byte(`{"data":{"user":null}}`))
+		assert.EqualError(t, err, "failed")
+	})
+}
+
+func TestResponsePipeline_BuiltInPostProcessors(t *testing.T) {
+	definition := unsafeparser.ParseGraphqlDocumentString(`
+		directive @upper on FIELD
+		schema { query: Query }
+		type Query { user: User nodes: [Node] }
+		interface Node { id: ID! }
+		type User implements Node { id: ID! name: String email: String friends: [User] }
+		type Post implements Node { id: ID! email: String }`)
+	require.NoError(t, asttransform.MergeDefinitionWithBaseSchema(&definition))
+	operation := unsafeparser.ParseGraphqlDocumentString(`
+		query Q { user { name @upper email friends { mail: email name } } nodes { __typename ... on User { email } ... on Post { email } } }`)
+	ctx := WithResponseOperation(context.Background(), &operation, &definition, "Q")
+
+	upper := HandleDirectives(map[string]ResponseDirectiveHandler{
+		"upper": func(ctx context.Context, value json.RawMessage) (json.RawMessage, error) {
+			return bytes.ToUpper(value), nil
+		},
+	})
+	maskEmail := MaskFields([]byte(`"***"`), FieldCoordinate{TypeName: "User", FieldName: "email"})
+	extensions := Extensions(func() ([]byte, error) {
+		return []byte(`{"cost":3}`), nil
+	})
+
+	response := []byte(`{"data":{"user":{"name":"Jens","email":"jens@example.com","friends":[{"mail":"stefan@example.com","name":null}]},"nodes":[{"__typename":"User","email":"a@example.com"},{"__typename":"Post","email":"b@example.com"}]},"extensions":{"trace":"abc"}}`)
+
+	t.Run("runs the built-in post processors in their order", func(t *testing.T) {
+		pipeline := NewResponsePipeline().
+			Register(ResponseOrderNullOmission, OmitNullFields()).
+			Register(ResponseOrderExtensions, extensions).
+			Register(ResponseOrderDirectives, upper).
+			Register(ResponseOrderFieldMasking, maskEmail)
+
+		out, err := pipeline.Process(ctx, response)
+		require.NoError(t, err)
+		assert.Equal(t, `{"data":{"user":{"name":"JENS","email":"***","friends":[{"mail":"***"}]},"nodes":[{"__typename":"User","email":"***"},{"__typename":"Post","email":"b@example.com"}]},"extensions":{"trace":"abc","cost":3}}`, string(out))
+	})
+
+	t.Run("custom post processor between built-in ones", func(t *testing.T) {
+		pipeline := NewResponsePipeline().
+			Register(ResponseOrderFieldMasking, maskEmail).
+			Register(ResponseOrderFieldMasking-1, ResponsePostProcessorFunc(func(ctx context.Context, response *Response) error {
+				email, err := jsonparser.GetString(response.Data, "user", "email")
+				if err != nil {
+					return err
+				}
+				response.Extensions = []byte(`{"email":"` + email + `"}`)
+				return nil
+			}))
+
+		out, err := pipeline.Process(ctx, response)
+		require.NoError(t, err)
+		extension, err := jsonparser.GetString(out, "extensions", "email")
+		require.NoError(t, err)
+		assert.Equal(t, "jens@example.com", extension)
+	})
+
+	t.Run("post processors needing the operation skip responses without it", func(t *testing.T) {
+		out, err := NewResponsePipeline().Register(ResponseOrderFieldMasking, maskEmail).Process(context.Background(), response)
+		require.NoError(t, err)
+		assert.Equal(t, string(response), string(out))
+	})
+
+	t.Run("with does not change the pipeline", func(t *testing.T) {
+		pipeline := NewResponsePipeline().Register(ResponseOrderNullOmission, OmitNullFields())
+		extended := pipeline.With(ResponseOrderExtensions, extensions)
+
+		out, err := pipeline.Process(ctx, []byte(`{"data":{"user":null}}`))
+		require.NoError(t, err)
+		assert.Equal(t, `{"data":{}}`, string(out))
+		out, err = extended.Process(ctx, []byte(`{"data":{"user":null}}`))
+		require.NoError(t, err)
+		assert.Equal(t, `{"data":{},"extensions":{"cost":3}}`, string(out))
+		assert.True(t, (*ResponsePipeline)(nil).Empty())
+	})
+
+	t.Run("fields of the operation selected by name", func(t *testing.T) {
+		operations := unsafeparser.ParseGraphqlDocumentString(`
+			query Names { user { email: name } }
+			query Emails { user { email } }`)
+		pipeline := NewResponsePipeline().Register(ResponseOrderFieldMasking, maskEmail)
+
+		out, err := pipeline.Process(WithResponseOperation(context.Background(), &operations, &definition, "Names"), []byte(`{"data":{"user":{"email":"Jens"}}}`))
+		require.NoError(t, err)
+		assert.Equal(t, `{"data":{"user":{"email":"Jens"}}}`, string(out))
+
+		out, err = pipeline.Process(WithResponseOperation(context.Background(), &operations, &definition, "Emails"), []byte(`{"data":{"user":{"email":"jens@example.com"}}}`))
+		require.NoError(t, err)
+		assert.Equal(t, `{"data":{"user":{"email":"***"}}}`, string(out))
+	})
+}
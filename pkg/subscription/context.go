@@ -2,8 +2,13 @@ package subscription
 
 import (
 	"context"
+	"encoding/json"
 	"net/http"
+	"sort"
 	"sync"
+	"time"
+
+	graphqlDataSource "github.com/wundergraph/graphql-go-tools/pkg/engine/datasource/graphql_datasource"
 )
 
 type InitialHttpRequestContext struct {
@@ -18,9 +23,23 @@ func NewInitialHttpRequestContext(r *http.Request) *InitialHttpRequestContext {
 	}
 }
 
+// SubscriptionInfo describes an active subscription of a client, see Handler.Subscriptions.
+type SubscriptionInfo struct {
+	// Id is the id of the start message chosen by the client.
+	Id string
+	// Payload is the GraphQL request of the start message.
+	Payload json.RawMessage
+	// StartedAt is the time the subscription was started.
+	StartedAt time.Time
+	// Upstreams are the subscriptions to the origins the subscription is resolved by.
+	Upstreams []graphqlDataSource.UpstreamSubscription
+}
+
 type subscriptionCancellations struct {
 	mu            sync.RWMutex
 	cancellations map[string]context.CancelFunc
+	// infos describe the subscriptions added with AddWithInfo by id
+	infos map[string]*SubscriptionInfo
 }
 
 func (sc *subscriptionCancellations) AddWithParent(id string, parent context.Context) context.Context {
@@ -34,6 +53,46 @@ func (sc *subscriptionCancellations) AddWithParent(id string, parent context.Con
 	return ctx
 }
 
+// AddWithInfo adds the subscription like AddWithParent and keeps its info until it's cancelled.
+// The upstream subscriptions started with the returned context are added to the info.
+func (sc *subscriptionCancellations) AddWithInfo(info SubscriptionInfo, parent context.Context) context.Context {
+	ctx := sc.AddWithParent(info.Id, parent)
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	if sc.infos == nil {
+		sc.infos = make(map[string]*SubscriptionInfo)
+	}
+	added := &info
+	sc.infos[info.Id] = added
+	return graphqlDataSource.ContextWithUpstreamSubscriptionObserver(ctx, func(upstream graphqlDataSource.UpstreamSubscription) {
+		sc.mu.Lock()
+		defer sc.mu.Unlock()
+		// the id might be reused by the client once the subscription is cancelled
+		if sc.infos[info.Id] == added {
+			added.Upstreams = append(added.Upstreams, upstream)
+		}
+	})
+}
+
+// Infos returns the infos of the subscriptions added with AddWithInfo, ordered by their start
+func (sc *subscriptionCancellations) Infos() []SubscriptionInfo {
+	sc.mu.RLock()
+	defer sc.mu.RUnlock()
+	infos := make([]SubscriptionInfo, 0, len(sc.infos))
+	for _, info := range sc.infos {
+		copied := *info
+		copied.Upstreams = append([]graphqlDataSource.UpstreamSubscription(nil), info.Upstreams...)
+		infos = append(infos, copied)
+	}
+	sort.Slice(infos, func(i, j int) bool {
+		if !infos[i].StartedAt.Equal(infos[j].StartedAt) {
+			return infos[i].StartedAt.Before(infos[j].StartedAt)
+		}
+		return infos[i].Id < infos[j].Id
+	})
+	return infos
+}
+
 func (sc *subscriptionCancellations) Cancel(id string) (ok bool) {
 	sc.mu.Lock()
 	defer sc.mu.Unlock()
@@ -44,6 +103,7 @@ func (sc *subscriptionCancellations) Cancel(id string) (ok bool) {
 
 	cancelFunc()
 	delete(sc.cancellations, id)
+	delete(sc.infos, id)
 	return true
 }
 
@@ -57,6 +117,20 @@ func (sc *subscriptionCancellations) CancelAll() {
 	}
 }
 
+// CancelAndRemoveAll cancels and removes all subscriptions and returns their ids.
+func (sc *subscriptionCancellations) CancelAndRemoveAll() (ids []string) {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	ids = make([]string, 0, len(sc.cancellations))
+	for id, cancelFunc := range sc.cancellations {
+		cancelFunc()
+		ids = append(ids, id)
+	}
+	sc.cancellations = nil
+	sc.infos = nil
+	return ids
+}
+
 func (sc *subscriptionCancellations) Len() int {
 	sc.mu.RLock()
 	defer sc.mu.RUnlock()
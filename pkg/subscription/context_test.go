@@ -48,3 +48,25 @@ func TestSubscriptionCancellations(t *testing.T) {
 		assert.Equal(t, 0, cancellations.Len())
 	})
 }
+
+func TestSubscriptionCancellations_Infos(t *testing.T) {
+	cancellations := subscriptionCancellations{}
+	startedAt := time.Now()
+
+	cancellations.AddWithInfo(SubscriptionInfo{Id: "2", Payload: []byte(`{"query":"subscription { b }"}`), StartedAt: startedAt.Add(time.Second)}, context.Background())
+	cancellations.AddWithInfo(SubscriptionInfo{Id: "1", Payload: []byte(`{"query":"subscription { a }"}`), StartedAt: startedAt}, context.Background())
+
+	infos := cancellations.Infos()
+	require.Len(t, infos, 2)
+	assert.Equal(t, "1", infos[0].Id)
+	assert.Equal(t, `{"query":"subscription { a }"}`, string(infos[0].Payload))
+	assert.Equal(t, "2", infos[1].Id)
+
+	cancellations.Cancel("1")
+	infos = cancellations.Infos()
+	require.Len(t, infos, 1)
+	assert.Equal(t, "2", infos[0].Id)
+
+	cancellations.CancelAndRemoveAll()
+	assert.Empty(t, cancellations.Infos())
+}
@@ -4,12 +4,15 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/jensneuse/abstractlogger"
 
 	"github.com/wundergraph/graphql-go-tools/pkg/ast"
+	graphqlDataSource "github.com/wundergraph/graphql-go-tools/pkg/engine/datasource/graphql_datasource"
 	"github.com/wundergraph/graphql-go-tools/pkg/engine/resolve"
 	"github.com/wundergraph/graphql-go-tools/pkg/graphql"
 )
@@ -25,11 +28,22 @@ const (
 	MessageTypeData                = "data"
 	MessageTypeError               = "error"
 	MessageTypeComplete            = "complete"
+	MessageTypePing                = "ping"
+	MessageTypePong                = "pong"
+
+	// ProtocolGraphQLWS is the websocket sub-protocol of subscriptions-transport-ws, its keep-alive messages are "ka" messages
+	ProtocolGraphQLWS = "graphql-ws"
+	// ProtocolGraphQLTransportWS is the websocket sub-protocol of graphql-ws, its keep-alive messages are "ping" messages
+	// answered by the client with "pong" messages
+	ProtocolGraphQLTransportWS = "graphql-transport-ws"
 
 	DefaultKeepAliveInterval          = "15s"
 	DefaultSubscriptionUpdateInterval = "1s"
 )
 
+// ErrHandlerShuttingDown is returned for operations started while the handler is shutting down.
+var ErrHandlerShuttingDown = errors.New("server is shutting down")
+
 // Message defines the actual subscription message wich will be passed from client to server and vice versa.
 type Message struct {
 	Id      string          `json:"id"`
@@ -74,6 +88,12 @@ type Handler struct {
 	client Client
 	// keepAliveInterval is the actual interval on which the server send keep alive messages to the client.
 	keepAliveInterval time.Duration
+	// protocol is the websocket sub-protocol of the client, it determines the keep alive messages.
+	protocol string
+	// idleTimeout is the duration after which a client which didn't send any message is disconnected, 0 never disconnects.
+	idleTimeout time.Duration
+	// lastMessageAt is the time in unix nanoseconds of the last message read from the client.
+	lastMessageAt int64
 	// subscriptionUpdateInterval is the actual interval on which the server sends subscription updates to the client.
 	subscriptionUpdateInterval time.Duration
 	// subCancellations stores a map containing the cancellation functions to every active subscription.
@@ -84,6 +104,14 @@ type Handler struct {
 	bufferPool *sync.Pool
 	// initFunc will check initial payload to see whether to accept the websocket connection.
 	initFunc WebsocketInitFunc
+	// activeOperations tracks the goroutines of all running operations including subscriptions.
+	activeOperations sync.WaitGroup
+	// shutdownMu guards shuttingDown and the start of new operations.
+	shutdownMu sync.Mutex
+	// shuttingDown indicates that the handler does not accept new operations anymore.
+	shuttingDown bool
+	// middlewares wrap the start of every operation.
+	middlewares []Middleware
 }
 
 func NewHandlerWithInitFunc(
@@ -106,6 +134,7 @@ func NewHandlerWithInitFunc(
 		logger:                     logger,
 		client:                     client,
 		keepAliveInterval:          keepAliveInterval,
+		protocol:                   ProtocolGraphQLWS,
 		subscriptionUpdateInterval: subscriptionUpdateInterval,
 		subCancellations:           subscriptionCancellations{},
 		executorPool:               executorPool,
@@ -146,6 +175,7 @@ func (h *Handler) Handle(ctx context.Context) {
 
 			h.handleConnectionError("could not read message from client")
 		} else if message != nil {
+			atomic.StoreInt64(&h.lastMessageAt, time.Now().UnixNano())
 			switch message.Type {
 			case MessageTypeConnectionInit:
 				ctx, err = h.handleInit(ctx, message.Payload)
@@ -159,6 +189,10 @@ func (h *Handler) Handle(ctx context.Context) {
 				h.handleStart(ctx, message.Id, message.Payload)
 			case MessageTypeStop:
 				h.handleStop(message.Id)
+			case MessageTypePing:
+				h.sendPong()
+			case MessageTypePong:
+				// the client is alive, which is recorded for every message
 			case MessageTypeConnectionTerminate:
 				h.handleConnectionTerminate()
 				return
@@ -179,6 +213,19 @@ func (h *Handler) ChangeKeepAliveInterval(d time.Duration) {
 	h.keepAliveInterval = d
 }
 
+// ChangeProtocol can be used to change the websocket sub-protocol of the client, e.g. to ProtocolGraphQLTransportWS
+// to send "ping" instead of "ka" keep alive messages. Defaults to ProtocolGraphQLWS.
+func (h *Handler) ChangeProtocol(protocol string) {
+	h.protocol = protocol
+}
+
+// ChangeIdleTimeout can be used to disconnect clients which didn't send any message for the duration,
+// e.g. clients which don't answer "ping" keep alive messages. Clients of ProtocolGraphQLWS don't answer "ka" messages,
+// they must send messages on their own to stay connected. 0 disables the timeout, which is the default.
+func (h *Handler) ChangeIdleTimeout(d time.Duration) {
+	h.idleTimeout = d
+}
+
 // ChangeSubscriptionUpdateInterval can be used to change the update interval.
 func (h *Handler) ChangeSubscriptionUpdateInterval(d time.Duration) {
 	h.subscriptionUpdateInterval = d
@@ -200,6 +247,11 @@ func (h *Handler) handleInit(ctx context.Context, payload []byte) (extendedCtx c
 		extendedCtx = ctx
 	}
 
+	if len(payload) > 0 {
+		// make the payload available for forwarding it to upstream subscriptions
+		extendedCtx = graphqlDataSource.ContextWithInitPayload(extendedCtx, payload)
+	}
+
 	ackMessage := Message{
 		Type: MessageTypeConnectionAck,
 	}
@@ -228,13 +280,42 @@ func (h *Handler) handleStart(ctx context.Context, id string, payload []byte) {
 		return
 	}
 
+	start := h.wrapStart(func(ctx context.Context, _ OperationInfo) error {
+		return h.startOperation(ctx, id, payload, executor)
+	})
+	operation := OperationInfo{
+		Id:            id,
+		OperationType: executor.OperationType(),
+		Payload:       payload,
+	}
+	if err = start(ctx, operation); err != nil {
+		h.handleError(id, graphql.RequestErrorsFromError(err))
+	}
+}
+
+// startOperation will start the execution of the operation in a new goroutine.
+func (h *Handler) startOperation(ctx context.Context, id string, payload []byte, executor Executor) error {
+	h.shutdownMu.Lock()
+	defer h.shutdownMu.Unlock()
+	if h.shuttingDown {
+		return ErrHandlerShuttingDown
+	}
+	h.activeOperations.Add(1)
+
 	if executor.OperationType() == ast.OperationTypeSubscription {
-		ctx := h.subCancellations.AddWithParent(id, ctx)
-		go h.startSubscription(ctx, id, executor)
-		return
+		ctx := h.subCancellations.AddWithInfo(SubscriptionInfo{Id: id, Payload: payload, StartedAt: time.Now()}, ctx)
+		go func() {
+			defer h.activeOperations.Done()
+			h.startSubscription(ctx, id, executor)
+		}()
+		return nil
 	}
 
-	go h.handleNonSubscriptionOperation(ctx, id, executor)
+	go func() {
+		defer h.activeOperations.Done()
+		h.handleNonSubscriptionOperation(ctx, id, executor)
+	}()
+	return nil
 }
 
 func (h *Handler) handleOnBeforeStart(executor Executor) error {
@@ -346,12 +427,22 @@ func (h *Handler) executeSubscription(buf *graphql.EngineResultWriter, id string
 	}
 }
 
-// handleStop will handle a stop message,
+// handleStop will handle a stop message.
+// Subscriptions which aren't active anymore while the handler shuts down are completed by Shutdown,
+// so that a stop message racing with the shutdown doesn't complete a subscription twice.
 func (h *Handler) handleStop(id string) {
-	h.subCancellations.Cancel(id)
+	if !h.subCancellations.Cancel(id) && h.isShuttingDown() {
+		return
+	}
 	h.sendComplete(id)
 }
 
+func (h *Handler) isShuttingDown() bool {
+	h.shutdownMu.Lock()
+	defer h.shutdownMu.Unlock()
+	return h.shuttingDown
+}
+
 // sendData will send a data message to the client.
 func (h *Handler) sendData(id string, responseData []byte) {
 	dataMessage := Message{
@@ -396,22 +487,51 @@ func (h *Handler) handleConnectionTerminate() {
 }
 
 // handleKeepAlive will handle the keep alive loop.
+// Clients which didn't send a message within the idle timeout are disconnected.
 func (h *Handler) handleKeepAlive(ctx context.Context) {
+	atomic.StoreInt64(&h.lastMessageAt, time.Now().UnixNano())
 	for {
 		select {
 		case <-ctx.Done():
 			return
 		case <-time.After(h.keepAliveInterval):
+			if !h.client.IsConnected() {
+				return
+			}
+			if h.isIdle() {
+				h.logger.Debug("subscription.Handler.handleKeepAlive()",
+					abstractlogger.String("message", "disconnecting idle client"),
+				)
+				h.terminateConnection("idle timeout exceeded")
+				if err := h.client.Disconnect(); err != nil {
+					h.logger.Error("subscription.Handler.handleKeepAlive()",
+						abstractlogger.Error(err),
+					)
+				}
+				return
+			}
 			h.sendKeepAlive()
 		}
 	}
 }
 
-// sendKeepAlive will send a keep alive message to the client.
+// isIdle reports whether the client didn't send any message within the idle timeout.
+func (h *Handler) isIdle() bool {
+	if h.idleTimeout <= 0 {
+		return false
+	}
+	lastMessageAt := time.Unix(0, atomic.LoadInt64(&h.lastMessageAt))
+	return time.Since(lastMessageAt) > h.idleTimeout
+}
+
+// sendKeepAlive will send a keep alive message of the protocol to the client.
 func (h *Handler) sendKeepAlive() {
 	keepAliveMessage := Message{
 		Type: MessageTypeConnectionKeepAlive,
 	}
+	if h.protocol == ProtocolGraphQLTransportWS {
+		keepAliveMessage.Type = MessageTypePing
+	}
 
 	err := h.client.WriteToClient(keepAliveMessage)
 	if err != nil {
@@ -421,6 +541,18 @@ func (h *Handler) sendKeepAlive() {
 	}
 }
 
+// sendPong will answer a ping message of the client.
+func (h *Handler) sendPong() {
+	err := h.client.WriteToClient(Message{
+		Type: MessageTypePong,
+	})
+	if err != nil {
+		h.logger.Error("subscription.Handler.sendPong()",
+			abstractlogger.Error(err),
+		)
+	}
+}
+
 func (h *Handler) terminateConnection(reason interface{}) {
 	payloadBytes, err := json.Marshal(reason)
 	if err != nil {
@@ -504,7 +636,46 @@ func (h *Handler) handleError(id string, errors graphql.RequestErrors) {
 	}
 }
 
+// Shutdown will gracefully close the connection to the client.
+// It stops accepting new operations, sends a complete message to every active subscription not completed yet
+// and waits for in-flight operations until the context is done, before it disconnects the client.
+func (h *Handler) Shutdown(ctx context.Context) error {
+	h.shutdownMu.Lock()
+	h.shuttingDown = true
+	h.shutdownMu.Unlock()
+
+	subscriptionIds := h.subCancellations.CancelAndRemoveAll()
+
+	finished := make(chan struct{})
+	go func() {
+		h.activeOperations.Wait()
+		close(finished)
+	}()
+
+	var err error
+	select {
+	case <-finished:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	for _, id := range subscriptionIds {
+		h.sendComplete(id)
+	}
+
+	if disconnectErr := h.client.Disconnect(); disconnectErr != nil && err == nil {
+		err = disconnectErr
+	}
+
+	return err
+}
+
 // ActiveSubscriptions will return the actual number of active subscriptions for that client.
 func (h *Handler) ActiveSubscriptions() int {
 	return h.subCancellations.Len()
 }
+
+// Subscriptions returns the active subscriptions of the client ordered by their start, e.g. for debugging.
+func (h *Handler) Subscriptions() []SubscriptionInfo {
+	return h.subCancellations.Infos()
+}
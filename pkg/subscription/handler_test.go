@@ -15,6 +15,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/wundergraph/graphql-go-tools/pkg/ast"
 	"github.com/wundergraph/graphql-go-tools/pkg/engine/datasource/graphql_datasource"
 	"github.com/wundergraph/graphql-go-tools/pkg/engine/datasource/httpclient"
 	"github.com/wundergraph/graphql-go-tools/pkg/engine/plan"
@@ -114,6 +115,90 @@ func TestHandler_Handle(t *testing.T) {
 
 				cancelFunc()
 			})
+
+			t.Run("should send ping messages as keep alive messages of graphql-transport-ws", func(t *testing.T) {
+				subscriptionHandler, client, handlerRoutine := setupSubscriptionHandlerTest(t, executorPool)
+				subscriptionHandler.ChangeKeepAliveInterval(5 * time.Millisecond)
+				subscriptionHandler.ChangeProtocol(ProtocolGraphQLTransportWS)
+
+				client.prepareConnectionInitMessage().withoutError().and().send()
+				ctx, cancelFunc := context.WithCancel(context.Background())
+				defer cancelFunc()
+
+				handlerRoutineFunc := handlerRoutine(ctx)
+				go handlerRoutineFunc()
+
+				assert.Eventually(t, func() bool {
+					return client.hasMoreMessagesThan(2)
+				}, 1*time.Second, 5*time.Millisecond)
+				messagesFromServer := client.readFromServer()
+				assert.Contains(t, messagesFromServer, Message{Type: MessageTypePing})
+				assert.NotContains(t, messagesFromServer, Message{Type: MessageTypeConnectionKeepAlive})
+			})
+
+			t.Run("should answer ping messages with pong messages", func(t *testing.T) {
+				subscriptionHandler, client, handlerRoutine := setupSubscriptionHandlerTest(t, executorPool)
+				subscriptionHandler.ChangeProtocol(ProtocolGraphQLTransportWS)
+
+				client.prepareConnectionInitMessage().withoutError().and().send()
+				ctx, cancelFunc := context.WithCancel(context.Background())
+				defer cancelFunc()
+
+				handlerRoutineFunc := handlerRoutine(ctx)
+				go handlerRoutineFunc()
+
+				client.preparePingMessage().withoutError().and().send()
+				assert.Eventually(t, func() bool {
+					for _, message := range client.readFromServer() {
+						if message.Type == MessageTypePong {
+							return true
+						}
+					}
+					return false
+				}, 1*time.Second, 5*time.Millisecond)
+			})
+
+			t.Run("should disconnect a client which doesn't answer within the idle timeout", func(t *testing.T) {
+				subscriptionHandler, client, handlerRoutine := setupSubscriptionHandlerTest(t, executorPool)
+				subscriptionHandler.ChangeKeepAliveInterval(5 * time.Millisecond)
+				subscriptionHandler.ChangeProtocol(ProtocolGraphQLTransportWS)
+				subscriptionHandler.ChangeIdleTimeout(50 * time.Millisecond)
+
+				client.prepareConnectionInitMessage().withoutError().and().send()
+				ctx, cancelFunc := context.WithCancel(context.Background())
+				defer cancelFunc()
+
+				handlerRoutineFunc := handlerRoutine(ctx)
+				go handlerRoutineFunc()
+
+				assert.Eventually(t, func() bool {
+					return !client.IsConnected()
+				}, 1*time.Second, 5*time.Millisecond)
+				assert.Contains(t, client.readFromServer(), Message{
+					Type:    MessageTypeConnectionTerminate,
+					Payload: jsonizePayload(t, "idle timeout exceeded"),
+				})
+			})
+
+			t.Run("should keep a client connected which answers with pong messages", func(t *testing.T) {
+				subscriptionHandler, client, handlerRoutine := setupSubscriptionHandlerTest(t, executorPool)
+				subscriptionHandler.ChangeKeepAliveInterval(5 * time.Millisecond)
+				subscriptionHandler.ChangeProtocol(ProtocolGraphQLTransportWS)
+				subscriptionHandler.ChangeIdleTimeout(50 * time.Millisecond)
+
+				client.prepareConnectionInitMessage().withoutError().and().send()
+				ctx, cancelFunc := context.WithCancel(context.Background())
+				defer cancelFunc()
+
+				handlerRoutineFunc := handlerRoutine(ctx)
+				go handlerRoutineFunc()
+
+				for i := 0; i < 10; i++ {
+					time.Sleep(10 * time.Millisecond)
+					client.preparePongMessage().withoutError().and().send()
+				}
+				assert.True(t, client.IsConnected())
+			})
 		})
 
 		t.Run("erroneous operation(s)", func(t *testing.T) {
@@ -162,7 +247,7 @@ func TestHandler_Handle(t *testing.T) {
 				assert.Len(t, messagesFromServer, 1)
 				assert.Equal(t, "1", messagesFromServer[0].Id)
 				assert.Equal(t, MessageTypeError, messagesFromServer[0].Type)
-				assert.Equal(t, `[{"message":"field: invalid not defined on type: Character","path":["query","hero","invalid"]}]`, string(messagesFromServer[0].Payload))
+				assert.Equal(t, `[{"message":"field: invalid not defined on type: Character","locations":[{"line":3,"column":9}],"path":["query","hero","invalid"]}]`, string(messagesFromServer[0].Payload))
 				assert.Equal(t, 0, subscriptionHandler.ActiveSubscriptions())
 			})
 
@@ -472,7 +557,7 @@ func TestHandler_Handle(t *testing.T) {
 				expectedErrorMessage := Message{
 					Id:      "1",
 					Type:    MessageTypeError,
-					Payload: []byte(`[{"message":"field: serverName not defined on type: Query","path":["query","serverName"]}]`),
+					Payload: []byte(`[{"message":"field: serverName not defined on type: Query","locations":[{"line":2,"column":2}],"path":["query","serverName"]}]`),
 				}
 
 				messagesFromServer := client.readFromServer()
@@ -659,6 +744,46 @@ func TestHandler_Handle(t *testing.T) {
 				cancelFunc()
 			})
 
+			t.Run("should complete active subscriptions on shutdown", func(t *testing.T) {
+				subscriptionHandler, client, handlerRoutine := setupSubscriptionHandlerTest(t, executorPool)
+				payload, err := subscriptiontesting.GraphQLRequestForOperation(subscriptiontesting.SubscriptionLiveMessages)
+				require.NoError(t, err)
+				client.prepareStartMessage("1", payload).withoutError().and().send()
+
+				ctx, cancelFunc := context.WithCancel(context.Background())
+				defer cancelFunc()
+				handlerRoutineFunc := handlerRoutine(ctx)
+				go handlerRoutineFunc()
+
+				require.Eventually(t, func() bool {
+					return subscriptionHandler.ActiveSubscriptions() == 1
+				}, 1*time.Second, 5*time.Millisecond)
+
+				shutdownCtx, cancelShutdown := context.WithTimeout(context.Background(), 1*time.Second)
+				defer cancelShutdown()
+				require.NoError(t, subscriptionHandler.Shutdown(shutdownCtx))
+
+				messagesFromServer := client.readFromServer()
+				require.NotEmpty(t, messagesFromServer)
+				assert.Equal(t, Message{
+					Id:      "1",
+					Type:    MessageTypeComplete,
+					Payload: nil,
+				}, messagesFromServer[len(messagesFromServer)-1])
+				assert.Equal(t, 0, subscriptionHandler.ActiveSubscriptions())
+				assert.False(t, client.IsConnected())
+
+				// a stop message racing with the shutdown doesn't complete the subscription twice
+				subscriptionHandler.handleStop("1")
+				completeMessages := 0
+				for _, message := range client.readFromServer() {
+					if message.Id == "1" && message.Type == MessageTypeComplete {
+						completeMessages++
+					}
+				}
+				assert.Equal(t, 1, completeMessages)
+			})
+
 			t.Run("should interrupt subscription on start and return error message from hook", func(t *testing.T) {
 				subscriptionHandler, client, handlerRoutine := setupSubscriptionHandlerTest(t, executorPool)
 
@@ -702,6 +827,101 @@ func TestHandler_Handle(t *testing.T) {
 			})
 		})
 
+		t.Run("middleware", func(t *testing.T) {
+			executorPool, _ := setupEngineV2(t, ctx, chatServer.URL)
+
+			type tokenContextKey struct{}
+			setupHandler := func(t *testing.T) (*Handler, *mockClient, handlerRoutine, *[]string) {
+				subscriptionHandler, client, handlerRoutine := setupSubscriptionHandlerWithInitFuncTest(t, executorPool, func(ctx context.Context, initPayload InitPayload) (context.Context, error) {
+					return context.WithValue(ctx, tokenContextKey{}, initPayload.Authorization()), nil
+				})
+
+				var calls []string
+				logging := func(next StartFunc) StartFunc {
+					return func(ctx context.Context, operation OperationInfo) error {
+						calls = append(calls, "logging:"+operation.Id)
+						return next(ctx, operation)
+					}
+				}
+				auth := func(next StartFunc) StartFunc {
+					return func(ctx context.Context, operation OperationInfo) error {
+						calls = append(calls, "auth:"+operation.Id)
+						if token, _ := ctx.Value(tokenContextKey{}).(string); operation.OperationType == ast.OperationTypeSubscription && token == "" {
+							return errors.New("missing token")
+						}
+						return next(ctx, operation)
+					}
+				}
+				subscriptionHandler.Use(logging, auth)
+
+				return subscriptionHandler, client, handlerRoutine, &calls
+			}
+
+			payload, err := subscriptiontesting.GraphQLRequestForOperation(subscriptiontesting.SubscriptionLiveMessages)
+			require.NoError(t, err)
+
+			t.Run("should reject subscription without token with error message", func(t *testing.T) {
+				subscriptionHandler, client, handlerRoutine, calls := setupHandler(t)
+				client.prepareConnectionInitMessage().withoutError().and().send()
+
+				ctx, cancelFunc := context.WithCancel(context.Background())
+				defer cancelFunc()
+				go handlerRoutine(ctx)()
+
+				client.prepareStartMessage("1", payload).withoutError().and().send()
+
+				require.Eventually(t, func() bool {
+					return client.hasMoreMessagesThan(1)
+				}, 1*time.Second, 10*time.Millisecond)
+
+				jsonErrMessage, err := json.Marshal(graphql.RequestErrors{
+					{Message: "missing token"},
+				})
+				require.NoError(t, err)
+
+				messagesFromServer := client.readFromServer()
+				assert.Equal(t, []Message{
+					{Type: MessageTypeConnectionAck},
+					{Id: "1", Type: MessageTypeError, Payload: jsonErrMessage},
+				}, messagesFromServer)
+				assert.Equal(t, []string{"logging:1", "auth:1"}, *calls)
+				assert.Equal(t, 0, subscriptionHandler.ActiveSubscriptions())
+			})
+
+			t.Run("should start subscription with token", func(t *testing.T) {
+				subscriptionHandler, client, handlerRoutine, calls := setupHandler(t)
+				client.prepareConnectionInitMessageWithPayload([]byte(`{"Authorization": "123"}`)).withoutError().and().send()
+
+				ctx, cancelFunc := context.WithCancel(context.Background())
+				defer cancelFunc()
+				go handlerRoutine(ctx)()
+
+				client.prepareStartMessage("1", payload).withoutError().and().send()
+
+				require.Eventually(t, func() bool {
+					return subscriptionHandler.ActiveSubscriptions() == 1
+				}, 1*time.Second, 5*time.Millisecond)
+
+				time.Sleep(50 * time.Millisecond)
+				go sendChatMutation(t, chatServer.URL)
+
+				expectedMessage := Message{
+					Id:      "1",
+					Type:    MessageTypeData,
+					Payload: []byte(`{"data":{"messageAdded":{"text":"Hello World!","createdBy":"myuser"}}}`),
+				}
+				require.Eventually(t, func() bool {
+					for _, message := range client.readFromServer() {
+						if message.Type == expectedMessage.Type && string(message.Payload) == string(expectedMessage.Payload) {
+							return true
+						}
+					}
+					return false
+				}, 1*time.Second, 10*time.Millisecond)
+				assert.Equal(t, []string{"logging:1", "auth:1"}, *calls)
+			})
+		})
+
 		t.Run("connection_terminate", func(t *testing.T) {
 			executorPool, _ := setupEngineV2(t, ctx, chatServer.URL)
 			_, client, handlerRoutine := setupSubscriptionHandlerTest(t, executorPool)
@@ -0,0 +1,42 @@
+package subscription
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/wundergraph/graphql-go-tools/pkg/ast"
+)
+
+// OperationInfo describes an operation sent by the client with a start message.
+type OperationInfo struct {
+	// Id is the id of the start message chosen by the client.
+	Id string
+	// OperationType is the type of the operation, e.g. ast.OperationTypeSubscription.
+	OperationType ast.OperationType
+	// Payload is the GraphQL request of the start message.
+	Payload json.RawMessage
+}
+
+// StartFunc starts an operation with the context of the connection.
+// An error rejects the operation, it's sent to the client as an error message.
+type StartFunc func(ctx context.Context, operation OperationInfo) error
+
+// Middleware wraps the start of every operation sent over a connection, e.g. to authorize subscriptions
+// like the http middleware of queries. The context contains the values added by the WebsocketInitFunc.
+// A middleware rejects an operation by returning an error instead of calling next.
+type Middleware func(next StartFunc) StartFunc
+
+// Use registers middlewares around the start of operations. The first middleware is the outermost,
+// middlewares registered by a later call run inside the ones registered before.
+// Use must be called before Handle.
+func (h *Handler) Use(middlewares ...Middleware) {
+	h.middlewares = append(h.middlewares, middlewares...)
+}
+
+// wrapStart wraps start with the registered middlewares.
+func (h *Handler) wrapStart(start StartFunc) StartFunc {
+	for i := len(h.middlewares) - 1; i >= 0; i-- {
+		start = h.middlewares[i](start)
+	}
+	return start
+}
@@ -46,10 +46,14 @@ func (c *mockClient) WriteToClient(message Message) error {
 }
 
 func (c *mockClient) IsConnected() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
 	return c.connected
 }
 
 func (c *mockClient) Disconnect() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
 	c.connected = false
 	return nil
 }
@@ -103,6 +107,22 @@ func (c *mockClient) prepareStopMessage(id string) *mockClient {
 	return c
 }
 
+func (c *mockClient) preparePingMessage() *mockClient {
+	c.messageToServer = &Message{
+		Type: MessageTypePing,
+	}
+
+	return c
+}
+
+func (c *mockClient) preparePongMessage() *mockClient {
+	c.messageToServer = &Message{
+		Type: MessageTypePong,
+	}
+
+	return c
+}
+
 func (c *mockClient) prepareConnectionTerminateMessage() *mockClient {
 	c.messageToServer = &Message{
 		Type: MessageTypeConnectionTerminate,
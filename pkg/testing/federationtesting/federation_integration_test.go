@@ -7,17 +7,27 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"github.com/gobwas/ws/wsutil"
 	"github.com/jensneuse/abstractlogger"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
 	"path"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
+	"github.com/wundergraph/graphql-go-tools/pkg/engine/plan"
+	"github.com/wundergraph/graphql-go-tools/pkg/engine/resolve"
+	"github.com/wundergraph/graphql-go-tools/pkg/graphql"
 	accounts "github.com/wundergraph/graphql-go-tools/pkg/testing/federationtesting/accounts/graph"
 	"github.com/wundergraph/graphql-go-tools/pkg/testing/federationtesting/gateway"
+	gatewayhttp "github.com/wundergraph/graphql-go-tools/pkg/testing/federationtesting/gateway/http"
+	loyalty "github.com/wundergraph/graphql-go-tools/pkg/testing/federationtesting/loyalty/graph"
 	products "github.com/wundergraph/graphql-go-tools/pkg/testing/federationtesting/products/graph"
 	reviews "github.com/wundergraph/graphql-go-tools/pkg/testing/federationtesting/reviews/graph"
 )
@@ -47,6 +57,7 @@ func newFederationSetup() *federationSetup {
 		accountsUpstreamServer: accountUpstreamServer,
 		productsUpstreamServer: productsUpstreamServer,
 		reviewsUpstreamServer:  reviewsUpstreamServer,
+		gateway:                gtw,
 		gatewayServer:          gatewayServer,
 	}
 }
@@ -55,6 +66,7 @@ type federationSetup struct {
 	accountsUpstreamServer *httptest.Server
 	productsUpstreamServer *httptest.Server
 	reviewsUpstreamServer  *httptest.Server
+	gateway                *gateway.Gateway
 	gatewayServer          *httptest.Server
 }
 
@@ -86,6 +98,14 @@ func TestFederationIntegrationTest(t *testing.T) {
 		assert.Equal(t, `{"data":{"topProducts":[{"name":"Trilby","reviews":[{"body":"A highly effective form of birth control.","author":{"username":"Me"}}]},{"name":"Fedora","reviews":[{"body":"Fedoras are one of the most fashionable hats around and can look great with a variety of outfits.","author":{"username":"Me"}}]},{"name":"Boater","reviews":[{"body":"This is the last straw. Hat you will wear. 11/10","author":{"username":"User 7777"}}]}]}}`, string(resp))
 	})
 
+	t.Run("query with deferred fragment", func(t *testing.T) {
+		parts := gqlClient.QueryMultipart(ctx, setup.gatewayServer.URL, path.Join("testdata", "queries/deferred_reviews.query"), nil, t)
+		if assert.Len(t, parts, 2) {
+			assert.Equal(t, `{"data":{"topProducts":[{"name":"Trilby"},{"name":"Fedora"},{"name":"Boater"}]},"hasNext":true}`, string(parts[0]))
+			assert.Equal(t, `{"incremental":[{"data":{"reviews":[{"body":"A highly effective form of birth control."}]},"path":["topProducts",0]},{"data":{"reviews":[{"body":"Fedoras are one of the most fashionable hats around and can look great with a variety of outfits."}]},"path":["topProducts",1]},{"data":{"reviews":[{"body":"This is the last straw. Hat you will wear. 11/10"}]},"path":["topProducts",2]}],"hasNext":false}`, string(parts[1]))
+		}
+	})
+
 	t.Run("mutation operation with variables", func(t *testing.T) {
 		resp := gqlClient.Query(ctx, setup.gatewayServer.URL, path.Join("testdata", "mutations/mutation_with_variables.query"), queryVariables{
 			"authorID": "3210",
@@ -95,14 +115,170 @@ func TestFederationIntegrationTest(t *testing.T) {
 		assert.Equal(t, `{"data":{"addReview":{"body":"This is the last straw. Hat you will wear. 11/10","author":{"username":"User 3210"}}}}`, string(resp))
 	})
 
+	t.Run("query over GET", func(t *testing.T) {
+		statusCode, resp := gqlClient.QueryGet(ctx, setup.gatewayServer.URL, path.Join("testdata", "queries/single_upstream.query"), nil, t)
+		assert.Equal(t, http.StatusOK, statusCode)
+		assert.Equal(t, `{"data":{"me":{"id":"1234","username":"Me"}}}`, string(resp))
+	})
+
+	t.Run("mutation over GET is rejected", func(t *testing.T) {
+		statusCode, resp := gqlClient.QueryGet(ctx, setup.gatewayServer.URL, path.Join("testdata", "mutations/mutation_with_variables.query"), queryVariables{
+			"authorID": "3210",
+			"upc":      "top-1",
+			"review":   "This is the last straw. Hat you will wear. 11/10",
+		}, t)
+		assert.Equal(t, http.StatusMethodNotAllowed, statusCode)
+		assert.Equal(t, `{"errors":[{"message":"only queries are allowed over GET, use POST for mutations and subscriptions"}]}`, string(resp))
+	})
+
+	t.Run("batched query and mutation operations", func(t *testing.T) {
+		resp := gqlClient.QueryBatch(ctx, setup.gatewayServer.URL, []batchOperation{
+			{queryFilePath: path.Join("testdata", "queries/single_upstream.query")},
+			{queryFilePath: path.Join("testdata", "mutations/mutation_with_variables.query"), variables: queryVariables{
+				"authorID": "3210",
+				"upc":      "top-1",
+				"review":   "This is the last straw. Hat you will wear. 11/10",
+			}},
+		}, t)
+		assert.Equal(t, `[{"data":{"me":{"id":"1234","username":"Me"}}},{"data":{"addReview":{"body":"This is the last straw. Hat you will wear. 11/10","author":{"username":"User 3210"}}}}]`, string(resp))
+	})
+
 	t.Run("union query", func(t *testing.T) {
 		resp := gqlClient.Query(ctx, setup.gatewayServer.URL, path.Join("testdata", "queries/union.query"), nil, t)
-		assert.Equal(t, `{"data":{"me":{"username":"Me","history":[{"__typename":"Purchase","wallet":{"amount":123}},{"__typename":"Sale","rating":5},{"__typename":"Purchase","wallet":{"amount":123}}]}}}`, string(resp))
+		assert.Equal(t, `{"data":{"me":{"username":"Me","history":[{"__typename":"Purchase","wallet":{"amount":123.0}},{"__typename":"Sale","rating":5},{"__typename":"Purchase","wallet":{"amount":123.0}}]}}}`, string(resp))
+	})
+
+	t.Run("union query selecting only __typename", func(t *testing.T) {
+		resp := gqlClient.Query(ctx, setup.gatewayServer.URL, path.Join("testdata", "queries/union_typename.query"), nil, t)
+		assert.Equal(t, `{"data":{"me":{"history":[{"__typename":"Purchase"},{"__typename":"Sale"},{"__typename":"Purchase"}]}}}`, string(resp))
+	})
+
+	t.Run("query selecting __typename of the root type", func(t *testing.T) {
+		resp := gqlClient.Query(ctx, setup.gatewayServer.URL, path.Join("testdata", "queries/root_typename.query"), nil, t)
+		assert.Equal(t, `{"data":{"__typename":"Query","me":{"username":"Me"}}}`, string(resp))
 	})
 
 	t.Run("interface query", func(t *testing.T) {
 		resp := gqlClient.Query(ctx, setup.gatewayServer.URL, path.Join("testdata", "queries/interface.query"), nil, t)
-		assert.Equal(t, `{"data":{"me":{"username":"Me","history":[{"wallet":{"amount":123,"specialField1":"some special value 1"}},{"rating":5},{"wallet":{"amount":123,"specialField2":"some special value 2"}}]}}}`, string(resp))
+		assert.Equal(t, `{"data":{"me":{"username":"Me","history":[{"wallet":{"amount":123.0,"specialField1":"some special value 1"}},{"rating":5},{"wallet":{"amount":123.0,"specialField2":"some special value 2"}}]}}}`, string(resp))
+	})
+
+	t.Run("explain query spanning multiple federated servers", func(t *testing.T) {
+		explain := func(t *testing.T, queryFilePath string) plan.ExplainedPlan {
+			resp := gqlClient.Query(ctx, setup.gatewayServer.URL+"?explain", queryFilePath, nil, t)
+			var explained plan.ExplainedPlan
+			require.NoError(t, json.Unmarshal(resp, &explained))
+			assert.Equal(t, plan.ExplainedPlanKindSynchronous, explained.Kind)
+			for i, fetch := range explained.Fetches {
+				assert.Equal(t, i, fetch.Id)
+			}
+			return explained
+		}
+
+		t.Run("provided fields", func(t *testing.T) {
+			explained := explain(t, path.Join("testdata", "queries/multiple_upstream.query"))
+			// the username of review authors is provided by the reviews service
+			require.Len(t, explained.Fetches, 2)
+
+			products := explained.Fetches[0]
+			assert.Equal(t, "products", products.Service)
+			assert.Equal(t, setup.productsUpstreamServer.URL, products.URL)
+			assert.Equal(t, plan.ExplainedFetchKindSingle, products.Kind)
+			assert.Equal(t, "query($a: Int){topProducts(first: $a){name upc}}", products.Query)
+			assert.Equal(t, []string{}, products.Path)
+			assert.Equal(t, []int{}, products.DependsOn)
+
+			reviews := explained.Fetches[1]
+			assert.Equal(t, "reviews", reviews.Service)
+			assert.Equal(t, setup.reviewsUpstreamServer.URL, reviews.URL)
+			assert.Equal(t, plan.ExplainedFetchKindBatch, reviews.Kind)
+			assert.Equal(t, "query($representations: [_Any!]!){_entities(representations: $representations){__typename ... on Product {reviews {body author {username id}}}}}", reviews.Query)
+			assert.Equal(t, `[{"upc":$$object.upc$$,"__typename":"Product"}]`, reviews.Representations)
+			assert.Equal(t, []string{"topProducts", "@"}, reviews.Path)
+			assert.Equal(t, []int{0}, reviews.DependsOn)
+		})
+
+		t.Run("fetches in dependency order", func(t *testing.T) {
+			explained := explain(t, path.Join("testdata", "queries/review_author_history.query"))
+			require.Len(t, explained.Fetches, 3)
+
+			services := make([]string, 0, len(explained.Fetches))
+			for _, fetch := range explained.Fetches {
+				services = append(services, fetch.Service)
+			}
+			assert.Equal(t, []string{"products", "reviews", "accounts"}, services)
+			assert.Equal(t, []int{0}, explained.Fetches[1].DependsOn)
+
+			accounts := explained.Fetches[2]
+			assert.Equal(t, setup.accountsUpstreamServer.URL, accounts.URL)
+			assert.Equal(t, "query($representations: [_Any!]!){_entities(representations: $representations){__typename ... on User {history {__typename ... on Sale {rating}}}}}", accounts.Query)
+			assert.Equal(t, `[{"id":$$object.id$$,"__typename":"User"}]`, accounts.Representations)
+			assert.Equal(t, []string{"topProducts", "@", "reviews", "@", "author"}, accounts.Path)
+			assert.Equal(t, []int{1}, accounts.DependsOn)
+		})
+	})
+
+	t.Run("explain union query selecting only __typename", func(t *testing.T) {
+		explain := func(t *testing.T, queryFilePath string) plan.ExplainedPlan {
+			resp := gqlClient.Query(ctx, setup.gatewayServer.URL+"?explain", queryFilePath, nil, t)
+			var explained plan.ExplainedPlan
+			require.NoError(t, json.Unmarshal(resp, &explained))
+			return explained
+		}
+
+		t.Run("owning subgraph", func(t *testing.T) {
+			explained := explain(t, path.Join("testdata", "queries/union_typename.query"))
+			require.Len(t, explained.Fetches, 1)
+			assert.Equal(t, "accounts", explained.Fetches[0].Service)
+		})
+
+		t.Run("nested in entities", func(t *testing.T) {
+			explained := explain(t, path.Join("testdata", "queries/review_author_history_typename.query"))
+			require.Len(t, explained.Fetches, 3)
+
+			services := make([]string, 0, len(explained.Fetches))
+			for _, fetch := range explained.Fetches {
+				services = append(services, fetch.Service)
+			}
+			assert.Equal(t, []string{"products", "reviews", "accounts"}, services)
+			// the __typename of the history is fetched along with the history from the accounts service only
+			assert.NotContains(t, explained.Fetches[0].Query, "__typename")
+			assert.NotContains(t, explained.Fetches[1].Query, "history")
+			assert.Contains(t, explained.Fetches[2].Query, "history {__typename")
+
+			resp := gqlClient.Query(ctx, setup.gatewayServer.URL, path.Join("testdata", "queries/review_author_history_typename.query"), nil, t)
+			history := `{"history":[{"__typename":"Purchase"},{"__typename":"Sale"},{"__typename":"Purchase"}]}`
+			assert.Equal(t, `{"data":{"topProducts":[{"reviews":[{"author":`+history+`}]},{"reviews":[{"author":`+history+`}]},{"reviews":[{"author":`+history+`}]}]}}`, string(resp))
+		})
+	})
+
+	t.Run("subgraph metrics of query spanning multiple federated servers", func(t *testing.T) {
+		header := http.Header{"X-Graphql-Subgraph-Metrics": []string{"true"}}
+		resp := gqlClient.QueryWithHeader(ctx, setup.gatewayServer.URL, path.Join("testdata", "queries/review_author_history.query"), nil, header, t)
+
+		var response struct {
+			Data       json.RawMessage `json:"data"`
+			Extensions struct {
+				Subgraphs []resolve.SubgraphMetric `json:"subgraphs"`
+			} `json:"extensions"`
+		}
+		require.NoError(t, json.Unmarshal(resp, &response))
+		assert.NotEqual(t, "null", string(response.Data))
+
+		subgraphs := response.Extensions.Subgraphs
+		require.Len(t, subgraphs, 3)
+		for i, service := range []string{"accounts", "products", "reviews"} {
+			assert.Equal(t, service, subgraphs[i].Subgraph)
+			assert.Greater(t, subgraphs[i].Fetches, 0)
+			assert.Greater(t, subgraphs[i].Bytes, 0)
+			assert.Greater(t, subgraphs[i].LatencyNanoseconds, int64(0))
+		}
+		// without the data loader the reviews are fetched for each of the 3 products
+		assert.Equal(t, 1, subgraphs[1].Fetches)
+		assert.Equal(t, 3, subgraphs[2].Fetches)
+
+		resp = gqlClient.Query(ctx, setup.gatewayServer.URL, path.Join("testdata", "queries/review_author_history.query"), nil, t)
+		assert.NotContains(t, string(resp), "extensions")
 	})
 
 	t.Run("subscription query through WebSocket transport", func(t *testing.T) {
@@ -207,6 +383,14 @@ func TestFederationIntegrationTest(t *testing.T) {
 		assert.Equal(t, compact(expected), string(resp))
 	})
 
+	t.Run("operation selected by name from multiple operations", func(t *testing.T) {
+		resp := gqlClient.QueryOperation(ctx, setup.gatewayServer.URL, path.Join("testdata", "queries/multiple_operations.query"), "Me", nil, t)
+		assert.Equal(t, `{"data":{"me":{"id":"1234","username":"Me"}}}`, string(resp))
+
+		resp = gqlClient.QueryOperation(ctx, setup.gatewayServer.URL, path.Join("testdata", "queries/multiple_operations.query"), "TopProducts", nil, t)
+		assert.Equal(t, `{"data":{"topProducts":[{"upc":"top-1","price":11},{"upc":"top-2","price":22},{"upc":"top-3","price":33}]}}`, string(resp))
+	})
+
 	t.Run("Query that returns union", func(t *testing.T) {
 		ctx, cancel := context.WithCancel(ctx)
 		defer cancel()
@@ -292,3 +476,868 @@ func compact(input string) string {
 	}
 	return out.String()
 }
+
+func TestFederationIntegrationTest_Shutdown(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	// Reset the products slice to the original state
+	defer products.Reset()
+
+	setup := newFederationSetup()
+	defer setup.close()
+
+	gqlClient := NewGraphqlClient(http.DefaultClient)
+
+	wsAddr := strings.ReplaceAll(setup.gatewayServer.URL, "http://", "ws://")
+	conn := gqlClient.StartSubscription(ctx, wsAddr, path.Join("testdata", "subscriptions/subscription.query"), queryVariables{
+		"upc": "top-1",
+	}, t)
+	defer conn.Close()
+
+	assert.Equal(t, `{"id":"1","type":"data","payload":{"data":{"updateProductPrice":{"upc":"top-1","name":"Trilby","price":1}}}}`, string(gqlClient.readMessageFromServer(t, conn)))
+
+	shutdownCtx, cancelShutdown := context.WithTimeout(ctx, 5*time.Second)
+	defer cancelShutdown()
+	require.NoError(t, setup.gateway.Shutdown(shutdownCtx))
+
+	var lastMessage []byte
+	for {
+		msgBytes, _, err := wsutil.ReadServerData(conn)
+		if err != nil {
+			break
+		}
+		lastMessage = msgBytes
+	}
+	assert.Equal(t, `{"id":"1","type":"complete","payload":null}`, string(lastMessage))
+
+	resp, err := http.Post(setup.gatewayServer.URL, "application/json", strings.NewReader(`{"query":"{ me { id } }"}`))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+}
+
+func TestFederationIntegrationTest_ErrorPresenter(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	accountsHandler := accounts.GraphQLEndpointHandler(accounts.TestOptions)
+	// the subgraph answers the sdl query of the poller but fails every operation with an internal error
+	accountsUpstreamServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := ioutil.ReadAll(r.Body)
+		require.NoError(t, err)
+		if bytes.Contains(body, []byte("_service")) {
+			r.Body = ioutil.NopCloser(bytes.NewReader(body))
+			accountsHandler.ServeHTTP(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = w.Write([]byte(`{"errors":[{"message":"dial tcp accounts.internal:5432: connect: connection refused"}]}`))
+	}))
+	defer accountsUpstreamServer.Close()
+	productsUpstreamServer := httptest.NewServer(products.GraphQLEndpointHandler(products.TestOptions))
+	defer productsUpstreamServer.Close()
+	reviewsUpstreamServer := httptest.NewServer(reviews.GraphQLEndpointHandler(reviews.TestOptions))
+	defer reviewsUpstreamServer.Close()
+
+	httpClient := http.DefaultClient
+	poller := gateway.NewDatasource([]gateway.ServiceConfig{
+		{Name: "accounts", URL: accountsUpstreamServer.URL},
+		{Name: "products", URL: productsUpstreamServer.URL},
+		{Name: "reviews", URL: reviewsUpstreamServer.URL},
+	}, httpClient)
+
+	var presentedErrors []error
+	presenter := func(ctx context.Context, err error) gatewayhttp.GraphQLError {
+		presentedErrors = append(presentedErrors, err)
+		if _, ok := err.(*gatewayhttp.ResponseError); ok {
+			return gatewayhttp.GraphQLError{
+				Message:    "Internal error",
+				Extensions: map[string]interface{}{"code": "INTERNAL"},
+			}
+		}
+		return gatewayhttp.DefaultErrorPresenter(ctx, err)
+	}
+
+	gtw := gateway.Handler(abstractlogger.NoopLogger, poller, httpClient, gateway.WithErrorPresenter(presenter))
+
+	pollerCtx, cancelPoller := context.WithTimeout(ctx, 1*time.Second)
+	defer cancelPoller()
+	poller.Run(pollerCtx)
+
+	gatewayServer := httptest.NewServer(gtw)
+	defer gatewayServer.Close()
+
+	gqlClient := NewGraphqlClient(http.DefaultClient)
+
+	t.Run("subgraph error is replaced", func(t *testing.T) {
+		presentedErrors = nil
+		resp := gqlClient.Query(ctx, gatewayServer.URL, path.Join("testdata", "queries/single_upstream.query"), nil, t)
+		assert.Equal(t, `{"errors":[{"message":"Internal error","extensions":{"code":"INTERNAL"}}],"data":{"me":null}}`, string(resp))
+
+		require.Len(t, presentedErrors, 1)
+		assert.EqualError(t, presentedErrors[0], "dial tcp accounts.internal:5432: connect: connection refused")
+	})
+
+	t.Run("validation error is presented", func(t *testing.T) {
+		presentedErrors = nil
+		resp := gqlClient.post(ctx, gatewayServer.URL, []byte(`{"query":"{ me { password } }"}`), nil, t)
+		assert.Equal(t, `{"errors":[{"message":"field: password not defined on type: User","locations":[{"line":1,"column":8}],"path":["query","me","password"]}]}`, string(resp))
+
+		require.Len(t, presentedErrors, 1)
+		assert.IsType(t, graphql.RequestError{}, presentedErrors[0])
+	})
+
+	t.Run("missing operation name of multiple operations is presented", func(t *testing.T) {
+		resp := gqlClient.Query(ctx, gatewayServer.URL, path.Join("testdata", "queries/multiple_operations.query"), nil, t)
+		assert.Equal(t, `{"errors":[{"message":"operation name is required when providing multiple operations"}]}`, string(resp))
+	})
+
+	t.Run("unknown operation name is presented", func(t *testing.T) {
+		resp := gqlClient.QueryOperation(ctx, gatewayServer.URL, path.Join("testdata", "queries/multiple_operations.query"), "Unknown", nil, t)
+		assert.Equal(t, `{"errors":[{"message":"cannot find an operation with name: Unknown"}]}`, string(resp))
+	})
+}
+
+func TestFederationIntegrationTest_EntityFetchDeduplication(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	accountsUpstreamServer := httptest.NewServer(accounts.GraphQLEndpointHandler(accounts.TestOptions))
+	defer accountsUpstreamServer.Close()
+	reviewsUpstreamServer := httptest.NewServer(reviews.GraphQLEndpointHandler(reviews.TestOptions))
+	defer reviewsUpstreamServer.Close()
+
+	productsHandler := products.GraphQLEndpointHandler(products.TestOptions)
+	var (
+		entityFetchesMu sync.Mutex
+		entityFetches   []string
+	)
+	productsUpstreamServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := ioutil.ReadAll(r.Body)
+		require.NoError(t, err)
+		if bytes.Contains(body, []byte("_entities")) {
+			entityFetchesMu.Lock()
+			entityFetches = append(entityFetches, string(body))
+			entityFetchesMu.Unlock()
+		}
+		r.Body = ioutil.NopCloser(bytes.NewReader(body))
+		productsHandler.ServeHTTP(w, r)
+	}))
+	defer productsUpstreamServer.Close()
+
+	httpClient := http.DefaultClient
+	poller := gateway.NewDatasource([]gateway.ServiceConfig{
+		{Name: "accounts", URL: accountsUpstreamServer.URL},
+		{Name: "products", URL: productsUpstreamServer.URL},
+		{Name: "reviews", URL: reviewsUpstreamServer.URL},
+	}, httpClient)
+	gtw := gateway.Handler(abstractlogger.NoopLogger, poller, httpClient)
+
+	pollerCtx, cancelPoller := context.WithTimeout(ctx, 1*time.Second)
+	defer cancelPoller()
+	poller.Run(pollerCtx)
+
+	gatewayServer := httptest.NewServer(gtw)
+	defer gatewayServer.Close()
+
+	gqlClient := NewGraphqlClient(http.DefaultClient)
+
+	t.Run("aliased fields of the same product are fetched once", func(t *testing.T) {
+		resp := gqlClient.post(ctx, gatewayServer.URL, requestBody(t, `{ topProducts { upc reviews { a: product { name } b: product { name } } } }`, nil), nil, t)
+
+		var result struct {
+			Data struct {
+				TopProducts []struct {
+					Upc     string `json:"upc"`
+					Reviews []struct {
+						A struct{ Name string } `json:"a"`
+						B struct{ Name string } `json:"b"`
+					} `json:"reviews"`
+				} `json:"topProducts"`
+			} `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(resp, &result))
+		require.Len(t, result.Data.TopProducts, 3)
+		for _, product := range result.Data.TopProducts {
+			require.NotEmpty(t, product.Reviews)
+			for _, review := range product.Reviews {
+				assert.NotEmpty(t, review.A.Name)
+				assert.Equal(t, review.A, review.B)
+			}
+		}
+
+		// one entity fetch per product, although every review selects its product twice
+		entityFetchesMu.Lock()
+		defer entityFetchesMu.Unlock()
+		require.Len(t, entityFetches, 3)
+		for _, product := range result.Data.TopProducts {
+			representation := fmt.Sprintf(`"representations":[{"upc":"%s","__typename":"Product"}]`, product.Upc)
+			var fetches int
+			for _, entityFetch := range entityFetches {
+				if strings.Contains(entityFetch, representation) {
+					fetches++
+				}
+			}
+			assert.Equal(t, 1, fetches, product.Upc)
+		}
+	})
+}
+
+func TestFederationIntegrationTest_ParallelFetches(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// barrier holds every request of a service but the sdl query of the poller until both services received one,
+	// which only happens in time if the fetches run in parallel
+	var arrivals, timedOut int32
+	bothArrived := make(chan struct{})
+	barrier := func(handler http.Handler) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, err := ioutil.ReadAll(r.Body)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			if !bytes.Contains(body, []byte("_service")) {
+				if atomic.AddInt32(&arrivals, 1) == 2 {
+					close(bothArrived)
+				}
+				select {
+				case <-bothArrived:
+				case <-time.After(time.Second):
+					atomic.StoreInt32(&timedOut, 1)
+				}
+			}
+			r.Body = ioutil.NopCloser(bytes.NewReader(body))
+			handler.ServeHTTP(w, r)
+		}))
+	}
+
+	accountsUpstreamServer := barrier(accounts.GraphQLEndpointHandler(accounts.TestOptions))
+	defer accountsUpstreamServer.Close()
+	productsUpstreamServer := barrier(products.GraphQLEndpointHandler(products.TestOptions))
+	defer productsUpstreamServer.Close()
+	reviewsUpstreamServer := httptest.NewServer(reviews.GraphQLEndpointHandler(reviews.TestOptions))
+	defer reviewsUpstreamServer.Close()
+
+	httpClient := http.DefaultClient
+	poller := gateway.NewDatasource([]gateway.ServiceConfig{
+		{Name: "accounts", URL: accountsUpstreamServer.URL},
+		{Name: "products", URL: productsUpstreamServer.URL},
+		{Name: "reviews", URL: reviewsUpstreamServer.URL},
+	}, httpClient)
+	gtw := gateway.Handler(abstractlogger.NoopLogger, poller, httpClient)
+
+	pollerCtx, cancelPoller := context.WithTimeout(ctx, 1*time.Second)
+	defer cancelPoller()
+	poller.Run(pollerCtx)
+
+	gatewayServer := httptest.NewServer(gtw)
+	defer gatewayServer.Close()
+
+	gqlClient := NewGraphqlClient(http.DefaultClient)
+	queryFilePath := path.Join("testdata", "queries/independent_roots.query")
+
+	t.Run("fetches of independent root fields don't depend on each other", func(t *testing.T) {
+		resp := gqlClient.Query(ctx, gatewayServer.URL+"?explain", queryFilePath, nil, t)
+		var explained plan.ExplainedPlan
+		require.NoError(t, json.Unmarshal(resp, &explained))
+		require.Len(t, explained.Fetches, 2)
+
+		services := make([]string, 0, len(explained.Fetches))
+		for _, fetch := range explained.Fetches {
+			services = append(services, fetch.Service)
+			assert.Equal(t, []int{}, fetch.DependsOn)
+		}
+		assert.ElementsMatch(t, []string{"accounts", "products"}, services)
+	})
+
+	t.Run("fetches of independent root fields run in parallel", func(t *testing.T) {
+		resp := gqlClient.Query(ctx, gatewayServer.URL, queryFilePath, nil, t)
+
+		assert.Equal(t, `{"data":{"me":{"username":"Me"},"topProducts":[{"name":"Trilby"},{"name":"Fedora"},{"name":"Boater"}]}}`, string(resp))
+		assert.Equal(t, int32(2), atomic.LoadInt32(&arrivals))
+		assert.Equal(t, int32(0), atomic.LoadInt32(&timedOut), "a fetch waited for the other one")
+	})
+}
+
+func TestFederationIntegrationTest_EntityExtendedByThirdSubgraph(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	accountsUpstreamServer := httptest.NewServer(accounts.GraphQLEndpointHandler(accounts.TestOptions))
+	defer accountsUpstreamServer.Close()
+	productsUpstreamServer := httptest.NewServer(products.GraphQLEndpointHandler(products.TestOptions))
+	defer productsUpstreamServer.Close()
+	reviewsUpstreamServer := httptest.NewServer(reviews.GraphQLEndpointHandler(reviews.TestOptions))
+	defer reviewsUpstreamServer.Close()
+	loyaltyUpstreamServer := httptest.NewServer(loyalty.GraphQLEndpointHandler())
+	defer loyaltyUpstreamServer.Close()
+
+	httpClient := http.DefaultClient
+	poller := gateway.NewDatasource([]gateway.ServiceConfig{
+		{Name: "accounts", URL: accountsUpstreamServer.URL},
+		{Name: "products", URL: productsUpstreamServer.URL},
+		{Name: "reviews", URL: reviewsUpstreamServer.URL},
+		{Name: "loyalty", URL: loyaltyUpstreamServer.URL},
+	}, httpClient)
+	gtw := gateway.Handler(abstractlogger.NoopLogger, poller, httpClient)
+
+	pollerCtx, cancelPoller := context.WithTimeout(ctx, 1*time.Second)
+	defer cancelPoller()
+	poller.Run(pollerCtx)
+
+	gatewayServer := httptest.NewServer(gtw)
+	defer gatewayServer.Close()
+
+	gqlClient := NewGraphqlClient(http.DefaultClient)
+
+	t.Run("points of review authors resolve through products, reviews and loyalty", func(t *testing.T) {
+		resp := gqlClient.Query(ctx, gatewayServer.URL, path.Join("testdata", "queries/review_author_points.query"), nil, t)
+		assert.Equal(t, `{"data":{"topProducts":[{"name":"Trilby","reviews":[{"author":{"username":"Me","points":120}}]},{"name":"Fedora","reviews":[{"author":{"username":"Me","points":120}}]},{"name":"Boater","reviews":[{"author":{"username":"User 7777","points":30}}]}]}}`, string(resp))
+	})
+
+	t.Run("points of review authors are fetched by the author id of the reviews fetch", func(t *testing.T) {
+		resp := gqlClient.Query(ctx, gatewayServer.URL+"?explain", path.Join("testdata", "queries/review_author_points.query"), nil, t)
+		var explained plan.ExplainedPlan
+		require.NoError(t, json.Unmarshal(resp, &explained))
+		require.Len(t, explained.Fetches, 3)
+
+		services := make([]string, 0, len(explained.Fetches))
+		for _, fetch := range explained.Fetches {
+			services = append(services, fetch.Service)
+		}
+		assert.Equal(t, []string{"products", "reviews", "loyalty"}, services)
+
+		loyaltyFetch := explained.Fetches[2]
+		assert.Equal(t, plan.ExplainedFetchKindBatch, loyaltyFetch.Kind)
+		assert.Equal(t, `[{"id":$$object.id$$,"__typename":"User"}]`, loyaltyFetch.Representations)
+		assert.Equal(t, []string{"topProducts", "@", "reviews", "@", "author"}, loyaltyFetch.Path)
+		assert.Equal(t, []int{1}, loyaltyFetch.DependsOn)
+	})
+
+	t.Run("points of a user resolve through accounts and loyalty", func(t *testing.T) {
+		resp := gqlClient.Query(ctx, gatewayServer.URL, path.Join("testdata", "queries/my_points.query"), nil, t)
+		assert.Equal(t, `{"data":{"me":{"username":"Me","points":120}}}`, string(resp))
+	})
+}
+
+func TestFederationIntegrationTest_CircuitBreaker(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	accountsUpstreamServer := httptest.NewServer(accounts.GraphQLEndpointHandler(accounts.TestOptions))
+	defer accountsUpstreamServer.Close()
+	productsUpstreamServer := httptest.NewServer(products.GraphQLEndpointHandler(products.TestOptions))
+	defer productsUpstreamServer.Close()
+
+	reviewsHandler := reviews.GraphQLEndpointHandler(reviews.TestOptions)
+	var (
+		reviewsFailing  int32
+		reviewsRequests int32
+	)
+	reviewsUpstreamServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := ioutil.ReadAll(r.Body)
+		require.NoError(t, err)
+		if !bytes.Contains(body, []byte("_service")) {
+			atomic.AddInt32(&reviewsRequests, 1)
+			if atomic.LoadInt32(&reviewsFailing) == 1 {
+				w.WriteHeader(http.StatusInternalServerError)
+				_, _ = w.Write([]byte(`{"errors":[{"message":"internal server error"}]}`))
+				return
+			}
+		}
+		r.Body = ioutil.NopCloser(bytes.NewReader(body))
+		reviewsHandler.ServeHTTP(w, r)
+	}))
+	defer reviewsUpstreamServer.Close()
+
+	const cooldown = 200 * time.Millisecond
+
+	httpClient := http.DefaultClient
+	poller := gateway.NewDatasource([]gateway.ServiceConfig{
+		{Name: "accounts", URL: accountsUpstreamServer.URL},
+		{Name: "products", URL: productsUpstreamServer.URL},
+		{
+			Name: "reviews",
+			URL:  reviewsUpstreamServer.URL,
+			CircuitBreaker: gateway.CircuitBreakerConfig{
+				FailureThreshold: 2,
+				FailureWindow:    time.Minute,
+				Cooldown:         cooldown,
+			},
+		},
+	}, httpClient)
+	gtw := gateway.Handler(abstractlogger.NoopLogger, poller, httpClient)
+
+	pollerCtx, cancelPoller := context.WithTimeout(ctx, 1*time.Second)
+	defer cancelPoller()
+	poller.Run(pollerCtx)
+
+	gatewayServer := httptest.NewServer(gtw)
+	defer gatewayServer.Close()
+
+	gqlClient := NewGraphqlClient(http.DefaultClient)
+	query := func() string {
+		return string(gqlClient.post(ctx, gatewayServer.URL, requestBody(t, `{ me { id reviews { body } } }`, nil), nil, t))
+	}
+
+	atomic.StoreInt32(&reviewsFailing, 1)
+
+	t.Run("failures open the circuit", func(t *testing.T) {
+		assert.Equal(t, `{"errors":[{"message":"internal server error"}],"data":{"me":{"id":"1234","reviews":null}}}`, query())
+		assert.Equal(t, `{"errors":[{"message":"internal server error"}],"data":{"me":{"id":"1234","reviews":null}}}`, query())
+		assert.Equal(t, int32(2), atomic.LoadInt32(&reviewsRequests))
+	})
+
+	t.Run("open circuit fails fast without calling the service", func(t *testing.T) {
+		start := time.Now()
+		for i := 0; i < 3; i++ {
+			assert.Equal(t, `{"errors":[{"message":"service reviews is unavailable: circuit breaker is open","extensions":{"code":"SERVICE_UNAVAILABLE","serviceName":"reviews"}}],"data":{"me":{"id":"1234","reviews":null}}}`, query())
+		}
+		assert.Less(t, int64(time.Since(start)), int64(cooldown))
+		assert.Equal(t, int32(2), atomic.LoadInt32(&reviewsRequests))
+	})
+
+	atomic.StoreInt32(&reviewsFailing, 0)
+
+	t.Run("circuit closes once the service recovered", func(t *testing.T) {
+		time.Sleep(cooldown)
+		assert.Equal(t, `{"data":{"me":{"id":"1234","reviews":[{"body":"A highly effective form of birth control."},{"body":"Fedoras are one of the most fashionable hats around and can look great with a variety of outfits."}]}}}`, query())
+		assert.Equal(t, `{"data":{"me":{"id":"1234","reviews":[{"body":"A highly effective form of birth control."},{"body":"Fedoras are one of the most fashionable hats around and can look great with a variety of outfits."}]}}}`, query())
+		assert.Equal(t, int32(4), atomic.LoadInt32(&reviewsRequests))
+	})
+}
+
+func TestFederationIntegrationTest_RetryAfter(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	accountsUpstreamServer := httptest.NewServer(accounts.GraphQLEndpointHandler(accounts.TestOptions))
+	defer accountsUpstreamServer.Close()
+	productsUpstreamServer := httptest.NewServer(products.GraphQLEndpointHandler(products.TestOptions))
+	defer productsUpstreamServer.Close()
+
+	reviewsHandler := reviews.GraphQLEndpointHandler(reviews.TestOptions)
+	var (
+		reviewsRejections int32
+		reviewsRequestsMu sync.Mutex
+		reviewsRequestAt  []time.Time
+	)
+	reviewsUpstreamServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := ioutil.ReadAll(r.Body)
+		require.NoError(t, err)
+		if !bytes.Contains(body, []byte("_service")) {
+			reviewsRequestsMu.Lock()
+			reviewsRequestAt = append(reviewsRequestAt, time.Now())
+			reviewsRequestsMu.Unlock()
+			if atomic.AddInt32(&reviewsRejections, -1) >= 0 {
+				w.Header().Set("Retry-After", "1")
+				w.WriteHeader(http.StatusTooManyRequests)
+				_, _ = w.Write([]byte("Too Many Requests"))
+				return
+			}
+		}
+		r.Body = ioutil.NopCloser(bytes.NewReader(body))
+		reviewsHandler.ServeHTTP(w, r)
+	}))
+	defer reviewsUpstreamServer.Close()
+
+	httpClient := http.DefaultClient
+	poller := gateway.NewDatasource([]gateway.ServiceConfig{
+		{Name: "accounts", URL: accountsUpstreamServer.URL},
+		{Name: "products", URL: productsUpstreamServer.URL},
+		{
+			Name:  "reviews",
+			URL:   reviewsUpstreamServer.URL,
+			Retry: gateway.RetryConfig{MaxRetries: 1},
+		},
+	}, httpClient)
+	gtw := gateway.Handler(abstractlogger.NoopLogger, poller, httpClient)
+
+	pollerCtx, cancelPoller := context.WithTimeout(ctx, 1*time.Second)
+	defer cancelPoller()
+	poller.Run(pollerCtx)
+
+	gatewayServer := httptest.NewServer(gtw)
+	defer gatewayServer.Close()
+
+	gqlClient := NewGraphqlClient(http.DefaultClient)
+	query := func() string {
+		return string(gqlClient.post(ctx, gatewayServer.URL, requestBody(t, `{ me { id reviews { body } } }`, nil), nil, t))
+	}
+	reset := func(rejections int32) {
+		atomic.StoreInt32(&reviewsRejections, rejections)
+		reviewsRequestsMu.Lock()
+		reviewsRequestAt = nil
+		reviewsRequestsMu.Unlock()
+	}
+	waitBeforeRetry := func(t *testing.T) {
+		reviewsRequestsMu.Lock()
+		defer reviewsRequestsMu.Unlock()
+		require.Len(t, reviewsRequestAt, 2)
+		assert.GreaterOrEqual(t, int64(reviewsRequestAt[1].Sub(reviewsRequestAt[0])), int64(time.Second))
+	}
+
+	t.Run("waits for Retry-After before retrying", func(t *testing.T) {
+		reset(1)
+		assert.Equal(t, `{"data":{"me":{"id":"1234","reviews":[{"body":"A highly effective form of birth control."},{"body":"Fedoras are one of the most fashionable hats around and can look great with a variety of outfits."}]}}}`, query())
+		waitBeforeRetry(t)
+	})
+
+	t.Run("still limited after the retries", func(t *testing.T) {
+		reset(2)
+		assert.Equal(t, `{"errors":[{"message":"service reviews is rate limited","extensions":{"code":"RATE_LIMITED","retryAfter":1,"serviceName":"reviews"}}],"data":{"me":{"id":"1234","reviews":null}}}`, query())
+		waitBeforeRetry(t)
+	})
+}
+
+func TestFederationIntegrationTest_FieldMock(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	accountsUpstreamServer := httptest.NewServer(accounts.GraphQLEndpointHandler(accounts.TestOptions))
+	defer accountsUpstreamServer.Close()
+	reviewsUpstreamServer := httptest.NewServer(reviews.GraphQLEndpointHandler(reviews.TestOptions))
+	defer reviewsUpstreamServer.Close()
+
+	productsHandler := products.GraphQLEndpointHandler(products.TestOptions)
+	var (
+		productsQueriesMu sync.Mutex
+		productsQueries   []string
+	)
+	productsUpstreamServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := ioutil.ReadAll(r.Body)
+		require.NoError(t, err)
+		productsQueriesMu.Lock()
+		productsQueries = append(productsQueries, string(body))
+		productsQueriesMu.Unlock()
+		r.Body = ioutil.NopCloser(bytes.NewReader(body))
+		productsHandler.ServeHTTP(w, r)
+	}))
+	defer productsUpstreamServer.Close()
+
+	var mockCalls int32
+	mockProductName := func(ctx context.Context) ([]byte, error) {
+		atomic.AddInt32(&mockCalls, 1)
+		return []byte(`"MOCK"`), nil
+	}
+
+	httpClient := http.DefaultClient
+	poller := gateway.NewDatasource([]gateway.ServiceConfig{
+		{Name: "accounts", URL: accountsUpstreamServer.URL},
+		{Name: "products", URL: productsUpstreamServer.URL},
+		{Name: "reviews", URL: reviewsUpstreamServer.URL},
+	}, httpClient)
+	gtw := gateway.Handler(abstractlogger.NoopLogger, poller, httpClient, gateway.WithFieldMock("Product", "name", mockProductName))
+
+	pollerCtx, cancelPoller := context.WithTimeout(ctx, 1*time.Second)
+	defer cancelPoller()
+	poller.Run(pollerCtx)
+
+	gatewayServer := httptest.NewServer(gtw)
+	defer gatewayServer.Close()
+
+	gqlClient := NewGraphqlClient(http.DefaultClient)
+
+	t.Run("mocked and real fields are merged", func(t *testing.T) {
+		// prices are changed by other tests, so the expected prices come from the subgraph itself
+		prices := gqlClient.post(ctx, productsUpstreamServer.URL, requestBody(t, `{ topProducts { upc price } }`, nil), http.Header{"Content-Type": []string{"application/json"}}, t)
+		var subgraphResponse struct {
+			Data struct {
+				TopProducts []struct {
+					Upc   string `json:"upc"`
+					Price int    `json:"price"`
+				} `json:"topProducts"`
+			} `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(prices, &subgraphResponse))
+		require.Len(t, subgraphResponse.Data.TopProducts, 3)
+
+		productsQueriesMu.Lock()
+		productsQueries = nil
+		productsQueriesMu.Unlock()
+		atomic.StoreInt32(&mockCalls, 0)
+
+		resp := gqlClient.post(ctx, gatewayServer.URL, requestBody(t, `{ topProducts { upc name price } }`, nil), nil, t)
+
+		expected := make([]string, 0, len(subgraphResponse.Data.TopProducts))
+		for _, product := range subgraphResponse.Data.TopProducts {
+			expected = append(expected, fmt.Sprintf(`{"upc":"%s","name":"MOCK","price":%d}`, product.Upc, product.Price))
+		}
+		assert.Equal(t, `{"data":{"topProducts":[`+strings.Join(expected, ",")+`]}}`, string(resp))
+		assert.Equal(t, int32(3), atomic.LoadInt32(&mockCalls))
+
+		productsQueriesMu.Lock()
+		defer productsQueriesMu.Unlock()
+		require.Len(t, productsQueries, 1)
+		assert.NotContains(t, productsQueries[0], "name")
+	})
+
+	t.Run("mocked field of an entity fetched from another subgraph", func(t *testing.T) {
+		resp := gqlClient.post(ctx, gatewayServer.URL, requestBody(t, `{ me { reviews { product { upc name } } } }`, nil), nil, t)
+		assert.Equal(t, `{"data":{"me":{"reviews":[{"product":{"upc":"top-1","name":"MOCK"}},{"product":{"upc":"top-2","name":"MOCK"}}]}}}`, string(resp))
+	})
+}
+
+func TestFederationIntegrationTest_ForwardInitPayload(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	// Reset the products slice to the original state
+	defer products.Reset()
+
+	accountsUpstreamServer := httptest.NewServer(accounts.GraphQLEndpointHandler(accounts.TestOptions))
+	defer accountsUpstreamServer.Close()
+	productsUpstreamServer := httptest.NewServer(products.GraphQLEndpointHandler(products.TestOptions))
+	defer productsUpstreamServer.Close()
+	reviewsUpstreamServer := httptest.NewServer(reviews.GraphQLEndpointHandler(reviews.TestOptions))
+	defer reviewsUpstreamServer.Close()
+
+	httpClient := http.DefaultClient
+	poller := gateway.NewDatasource([]gateway.ServiceConfig{
+		{Name: "accounts", URL: accountsUpstreamServer.URL},
+		{
+			Name: "products",
+			URL:  productsUpstreamServer.URL,
+			WS:   strings.ReplaceAll(productsUpstreamServer.URL, "http:", "ws:"),
+			ForwardInitPayloadFields: map[string]string{
+				"token": "authToken",
+			},
+		},
+		{Name: "reviews", URL: reviewsUpstreamServer.URL},
+	}, httpClient)
+	gtw := gateway.Handler(abstractlogger.NoopLogger, poller, httpClient)
+
+	pollerCtx, cancelPoller := context.WithTimeout(ctx, 1*time.Second)
+	defer cancelPoller()
+	poller.Run(pollerCtx)
+	gatewayServer := httptest.NewServer(gtw)
+	defer gatewayServer.Close()
+
+	gqlClient := NewGraphqlClient(http.DefaultClient)
+	wsAddr := strings.ReplaceAll(gatewayServer.URL, "http://", "ws://")
+	conn := gqlClient.StartSubscriptionWithInitPayload(ctx, wsAddr, path.Join("testdata", "subscriptions/subscription.query"), queryVariables{
+		"upc": "top-1",
+	}, json.RawMessage(`{"token":"secret-token","password":"secret-password"}`), t)
+	defer conn.Close()
+
+	assert.Equal(t, `{"id":"1","type":"data","payload":{"data":{"updateProductPrice":{"upc":"top-1","name":"Trilby","price":1}}}}`, string(gqlClient.readMessageFromServer(t, conn)))
+
+	// only the configured field is forwarded, renamed as configured
+	initPayload := products.WebsocketInitPayload()
+	assert.Equal(t, "secret-token", initPayload["authToken"])
+	assert.NotContains(t, initPayload, "token")
+	assert.NotContains(t, initPayload, "password")
+}
+
+func TestFederationIntegrationTest_SubscriptionFilter(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	// Reset the products slice to the original state
+	defer products.Reset()
+
+	// the subgraph publishes the price updates of top-1, top-2 and top-3 in turn to every subscription
+	productsOptions := products.TestOptions
+	productsOptions.PublishAllPriceUpdates = true
+
+	accountsUpstreamServer := httptest.NewServer(accounts.GraphQLEndpointHandler(accounts.TestOptions))
+	defer accountsUpstreamServer.Close()
+	productsUpstreamServer := httptest.NewServer(products.GraphQLEndpointHandler(productsOptions))
+	defer productsUpstreamServer.Close()
+	reviewsUpstreamServer := httptest.NewServer(reviews.GraphQLEndpointHandler(reviews.TestOptions))
+	defer reviewsUpstreamServer.Close()
+
+	httpClient := http.DefaultClient
+	poller := gateway.NewDatasource([]gateway.ServiceConfig{
+		{Name: "accounts", URL: accountsUpstreamServer.URL},
+		{Name: "products", URL: productsUpstreamServer.URL},
+		{Name: "reviews", URL: reviewsUpstreamServer.URL},
+	}, httpClient)
+	gtw := gateway.Handler(abstractlogger.NoopLogger, poller, httpClient,
+		gateway.WithSubscriptionFilter("Subscription", "updateProductPrice", plan.SubscriptionFilterConditionConfiguration{
+			ArgumentName: "upc",
+			FieldPath:    []string{"upc"},
+		}),
+	)
+
+	pollerCtx, cancelPoller := context.WithTimeout(ctx, 1*time.Second)
+	defer cancelPoller()
+	poller.Run(pollerCtx)
+	gatewayServer := httptest.NewServer(gtw)
+	defer gatewayServer.Close()
+
+	gqlClient := NewGraphqlClient(http.DefaultClient)
+	wsAddr := strings.ReplaceAll(gatewayServer.URL, "http://", "ws://")
+	messages := gqlClient.Subscription(ctx, wsAddr, path.Join("testdata", "subscriptions/subscription.query"), queryVariables{
+		"upc": "top-2",
+	}, t)
+
+	// the updates of top-1 and top-3 in between are dropped by the gateway
+	assert.Equal(t, `{"id":"1","type":"data","payload":{"data":{"updateProductPrice":{"upc":"top-2","name":"Fedora","price":2}}}}`, string(<-messages))
+	assert.Equal(t, `{"id":"1","type":"data","payload":{"data":{"updateProductPrice":{"upc":"top-2","name":"Fedora","price":5}}}}`, string(<-messages))
+}
+
+func TestFederationIntegrationTest_OperationCoalescing(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	accountsUpstreamServer := httptest.NewServer(accounts.GraphQLEndpointHandler(accounts.TestOptions))
+	defer accountsUpstreamServer.Close()
+	reviewsUpstreamServer := httptest.NewServer(reviews.GraphQLEndpointHandler(reviews.TestOptions))
+	defer reviewsUpstreamServer.Close()
+
+	productsHandler := products.GraphQLEndpointHandler(products.TestOptions)
+	var topProductsRequests int32
+	productsUpstreamServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := ioutil.ReadAll(r.Body)
+		require.NoError(t, err)
+		if bytes.Contains(body, []byte("topProducts")) {
+			atomic.AddInt32(&topProductsRequests, 1)
+			// keep the operation in flight until all clients sent their request
+			time.Sleep(200 * time.Millisecond)
+		}
+		r.Body = ioutil.NopCloser(bytes.NewReader(body))
+		productsHandler.ServeHTTP(w, r)
+	}))
+	defer productsUpstreamServer.Close()
+
+	httpClient := http.DefaultClient
+	poller := gateway.NewDatasource([]gateway.ServiceConfig{
+		{Name: "accounts", URL: accountsUpstreamServer.URL},
+		{Name: "products", URL: productsUpstreamServer.URL},
+		{Name: "reviews", URL: reviewsUpstreamServer.URL},
+	}, httpClient)
+	gtw := gateway.Handler(abstractlogger.NoopLogger, poller, httpClient, gateway.WithOperationCoalescing())
+
+	pollerCtx, cancelPoller := context.WithTimeout(ctx, 1*time.Second)
+	defer cancelPoller()
+	poller.Run(pollerCtx)
+	gatewayServer := httptest.NewServer(gtw)
+	defer gatewayServer.Close()
+
+	gqlClient := NewGraphqlClient(http.DefaultClient)
+
+	t.Run("identical queries are executed once", func(t *testing.T) {
+		atomic.StoreInt32(&topProductsRequests, 0)
+
+		responses := make([][]byte, 10)
+		wg := &sync.WaitGroup{}
+		wg.Add(len(responses))
+		for i := range responses {
+			go func(i int) {
+				defer wg.Done()
+				responses[i] = gqlClient.post(ctx, gatewayServer.URL, requestBody(t, `{ topProducts { upc name } }`, nil), nil, t)
+			}(i)
+		}
+		wg.Wait()
+
+		assert.Equal(t, int32(1), atomic.LoadInt32(&topProductsRequests))
+		for i := range responses {
+			assert.Equal(t, `{"data":{"topProducts":[{"upc":"top-1","name":"Trilby"},{"upc":"top-2","name":"Fedora"},{"upc":"top-3","name":"Boater"}]}}`, string(responses[i]))
+		}
+	})
+
+	t.Run("different variables are executed separately", func(t *testing.T) {
+		atomic.StoreInt32(&topProductsRequests, 0)
+
+		wg := &sync.WaitGroup{}
+		wg.Add(2)
+		for _, first := range []int{1, 2} {
+			go func(first int) {
+				defer wg.Done()
+				gqlClient.post(ctx, gatewayServer.URL, requestBody(t, `query TopProducts($first: Int) { topProducts(first: $first) { upc } }`, queryVariables{"first": first}), nil, t)
+			}(first)
+		}
+		wg.Wait()
+
+		assert.Equal(t, int32(2), atomic.LoadInt32(&topProductsRequests))
+	})
+
+	t.Run("different cookies are executed separately", func(t *testing.T) {
+		atomic.StoreInt32(&topProductsRequests, 0)
+
+		wg := &sync.WaitGroup{}
+		wg.Add(2)
+		for _, session := range []string{"first", "second"} {
+			go func(session string) {
+				defer wg.Done()
+				gqlClient.post(ctx, gatewayServer.URL, requestBody(t, `{ topProducts { upc } }`, nil), http.Header{"Cookie": []string{"session=" + session}}, t)
+			}(session)
+		}
+		wg.Wait()
+
+		assert.Equal(t, int32(2), atomic.LoadInt32(&topProductsRequests))
+	})
+}
+
+func TestFederationIntegrationTest_SubscriptionsDebugEndpoint(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	// Reset the products slice to the original state
+	defer products.Reset()
+
+	accountsUpstreamServer := httptest.NewServer(accounts.GraphQLEndpointHandler(accounts.TestOptions))
+	defer accountsUpstreamServer.Close()
+	productsUpstreamServer := httptest.NewServer(products.GraphQLEndpointHandler(products.TestOptions))
+	defer productsUpstreamServer.Close()
+	reviewsUpstreamServer := httptest.NewServer(reviews.GraphQLEndpointHandler(reviews.TestOptions))
+	defer reviewsUpstreamServer.Close()
+
+	httpClient := http.DefaultClient
+	poller := gateway.NewDatasource([]gateway.ServiceConfig{
+		{Name: "accounts", URL: accountsUpstreamServer.URL},
+		{Name: "products", URL: productsUpstreamServer.URL, WS: strings.ReplaceAll(productsUpstreamServer.URL, "http:", "ws:")},
+		{Name: "reviews", URL: reviewsUpstreamServer.URL},
+	}, httpClient)
+	gtw := gateway.Handler(abstractlogger.NoopLogger, poller, httpClient, gateway.WithSubscriptionsDebugEndpoint())
+
+	pollerCtx, cancelPoller := context.WithTimeout(ctx, 1*time.Second)
+	defer cancelPoller()
+	poller.Run(pollerCtx)
+	gatewayServer := httptest.NewServer(gtw)
+	defer gatewayServer.Close()
+
+	gqlClient := NewGraphqlClient(http.DefaultClient)
+	wsAddr := strings.ReplaceAll(gatewayServer.URL, "http://", "ws://")
+	for _, upc := range []string{"top-1", "top-2"} {
+		conn := gqlClient.StartSubscription(ctx, wsAddr, path.Join("testdata", "subscriptions/subscription.query"), queryVariables{
+			"upc": upc,
+		}, t)
+		defer conn.Close()
+		assert.Contains(t, string(gqlClient.readMessageFromServer(t, conn)), fmt.Sprintf(`"upc":"%s"`, upc))
+	}
+
+	var subscriptions []gatewayhttp.ActiveSubscription
+	// the upstream subscriptions are recorded once they're started
+	require.Eventually(t, func() bool {
+		resp, err := http.Get(gatewayServer.URL + "/debug/subscriptions")
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		var response struct {
+			Subscriptions []gatewayhttp.ActiveSubscription `json:"subscriptions"`
+		}
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&response))
+		subscriptions = response.Subscriptions
+		return len(subscriptions) == 2 && len(subscriptions[0].Upstreams) == 1 && len(subscriptions[1].Upstreams) == 1
+	}, time.Second, 10*time.Millisecond)
+
+	query, err := ioutil.ReadFile(path.Join("testdata", "subscriptions/subscription.query"))
+	require.NoError(t, err)
+	for i, upc := range []string{"top-1", "top-2"} {
+		assert.Equal(t, "1", subscriptions[i].ID)
+		assert.Equal(t, string(query), subscriptions[i].Query)
+		assert.JSONEq(t, fmt.Sprintf(`{"upc":"%s"}`, upc), string(subscriptions[i].Variables))
+		assert.False(t, subscriptions[i].StartedAt.IsZero())
+		assert.False(t, subscriptions[i].Upstreams[0].SSE)
+		assert.NotZero(t, subscriptions[i].Upstreams[0].ConnectionID)
+	}
+	// every subscription was started over a websocket connection of its own
+	assert.NotEqual(t, subscriptions[0].ClientID, subscriptions[1].ClientID)
+	// the subscriptions differ in their variables, so they're not multiplexed
+	assert.NotEqual(t, subscriptions[0].Upstreams[0].StreamID, subscriptions[1].Upstreams[0].StreamID)
+
+	assert.Len(t, gtw.Subscriptions(), 2)
+}
@@ -0,0 +1,208 @@
+package gateway
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const defaultCircuitBreakerCooldown = 30 * time.Second
+
+// CircuitBreakerConfig configures the circuit breaker of a service.
+// While the circuit is open fetches fail immediately instead of waiting for the unavailable service.
+type CircuitBreakerConfig struct {
+	// FailureThreshold is the number of consecutive failed fetches opening the circuit, 0 disables the circuit breaker.
+	// Failed fetches are fetches without response or with a 5xx status code.
+	FailureThreshold int
+	// FailureWindow limits the time between the first and the last of the consecutive failures,
+	// failures spread over a longer time don't open the circuit. 0 counts failures regardless of their time.
+	FailureWindow time.Duration
+	// Cooldown is the time the circuit stays open until a single fetch is let through to probe the service,
+	// defaults to 30s. The circuit closes if the probe succeeds and opens again otherwise.
+	Cooldown time.Duration
+}
+
+type circuitState int
+
+const (
+	circuitClosed circuitState = iota
+	circuitOpen
+	circuitHalfOpen
+)
+
+// circuitBreaker tracks the failures of the fetches to a service
+type circuitBreaker struct {
+	config CircuitBreakerConfig
+	now    func() time.Time
+
+	mu           sync.Mutex
+	state        circuitState
+	failures     int
+	firstFailure time.Time
+	openedAt     time.Time
+	// probing is true while the probe of a half open circuit is in flight
+	probing bool
+}
+
+func newCircuitBreaker(config CircuitBreakerConfig) *circuitBreaker {
+	if config.Cooldown == 0 {
+		config.Cooldown = defaultCircuitBreakerCooldown
+	}
+	return &circuitBreaker{
+		config: config,
+		now:    time.Now,
+	}
+}
+
+// allow reports whether a fetch may be sent to the service.
+// Once the cooldown of an open circuit is over, a single fetch is allowed to probe the service.
+func (c *circuitBreaker) allow() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	switch c.state {
+	case circuitOpen:
+		if c.now().Sub(c.openedAt) < c.config.Cooldown {
+			return false
+		}
+		c.state = circuitHalfOpen
+		c.probing = true
+		return true
+	case circuitHalfOpen:
+		if c.probing {
+			return false
+		}
+		c.probing = true
+		return true
+	default:
+		return true
+	}
+}
+
+func (c *circuitBreaker) recordSuccess() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.state = circuitClosed
+	c.failures = 0
+	c.probing = false
+}
+
+// releaseProbe lets the next fetch probe a half open circuit, e.g. if the probe was cancelled
+func (c *circuitBreaker) releaseProbe() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.probing = false
+}
+
+func (c *circuitBreaker) recordFailure() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.now()
+	if c.state == circuitHalfOpen {
+		c.state = circuitOpen
+		c.openedAt = now
+		c.probing = false
+		return
+	}
+
+	if c.failures == 0 || (c.config.FailureWindow > 0 && now.Sub(c.firstFailure) > c.config.FailureWindow) {
+		c.failures = 0
+		c.firstFailure = now
+	}
+	c.failures++
+	if c.failures >= c.config.FailureThreshold {
+		c.state = circuitOpen
+		c.openedAt = now
+		c.failures = 0
+	}
+}
+
+// circuitBreakerTransport fails requests to a service immediately while its circuit is open.
+// The failure is written as GraphQL error response, so that only the fields of the service resolve to null.
+type circuitBreakerTransport struct {
+	serviceName string
+	breaker     *circuitBreaker
+	transport   http.RoundTripper
+}
+
+func (c *circuitBreakerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !c.breaker.allow() {
+		return c.circuitOpenResponse(req)
+	}
+
+	resp, err := c.transport.RoundTrip(req)
+	switch {
+	case err != nil && req.Context().Err() != nil:
+		// the request was cancelled, it says nothing about the service
+		c.breaker.releaseProbe()
+	case err != nil || resp.StatusCode >= http.StatusInternalServerError:
+		c.breaker.recordFailure()
+	default:
+		c.breaker.recordSuccess()
+	}
+	return resp, err
+}
+
+func (c *circuitBreakerTransport) circuitOpenResponse(req *http.Request) (*http.Response, error) {
+	body, err := json.Marshal(map[string]interface{}{
+		"errors": []map[string]interface{}{
+			{
+				"message": fmt.Sprintf("service %s is unavailable: circuit breaker is open", c.serviceName),
+				"extensions": map[string]interface{}{
+					"code":        "SERVICE_UNAVAILABLE",
+					"serviceName": c.serviceName,
+				},
+			},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &http.Response{
+		Status:        http.StatusText(http.StatusServiceUnavailable),
+		StatusCode:    http.StatusServiceUnavailable,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        http.Header{"Content-Type": []string{"application/json"}},
+		Body:          ioutil.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}, nil
+}
+
+// withCircuitBreakers returns copies of the service clients failing fast while the service is unavailable,
+// for services with a configured circuit breaker. The clients of the poller stay unchanged,
+// so polling the SDLs doesn't trip or get blocked by the circuit breakers.
+func withCircuitBreakers(clients map[string]*http.Client, services []ServiceConfig) map[string]*http.Client {
+	guarded := make(map[string]*http.Client, len(clients))
+	for serviceURL, client := range clients {
+		guarded[serviceURL] = client
+	}
+	for _, service := range services {
+		client, ok := guarded[service.URL]
+		if !ok || service.CircuitBreaker.FailureThreshold <= 0 {
+			continue
+		}
+		transport := client.Transport
+		if transport == nil {
+			transport = http.DefaultTransport
+		}
+		guardedClient := *client
+		guardedClient.Transport = &circuitBreakerTransport{
+			serviceName: service.Name,
+			breaker:     newCircuitBreaker(service.CircuitBreaker),
+			transport:   transport,
+		}
+		guarded[service.URL] = &guardedClient
+	}
+	return guarded
+}
@@ -0,0 +1,71 @@
+package gateway
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	newBreaker := func(config CircuitBreakerConfig) (*circuitBreaker, *time.Time) {
+		now := time.Unix(0, 0)
+		breaker := newCircuitBreaker(config)
+		breaker.now = func() time.Time { return now }
+		return breaker, &now
+	}
+
+	t.Run("opens after consecutive failures", func(t *testing.T) {
+		breaker, _ := newBreaker(CircuitBreakerConfig{FailureThreshold: 3})
+		breaker.recordFailure()
+		breaker.recordFailure()
+		breaker.recordSuccess()
+		breaker.recordFailure()
+		breaker.recordFailure()
+		assert.True(t, breaker.allow())
+
+		breaker.recordFailure()
+		assert.False(t, breaker.allow())
+	})
+
+	t.Run("failures outside of the window don't open the circuit", func(t *testing.T) {
+		breaker, now := newBreaker(CircuitBreakerConfig{FailureThreshold: 2, FailureWindow: time.Second})
+		breaker.recordFailure()
+		*now = now.Add(2 * time.Second)
+		breaker.recordFailure()
+		assert.True(t, breaker.allow())
+
+		*now = now.Add(500 * time.Millisecond)
+		breaker.recordFailure()
+		assert.False(t, breaker.allow())
+	})
+
+	t.Run("half opens after the cooldown", func(t *testing.T) {
+		breaker, now := newBreaker(CircuitBreakerConfig{FailureThreshold: 1, Cooldown: time.Second})
+		breaker.recordFailure()
+		assert.False(t, breaker.allow())
+
+		*now = now.Add(time.Second)
+		assert.True(t, breaker.allow(), "probe")
+		assert.False(t, breaker.allow(), "only a single probe is let through")
+
+		breaker.recordFailure()
+		assert.False(t, breaker.allow(), "failed probe opens the circuit again")
+
+		*now = now.Add(time.Second)
+		assert.True(t, breaker.allow(), "probe")
+		breaker.recordSuccess()
+		assert.True(t, breaker.allow())
+		assert.True(t, breaker.allow())
+	})
+
+	t.Run("cancelled probe lets the next fetch probe", func(t *testing.T) {
+		breaker, now := newBreaker(CircuitBreakerConfig{FailureThreshold: 1, Cooldown: time.Second})
+		breaker.recordFailure()
+		*now = now.Add(time.Second)
+		assert.True(t, breaker.allow())
+		breaker.releaseProbe()
+		assert.True(t, breaker.allow())
+		assert.False(t, breaker.allow())
+	})
+}
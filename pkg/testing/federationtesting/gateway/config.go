@@ -0,0 +1,199 @@
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	log "github.com/jensneuse/abstractlogger"
+)
+
+// Config is the declarative configuration of a gateway, see FromConfig.
+// Durations are written as strings parsed by time.ParseDuration, e.g. "30s".
+//
+//	{
+//	  "pollingInterval": "30s",
+//	  "timeout": "10s",
+//	  "services": [
+//	    {"name": "accounts", "url": "http://accounts:4001/query", "headers": {"Authorization": "Bearer token"}},
+//	    {"name": "products", "url": "http://products:4002/query", "ws": "ws://products:4002/query"}
+//	  ]
+//	}
+type Config struct {
+	Services []ServiceFileConfig `json:"services"`
+	// PollingInterval is the interval the SDLs of the services are polled in, they're polled once if it's zero.
+	PollingInterval Duration `json:"pollingInterval"`
+	// Timeout limits the requests to the services, including reading their responses. No timeout by default.
+	Timeout Duration `json:"timeout"`
+	// MaxIdleConnsPerHost limits the idle (keep-alive) connections of each service transport, defaults to 100.
+	MaxIdleConnsPerHost int `json:"maxIdleConnsPerHost"`
+	// IdleConnTimeout is the time an idle connection of a service transport is kept open, defaults to 90s.
+	IdleConnTimeout Duration `json:"idleConnTimeout"`
+}
+
+// ServiceFileConfig is the configuration of a service in a Config, see ServiceConfig
+type ServiceFileConfig struct {
+	Name string `json:"name"`
+	URL  string `json:"url"`
+	WS   string `json:"ws"`
+	// Headers are sent with every request to the service
+	Headers                  map[string]string        `json:"headers"`
+	CircuitBreaker           CircuitBreakerFileConfig `json:"circuitBreaker"`
+	Retry                    RetryFileConfig          `json:"retry"`
+	ForwardInitPayloadFields map[string]string        `json:"forwardInitPayloadFields"`
+}
+
+// CircuitBreakerFileConfig is the configuration of the circuit breaker of a service in a Config, see CircuitBreakerConfig
+type CircuitBreakerFileConfig struct {
+	FailureThreshold int      `json:"failureThreshold"`
+	FailureWindow    Duration `json:"failureWindow"`
+	Cooldown         Duration `json:"cooldown"`
+}
+
+// RetryFileConfig is the configuration of the retries of a service in a Config, see RetryConfig
+type RetryFileConfig struct {
+	MaxRetries int      `json:"maxRetries"`
+	Backoff    Duration `json:"backoff"`
+	MaxBackoff Duration `json:"maxBackoff"`
+}
+
+// Duration is a time.Duration written as a string in a Config, e.g. "1m30s"
+type Duration time.Duration
+
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var value string
+	if err := json.Unmarshal(data, &value); err != nil {
+		return fmt.Errorf("duration must be a string like \"30s\", got %s", data)
+	}
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		return err
+	}
+	*d = Duration(duration)
+	return nil
+}
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+// ParseConfig reads and validates a Config. Unknown fields are rejected to catch typos.
+func ParseConfig(r io.Reader) (Config, error) {
+	var config Config
+	decoder := json.NewDecoder(r)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&config); err != nil {
+		return Config{}, fmt.Errorf("parse gateway config: %w", err)
+	}
+	if err := config.Validate(); err != nil {
+		return Config{}, err
+	}
+	return config, nil
+}
+
+// Validate returns an error for a config without services, for services without a name or with the name of
+// another service, and for malformed urls. Service urls must be http or https urls, ws urls must be ws or wss urls.
+func (c Config) Validate() error {
+	if len(c.Services) == 0 {
+		return fmt.Errorf("invalid gateway config: no services configured")
+	}
+
+	indexes := make(map[string]int, len(c.Services))
+	for i, service := range c.Services {
+		if service.Name == "" {
+			return fmt.Errorf("invalid gateway config: service %d has no name", i)
+		}
+		if first, ok := indexes[service.Name]; ok {
+			return fmt.Errorf("invalid gateway config: services %d and %d are both named %q", first, i, service.Name)
+		}
+		indexes[service.Name] = i
+
+		if err := validateServiceURL(service.URL, "http", "https"); err != nil {
+			return fmt.Errorf("invalid gateway config: service %q: url: %w", service.Name, err)
+		}
+		if service.WS != "" {
+			if err := validateServiceURL(service.WS, "ws", "wss"); err != nil {
+				return fmt.Errorf("invalid gateway config: service %q: ws: %w", service.Name, err)
+			}
+		}
+	}
+	return nil
+}
+
+func validateServiceURL(rawURL string, schemes ...string) error {
+	if rawURL == "" {
+		return fmt.Errorf("missing")
+	}
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return err
+	}
+	if parsed.Host == "" {
+		return fmt.Errorf("%q has no host", rawURL)
+	}
+	for _, scheme := range schemes {
+		if parsed.Scheme == scheme {
+			return nil
+		}
+	}
+	return fmt.Errorf("%q must use one of the schemes %v", rawURL, schemes)
+}
+
+// ServiceConfigs converts the services of the config into the configs of the datasource poller
+func (c Config) ServiceConfigs() []ServiceConfig {
+	services := make([]ServiceConfig, 0, len(c.Services))
+	for _, service := range c.Services {
+		var header http.Header
+		if len(service.Headers) != 0 {
+			header = make(http.Header, len(service.Headers))
+			for key, value := range service.Headers {
+				header.Set(key, value)
+			}
+		}
+		services = append(services, ServiceConfig{
+			Name: service.Name,
+			URL:  service.URL,
+			WS:   service.WS,
+			CircuitBreaker: CircuitBreakerConfig{
+				FailureThreshold: service.CircuitBreaker.FailureThreshold,
+				FailureWindow:    time.Duration(service.CircuitBreaker.FailureWindow),
+				Cooldown:         time.Duration(service.CircuitBreaker.Cooldown),
+			},
+			Retry: RetryConfig{
+				MaxRetries: service.Retry.MaxRetries,
+				Backoff:    time.Duration(service.Retry.Backoff),
+				MaxBackoff: time.Duration(service.Retry.MaxBackoff),
+			},
+			ForwardInitPayloadFields: service.ForwardInitPayloadFields,
+			Header:                   header,
+		})
+	}
+	return services
+}
+
+// FromConfig reads a Config and builds a gateway serving its services.
+// The SDLs of the services are polled in the background until ctx is done, the gateway answers requests
+// once they're loaded, see Gateway.Ready.
+func FromConfig(ctx context.Context, r io.Reader, logger log.Logger, options ...HandlerOption) (*Gateway, error) {
+	config, err := ParseConfig(r)
+	if err != nil {
+		return nil, err
+	}
+
+	httpClient := &http.Client{Timeout: time.Duration(config.Timeout)}
+	poller := NewDatasourcePoller(httpClient, DatasourcePollerConfig{
+		Services:            config.ServiceConfigs(),
+		PollingInterval:     time.Duration(config.PollingInterval),
+		MaxIdleConnsPerHost: config.MaxIdleConnsPerHost,
+		IdleConnTimeout:     time.Duration(config.IdleConnTimeout),
+	})
+
+	gateway := Handler(logger, poller, httpClient, options...)
+	go poller.Run(ctx)
+
+	return gateway, nil
+}
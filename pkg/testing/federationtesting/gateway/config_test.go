@@ -0,0 +1,103 @@
+package gateway
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jensneuse/abstractlogger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newStaticService returns a service answering the sdl query with the sdl and every other query with the data,
+// it records the header of the last request
+func newStaticService(t testing.TB, sdl, data string) (*httptest.Server, *http.Header) {
+	header := &http.Header{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := ioutil.ReadAll(r.Body)
+		require.NoError(t, err)
+		*header = r.Header.Clone()
+		if strings.Contains(string(body), "_service") {
+			_, _ = fmt.Fprintf(w, `{"data":{"_service":{"sdl":%q}}}`, sdl)
+			return
+		}
+		_, _ = fmt.Fprintf(w, `{"data":%s}`, data)
+	}))
+	t.Cleanup(server.Close)
+	return server, header
+}
+
+func TestFromConfig(t *testing.T) {
+	accounts, accountsHeader := newStaticService(t, "extend type Query { me: String }", `{"me":"Me"}`)
+	products, _ := newStaticService(t, "extend type Query { topProducts: [String] }", `{"topProducts":["Table","Couch"]}`)
+
+	config := fmt.Sprintf(`{
+		"timeout": "5s",
+		"services": [
+			{"name": "accounts", "url": %q, "headers": {"X-Api-Key": "secret"}},
+			{"name": "products", "url": %q, "ws": %q, "circuitBreaker": {"failureThreshold": 5, "cooldown": "10s"}}
+		]
+	}`, accounts.URL, products.URL, strings.Replace(products.URL, "http:", "ws:", 1))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	gateway, err := FromConfig(ctx, strings.NewReader(config), abstractlogger.NoopLogger)
+	require.NoError(t, err)
+	gateway.Ready()
+
+	recorder := httptest.NewRecorder()
+	gateway.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"query":"{ me topProducts }"}`)))
+
+	assert.Equal(t, http.StatusOK, recorder.Code)
+	assert.JSONEq(t, `{"data":{"me":"Me","topProducts":["Table","Couch"]}}`, recorder.Body.String())
+	assert.Equal(t, "secret", accountsHeader.Get("X-Api-Key"))
+}
+
+func TestParseConfig(t *testing.T) {
+	run := func(config string, expectedErr string) func(t *testing.T) {
+		return func(t *testing.T) {
+			_, err := ParseConfig(strings.NewReader(config))
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), expectedErr)
+		}
+	}
+
+	t.Run("valid config", func(t *testing.T) {
+		config, err := ParseConfig(strings.NewReader(`{
+			"pollingInterval": "1m",
+			"services": [{
+				"name": "accounts", "url": "https://accounts.example.com/query", "ws": "wss://accounts.example.com/query",
+				"retry": {"maxRetries": 2, "backoff": "50ms"}
+			}]
+		}`))
+		require.NoError(t, err)
+		assert.Equal(t, Duration(time.Minute), config.PollingInterval)
+
+		services := config.ServiceConfigs()
+		require.Len(t, services, 1)
+		assert.Equal(t, "accounts", services[0].Name)
+		assert.Equal(t, "wss://accounts.example.com/query", services[0].WS)
+		assert.Nil(t, services[0].Header)
+		assert.Equal(t, RetryConfig{MaxRetries: 2, Backoff: 50 * time.Millisecond}, services[0].Retry)
+	})
+	t.Run("no services", run(`{"services": []}`, "no services configured"))
+	t.Run("missing name", run(`{"services": [{"url": "http://accounts"}]}`, "service 0 has no name"))
+	t.Run("duplicate name", run(`{"services": [
+		{"name": "accounts", "url": "http://accounts"},
+		{"name": "products", "url": "http://products"},
+		{"name": "accounts", "url": "http://accounts-2"}
+	]}`, `services 0 and 2 are both named "accounts"`))
+	t.Run("missing url", run(`{"services": [{"name": "accounts"}]}`, `service "accounts": url: missing`))
+	t.Run("malformed url", run(`{"services": [{"name": "accounts", "url": "http://acc ounts"}]}`, `service "accounts": url: parse`))
+	t.Run("url without host", run(`{"services": [{"name": "accounts", "url": "/query"}]}`, `"/query" has no host`))
+	t.Run("ws url with http scheme", run(`{"services": [{"name": "accounts", "url": "http://accounts", "ws": "http://accounts"}]}`, `service "accounts": ws: "http://accounts" must use one of the schemes [ws wss]`))
+	t.Run("invalid duration", run(`{"timeout": "soon", "services": []}`, `time: invalid duration "soon"`))
+	t.Run("unknown field", run(`{"service": []}`, `unknown field "service"`))
+}
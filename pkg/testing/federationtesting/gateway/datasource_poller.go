@@ -3,6 +3,7 @@ package gateway
 import (
 	"bytes"
 	"context"
+	"crypto/tls"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
@@ -15,15 +16,41 @@ import (
 	graphqlDataSource "github.com/wundergraph/graphql-go-tools/pkg/engine/datasource/graphql_datasource"
 )
 
+const (
+	defaultMaxIdleConnsPerHost = 100
+	defaultIdleConnTimeout     = 90 * time.Second
+)
+
 type ServiceConfig struct {
 	Name string
 	URL  string
 	WS   string
+	// Transport overrides the transport used to fetch from the service.
+	// By default, a dedicated transport with HTTP/2 enabled and tuned for connection reuse is created per service.
+	Transport http.RoundTripper
+	// TLSConfig configures the TLS connections of the dedicated transport of the service,
+	// e.g. the client certificate presented to a service requiring mTLS and the CAs to verify the service.
+	// It's ignored when Transport is set.
+	TLSConfig *tls.Config
+	// CircuitBreaker fails the fetches of the service immediately after repeated failures, disabled by default.
+	CircuitBreaker CircuitBreakerConfig
+	// Retry retries the fetches of the service rejected with 429 or 503, respecting their Retry-After header,
+	// disabled by default.
+	Retry RetryConfig
+	// ForwardInitPayloadFields maps fields of the connection_init payload of clients to fields of the
+	// connection_init payload sent to the service, fields not listed aren't forwarded.
+	ForwardInitPayloadFields map[string]string
+	// Header is sent with every request to the service, e.g. to authenticate the gateway.
+	Header http.Header
 }
 
 type DatasourcePollerConfig struct {
 	Services        []ServiceConfig
 	PollingInterval time.Duration
+	// MaxIdleConnsPerHost limits the idle (keep-alive) connections of each service transport, defaults to 100.
+	MaxIdleConnsPerHost int
+	// IdleConnTimeout is the time an idle connection of a service transport is kept open, defaults to 90s.
+	IdleConnTimeout time.Duration
 }
 
 const ServiceDefinitionQuery = `
@@ -51,19 +78,69 @@ func NewDatasourcePoller(
 	httpClient *http.Client,
 	config DatasourcePollerConfig,
 ) *DatasourcePollerPoller {
+	if config.MaxIdleConnsPerHost == 0 {
+		config.MaxIdleConnsPerHost = defaultMaxIdleConnsPerHost
+	}
+	if config.IdleConnTimeout == 0 {
+		config.IdleConnTimeout = defaultIdleConnTimeout
+	}
+
+	serviceHttpClients := make(map[string]*http.Client, len(config.Services))
+	for _, serviceConfig := range config.Services {
+		serviceHttpClients[serviceConfig.URL] = newServiceHttpClient(httpClient, serviceConfig, config)
+	}
+
 	return &DatasourcePollerPoller{
-		httpClient: httpClient,
-		config:     config,
-		sdlMap:     make(map[string]string),
+		httpClient:         httpClient,
+		serviceHttpClients: serviceHttpClients,
+		config:             config,
+		sdlMap:             make(map[string]string),
+		statuses:           make(map[string]ServiceStatus, len(config.Services)),
 	}
 }
 
+// newServiceHttpClient creates the client for a service, it inherits the timeout of the shared client
+func newServiceHttpClient(httpClient *http.Client, serviceConfig ServiceConfig, config DatasourcePollerConfig) *http.Client {
+	transport := serviceConfig.Transport
+	if transport == nil {
+		defaultTransport := http.DefaultTransport.(*http.Transport).Clone()
+		defaultTransport.ForceAttemptHTTP2 = true
+		defaultTransport.MaxIdleConns = 0
+		defaultTransport.MaxIdleConnsPerHost = config.MaxIdleConnsPerHost
+		defaultTransport.IdleConnTimeout = config.IdleConnTimeout
+		if serviceConfig.TLSConfig != nil {
+			defaultTransport.TLSClientConfig = serviceConfig.TLSConfig.Clone()
+		}
+		transport = defaultTransport
+	}
+
+	client := &http.Client{Transport: transport}
+	if httpClient != nil {
+		client.Timeout = httpClient.Timeout
+	}
+	return client
+}
+
+// ServiceStatus is the result of the last poll of a service
+type ServiceStatus struct {
+	Name      string    `json:"name"`
+	URL       string    `json:"url"`
+	Reachable bool      `json:"reachable"`
+	Error     string    `json:"error,omitempty"`
+	LastPoll  time.Time `json:"lastPoll"`
+}
+
 type DatasourcePollerPoller struct {
-	httpClient *http.Client
+	httpClient         *http.Client
+	serviceHttpClients map[string]*http.Client
 
 	config DatasourcePollerConfig
 	sdlMap map[string]string
 
+	// statuses are the results of the last poll, keyed by the service name
+	statuses   map[string]ServiceStatus
+	statusesMu sync.Mutex
+
 	updateDatasourceObservers []DataSourceObserver
 }
 
@@ -71,6 +148,57 @@ func (d *DatasourcePollerPoller) Register(updateDatasourceObserver DataSourceObs
 	d.updateDatasourceObservers = append(d.updateDatasourceObservers, updateDatasourceObserver)
 }
 
+// ServiceHttpClients returns the http clients of the services, keyed by the service url
+func (d *DatasourcePollerPoller) ServiceHttpClients() map[string]*http.Client {
+	return d.serviceHttpClients
+}
+
+// ServiceNames returns the names of the services, keyed by the service url
+func (d *DatasourcePollerPoller) ServiceNames() map[string]string {
+	serviceNames := make(map[string]string, len(d.config.Services))
+	for _, serviceConfig := range d.config.Services {
+		serviceNames[serviceConfig.URL] = serviceConfig.Name
+	}
+	return serviceNames
+}
+
+// ServiceStatuses returns the reachability of the services as of the last poll, in the order of the configured services.
+// Services which weren't polled yet are reported as unreachable.
+func (d *DatasourcePollerPoller) ServiceStatuses() []ServiceStatus {
+	d.statusesMu.Lock()
+	defer d.statusesMu.Unlock()
+
+	statuses := make([]ServiceStatus, 0, len(d.config.Services))
+	for _, serviceConfig := range d.config.Services {
+		status, ok := d.statuses[serviceConfig.Name]
+		if !ok {
+			status = ServiceStatus{
+				Name:  serviceConfig.Name,
+				URL:   serviceConfig.URL,
+				Error: "not polled yet",
+			}
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses
+}
+
+func (d *DatasourcePollerPoller) setServiceStatus(serviceConfig ServiceConfig, err error) {
+	status := ServiceStatus{
+		Name:      serviceConfig.Name,
+		URL:       serviceConfig.URL,
+		Reachable: err == nil,
+		LastPoll:  time.Now(),
+	}
+	if err != nil {
+		status.Error = err.Error()
+	}
+
+	d.statusesMu.Lock()
+	d.statuses[serviceConfig.Name] = status
+	d.statusesMu.Unlock()
+}
+
 func (d *DatasourcePollerPoller) Run(ctx context.Context) {
 	d.updateSDLs(ctx)
 
@@ -108,6 +236,7 @@ func (d *DatasourcePollerPoller) updateSDLs(ctx context.Context) {
 			defer wg.Done()
 
 			sdl, err := d.fetchServiceSDL(ctx, serviceConf.URL)
+			d.setServiceStatus(serviceConf, err)
 			if err != nil {
 				log.Printf("Failed to get sdl for service: %s, err: %s\n", serviceConf.Name, err)
 				return
@@ -156,9 +285,11 @@ func (d *DatasourcePollerPoller) createDatasourceConfig() []graphqlDataSource.Co
 			Fetch: graphqlDataSource.FetchConfiguration{
 				URL:    serviceConfig.URL,
 				Method: http.MethodPost,
+				Header: serviceConfig.Header,
 			},
 			Subscription: graphqlDataSource.SubscriptionConfiguration{
-				URL: serviceConfig.WS,
+				URL:                      serviceConfig.WS,
+				ForwardInitPayloadFields: serviceConfig.ForwardInitPayloadFields,
 			},
 			Federation: graphqlDataSource.FederationConfiguration{
 				Enabled:    true,
@@ -172,6 +303,22 @@ func (d *DatasourcePollerPoller) createDatasourceConfig() []graphqlDataSource.Co
 	return dataSourceConfigs
 }
 
+func (d *DatasourcePollerPoller) serviceHttpClient(serviceURL string) *http.Client {
+	if client, ok := d.serviceHttpClients[serviceURL]; ok {
+		return client
+	}
+	return d.httpClient
+}
+
+func (d *DatasourcePollerPoller) serviceHeader(serviceURL string) http.Header {
+	for _, serviceConfig := range d.config.Services {
+		if serviceConfig.URL == serviceURL {
+			return serviceConfig.Header
+		}
+	}
+	return nil
+}
+
 func (d *DatasourcePollerPoller) fetchServiceSDL(ctx context.Context, serviceURL string) (string, error) {
 	req, err := http.NewRequestWithContext(ctx, http.MethodPost, serviceURL, bytes.NewReader([]byte(ServiceDefinitionQuery)))
 	req.Header.Add("Content-Type", "application/json")
@@ -179,8 +326,13 @@ func (d *DatasourcePollerPoller) fetchServiceSDL(ctx context.Context, serviceURL
 	if err != nil {
 		return "", fmt.Errorf("create request: %v", err)
 	}
+	for key, values := range d.serviceHeader(serviceURL) {
+		for _, value := range values {
+			req.Header.Add(key, value)
+		}
+	}
 
-	resp, err := d.httpClient.Do(req)
+	resp, err := d.serviceHttpClient(serviceURL).Do(req)
 	if err != nil {
 		return "", fmt.Errorf("do request: %v", err)
 	}
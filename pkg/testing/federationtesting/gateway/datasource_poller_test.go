@@ -0,0 +1,241 @@
+package gateway
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newConnectionCountingServer returns a service which counts the connections opened by its clients
+func newConnectionCountingServer(t testing.TB) (*httptest.Server, *int64) {
+	var connections int64
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"data":{"_service":{"sdl":"type Query { me: String }"}}}`))
+	}))
+	server.Config.ConnState = func(conn net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			atomic.AddInt64(&connections, 1)
+		}
+	}
+	server.Start()
+	t.Cleanup(server.Close)
+	return server, &connections
+}
+
+// fetchBurst fetches the service sdl concurrently, repeated for the given number of rounds
+func fetchBurst(t testing.TB, poller *DatasourcePollerPoller, serviceURL string, rounds, concurrency int) {
+	for round := 0; round < rounds; round++ {
+		wg := sync.WaitGroup{}
+		wg.Add(concurrency)
+		for i := 0; i < concurrency; i++ {
+			go func() {
+				defer wg.Done()
+				_, err := poller.fetchServiceSDL(context.Background(), serviceURL)
+				assert.NoError(t, err)
+			}()
+		}
+		wg.Wait()
+	}
+}
+
+// newCertificateAuthority returns a self-signed CA to issue client certificates
+func newCertificateAuthority(t testing.TB) (*x509.Certificate, *ecdsa.PrivateKey) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	return cert, key
+}
+
+// newClientCertificate issues a client certificate signed by the CA
+func newClientCertificate(t testing.TB, ca *x509.Certificate, caKey *ecdsa.PrivateKey) tls.Certificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: "gateway"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, ca, &key.PublicKey, caKey)
+	require.NoError(t, err)
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+}
+
+// newMutualTLSServer returns a service which requires a client certificate signed by the CA
+func newMutualTLSServer(t testing.TB, ca *x509.Certificate) *httptest.Server {
+	clientCAs := x509.NewCertPool()
+	clientCAs.AddCert(ca)
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"data":{"_service":{"sdl":"type Query { me: String }"}}}`))
+	}))
+	server.TLS = &tls.Config{
+		ClientAuth: tls.RequireAndVerifyClientCert,
+		ClientCAs:  clientCAs,
+	}
+	server.StartTLS()
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestDatasourcePoller_ServiceHttpClients(t *testing.T) {
+	t.Run("reuses connections", func(t *testing.T) {
+		server, connections := newConnectionCountingServer(t)
+		poller := NewDatasourcePoller(http.DefaultClient, DatasourcePollerConfig{
+			Services: []ServiceConfig{{Name: "accounts", URL: server.URL}},
+		})
+
+		for i := 0; i < 10; i++ {
+			sdl, err := poller.fetchServiceSDL(context.Background(), server.URL)
+			require.NoError(t, err)
+			assert.Equal(t, "type Query { me: String }", sdl)
+		}
+		assert.Equal(t, int64(1), atomic.LoadInt64(connections))
+	})
+
+	t.Run("keeps idle connections of a burst open", func(t *testing.T) {
+		server, connections := newConnectionCountingServer(t)
+		poller := NewDatasourcePoller(http.DefaultClient, DatasourcePollerConfig{
+			Services: []ServiceConfig{{Name: "accounts", URL: server.URL}},
+		})
+
+		fetchBurst(t, poller, server.URL, 5, 16)
+		assert.LessOrEqual(t, atomic.LoadInt64(connections), int64(16))
+	})
+
+	t.Run("transport override", func(t *testing.T) {
+		server, _ := newConnectionCountingServer(t)
+		transport := &http.Transport{}
+		poller := NewDatasourcePoller(http.DefaultClient, DatasourcePollerConfig{
+			Services: []ServiceConfig{{Name: "accounts", URL: server.URL, Transport: transport}},
+		})
+
+		assert.Same(t, transport, poller.ServiceHttpClients()[server.URL].Transport)
+	})
+
+	t.Run("tuned transport", func(t *testing.T) {
+		poller := NewDatasourcePoller(&http.Client{Timeout: 5}, DatasourcePollerConfig{
+			Services:            []ServiceConfig{{Name: "accounts", URL: "http://accounts.service"}},
+			MaxIdleConnsPerHost: 32,
+		})
+
+		client := poller.ServiceHttpClients()["http://accounts.service"]
+		require.NotNil(t, client)
+		assert.EqualValues(t, 5, client.Timeout)
+
+		transport, ok := client.Transport.(*http.Transport)
+		require.True(t, ok)
+		assert.True(t, transport.ForceAttemptHTTP2)
+		assert.Equal(t, 32, transport.MaxIdleConnsPerHost)
+		assert.Equal(t, defaultIdleConnTimeout, transport.IdleConnTimeout)
+	})
+
+	t.Run("mutual tls", func(t *testing.T) {
+		accountsCA, accountsCAKey := newCertificateAuthority(t)
+		productsCA, productsCAKey := newCertificateAuthority(t)
+		accounts := newMutualTLSServer(t, accountsCA)
+		products := newMutualTLSServer(t, productsCA)
+
+		serverCAs := func(servers ...*httptest.Server) *x509.CertPool {
+			pool := x509.NewCertPool()
+			for _, server := range servers {
+				pool.AddCert(server.Certificate())
+			}
+			return pool
+		}
+
+		poller := NewDatasourcePoller(http.DefaultClient, DatasourcePollerConfig{
+			Services: []ServiceConfig{
+				{
+					Name: "accounts",
+					URL:  accounts.URL,
+					TLSConfig: &tls.Config{
+						RootCAs:      serverCAs(accounts),
+						Certificates: []tls.Certificate{newClientCertificate(t, accountsCA, accountsCAKey)},
+					},
+				},
+				{
+					Name: "products",
+					URL:  products.URL,
+					TLSConfig: &tls.Config{
+						RootCAs:      serverCAs(products),
+						Certificates: []tls.Certificate{newClientCertificate(t, productsCA, productsCAKey)},
+					},
+				},
+			},
+		})
+
+		for _, serviceURL := range []string{accounts.URL, products.URL} {
+			sdl, err := poller.fetchServiceSDL(context.Background(), serviceURL)
+			require.NoError(t, err)
+			assert.Equal(t, "type Query { me: String }", sdl)
+		}
+
+		for name, tlsConfig := range map[string]*tls.Config{
+			"without certificate": {
+				RootCAs: serverCAs(accounts),
+			},
+			"with certificate of another ca": {
+				RootCAs:      serverCAs(accounts),
+				Certificates: []tls.Certificate{newClientCertificate(t, productsCA, productsCAKey)},
+			},
+		} {
+			poller := NewDatasourcePoller(http.DefaultClient, DatasourcePollerConfig{
+				Services: []ServiceConfig{{Name: "accounts", URL: accounts.URL, TLSConfig: tlsConfig}},
+			})
+			_, err := poller.fetchServiceSDL(context.Background(), accounts.URL)
+			assert.Error(t, err, name)
+		}
+	})
+}
+
+func BenchmarkDatasourcePoller_ConnectionReuse(b *testing.B) {
+	benchmark := func(b *testing.B, transport http.RoundTripper) {
+		server, connections := newConnectionCountingServer(b)
+		poller := NewDatasourcePoller(http.DefaultClient, DatasourcePollerConfig{
+			Services: []ServiceConfig{{Name: "accounts", URL: server.URL, Transport: transport}},
+		})
+
+		b.ResetTimer()
+		fetchBurst(b, poller, server.URL, b.N, 32)
+		b.ReportMetric(float64(atomic.LoadInt64(connections))/float64(b.N), "conns/op")
+	}
+
+	b.Run("default client", func(b *testing.B) {
+		benchmark(b, http.DefaultTransport.(*http.Transport).Clone())
+	})
+	b.Run("service transport", func(b *testing.B) {
+		benchmark(b, nil)
+	})
+}
@@ -0,0 +1,40 @@
+package gateway
+
+import (
+	"encoding/json"
+	"net/http"
+
+	http2 "github.com/wundergraph/graphql-go-tools/pkg/testing/federationtesting/gateway/http"
+)
+
+const subscriptionsDebugPattern = "/debug/subscriptions"
+
+type subscriptionsDebugResponse struct {
+	Subscriptions []http2.ActiveSubscription `json:"subscriptions"`
+}
+
+// NewSubscriptionsDebugHandler lists the active subscriptions of the gateway, e.g.:
+//
+//	{"subscriptions":[{"clientId":1,"remoteAddr":"127.0.0.1:53412","id":"1","query":"subscription { updatedPrice { upc } }","startedAt":"...","upstreams":[{"url":"ws://products/query","sse":false,"connectionId":...,"streamId":...}]}]}
+//
+// Subscriptions sharing an upstream subscription have the same stream id, subscriptions sharing the connection
+// to a subgraph the same connection id. Only GET requests are allowed.
+func NewSubscriptionsDebugHandler(gateway *Gateway) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.Header().Set("Allow", http.MethodGet)
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+		response := subscriptionsDebugResponse{
+			Subscriptions: gateway.Subscriptions(),
+		}
+		if response.Subscriptions == nil {
+			response.Subscriptions = []http2.ActiveSubscription{}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(response)
+	})
+}
@@ -8,7 +8,10 @@ import (
 	log "github.com/jensneuse/abstractlogger"
 
 	graphqlDataSource "github.com/wundergraph/graphql-go-tools/pkg/engine/datasource/graphql_datasource"
+	"github.com/wundergraph/graphql-go-tools/pkg/engine/plan"
+	"github.com/wundergraph/graphql-go-tools/pkg/federation"
 	"github.com/wundergraph/graphql-go-tools/pkg/graphql"
+	http2 "github.com/wundergraph/graphql-go-tools/pkg/testing/federationtesting/gateway/http"
 )
 
 type DataSourceObserver interface {
@@ -34,30 +37,58 @@ func NewGateway(
 	httpClient *http.Client,
 	logger log.Logger,
 ) *Gateway {
-	return &Gateway{
+	gateway := &Gateway{
 		gqlHandlerFactory: gqlHandlerFactory,
 		httpClient:        httpClient,
 		logger:            logger,
 
 		mu:        &sync.Mutex{},
+		mux:       http.NewServeMux(),
 		readyCh:   make(chan struct{}),
 		readyOnce: &sync.Once{},
 	}
+	gateway.mux.Handle("/", http.HandlerFunc(gateway.serveGraphQL))
+	return gateway
 }
 
 type Gateway struct {
-	gqlHandlerFactory HandlerFactory
-	httpClient        *http.Client
-	logger            log.Logger
+	gqlHandlerFactory  HandlerFactory
+	httpClient         *http.Client
+	serviceHttpClients map[string]*http.Client
+	operations         *http2.OperationTracker
+	fieldMocks         []fieldMock
+	// subscriptionFilters are the filters of the events of subscription fields, see WithSubscriptionFilter
+	subscriptionFilters []subscriptionFilter
+	logger              log.Logger
+	// rejectBreakingChanges keeps serving the current schema if an update of the data sources breaks it
+	rejectBreakingChanges bool
+	mergedSchemaSDL       string
+	// publicSchemaDirectives are the directives shown in the public schema, the usages of all are shown if it's empty
+	publicSchemaDirectives []string
+	// operationHasher is nil if the engine uses its default hasher for the keys of operations
+	operationHasher graphql.OperationHasher
 
 	gqlHandler http.Handler
+	engine     *graphql.ExecutionEngineV2
 	mu         *sync.Mutex
+	// mux routes requests to additional routes, all other requests are served by the GraphQL handler
+	mux *http.ServeMux
 
 	readyCh   chan struct{}
 	readyOnce *sync.Once
 }
 
 func (g *Gateway) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	g.mux.ServeHTTP(w, r)
+}
+
+// Handle registers a handler for a route served alongside the GraphQL endpoint, e.g. "/healthz".
+// Patterns follow the rules of http.ServeMux, requests not matching any route are served by the GraphQL handler.
+func (g *Gateway) Handle(pattern string, handler http.Handler) {
+	g.mux.Handle(pattern, handler)
+}
+
+func (g *Gateway) serveGraphQL(w http.ResponseWriter, r *http.Request) {
 	g.mu.Lock()
 	handler := g.gqlHandler
 	g.mu.Unlock()
@@ -69,6 +100,49 @@ func (g *Gateway) Ready() {
 	<-g.readyCh
 }
 
+// WarmUp plans the operations once the data sources are available, so the first requests of the operations
+// are served from the plan cache, see graphql.ExecutionEngineV2.WarmUp. It returns the errors of the operations
+// which can't be planned. Plans are cached per schema, after an update of the data sources the operations are
+// planned again by their first request.
+func (g *Gateway) WarmUp(ctx context.Context, operations [][]byte) ([]graphql.WarmUpError, error) {
+	select {
+	case <-g.readyCh:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	g.mu.Lock()
+	engine := g.engine
+	g.mu.Unlock()
+
+	return engine.WarmUp(ctx, operations), nil
+}
+
+// Shutdown stops accepting new operations, sends a complete message to all active subscriptions
+// and waits for in-flight operations until the context is done.
+func (g *Gateway) Shutdown(ctx context.Context) error {
+	if g.operations == nil {
+		return nil
+	}
+	return g.operations.Shutdown(ctx)
+}
+
+// ActiveSubscriptions returns the number of active subscriptions of all websocket connections
+func (g *Gateway) ActiveSubscriptions() int {
+	if g.operations == nil {
+		return 0
+	}
+	return g.operations.ActiveSubscriptions()
+}
+
+// Subscriptions returns the active subscriptions of all websocket connections, see http.OperationTracker.Subscriptions
+func (g *Gateway) Subscriptions() []http2.ActiveSubscription {
+	if g.operations == nil {
+		return nil
+	}
+	return g.operations.Subscriptions()
+}
+
 // Error handling is not finished.
 func (g *Gateway) UpdateDataSources(newDataSourcesConfig []graphqlDataSource.Configuration) {
 	ctx := context.Background()
@@ -76,11 +150,35 @@ func (g *Gateway) UpdateDataSources(newDataSourcesConfig []graphqlDataSource.Con
 		newDataSourcesConfig,
 		graphqlDataSource.NewBatchFactory(),
 		graphql.WithFederationHttpClient(g.httpClient),
+		graphql.WithFederationDataSourceHttpClients(g.serviceHttpClients),
+		graphql.WithFederationSubscriptionMultiplexing(),
+		graphql.WithFederationPublicSchemaDirectives(g.publicSchemaDirectives...),
 	)
 
-	schema, err := engineConfigFactory.MergedSchema()
+	mergedSchema, err := engineConfigFactory.MergedSchema()
+	if err != nil {
+		g.logger.Error("get merged schema:", log.Error(err))
+		return
+	}
+	mergedSchemaSDL := string(mergedSchema.Input())
+	if g.rejectBreakingChanges && g.mergedSchemaSDL != "" {
+		report, err := federation.CheckSchemaCompatibility(g.mergedSchemaSDL, mergedSchemaSDL)
+		if err != nil {
+			g.logger.Error("check schema compatibility:", log.Error(err))
+			return
+		}
+		if report.HasBreakingChanges() {
+			for _, change := range report.BreakingChanges() {
+				g.logger.Error("rejected breaking schema change", log.String("path", change.Path), log.String("change", change.Message))
+			}
+			return
+		}
+	}
+
+	// clients only get to see the public schema, planning uses the merged schema of the engine config
+	schema, err := engineConfigFactory.PublicSchema()
 	if err != nil {
-		g.logger.Error("get schema:", log.Error(err))
+		g.logger.Error("get public schema:", log.Error(err))
 		return
 	}
 
@@ -89,6 +187,19 @@ func (g *Gateway) UpdateDataSources(newDataSourcesConfig []graphqlDataSource.Con
 		g.logger.Error("get engine config: %v", log.Error(err))
 		return
 	}
+	datasourceConfig.EnableFetchDeduplication(true)
+	if g.operationHasher != nil {
+		datasourceConfig.SetOperationHasher(g.operationHasher)
+	}
+	for _, mock := range g.fieldMocks {
+		datasourceConfig.AddFieldMock(mock.typeName, mock.fieldName, mock.resolver)
+	}
+	datasourceConfig.EnableFieldMocks(len(g.fieldMocks) > 0)
+	for _, filter := range g.subscriptionFilters {
+		datasourceConfig.SetSubscriptionFilter(filter.typeName, filter.fieldName, &plan.SubscriptionFilterConfiguration{
+			Conditions: filter.conditions,
+		})
+	}
 
 	engine, err := graphql.NewExecutionEngineV2(ctx, g.logger, datasourceConfig)
 	if err != nil {
@@ -98,6 +209,8 @@ func (g *Gateway) UpdateDataSources(newDataSourcesConfig []graphqlDataSource.Con
 
 	g.mu.Lock()
 	g.gqlHandler = g.gqlHandlerFactory.Make(schema, engine)
+	g.engine = engine
+	g.mergedSchemaSDL = mergedSchemaSDL
 	g.mu.Unlock()
 
 	g.readyOnce.Do(func() { close(g.readyCh) })
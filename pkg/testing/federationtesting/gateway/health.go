@@ -0,0 +1,41 @@
+package gateway
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+const (
+	healthStatusPass = "pass"
+	healthStatusFail = "fail"
+)
+
+type healthResponse struct {
+	Status   string          `json:"status"`
+	Services []ServiceStatus `json:"services"`
+}
+
+// NewHealthHandler reports the reachability of the services as of the last poll of the poller, e.g.:
+//
+//	{"status":"fail","services":[{"name":"accounts","url":"http://accounts","reachable":false,"error":"...","lastPoll":"..."}]}
+//
+// It responds with 200 if all services are reachable, otherwise with 503.
+func NewHealthHandler(poller *DatasourcePollerPoller) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		response := healthResponse{
+			Status:   healthStatusPass,
+			Services: poller.ServiceStatuses(),
+		}
+		for _, service := range response.Services {
+			if !service.Reachable {
+				response.Status = healthStatusFail
+			}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if response.Status != healthStatusPass {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+		_ = json.NewEncoder(w).Encode(response)
+	})
+}
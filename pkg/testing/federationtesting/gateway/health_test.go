@@ -0,0 +1,95 @@
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/jensneuse/abstractlogger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHealthHandler(t *testing.T) {
+	accounts, _ := newConnectionCountingServer(t)
+	products := httptest.NewServer(http.NotFoundHandler())
+	products.Close()
+
+	poller := NewDatasourcePoller(http.DefaultClient, DatasourcePollerConfig{
+		Services: []ServiceConfig{
+			{Name: "accounts", URL: accounts.URL},
+			{Name: "products", URL: products.URL},
+		},
+	})
+	gateway := Handler(abstractlogger.NoopLogger, poller, http.DefaultClient,
+		WithRoute("/healthz", NewHealthHandler(poller)),
+	)
+	gateway.Handle("/metrics", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("operations_total 0"))
+	}))
+
+	healthz := func(t *testing.T) (int, healthResponse) {
+		recorder := httptest.NewRecorder()
+		gateway.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+
+		var response healthResponse
+		require.NoError(t, json.NewDecoder(recorder.Body).Decode(&response))
+		return recorder.Code, response
+	}
+
+	t.Run("services are unreachable before the first poll", func(t *testing.T) {
+		code, response := healthz(t)
+		assert.Equal(t, http.StatusServiceUnavailable, code)
+		assert.Equal(t, healthStatusFail, response.Status)
+		require.Len(t, response.Services, 2)
+		assert.Equal(t, "not polled yet", response.Services[0].Error)
+		assert.Equal(t, "not polled yet", response.Services[1].Error)
+	})
+
+	poller.updateSDLs(context.Background())
+
+	t.Run("reports status of each service", func(t *testing.T) {
+		code, response := healthz(t)
+		assert.Equal(t, http.StatusServiceUnavailable, code)
+		assert.Equal(t, healthStatusFail, response.Status)
+		require.Len(t, response.Services, 2)
+
+		assert.Equal(t, "accounts", response.Services[0].Name)
+		assert.Equal(t, accounts.URL, response.Services[0].URL)
+		assert.True(t, response.Services[0].Reachable)
+		assert.Empty(t, response.Services[0].Error)
+		assert.False(t, response.Services[0].LastPoll.IsZero())
+
+		assert.Equal(t, "products", response.Services[1].Name)
+		assert.Equal(t, products.URL, response.Services[1].URL)
+		assert.False(t, response.Services[1].Reachable)
+		assert.Contains(t, response.Services[1].Error, "connection refused")
+		assert.False(t, response.Services[1].LastPoll.IsZero())
+	})
+
+	t.Run("passes when all services are reachable", func(t *testing.T) {
+		poller := NewDatasourcePoller(http.DefaultClient, DatasourcePollerConfig{
+			Services: []ServiceConfig{{Name: "accounts", URL: accounts.URL}},
+		})
+		poller.updateSDLs(context.Background())
+
+		recorder := httptest.NewRecorder()
+		NewHealthHandler(poller).ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		assert.Contains(t, recorder.Body.String(), `"status":"pass"`)
+	})
+
+	t.Run("custom routes coexist with the graphql endpoint", func(t *testing.T) {
+		recorder := httptest.NewRecorder()
+		gateway.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+		assert.Equal(t, "operations_total 0", recorder.Body.String())
+
+		recorder = httptest.NewRecorder()
+		gateway.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"query":"{ me }"}`)))
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		assert.Contains(t, recorder.Body.String(), `"data"`)
+	})
+}
@@ -0,0 +1,71 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"errors"
+
+	"github.com/wundergraph/graphql-go-tools/pkg/graphql"
+	"github.com/wundergraph/graphql-go-tools/pkg/subscription"
+)
+
+// ErrPersistedQueryNotInAllowlist rejects operations which aren't in the OperationAllowlist of the handler
+var ErrPersistedQueryNotInAllowlist = errors.New("PersistedQueryNotInAllowlist")
+
+// OperationAllowlist restricts the operations executed by the handler to a fixed set of operations,
+// e.g. the persisted operations of the clients of a locked-down deployment.
+// Other than automatic persisted queries the set never grows, every other operation is rejected without executing it.
+// Operations are identified by their key, see OperationAllowlistKey.
+type OperationAllowlist struct {
+	hasher graphql.OperationHasher
+	keys   map[string]struct{}
+}
+
+// NewOperationAllowlist creates an allowlist of the operations with the keys computed with the hasher,
+// see OperationAllowlistKey
+func NewOperationAllowlist(hasher graphql.OperationHasher, keys ...string) *OperationAllowlist {
+	allowlist := &OperationAllowlist{
+		hasher: hasher,
+		keys:   make(map[string]struct{}, len(keys)),
+	}
+	for _, key := range keys {
+		allowlist.keys[key] = struct{}{}
+	}
+	return allowlist
+}
+
+// OperationAllowlistKey returns the key of the query identifying it in an OperationAllowlist using the hasher.
+// The key is independent of formatting and variables but includes directives, see graphql.Request.FingerprintKey.
+func OperationAllowlistKey(hasher graphql.OperationHasher, query string) (string, error) {
+	request := graphql.Request{Query: query}
+	return request.FingerprintKey(hasher, graphql.WithFingerprintDirectives())
+}
+
+// Allows reports whether the operation of the request is in the allowlist, operations which can't be parsed are not
+func (a *OperationAllowlist) Allows(gqlRequest *graphql.Request) bool {
+	key, err := gqlRequest.FingerprintKey(a.hasher, graphql.WithFingerprintDirectives())
+	if err != nil {
+		return false
+	}
+	_, ok := a.keys[key]
+	return ok
+}
+
+// subscriptionMiddleware rejects operations sent over websockets which aren't in the allowlist
+func (a *OperationAllowlist) subscriptionMiddleware(next subscription.StartFunc) subscription.StartFunc {
+	return func(ctx context.Context, operation subscription.OperationInfo) error {
+		var gqlRequest graphql.Request
+		if err := graphql.UnmarshalRequest(bytes.NewReader(operation.Payload), &gqlRequest); err != nil {
+			return err
+		}
+		if !a.Allows(&gqlRequest) {
+			return ErrPersistedQueryNotInAllowlist
+		}
+		return next(ctx, operation)
+	}
+}
+
+// allows reports whether the handler executes the operation of the request
+func (g *GraphQLHTTPRequestHandler) allows(gqlRequest *graphql.Request) bool {
+	return g.allowlist == nil || g.allowlist.Allows(gqlRequest)
+}
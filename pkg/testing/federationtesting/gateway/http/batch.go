@@ -0,0 +1,115 @@
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+
+	log "github.com/jensneuse/abstractlogger"
+
+	"github.com/wundergraph/graphql-go-tools/pkg/graphql"
+)
+
+const (
+	// DefaultMaxBatchSize is the maximum number of operations of a batched request,
+	// larger batches are rejected without executing any of their operations
+	DefaultMaxBatchSize = 100
+	// DefaultBatchConcurrency is the maximum number of operations of a batched request executed at the same time
+	DefaultBatchConcurrency = 10
+)
+
+// ErrIncrementalDeliveryInBatch rejects operations of batched requests using @defer or @stream,
+// their multipart responses can't be part of the JSON array of a batched response
+var ErrIncrementalDeliveryInBatch = errors.New("@defer and @stream are not supported in batched requests")
+
+// handleBatchHTTP executes the operations of a batched request concurrently, at most batchConcurrency at a time,
+// and writes the results as a JSON array in the order of the request.
+// Every operation runs through the same path as the operation of a single request, see executeRequest.
+// An error of a single operation is written as the result of this operation only.
+func (g *GraphQLHTTPRequestHandler) handleBatchHTTP(w http.ResponseWriter, r *http.Request, body []byte) {
+	var operations []json.RawMessage
+	if err := json.Unmarshal(body, &operations); err != nil {
+		g.log.Error("unmarshal batch request", log.Error(err))
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	if len(operations) > g.maxBatchSize {
+		g.writeRequestError(w, http.StatusBadRequest, fmt.Sprintf("the batch contains %d operations, at most %d are allowed", len(operations), g.maxBatchSize))
+		return
+	}
+
+	results := make([]operationResult, len(operations))
+	concurrency := make(chan struct{}, g.batchConcurrency)
+
+	wg := &sync.WaitGroup{}
+	wg.Add(len(operations))
+	for i := range operations {
+		concurrency <- struct{}{}
+		go func(i int) {
+			defer func() {
+				<-concurrency
+				wg.Done()
+			}()
+			results[i] = g.executeBatchOperation(r, operations[i])
+		}(i)
+	}
+	wg.Wait()
+
+	buf := bytes.NewBuffer(make([]byte, 0, 4096))
+	buf.WriteByte('[')
+	var cacheControl graphql.CacheControl
+	for i := range results {
+		if i != 0 {
+			buf.WriteByte(',')
+			cacheControl = mergeCacheControl(cacheControl, results[i].cacheControl)
+		} else {
+			cacheControl = results[i].cacheControl
+		}
+		buf.Write(results[i].response)
+	}
+	buf.WriteByte(']')
+
+	w.Header().Set(httpHeaderCacheControl, cacheControl.HeaderValue())
+	g.writeResponse(w, http.StatusOK, buf.Bytes())
+}
+
+func (g *GraphQLHTTPRequestHandler) executeBatchOperation(r *http.Request, operation []byte) operationResult {
+	var gqlRequest graphql.Request
+	if err := graphql.UnmarshalRequest(bytes.NewReader(operation), &gqlRequest); err != nil {
+		g.log.Error("UnmarshalRequest", log.Error(err))
+		g.recordOperation(nil, nil, err)
+		return operationResult{response: g.errorResponse(r.Context(), err)}
+	}
+	gqlRequest.SetHeader(r.Header)
+
+	result := g.executeRequest(nil, r, &gqlRequest)
+	if result.statusCode != 0 {
+		result.response = g.errorResponse(r.Context(), errors.New(http.StatusText(result.statusCode)))
+	}
+	return result
+}
+
+// mergeCacheControl returns the cache policy of a response containing responses of both policies
+func mergeCacheControl(a, b graphql.CacheControl) graphql.CacheControl {
+	if !a.Cacheable() || !b.Cacheable() {
+		return graphql.CacheControl{}
+	}
+	merged := a
+	if b.MaxAge < merged.MaxAge {
+		merged.MaxAge = b.MaxAge
+	}
+	if b.Scope == graphql.CacheControlScopePrivate {
+		merged.Scope = graphql.CacheControlScopePrivate
+	}
+	return merged
+}
+
+// isBatchRequest reports whether the body contains a JSON array of operations.
+func isBatchRequest(body []byte) bool {
+	body = bytes.TrimLeft(body, " \t\r\n")
+	return len(body) != 0 && body[0] == '['
+}
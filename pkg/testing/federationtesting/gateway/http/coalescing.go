@@ -0,0 +1,171 @@
+package http
+
+import (
+	"context"
+	"encoding/binary"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/cespare/xxhash/v2"
+
+	"github.com/wundergraph/graphql-go-tools/pkg/graphql"
+)
+
+// OperationCoalescer runs concurrent identical queries only once and shares the result with all callers,
+// e.g. to protect subgraphs from a thundering herd of identical queries after a cache expired.
+// Queries are identical if the normalized operation, the variables, the authorization scopes of the requests
+// and their headers are equal. Mutations and subscriptions are never coalesced.
+// The shared execution runs with the context and the headers of the first request, so the headers of the coalescer
+// must contain every header the execution reads or forwards, e.g. to the subgraphs or a URL rewriter.
+type OperationCoalescer struct {
+	mu       sync.Mutex
+	inflight map[uint64]*inflightOperation
+	// headers are the request headers which must be equal, nil if all headers must be equal
+	headers []string
+	// timeout bounds the shared execution, it doesn't end with the request starting it
+	timeout time.Duration
+}
+
+// DefaultCoalescingTimeout is the time a coalesced operation may take before it's canceled
+const DefaultCoalescingTimeout = 30 * time.Second
+
+type inflightOperation struct {
+	done     chan struct{}
+	response []byte
+	err      error
+}
+
+// NewOperationCoalescer returns a coalescer of the queries whose headers are equal.
+// Without headers all headers of the requests must be equal, as the execution might forward any of them.
+// The header of the subgraph metrics always has to be equal, as it changes the response.
+func NewOperationCoalescer(headers ...string) *OperationCoalescer {
+	if len(headers) != 0 {
+		headers = append(append(make([]string, 0, len(headers)+1), headers...), httpHeaderSubgraphMetrics)
+	}
+	return &OperationCoalescer{
+		inflight: map[uint64]*inflightOperation{},
+		headers:  headers,
+		timeout:  DefaultCoalescingTimeout,
+	}
+}
+
+// do runs execute unless an operation with the same key is in flight, in which case it waits for its result
+// or until ctx is done.
+// execute is called with a context keeping the values of ctx, which isn't canceled with ctx as other callers
+// might wait for the result, but times out after the timeout of the coalescer.
+// The response must not be modified by the callers as it's shared.
+func (c *OperationCoalescer) do(ctx context.Context, key uint64, execute func(ctx context.Context) ([]byte, error)) ([]byte, error) {
+	c.mu.Lock()
+	if operation, ok := c.inflight[key]; ok {
+		c.mu.Unlock()
+		select {
+		case <-operation.done:
+			return operation.response, operation.err
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	operation := &inflightOperation{done: make(chan struct{})}
+	c.inflight[key] = operation
+	c.mu.Unlock()
+
+	executionCtx, cancel := context.WithTimeout(detachedContext{parent: ctx}, c.timeout)
+	defer func() {
+		cancel()
+		c.mu.Lock()
+		delete(c.inflight, key)
+		c.mu.Unlock()
+		close(operation.done)
+	}()
+
+	operation.response, operation.err = execute(executionCtx)
+	return operation.response, operation.err
+}
+
+// coalescingKey returns the key of the operation of the request, ok is false if the operation must not be coalesced
+func (g *GraphQLHTTPRequestHandler) coalescingKey(ctx context.Context, gqlRequest *graphql.Request, header http.Header) (key uint64, ok bool) {
+	operationType, err := gqlRequest.OperationType()
+	if err != nil || operationType != graphql.OperationTypeQuery {
+		return 0, false
+	}
+	// invalid operations are executed to report their errors
+	if result, err := gqlRequest.Normalize(g.schema); err != nil || !result.Successful {
+		return 0, false
+	}
+	operationHash, err := gqlRequest.OperationHash()
+	if err != nil {
+		return 0, false
+	}
+
+	hash := xxhash.New()
+	var buf [8]byte
+	binary.LittleEndian.PutUint64(buf[:], g.schema.Hash())
+	_, _ = hash.Write(buf[:])
+	binary.LittleEndian.PutUint64(buf[:], operationHash)
+	_, _ = hash.Write(buf[:])
+	writeAuthorizationScopes(hash, ctx)
+
+	names := g.coalescer.headers
+	if names == nil {
+		names = make([]string, 0, len(header))
+		for name := range header {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+	}
+	writeHeaders(hash, header, names)
+	return hash.Sum64(), true
+}
+
+// writeAuthorizationScopes writes the scopes granted to the request to the hash of a key,
+// the results of requests with different scopes might differ, see graphql.WithAuthorizationScopes
+func writeAuthorizationScopes(hash *xxhash.Digest, ctx context.Context) {
+	scopes, authenticated := graphql.AuthorizationScopesFromContext(ctx)
+	if !authenticated {
+		return
+	}
+	sorted := make([]string, len(scopes))
+	copy(sorted, scopes)
+	sort.Strings(sorted)
+	_, _ = hash.WriteString("scopes:")
+	for _, scope := range sorted {
+		_, _ = hash.WriteString(scope)
+		_, _ = hash.Write([]byte{0})
+	}
+}
+
+// writeHeaders writes the values of the headers with the names to the hash of a key
+func writeHeaders(hash *xxhash.Digest, header http.Header, names []string) {
+	for _, name := range names {
+		for _, value := range header.Values(name) {
+			_, _ = hash.WriteString(http.CanonicalHeaderKey(name))
+			_, _ = hash.Write([]byte{':'})
+			_, _ = hash.WriteString(value)
+			_, _ = hash.Write([]byte{0})
+		}
+	}
+}
+
+// detachedContext keeps the values of the context of the request starting a coalesced operation,
+// but isn't canceled with it, as other requests wait for the result of the operation
+type detachedContext struct {
+	parent context.Context
+}
+
+func (d detachedContext) Deadline() (deadline time.Time, ok bool) {
+	return time.Time{}, false
+}
+
+func (d detachedContext) Done() <-chan struct{} {
+	return nil
+}
+
+func (d detachedContext) Err() error {
+	return nil
+}
+
+func (d detachedContext) Value(key interface{}) interface{} {
+	return d.parent.Value(key)
+}
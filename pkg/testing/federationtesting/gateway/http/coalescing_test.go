@@ -0,0 +1,208 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/wundergraph/graphql-go-tools/pkg/graphql"
+)
+
+func TestOperationCoalescer(t *testing.T) {
+	// runConcurrently calls do with the key from n goroutines while the first execution is in flight
+	runConcurrently := func(coalescer *OperationCoalescer, n int, execute func() ([]byte, error)) (responses [][]byte, errs []error) {
+		responses, errs = make([][]byte, n), make([]error, n)
+		started := make(chan struct{})
+		release := make(chan struct{})
+
+		wg := &sync.WaitGroup{}
+		wg.Add(n)
+		go func() {
+			defer wg.Done()
+			responses[0], errs[0] = coalescer.do(context.Background(), 1, func(ctx context.Context) ([]byte, error) {
+				close(started)
+				<-release
+				return execute()
+			})
+		}()
+		<-started
+
+		for i := 1; i < n; i++ {
+			go func(i int) {
+				defer wg.Done()
+				responses[i], errs[i] = coalescer.do(context.Background(), 1, func(ctx context.Context) ([]byte, error) {
+					return execute()
+				})
+			}(i)
+		}
+		// give the waiters time to join the in-flight operation
+		time.Sleep(50 * time.Millisecond)
+		close(release)
+		wg.Wait()
+		return responses, errs
+	}
+
+	t.Run("shares the response", func(t *testing.T) {
+		var executions int32
+		responses, errs := runConcurrently(NewOperationCoalescer(), 10, func() ([]byte, error) {
+			atomic.AddInt32(&executions, 1)
+			return []byte(`{"data":{}}`), nil
+		})
+
+		assert.Equal(t, int32(1), atomic.LoadInt32(&executions))
+		for i := range responses {
+			assert.NoError(t, errs[i])
+			assert.Equal(t, `{"data":{}}`, string(responses[i]))
+		}
+	})
+
+	t.Run("propagates errors to all waiters", func(t *testing.T) {
+		errExecution := errors.New("execution failed")
+		var executions int32
+		_, errs := runConcurrently(NewOperationCoalescer(), 10, func() ([]byte, error) {
+			atomic.AddInt32(&executions, 1)
+			return nil, errExecution
+		})
+
+		assert.Equal(t, int32(1), atomic.LoadInt32(&executions))
+		for i := range errs {
+			assert.Equal(t, errExecution, errs[i])
+		}
+	})
+
+	t.Run("executes again after the operation finished", func(t *testing.T) {
+		coalescer := NewOperationCoalescer()
+		var executions int32
+		execute := func(ctx context.Context) ([]byte, error) {
+			atomic.AddInt32(&executions, 1)
+			return []byte(`{"data":{}}`), nil
+		}
+
+		_, err := coalescer.do(context.Background(), 1, execute)
+		require.NoError(t, err)
+		_, err = coalescer.do(context.Background(), 1, execute)
+		require.NoError(t, err)
+		assert.Equal(t, int32(2), atomic.LoadInt32(&executions))
+	})
+
+	t.Run("waiters return when their context is done", func(t *testing.T) {
+		coalescer := NewOperationCoalescer()
+		started := make(chan struct{})
+		release := make(chan struct{})
+		defer close(release)
+
+		go func() {
+			_, _ = coalescer.do(context.Background(), 1, func(ctx context.Context) ([]byte, error) {
+				close(started)
+				<-release
+				return nil, nil
+			})
+		}()
+		<-started
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		_, err := coalescer.do(ctx, 1, func(ctx context.Context) ([]byte, error) {
+			t.Fatal("operation in flight must not be executed again")
+			return nil, nil
+		})
+		assert.Equal(t, context.DeadlineExceeded, err)
+	})
+
+	t.Run("shared execution times out", func(t *testing.T) {
+		coalescer := NewOperationCoalescer()
+		coalescer.timeout = 50 * time.Millisecond
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		_, err := coalescer.do(ctx, 1, func(ctx context.Context) ([]byte, error) {
+			// the execution isn't canceled with the request starting it
+			assert.NoError(t, ctx.Err())
+			<-ctx.Done()
+			return nil, ctx.Err()
+		})
+		assert.Equal(t, context.DeadlineExceeded, err)
+	})
+}
+
+func TestGraphQLHTTPRequestHandler_coalescingKey(t *testing.T) {
+	schema, err := graphql.NewSchemaFromString(`
+		schema { query: Query mutation: Mutation }
+		type Query { product(upc: String!): String }
+		type Mutation { setPrice(upc: String!, price: Int!): String }
+	`)
+	require.NoError(t, err)
+	handler := &GraphQLHTTPRequestHandler{schema: schema, coalescer: NewOperationCoalescer()}
+
+	keyWithContext := func(t *testing.T, handler *GraphQLHTTPRequestHandler, ctx context.Context, query, variables string, header http.Header) (uint64, bool) {
+		request := graphql.Request{Query: query}
+		if variables != "" {
+			request.Variables = []byte(variables)
+		}
+		return handler.coalescingKey(ctx, &request, header)
+	}
+	key := func(t *testing.T, query, variables string, header http.Header) (uint64, bool) {
+		return keyWithContext(t, handler, context.Background(), query, variables, header)
+	}
+
+	t.Run("identical queries have the same key", func(t *testing.T) {
+		first, ok := key(t, `query P { product(upc: "1") }`, "", nil)
+		require.True(t, ok)
+		second, ok := key(t, "query P($a: String!) {\n\tproduct(upc: $a)\n}", `{"a":"1"}`, nil)
+		require.True(t, ok)
+		assert.Equal(t, first, second)
+	})
+
+	t.Run("variables change the key", func(t *testing.T) {
+		first, _ := key(t, `query P($upc: String!) { product(upc: $upc) }`, `{"upc":"1"}`, nil)
+		second, _ := key(t, `query P($upc: String!) { product(upc: $upc) }`, `{"upc":"2"}`, nil)
+		assert.NotEqual(t, first, second)
+	})
+
+	t.Run("authorization changes the key", func(t *testing.T) {
+		first, _ := key(t, `{ product(upc: "1") }`, "", http.Header{"Authorization": []string{"first"}})
+		second, _ := key(t, `{ product(upc: "1") }`, "", http.Header{"Authorization": []string{"second"}})
+		assert.NotEqual(t, first, second)
+	})
+
+	t.Run("cookies change the key", func(t *testing.T) {
+		first, _ := key(t, `{ product(upc: "1") }`, "", http.Header{"Cookie": []string{"session=first"}})
+		second, _ := key(t, `{ product(upc: "1") }`, "", http.Header{"Cookie": []string{"session=second"}})
+		assert.NotEqual(t, first, second)
+	})
+
+	t.Run("authorization scopes change the key", func(t *testing.T) {
+		first, _ := keyWithContext(t, handler, graphql.WithAuthorizationScopes(context.Background(), "read"), `{ product(upc: "1") }`, "", nil)
+		second, _ := keyWithContext(t, handler, graphql.WithAuthorizationScopes(context.Background(), "read", "write"), `{ product(upc: "1") }`, "", nil)
+		anonymous, _ := key(t, `{ product(upc: "1") }`, "", nil)
+		assert.NotEqual(t, first, second)
+		assert.NotEqual(t, first, anonymous)
+	})
+
+	t.Run("only the headers of the coalescer change the key", func(t *testing.T) {
+		handler := &GraphQLHTTPRequestHandler{schema: schema, coalescer: NewOperationCoalescer("Authorization")}
+		first, _ := keyWithContext(t, handler, context.Background(), `{ product(upc: "1") }`, "", http.Header{"Authorization": []string{"token"}, "User-Agent": []string{"first"}})
+		second, _ := keyWithContext(t, handler, context.Background(), `{ product(upc: "1") }`, "", http.Header{"Authorization": []string{"token"}, "User-Agent": []string{"second"}})
+		assert.Equal(t, first, second)
+
+		withMetrics, _ := keyWithContext(t, handler, context.Background(), `{ product(upc: "1") }`, "", http.Header{"Authorization": []string{"token"}, httpHeaderSubgraphMetrics: []string{"true"}})
+		assert.NotEqual(t, first, withMetrics)
+	})
+
+	t.Run("mutations are not coalesced", func(t *testing.T) {
+		_, ok := key(t, `mutation { setPrice(upc: "1", price: 1) }`, "", nil)
+		assert.False(t, ok)
+	})
+
+	t.Run("subscriptions are not coalesced", func(t *testing.T) {
+		_, ok := key(t, `subscription { product(upc: "1") }`, "", nil)
+		assert.False(t, ok)
+	})
+}
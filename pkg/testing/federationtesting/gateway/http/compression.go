@@ -0,0 +1,162 @@
+package http
+
+import (
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+const (
+	httpHeaderAcceptEncoding  string = "Accept-Encoding"
+	httpHeaderContentEncoding string = "Content-Encoding"
+	httpHeaderContentLength   string = "Content-Length"
+	httpHeaderVary            string = "Vary"
+
+	encodingGzip    = "gzip"
+	encodingDeflate = "deflate"
+)
+
+// ResponseCompression compresses the responses of HTTP requests with gzip or deflate, whichever the client prefers
+// according to its Accept-Encoding header. deflate is the zlib format of RFC 1950, as HTTP defines it.
+// br isn't supported as the standard library has no brotli encoder, clients only accepting br or other encodings
+// get uncompressed responses.
+// Streamed responses, e.g. multipart responses of incremental delivery, are compressed as one stream
+// which gets flushed with every part.
+type ResponseCompression struct {
+	// MinSize is the minimum size of a response in bytes to be compressed, smaller responses are written unchanged
+	MinSize int
+}
+
+// compressResponse wraps the writer to compress the response, if the client accepts a supported encoding.
+// The returned func must be called when the response is complete.
+func (c *ResponseCompression) compressResponse(w http.ResponseWriter, r *http.Request) (http.ResponseWriter, func()) {
+	w.Header().Add(httpHeaderVary, httpHeaderAcceptEncoding)
+	encoding := negotiateEncoding(r.Header.Get(httpHeaderAcceptEncoding))
+	if encoding == "" {
+		return w, func() {}
+	}
+	writer := &compressingResponseWriter{
+		ResponseWriter: w,
+		encoding:       encoding,
+		minSize:        c.MinSize,
+		statusCode:     http.StatusOK,
+	}
+	return writer, writer.close
+}
+
+// negotiateEncoding returns the supported encoding with the highest quality of the Accept-Encoding header,
+// gzip is preferred over deflate for equal qualities. It returns "" if none of them is accepted.
+func negotiateEncoding(acceptEncoding string) string {
+	var (
+		encoding    string
+		bestQuality float64
+	)
+	for _, element := range strings.Split(acceptEncoding, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(element), ";")
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name != encodingGzip && name != encodingDeflate {
+			continue
+		}
+		quality := 1.0
+		if params = strings.TrimSpace(params); strings.HasPrefix(params, "q=") {
+			parsed, err := strconv.ParseFloat(strings.TrimPrefix(params, "q="), 64)
+			if err != nil {
+				continue
+			}
+			quality = parsed
+		}
+		if quality <= 0 {
+			continue
+		}
+		if quality > bestQuality || (quality == bestQuality && name == encodingGzip) {
+			encoding, bestQuality = name, quality
+		}
+	}
+	return encoding
+}
+
+// compressingResponseWriter buffers the response until it reaches the minimum size to be compressed.
+// A flush compresses the response regardless of its size, as a streamed response might continue indefinitely.
+type compressingResponseWriter struct {
+	http.ResponseWriter
+	encoding   string
+	minSize    int
+	statusCode int
+	buf        []byte
+	// compressor is nil until the response is compressed
+	compressor io.WriteCloser
+	// committed is true once the header is written, the response is written uncompressed if compressor is nil
+	committed bool
+}
+
+func (c *compressingResponseWriter) WriteHeader(statusCode int) {
+	c.statusCode = statusCode
+}
+
+func (c *compressingResponseWriter) Write(p []byte) (int, error) {
+	if c.committed {
+		if c.compressor != nil {
+			return c.compressor.Write(p)
+		}
+		return c.ResponseWriter.Write(p)
+	}
+	c.buf = append(c.buf, p...)
+	if len(c.buf) >= c.minSize {
+		if err := c.startCompression(); err != nil {
+			return 0, err
+		}
+	}
+	return len(p), nil
+}
+
+func (c *compressingResponseWriter) Flush() {
+	if !c.committed {
+		if err := c.startCompression(); err != nil {
+			return
+		}
+	}
+	if flusher, ok := c.compressor.(interface{ Flush() error }); ok {
+		_ = flusher.Flush()
+	}
+	if flusher, ok := c.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+func (c *compressingResponseWriter) startCompression() (err error) {
+	c.committed = true
+	header := c.Header()
+	header.Set(httpHeaderContentEncoding, c.encoding)
+	header.Del(httpHeaderContentLength)
+	c.ResponseWriter.WriteHeader(c.statusCode)
+
+	switch c.encoding {
+	case encodingGzip:
+		c.compressor = gzip.NewWriter(c.ResponseWriter)
+	default:
+		c.compressor = zlib.NewWriter(c.ResponseWriter)
+	}
+	buffered := c.buf
+	c.buf = nil
+	_, err = c.compressor.Write(buffered)
+	return err
+}
+
+// close writes responses smaller than the minimum size uncompressed and completes compressed responses
+func (c *compressingResponseWriter) close() {
+	if c.compressor != nil {
+		_ = c.compressor.Close()
+		return
+	}
+	if c.committed {
+		return
+	}
+	c.committed = true
+	c.ResponseWriter.WriteHeader(c.statusCode)
+	if len(c.buf) > 0 {
+		_, _ = c.ResponseWriter.Write(c.buf)
+	}
+}
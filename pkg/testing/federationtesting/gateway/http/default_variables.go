@@ -0,0 +1,44 @@
+package http
+
+import (
+	"encoding/json"
+
+	"github.com/wundergraph/graphql-go-tools/pkg/graphql"
+)
+
+// DefaultVariables are the values of variables the handler fills in when clients omit them, e.g. a locale.
+// Default values declared by the operation take precedence, see graphql.Request.SetDefaultVariables.
+type DefaultVariables struct {
+	// Global are the defaults of every operation
+	Global map[string]json.RawMessage
+	// Operations maps operation names to their defaults, they take precedence over the global defaults
+	Operations map[string]map[string]json.RawMessage
+}
+
+// forOperation returns the defaults of the operation with the name
+func (d *DefaultVariables) forOperation(operationName string) map[string]json.RawMessage {
+	operationDefaults := d.Operations[operationName]
+	if len(operationDefaults) == 0 {
+		return d.Global
+	}
+	if len(d.Global) == 0 {
+		return operationDefaults
+	}
+	defaults := make(map[string]json.RawMessage, len(d.Global)+len(operationDefaults))
+	for name, value := range d.Global {
+		defaults[name] = value
+	}
+	for name, value := range operationDefaults {
+		defaults[name] = value
+	}
+	return defaults
+}
+
+// setDefaultVariables fills in the variables of the request the client omitted,
+// invalid operations are left unchanged as their errors are reported by the execution
+func (g *GraphQLHTTPRequestHandler) setDefaultVariables(gqlRequest *graphql.Request) {
+	if g.defaultVariables == nil {
+		return
+	}
+	_ = gqlRequest.SetDefaultVariables(g.defaultVariables.forOperation(gqlRequest.OperationName))
+}
@@ -0,0 +1,121 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+
+	"github.com/wundergraph/graphql-go-tools/pkg/graphql"
+	"github.com/wundergraph/graphql-go-tools/pkg/graphqlerrors"
+	"github.com/wundergraph/graphql-go-tools/pkg/operationreport"
+	"github.com/wundergraph/graphql-go-tools/pkg/postprocess"
+)
+
+// GraphQLError is a single error of the "errors" array of a response
+type GraphQLError struct {
+	Message    string                   `json:"message"`
+	Locations  []graphqlerrors.Location `json:"locations,omitempty"`
+	Path       []interface{}            `json:"path,omitempty"`
+	Extensions map[string]interface{}   `json:"extensions,omitempty"`
+}
+
+// ResponseError is an error of the "errors" array of a resolved response, e.g. an error returned by a subgraph
+type ResponseError struct {
+	GraphQLError
+}
+
+func (e *ResponseError) Error() string {
+	return e.Message
+}
+
+// ErrorPresenter turns an error into the error written to the response.
+// It receives the original error, e.g. a graphql.RequestError for planning and validation errors
+// or a *ResponseError for fetch and resolve errors, and might replace internal messages with safe ones.
+type ErrorPresenter func(ctx context.Context, err error) GraphQLError
+
+// DefaultErrorPresenter presents errors the same way as a handler without ErrorPresenter
+func DefaultErrorPresenter(_ context.Context, err error) GraphQLError {
+	switch e := err.(type) {
+	case *ResponseError:
+		return e.GraphQLError
+	case graphql.RequestError:
+		presented := GraphQLError{
+			Message:   e.Message,
+			Locations: e.Locations,
+		}
+		if e.Path.Len() != 0 {
+			path, _ := e.Path.MarshalJSON()
+			_ = json.Unmarshal(path, &presented.Path)
+		}
+		return presented
+	default:
+		return GraphQLError{
+			Message: err.Error(),
+		}
+	}
+}
+
+// writeErrors writes the response for an operation which couldn't be executed
+func (g *GraphQLHTTPRequestHandler) writeErrors(ctx context.Context, buf *bytes.Buffer, err error) {
+	if g.errorPresenter == nil {
+		_, _ = graphql.RequestErrorsFromError(err).WriteResponse(buf)
+		return
+	}
+
+	var errs []error
+	switch err.(type) {
+	case graphql.RequestErrors, operationreport.Report:
+		for _, requestError := range graphql.RequestErrorsFromError(err) {
+			errs = append(errs, requestError)
+		}
+	default:
+		errs = append(errs, err)
+	}
+
+	presented := make([]GraphQLError, len(errs))
+	for i := range errs {
+		presented[i] = g.errorPresenter(ctx, errs[i])
+	}
+
+	response, _ := json.Marshal(struct {
+		Errors []GraphQLError `json:"errors"`
+	}{
+		Errors: presented,
+	})
+	buf.Write(response)
+}
+
+// errorResponse returns the response for an operation which couldn't be executed, see writeErrors
+func (g *GraphQLHTTPRequestHandler) errorResponse(ctx context.Context, err error) []byte {
+	buf := &bytes.Buffer{}
+	g.writeErrors(ctx, buf, err)
+	return buf.Bytes()
+}
+
+// presentResponseErrors returns a post processor which runs the presenter over every error of a resolved response
+func presentResponseErrors(presenter ErrorPresenter) postprocess.ResponsePostProcessor {
+	return postprocess.ResponsePostProcessorFunc(func(ctx context.Context, response *postprocess.Response) error {
+		if response.Errors == nil {
+			return nil
+		}
+
+		// numbers of the extensions are decoded as json.Number to write them unchanged
+		decoder := json.NewDecoder(bytes.NewReader(response.Errors))
+		decoder.UseNumber()
+		var errs []GraphQLError
+		if err := decoder.Decode(&errs); err != nil {
+			return err
+		}
+
+		for i := range errs {
+			errs[i] = presenter(ctx, &ResponseError{GraphQLError: errs[i]})
+		}
+
+		presented, err := json.Marshal(errs)
+		if err != nil {
+			return err
+		}
+		response.Errors = presented
+		return nil
+	})
+}
@@ -0,0 +1,42 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	log "github.com/jensneuse/abstractlogger"
+
+	"github.com/wundergraph/graphql-go-tools/pkg/graphql"
+)
+
+const (
+	explainQueryParam string = "explain"
+	httpHeaderExplain string = "X-Graphql-Explain"
+)
+
+// isExplainRequest reports whether the plan of the operation should be returned instead of executing it.
+// Explain mode is enabled by the "explain" query parameter or the X-Graphql-Explain header.
+func isExplainRequest(r *http.Request) bool {
+	if _, ok := r.URL.Query()[explainQueryParam]; ok {
+		return true
+	}
+	return r.Header.Get(httpHeaderExplain) != ""
+}
+
+// explain plans the operation and returns the planned fetches as JSON without executing them,
+// planning errors are returned as the errors of the response.
+// See plan.ExplainedPlan for the shape of the response.
+func (g *GraphQLHTTPRequestHandler) explain(ctx context.Context, gqlRequest *graphql.Request) ([]byte, error) {
+	explained, err := g.engine.Explain(ctx, gqlRequest)
+	if err != nil {
+		g.log.Error("engine.Explain", log.Error(err))
+		return g.errorResponse(ctx, err), nil
+	}
+
+	for i := range explained.Fetches {
+		explained.Fetches[i].Service = g.serviceNames[explained.Fetches[i].URL]
+	}
+
+	return json.Marshal(explained)
+}
@@ -0,0 +1,74 @@
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+
+	log "github.com/jensneuse/abstractlogger"
+
+	"github.com/wundergraph/graphql-go-tools/pkg/graphql"
+)
+
+const (
+	queryParamQuery         string = "query"
+	queryParamVariables     string = "variables"
+	queryParamOperationName string = "operationName"
+)
+
+var (
+	errMissingQueryParam     = errors.New("the query parameter \"query\" is missing")
+	errInvalidVariablesParam = errors.New("the query parameter \"variables\" must be valid JSON")
+)
+
+// handleGetHTTP executes a query sent as URL query parameters, which allows caching of the response, e.g. by a CDN.
+// The variables are expected as URL encoded JSON. Only queries are allowed,
+// mutations and subscriptions are rejected with 405 Method Not Allowed to protect against CSRF.
+func (g *GraphQLHTTPRequestHandler) handleGetHTTP(w http.ResponseWriter, r *http.Request) {
+	var gqlRequest graphql.Request
+	if err := unmarshalQueryParams(r.URL.Query(), &gqlRequest); err != nil {
+		g.log.Error("unmarshal query params", log.Error(err))
+		g.writeRequestError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	gqlRequest.SetHeader(r.Header)
+
+	// operations which can't be parsed are passed through to get the errors of the normal pipeline
+	if operationType, err := gqlRequest.OperationType(); err == nil && operationType != graphql.OperationTypeQuery && operationType != graphql.OperationTypeUnknown {
+		w.Header().Set("Allow", http.MethodPost)
+		g.writeRequestError(w, http.StatusMethodNotAllowed, "only queries are allowed over GET, use POST for mutations and subscriptions")
+		return
+	}
+
+	g.executeHTTP(w, r, &gqlRequest)
+}
+
+func unmarshalQueryParams(values url.Values, request *graphql.Request) error {
+	request.Query = values.Get(queryParamQuery)
+	if request.Query == "" {
+		return errMissingQueryParam
+	}
+	request.OperationName = values.Get(queryParamOperationName)
+
+	if variables := values.Get(queryParamVariables); variables != "" {
+		if !json.Valid([]byte(variables)) {
+			return errInvalidVariablesParam
+		}
+		request.Variables = json.RawMessage(variables)
+	}
+
+	return nil
+}
+
+func (g *GraphQLHTTPRequestHandler) writeRequestError(w http.ResponseWriter, statusCode int, message string) {
+	buf := &bytes.Buffer{}
+	_, _ = graphql.RequestErrors{{Message: message}}.WriteResponse(buf)
+
+	w.Header().Set(httpHeaderContentType, httpContentTypeApplicationJson)
+	w.WriteHeader(statusCode)
+	if _, err := w.Write(buf.Bytes()); err != nil {
+		g.log.Error("write response", log.Error(err))
+	}
+}
@@ -1,42 +1,107 @@
 package http
 
 import (
+	"context"
 	"net/http"
+	"time"
 
 	"github.com/gobwas/ws"
 	log "github.com/jensneuse/abstractlogger"
 
 	"github.com/wundergraph/graphql-go-tools/pkg/graphql"
+	"github.com/wundergraph/graphql-go-tools/pkg/postprocess"
+	"github.com/wundergraph/graphql-go-tools/pkg/subscription"
 )
 
 const (
 	httpHeaderUpgrade string = "Upgrade"
 )
 
-func NewGraphqlHTTPHandler(
-	schema *graphql.Schema,
-	engine *graphql.ExecutionEngineV2,
-	upgrader *ws.HTTPUpgrader,
-	logger log.Logger,
-) http.Handler {
-	return &GraphQLHTTPRequestHandler{
-		schema:     schema,
-		engine:     engine,
-		wsUpgrader: upgrader,
-		log:        logger,
+// NewGraphqlHTTPHandler serves the schema with the engine over http and websockets, configured by the options.
+// Without options every operation is executed without any of the middlewares of the handler.
+func NewGraphqlHTTPHandler(schema *graphql.Schema, engine *graphql.ExecutionEngineV2, logger log.Logger, options ...Option) http.Handler {
+	if logger == nil {
+		logger = log.NoopLogger
 	}
+	handler := &GraphQLHTTPRequestHandler{
+		schema:           schema,
+		engine:           engine,
+		wsUpgrader:       &ws.HTTPUpgrader{},
+		maxBatchSize:     DefaultMaxBatchSize,
+		batchConcurrency: DefaultBatchConcurrency,
+		log:              logger,
+	}
+	for _, option := range options {
+		option(handler)
+	}
+	if handler.allowlist != nil {
+		// operations which aren't allowed are rejected before any other middleware runs
+		handler.subscriptionMiddlewares = append([]subscription.Middleware{handler.allowlist.subscriptionMiddleware}, handler.subscriptionMiddlewares...)
+	}
+	if handler.errorPresenter != nil {
+		handler.errorPipeline = postprocess.NewResponsePipeline().Register(0, presentResponseErrors(handler.errorPresenter))
+	}
+	return handler
 }
 
 type GraphQLHTTPRequestHandler struct {
-	log        log.Logger
-	wsUpgrader *ws.HTTPUpgrader
-	engine     *graphql.ExecutionEngineV2
-	schema     *graphql.Schema
+	log          log.Logger
+	wsUpgrader   *ws.HTTPUpgrader
+	engine       *graphql.ExecutionEngineV2
+	schema       *graphql.Schema
+	operations   *OperationTracker
+	serviceNames map[string]string
+	// errorPresenter is nil if errors are written unchanged
+	errorPresenter ErrorPresenter
+	errorPipeline  *postprocess.ResponsePipeline
+	// metrics is nil if no metrics are recorded
+	metrics Metrics
+	// coalescer is nil if identical queries are executed independently
+	coalescer *OperationCoalescer
+	// subscriptionMiddlewares wrap the start of every operation sent over websockets
+	subscriptionMiddlewares []subscription.Middleware
+	// subgraphExtensions merges the extensions of the responses of subgraphs into the response
+	subgraphExtensions bool
+	// allowlist is nil if every operation is executed
+	allowlist *OperationAllowlist
+	// defaultVariables is nil if only the variables sent by clients are used
+	defaultVariables *DefaultVariables
+	// responseTransformer is nil if responses are written unchanged
+	responseTransformer ResponseTransformer
+	// statusCodePolicy is nil if every resolved response is written with 200 OK
+	statusCodePolicy StatusCodePolicy
+	// maxRequestBodySize is the maximum size of the body or the query parameters of a request in bytes, 0 means no limit
+	maxRequestBodySize int64
+	// requestTimeout bounds the time of resolving an operation, 0 means no timeout
+	requestTimeout time.Duration
+	// compression is nil if responses are written uncompressed
+	compression *ResponseCompression
+	// responseCache is nil if every query is executed
+	responseCache *ResponseCache
+	// maxBatchSize and batchConcurrency bound the operations of batched requests, see handleBatchHTTP
+	maxBatchSize     int
+	batchConcurrency int
+}
+
+type requestHeaderContextKey struct{}
+
+// RequestHeaderFromContext returns the header of the client request an operation is executed for,
+// e.g. to route the fetches of the operation by a header of the client
+func RequestHeaderFromContext(ctx context.Context) (http.Header, bool) {
+	header, ok := ctx.Value(requestHeaderContextKey{}).(http.Header)
+	return header, ok
 }
 
 func (g *GraphQLHTTPRequestHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !g.operations.start() {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+	r = r.WithContext(context.WithValue(r.Context(), requestHeaderContextKey{}, r.Header))
+
 	isUpgrade := g.isWebsocketUpgrade(r)
 	if isUpgrade {
+		// the operation is finished by the websocket goroutine
 		err := g.upgradeWithNewGoroutine(w, r)
 		if err != nil {
 			g.log.Error("GraphQLHTTPRequestHandler.ServeHTTP",
@@ -46,12 +111,20 @@ func (g *GraphQLHTTPRequestHandler) ServeHTTP(w http.ResponseWriter, r *http.Req
 		}
 		return
 	}
+
+	defer g.operations.finish()
+	if g.compression != nil {
+		var done func()
+		w, done = g.compression.compressResponse(w, r)
+		defer done()
+	}
 	g.handleHTTP(w, r)
 }
 
 func (g *GraphQLHTTPRequestHandler) upgradeWithNewGoroutine(w http.ResponseWriter, r *http.Request) error {
 	conn, _, _, err := g.wsUpgrader.Upgrade(r, w)
 	if err != nil {
+		g.operations.finish()
 		return err
 	}
 	g.handleWebsocket(r.Context(), conn)
@@ -3,6 +3,10 @@ package http
 
 import (
 	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"mime"
 	"net/http"
 
 	log "github.com/jensneuse/abstractlogger"
@@ -12,30 +16,220 @@ import (
 
 const (
 	httpHeaderContentType          string = "Content-Type"
+	httpHeaderCacheControl         string = "Cache-Control"
 	httpContentTypeApplicationJson string = "application/json"
+	// httpContentTypeApplicationGraphQL is the content type of requests with the query as body, without a JSON envelope
+	httpContentTypeApplicationGraphQL string = "application/graphql"
 )
 
+var errJSONEnvelopeWithGraphQLContentType = errors.New("the body of a request with the content type \"application/graphql\" must be the query, not a JSON envelope")
+
 func (g *GraphQLHTTPRequestHandler) handleHTTP(w http.ResponseWriter, r *http.Request) {
-	var err error
+	if r.Method == http.MethodGet {
+		if g.queryParamsTooLarge(r) {
+			g.writeRequestTooLarge(w)
+			return
+		}
+		g.handleGetHTTP(w, r)
+		return
+	}
+
+	body, err := g.readBody(r)
+	if errors.Is(err, errRequestTooLarge) {
+		g.writeRequestTooLarge(w)
+		return
+	}
+	if err != nil {
+		g.log.Error("read request body", log.Error(err))
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	if isRawQueryRequest(r) {
+		var gqlRequest graphql.Request
+		if err = unmarshalRawQuery(body, &gqlRequest); err != nil {
+			g.log.Error("unmarshal raw query", log.Error(err))
+			g.writeRequestError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		gqlRequest.SetHeader(r.Header)
+		g.executeHTTP(w, r, &gqlRequest)
+		return
+	}
+
+	if isBatchRequest(body) {
+		g.handleBatchHTTP(w, r, body)
+		return
+	}
 
 	var gqlRequest graphql.Request
-	if err = graphql.UnmarshalHttpRequest(r, &gqlRequest); err != nil {
+	if err = graphql.UnmarshalRequest(bytes.NewReader(body), &gqlRequest); err != nil {
 		g.log.Error("UnmarshalHttpRequest", log.Error(err))
 		w.WriteHeader(http.StatusBadRequest)
 		return
 	}
+	gqlRequest.SetHeader(r.Header)
 
-	buf := bytes.NewBuffer(make([]byte, 0, 4096))
-	resultWriter := graphql.NewEngineResultWriterFromBuffer(buf)
-	if err = g.engine.Execute(r.Context(), &gqlRequest, &resultWriter); err != nil {
-		g.log.Error("engine.Execute", log.Error(err))
-		w.WriteHeader(http.StatusInternalServerError)
+	g.executeHTTP(w, r, &gqlRequest)
+}
+
+// isRawQueryRequest returns true for requests sending the query as body with the content type application/graphql
+func isRawQueryRequest(r *http.Request) bool {
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get(httpHeaderContentType))
+	return err == nil && mediaType == httpContentTypeApplicationGraphQL
+}
+
+// unmarshalRawQuery uses the whole body as query of the request, the request has no variables.
+// JSON objects and arrays are rejected as they're meant to be sent as application/json.
+func unmarshalRawQuery(body []byte, request *graphql.Request) error {
+	trimmed := bytes.TrimSpace(body)
+	if len(trimmed) > 0 && (trimmed[0] == '{' || trimmed[0] == '[') && json.Valid(trimmed) {
+		return errJSONEnvelopeWithGraphQLContentType
+	}
+	request.Query = string(body)
+	return nil
+}
+
+// executeHTTP runs the operation of a single (non batched) request
+func (g *GraphQLHTTPRequestHandler) executeHTTP(w http.ResponseWriter, r *http.Request, gqlRequest *graphql.Request) {
+	result := g.executeRequest(w, r, gqlRequest)
+	if result.streamed {
 		return
 	}
 
+	w.Header().Set(httpHeaderCacheControl, result.cacheControl.HeaderValue())
+	if result.statusCode != 0 {
+		w.WriteHeader(result.statusCode)
+		return
+	}
+	g.writeResponse(w, g.statusCodePolicy.StatusCode(result.errorClass), result.response)
+}
+
+// operationResult is the outcome of a single operation of a request
+type operationResult struct {
+	response     []byte
+	cacheControl graphql.CacheControl
+	// statusCode is set if the operation failed without a response to write
+	statusCode int
+	// errorClass chooses the status code of the response, see StatusCodePolicy
+	errorClass ErrorClass
+	// streamed is set if the response was already written as incremental response
+	streamed bool
+}
+
+// executeRequest runs a single operation, either the operation of a request or one of the operations of a batched request,
+// so that the allowlist, default variables, explain mode, cache control, response caching and coalescing apply to both alike.
+// Incremental responses are streamed to w, which is nil for batched operations
+// as a multipart response can't be part of the JSON array of a batched response.
+func (g *GraphQLHTTPRequestHandler) executeRequest(w http.ResponseWriter, r *http.Request, gqlRequest *graphql.Request) operationResult {
+	ctx := r.Context()
+
+	if !g.allows(gqlRequest) {
+		g.recordOperation(gqlRequest, nil, ErrPersistedQueryNotInAllowlist)
+		return operationResult{
+			response:   g.transformResponse(ctx, gqlRequest, g.errorResponse(ctx, ErrPersistedQueryNotInAllowlist)),
+			errorClass: ErrorClassAuthorization,
+		}
+	}
+	g.setDefaultVariables(gqlRequest)
+
+	if isExplainRequest(r) {
+		response, err := g.explain(ctx, gqlRequest)
+		if err != nil {
+			g.log.Error("marshal explained plan", log.Error(err))
+			return operationResult{statusCode: http.StatusInternalServerError}
+		}
+		return operationResult{response: response}
+	}
+
+	if incremental, _ := gqlRequest.HasIncrementalDelivery(); incremental {
+		if w == nil {
+			g.recordOperation(gqlRequest, nil, ErrIncrementalDeliveryInBatch)
+			return operationResult{
+				response:   g.transformResponse(ctx, gqlRequest, g.errorResponse(ctx, ErrIncrementalDeliveryInBatch)),
+				errorClass: ErrorClassValidation,
+			}
+		}
+		g.handleIncrementalHTTP(w, r, gqlRequest)
+		return operationResult{streamed: true}
+	}
+
+	// invalid operations are uncacheable, their errors are reported by the execution
+	cacheControl, _ := g.cacheControl(gqlRequest)
+
+	var (
+		cacheKey  uint64
+		cacheable bool
+	)
+	if g.responseCache != nil {
+		cacheKey, cacheable = g.responseCache.key(ctx, r.Header, g.schema, gqlRequest, cacheControl)
+	}
+	if cacheable {
+		if response, ok := g.responseCache.get(cacheKey); ok {
+			g.recordOperation(gqlRequest, response, nil)
+			return operationResult{
+				response:     g.transformResponse(ctx, gqlRequest, response),
+				cacheControl: cacheControl,
+			}
+		}
+	}
+
+	response, err := g.execute(ctx, r.Header, gqlRequest)
+	g.recordOperation(gqlRequest, response, err)
+	var errorClass ErrorClass
+	if err != nil {
+		g.log.Error("engine.Execute", log.Error(err))
+		cacheControl = graphql.CacheControl{}
+		errorClass = classifyError(gqlRequest, err)
+		if g.errorPresenter == nil && w != nil && !g.statusCodePolicy.maps(errorClass) {
+			return operationResult{statusCode: http.StatusInternalServerError}
+		}
+		// the response might be shared with coalesced requests, so errors are written to a new buffer
+		response = g.errorResponse(ctx, err)
+	} else {
+		errorClass = classifyResponse(response)
+	}
+
+	// the resolver writes errors before the data, responses with errors must not be cached
+	if bytes.HasPrefix(response, []byte(`{"errors"`)) {
+		cacheControl = graphql.CacheControl{}
+	} else if cacheable && err == nil {
+		g.responseCache.set(cacheKey, response, cacheControl)
+	}
+
+	return operationResult{
+		response:     g.transformResponse(ctx, gqlRequest, response),
+		cacheControl: cacheControl,
+		errorClass:   errorClass,
+	}
+}
+
+// execute runs the operation and returns the response,
+// identical queries running at the same time are executed once if a coalescer is configured
+func (g *GraphQLHTTPRequestHandler) execute(ctx context.Context, header http.Header, gqlRequest *graphql.Request) ([]byte, error) {
+	if g.coalescer == nil {
+		return g.executeOperation(ctx, header, gqlRequest)
+	}
+	key, ok := g.coalescingKey(ctx, gqlRequest, header)
+	if !ok {
+		return g.executeOperation(ctx, header, gqlRequest)
+	}
+	return g.coalescer.do(ctx, key, func(ctx context.Context) ([]byte, error) {
+		return g.executeOperation(ctx, header, gqlRequest)
+	})
+}
+
+func (g *GraphQLHTTPRequestHandler) executeOperation(ctx context.Context, header http.Header, gqlRequest *graphql.Request) ([]byte, error) {
+	buf := bytes.NewBuffer(make([]byte, 0, 4096))
+	resultWriter := graphql.NewEngineResultWriterFromBuffer(buf)
+	err := g.engine.Execute(ctx, gqlRequest, &resultWriter, g.executionOptions(header)...)
+	return buf.Bytes(), err
+}
+
+func (g *GraphQLHTTPRequestHandler) writeResponse(w http.ResponseWriter, statusCode int, response []byte) {
 	w.Header().Add(httpHeaderContentType, httpContentTypeApplicationJson)
-	w.WriteHeader(http.StatusOK)
-	if _, err = w.Write(buf.Bytes()); err != nil {
+	w.WriteHeader(statusCode)
+	if _, err := w.Write(response); err != nil {
 		g.log.Error("write response", log.Error(err))
 		return
 	}
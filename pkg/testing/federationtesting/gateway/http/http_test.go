@@ -0,0 +1,874 @@
+package http
+
+import (
+	"compress/gzip"
+	"compress/zlib"
+	"context"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	log "github.com/jensneuse/abstractlogger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tidwall/sjson"
+
+	"github.com/wundergraph/graphql-go-tools/pkg/engine/datasource/graphql_datasource"
+	"github.com/wundergraph/graphql-go-tools/pkg/engine/datasource/staticdatasource"
+	"github.com/wundergraph/graphql-go-tools/pkg/engine/plan"
+	"github.com/wundergraph/graphql-go-tools/pkg/graphql"
+)
+
+func TestGraphQLHTTPRequestHandler_CacheControl(t *testing.T) {
+	schema, err := graphql.NewSchemaFromString(`
+		enum CacheControlScope { PUBLIC PRIVATE }
+		directive @cacheControl(maxAge: Int, scope: CacheControlScope) on FIELD_DEFINITION
+
+		schema { query: Query }
+		type Query {
+			topProducts: String @cacheControl(maxAge: 30)
+			latestReviews: String @cacheControl(maxAge: 10)
+			me: String @cacheControl(maxAge: 60, scope: PRIVATE)
+		}
+	`)
+	require.NoError(t, err)
+
+	engineConf := graphql.NewEngineV2Configuration(schema)
+	var fields plan.FieldConfigurations
+	for _, fieldName := range []string{"topProducts", "latestReviews", "me"} {
+		engineConf.AddDataSource(plan.DataSourceConfiguration{
+			RootNodes: []plan.TypeField{
+				{TypeName: "Query", FieldNames: []string{fieldName}},
+			},
+			Factory: &staticdatasource.Factory{},
+			Custom: staticdatasource.ConfigJSON(staticdatasource.Configuration{
+				Data: `"` + fieldName + `"`,
+			}),
+		})
+		fields = append(fields, plan.FieldConfiguration{TypeName: "Query", FieldName: fieldName, DisableDefaultMapping: true})
+	}
+	engineConf.SetFieldConfigurations(fields)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	engine, err := graphql.NewExecutionEngineV2(ctx, log.NoopLogger, engineConf)
+	require.NoError(t, err)
+
+	handler := NewGraphqlHTTPHandler(schema, engine, log.NoopLogger)
+
+	execute := func(t *testing.T, body string) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(body)))
+		require.Equal(t, http.StatusOK, recorder.Code)
+		return recorder
+	}
+
+	t.Run("max age is the minimum of the selected fields", func(t *testing.T) {
+		recorder := execute(t, `{"query":"{ topProducts latestReviews }"}`)
+		assert.Equal(t, `{"data":{"topProducts":"topProducts","latestReviews":"latestReviews"}}`, recorder.Body.String())
+		assert.Equal(t, "max-age=10", recorder.Header().Get("Cache-Control"))
+	})
+
+	t.Run("private scope", func(t *testing.T) {
+		recorder := execute(t, `{"query":"{ topProducts me }"}`)
+		assert.Equal(t, "private, max-age=30", recorder.Header().Get("Cache-Control"))
+	})
+
+	t.Run("invalid operation is uncacheable", func(t *testing.T) {
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(`{"query":"{ unknown }"}`)))
+		assert.Equal(t, "no-store", recorder.Header().Get("Cache-Control"))
+	})
+
+	t.Run("nil and zero options keep the defaults", func(t *testing.T) {
+		handler := NewGraphqlHTTPHandler(schema, engine, nil,
+			WithUpgrader(nil),
+			WithOperationTracker(nil),
+			WithSubscriptionMiddlewares(nil),
+			WithOperationAllowlist(nil),
+			WithErrorPresenter(nil),
+			WithMaxBatchSize(0),
+			WithBatchConcurrency(0),
+		)
+
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(`[{"query":"{ topProducts }"},{"query":"{ me }"}]`)))
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		assert.Equal(t, `[{"data":{"topProducts":"topProducts"}},{"data":{"me":"me"}}]`, recorder.Body.String())
+	})
+
+	t.Run("batched operations", func(t *testing.T) {
+		t.Run("policy covers all operations", func(t *testing.T) {
+			recorder := execute(t, `[{"query":"{ topProducts }"},{"query":"{ latestReviews me }"}]`)
+			assert.Equal(t, `[{"data":{"topProducts":"topProducts"}},{"data":{"latestReviews":"latestReviews","me":"me"}}]`, recorder.Body.String())
+			assert.Equal(t, "private, max-age=10", recorder.Header().Get("Cache-Control"))
+		})
+
+		t.Run("invalid operation makes the batch uncacheable", func(t *testing.T) {
+			recorder := execute(t, `[{"query":"{ topProducts }"},{"query":"{ unknown }"}]`)
+			assert.Equal(t, "no-store", recorder.Header().Get("Cache-Control"))
+		})
+
+		t.Run("incremental delivery is rejected", func(t *testing.T) {
+			recorder := execute(t, `[{"query":"{ topProducts }"},{"query":"{ ... @defer { me } }"}]`)
+			assert.Equal(t, `[{"data":{"topProducts":"topProducts"}},{"errors":[{"message":"@defer and @stream are not supported in batched requests"}]}]`, recorder.Body.String())
+			assert.Equal(t, "no-store", recorder.Header().Get("Cache-Control"))
+		})
+
+		t.Run("too many operations are rejected", func(t *testing.T) {
+			handler := NewGraphqlHTTPHandler(schema, engine, log.NoopLogger, WithMaxBatchSize(2))
+
+			recorder := httptest.NewRecorder()
+			handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(`[{"query":"{ topProducts }"},{"query":"{ me }"},{"query":"{ latestReviews }"}]`)))
+			assert.Equal(t, http.StatusBadRequest, recorder.Code)
+			assert.Equal(t, `{"errors":[{"message":"the batch contains 3 operations, at most 2 are allowed"}]}`, recorder.Body.String())
+		})
+	})
+}
+
+func TestGraphQLHTTPRequestHandler_ResponseCache(t *testing.T) {
+	schema, err := graphql.NewSchemaFromString(`
+		enum CacheControlScope { PUBLIC PRIVATE }
+		directive @cacheControl(maxAge: Int, scope: CacheControlScope) on FIELD_DEFINITION
+		directive @requiresScopes(scopes: [[String!]!]!) on FIELD_DEFINITION
+
+		schema { query: Query }
+		type Query {
+			topProducts: String @cacheControl(maxAge: 30)
+			me: String @cacheControl(maxAge: 30, scope: PRIVATE)
+			profile: String @cacheControl(maxAge: 30)
+			salary: String @cacheControl(maxAge: 30) @requiresScopes(scopes: [["read:salary"]])
+		}
+	`)
+	require.NoError(t, err)
+
+	var upstreamCalls int64
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		call := strconv.FormatInt(atomic.AddInt64(&upstreamCalls, 1), 10)
+		_, _ = w.Write([]byte(`{"data":{"topProducts":"products","me":"me ` + call + `","profile":"profile ` + call + `","salary":"salary ` + call + `"}}`))
+	}))
+	defer upstream.Close()
+
+	engineConf := graphql.NewEngineV2Configuration(schema)
+	engineConf.SetDataSources([]plan.DataSourceConfiguration{
+		{
+			RootNodes: []plan.TypeField{
+				{TypeName: "Query", FieldNames: []string{"topProducts", "me", "profile", "salary"}},
+			},
+			Factory: &graphql_datasource.Factory{HTTPClient: http.DefaultClient},
+			Custom: graphql_datasource.ConfigJson(graphql_datasource.Configuration{
+				Fetch: graphql_datasource.FetchConfiguration{URL: upstream.URL, Method: http.MethodPost},
+			}),
+		},
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	engine, err := graphql.NewExecutionEngineV2(ctx, log.NoopLogger, engineConf)
+	require.NoError(t, err)
+
+	identity := func(ctx context.Context) (string, bool) {
+		header, ok := RequestHeaderFromContext(ctx)
+		if !ok || header.Get("Authorization") == "" {
+			return "", false
+		}
+		return header.Get("Authorization"), true
+	}
+	cache := NewResponseCache(identity, graphql.TypeFields{TypeName: "Query", FieldNames: []string{"profile"}})
+	handler := NewGraphqlHTTPHandler(schema, engine, log.NoopLogger, WithResponseCache(cache))
+
+	execute := func(t *testing.T, authorization, body string) *httptest.ResponseRecorder {
+		t.Helper()
+		request := httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(body))
+		if authorization != "" {
+			request.Header.Set("Authorization", authorization)
+		}
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, request)
+		require.Equal(t, http.StatusOK, recorder.Code)
+		return recorder
+	}
+
+	t.Run("public response is shared by all callers", func(t *testing.T) {
+		atomic.StoreInt64(&upstreamCalls, 0)
+		alice := execute(t, "alice", `{"query":"{ topProducts }"}`)
+		bob := execute(t, "bob", `{"query":"{ topProducts }"}`)
+		anonymous := execute(t, "", `{"query":"{ topProducts }"}`)
+
+		assert.Equal(t, `{"data":{"topProducts":"products"}}`, alice.Body.String())
+		assert.Equal(t, alice.Body.String(), bob.Body.String())
+		assert.Equal(t, alice.Body.String(), anonymous.Body.String())
+		assert.Equal(t, "max-age=30", bob.Header().Get("Cache-Control"))
+		assert.Equal(t, int64(1), atomic.LoadInt64(&upstreamCalls))
+	})
+
+	t.Run("private response is cached per caller", func(t *testing.T) {
+		atomic.StoreInt64(&upstreamCalls, 0)
+		alice := execute(t, "alice", `{"query":"{ topProducts me }"}`)
+		aliceAgain := execute(t, "alice", `{"query":"{ topProducts me }"}`)
+		bob := execute(t, "bob", `{"query":"{ topProducts me }"}`)
+
+		assert.Equal(t, `{"data":{"topProducts":"products","me":"me 1"}}`, alice.Body.String())
+		assert.Equal(t, alice.Body.String(), aliceAgain.Body.String())
+		assert.Equal(t, `{"data":{"topProducts":"products","me":"me 2"}}`, bob.Body.String())
+		assert.Equal(t, "private, max-age=30", aliceAgain.Header().Get("Cache-Control"))
+		assert.Equal(t, int64(2), atomic.LoadInt64(&upstreamCalls))
+	})
+
+	t.Run("private response of anonymous callers isn't cached", func(t *testing.T) {
+		atomic.StoreInt64(&upstreamCalls, 0)
+		first := execute(t, "", `{"query":"{ me }"}`)
+		second := execute(t, "", `{"query":"{ me }"}`)
+
+		assert.Equal(t, `{"data":{"me":"me 1"}}`, first.Body.String())
+		assert.Equal(t, `{"data":{"me":"me 2"}}`, second.Body.String())
+		assert.Equal(t, int64(2), atomic.LoadInt64(&upstreamCalls))
+	})
+
+	t.Run("configured private field is cached per caller", func(t *testing.T) {
+		atomic.StoreInt64(&upstreamCalls, 0)
+		alice := execute(t, "alice", `{"query":"{ profile }"}`)
+		aliceAgain := execute(t, "alice", `{"query":"{ profile }"}`)
+		bob := execute(t, "bob", `{"query":"{ profile }"}`)
+
+		assert.Equal(t, `{"data":{"profile":"profile 1"}}`, alice.Body.String())
+		assert.Equal(t, alice.Body.String(), aliceAgain.Body.String())
+		assert.Equal(t, `{"data":{"profile":"profile 2"}}`, bob.Body.String())
+		assert.Equal(t, "private, max-age=30", bob.Header().Get("Cache-Control"))
+		assert.Equal(t, int64(2), atomic.LoadInt64(&upstreamCalls))
+	})
+
+	t.Run("response with fields requiring scopes is not shared with unscoped callers", func(t *testing.T) {
+		atomic.StoreInt64(&upstreamCalls, 0)
+		execute := func(authorization string, scopes ...string) *httptest.ResponseRecorder {
+			request := httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(`{"query":"{ salary }"}`))
+			if authorization != "" {
+				request.Header.Set("Authorization", authorization)
+			}
+			if scopes != nil {
+				request = request.WithContext(graphql.WithAuthorizationScopes(request.Context(), scopes...))
+			}
+			recorder := httptest.NewRecorder()
+			handler.ServeHTTP(recorder, request)
+			require.Equal(t, http.StatusOK, recorder.Code)
+			return recorder
+		}
+
+		alice := execute("alice", "read:salary")
+		assert.Equal(t, `{"data":{"salary":"salary 1"}}`, alice.Body.String())
+		assert.Equal(t, "private, max-age=30", alice.Header().Get("Cache-Control"))
+
+		bob := execute("bob")
+		assert.Contains(t, bob.Body.String(), "unauthorized to access field Query.salary")
+		assert.NotContains(t, bob.Body.String(), "salary 1")
+		anonymous := execute("")
+		assert.Contains(t, anonymous.Body.String(), "unauthorized to access field Query.salary")
+		assert.NotContains(t, anonymous.Body.String(), "salary 1")
+		assert.Equal(t, int64(1), atomic.LoadInt64(&upstreamCalls))
+	})
+
+	t.Run("response is cached per value of the vary headers", func(t *testing.T) {
+		atomic.StoreInt64(&upstreamCalls, 0)
+		cache := NewResponseCache(nil).VaryByHeaders("X-Canary")
+		handler := NewGraphqlHTTPHandler(schema, engine, log.NoopLogger, WithResponseCache(cache))
+		execute := func(canary string) {
+			request := httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(`{"query":"{ topProducts }"}`))
+			if canary != "" {
+				request.Header.Set("X-Canary", canary)
+			}
+			recorder := httptest.NewRecorder()
+			handler.ServeHTTP(recorder, request)
+			require.Equal(t, http.StatusOK, recorder.Code)
+		}
+
+		execute("")
+		execute("true")
+		execute("true")
+		execute("")
+		assert.Equal(t, int64(2), atomic.LoadInt64(&upstreamCalls))
+	})
+
+	t.Run("expired response is executed again", func(t *testing.T) {
+		atomic.StoreInt64(&upstreamCalls, 0)
+		now := time.Now()
+		cache.now = func() time.Time { return now }
+		defer func() { cache.now = time.Now }()
+
+		execute(t, "alice", `{"query":"{ me topProducts }"}`)
+		now = now.Add(31 * time.Second)
+		execute(t, "alice", `{"query":"{ me topProducts }"}`)
+		assert.Equal(t, int64(2), atomic.LoadInt64(&upstreamCalls))
+	})
+}
+
+func TestGraphQLHTTPRequestHandler_ApplicationGraphQL(t *testing.T) {
+	schema, err := graphql.NewSchemaFromString(`
+		schema { query: Query }
+		type Query {
+			topProducts: String
+		}
+	`)
+	require.NoError(t, err)
+
+	engineConf := graphql.NewEngineV2Configuration(schema)
+	engineConf.AddDataSource(plan.DataSourceConfiguration{
+		RootNodes: []plan.TypeField{
+			{TypeName: "Query", FieldNames: []string{"topProducts"}},
+		},
+		Factory: &staticdatasource.Factory{},
+		Custom: staticdatasource.ConfigJSON(staticdatasource.Configuration{
+			Data: `"Table"`,
+		}),
+	})
+	engineConf.SetFieldConfigurations(plan.FieldConfigurations{
+		{TypeName: "Query", FieldName: "topProducts", DisableDefaultMapping: true},
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	engine, err := graphql.NewExecutionEngineV2(ctx, log.NoopLogger, engineConf)
+	require.NoError(t, err)
+
+	handler := NewGraphqlHTTPHandler(schema, engine, log.NoopLogger)
+
+	execute := func(body string) *httptest.ResponseRecorder {
+		request := httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(body))
+		request.Header.Set("Content-Type", "application/graphql; charset=utf-8")
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, request)
+		return recorder
+	}
+
+	t.Run("body is executed as query", func(t *testing.T) {
+		recorder := execute(`query Products { topProducts }`)
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		assert.Equal(t, `{"data":{"topProducts":"Table"}}`, recorder.Body.String())
+	})
+
+	t.Run("shorthand query is not mistaken for a JSON envelope", func(t *testing.T) {
+		recorder := execute(`{ topProducts }`)
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		assert.Equal(t, `{"data":{"topProducts":"Table"}}`, recorder.Body.String())
+	})
+
+	t.Run("JSON envelope is rejected", func(t *testing.T) {
+		recorder := execute(`{"query":"{ topProducts }"}`)
+		assert.Equal(t, http.StatusBadRequest, recorder.Code)
+		assert.Equal(t, `{"errors":[{"message":"the body of a request with the content type \"application/graphql\" must be the query, not a JSON envelope"}]}`, recorder.Body.String())
+	})
+}
+
+// countingReader counts the bytes read from the body of a request
+type countingReader struct {
+	reader io.Reader
+	read   int
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.reader.Read(p)
+	c.read += n
+	return n, err
+}
+
+func TestGraphQLHTTPRequestHandler_MaxRequestBodySize(t *testing.T) {
+	schema, err := graphql.NewSchemaFromString(`
+		schema { query: Query }
+		type Query {
+			topProducts: String
+		}
+	`)
+	require.NoError(t, err)
+
+	engineConf := graphql.NewEngineV2Configuration(schema)
+	engineConf.AddDataSource(plan.DataSourceConfiguration{
+		RootNodes: []plan.TypeField{
+			{TypeName: "Query", FieldNames: []string{"topProducts"}},
+		},
+		Factory: &staticdatasource.Factory{},
+		Custom: staticdatasource.ConfigJSON(staticdatasource.Configuration{
+			Data: `"Table"`,
+		}),
+	})
+	engineConf.SetFieldConfigurations(plan.FieldConfigurations{
+		{TypeName: "Query", FieldName: "topProducts", DisableDefaultMapping: true},
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	engine, err := graphql.NewExecutionEngineV2(ctx, log.NoopLogger, engineConf)
+	require.NoError(t, err)
+
+	const maxRequestBodySize = 64
+	handler := NewGraphqlHTTPHandler(schema, engine, log.NoopLogger, WithMaxRequestBodySize(maxRequestBodySize))
+
+	t.Run("operation within the limit is executed", func(t *testing.T) {
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(`{"query":"{ topProducts }"}`)))
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		assert.Equal(t, `{"data":{"topProducts":"Table"}}`, recorder.Body.String())
+	})
+
+	t.Run("oversized body is rejected before it is read", func(t *testing.T) {
+		// the body is malformed, parsing it would fail with 400 Bad Request
+		body := &countingReader{reader: strings.NewReader(`{"query":"{ topProducts }` + strings.Repeat(" ", 1<<20))}
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/graphql", body))
+		assert.Equal(t, http.StatusRequestEntityTooLarge, recorder.Code)
+		assert.Equal(t, `{"errors":[{"message":"the operation exceeds the maximum request size of 64 bytes"}]}`, recorder.Body.String())
+		assert.LessOrEqual(t, body.read, maxRequestBodySize+1)
+	})
+
+	t.Run("oversized query params are rejected", func(t *testing.T) {
+		query := url.Values{"query": []string{"{ topProducts " + strings.Repeat(" ", maxRequestBodySize) + "}"}}
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/graphql?"+query.Encode(), nil))
+		assert.Equal(t, http.StatusRequestEntityTooLarge, recorder.Code)
+		assert.Equal(t, `{"errors":[{"message":"the operation exceeds the maximum request size of 64 bytes"}]}`, recorder.Body.String())
+	})
+}
+
+func TestGraphQLHTTPRequestHandler_RequestTimeout(t *testing.T) {
+	reviews := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"data":{"topReviews":[{"body":"A","author":{"__typename":"User","id":"1"}}]}}`))
+	}))
+	defer reviews.Close()
+
+	accountsCancelled := make(chan struct{}, 1)
+	accounts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-time.After(time.Second):
+			_, _ = w.Write([]byte(`{"data":{"_entities":[{"__typename":"User","username":"Me"}]}}`))
+		case <-r.Context().Done():
+			accountsCancelled <- struct{}{}
+		}
+	}))
+	defer accounts.Close()
+
+	factory := graphql.NewFederationEngineConfigFactory([]graphql_datasource.Configuration{
+		{
+			Fetch: graphql_datasource.FetchConfiguration{URL: reviews.URL},
+			Federation: graphql_datasource.FederationConfiguration{
+				Enabled:    true,
+				ServiceSDL: `extend type Query { topReviews: [Review] } type Review { body: String! author: User } extend type User @key(fields: "id") { id: ID! @external }`,
+			},
+		},
+		{
+			Fetch: graphql_datasource.FetchConfiguration{URL: accounts.URL},
+			Federation: graphql_datasource.FederationConfiguration{
+				Enabled:    true,
+				ServiceSDL: `extend type Query { me: User } type User @key(fields: "id") { id: ID! username: String! }`,
+			},
+		},
+	}, graphql_datasource.NewBatchFactory())
+	engineConf, err := factory.EngineV2Configuration()
+	require.NoError(t, err)
+	schema, err := factory.MergedSchema()
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	engine, err := graphql.NewExecutionEngineV2(ctx, log.NoopLogger, engineConf)
+	require.NoError(t, err)
+
+	handler := NewGraphqlHTTPHandler(schema, engine, log.NoopLogger, WithRequestTimeout(100*time.Millisecond))
+
+	execute := func(t *testing.T, body string) *httptest.ResponseRecorder {
+		start := time.Now()
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(body)))
+
+		assert.Less(t, int64(time.Since(start)), int64(time.Second))
+		select {
+		case <-accountsCancelled:
+		case <-time.After(time.Second):
+			t.Fatal("fetch of the accounts subgraph wasn't cancelled")
+		}
+		return recorder
+	}
+
+	t.Run("operation", func(t *testing.T) {
+		recorder := execute(t, `{"query":"{ topReviews { body author { username } } }"}`)
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		assert.Contains(t, recorder.Body.String(), `{"message":"operation timed out after 100ms"}`)
+		assert.Contains(t, recorder.Body.String(), `"body":"A"`)
+	})
+
+	t.Run("deferred operation", func(t *testing.T) {
+		recorder := execute(t, `{"query":"{ topReviews { body ... @defer { author { username } } } }"}`)
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		assert.Equal(t, httpContentTypeMultipartMixed, recorder.Header().Get("Content-Type"))
+		assert.Contains(t, recorder.Body.String(), `{"data":{"topReviews":[{"body":"A"}]},"hasNext":true}`)
+		assert.True(t, strings.HasSuffix(recorder.Body.String(), multipartEnd))
+	})
+}
+
+func TestGraphQLHTTPRequestHandler_ResponseCompression(t *testing.T) {
+	schema, err := graphql.NewSchemaFromString(`
+		schema { query: Query }
+		type Query {
+			large: String
+			small: String
+		}
+	`)
+	require.NoError(t, err)
+
+	largeValue := strings.Repeat("product ", 512)
+	engineConf := graphql.NewEngineV2Configuration(schema)
+	var fields plan.FieldConfigurations
+	for fieldName, value := range map[string]string{"large": largeValue, "small": "small"} {
+		engineConf.AddDataSource(plan.DataSourceConfiguration{
+			RootNodes: []plan.TypeField{
+				{TypeName: "Query", FieldNames: []string{fieldName}},
+			},
+			Factory: &staticdatasource.Factory{},
+			Custom: staticdatasource.ConfigJSON(staticdatasource.Configuration{
+				Data: `"` + value + `"`,
+			}),
+		})
+		fields = append(fields, plan.FieldConfiguration{TypeName: "Query", FieldName: fieldName, DisableDefaultMapping: true})
+	}
+	engineConf.SetFieldConfigurations(fields)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	engine, err := graphql.NewExecutionEngineV2(ctx, log.NoopLogger, engineConf)
+	require.NoError(t, err)
+
+	handler := NewGraphqlHTTPHandler(schema, engine, log.NoopLogger, WithResponseCompression(&ResponseCompression{MinSize: 1024}))
+
+	execute := func(t *testing.T, query, acceptEncoding string) *httptest.ResponseRecorder {
+		request := httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(`{"query":"`+query+`"}`))
+		request.Header.Set("Accept-Encoding", acceptEncoding)
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, request)
+		require.Equal(t, http.StatusOK, recorder.Code)
+		return recorder
+	}
+
+	t.Run("large response is compressed with gzip", func(t *testing.T) {
+		recorder := execute(t, "{ large }", "br;q=1.0, gzip;q=0.8, deflate;q=0.5")
+		assert.Equal(t, "gzip", recorder.Header().Get("Content-Encoding"))
+		assert.Equal(t, "Accept-Encoding", recorder.Header().Get("Vary"))
+
+		reader, err := gzip.NewReader(recorder.Body)
+		require.NoError(t, err)
+		body, err := ioutil.ReadAll(reader)
+		require.NoError(t, err)
+		assert.Equal(t, `{"data":{"large":"`+largeValue+`"}}`, string(body))
+	})
+
+	t.Run("large response is compressed with deflate", func(t *testing.T) {
+		recorder := execute(t, "{ large }", "gzip;q=0.5, deflate")
+		assert.Equal(t, "deflate", recorder.Header().Get("Content-Encoding"))
+
+		reader, err := zlib.NewReader(recorder.Body)
+		require.NoError(t, err)
+		body, err := ioutil.ReadAll(reader)
+		require.NoError(t, err)
+		assert.Equal(t, `{"data":{"large":"`+largeValue+`"}}`, string(body))
+	})
+
+	t.Run("small response is written uncompressed", func(t *testing.T) {
+		recorder := execute(t, "{ small }", "gzip")
+		assert.Equal(t, "", recorder.Header().Get("Content-Encoding"))
+		assert.Equal(t, `{"data":{"small":"small"}}`, recorder.Body.String())
+	})
+
+	t.Run("response is written uncompressed for clients only accepting br", func(t *testing.T) {
+		recorder := execute(t, "{ large }", "br, gzip;q=0")
+		assert.Equal(t, "", recorder.Header().Get("Content-Encoding"))
+		assert.Equal(t, `{"data":{"large":"`+largeValue+`"}}`, recorder.Body.String())
+	})
+}
+
+func TestNegotiateEncoding(t *testing.T) {
+	assert.Equal(t, "gzip", negotiateEncoding("gzip, deflate"))
+	assert.Equal(t, "gzip", negotiateEncoding("deflate, GZIP"))
+	assert.Equal(t, "deflate", negotiateEncoding("gzip;q=0.5, deflate"))
+	assert.Equal(t, "", negotiateEncoding("br, identity"))
+	assert.Equal(t, "", negotiateEncoding(""))
+}
+
+func TestMergeCacheControl(t *testing.T) {
+	public := graphql.CacheControl{MaxAge: 30, Scope: graphql.CacheControlScopePublic}
+	private := graphql.CacheControl{MaxAge: 60, Scope: graphql.CacheControlScopePrivate}
+
+	assert.Equal(t, graphql.CacheControl{MaxAge: 30, Scope: graphql.CacheControlScopePrivate}, mergeCacheControl(public, private))
+	assert.Equal(t, graphql.CacheControl{MaxAge: 30, Scope: graphql.CacheControlScopePrivate}, mergeCacheControl(private, public))
+	assert.False(t, mergeCacheControl(public, graphql.CacheControl{}).Cacheable())
+}
+
+func TestGraphQLHTTPRequestHandler_OperationAllowlist(t *testing.T) {
+	schema, err := graphql.NewSchemaFromString(`
+		schema { query: Query }
+		type Query {
+			hello: String
+		}
+	`)
+	require.NoError(t, err)
+
+	engineConf := graphql.NewEngineV2Configuration(schema)
+	engineConf.AddDataSource(plan.DataSourceConfiguration{
+		RootNodes: []plan.TypeField{
+			{TypeName: "Query", FieldNames: []string{"hello"}},
+		},
+		Factory: &staticdatasource.Factory{},
+		Custom: staticdatasource.ConfigJSON(staticdatasource.Configuration{
+			Data: `"world"`,
+		}),
+	})
+	engineConf.SetFieldConfigurations(plan.FieldConfigurations{
+		{TypeName: "Query", FieldName: "hello", DisableDefaultMapping: true},
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	engine, err := graphql.NewExecutionEngineV2(ctx, log.NoopLogger, engineConf)
+	require.NoError(t, err)
+
+	allowed, err := OperationAllowlistKey(graphql.XXHashOperationHasher, `query Hello { hello }`)
+	require.NoError(t, err)
+
+	allowlist := NewOperationAllowlist(graphql.XXHashOperationHasher, allowed)
+	handler := NewGraphqlHTTPHandler(schema, engine, log.NoopLogger, WithOperationAllowlist(allowlist))
+
+	execute := func(t *testing.T, body string) string {
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(body)))
+		require.Equal(t, http.StatusOK, recorder.Code)
+		return recorder.Body.String()
+	}
+
+	t.Run("allowlisted operation is executed", func(t *testing.T) {
+		// the hash doesn't depend on the formatting of the operation
+		assert.Equal(t, `{"data":{"hello":"world"}}`, execute(t, `{"query":"query Hello {\n  hello\n}"}`))
+	})
+
+	t.Run("operation not in allowlist is rejected", func(t *testing.T) {
+		assert.Equal(t, `{"errors":[{"message":"PersistedQueryNotInAllowlist"}]}`, execute(t, `{"query":"{ hello }"}`))
+	})
+
+	t.Run("batched operations are checked one by one", func(t *testing.T) {
+		assert.Equal(t, `[{"data":{"hello":"world"}},{"errors":[{"message":"PersistedQueryNotInAllowlist"}]}]`,
+			execute(t, `[{"query":"query Hello { hello }"},{"query":"{ hello }"}]`))
+	})
+}
+
+func TestGraphQLHTTPRequestHandler_DefaultVariables(t *testing.T) {
+	requests := make(chan string, 1)
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := ioutil.ReadAll(r.Body)
+		require.NoError(t, err)
+		requests <- string(body)
+		_, _ = w.Write([]byte(`{"data":{"topProducts":["Table"]}}`))
+	}))
+	defer upstream.Close()
+
+	factory := graphql.NewFederationEngineConfigFactory([]graphql_datasource.Configuration{
+		{
+			Fetch: graphql_datasource.FetchConfiguration{
+				URL: upstream.URL,
+			},
+			Federation: graphql_datasource.FederationConfiguration{
+				Enabled:    true,
+				ServiceSDL: `extend type Query { topProducts(locale: String): [String] }`,
+			},
+		},
+	}, graphql_datasource.NewBatchFactory())
+	engineConf, err := factory.EngineV2Configuration()
+	require.NoError(t, err)
+	schema, err := factory.MergedSchema()
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	engine, err := graphql.NewExecutionEngineV2(ctx, log.NoopLogger, engineConf)
+	require.NoError(t, err)
+
+	defaultVariables := &DefaultVariables{
+		Global: map[string]json.RawMessage{"locale": json.RawMessage(`"en-US"`)},
+		Operations: map[string]map[string]json.RawMessage{
+			"GermanProducts": {"locale": json.RawMessage(`"de-DE"`)},
+		},
+	}
+	handler := NewGraphqlHTTPHandler(schema, engine, log.NoopLogger, WithDefaultVariables(defaultVariables))
+
+	execute := func(t *testing.T, body string) string {
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(body)))
+		require.Equal(t, http.StatusOK, recorder.Code)
+		assert.Equal(t, `{"data":{"topProducts":["Table"]}}`, recorder.Body.String())
+		return <-requests
+	}
+
+	t.Run("omitted variable is set to the global default", func(t *testing.T) {
+		upstreamRequest := execute(t, `{"query":"query Products($locale: String) { topProducts(locale: $locale) }"}`)
+		assert.Contains(t, upstreamRequest, `"variables":{"locale":"en-US"}`)
+	})
+
+	t.Run("default of the operation takes precedence over the global default", func(t *testing.T) {
+		upstreamRequest := execute(t, `{"operationName":"GermanProducts","query":"query GermanProducts($locale: String) { topProducts(locale: $locale) }"}`)
+		assert.Contains(t, upstreamRequest, `"variables":{"locale":"de-DE"}`)
+	})
+
+	t.Run("variable sent by the client is kept", func(t *testing.T) {
+		upstreamRequest := execute(t, `{"query":"query Products($locale: String) { topProducts(locale: $locale) }","variables":{"locale":"fr-FR"}}`)
+		assert.Contains(t, upstreamRequest, `"variables":{"locale":"fr-FR"}`)
+	})
+
+	t.Run("default value declared by the operation takes precedence", func(t *testing.T) {
+		upstreamRequest := execute(t, `{"query":"query Products($locale: String = \"it-IT\") { topProducts(locale: $locale) }"}`)
+		assert.Contains(t, upstreamRequest, `it-IT`)
+		assert.NotContains(t, upstreamRequest, `en-US`)
+	})
+}
+
+func TestGraphQLHTTPRequestHandler_ResponseTransformer(t *testing.T) {
+	schema, err := graphql.NewSchemaFromString(`
+		schema { query: Query }
+		type Query {
+			hello: String
+		}
+	`)
+	require.NoError(t, err)
+
+	engineConf := graphql.NewEngineV2Configuration(schema)
+	engineConf.AddDataSource(plan.DataSourceConfiguration{
+		RootNodes: []plan.TypeField{
+			{TypeName: "Query", FieldNames: []string{"hello"}},
+		},
+		Factory: &staticdatasource.Factory{},
+		Custom: staticdatasource.ConfigJSON(staticdatasource.Configuration{
+			Data: `"world"`,
+		}),
+	})
+	engineConf.SetFieldConfigurations(plan.FieldConfigurations{
+		{TypeName: "Query", FieldName: "hello", DisableDefaultMapping: true},
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	engine, err := graphql.NewExecutionEngineV2(ctx, log.NoopLogger, engineConf)
+	require.NoError(t, err)
+
+	transformer := func(ctx context.Context, response []byte) ([]byte, error) {
+		operation, ok := OperationFromContext(ctx)
+		require.True(t, ok)
+		return sjson.SetBytes(response, "extensions.requestId", "req-"+operation.OperationName)
+	}
+	handler := NewGraphqlHTTPHandler(schema, engine, log.NoopLogger, WithResponseTransformer(transformer))
+
+	execute := func(t *testing.T, body string) string {
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(body)))
+		require.Equal(t, http.StatusOK, recorder.Code)
+		return recorder.Body.String()
+	}
+
+	t.Run("extensions are added to the response", func(t *testing.T) {
+		assert.Equal(t, `{"data":{"hello":"world"},"extensions":{"requestId":"req-Hello"}}`,
+			execute(t, `{"operationName":"Hello","query":"query Hello { hello }"}`))
+	})
+
+	t.Run("batched operations are transformed one by one", func(t *testing.T) {
+		assert.Equal(t, `[{"data":{"hello":"world"},"extensions":{"requestId":"req-A"}},{"data":{"hello":"world"},"extensions":{"requestId":"req-B"}}]`,
+			execute(t, `[{"operationName":"A","query":"query A { hello }"},{"operationName":"B","query":"query B { hello }"}]`))
+	})
+}
+
+func TestGraphQLHTTPRequestHandler_StatusCodePolicy(t *testing.T) {
+	upstream := func(response string) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte(response))
+		}))
+	}
+	newHandler := func(t *testing.T, productsURL, reviewsURL string) http.Handler {
+		factory := graphql.NewFederationEngineConfigFactory([]graphql_datasource.Configuration{
+			{
+				Fetch: graphql_datasource.FetchConfiguration{
+					URL: productsURL,
+				},
+				Federation: graphql_datasource.FederationConfiguration{
+					Enabled:    true,
+					ServiceSDL: `extend type Query { topProducts: [String] }`,
+				},
+			},
+			{
+				Fetch: graphql_datasource.FetchConfiguration{
+					URL: reviewsURL,
+				},
+				Federation: graphql_datasource.FederationConfiguration{
+					Enabled:    true,
+					ServiceSDL: `extend type Query { latestReviews: [String] }`,
+				},
+			},
+		}, graphql_datasource.NewBatchFactory())
+		engineConf, err := factory.EngineV2Configuration()
+		require.NoError(t, err)
+		schema, err := factory.MergedSchema()
+		require.NoError(t, err)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		t.Cleanup(cancel)
+		engine, err := graphql.NewExecutionEngineV2(ctx, log.NoopLogger, engineConf)
+		require.NoError(t, err)
+
+		return NewGraphqlHTTPHandler(schema, engine, log.NoopLogger, WithStatusCodePolicy(DefaultStatusCodePolicy))
+	}
+	execute := func(handler http.Handler, body string) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(body)))
+		return recorder
+	}
+
+	products := upstream(`{"data":{"topProducts":["Table"]}}`)
+	defer products.Close()
+	reviews := upstream(`{"errors":[{"message":"reviews unavailable"}],"data":{"latestReviews":null}}`)
+	defer reviews.Close()
+	handler := newHandler(t, products.URL, reviews.URL)
+
+	t.Run("malformed query", func(t *testing.T) {
+		recorder := execute(handler, `{"query":"{ topProducts "}`)
+		assert.Equal(t, http.StatusBadRequest, recorder.Code)
+		assert.Contains(t, recorder.Body.String(), `{"errors":[`)
+	})
+
+	t.Run("invalid query", func(t *testing.T) {
+		recorder := execute(handler, `{"query":"{ bestsellers }"}`)
+		assert.Equal(t, http.StatusBadRequest, recorder.Code)
+	})
+
+	t.Run("partial data", func(t *testing.T) {
+		recorder := execute(handler, `{"query":"{ topProducts latestReviews }"}`)
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		assert.Contains(t, recorder.Body.String(), `reviews unavailable`)
+		assert.Contains(t, recorder.Body.String(), `"topProducts":["Table"]`)
+	})
+
+	t.Run("all subgraphs down", func(t *testing.T) {
+		down := upstream(``)
+		down.Close()
+		recorder := execute(newHandler(t, down.URL, down.URL), `{"query":"{ topProducts latestReviews }"}`)
+		assert.Equal(t, http.StatusBadGateway, recorder.Code)
+		assert.Contains(t, recorder.Body.String(), `{"errors":[`)
+	})
+
+	t.Run("batched requests are written with 200", func(t *testing.T) {
+		recorder := execute(handler, `[{"query":"{ topProducts "}]`)
+		assert.Equal(t, http.StatusOK, recorder.Code)
+	})
+}
+
+func TestClassifyResponse(t *testing.T) {
+	assert.Equal(t, ErrorClassNone, classifyResponse([]byte(`{"data":{"topProducts":["Table"]}}`)))
+	assert.Equal(t, ErrorClassPartial, classifyResponse([]byte(`{"errors":[{"message":"failed"}],"data":{"topProducts":null}}`)))
+	assert.Equal(t, ErrorClassUpstream, classifyResponse([]byte(`{"errors":[{"message":"failed"}],"data":null}`)))
+}
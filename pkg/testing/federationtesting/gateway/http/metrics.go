@@ -0,0 +1,81 @@
+package http
+
+import (
+	"bytes"
+	"net/http"
+	"time"
+
+	"github.com/wundergraph/graphql-go-tools/pkg/graphql"
+)
+
+const httpHeaderSubgraphMetrics string = "X-Graphql-Subgraph-Metrics"
+
+const (
+	OperationResultSuccess = "success"
+	OperationResultError   = "error"
+)
+
+// Metrics records the metrics of a gateway, e.g. as Prometheus counters and histograms.
+// Implementations must be safe for concurrent use.
+type Metrics interface {
+	// OperationCompleted is called once an operation of a HTTP request is executed.
+	// operationType is "query", "mutation", "subscription" or "unknown" for invalid operations,
+	// result is OperationResultSuccess or OperationResultError if the response contains errors.
+	OperationCompleted(operationType, result string)
+	// FetchCompleted is called once a fetch of a subgraph is done.
+	// statusCode is 0 if no response was received, err is the error of the round trip.
+	FetchCompleted(serviceName string, duration time.Duration, statusCode int, err error)
+}
+
+// executionOptions returns the options for executing an operation of the request.
+// The metrics per subgraph are added to the extensions of the response if the X-Graphql-Subgraph-Metrics header is set.
+// The extensions of the responses of subgraphs are merged into the extensions of the response if enabled.
+// The errors of the response are passed through the error presenter if one is configured.
+func (g *GraphQLHTTPRequestHandler) executionOptions(header http.Header) []graphql.ExecutionOptionsV2 {
+	var options []graphql.ExecutionOptionsV2
+	if header.Get(httpHeaderSubgraphMetrics) != "" {
+		options = append(options, graphql.WithSubgraphMetrics(g.serviceNames))
+	}
+	if g.subgraphExtensions {
+		options = append(options, graphql.WithSubgraphExtensions(g.serviceNames))
+	}
+	if g.errorPipeline != nil {
+		options = append(options, graphql.WithResponsePipeline(g.errorPipeline))
+	}
+	if g.requestTimeout > 0 {
+		options = append(options, graphql.WithRequestTimeout(g.requestTimeout))
+	}
+	return options
+}
+
+// recordOperation reports an executed operation to the metrics, if any
+func (g *GraphQLHTTPRequestHandler) recordOperation(gqlRequest *graphql.Request, response []byte, err error) {
+	if g.metrics == nil {
+		return
+	}
+	result := OperationResultSuccess
+	if err != nil || bytes.HasPrefix(response, []byte(`{"errors"`)) {
+		result = OperationResultError
+	}
+	g.metrics.OperationCompleted(operationTypeName(gqlRequest), result)
+}
+
+func operationTypeName(gqlRequest *graphql.Request) string {
+	if gqlRequest == nil {
+		return "unknown"
+	}
+	operationType, err := gqlRequest.OperationType()
+	if err != nil {
+		return "unknown"
+	}
+	switch operationType {
+	case graphql.OperationTypeQuery:
+		return "query"
+	case graphql.OperationTypeMutation:
+		return "mutation"
+	case graphql.OperationTypeSubscription:
+		return "subscription"
+	default:
+		return "unknown"
+	}
+}
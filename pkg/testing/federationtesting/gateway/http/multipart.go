@@ -0,0 +1,87 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+
+	log "github.com/jensneuse/abstractlogger"
+
+	"github.com/wundergraph/graphql-go-tools/pkg/graphql"
+)
+
+const (
+	httpContentTypeMultipartMixed string = `multipart/mixed; boundary="-"`
+	multipartPartHeader           string = "\r\n---\r\nContent-Type: application/json; charset=utf-8\r\n\r\n"
+	multipartEnd                  string = "\r\n-----\r\n"
+)
+
+// handleIncrementalHTTP executes an operation using @defer or @stream
+// and writes every payload as a part of a multipart/mixed response as soon as it is resolved.
+// The operation is executed with the same options as operations responded at once, the status code of the response
+// is chosen by the class of the errors of the initial payload, see StatusCodePolicy.
+func (g *GraphQLHTTPRequestHandler) handleIncrementalHTTP(w http.ResponseWriter, r *http.Request, gqlRequest *graphql.Request) {
+	ctx := r.Context()
+	writer := &multipartResponseWriter{
+		w: w,
+		transform: func(payload []byte) []byte {
+			return g.transformResponse(ctx, gqlRequest, payload)
+		},
+		statusCodePolicy: g.statusCodePolicy,
+	}
+	err := g.engine.ExecuteIncremental(ctx, gqlRequest, writer, g.executionOptions(r.Header)...)
+	g.recordOperation(gqlRequest, writer.initial, err)
+	if err != nil {
+		g.log.Error("engine.ExecuteIncremental", log.Error(err))
+		if writer.started {
+			return
+		}
+		if errors.Is(err, graphql.ErrIncrementalDeliveryOnMutation) {
+			g.writeRequestError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		errorClass := classifyError(gqlRequest, err)
+		if g.errorPresenter == nil && !g.statusCodePolicy.maps(errorClass) {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		g.writeResponse(w, g.statusCodePolicy.StatusCode(errorClass), g.transformResponse(ctx, gqlRequest, g.errorResponse(ctx, err)))
+		return
+	}
+
+	if _, err := w.Write([]byte(multipartEnd)); err != nil {
+		g.log.Error("write multipart end", log.Error(err))
+	}
+}
+
+// multipartResponseWriter writes the payloads of an incremental response as parts of a multipart/mixed response
+type multipartResponseWriter struct {
+	w http.ResponseWriter
+	// transform runs the ResponseTransformer of the handler over every payload
+	transform        func(payload []byte) []byte
+	statusCodePolicy StatusCodePolicy
+	// initial is the initial payload once it's written
+	initial []byte
+	started bool
+}
+
+func (m *multipartResponseWriter) WritePayload(payload []byte) error {
+	payload = m.transform(payload)
+	if !m.started {
+		m.initial = payload
+		m.w.Header().Set(httpHeaderContentType, httpContentTypeMultipartMixed)
+		m.w.WriteHeader(m.statusCodePolicy.StatusCode(classifyResponse(payload)))
+		m.started = true
+	}
+
+	if _, err := m.w.Write([]byte(multipartPartHeader)); err != nil {
+		return err
+	}
+	if _, err := m.w.Write(payload); err != nil {
+		return err
+	}
+
+	if flusher, ok := m.w.(http.Flusher); ok {
+		flusher.Flush()
+	}
+	return nil
+}
@@ -0,0 +1,151 @@
+package http
+
+import (
+	"time"
+
+	"github.com/gobwas/ws"
+
+	"github.com/wundergraph/graphql-go-tools/pkg/subscription"
+)
+
+// Option configures the handler created by NewGraphqlHTTPHandler.
+// Passing nil or a zero value to an option keeps the behaviour of the handler without the option.
+type Option func(handler *GraphQLHTTPRequestHandler)
+
+// WithUpgrader upgrades websocket requests with the upgrader, e.g. to add headers to the upgrade response.
+func WithUpgrader(upgrader *ws.HTTPUpgrader) Option {
+	return func(handler *GraphQLHTTPRequestHandler) {
+		if upgrader != nil {
+			handler.wsUpgrader = upgrader
+		}
+	}
+}
+
+// WithOperationTracker tracks the operations and subscriptions of the handler, so that they can be drained on shutdown.
+// The tracker might be shared by several handlers.
+func WithOperationTracker(operations *OperationTracker) Option {
+	return func(handler *GraphQLHTTPRequestHandler) {
+		handler.operations = operations
+	}
+}
+
+// WithServiceNames names the subgraphs by their URL, e.g. to namespace the extensions of their responses.
+func WithServiceNames(serviceNames map[string]string) Option {
+	return func(handler *GraphQLHTTPRequestHandler) {
+		handler.serviceNames = serviceNames
+	}
+}
+
+// WithErrorPresenter runs the presenter over every error before it's written to the response.
+func WithErrorPresenter(presenter ErrorPresenter) Option {
+	return func(handler *GraphQLHTTPRequestHandler) {
+		handler.errorPresenter = presenter
+	}
+}
+
+// WithMetrics records the operations and the fetches of every subgraph in metrics.
+func WithMetrics(metrics Metrics) Option {
+	return func(handler *GraphQLHTTPRequestHandler) {
+		handler.metrics = metrics
+	}
+}
+
+// WithOperationCoalescer executes identical queries arriving at the same time only once, see OperationCoalescer.
+func WithOperationCoalescer(coalescer *OperationCoalescer) Option {
+	return func(handler *GraphQLHTTPRequestHandler) {
+		handler.coalescer = coalescer
+	}
+}
+
+// WithSubscriptionMiddlewares wraps the start of every operation sent over websockets with the middlewares.
+// The first middleware is the outermost, see subscription.Handler.Use.
+func WithSubscriptionMiddlewares(middlewares ...subscription.Middleware) Option {
+	return func(handler *GraphQLHTTPRequestHandler) {
+		for _, middleware := range middlewares {
+			if middleware != nil {
+				handler.subscriptionMiddlewares = append(handler.subscriptionMiddlewares, middleware)
+			}
+		}
+	}
+}
+
+// WithSubgraphExtensions merges the extensions of the responses of subgraphs into the response.
+func WithSubgraphExtensions(enabled bool) Option {
+	return func(handler *GraphQLHTTPRequestHandler) {
+		handler.subgraphExtensions = enabled
+	}
+}
+
+// WithOperationAllowlist rejects operations which aren't in the allowlist, before any other middleware runs.
+func WithOperationAllowlist(allowlist *OperationAllowlist) Option {
+	return func(handler *GraphQLHTTPRequestHandler) {
+		handler.allowlist = allowlist
+	}
+}
+
+// WithDefaultVariables merges the default variables into the variables sent by clients.
+func WithDefaultVariables(defaultVariables *DefaultVariables) Option {
+	return func(handler *GraphQLHTTPRequestHandler) {
+		handler.defaultVariables = defaultVariables
+	}
+}
+
+// WithResponseTransformer runs the transformer over every response before it's written.
+func WithResponseTransformer(transformer ResponseTransformer) Option {
+	return func(handler *GraphQLHTTPRequestHandler) {
+		handler.responseTransformer = transformer
+	}
+}
+
+// WithStatusCodePolicy chooses the status code of resolved responses with the policy.
+func WithStatusCodePolicy(policy StatusCodePolicy) Option {
+	return func(handler *GraphQLHTTPRequestHandler) {
+		handler.statusCodePolicy = policy
+	}
+}
+
+// WithMaxRequestBodySize rejects requests whose body or query parameters exceed the size in bytes, 0 means no limit.
+func WithMaxRequestBodySize(bytes int64) Option {
+	return func(handler *GraphQLHTTPRequestHandler) {
+		handler.maxRequestBodySize = bytes
+	}
+}
+
+// WithRequestTimeout bounds the time of resolving an operation, 0 means no timeout.
+func WithRequestTimeout(timeout time.Duration) Option {
+	return func(handler *GraphQLHTTPRequestHandler) {
+		handler.requestTimeout = timeout
+	}
+}
+
+// WithResponseCompression compresses responses with an encoding accepted by the client.
+func WithResponseCompression(compression *ResponseCompression) Option {
+	return func(handler *GraphQLHTTPRequestHandler) {
+		handler.compression = compression
+	}
+}
+
+// WithResponseCache serves cacheable queries from the cache.
+func WithResponseCache(cache *ResponseCache) Option {
+	return func(handler *GraphQLHTTPRequestHandler) {
+		handler.responseCache = cache
+	}
+}
+
+// WithMaxBatchSize rejects batched requests with more operations, 0 keeps DefaultMaxBatchSize.
+func WithMaxBatchSize(size int) Option {
+	return func(handler *GraphQLHTTPRequestHandler) {
+		if size > 0 {
+			handler.maxBatchSize = size
+		}
+	}
+}
+
+// WithBatchConcurrency bounds the operations of a batched request executed at the same time, 0 keeps DefaultBatchConcurrency.
+func WithBatchConcurrency(concurrency int) Option {
+	return func(handler *GraphQLHTTPRequestHandler) {
+		if concurrency > 0 {
+			handler.batchConcurrency = concurrency
+		}
+	}
+}
@@ -0,0 +1,163 @@
+package http
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// fetchDurationBuckets are the upper bounds of the buckets of the fetch duration histogram in seconds
+var fetchDurationBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// PrometheusMetrics records the metrics of a gateway as Prometheus counters, histograms and gauges
+// and serves them in the Prometheus text exposition format, e.g. on the "/metrics" route of the gateway.
+// The metrics are kept by the instance, so gateways in the same process don't share any state.
+type PrometheusMetrics struct {
+	mu             sync.Mutex
+	operations     map[operationSeries]uint64
+	fetchDurations map[string]*durationHistogram
+	fetchErrors    map[fetchErrorSeries]uint64
+	// activeSubscriptions is nil if the active subscriptions aren't observed
+	activeSubscriptions func() int
+}
+
+type operationSeries struct {
+	operationType, result string
+}
+
+type fetchErrorSeries struct {
+	serviceName, statusCode string
+}
+
+type durationHistogram struct {
+	// buckets are the cumulative counts of the observations per bucket of fetchDurationBuckets
+	buckets []uint64
+	sum     float64
+	count   uint64
+}
+
+func NewPrometheusMetrics() *PrometheusMetrics {
+	return &PrometheusMetrics{
+		operations:     make(map[operationSeries]uint64),
+		fetchDurations: make(map[string]*durationHistogram),
+		fetchErrors:    make(map[fetchErrorSeries]uint64),
+	}
+}
+
+// ObserveActiveSubscriptions exposes the number returned by activeSubscriptions as gauge, e.g. OperationTracker.ActiveSubscriptions
+func (p *PrometheusMetrics) ObserveActiveSubscriptions(activeSubscriptions func() int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.activeSubscriptions = activeSubscriptions
+}
+
+func (p *PrometheusMetrics) OperationCompleted(operationType, result string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.operations[operationSeries{operationType: operationType, result: result}]++
+}
+
+// FetchCompleted records the duration of every fetch. Fetches without a response or with a status code of 400 or above
+// are counted as errors by service and status code.
+func (p *PrometheusMetrics) FetchCompleted(serviceName string, duration time.Duration, statusCode int, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	histogram, ok := p.fetchDurations[serviceName]
+	if !ok {
+		histogram = &durationHistogram{buckets: make([]uint64, len(fetchDurationBuckets))}
+		p.fetchDurations[serviceName] = histogram
+	}
+	seconds := duration.Seconds()
+	for i, upperBound := range fetchDurationBuckets {
+		if seconds <= upperBound {
+			histogram.buckets[i]++
+		}
+	}
+	histogram.sum += seconds
+	histogram.count++
+
+	if err != nil || statusCode == 0 || statusCode >= http.StatusBadRequest {
+		p.fetchErrors[fetchErrorSeries{serviceName: serviceName, statusCode: strconv.Itoa(statusCode)}]++
+	}
+}
+
+// ServeHTTP writes the metrics in the Prometheus text exposition format
+func (p *PrometheusMetrics) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.write(w)
+}
+
+func (p *PrometheusMetrics) write(w io.Writer) {
+	writeMetricHeader(w, "graphql_gateway_operations_total", "counter", "Operations executed by the gateway by operation type and result.")
+	operations := make([]operationSeries, 0, len(p.operations))
+	for series := range p.operations {
+		operations = append(operations, series)
+	}
+	sort.Slice(operations, func(i, j int) bool {
+		if operations[i].operationType != operations[j].operationType {
+			return operations[i].operationType < operations[j].operationType
+		}
+		return operations[i].result < operations[j].result
+	})
+	for _, series := range operations {
+		fmt.Fprintf(w, "graphql_gateway_operations_total{operation_type=%s,result=%s} %d\n",
+			quoteLabelValue(series.operationType), quoteLabelValue(series.result), p.operations[series])
+	}
+
+	writeMetricHeader(w, "graphql_gateway_subgraph_fetch_duration_seconds", "histogram", "Duration of the fetches of subgraphs by service.")
+	services := make([]string, 0, len(p.fetchDurations))
+	for serviceName := range p.fetchDurations {
+		services = append(services, serviceName)
+	}
+	sort.Strings(services)
+	for _, serviceName := range services {
+		histogram, service := p.fetchDurations[serviceName], quoteLabelValue(serviceName)
+		for i, upperBound := range fetchDurationBuckets {
+			fmt.Fprintf(w, "graphql_gateway_subgraph_fetch_duration_seconds_bucket{service=%s,le=\"%s\"} %d\n",
+				service, strconv.FormatFloat(upperBound, 'g', -1, 64), histogram.buckets[i])
+		}
+		fmt.Fprintf(w, "graphql_gateway_subgraph_fetch_duration_seconds_bucket{service=%s,le=\"+Inf\"} %d\n", service, histogram.count)
+		fmt.Fprintf(w, "graphql_gateway_subgraph_fetch_duration_seconds_sum{service=%s} %s\n", service, strconv.FormatFloat(histogram.sum, 'g', -1, 64))
+		fmt.Fprintf(w, "graphql_gateway_subgraph_fetch_duration_seconds_count{service=%s} %d\n", service, histogram.count)
+	}
+
+	writeMetricHeader(w, "graphql_gateway_subgraph_fetch_errors_total", "counter", "Failed fetches of subgraphs by service and status code, 0 if no response was received.")
+	fetchErrors := make([]fetchErrorSeries, 0, len(p.fetchErrors))
+	for series := range p.fetchErrors {
+		fetchErrors = append(fetchErrors, series)
+	}
+	sort.Slice(fetchErrors, func(i, j int) bool {
+		if fetchErrors[i].serviceName != fetchErrors[j].serviceName {
+			return fetchErrors[i].serviceName < fetchErrors[j].serviceName
+		}
+		return fetchErrors[i].statusCode < fetchErrors[j].statusCode
+	})
+	for _, series := range fetchErrors {
+		fmt.Fprintf(w, "graphql_gateway_subgraph_fetch_errors_total{service=%s,status_code=%s} %d\n",
+			quoteLabelValue(series.serviceName), quoteLabelValue(series.statusCode), p.fetchErrors[series])
+	}
+
+	if p.activeSubscriptions != nil {
+		writeMetricHeader(w, "graphql_gateway_active_subscriptions", "gauge", "Subscriptions active on the gateway.")
+		fmt.Fprintf(w, "graphql_gateway_active_subscriptions %d\n", p.activeSubscriptions())
+	}
+}
+
+func writeMetricHeader(w io.Writer, name, metricType, help string) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, metricType)
+}
+
+var labelValueReplacer = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// quoteLabelValue quotes a label value with the escaping of the text exposition format
+func quoteLabelValue(value string) string {
+	return `"` + labelValueReplacer.Replace(value) + `"`
+}
@@ -0,0 +1,93 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrometheusMetrics(t *testing.T) {
+	t.Run("without metrics", func(t *testing.T) {
+		recorder := httptest.NewRecorder()
+		NewPrometheusMetrics().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		assert.Equal(t, "text/plain; version=0.0.4; charset=utf-8", recorder.Header().Get("Content-Type"))
+		assert.Equal(t, `# HELP graphql_gateway_operations_total Operations executed by the gateway by operation type and result.
+# TYPE graphql_gateway_operations_total counter
+# HELP graphql_gateway_subgraph_fetch_duration_seconds Duration of the fetches of subgraphs by service.
+# TYPE graphql_gateway_subgraph_fetch_duration_seconds histogram
+# HELP graphql_gateway_subgraph_fetch_errors_total Failed fetches of subgraphs by service and status code, 0 if no response was received.
+# TYPE graphql_gateway_subgraph_fetch_errors_total counter
+`, recorder.Body.String())
+	})
+
+	t.Run("operations, fetches and active subscriptions", func(t *testing.T) {
+		metrics := NewPrometheusMetrics()
+		metrics.ObserveActiveSubscriptions(func() int {
+			return 3
+		})
+		metrics.OperationCompleted("query", "success")
+		metrics.OperationCompleted("subscription", "success")
+		metrics.OperationCompleted("query", "error")
+		metrics.OperationCompleted("query", "success")
+		metrics.FetchCompleted("products", 500*time.Millisecond, 0, errors.New("connection refused"))
+		metrics.FetchCompleted("accounts", 250*time.Millisecond, http.StatusOK, nil)
+		metrics.FetchCompleted("accounts", 2*time.Second, http.StatusBadGateway, nil)
+
+		recorder := httptest.NewRecorder()
+		metrics.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+		assert.Equal(t, `# HELP graphql_gateway_operations_total Operations executed by the gateway by operation type and result.
+# TYPE graphql_gateway_operations_total counter
+graphql_gateway_operations_total{operation_type="query",result="error"} 1
+graphql_gateway_operations_total{operation_type="query",result="success"} 2
+graphql_gateway_operations_total{operation_type="subscription",result="success"} 1
+# HELP graphql_gateway_subgraph_fetch_duration_seconds Duration of the fetches of subgraphs by service.
+# TYPE graphql_gateway_subgraph_fetch_duration_seconds histogram
+graphql_gateway_subgraph_fetch_duration_seconds_bucket{service="accounts",le="0.005"} 0
+graphql_gateway_subgraph_fetch_duration_seconds_bucket{service="accounts",le="0.01"} 0
+graphql_gateway_subgraph_fetch_duration_seconds_bucket{service="accounts",le="0.025"} 0
+graphql_gateway_subgraph_fetch_duration_seconds_bucket{service="accounts",le="0.05"} 0
+graphql_gateway_subgraph_fetch_duration_seconds_bucket{service="accounts",le="0.1"} 0
+graphql_gateway_subgraph_fetch_duration_seconds_bucket{service="accounts",le="0.25"} 1
+graphql_gateway_subgraph_fetch_duration_seconds_bucket{service="accounts",le="0.5"} 1
+graphql_gateway_subgraph_fetch_duration_seconds_bucket{service="accounts",le="1"} 1
+graphql_gateway_subgraph_fetch_duration_seconds_bucket{service="accounts",le="2.5"} 2
+graphql_gateway_subgraph_fetch_duration_seconds_bucket{service="accounts",le="5"} 2
+graphql_gateway_subgraph_fetch_duration_seconds_bucket{service="accounts",le="10"} 2
+graphql_gateway_subgraph_fetch_duration_seconds_bucket{service="accounts",le="+Inf"} 2
+graphql_gateway_subgraph_fetch_duration_seconds_sum{service="accounts"} 2.25
+graphql_gateway_subgraph_fetch_duration_seconds_count{service="accounts"} 2
+graphql_gateway_subgraph_fetch_duration_seconds_bucket{service="products",le="0.005"} 0
+graphql_gateway_subgraph_fetch_duration_seconds_bucket{service="products",le="0.01"} 0
+graphql_gateway_subgraph_fetch_duration_seconds_bucket{service="products",le="0.025"} 0
+graphql_gateway_subgraph_fetch_duration_seconds_bucket{service="products",le="0.05"} 0
+graphql_gateway_subgraph_fetch_duration_seconds_bucket{service="products",le="0.1"} 0
+graphql_gateway_subgraph_fetch_duration_seconds_bucket{service="products",le="0.25"} 0
+graphql_gateway_subgraph_fetch_duration_seconds_bucket{service="products",le="0.5"} 1
+graphql_gateway_subgraph_fetch_duration_seconds_bucket{service="products",le="1"} 1
+graphql_gateway_subgraph_fetch_duration_seconds_bucket{service="products",le="2.5"} 1
+graphql_gateway_subgraph_fetch_duration_seconds_bucket{service="products",le="5"} 1
+graphql_gateway_subgraph_fetch_duration_seconds_bucket{service="products",le="10"} 1
+graphql_gateway_subgraph_fetch_duration_seconds_bucket{service="products",le="+Inf"} 1
+graphql_gateway_subgraph_fetch_duration_seconds_sum{service="products"} 0.5
+graphql_gateway_subgraph_fetch_duration_seconds_count{service="products"} 1
+# HELP graphql_gateway_subgraph_fetch_errors_total Failed fetches of subgraphs by service and status code, 0 if no response was received.
+# TYPE graphql_gateway_subgraph_fetch_errors_total counter
+graphql_gateway_subgraph_fetch_errors_total{service="accounts",status_code="502"} 1
+graphql_gateway_subgraph_fetch_errors_total{service="products",status_code="0"} 1
+# HELP graphql_gateway_active_subscriptions Subscriptions active on the gateway.
+# TYPE graphql_gateway_active_subscriptions gauge
+graphql_gateway_active_subscriptions 3
+`, recorder.Body.String())
+	})
+
+	t.Run("label values are escaped", func(t *testing.T) {
+		assert.Equal(t, `"a\"b\\c\nd"`, quoteLabelValue("a\"b\\c\nd"))
+	})
+}
@@ -0,0 +1,36 @@
+package http
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+)
+
+var errRequestTooLarge = errors.New("request too large")
+
+// readBody reads the body of the request. It reads at most one byte more than maxRequestBodySize,
+// so an oversized body is rejected with errRequestTooLarge without buffering it.
+func (g *GraphQLHTTPRequestHandler) readBody(r *http.Request) ([]byte, error) {
+	if g.maxRequestBodySize <= 0 {
+		return ioutil.ReadAll(r.Body)
+	}
+	body, err := ioutil.ReadAll(io.LimitReader(r.Body, g.maxRequestBodySize+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(body)) > g.maxRequestBodySize {
+		return nil, errRequestTooLarge
+	}
+	return body, nil
+}
+
+// queryParamsTooLarge reports whether the query parameters of a GET request exceed maxRequestBodySize
+func (g *GraphQLHTTPRequestHandler) queryParamsTooLarge(r *http.Request) bool {
+	return g.maxRequestBodySize > 0 && int64(len(r.URL.RawQuery)) > g.maxRequestBodySize
+}
+
+func (g *GraphQLHTTPRequestHandler) writeRequestTooLarge(w http.ResponseWriter) {
+	g.writeRequestError(w, http.StatusRequestEntityTooLarge, fmt.Sprintf("the operation exceeds the maximum request size of %d bytes", g.maxRequestBodySize))
+}
@@ -0,0 +1,141 @@
+package http
+
+import (
+	"context"
+	"encoding/binary"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/cespare/xxhash/v2"
+
+	"github.com/wundergraph/graphql-go-tools/pkg/graphql"
+)
+
+// CallerIdentity returns the identity of the caller an operation is executed for, e.g. the subject of its token.
+// The header of the request is available from ctx, see RequestHeaderFromContext.
+// ok is false for anonymous callers.
+type CallerIdentity func(ctx context.Context) (identity string, ok bool)
+
+// DefaultResponseCacheSize is the number of responses a ResponseCache keeps at most
+const DefaultResponseCacheSize = 1024
+
+// ResponseCache caches the responses of queries for the max age of their cache policy, see graphql.CacheControl.
+// Responses with a public scope are shared by all callers. Responses with a private scope, i.e. selecting a field
+// with @cacheControl(scope: PRIVATE), a field requiring authorization or one of the private fields of the cache,
+// are cached per caller by the identity of the caller and aren't cached at all for anonymous callers.
+// Responses with errors are never cached. Requests with different values of the vary headers of the cache
+// are cached separately, see VaryByHeaders.
+type ResponseCache struct {
+	mu      sync.Mutex
+	entries map[uint64]cachedResponse
+	// identity is nil if private responses aren't cached
+	identity      CallerIdentity
+	privateFields []graphql.TypeFields
+	// varyHeaders are the request headers changing the responses besides the operation
+	varyHeaders []string
+	maxSize     int
+	now         func() time.Time
+}
+
+type cachedResponse struct {
+	response []byte
+	expires  time.Time
+}
+
+// NewResponseCache returns a cache identifying the callers of private responses with identity.
+// The privateFields make responses private in addition to the fields with @cacheControl(scope: PRIVATE).
+func NewResponseCache(identity CallerIdentity, privateFields ...graphql.TypeFields) *ResponseCache {
+	return &ResponseCache{
+		entries:       map[uint64]cachedResponse{},
+		identity:      identity,
+		privateFields: privateFields,
+		maxSize:       DefaultResponseCacheSize,
+		now:           time.Now,
+	}
+}
+
+// VaryByHeaders caches the responses separately for every value of the request headers,
+// e.g. of headers routing the fetches to other deployments of the subgraphs.
+func (c *ResponseCache) VaryByHeaders(headers ...string) *ResponseCache {
+	c.varyHeaders = append(c.varyHeaders, headers...)
+	return c
+}
+
+// key returns the key of the response of a normalized request, ok is false if the response must not be cached
+func (c *ResponseCache) key(ctx context.Context, header http.Header, schema *graphql.Schema, gqlRequest *graphql.Request, cacheControl graphql.CacheControl) (key uint64, ok bool) {
+	if !cacheControl.Cacheable() {
+		return 0, false
+	}
+	operationHash, err := gqlRequest.OperationHash()
+	if err != nil {
+		return 0, false
+	}
+
+	hash := xxhash.New()
+	var buf [8]byte
+	binary.LittleEndian.PutUint64(buf[:], schema.Hash())
+	_, _ = hash.Write(buf[:])
+	binary.LittleEndian.PutUint64(buf[:], operationHash)
+	_, _ = hash.Write(buf[:])
+	writeHeaders(hash, header, c.varyHeaders)
+	if cacheControl.Scope == graphql.CacheControlScopePrivate {
+		if c.identity == nil {
+			return 0, false
+		}
+		identity, ok := c.identity(ctx)
+		if !ok {
+			return 0, false
+		}
+		// separates the identity from the shared responses, whose key has no suffix
+		_, _ = hash.WriteString("private:")
+		_, _ = hash.WriteString(identity)
+	}
+	return hash.Sum64(), true
+}
+
+// get returns the cached response of the key unless it expired.
+// The response must not be modified by the callers as it's shared.
+func (c *ResponseCache) get(key uint64) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	if !c.now().Before(entry.expires) {
+		delete(c.entries, key)
+		return nil, false
+	}
+	return entry.response, true
+}
+
+// set caches the response for the max age of the cache policy.
+// Expired responses are evicted if the cache is full, the response isn't cached if it's still full.
+func (c *ResponseCache) set(key uint64, response []byte, cacheControl graphql.CacheControl) {
+	now := c.now()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.entries[key]; !ok && len(c.entries) >= c.maxSize {
+		for entryKey, entry := range c.entries {
+			if !now.Before(entry.expires) {
+				delete(c.entries, entryKey)
+			}
+		}
+		if len(c.entries) >= c.maxSize {
+			return
+		}
+	}
+	c.entries[key] = cachedResponse{
+		response: response,
+		expires:  now.Add(time.Duration(cacheControl.MaxAge) * time.Second),
+	}
+}
+
+// cacheControl computes the cache policy of the request including the private fields of the response cache
+func (g *GraphQLHTTPRequestHandler) cacheControl(gqlRequest *graphql.Request) (graphql.CacheControl, error) {
+	if g.responseCache == nil {
+		return gqlRequest.CacheControl(g.schema)
+	}
+	return gqlRequest.CacheControl(g.schema, g.responseCache.privateFields...)
+}
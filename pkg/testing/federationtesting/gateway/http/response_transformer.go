@@ -0,0 +1,42 @@
+package http
+
+import (
+	"bytes"
+	"context"
+
+	log "github.com/jensneuse/abstractlogger"
+
+	"github.com/wundergraph/graphql-go-tools/pkg/graphql"
+)
+
+// ResponseTransformer transforms the serialized response of an operation before it's written,
+// e.g. to add a correlation id to the extensions or to redact fields for some clients.
+// The request of the operation is available from the context, see OperationFromContext.
+// The response might be shared with coalesced requests, so it must not be modified in place.
+// It runs for every payload of an incremental response too, but not for operations sent over websockets.
+type ResponseTransformer func(ctx context.Context, response []byte) ([]byte, error)
+
+type operationContextKey struct{}
+
+// OperationFromContext returns the request of the operation the response is transformed for, see ResponseTransformer
+func OperationFromContext(ctx context.Context) (*graphql.Request, bool) {
+	gqlRequest, ok := ctx.Value(operationContextKey{}).(*graphql.Request)
+	return gqlRequest, ok
+}
+
+// transformResponse runs the ResponseTransformer of the handler over the response,
+// an error of the transformer is written as the response instead
+func (g *GraphQLHTTPRequestHandler) transformResponse(ctx context.Context, gqlRequest *graphql.Request, response []byte) []byte {
+	if g.responseTransformer == nil {
+		return response
+	}
+
+	transformed, err := g.responseTransformer(context.WithValue(ctx, operationContextKey{}, gqlRequest), response)
+	if err != nil {
+		g.log.Error("transform response", log.Error(err))
+		buf := &bytes.Buffer{}
+		g.writeErrors(ctx, buf, err)
+		return buf.Bytes()
+	}
+	return transformed
+}
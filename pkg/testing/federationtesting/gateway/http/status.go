@@ -0,0 +1,99 @@
+package http
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+
+	"github.com/buger/jsonparser"
+
+	"github.com/wundergraph/graphql-go-tools/pkg/graphql"
+	"github.com/wundergraph/graphql-go-tools/pkg/operationreport"
+)
+
+// ErrorClass classifies the errors of an operation to choose the http status code of its response, see StatusCodePolicy
+type ErrorClass int
+
+const (
+	// ErrorClassNone is the class of responses without errors
+	ErrorClassNone ErrorClass = iota
+	// ErrorClassPartial is the class of responses with data and errors, e.g. errors of a subgraph or of unauthorized fields
+	ErrorClassPartial
+	// ErrorClassSyntax is the class of operations which can't be parsed
+	ErrorClassSyntax
+	// ErrorClassValidation is the class of operations which are invalid for the schema or have invalid variables
+	ErrorClassValidation
+	// ErrorClassAuthorization is the class of operations which aren't allowed, e.g. because they aren't in the allowlist
+	ErrorClassAuthorization
+	// ErrorClassUpstream is the class of operations resolved without data, e.g. because their subgraphs are unavailable
+	ErrorClassUpstream
+	// ErrorClassInternal is the class of operations failing in the gateway, e.g. because they can't be planned
+	ErrorClassInternal
+)
+
+// StatusCodePolicy maps the error class of an operation to the http status code of its response.
+// Classes missing in the policy are written with 200 OK, as GraphQL over HTTP recommends for application/json responses,
+// so a nil policy keeps the status of every response which could be resolved at 200.
+// The status of batched requests is always 200, their operations fail independently.
+type StatusCodePolicy map[ErrorClass]int
+
+// DefaultStatusCodePolicy fails requests with the status code matching the class of their errors
+// and keeps 200 OK for responses with partial data
+var DefaultStatusCodePolicy = StatusCodePolicy{
+	ErrorClassSyntax:        http.StatusBadRequest,
+	ErrorClassValidation:    http.StatusBadRequest,
+	ErrorClassAuthorization: http.StatusForbidden,
+	ErrorClassUpstream:      http.StatusBadGateway,
+	ErrorClassInternal:      http.StatusInternalServerError,
+}
+
+// StatusCode returns the status code of responses of the error class
+func (p StatusCodePolicy) StatusCode(class ErrorClass) int {
+	if statusCode, ok := p[class]; ok {
+		return statusCode
+	}
+	return http.StatusOK
+}
+
+func (p StatusCodePolicy) maps(class ErrorClass) bool {
+	_, ok := p[class]
+	return ok
+}
+
+// classifyError returns the class of an error returned by executing the operation.
+// Errors of the request are syntax or validation errors, the remaining errors are returned by resolving the operation,
+// e.g. because fetching from a subgraph failed.
+func classifyError(gqlRequest *graphql.Request, err error) ErrorClass {
+	if errors.Is(err, ErrPersistedQueryNotInAllowlist) {
+		return ErrorClassAuthorization
+	}
+	if _, parseErr := gqlRequest.OperationType(); parseErr != nil {
+		return ErrorClassSyntax
+	}
+	if errors.Is(err, ErrIncrementalDeliveryInBatch) || errors.Is(err, graphql.ErrIncrementalDeliveryOnMutation) {
+		return ErrorClassValidation
+	}
+
+	switch e := err.(type) {
+	case graphql.RequestErrors, graphql.RequestError:
+		return ErrorClassValidation
+	case operationreport.Report:
+		if len(e.ExternalErrors) != 0 {
+			return ErrorClassValidation
+		}
+		return ErrorClassInternal
+	}
+	return ErrorClassUpstream
+}
+
+// classifyResponse returns the class of a resolved response, responses with errors but without data are upstream errors
+func classifyResponse(response []byte) ErrorClass {
+	// the resolver writes errors before the data
+	if !bytes.HasPrefix(response, []byte(`{"errors"`)) {
+		return ErrorClassNone
+	}
+	if _, dataType, _, err := jsonparser.Get(response, "data"); err != nil || dataType == jsonparser.Null {
+		return ErrorClassUpstream
+	}
+	return ErrorClassPartial
+}
@@ -0,0 +1,194 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"sync"
+	"time"
+
+	graphqlDataSource "github.com/wundergraph/graphql-go-tools/pkg/engine/datasource/graphql_datasource"
+	"github.com/wundergraph/graphql-go-tools/pkg/subscription"
+)
+
+// OperationTracker keeps track of in-flight operations and websocket connections,
+// it is shared by all handlers of a gateway, so that they can be drained on shutdown.
+// A nil tracker doesn't track anything, e.g. for handlers which are never shut down.
+type OperationTracker struct {
+	mu                   sync.Mutex
+	shuttingDown         bool
+	operations           sync.WaitGroup
+	subscriptionHandlers map[*subscription.Handler]websocketClient
+	lastClientID         uint64
+}
+
+// websocketClient identifies the websocket connection of a subscription handler
+type websocketClient struct {
+	id         uint64
+	remoteAddr string
+}
+
+// ActiveSubscription describes an active subscription of a websocket client, see OperationTracker.Subscriptions
+type ActiveSubscription struct {
+	// ClientID identifies the websocket connection of the client, it's unique for the lifetime of the gateway
+	ClientID      uint64          `json:"clientId"`
+	RemoteAddr    string          `json:"remoteAddr"`
+	ID            string          `json:"id"`
+	OperationName string          `json:"operationName,omitempty"`
+	Query         string          `json:"query"`
+	Variables     json.RawMessage `json:"variables,omitempty"`
+	StartedAt     time.Time       `json:"startedAt"`
+	// Upstreams are the subscriptions to the subgraphs, subscriptions sharing an upstream subscription
+	// have the same stream id, see graphql_datasource.WithMultiplexing
+	Upstreams []graphqlDataSource.UpstreamSubscription `json:"upstreams"`
+}
+
+func NewOperationTracker() *OperationTracker {
+	return &OperationTracker{
+		subscriptionHandlers: map[*subscription.Handler]websocketClient{},
+	}
+}
+
+// start registers a new operation, it returns false when the tracker is shutting down.
+func (o *OperationTracker) start() bool {
+	if o == nil {
+		return true
+	}
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.shuttingDown {
+		return false
+	}
+	o.operations.Add(1)
+	return true
+}
+
+func (o *OperationTracker) finish() {
+	if o == nil {
+		return
+	}
+	o.operations.Done()
+}
+
+// addSubscriptionHandler registers the subscription handler of a websocket connection,
+// it returns false when the tracker is shutting down.
+func (o *OperationTracker) addSubscriptionHandler(handler *subscription.Handler, remoteAddr string) bool {
+	if o == nil {
+		return true
+	}
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.shuttingDown {
+		return false
+	}
+	o.lastClientID++
+	o.subscriptionHandlers[handler] = websocketClient{id: o.lastClientID, remoteAddr: remoteAddr}
+	return true
+}
+
+func (o *OperationTracker) removeSubscriptionHandler(handler *subscription.Handler) {
+	if o == nil {
+		return
+	}
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	delete(o.subscriptionHandlers, handler)
+}
+
+// ActiveSubscriptions returns the number of active subscriptions of all websocket connections
+func (o *OperationTracker) ActiveSubscriptions() int {
+	if o == nil {
+		return 0
+	}
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	var count int
+	for handler := range o.subscriptionHandlers {
+		count += handler.ActiveSubscriptions()
+	}
+	return count
+}
+
+// Subscriptions returns the active subscriptions of all websocket connections ordered by client and start,
+// e.g. to find subscriptions which are never stopped
+func (o *OperationTracker) Subscriptions() []ActiveSubscription {
+	if o == nil {
+		return nil
+	}
+	o.mu.Lock()
+	clients := make([]websocketClient, 0, len(o.subscriptionHandlers))
+	handlers := make(map[uint64]*subscription.Handler, len(o.subscriptionHandlers))
+	for handler, client := range o.subscriptionHandlers {
+		clients = append(clients, client)
+		handlers[client.id] = handler
+	}
+	o.mu.Unlock()
+
+	sort.Slice(clients, func(i, j int) bool {
+		return clients[i].id < clients[j].id
+	})
+
+	subscriptions := make([]ActiveSubscription, 0, len(clients))
+	for _, client := range clients {
+		for _, info := range handlers[client.id].Subscriptions() {
+			subscriptions = append(subscriptions, newActiveSubscription(client, info))
+		}
+	}
+	return subscriptions
+}
+
+func newActiveSubscription(client websocketClient, info subscription.SubscriptionInfo) ActiveSubscription {
+	active := ActiveSubscription{
+		ClientID:   client.id,
+		RemoteAddr: client.remoteAddr,
+		ID:         info.Id,
+		StartedAt:  info.StartedAt,
+		Upstreams:  info.Upstreams,
+	}
+	// the payload was parsed when the subscription was started, so it's a valid request
+	var request struct {
+		OperationName string          `json:"operationName"`
+		Query         string          `json:"query"`
+		Variables     json.RawMessage `json:"variables"`
+	}
+	if err := json.Unmarshal(info.Payload, &request); err == nil {
+		active.OperationName = request.OperationName
+		active.Query = request.Query
+		active.Variables = request.Variables
+	}
+	return active
+}
+
+// Shutdown stops accepting new operations, completes all active subscriptions
+// and waits for in-flight operations until the context is done.
+func (o *OperationTracker) Shutdown(ctx context.Context) error {
+	if o == nil {
+		return nil
+	}
+	o.mu.Lock()
+	o.shuttingDown = true
+	handlers := make([]*subscription.Handler, 0, len(o.subscriptionHandlers))
+	for handler := range o.subscriptionHandlers {
+		handlers = append(handlers, handler)
+	}
+	o.mu.Unlock()
+
+	for _, handler := range handlers {
+		go func(handler *subscription.Handler) {
+			_ = handler.Shutdown(ctx)
+		}(handler)
+	}
+
+	finished := make(chan struct{})
+	go func() {
+		o.operations.Wait()
+		close(finished)
+	}()
+
+	select {
+	case <-finished:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -117,7 +117,8 @@ func (w *WebsocketSubscriptionClient) isClosedConnectionError(err error) bool {
 	return w.isClosedConnection
 }
 
-func HandleWebsocket(done chan bool, errChan chan error, conn net.Conn, executorPool subscription.ExecutorPool, logger abstractlogger.Logger) {
+func HandleWebsocket(done chan bool, errChan chan error, conn net.Conn, executorPool subscription.ExecutorPool, operations *OperationTracker, middlewares []subscription.Middleware, logger abstractlogger.Logger) {
+	defer operations.finish()
 	defer func() {
 		if err := conn.Close(); err != nil {
 			logger.Error("http.HandleWebsocket()",
@@ -138,6 +139,13 @@ func HandleWebsocket(done chan bool, errChan chan error, conn net.Conn, executor
 		errChan <- err
 		return
 	}
+	subscriptionHandler.Use(middlewares...)
+
+	if !operations.addSubscriptionHandler(subscriptionHandler, conn.RemoteAddr().String()) {
+		errChan <- subscription.ErrHandlerShuttingDown
+		return
+	}
+	defer operations.removeSubscriptionHandler(subscriptionHandler)
 
 	close(done)
 	subscriptionHandler.Handle(context.Background()) // Blocking
@@ -149,7 +157,7 @@ func (g *GraphQLHTTPRequestHandler) handleWebsocket(connInitReqCtx context.Conte
 	errChan := make(chan error)
 
 	executorPool := subscription.NewExecutorV2Pool(g.engine, connInitReqCtx)
-	go HandleWebsocket(done, errChan, conn, executorPool, g.log)
+	go HandleWebsocket(done, errChan, conn, executorPool, g.operations, g.subscriptionMiddlewares, g.log)
 	select {
 	case err := <-errChan:
 		g.log.Error("http.GraphQLHTTPRequestHandler.handleWebsocket()",
@@ -1,6 +1,7 @@
 package gateway
 
 import (
+	"encoding/json"
 	"net/http"
 	"time"
 
@@ -9,7 +10,10 @@ import (
 
 	http2 "github.com/wundergraph/graphql-go-tools/pkg/testing/federationtesting/gateway/http"
 
+	"github.com/wundergraph/graphql-go-tools/pkg/engine/datasource/mockdatasource"
+	"github.com/wundergraph/graphql-go-tools/pkg/engine/plan"
 	"github.com/wundergraph/graphql-go-tools/pkg/graphql"
+	"github.com/wundergraph/graphql-go-tools/pkg/subscription"
 )
 
 func NewDatasource(serviceConfig []ServiceConfig, httpClient *http.Client) *DatasourcePollerPoller {
@@ -19,22 +23,351 @@ func NewDatasource(serviceConfig []ServiceConfig, httpClient *http.Client) *Data
 	})
 }
 
+type handlerOptions struct {
+	errorPresenter          http2.ErrorPresenter
+	metrics                 http2.Metrics
+	routes                  []route
+	fieldMocks              []fieldMock
+	subscriptionFilters     []subscriptionFilter
+	coalesce                bool
+	coalescingHeaders       []string
+	subscriptionMiddlewares []subscription.Middleware
+	subgraphExtensions      bool
+	rejectBreakingChanges   bool
+	publicSchemaDirectives  []string
+	allowlist               bool
+	allowlistKeys           []string
+	operationHasher         graphql.OperationHasher
+	defaultVariables        *http2.DefaultVariables
+	responseTransformer     http2.ResponseTransformer
+	statusCodePolicy        http2.StatusCodePolicy
+	maxRequestBodySize      int64
+	requestTimeout          time.Duration
+	compression             *http2.ResponseCompression
+	responseCache           *http2.ResponseCache
+	subscriptionsDebug      bool
+	urlRewriter             URLRewriter
+	urlRewriterHeaders      []string
+}
+
+type fieldMock struct {
+	typeName  string
+	fieldName string
+	resolver  mockdatasource.Resolver
+}
+
+type subscriptionFilter struct {
+	typeName   string
+	fieldName  string
+	conditions []plan.SubscriptionFilterConditionConfiguration
+}
+
+type route struct {
+	pattern string
+	handler http.Handler
+}
+
+// HandlerOption configures the gateway created by Handler
+type HandlerOption func(options *handlerOptions)
+
+// WithErrorPresenter runs the presenter over every error of planning, fetching and resolving an operation
+// before it's written to the response, e.g. to hide the messages of subgraphs from clients.
+// Without a presenter errors are written unchanged.
+func WithErrorPresenter(presenter http2.ErrorPresenter) HandlerOption {
+	return func(options *handlerOptions) {
+		options.errorPresenter = presenter
+	}
+}
+
+// WithMetrics records the operations and the fetches of every subgraph in metrics,
+// e.g. http.PrometheusMetrics, which serves them in the Prometheus text format when passed to WithRoute("/metrics", metrics).
+// The number of active subscriptions is available from Gateway.ActiveSubscriptions,
+// metrics observing active subscriptions like http.PrometheusMetrics are wired to it.
+func WithMetrics(metrics http2.Metrics) HandlerOption {
+	return func(options *handlerOptions) {
+		options.metrics = metrics
+	}
+}
+
+// WithRoute serves the handler on the route alongside the GraphQL endpoint, e.g. "/healthz" or "/metrics".
+// More routes can be registered on the returned Gateway with Handle.
+func WithRoute(pattern string, handler http.Handler) HandlerOption {
+	return func(options *handlerOptions) {
+		options.routes = append(options.routes, route{pattern: pattern, handler: handler})
+	}
+}
+
+// WithFieldMock resolves the field typeName.fieldName with the resolver instead of fetching it from its subgraph,
+// e.g. to develop a frontend against fields which aren't implemented yet.
+func WithFieldMock(typeName, fieldName string, resolver mockdatasource.Resolver) HandlerOption {
+	return func(options *handlerOptions) {
+		options.fieldMocks = append(options.fieldMocks, fieldMock{typeName: typeName, fieldName: fieldName, resolver: resolver})
+	}
+}
+
+// WithSubscriptionFilter only sends the events of the subscription field typeName.fieldName to a client
+// which match the arguments the client subscribed with, e.g. if a subgraph publishes the price updates of all products
+// for updateProductPrice(upc: "top-1"). Omitted arguments don't filter events.
+func WithSubscriptionFilter(typeName, fieldName string, conditions ...plan.SubscriptionFilterConditionConfiguration) HandlerOption {
+	return func(options *handlerOptions) {
+		options.subscriptionFilters = append(options.subscriptionFilters, subscriptionFilter{typeName: typeName, fieldName: fieldName, conditions: conditions})
+	}
+}
+
+// WithOperationCoalescing executes identical queries arriving at the same time only once and shares the response,
+// see http.OperationCoalescer. Queries are only coalesced if the headers are equal, which must contain every header
+// the execution reads or forwards. Without headers all headers of the requests must be equal.
+func WithOperationCoalescing(headers ...string) HandlerOption {
+	return func(options *handlerOptions) {
+		options.coalesce = true
+		options.coalescingHeaders = headers
+	}
+}
+
+// WithSubscriptionMiddleware wraps the start of every operation sent over websockets with the middlewares,
+// e.g. to run the authorization of the http middleware for subscriptions too, which bypass it after the upgrade.
+// The token of a client is available from the payload of its connection_init message, see graphql_datasource.InitPayloadFromContext.
+// The first middleware is the outermost, see subscription.Handler.Use.
+func WithSubscriptionMiddleware(middlewares ...subscription.Middleware) HandlerOption {
+	return func(options *handlerOptions) {
+		options.subscriptionMiddlewares = append(options.subscriptionMiddlewares, middlewares...)
+	}
+}
+
+// WithSubgraphExtensions merges the "extensions" of the responses of subgraphs into the extensions of the response,
+// namespaced by the name of the service, e.g. "extensions.products". Subgraphs might return internal extensions,
+// so they are dropped unless enabled.
+func WithSubgraphExtensions() HandlerOption {
+	return func(options *handlerOptions) {
+		options.subgraphExtensions = true
+	}
+}
+
+// WithBreakingChangeRejection keeps serving the current schema if an update of the subgraphs would break it,
+// e.g. a removed field or a changed field type, see federation.CheckSchemaCompatibility.
+// The breaking changes are logged, the update is applied once the subgraphs are compatible again.
+func WithBreakingChangeRejection() HandlerOption {
+	return func(options *handlerOptions) {
+		options.rejectBreakingChanges = true
+	}
+}
+
+// WithPublicSchemaDirectives restricts the directive usages shown to clients by introspection and the SDL of the schema
+// to @deprecated and the directives, e.g. to show @requiresScopes while hiding federation directives like @key.
+// Without it the usages of all directives but @inaccessible, @tag and @sourceField are shown.
+func WithPublicSchemaDirectives(directives ...string) HandlerOption {
+	return func(options *handlerOptions) {
+		options.publicSchemaDirectives = directives
+	}
+}
+
+// WithOperationAllowlist only executes the operations with the keys, every other operation is rejected with a
+// PersistedQueryNotInAllowlist error without executing it, e.g. for a locked-down production deployment.
+// The key of an operation is returned by http.OperationAllowlistKey with the hasher of the gateway,
+// see WithOperationHasher.
+func WithOperationAllowlist(keys ...string) HandlerOption {
+	return func(options *handlerOptions) {
+		options.allowlist = true
+		options.allowlistKeys = append(options.allowlistKeys, keys...)
+	}
+}
+
+// WithOperationHasher sets the hasher of the keys of operations, e.g. of the plan cache and the allowlist,
+// defaults to graphql.XXHashOperationHasher. Automatic persisted queries are always hashed with sha256.
+func WithOperationHasher(hasher graphql.OperationHasher) HandlerOption {
+	return func(options *handlerOptions) {
+		options.operationHasher = hasher
+	}
+}
+
+// WithDefaultVariables fills in the variables clients omit with the defaults for every operation, e.g. a locale.
+// Default values declared by the operation take precedence, see graphql.Request.SetDefaultVariables.
+// The defaults apply to operations sent over http.
+func WithDefaultVariables(defaults map[string]json.RawMessage) HandlerOption {
+	return func(options *handlerOptions) {
+		options.ensureDefaultVariables()
+		options.defaultVariables.Global = defaults
+	}
+}
+
+// WithOperationDefaultVariables fills in the variables clients omit with the defaults for the operations with the name,
+// they take precedence over the defaults of WithDefaultVariables.
+func WithOperationDefaultVariables(operationName string, defaults map[string]json.RawMessage) HandlerOption {
+	return func(options *handlerOptions) {
+		options.ensureDefaultVariables()
+		if options.defaultVariables.Operations == nil {
+			options.defaultVariables.Operations = make(map[string]map[string]json.RawMessage)
+		}
+		options.defaultVariables.Operations[operationName] = defaults
+	}
+}
+
+// WithResponseTransformer transforms the serialized response of every operation before it's written,
+// e.g. to add a correlation id to the extensions, see http.ResponseTransformer.
+func WithResponseTransformer(transformer http2.ResponseTransformer) HandlerOption {
+	return func(options *handlerOptions) {
+		options.responseTransformer = transformer
+	}
+}
+
+// WithStatusCodePolicy writes the responses of failed operations with the status code of the class of their errors,
+// e.g. http.DefaultStatusCodePolicy returns 400 for invalid operations and 502 if the subgraphs are unavailable.
+// Without a policy every response which could be resolved is written with 200 OK.
+func WithStatusCodePolicy(policy http2.StatusCodePolicy) HandlerOption {
+	return func(options *handlerOptions) {
+		options.statusCodePolicy = policy
+	}
+}
+
+// WithMaxRequestBodySize rejects requests whose body or query parameters exceed the size in bytes
+// with 413 Request Entity Too Large before the operation is parsed. Without a limit requests of any size are read.
+func WithMaxRequestBodySize(bytes int64) HandlerOption {
+	return func(options *handlerOptions) {
+		options.maxRequestBodySize = bytes
+	}
+}
+
+// WithRequestTimeout bounds the total time of resolving an operation sent over HTTP, regardless of the timeouts of
+// the subgraphs which bound single fetches. Fetches still running at the deadline are cancelled and the response
+// contains the data resolved so far with a timeout error.
+func WithRequestTimeout(timeout time.Duration) HandlerOption {
+	return func(options *handlerOptions) {
+		options.requestTimeout = timeout
+	}
+}
+
+// WithResponseCompression compresses responses of at least minSize bytes with gzip or deflate
+// if the client accepts it, see http2.ResponseCompression.
+func WithResponseCompression(minSize int) HandlerOption {
+	return func(options *handlerOptions) {
+		options.compression = &http2.ResponseCompression{MinSize: minSize}
+	}
+}
+
+// WithResponseCache caches the responses of queries for the max age of their @cacheControl policy.
+// Responses selecting a private field, i.e. one with @cacheControl(scope: PRIVATE), @authenticated, @requiresScopes
+// or one of the privateFields, are cached per caller by the identity returned by identity and aren't cached for
+// anonymous callers, all other responses are shared by all callers, see http2.ResponseCache.
+func WithResponseCache(identity http2.CallerIdentity, privateFields ...graphql.TypeFields) HandlerOption {
+	return func(options *handlerOptions) {
+		options.responseCache = http2.NewResponseCache(identity, privateFields...)
+	}
+}
+
+// WithSubscriptionsDebugEndpoint lists the active subscriptions on "GET /debug/subscriptions",
+// see NewSubscriptionsDebugHandler. The list contains the variables of the subscriptions, so it's disabled by default.
+func WithSubscriptionsDebugEndpoint() HandlerOption {
+	return func(options *handlerOptions) {
+		options.subscriptionsDebug = true
+	}
+}
+
+// WithURLRewriter sends the fetches of every subgraph to the URL returned by the rewriter, e.g. to route the requests
+// bearing a header to canary deployments of the subgraphs. The SDLs are still polled from the configured URLs.
+// The headers are the request headers the rewriter reads, operations are only coalesced and responses are only
+// cached for requests with equal values of the headers, see WithOperationCoalescing and WithResponseCache.
+// Retries, circuit breakers and metrics see the fetches to the rewritten URLs as fetches of the service.
+func WithURLRewriter(rewriter URLRewriter, headers ...string) HandlerOption {
+	return func(options *handlerOptions) {
+		options.urlRewriter = rewriter
+		options.urlRewriterHeaders = headers
+	}
+}
+
+func (o *handlerOptions) ensureDefaultVariables() {
+	if o.defaultVariables == nil {
+		o.defaultVariables = &http2.DefaultVariables{}
+	}
+}
+
 func Handler(
 	logger log.Logger,
 	datasourcePoller *DatasourcePollerPoller,
 	httpClient *http.Client,
+	options ...HandlerOption,
 ) *Gateway {
+	var opts handlerOptions
+	for _, option := range options {
+		option(&opts)
+	}
+
 	upgrader := &ws.DefaultHTTPUpgrader
 	upgrader.Header = http.Header{}
 	//upgrader.Header.Add("Sec-Websocket-Protocol", "graphql-ws")
 
 	datasourceWatcher := datasourcePoller
 
+	operations := http2.NewOperationTracker()
+	serviceNames := datasourcePoller.ServiceNames()
+	var coalescer *http2.OperationCoalescer
+	if opts.coalesce {
+		// without coalescing headers all headers must be equal, including the ones of the rewriter
+		var coalescingHeaders []string
+		if len(opts.coalescingHeaders) != 0 {
+			coalescingHeaders = append(append(coalescingHeaders, opts.coalescingHeaders...), opts.urlRewriterHeaders...)
+		}
+		coalescer = http2.NewOperationCoalescer(coalescingHeaders...)
+	}
+	if opts.responseCache != nil {
+		opts.responseCache.VaryByHeaders(opts.urlRewriterHeaders...)
+	}
+	var allowlist *http2.OperationAllowlist
+	if opts.allowlist {
+		hasher := opts.operationHasher
+		if hasher == nil {
+			hasher = graphql.XXHashOperationHasher
+		}
+		allowlist = http2.NewOperationAllowlist(hasher, opts.allowlistKeys...)
+	}
+
 	var gqlHandlerFactory HandlerFactoryFn = func(schema *graphql.Schema, engine *graphql.ExecutionEngineV2) http.Handler {
-		return http2.NewGraphqlHTTPHandler(schema, engine, upgrader, logger)
+		return http2.NewGraphqlHTTPHandler(schema, engine, logger,
+			http2.WithUpgrader(upgrader),
+			http2.WithOperationTracker(operations),
+			http2.WithServiceNames(serviceNames),
+			http2.WithErrorPresenter(opts.errorPresenter),
+			http2.WithMetrics(opts.metrics),
+			http2.WithOperationCoalescer(coalescer),
+			http2.WithSubscriptionMiddlewares(opts.subscriptionMiddlewares...),
+			http2.WithSubgraphExtensions(opts.subgraphExtensions),
+			http2.WithOperationAllowlist(allowlist),
+			http2.WithDefaultVariables(opts.defaultVariables),
+			http2.WithResponseTransformer(opts.responseTransformer),
+			http2.WithStatusCodePolicy(opts.statusCodePolicy),
+			http2.WithMaxRequestBodySize(opts.maxRequestBodySize),
+			http2.WithRequestTimeout(opts.requestTimeout),
+			http2.WithResponseCompression(opts.compression),
+			http2.WithResponseCache(opts.responseCache),
+		)
 	}
 
 	gateway := NewGateway(gqlHandlerFactory, httpClient, logger)
+	serviceHttpClients := datasourcePoller.ServiceHttpClients()
+	// the rewriter is the innermost transport, so every attempt of a fetch is sent to the rewritten URL
+	if opts.urlRewriter != nil {
+		serviceHttpClients = rewriteServiceHttpClients(serviceHttpClients, serviceNames, opts.urlRewriter)
+	}
+	// the circuit breakers see the outcome of a fetch after its retries
+	serviceHttpClients = withRetries(serviceHttpClients, datasourcePoller.config.Services)
+	gateway.serviceHttpClients = withCircuitBreakers(serviceHttpClients, datasourcePoller.config.Services)
+	if opts.metrics != nil {
+		gateway.serviceHttpClients = instrumentServiceHttpClients(gateway.serviceHttpClients, serviceNames, opts.metrics)
+	}
+	if observer, ok := opts.metrics.(activeSubscriptionsObserver); ok {
+		observer.ObserveActiveSubscriptions(operations.ActiveSubscriptions)
+	}
+	gateway.operations = operations
+	gateway.fieldMocks = opts.fieldMocks
+	gateway.subscriptionFilters = opts.subscriptionFilters
+	gateway.rejectBreakingChanges = opts.rejectBreakingChanges
+	gateway.publicSchemaDirectives = opts.publicSchemaDirectives
+	gateway.operationHasher = opts.operationHasher
+	for _, route := range opts.routes {
+		gateway.Handle(route.pattern, route.handler)
+	}
+	if opts.subscriptionsDebug {
+		gateway.Handle(subscriptionsDebugPattern, NewSubscriptionsDebugHandler(gateway))
+	}
 
 	datasourceWatcher.Register(gateway)
 
@@ -0,0 +1,52 @@
+package gateway
+
+import (
+	"net/http"
+	"time"
+
+	http2 "github.com/wundergraph/graphql-go-tools/pkg/testing/federationtesting/gateway/http"
+)
+
+// activeSubscriptionsObserver is implemented by metrics exposing the number of active subscriptions, e.g. http.PrometheusMetrics
+type activeSubscriptionsObserver interface {
+	ObserveActiveSubscriptions(activeSubscriptions func() int)
+}
+
+// instrumentServiceHttpClients returns copies of the service clients recording every fetch in metrics.
+// The clients of the poller stay unchanged, so polling the SDLs isn't recorded as fetches.
+func instrumentServiceHttpClients(clients map[string]*http.Client, serviceNames map[string]string, metrics http2.Metrics) map[string]*http.Client {
+	instrumented := make(map[string]*http.Client, len(clients))
+	for serviceURL, client := range clients {
+		transport := client.Transport
+		if transport == nil {
+			transport = http.DefaultTransport
+		}
+		instrumentedClient := *client
+		instrumentedClient.Transport = &metricsTransport{
+			serviceName: serviceNames[serviceURL],
+			transport:   transport,
+			metrics:     metrics,
+		}
+		instrumented[serviceURL] = &instrumentedClient
+	}
+	return instrumented
+}
+
+// metricsTransport records the duration and status code of the requests to a service
+type metricsTransport struct {
+	serviceName string
+	transport   http.RoundTripper
+	metrics     http2.Metrics
+}
+
+func (m *metricsTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	resp, err := m.transport.RoundTrip(req)
+
+	var statusCode int
+	if resp != nil {
+		statusCode = resp.StatusCode
+	}
+	m.metrics.FetchCompleted(m.serviceName, time.Since(start), statusCode, err)
+	return resp, err
+}
@@ -0,0 +1,124 @@
+package gateway
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jensneuse/abstractlogger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	http2 "github.com/wundergraph/graphql-go-tools/pkg/testing/federationtesting/gateway/http"
+)
+
+type recordingMetrics struct {
+	mu         sync.Mutex
+	operations map[string]int
+	fetches    map[string][]time.Duration
+	statuses   map[string][]int
+}
+
+func newRecordingMetrics() *recordingMetrics {
+	return &recordingMetrics{
+		operations: map[string]int{},
+		fetches:    map[string][]time.Duration{},
+		statuses:   map[string][]int{},
+	}
+}
+
+func (r *recordingMetrics) OperationCompleted(operationType, result string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.operations[operationType+":"+result]++
+}
+
+func (r *recordingMetrics) FetchCompleted(serviceName string, duration time.Duration, statusCode int, _ error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.fetches[serviceName] = append(r.fetches[serviceName], duration)
+	r.statuses[serviceName] = append(r.statuses[serviceName], statusCode)
+}
+
+func TestHandler_WithMetrics(t *testing.T) {
+	accounts, _ := newConnectionCountingServer(t)
+	poller := NewDatasourcePoller(http.DefaultClient, DatasourcePollerConfig{
+		Services: []ServiceConfig{{Name: "accounts", URL: accounts.URL}},
+	})
+	metrics := newRecordingMetrics()
+	gateway := Handler(abstractlogger.NoopLogger, poller, http.DefaultClient, WithMetrics(metrics))
+	poller.updateSDLs(context.Background())
+
+	query := func(body string) int {
+		recorder := httptest.NewRecorder()
+		gateway.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body)))
+		return recorder.Code
+	}
+
+	// polling the SDL isn't recorded as fetch
+	assert.Empty(t, metrics.fetches)
+
+	assert.Equal(t, http.StatusOK, query(`{"query":"{ me }"}`))
+	assert.Equal(t, http.StatusOK, query(`{"query":"query Me { me }"}`))
+	assert.Equal(t, http.StatusInternalServerError, query(`{"query":"{ unknown }"}`))
+	assert.Equal(t, http.StatusOK, query(`[{"query":"{ me }"},{"query":"{ me }"}]`))
+
+	metrics.mu.Lock()
+	defer metrics.mu.Unlock()
+
+	assert.Equal(t, map[string]int{
+		"query:success": 4,
+		"query:error":   1,
+	}, metrics.operations)
+
+	require.Len(t, metrics.fetches["accounts"], 4)
+	for _, duration := range metrics.fetches["accounts"] {
+		assert.Greater(t, int64(duration), int64(0))
+	}
+	assert.Equal(t, []int{200, 200, 200, 200}, metrics.statuses["accounts"])
+	assert.Equal(t, 0, gateway.ActiveSubscriptions())
+}
+
+func TestHandler_WithPrometheusMetrics(t *testing.T) {
+	accounts, _ := newConnectionCountingServer(t)
+	poller := NewDatasourcePoller(http.DefaultClient, DatasourcePollerConfig{
+		Services: []ServiceConfig{{Name: "accounts", URL: accounts.URL}},
+	})
+	metrics := http2.NewPrometheusMetrics()
+	gateway := Handler(abstractlogger.NoopLogger, poller, http.DefaultClient, WithMetrics(metrics), WithRoute("/metrics", metrics))
+	poller.updateSDLs(context.Background())
+
+	query := func(body string) int {
+		recorder := httptest.NewRecorder()
+		gateway.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body)))
+		return recorder.Code
+	}
+	scrape := func() string {
+		recorder := httptest.NewRecorder()
+		gateway.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+		require.Equal(t, http.StatusOK, recorder.Code)
+		assert.Equal(t, "text/plain; version=0.0.4; charset=utf-8", recorder.Header().Get("Content-Type"))
+		return recorder.Body.String()
+	}
+
+	assert.Contains(t, scrape(), "graphql_gateway_active_subscriptions 0\n")
+
+	assert.Equal(t, http.StatusOK, query(`{"query":"{ me }"}`))
+	assert.Equal(t, http.StatusOK, query(`{"query":"query Me { me }"}`))
+	assert.Equal(t, http.StatusInternalServerError, query(`{"query":"{ unknown }"}`))
+
+	scraped := scrape()
+	assert.Contains(t, scraped, `graphql_gateway_operations_total{operation_type="query",result="success"} 2`+"\n")
+	assert.Contains(t, scraped, `graphql_gateway_operations_total{operation_type="query",result="error"} 1`+"\n")
+	assert.Contains(t, scraped, `graphql_gateway_subgraph_fetch_duration_seconds_bucket{service="accounts",le="+Inf"} 2`+"\n")
+	assert.Contains(t, scraped, `graphql_gateway_subgraph_fetch_duration_seconds_count{service="accounts"} 2`+"\n")
+	assert.NotContains(t, scraped, "graphql_gateway_subgraph_fetch_errors_total{")
+	assert.Contains(t, scraped, "graphql_gateway_active_subscriptions 0\n")
+
+	assert.Equal(t, http.StatusOK, query(`{"query":"{ me }"}`))
+	assert.Contains(t, scrape(), `graphql_gateway_subgraph_fetch_duration_seconds_count{service="accounts"} 3`+"\n")
+}
@@ -0,0 +1,219 @@
+package gateway
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultRetryBackoff    = 100 * time.Millisecond
+	defaultRetryMaxBackoff = 10 * time.Second
+
+	httpHeaderRetryAfter = "Retry-After"
+)
+
+// RetryConfig configures the retries of the fetches of a service rejected with 429 Too Many Requests or
+// 503 Service Unavailable. The service didn't process rejected fetches, so mutations are retried too.
+type RetryConfig struct {
+	// MaxRetries is the number of retries of a rejected fetch, 0 disables retries.
+	MaxRetries int
+	// Backoff is the wait before the first retry of a fetch rejected without Retry-After header,
+	// doubled for every further retry. Defaults to 100ms.
+	Backoff time.Duration
+	// MaxBackoff limits the wait before a retry, defaults to 10s.
+	// Fetches asking for a longer wait with their Retry-After header aren't retried.
+	MaxBackoff time.Duration
+}
+
+// retryTransport retries the requests to a service rejected with 429 or 503, after the wait requested by the
+// Retry-After header of the rejection or the backoff of the config. Once the retries are exhausted, or the wait would
+// outlast the deadline of the request, the rejection is written as GraphQL error response, so that only the fields of
+// the service resolve to null.
+type retryTransport struct {
+	serviceName string
+	config      RetryConfig
+	transport   http.RoundTripper
+	now         func() time.Time
+	// sleep waits for the duration or until the request is cancelled, it returns false if the request was cancelled
+	sleep func(req *http.Request, d time.Duration) bool
+}
+
+func newRetryTransport(serviceName string, config RetryConfig, transport http.RoundTripper) *retryTransport {
+	if config.Backoff == 0 {
+		config.Backoff = defaultRetryBackoff
+	}
+	if config.MaxBackoff == 0 {
+		config.MaxBackoff = defaultRetryMaxBackoff
+	}
+	return &retryTransport{
+		serviceName: serviceName,
+		config:      config,
+		transport:   transport,
+		now:         time.Now,
+		sleep:       sleepUntilCancelled,
+	}
+}
+
+func (r *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		// the body can't be sent again
+		return r.transport.RoundTrip(req)
+	}
+
+	backoff := r.config.Backoff
+	for attempt := 0; ; attempt++ {
+		resp, err := r.transport.RoundTrip(req)
+		if err != nil || !isRetryableStatus(resp.StatusCode) {
+			return resp, err
+		}
+
+		retryAfter, hasRetryAfter := parseRetryAfter(resp.Header.Get(httpHeaderRetryAfter), r.now())
+		wait := retryAfter
+		if !hasRetryAfter {
+			wait = backoff
+			if wait > r.config.MaxBackoff {
+				wait = r.config.MaxBackoff
+			}
+		}
+		backoff *= 2
+
+		if attempt >= r.config.MaxRetries || wait > r.config.MaxBackoff || r.exceedsDeadline(req, wait) {
+			return r.rejectedResponse(req, resp, retryAfter, hasRetryAfter)
+		}
+		drainBody(resp)
+		if !r.sleep(req, wait) {
+			return nil, req.Context().Err()
+		}
+
+		retry := req.Clone(req.Context())
+		if req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			retry.Body = body
+		}
+		req = retry
+	}
+}
+
+// exceedsDeadline reports whether the request would time out while waiting for the retry
+func (r *retryTransport) exceedsDeadline(req *http.Request, wait time.Duration) bool {
+	deadline, ok := req.Context().Deadline()
+	return ok && r.now().Add(wait).After(deadline)
+}
+
+func (r *retryTransport) rejectedResponse(req *http.Request, rejected *http.Response, retryAfter time.Duration, hasRetryAfter bool) (*http.Response, error) {
+	drainBody(rejected)
+
+	message := fmt.Sprintf("service %s is unavailable", r.serviceName)
+	code := "SERVICE_UNAVAILABLE"
+	if rejected.StatusCode == http.StatusTooManyRequests {
+		message = fmt.Sprintf("service %s is rate limited", r.serviceName)
+		code = "RATE_LIMITED"
+	}
+	extensions := map[string]interface{}{
+		"code":        code,
+		"serviceName": r.serviceName,
+	}
+	if hasRetryAfter {
+		extensions["retryAfter"] = int(retryAfter.Round(time.Second) / time.Second)
+	}
+	body, err := json.Marshal(map[string]interface{}{
+		"errors": []map[string]interface{}{
+			{
+				"message":    message,
+				"extensions": extensions,
+			},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &http.Response{
+		Status:        rejected.Status,
+		StatusCode:    rejected.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        http.Header{"Content-Type": []string{"application/json"}},
+		Body:          ioutil.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}, nil
+}
+
+func isRetryableStatus(statusCode int) bool {
+	return statusCode == http.StatusTooManyRequests || statusCode == http.StatusServiceUnavailable
+}
+
+// parseRetryAfter returns the wait requested by the value of a Retry-After header,
+// which is either a number of seconds or an HTTP date. Dates in the past request no wait.
+func parseRetryAfter(value string, now time.Time) (time.Duration, bool) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+	date, err := http.ParseTime(value)
+	if err != nil {
+		return 0, false
+	}
+	if wait := date.Sub(now); wait > 0 {
+		return wait, true
+	}
+	return 0, true
+}
+
+// drainBody reads the rest of the body, so that the connection can be reused
+func drainBody(resp *http.Response) {
+	_, _ = io.Copy(ioutil.Discard, resp.Body)
+	_ = resp.Body.Close()
+}
+
+func sleepUntilCancelled(req *http.Request, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-req.Context().Done():
+		return false
+	}
+}
+
+// withRetries returns copies of the service clients retrying rejected fetches, for services with configured retries.
+// The clients of the poller stay unchanged, so polling the SDLs isn't retried.
+func withRetries(clients map[string]*http.Client, services []ServiceConfig) map[string]*http.Client {
+	retrying := make(map[string]*http.Client, len(clients))
+	for serviceURL, client := range clients {
+		retrying[serviceURL] = client
+	}
+	for _, service := range services {
+		client, ok := retrying[service.URL]
+		if !ok || service.Retry.MaxRetries <= 0 {
+			continue
+		}
+		transport := client.Transport
+		if transport == nil {
+			transport = http.DefaultTransport
+		}
+		retryingClient := *client
+		retryingClient.Transport = newRetryTransport(service.Name, service.Retry, transport)
+		retrying[service.URL] = &retryingClient
+	}
+	return retrying
+}
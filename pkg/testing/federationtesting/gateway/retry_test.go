@@ -0,0 +1,129 @@
+package gateway
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type roundTripperFunc func(req *http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestRetryTransport(t *testing.T) {
+	// newTransport returns a transport answering with the status codes and Retry-After headers in order,
+	// the bodies of the requests and the waits before the retries are recorded
+	newTransport := func(config RetryConfig, responses ...[2]string) (*retryTransport, *[]string, *[]time.Duration) {
+		var (
+			bodies []string
+			waits  []time.Duration
+		)
+		upstream := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			body, _ := ioutil.ReadAll(req.Body)
+			bodies = append(bodies, string(body))
+			recorder := httptest.NewRecorder()
+			if response := responses[len(bodies)-1]; response[0] != "200" {
+				if response[1] != "" {
+					recorder.Header().Set("Retry-After", response[1])
+				}
+				if response[0] == "429" {
+					recorder.WriteHeader(http.StatusTooManyRequests)
+				} else {
+					recorder.WriteHeader(http.StatusServiceUnavailable)
+				}
+				_, _ = recorder.WriteString("rejected")
+				return recorder.Result(), nil
+			}
+			_, _ = recorder.WriteString(`{"data":{}}`)
+			return recorder.Result(), nil
+		})
+		transport := newRetryTransport("reviews", config, upstream)
+		transport.now = func() time.Time { return time.Unix(0, 0) }
+		transport.sleep = func(req *http.Request, d time.Duration) bool {
+			waits = append(waits, d)
+			return true
+		}
+		return transport, &bodies, &waits
+	}
+
+	roundTrip := func(t *testing.T, transport http.RoundTripper, ctx context.Context) (int, string) {
+		t.Helper()
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, "http://reviews/query", strings.NewReader(`{"query":"{ topReviews }"}`))
+		require.NoError(t, err)
+		resp, err := transport.RoundTrip(req)
+		require.NoError(t, err)
+		body, err := ioutil.ReadAll(resp.Body)
+		require.NoError(t, err)
+		return resp.StatusCode, string(body)
+	}
+
+	t.Run("waits for Retry-After before retrying", func(t *testing.T) {
+		transport, bodies, waits := newTransport(RetryConfig{MaxRetries: 2}, [2]string{"429", "1"}, [2]string{"200"})
+		statusCode, body := roundTrip(t, transport, context.Background())
+		assert.Equal(t, http.StatusOK, statusCode)
+		assert.Equal(t, `{"data":{}}`, body)
+		assert.Equal(t, []time.Duration{time.Second}, *waits)
+		assert.Equal(t, []string{`{"query":"{ topReviews }"}`, `{"query":"{ topReviews }"}`}, *bodies)
+	})
+
+	t.Run("backs off exponentially without Retry-After", func(t *testing.T) {
+		transport, _, waits := newTransport(RetryConfig{MaxRetries: 3, Backoff: 100 * time.Millisecond, MaxBackoff: 300 * time.Millisecond},
+			[2]string{"503"}, [2]string{"503"}, [2]string{"503"}, [2]string{"200"})
+		statusCode, _ := roundTrip(t, transport, context.Background())
+		assert.Equal(t, http.StatusOK, statusCode)
+		assert.Equal(t, []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 300 * time.Millisecond}, *waits)
+	})
+
+	t.Run("exhausted retries are written as GraphQL error", func(t *testing.T) {
+		transport, bodies, _ := newTransport(RetryConfig{MaxRetries: 1}, [2]string{"429", "1"}, [2]string{"429", "1"})
+		statusCode, body := roundTrip(t, transport, context.Background())
+		assert.Equal(t, http.StatusTooManyRequests, statusCode)
+		assert.Equal(t, `{"errors":[{"extensions":{"code":"RATE_LIMITED","retryAfter":1,"serviceName":"reviews"},"message":"service reviews is rate limited"}]}`, body)
+		assert.Len(t, *bodies, 2)
+	})
+
+	t.Run("Retry-After beyond the max backoff isn't retried", func(t *testing.T) {
+		transport, bodies, _ := newTransport(RetryConfig{MaxRetries: 1, MaxBackoff: time.Second}, [2]string{"503", "120"})
+		statusCode, body := roundTrip(t, transport, context.Background())
+		assert.Equal(t, http.StatusServiceUnavailable, statusCode)
+		assert.Equal(t, `{"errors":[{"extensions":{"code":"SERVICE_UNAVAILABLE","retryAfter":120,"serviceName":"reviews"},"message":"service reviews is unavailable"}]}`, body)
+		assert.Len(t, *bodies, 1)
+	})
+
+	t.Run("Retry-After beyond the deadline of the request isn't retried", func(t *testing.T) {
+		transport, bodies, _ := newTransport(RetryConfig{MaxRetries: 1}, [2]string{"429", "5"})
+		ctx, cancel := context.WithDeadline(context.Background(), time.Unix(1, 0))
+		defer cancel()
+		statusCode, _ := roundTrip(t, transport, ctx)
+		assert.Equal(t, http.StatusTooManyRequests, statusCode)
+		assert.Len(t, *bodies, 1)
+	})
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2015, time.October, 21, 7, 28, 0, 0, time.UTC)
+
+	run := func(value string, expectedWait time.Duration, expectedOk bool) func(t *testing.T) {
+		return func(t *testing.T) {
+			wait, ok := parseRetryAfter(value, now)
+			assert.Equal(t, expectedOk, ok)
+			assert.Equal(t, expectedWait, wait)
+		}
+	}
+
+	t.Run("seconds", run("120", 2*time.Minute, true))
+	t.Run("http date", run("Wed, 21 Oct 2015 07:28:30 GMT", 30*time.Second, true))
+	t.Run("http date in the past", run("Wed, 21 Oct 2015 07:27:00 GMT", 0, true))
+	t.Run("missing", run("", 0, false))
+	t.Run("negative seconds", run("-1", 0, false))
+	t.Run("malformed", run("soon", 0, false))
+}
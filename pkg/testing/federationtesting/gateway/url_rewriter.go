@@ -0,0 +1,58 @@
+package gateway
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+)
+
+// URLRewriter returns the URL a fetch of the service is sent to instead of its configured URL,
+// e.g. to route some requests to a canary deployment of the service.
+// It returns defaultURL to send the fetch to the configured URL. The header of the client request is available from
+// the context, see http.RequestHeaderFromContext.
+type URLRewriter func(ctx context.Context, service string, defaultURL string) string
+
+// rewriteServiceHttpClients returns copies of the service clients sending fetches to the URLs returned by the rewriter.
+// The clients of the poller stay unchanged, so the SDLs are polled from the configured URLs.
+func rewriteServiceHttpClients(clients map[string]*http.Client, serviceNames map[string]string, rewriter URLRewriter) map[string]*http.Client {
+	rewritten := make(map[string]*http.Client, len(clients))
+	for serviceURL, client := range clients {
+		transport := client.Transport
+		if transport == nil {
+			transport = http.DefaultTransport
+		}
+		rewritingClient := *client
+		rewritingClient.Transport = &urlRewritingTransport{
+			serviceName: serviceNames[serviceURL],
+			transport:   transport,
+			rewriter:    rewriter,
+		}
+		rewritten[serviceURL] = &rewritingClient
+	}
+	return rewritten
+}
+
+// urlRewritingTransport sends the requests to a service to the URL returned by its rewriter
+type urlRewritingTransport struct {
+	serviceName string
+	transport   http.RoundTripper
+	rewriter    URLRewriter
+}
+
+func (u *urlRewritingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	defaultURL := req.URL.String()
+	rewrittenURL := u.rewriter(req.Context(), u.serviceName, defaultURL)
+	if rewrittenURL == defaultURL {
+		return u.transport.RoundTrip(req)
+	}
+
+	target, err := url.Parse(rewrittenURL)
+	if err != nil {
+		return nil, err
+	}
+	// a RoundTripper must not modify the request
+	rewrittenReq := req.Clone(req.Context())
+	rewrittenReq.URL = target
+	rewrittenReq.Host = target.Host
+	return u.transport.RoundTrip(rewrittenReq)
+}
@@ -0,0 +1,90 @@
+package gateway
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/jensneuse/abstractlogger"
+	"github.com/stretchr/testify/assert"
+
+	http2 "github.com/wundergraph/graphql-go-tools/pkg/testing/federationtesting/gateway/http"
+)
+
+func TestHandler_WithURLRewriter(t *testing.T) {
+	sdl := "extend type Query { topProducts: [String] }"
+	stable, _ := newStaticService(t, sdl, `{"topProducts":["Table"]}`)
+	canary, canaryHeader := newStaticService(t, sdl, `{"topProducts":["Canary Table"]}`)
+
+	poller := NewDatasourcePoller(http.DefaultClient, DatasourcePollerConfig{
+		Services: []ServiceConfig{{Name: "products", URL: stable.URL}},
+	})
+	rewriter := func(ctx context.Context, service string, defaultURL string) string {
+		header, ok := http2.RequestHeaderFromContext(ctx)
+		if ok && service == "products" && header.Get("X-Canary") == "true" {
+			return canary.URL
+		}
+		return defaultURL
+	}
+	gateway := Handler(abstractlogger.NoopLogger, poller, http.DefaultClient, WithURLRewriter(rewriter))
+	poller.updateSDLs(context.Background())
+
+	// the SDL is polled from the stable service
+	assert.Empty(t, *canaryHeader)
+
+	query := func(header http.Header) string {
+		request := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"query":"{ topProducts }"}`))
+		for key, values := range header {
+			request.Header[key] = values
+		}
+		recorder := httptest.NewRecorder()
+		gateway.ServeHTTP(recorder, request)
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		return recorder.Body.String()
+	}
+
+	t.Run("requests with the canary header are routed to the canary service", func(t *testing.T) {
+		assert.Equal(t, `{"data":{"topProducts":["Canary Table"]}}`, query(http.Header{"X-Canary": []string{"true"}}))
+	})
+
+	t.Run("other requests are routed to the stable service", func(t *testing.T) {
+		assert.Equal(t, `{"data":{"topProducts":["Table"]}}`, query(nil))
+		assert.Equal(t, `{"data":{"topProducts":["Table"]}}`, query(http.Header{"X-Canary": []string{"false"}}))
+	})
+}
+
+func TestHandler_WithURLRewriter_Retries(t *testing.T) {
+	sdl := "extend type Query { topProducts: [String] }"
+	stable, _ := newStaticService(t, sdl, `{"topProducts":["Table"]}`)
+	var canaryFetches int32
+	canary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&canaryFetches, 1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write([]byte(`{"data":{"topProducts":["Canary Table"]}}`))
+	}))
+	defer canary.Close()
+
+	poller := NewDatasourcePoller(http.DefaultClient, DatasourcePollerConfig{
+		Services: []ServiceConfig{{Name: "products", URL: stable.URL, Retry: RetryConfig{MaxRetries: 1, Backoff: time.Millisecond}}},
+	})
+	var rewrites int32
+	rewriter := func(ctx context.Context, service string, defaultURL string) string {
+		atomic.AddInt32(&rewrites, 1)
+		return canary.URL
+	}
+	gateway := Handler(abstractlogger.NoopLogger, poller, http.DefaultClient, WithURLRewriter(rewriter))
+	poller.updateSDLs(context.Background())
+
+	recorder := httptest.NewRecorder()
+	gateway.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"query":"{ topProducts }"}`)))
+	assert.Equal(t, `{"data":{"topProducts":["Canary Table"]}}`, recorder.Body.String())
+	// the rewriter is the innermost transport, every attempt of the fetch is rewritten
+	assert.Equal(t, int32(2), atomic.LoadInt32(&canaryFetches))
+	assert.Equal(t, int32(2), atomic.LoadInt32(&rewrites))
+}
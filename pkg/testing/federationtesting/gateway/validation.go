@@ -0,0 +1,105 @@
+package gateway
+
+import (
+	"encoding/json"
+
+	"github.com/wundergraph/graphql-go-tools/pkg/astnormalization"
+	"github.com/wundergraph/graphql-go-tools/pkg/astparser"
+	"github.com/wundergraph/graphql-go-tools/pkg/asttransform"
+	"github.com/wundergraph/graphql-go-tools/pkg/astvalidation"
+	"github.com/wundergraph/graphql-go-tools/pkg/graphql"
+	"github.com/wundergraph/graphql-go-tools/pkg/graphqlerrors"
+	"github.com/wundergraph/graphql-go-tools/pkg/operationreport"
+)
+
+// OperationError is a validation error of an operation, Locations point into the operation
+type OperationError struct {
+	Message   string                   `json:"message"`
+	Locations []graphqlerrors.Location `json:"locations,omitempty"`
+}
+
+// ValidateOperation validates an operation and its variables against the (merged) schema SDL without executing it.
+// The operation gets parsed, normalized and validated the same way the gateway does before planning,
+// the provided variables are validated against the variable definitions of the operation selected by operationName,
+// which can be empty for documents with a single operation.
+// It returns nil if the operation is valid.
+func ValidateOperation(schemaSDL, operation, operationName string, variables json.RawMessage) []OperationError {
+	definition, report := astparser.ParseGraphqlDocumentString(schemaSDL)
+	if report.HasErrors() {
+		return operationErrorsFromReport(report)
+	}
+	if err := asttransform.MergeDefinitionWithBaseSchema(&definition); err != nil {
+		return []OperationError{{Message: err.Error()}}
+	}
+
+	document, report := astparser.ParseGraphqlDocumentString(operation)
+	if report.HasErrors() {
+		return operationErrorsFromReport(report)
+	}
+	if len(variables) > 0 {
+		document.Input.Variables = variables
+	}
+
+	if operationName != "" {
+		astnormalization.NormalizeNamedOperation(&document, &definition, []byte(operationName), &report)
+	} else {
+		astnormalization.NormalizeOperation(&document, &definition, &report)
+	}
+	if report.HasErrors() {
+		return operationErrorsFromReport(report)
+	}
+
+	astvalidation.DefaultOperationValidator().Validate(&document, &definition, &report)
+	if report.HasErrors() {
+		return operationErrorsFromReport(report)
+	}
+
+	return validateVariables(schemaSDL, operation, operationName, variables)
+}
+
+func operationErrorsFromReport(report operationreport.Report) []OperationError {
+	errs := make([]OperationError, 0, len(report.ExternalErrors)+len(report.InternalErrors))
+	for _, externalErr := range report.ExternalErrors {
+		errs = append(errs, OperationError{
+			Message:   externalErr.Message,
+			Locations: externalErr.Locations,
+		})
+	}
+	for _, internalErr := range report.InternalErrors {
+		errs = append(errs, OperationError{
+			Message: internalErr.Error(),
+		})
+	}
+	return errs
+}
+
+// validateVariables validates the variables of the selected operation the same way the engine does before the execution
+func validateVariables(schemaSDL, operation, operationName string, variables json.RawMessage) []OperationError {
+	schema, err := graphql.NewSchemaFromString(schemaSDL)
+	if err != nil {
+		return []OperationError{{Message: err.Error()}}
+	}
+
+	request := graphql.Request{
+		OperationName: operationName,
+		Query:         operation,
+		Variables:     variables,
+	}
+	result, err := request.ValidateVariables(schema)
+	if err != nil {
+		return []OperationError{{Message: err.Error()}}
+	}
+	if result.Valid {
+		return nil
+	}
+
+	requestErrors, _ := result.Errors.(graphql.RequestErrors)
+	errs := make([]OperationError, 0, len(requestErrors))
+	for _, requestError := range requestErrors {
+		errs = append(errs, OperationError{
+			Message:   requestError.Message,
+			Locations: requestError.Locations,
+		})
+	}
+	return errs
+}
@@ -0,0 +1,110 @@
+package gateway
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/wundergraph/graphql-go-tools/pkg/graphqlerrors"
+)
+
+const validationTestSchema = `
+type Query {
+	me: User
+	topProducts(first: Int = 5): [Product]
+	product(upc: String!): Product
+}
+
+type User {
+	id: ID!
+	username: String!
+}
+
+type Product {
+	upc: String!
+	name: String!
+	price: Int!
+}
+`
+
+func TestValidateOperation(t *testing.T) {
+	t.Run("valid operation", func(t *testing.T) {
+		errs := ValidateOperation(validationTestSchema, `query TopProducts($first: Int) {
+			topProducts(first: $first) { upc name }
+		}`, "", []byte(`{"first":2}`))
+		assert.Nil(t, errs)
+	})
+
+	t.Run("unknown field", func(t *testing.T) {
+		errs := ValidateOperation(validationTestSchema, `query Me {
+  me {
+    username
+    email
+  }
+}`, "", nil)
+		assert.Equal(t, []OperationError{
+			{
+				Message:   "field: email not defined on type: User",
+				Locations: []graphqlerrors.Location{{Line: 4, Column: 5}},
+			},
+		}, errs)
+	})
+
+	t.Run("missing required argument", func(t *testing.T) {
+		errs := ValidateOperation(validationTestSchema, `{ product { name } }`, "", nil)
+		assert.Equal(t, []OperationError{
+			{Message: "argument: upc is required on field: product but missing"},
+		}, errs)
+	})
+
+	t.Run("variable type mismatch", func(t *testing.T) {
+		errs := ValidateOperation(validationTestSchema, `query Product($upc: Int!) {
+  product(upc: $upc) { name }
+}`, "", []byte(`{"upc":1}`))
+		assert.Equal(t, []OperationError{
+			{
+				Message:   `Variable "$upc" of type "Int!" used in position expecting type "String!".`,
+				Locations: []graphqlerrors.Location{{Line: 1, Column: 15}, {Line: 2, Column: 16}},
+			},
+		}, errs)
+	})
+
+	t.Run("invalid variable value", func(t *testing.T) {
+		errs := ValidateOperation(validationTestSchema, `query Product($upc: String!) {
+  product(upc: $upc) { name }
+}`, "", []byte(`{"upc":1}`))
+		assert.Len(t, errs, 1)
+		assert.Contains(t, errs[0].Message, `Variable "$upc" got invalid value`)
+		assert.Equal(t, []graphqlerrors.Location{{Line: 1, Column: 15}}, errs[0].Locations)
+	})
+
+	t.Run("missing required variable", func(t *testing.T) {
+		errs := ValidateOperation(validationTestSchema, `query Product($upc: String!) {
+  product(upc: $upc) { name }
+}`, "", nil)
+		assert.Equal(t, []OperationError{
+			{
+				Message:   `Variable "$upc" of required type "String!" was not provided.`,
+				Locations: []graphqlerrors.Location{{Line: 1, Column: 15}},
+			},
+		}, errs)
+	})
+	t.Run("variables of other operations", func(t *testing.T) {
+		operations := `query Product($upc: String!) {
+  product(upc: $upc) { name }
+}
+
+query TopProducts($first: Int!) {
+  topProducts(first: $first) { upc }
+}`
+		assert.Nil(t, ValidateOperation(validationTestSchema, operations, "TopProducts", []byte(`{"first":2}`)))
+
+		errs := ValidateOperation(validationTestSchema, operations, "Product", []byte(`{"first":2}`))
+		assert.Equal(t, []OperationError{
+			{
+				Message:   `Variable "$upc" of required type "String!" was not provided.`,
+				Locations: []graphqlerrors.Location{{Line: 1, Column: 15}},
+			},
+		}, errs)
+	})
+}
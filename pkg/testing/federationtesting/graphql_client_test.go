@@ -4,9 +4,13 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"io"
 	"io/ioutil"
+	"mime"
+	"mime/multipart"
 	"net"
 	"net/http"
+	"net/url"
 	"testing"
 
 	"github.com/gobwas/ws"
@@ -21,6 +25,10 @@ import (
 type queryVariables map[string]interface{}
 
 func requestBody(t *testing.T, query string, variables queryVariables) []byte {
+	return namedRequestBody(t, query, "", variables)
+}
+
+func namedRequestBody(t *testing.T, query, operationName string, variables queryVariables) []byte {
 	var variableJsonBytes []byte
 	if len(variables) > 0 {
 		var err error
@@ -29,7 +37,7 @@ func requestBody(t *testing.T, query string, variables queryVariables) []byte {
 	}
 
 	body := graphql.Request{
-		OperationName: "",
+		OperationName: operationName,
 		Variables:     variableJsonBytes,
 		Query:         query,
 	}
@@ -59,8 +67,72 @@ type GraphqlClient struct {
 
 func (g *GraphqlClient) Query(ctx context.Context, addr, queryFilePath string, variables queryVariables, t *testing.T) []byte {
 	reqBody := loadQuery(t, queryFilePath, variables)
+	return g.post(ctx, addr, reqBody, nil, t)
+}
+
+// QueryOperation sends the operation with the given name of a document containing multiple operations
+func (g *GraphqlClient) QueryOperation(ctx context.Context, addr, queryFilePath, operationName string, variables queryVariables, t *testing.T) []byte {
+	query, err := ioutil.ReadFile(queryFilePath)
+	require.NoError(t, err)
+
+	return g.post(ctx, addr, namedRequestBody(t, string(query), operationName, variables), nil, t)
+}
+
+// QueryWithHeader sends an operation with additional http headers
+func (g *GraphqlClient) QueryWithHeader(ctx context.Context, addr, queryFilePath string, variables queryVariables, header http.Header, t *testing.T) []byte {
+	reqBody := loadQuery(t, queryFilePath, variables)
+	return g.post(ctx, addr, reqBody, header, t)
+}
+
+// QueryGet sends an operation as URL query parameters using GET and returns the status code and the body of the response
+func (g *GraphqlClient) QueryGet(ctx context.Context, addr, queryFilePath string, variables queryVariables, t *testing.T) (int, []byte) {
+	query, err := ioutil.ReadFile(queryFilePath)
+	require.NoError(t, err)
+
+	params := url.Values{}
+	params.Set("query", string(query))
+	if len(variables) > 0 {
+		variablesJson, err := json.Marshal(variables)
+		require.NoError(t, err)
+		params.Set("variables", string(variablesJson))
+	}
+
+	req, err := http.NewRequest(http.MethodGet, addr+"?"+params.Encode(), nil)
+	require.NoError(t, err)
+	req = req.WithContext(ctx)
+	resp, err := g.httpClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	responseBodyBytes, err := ioutil.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.Contains(t, resp.Header.Get("Content-Type"), "application/json")
+
+	return resp.StatusCode, responseBodyBytes
+}
+
+type batchOperation struct {
+	queryFilePath string
+	variables     queryVariables
+}
+
+func (g *GraphqlClient) QueryBatch(ctx context.Context, addr string, operations []batchOperation, t *testing.T) []byte {
+	operationsBody := make([]json.RawMessage, 0, len(operations))
+	for _, operation := range operations {
+		operationsBody = append(operationsBody, loadQuery(t, operation.queryFilePath, operation.variables))
+	}
+
+	reqBody, err := json.Marshal(operationsBody)
+	require.NoError(t, err)
+
+	return g.post(ctx, addr, reqBody, nil, t)
+}
+
+func (g *GraphqlClient) post(ctx context.Context, addr string, reqBody []byte, header http.Header, t *testing.T) []byte {
 	req, err := http.NewRequest(http.MethodPost, addr, bytes.NewBuffer(reqBody))
 	require.NoError(t, err)
+	for key, values := range header {
+		req.Header[key] = values
+	}
 	req = req.WithContext(ctx)
 	resp, err := g.httpClient.Do(req)
 	require.NoError(t, err)
@@ -72,16 +144,75 @@ func (g *GraphqlClient) Query(ctx context.Context, addr, queryFilePath string, v
 	return responseBodyBytes
 }
 
+// QueryMultipart sends an operation using @defer or @stream and returns the parts of the multipart/mixed response.
+func (g *GraphqlClient) QueryMultipart(ctx context.Context, addr, queryFilePath string, variables queryVariables, t *testing.T) [][]byte {
+	reqBody := loadQuery(t, queryFilePath, variables)
+	req, err := http.NewRequest(http.MethodPost, addr, bytes.NewBuffer(reqBody))
+	require.NoError(t, err)
+	req = req.WithContext(ctx)
+	req.Header.Set("Accept", "multipart/mixed")
+	resp, err := g.httpClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	mediaType, params, err := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	require.NoError(t, err)
+	assert.Equal(t, "multipart/mixed", mediaType)
+
+	var parts [][]byte
+	reader := multipart.NewReader(resp.Body, params["boundary"])
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		assert.Contains(t, part.Header.Get("Content-Type"), "application/json")
+		partBytes, err := ioutil.ReadAll(part)
+		require.NoError(t, err)
+		parts = append(parts, partBytes)
+	}
+
+	return parts
+}
+
 func (g *GraphqlClient) Subscription(ctx context.Context, addr, queryFilePath string, variables queryVariables, t *testing.T) chan []byte {
 	messageCh := make(chan []byte)
 
+	conn := g.StartSubscription(ctx, addr, queryFilePath, variables, t)
+
+	// 4. start receiving messages from subscription
+
+	go func() {
+		defer conn.Close()
+		defer close(messageCh)
+
+		for {
+			msgBytes, _, err := wsutil.ReadServerData(conn)
+			require.NoError(t, err)
+
+			messageCh <- msgBytes
+		}
+	}()
+
+	return messageCh
+}
+
+// StartSubscription initializes a websocket connection and starts the subscription with id 1 on it.
+func (g *GraphqlClient) StartSubscription(ctx context.Context, addr, queryFilePath string, variables queryVariables, t *testing.T) net.Conn {
+	return g.StartSubscriptionWithInitPayload(ctx, addr, queryFilePath, variables, nil, t)
+}
+
+// StartSubscriptionWithInitPayload is StartSubscription sending the payload with the connection init message.
+func (g *GraphqlClient) StartSubscriptionWithInitPayload(ctx context.Context, addr, queryFilePath string, variables queryVariables, initPayload json.RawMessage, t *testing.T) net.Conn {
 	conn, _, _, err := ws.Dial(ctx, addr)
 	require.NoError(t, err)
 	// 1. send connection init
 	initialClientMessage := subscription.Message{
 		Id:      "",
 		Type:    subscription.MessageTypeConnectionInit,
-		Payload: nil,
+		Payload: initPayload,
 	}
 
 	err = g.sendMessageToServer(conn, initialClientMessage)
@@ -99,21 +230,7 @@ func (g *GraphqlClient) Subscription(ctx context.Context, addr, queryFilePath st
 	err = g.sendMessageToServer(conn, startSubscriptionMessage)
 	require.NoError(t, err)
 
-	// 4. start receiving messages from subscription
-
-	go func() {
-		defer conn.Close()
-		defer close(messageCh)
-
-		for {
-			msgBytes, _, err := wsutil.ReadServerData(conn)
-			require.NoError(t, err)
-
-			messageCh <- msgBytes
-		}
-	}()
-
-	return messageCh
+	return conn
 }
 
 func (g *GraphqlClient) sendMessageToServer(clientConn net.Conn, message subscription.Message) error {
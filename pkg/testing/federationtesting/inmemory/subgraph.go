@@ -0,0 +1,345 @@
+// Package inmemory provides subgraphs resolving the requests of the graphql data source from in-memory data,
+// so the planner and the resolver can be tested without starting upstream servers.
+package inmemory
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/buger/jsonparser"
+
+	"github.com/wundergraph/graphql-go-tools/pkg/ast"
+	"github.com/wundergraph/graphql-go-tools/pkg/astparser"
+)
+
+// FieldResolver returns the value of a root field for its arguments.
+// Values are JSON marshaled, e.g. maps, slices, scalars or structs with json tags,
+// fields of objects are selected by their name, abstract types must have a "__typename".
+type FieldResolver func(ctx context.Context, args map[string]json.RawMessage) (interface{}, error)
+
+// EntityResolver returns the entity for a representation of the _entities field, e.g. {"__typename":"User","id":"1"},
+// or nil if the entity doesn't exist. The __typename of the representation is added to the entity,
+// numbers of the representation are json.Number values to keep the precision of large integers.
+type EntityResolver func(ctx context.Context, representation map[string]interface{}) (interface{}, error)
+
+// Subgraph implements resolve.DataSource for the requests of the graphql data source,
+// set it as graphql_datasource.Factory.Loader to resolve the fetches of the data source in-memory.
+// Only the fields selected by the upstream operation are part of the response.
+type Subgraph struct {
+	// RootFields resolve the fields of the root operation types, keyed by field name
+	RootFields map[string]FieldResolver
+	// Entities resolve the representations of the _entities field, keyed by type name
+	Entities map[string]EntityResolver
+}
+
+type request struct {
+	operation *ast.Document
+	variables []byte
+	errors    []graphqlError
+}
+
+type graphqlError struct {
+	Message string        `json:"message"`
+	Path    []interface{} `json:"path,omitempty"`
+}
+
+func (s *Subgraph) Load(ctx context.Context, input []byte, w io.Writer) (err error) {
+	query, err := jsonparser.GetString(input, "body", "query")
+	if err != nil {
+		return fmt.Errorf("get query: %w", err)
+	}
+	variables, _, _, _ := jsonparser.Get(input, "body", "variables")
+
+	operation, report := astparser.ParseGraphqlDocumentString(query)
+	if report.HasErrors() {
+		return fmt.Errorf("parse query: %s", report.Error())
+	}
+	req := &request{
+		operation: &operation,
+		variables: variables,
+	}
+
+	selectionSet := -1
+	for _, rootNode := range operation.RootNodes {
+		if rootNode.Kind == ast.NodeKindOperationDefinition {
+			selectionSet = operation.OperationDefinitions[rootNode.Ref].SelectionSet
+			break
+		}
+	}
+	if selectionSet == -1 {
+		return fmt.Errorf("query without operation: %s", query)
+	}
+
+	data := &bytes.Buffer{}
+	data.WriteByte('{')
+	written := map[string]struct{}{}
+	for _, field := range req.fields(selectionSet, "") {
+		responseKey := operation.FieldAliasOrNameString(field)
+		if _, ok := written[responseKey]; ok {
+			continue
+		}
+		if len(written) != 0 {
+			data.WriteByte(',')
+		}
+		written[responseKey] = struct{}{}
+
+		writeKey(data, responseKey)
+		value := s.resolveRootField(ctx, req, field, responseKey)
+		if err = req.writeValue(data, value, field); err != nil {
+			return err
+		}
+	}
+	data.WriteByte('}')
+
+	response := &bytes.Buffer{}
+	response.WriteByte('{')
+	if len(req.errors) != 0 {
+		errors, err := json.Marshal(req.errors)
+		if err != nil {
+			return err
+		}
+		response.WriteString(`"errors":`)
+		response.Write(errors)
+		response.WriteByte(',')
+	}
+	response.WriteString(`"data":`)
+	response.Write(data.Bytes())
+	response.WriteByte('}')
+
+	_, err = w.Write(response.Bytes())
+	return err
+}
+
+// ServeHTTP serves the subgraph over HTTP, e.g. as the upstream server of a gateway.
+// The request body is the body of the requests of the graphql data source: {"query":"...","variables":{...}}.
+func (s *Subgraph) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	input := make([]byte, 0, len(body)+len(`{"body":}`))
+	input = append(input, `{"body":`...)
+	input = append(input, body...)
+	input = append(input, '}')
+
+	response := &bytes.Buffer{}
+	if err = s.Load(r.Context(), input, response); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(response.Bytes())
+}
+
+func (s *Subgraph) resolveRootField(ctx context.Context, req *request, field int, responseKey string) interface{} {
+	fieldName := req.operation.FieldNameString(field)
+	args, err := req.arguments(field)
+	if err != nil {
+		req.addError(err, responseKey)
+		return nil
+	}
+
+	if fieldName == "_entities" {
+		return s.resolveEntities(ctx, req, args["representations"], responseKey)
+	}
+
+	resolver, ok := s.RootFields[fieldName]
+	if !ok {
+		req.addError(fmt.Errorf("unknown field: %s", fieldName), responseKey)
+		return nil
+	}
+	value, err := resolver(ctx, args)
+	if err != nil {
+		req.addError(err, responseKey)
+		return nil
+	}
+	return value
+}
+
+func (s *Subgraph) resolveEntities(ctx context.Context, req *request, representationsJSON json.RawMessage, responseKey string) interface{} {
+	decoder := json.NewDecoder(bytes.NewReader(representationsJSON))
+	decoder.UseNumber()
+	var representations []map[string]interface{}
+	if err := decoder.Decode(&representations); err != nil {
+		req.addError(fmt.Errorf("invalid representations: %w", err), responseKey)
+		return nil
+	}
+
+	entities := make([]interface{}, len(representations))
+	for i, representation := range representations {
+		typeName, _ := representation["__typename"].(string)
+		resolver, ok := s.Entities[typeName]
+		if !ok {
+			req.addError(fmt.Errorf("unknown entity type: %s", typeName), responseKey, i)
+			continue
+		}
+		entity, err := resolver(ctx, representation)
+		if err != nil {
+			req.addError(err, responseKey, i)
+			continue
+		}
+		entity, err = normalize(entity)
+		if err != nil {
+			req.addError(err, responseKey, i)
+			continue
+		}
+		if object, ok := entity.(map[string]interface{}); ok {
+			withTypeName := make(map[string]interface{}, len(object)+1)
+			for key, value := range object {
+				withTypeName[key] = value
+			}
+			withTypeName["__typename"] = typeName
+			entity = withTypeName
+		}
+		entities[i] = entity
+	}
+	return entities
+}
+
+func (r *request) addError(err error, path ...interface{}) {
+	r.errors = append(r.errors, graphqlError{
+		Message: err.Error(),
+		Path:    path,
+	})
+}
+
+// arguments returns the JSON values of the arguments of the field, variables are replaced with their values
+func (r *request) arguments(field int) (map[string]json.RawMessage, error) {
+	refs := r.operation.FieldArguments(field)
+	args := make(map[string]json.RawMessage, len(refs))
+	for _, ref := range refs {
+		value := r.operation.ArgumentValue(ref)
+		if value.Kind == ast.ValueKindVariable {
+			variableValue, _, _, err := jsonparser.Get(r.variables, r.operation.VariableValueNameString(value.Ref))
+			if err != nil {
+				continue
+			}
+			args[r.operation.ArgumentNameString(ref)] = variableValue
+			continue
+		}
+		valueJSON, err := r.operation.ValueToJSON(value)
+		if err != nil {
+			return nil, err
+		}
+		args[r.operation.ArgumentNameString(ref)] = valueJSON
+	}
+	return args, nil
+}
+
+// fields returns the fields of the selection set, including the fields of fragments matching the type name.
+// Fragments are matched if the type name of the object is unknown, as the subgraph has no schema to match
+// fragments on abstract types, the graphql data source only uses fragments on object types for upstream requests.
+func (r *request) fields(selectionSet int, typeName string) []int {
+	var fields []int
+	for _, ref := range r.operation.SelectionSets[selectionSet].SelectionRefs {
+		selection := r.operation.Selections[ref]
+		switch selection.Kind {
+		case ast.SelectionKindField:
+			fields = append(fields, selection.Ref)
+		case ast.SelectionKindInlineFragment:
+			inlineFragment := r.operation.InlineFragments[selection.Ref]
+			typeCondition := r.operation.InlineFragmentTypeConditionNameString(selection.Ref)
+			if typeName != "" && typeCondition != "" && typeCondition != typeName {
+				continue
+			}
+			if inlineFragment.HasSelections {
+				fields = append(fields, r.fields(inlineFragment.SelectionSet, typeName)...)
+			}
+		case ast.SelectionKindFragmentSpread:
+			fragment, ok := r.operation.FragmentDefinitionRef(r.operation.FragmentSpreadNameBytes(selection.Ref))
+			if !ok {
+				continue
+			}
+			typeCondition := r.operation.FragmentDefinitionTypeName(fragment).String()
+			if typeName != "" && typeCondition != typeName {
+				continue
+			}
+			fields = append(fields, r.fields(r.operation.FragmentDefinitions[fragment].SelectionSet, typeName)...)
+		}
+	}
+	return fields
+}
+
+// writeValue writes the value of the field limited to the selections of the field
+func (r *request) writeValue(buf *bytes.Buffer, value interface{}, field int) error {
+	value, err := normalize(value)
+	if err != nil {
+		return err
+	}
+
+	switch v := value.(type) {
+	case []interface{}:
+		buf.WriteByte('[')
+		for i := range v {
+			if i != 0 {
+				buf.WriteByte(',')
+			}
+			if err = r.writeValue(buf, v[i], field); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte(']')
+		return nil
+	case map[string]interface{}:
+		if !r.operation.Fields[field].HasSelections {
+			break
+		}
+		typeName, _ := v["__typename"].(string)
+		written := map[string]struct{}{}
+		buf.WriteByte('{')
+		for _, objectField := range r.fields(r.operation.Fields[field].SelectionSet, typeName) {
+			responseKey := r.operation.FieldAliasOrNameString(objectField)
+			if _, ok := written[responseKey]; ok {
+				continue
+			}
+			if len(written) != 0 {
+				buf.WriteByte(',')
+			}
+			written[responseKey] = struct{}{}
+
+			writeKey(buf, responseKey)
+			if err = r.writeValue(buf, v[r.operation.FieldNameString(objectField)], objectField); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte('}')
+		return nil
+	}
+
+	out, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	buf.Write(out)
+	return nil
+}
+
+func writeKey(buf *bytes.Buffer, key string) {
+	buf.WriteByte('"')
+	buf.WriteString(key)
+	buf.WriteString(`":`)
+}
+
+// normalize converts a value to its generic JSON representation, so structs are handled like maps
+func normalize(value interface{}) (interface{}, error) {
+	switch value.(type) {
+	case nil, map[string]interface{}, []interface{}, string, bool, json.Number:
+		return value, nil
+	}
+	out, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(out))
+	decoder.UseNumber()
+	var normalized interface{}
+	err = decoder.Decode(&normalized)
+	return normalized, err
+}
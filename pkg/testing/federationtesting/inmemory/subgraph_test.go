@@ -0,0 +1,338 @@
+package inmemory
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"math"
+	"testing"
+
+	"github.com/jensneuse/abstractlogger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	graphqlDataSource "github.com/wundergraph/graphql-go-tools/pkg/engine/datasource/graphql_datasource"
+	"github.com/wundergraph/graphql-go-tools/pkg/engine/resolve"
+	"github.com/wundergraph/graphql-go-tools/pkg/graphql"
+	"github.com/wundergraph/graphql-go-tools/pkg/testing/federationtesting"
+)
+
+type user struct {
+	ID       string `json:"id"`
+	Username string `json:"username"`
+}
+
+var (
+	me = user{ID: "1234", Username: "Me"}
+
+	reviews = map[string][]map[string]interface{}{
+		"1234": {
+			{"body": "A highly effective form of birth control.", "product": map[string]interface{}{"upc": "top-1"}},
+			{"body": "Fedoras are one of the most fashionable hats around and can look great with a variety of outfits.", "product": map[string]interface{}{"upc": "top-2"}},
+		},
+	}
+
+	products = map[string]map[string]interface{}{
+		"top-1": {"upc": "top-1", "name": "Trilby", "price": 11},
+		"top-2": {"upc": "top-2", "name": "Fedora", "price": 22},
+	}
+)
+
+func accountsSubgraph() *Subgraph {
+	return &Subgraph{
+		RootFields: map[string]FieldResolver{
+			"me": func(ctx context.Context, args map[string]json.RawMessage) (interface{}, error) {
+				return me, nil
+			},
+		},
+	}
+}
+
+func reviewsSubgraph() *Subgraph {
+	return &Subgraph{
+		Entities: map[string]EntityResolver{
+			"User": func(ctx context.Context, representation map[string]interface{}) (interface{}, error) {
+				id, _ := representation["id"].(string)
+				return map[string]interface{}{"id": id, "reviews": reviews[id]}, nil
+			},
+		},
+	}
+}
+
+func productsSubgraph() *Subgraph {
+	return &Subgraph{
+		Entities: map[string]EntityResolver{
+			"Product": func(ctx context.Context, representation map[string]interface{}) (interface{}, error) {
+				upc, _ := representation["upc"].(string)
+				product, ok := products[upc]
+				if !ok {
+					return nil, nil
+				}
+				return product, nil
+			},
+		},
+	}
+}
+
+func TestSubgraph_Load(t *testing.T) {
+	load := func(t *testing.T, subgraph *Subgraph, body string) string {
+		out := &bytes.Buffer{}
+		err := subgraph.Load(context.Background(), []byte(`{"method":"POST","url":"http://accounts","body":`+body+`}`), out)
+		require.NoError(t, err)
+		return out.String()
+	}
+
+	t.Run("root field", func(t *testing.T) {
+		response := load(t, accountsSubgraph(), `{"query":"{me {id username}}"}`)
+		assert.Equal(t, `{"data":{"me":{"id":"1234","username":"Me"}}}`, response)
+	})
+
+	t.Run("root field with alias", func(t *testing.T) {
+		response := load(t, accountsSubgraph(), `{"query":"{self: me {name: username}}"}`)
+		assert.Equal(t, `{"data":{"self":{"name":"Me"}}}`, response)
+	})
+
+	t.Run("unknown root field", func(t *testing.T) {
+		response := load(t, accountsSubgraph(), `{"query":"{topProducts {upc}}"}`)
+		assert.Equal(t, `{"errors":[{"message":"unknown field: topProducts","path":["topProducts"]}],"data":{"topProducts":null}}`, response)
+	})
+
+	t.Run("entities", func(t *testing.T) {
+		response := load(t, productsSubgraph(), `{"query":"query($representations: [_Any!]!){_entities(representations: $representations){__typename ... on Product {name price}}}","variables":{"representations":[{"__typename":"Product","upc":"top-2"},{"__typename":"Product","upc":"top-1"},{"__typename":"Product","upc":"top-3"}]}}`)
+		assert.Equal(t, `{"data":{"_entities":[{"__typename":"Product","name":"Fedora","price":22},{"__typename":"Product","name":"Trilby","price":11},null]}}`, response)
+	})
+
+	t.Run("entities of unknown type", func(t *testing.T) {
+		response := load(t, productsSubgraph(), `{"query":"query($representations: [_Any!]!){_entities(representations: $representations){__typename ... on User {username}}}","variables":{"representations":[{"__typename":"User","id":"1234"}]}}`)
+		assert.Equal(t, `{"errors":[{"message":"unknown entity type: User","path":["_entities",0]}],"data":{"_entities":[null]}}`, response)
+	})
+}
+
+func TestSubgraph_Federation(t *testing.T) {
+	const (
+		accountsURL = "http://accounts.service"
+		productsURL = "http://products.service"
+		reviewsURL  = "http://reviews.service"
+	)
+
+	serviceSDL := func(t *testing.T, upstream federationtesting.Upstream) string {
+		sdl, err := federationtesting.LoadSDLFromExamplesDirectoryWithinPkg(upstream)
+		require.NoError(t, err)
+		return string(sdl)
+	}
+	dataSourceConfig := func(url, sdl string) graphqlDataSource.Configuration {
+		return graphqlDataSource.Configuration{
+			Fetch: graphqlDataSource.FetchConfiguration{URL: url},
+			Federation: graphqlDataSource.FederationConfiguration{
+				Enabled:    true,
+				ServiceSDL: sdl,
+			},
+		}
+	}
+
+	factory := graphql.NewFederationEngineConfigFactory(
+		[]graphqlDataSource.Configuration{
+			dataSourceConfig(accountsURL, serviceSDL(t, federationtesting.UpstreamAccounts)),
+			dataSourceConfig(productsURL, serviceSDL(t, federationtesting.UpstreamProducts)),
+			dataSourceConfig(reviewsURL, serviceSDL(t, federationtesting.UpstreamReviews)),
+		},
+		graphqlDataSource.NewBatchFactory(),
+		graphql.WithFederationDataSourceLoaders(map[string]resolve.DataSource{
+			accountsURL: accountsSubgraph(),
+			productsURL: productsSubgraph(),
+			reviewsURL:  reviewsSubgraph(),
+		}),
+	)
+	engineConf, err := factory.EngineV2Configuration()
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	engine, err := graphql.NewExecutionEngineV2(ctx, abstractlogger.Noop{}, engineConf)
+	require.NoError(t, err)
+
+	execute := func(t *testing.T, query string) string {
+		operation := graphql.Request{Query: query}
+		resultWriter := graphql.NewEngineResultWriter()
+		err := engine.Execute(context.Background(), &operation, &resultWriter)
+		require.NoError(t, err)
+		return resultWriter.String()
+	}
+
+	t.Run("query of a single subgraph", func(t *testing.T) {
+		response := execute(t, `{ me { id username } }`)
+		assert.Equal(t, `{"data":{"me":{"id":"1234","username":"Me"}}}`, response)
+	})
+
+	t.Run("query resolving entities", func(t *testing.T) {
+		response := execute(t, federationtesting.QueryReviewsOfMe)
+		assert.Equal(t, `{"data":{"me":{"reviews":[{"body":"A highly effective form of birth control.","product":{"upc":"top-1","name":"Trilby","price":11}},{"body":"Fedoras are one of the most fashionable hats around and can look great with a variety of outfits.","product":{"upc":"top-2","name":"Fedora","price":22}}]}}}`, response)
+	})
+}
+
+func TestSubgraph_LargeNumbers(t *testing.T) {
+	const (
+		billingURL  = "http://billing.service"
+		paymentsURL = "http://payments.service"
+	)
+
+	billing := &Subgraph{
+		RootFields: map[string]FieldResolver{
+			"invoice": func(ctx context.Context, args map[string]json.RawMessage) (interface{}, error) {
+				return map[string]interface{}{"id": int64(math.MaxInt64), "amount": json.Number("12345678901234567890.123456789")}, nil
+			},
+		},
+	}
+	payments := &Subgraph{
+		Entities: map[string]EntityResolver{
+			"Invoice": func(ctx context.Context, representation map[string]interface{}) (interface{}, error) {
+				id, _ := representation["id"].(json.Number)
+				return map[string]interface{}{"paid": id.String() == "9223372036854775807"}, nil
+			},
+		},
+	}
+
+	factory := graphql.NewFederationEngineConfigFactory(
+		[]graphqlDataSource.Configuration{
+			{
+				Fetch: graphqlDataSource.FetchConfiguration{URL: billingURL},
+				Federation: graphqlDataSource.FederationConfiguration{
+					Enabled:    true,
+					ServiceSDL: `extend type Query { invoice: Invoice } type Invoice @key(fields: "id") { id: Int! amount: Float! }`,
+				},
+			},
+			{
+				Fetch: graphqlDataSource.FetchConfiguration{URL: paymentsURL},
+				Federation: graphqlDataSource.FederationConfiguration{
+					Enabled:    true,
+					ServiceSDL: `extend type Invoice @key(fields: "id") { id: Int! @external paid: Boolean! }`,
+				},
+			},
+		},
+		graphqlDataSource.NewBatchFactory(),
+		graphql.WithFederationDataSourceLoaders(map[string]resolve.DataSource{
+			billingURL:  billing,
+			paymentsURL: payments,
+		}),
+	)
+	engineConf, err := factory.EngineV2Configuration()
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	engine, err := graphql.NewExecutionEngineV2(ctx, abstractlogger.Noop{}, engineConf)
+	require.NoError(t, err)
+
+	execute := func(t *testing.T, query string) string {
+		operation := graphql.Request{Query: query}
+		resultWriter := graphql.NewEngineResultWriter()
+		err := engine.Execute(context.Background(), &operation, &resultWriter)
+		require.NoError(t, err)
+		return resultWriter.String()
+	}
+
+	t.Run("numbers of a subgraph response", func(t *testing.T) {
+		response := execute(t, `{ invoice { id amount } }`)
+		assert.Equal(t, `{"data":{"invoice":{"id":9223372036854775807,"amount":12345678901234567890.123456789}}}`, response)
+	})
+
+	t.Run("numbers of entity representations", func(t *testing.T) {
+		response := execute(t, `{ invoice { id paid } }`)
+		assert.Equal(t, `{"data":{"invoice":{"id":9223372036854775807,"paid":true}}}`, response)
+	})
+}
+
+func TestSubgraph_Override(t *testing.T) {
+	const (
+		productsURL = "http://products.service"
+		pricingURL  = "http://pricing.service"
+	)
+
+	catalog := &Subgraph{
+		RootFields: map[string]FieldResolver{
+			"topProducts": func(ctx context.Context, args map[string]json.RawMessage) (interface{}, error) {
+				return []map[string]interface{}{
+					{"upc": "top-1", "name": "Trilby", "price": 1},
+					{"upc": "top-2", "name": "Fedora", "price": 2},
+				}, nil
+			},
+		},
+	}
+	pricing := &Subgraph{
+		Entities: map[string]EntityResolver{
+			"Product": func(ctx context.Context, representation map[string]interface{}) (interface{}, error) {
+				upc, _ := representation["upc"].(string)
+				return map[string]interface{}{"upc": upc, "price": products[upc]["price"]}, nil
+			},
+		},
+	}
+
+	execute := func(t *testing.T, label string, query string) string {
+		override := `@override(from: "products")`
+		if label != "" {
+			override = `@override(from: "products", label: "` + label + `")`
+		}
+
+		factory := graphql.NewFederationEngineConfigFactory(
+			[]graphqlDataSource.Configuration{
+				{
+					Fetch: graphqlDataSource.FetchConfiguration{URL: productsURL},
+					Federation: graphqlDataSource.FederationConfiguration{
+						Enabled:     true,
+						ServiceName: "products",
+						ServiceSDL:  `extend type Query { topProducts: [Product] } type Product @key(fields: "upc") { upc: String! name: String price: Int }`,
+					},
+				},
+				{
+					Fetch: graphqlDataSource.FetchConfiguration{URL: pricingURL},
+					Federation: graphqlDataSource.FederationConfiguration{
+						Enabled:     true,
+						ServiceName: "pricing",
+						ServiceSDL:  `extend type Product @key(fields: "upc") { upc: String! @external price: Int ` + override + ` }`,
+					},
+				},
+			},
+			graphqlDataSource.NewBatchFactory(),
+			graphql.WithFederationDataSourceLoaders(map[string]resolve.DataSource{
+				productsURL: catalog,
+				pricingURL:  pricing,
+			}),
+		)
+		engineConf, err := factory.EngineV2Configuration()
+		require.NoError(t, err)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		engine, err := graphql.NewExecutionEngineV2(ctx, abstractlogger.Noop{}, engineConf)
+		require.NoError(t, err)
+
+		operation := graphql.Request{Query: query}
+		resultWriter := graphql.NewEngineResultWriter()
+		err = engine.Execute(context.Background(), &operation, &resultWriter)
+		require.NoError(t, err)
+		return resultWriter.String()
+	}
+
+	t.Run("overridden field resolves from the overriding subgraph", func(t *testing.T) {
+		response := execute(t, "", `{ topProducts { upc name price } }`)
+		assert.Equal(t, `{"data":{"topProducts":[{"upc":"top-1","name":"Trilby","price":11},{"upc":"top-2","name":"Fedora","price":22}]}}`, response)
+	})
+
+	t.Run("fields which are not overridden resolve from the overridden subgraph", func(t *testing.T) {
+		response := execute(t, "", `{ topProducts { upc name } }`)
+		assert.Equal(t, `{"data":{"topProducts":[{"upc":"top-1","name":"Trilby"},{"upc":"top-2","name":"Fedora"}]}}`, response)
+	})
+
+	t.Run("label enabled for all operations", func(t *testing.T) {
+		response := execute(t, "percent(100)", `{ topProducts { name price } }`)
+		assert.Equal(t, `{"data":{"topProducts":[{"name":"Trilby","price":11},{"name":"Fedora","price":22}]}}`, response)
+	})
+
+	t.Run("label enabled for no operation", func(t *testing.T) {
+		response := execute(t, "percent(0)", `{ topProducts { name price } }`)
+		assert.Equal(t, `{"data":{"topProducts":[{"name":"Trilby","price":1},{"name":"Fedora","price":2}]}}`, response)
+	})
+}
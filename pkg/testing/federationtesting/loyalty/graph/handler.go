@@ -0,0 +1,37 @@
+// Package graph implements the loyalty subgraph, which extends the User entity of the accounts subgraph by the
+// loyalty points of the user. It's resolved from in-memory data, see inmemory.Subgraph.
+package graph
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"net/http"
+
+	"github.com/wundergraph/graphql-go-tools/pkg/testing/federationtesting/inmemory"
+)
+
+//go:embed schema.graphqls
+var schema string
+
+// points are the loyalty points of the users, keyed by their id
+var points = map[string]int{
+	"1234": 120,
+	"7777": 30,
+}
+
+func GraphQLEndpointHandler() http.Handler {
+	return &inmemory.Subgraph{
+		RootFields: map[string]inmemory.FieldResolver{
+			"_service": func(ctx context.Context, args map[string]json.RawMessage) (interface{}, error) {
+				return map[string]interface{}{"sdl": schema}, nil
+			},
+		},
+		Entities: map[string]inmemory.EntityResolver{
+			"User": func(ctx context.Context, representation map[string]interface{}) (interface{}, error) {
+				id, _ := representation["id"].(string)
+				return map[string]interface{}{"id": id, "points": points[id]}, nil
+			},
+		},
+	}
+}
@@ -0,0 +1,11 @@
+package loyalty
+
+import (
+	"net/http"
+
+	"github.com/wundergraph/graphql-go-tools/pkg/testing/federationtesting/loyalty/graph"
+)
+
+func Handler() http.Handler {
+	return graph.GraphQLEndpointHandler()
+}
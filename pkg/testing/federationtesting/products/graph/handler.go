@@ -18,10 +18,16 @@ import (
 
 var websocketConnections atomic.Uint32
 
+// websocketInitPayload is the payload of the last connection_init message
+var websocketInitPayload atomic.Value
+
 type EndpointOptions struct {
 	EnableDebug            bool
 	EnableRandomness       bool
 	OverrideUpdateInterval time.Duration
+	// PublishAllPriceUpdates publishes the price updates of all products to every updateProductPrice subscription,
+	// regardless of its upc, like a subgraph which leaves filtering the events to the gateway
+	PublishAllPriceUpdates bool
 }
 
 var TestOptions = EndpointOptions{
@@ -32,6 +38,7 @@ var TestOptions = EndpointOptions{
 
 func GraphQLEndpointHandler(opts EndpointOptions) http.Handler {
 	websocketConnections.Store(0)
+	websocketInitPayload.Store(transport.InitPayload(nil))
 	srv := handler.New(generated.NewExecutableSchema(generated.Config{Resolvers: &Resolver{}}))
 
 	srv.AddTransport(transport.POST{})
@@ -42,7 +49,8 @@ func GraphQLEndpointHandler(opts EndpointOptions) http.Handler {
 				return true
 			},
 		},
-		InitFunc: func(ctx context.Context, _ transport.InitPayload) (context.Context, error) {
+		InitFunc: func(ctx context.Context, initPayload transport.InitPayload) (context.Context, error) {
+			websocketInitPayload.Store(initPayload)
 			websocketConnections.Inc()
 			go func(ctx context.Context) {
 				<-ctx.Done()
@@ -58,6 +66,7 @@ func GraphQLEndpointHandler(opts EndpointOptions) http.Handler {
 	}
 
 	randomnessEnabled = opts.EnableRandomness
+	publishAllPriceUpdates = opts.PublishAllPriceUpdates
 
 	if opts.OverrideUpdateInterval > 0 {
 		updateInterval = opts.OverrideUpdateInterval
@@ -66,6 +75,11 @@ func GraphQLEndpointHandler(opts EndpointOptions) http.Handler {
 	return srv
 }
 
+// WebsocketInitPayload returns the payload of the last connection_init message received by the endpoint
+func WebsocketInitPayload() transport.InitPayload {
+	return websocketInitPayload.Load().(transport.InitPayload)
+}
+
 func WebsocketConnectionsHandler(w http.ResponseWriter, r *http.Request) {
 	response := map[string]uint32{
 		"websocket_connections": websocketConnections.Load(),
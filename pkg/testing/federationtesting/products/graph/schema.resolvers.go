@@ -70,8 +70,12 @@ func (r *subscriptionResolver) UpdateProductPrice(ctx context.Context, upc strin
 			case <-ctx.Done():
 				return
 			case <-time.After(100 * time.Millisecond):
-				product.Price = num
-				updatedPrice <- product
+				published := product
+				if publishAllPriceUpdates {
+					published = hats[(num-1)%len(hats)]
+				}
+				published.Price = num
+				updatedPrice <- published
 			}
 		}
 	}()
@@ -5,9 +5,10 @@ import (
 )
 
 var (
-	randomnessEnabled = true
-	minPrice          = 10
-	maxPrice          = 1499
-	currentPrice      = minPrice
-	updateInterval    = time.Second
+	randomnessEnabled      = true
+	publishAllPriceUpdates = false
+	minPrice               = 10
+	maxPrice               = 1499
+	currentPrice           = minPrice
+	updateInterval         = time.Second
 )